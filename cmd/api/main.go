@@ -12,6 +12,8 @@ import (
 	"project-bulky-be/internal/config"
 	"project-bulky-be/internal/controllers"
 	"project-bulky-be/internal/middleware"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/orderstate"
 	"project-bulky-be/internal/repositories"
 	"project-bulky-be/internal/routes"
 	"project-bulky-be/internal/services"
@@ -30,7 +32,7 @@ func main() {
 
 	cfg := config.LoadConfig()
 
-	// Initialize JWT config with 24 hour access token (single token)
+	// Initialize JWT config (access token pendek, diperpanjang lewat refresh token)
 	utils.SetJWTConfig(cfg.JWTSecret, cfg.JWTAccessDuration)
 
 	// Initialize custom validators
@@ -57,13 +59,20 @@ func main() {
 	produkGambarRepo := repositories.NewProdukGambarRepository(db)
 	produkDokumenRepo := repositories.NewProdukDokumenRepository(db)
 	adminRepo := repositories.NewAdminRepository(db)
-	adminSessionRepo := repositories.NewAdminSessionRepository(db)
+	authSessionRepo := repositories.NewAuthSessionRepository(db)
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(db)
+	loginThrottleRepo := repositories.NewLoginThrottleRepository(db)
+	passwordResetRepo := repositories.NewPasswordResetTokenRepository(db)
 	buyerRepo := repositories.NewBuyerRepository(db)
 	alamatBuyerRepo := repositories.NewAlamatBuyerRepository(db)
 	heroSectionRepo := repositories.NewHeroSectionRepository(db)
 	bannerEventPromoRepo := repositories.NewBannerEventPromoRepository(db)
 	pesananRepo := repositories.NewPesananRepository(db)
+	pesananPengirimanRepo := repositories.NewPesananPengirimanRepository(db)
 	pesananItemRepo := repositories.NewPesananItemRepository(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+	jobRepo := repositories.NewJobRepository(db)
+	idempotencyKeyRepo := repositories.NewIdempotencyKeyRepository(db)
 	ulasanRepo := repositories.NewUlasanRepository(db)
 	forceUpdateRepo := repositories.NewForceUpdateRepository(db)
 	modeMaintenanceRepo := repositories.NewModeMaintenanceRepository(db)
@@ -76,6 +85,12 @@ func main() {
 	whatsappHandlerRepo := repositories.NewWhatsAppHandlerRepository(db)
 	faqRepo := repositories.NewFAQRepository(db)
 	jadwalGudangRepo := repositories.NewJadwalGudangRepository(db)
+	jadwalGudangPengecualianRepo := repositories.NewJadwalGudangPengecualianRepository(db)
+	pickupAppointmentRepo := repositories.NewPickupAppointmentRepository(db)
+	pesananRefundRepo := repositories.NewPesananRefundRepository(db)
+	pesananDokumenRepo := repositories.NewPesananDokumenRepository(db)
+	efakturRepo := repositories.NewEFakturRepository(db)
+	pembayaranManualRepo := repositories.NewPembayaranManualRepository(db)
 	blogRepo := repositories.NewBlogRepository(db)
 	kategoriBlogRepo := repositories.NewKategoriBlogRepository(db)
 	labelBlogRepo := repositories.NewLabelBlogRepository(db)
@@ -86,6 +101,11 @@ func main() {
 	disclaimerConsentRepo := repositories.NewBuyerDisclaimerConsentRepository(db)
 	delivereeVehicleTypeRepo := repositories.NewDelivereeVehicleTypeRepository(db)
 	forwarderMappingRepo := repositories.NewForwarderMappingRepository(db)
+	shippingCoverageZoneRepo := repositories.NewShippingCoverageZoneRepository(db)
+	shippingQuoteRepo := repositories.NewShippingQuoteRepository(db)
+	pesananPengirimanEventRepo := repositories.NewPesananPengirimanEventRepository(db)
+	providerWebhookLogRepo := repositories.NewProviderWebhookLogRepository(db)
+	pesananBuktiPengirimanRepo := repositories.NewPesananBuktiPengirimanRepository(db)
 
 	// Auth V2 repositories
 	authRepo := repositories.NewAuthRepository(db)
@@ -93,6 +113,19 @@ func main() {
 	roleRepo := repositories.NewRoleRepository(db)
 	permissionRepo := repositories.NewPermissionRepository(db)
 
+	// Session server-side: setiap token terikat ke auth_session (claim sid)
+	// agar logout / nonaktif akun / ganti password langsung mencabut akses.
+	sessionService := services.NewSessionService(authSessionRepo, authRepo)
+	middleware.SetSessionValidator(sessionService)
+
+	// Permission admin di-resolve live dari tabel role/permission (JWT hanya
+	// membawa identitas), di-invalidate oleh RoleService & AdminService.
+	permissionCache := services.NewPermissionCache(authRepo)
+	middleware.SetPermissionResolver(permissionCache)
+
+	// Proteksi brute-force login (backoff + lockout per akun & IP)
+	loginThrottleService := services.NewLoginThrottleService(loginThrottleRepo)
+
 	// Initialize services
 	reorderService := services.NewReorderService(db)
 	kategoriService := services.NewKategoriProdukService(kategoriRepo, cfg)
@@ -107,10 +140,10 @@ func main() {
 	produkGambarService := services.NewProdukGambarService(produkGambarRepo, cfg)
 	produkDokumenService := services.NewProdukDokumenService(produkDokumenRepo, cfg)
 	produkService := services.NewProdukService(produkRepo, produkGambarRepo, produkDokumenRepo, warehouseRepo, tipeProdukRepo, cfg, db)
-	authService := services.NewAuthService(adminRepo, adminSessionRepo)
-	adminService := services.NewAdminService(adminRepo, adminSessionRepo, roleRepo)
+	authService := services.NewAuthService(adminRepo, sessionService)
+	adminService := services.NewAdminService(adminRepo, sessionService, permissionCache, loginThrottleService, roleRepo)
 	masterService := services.NewMasterService(kategoriRepo, merekRepo, kondisiRepo, kondisiPaketRepo, sumberRepo)
-	buyerService := services.NewBuyerService(buyerRepo, alamatBuyerRepo)
+	buyerService := services.NewBuyerService(buyerRepo, alamatBuyerRepo, sessionService)
 	alamatBuyerService := services.NewAlamatBuyerService(alamatBuyerRepo, buyerRepo)
 	heroSectionService := services.NewHeroSectionService(heroSectionRepo, cfg)
 	bannerEventPromoService := services.NewBannerEventPromoService(bannerEventPromoRepo, reorderService, kategoriService, cfg)
@@ -120,8 +153,22 @@ func main() {
 	delivereeVehicleTypeService := services.NewDelivereeVehicleTypeService(delivereeVehicleTypeRepo, warehouseRepo, activityLogService)
 	forwarderMappingService := services.NewForwarderMappingService(forwarderMappingRepo)
 	wmsService := services.NewWMSService(cfg.WMSBaseURL, cfg.WMSClientID, cfg.WMSClientSecret)
-	shippingService := services.NewShippingService(db, delivereeVehicleTypeService)
-	pesananAdminService := services.NewPesananAdminService(pesananRepo, shippingService, db, cfg)
+	jobQueueService := services.NewJobQueueService(jobRepo)
+	shippingCoverageService := services.NewShippingCoverageService(shippingCoverageZoneRepo, forwarderMappingRepo, alamatBuyerRepo)
+	shippingService := services.NewShippingService(db, pesananPengirimanRepo, pesananPengirimanEventRepo, pesananBuktiPengirimanRepo, shippingCoverageService, shippingQuoteRepo, delivereeVehicleTypeService, jobQueueService, cfg)
+	outboxService := services.NewOutboxService(outboxRepo)
+	idempotencyService := services.NewIdempotencyService(idempotencyKeyRepo, cfg.IdempotencyKeyTTL)
+	middleware.SetIdempotencyStore(idempotencyService)
+	orderMachine := orderstate.NewMachine(db)
+	pesananAdminService := services.NewPesananAdminService(pesananRepo, shippingService, orderMachine, outboxService, jobQueueService, db, cfg)
+	pickupAppointmentService := services.NewPickupAppointmentService(db, pickupAppointmentRepo, jadwalGudangPengecualianRepo, jadwalGudangRepo, warehouseRepo, orderMachine)
+	refundGateway := services.NewPaymentRefundGateway(cfg.PaymentGatewayMode, cfg.XenditBaseURL, cfg.XenditSecretKey)
+	refundService := services.NewRefundService(db, pesananRefundRepo, refundGateway, orderMachine)
+	pesananDokumenService := services.NewPesananDokumenService(db, pesananDokumenRepo, pesananBuktiPengirimanRepo, orderMachine, jobQueueService, cfg)
+	efakturService := services.NewEFakturService(efakturRepo, cfg)
+	paymentLinkGateway := services.NewPaymentLinkGateway(cfg.PaymentGatewayMode, cfg.XenditBaseURL, cfg.XenditSecretKey)
+	pesananPanelService := services.NewPesananPanelService(db, produkRepo, ppnRepo, paymentLinkGateway, jobQueueService)
+	pembayaranManualService := services.NewPembayaranManualService(db, pembayaranManualRepo, orderMachine, jobQueueService, cfg)
 	forceUpdateService := services.NewForceUpdateService(forceUpdateRepo)
 	modeMaintenanceService := services.NewModeMaintenanceService(modeMaintenanceRepo)
 	ppnService := services.NewPPNService(ppnRepo)
@@ -130,27 +177,48 @@ func main() {
 	disclaimerService := services.NewDisclaimerService(disclaimerRepo)
 	disclaimerConsentService := services.NewBuyerDisclaimerConsentService(disclaimerConsentRepo)
 	emailService := services.NewEmailService()
+	outboxService.RegisterHandler(models.OutboxEventEmail, services.EmailOutboxHandler(emailService))
 	formulirPartaiBesarService := services.NewFormulirPartaiBesarService(formulirPartaiBesarRepo, kategoriRepo, reorderService, emailService)
 	whatsappHandlerService := services.NewWhatsAppHandlerService(whatsappHandlerRepo)
 	faqService := services.NewFAQService(faqRepo, reorderService)
 	blogService := services.NewBlogService(blogRepo, kategoriBlogRepo, labelBlogRepo, cfg)
 	kategoriBlogService := services.NewKategoriBlogService(kategoriBlogRepo)
 	labelBlogService := services.NewLabelBlogService(labelBlogRepo)
-	videoService := services.NewVideoService(videoRepo, kategoriVideoRepo, cfg)
+	videoService := services.NewVideoService(videoRepo, kategoriVideoRepo, jobQueueService, cfg)
 	kategoriVideoService := services.NewKategoriVideoService(kategoriVideoRepo)
-
-	// Recovery: kembalikan video yang stuck di status 'processing' ke 'failed' saat startup
-	videoService.RecoverStuckJobs(context.Background())
 	kuponService := services.NewKuponService(kuponRepo, kategoriRepo, db)
 	dasborService := services.NewDasborService(dasborRepo)
 
 	// Auto-archive produk yang sudah terjual (is_sold=true) lebih dari 1 hari,
 	// dihitung sejak order-nya mencapai status SHIPPED atau COMPLETED.
+	// Dijalankan sebagai job cron setiap awal jam.
 	produkAutoArchiveService := services.NewProdukAutoArchiveService(produkRepo, activityLogRepo, 24*time.Hour)
+	jobQueueService.RegisterHandler(models.JobTypeProdukAutoArchive, services.ProdukAutoArchiveJobHandler(produkAutoArchiveService), services.JobHandlerOptions{Timeout: 10 * time.Minute, MaxAttempts: 1})
+	if err := jobQueueService.RegisterCron("produk-auto-archive", "0 * * * *", models.JobTypeProdukAutoArchive); err != nil {
+		log.Fatalf("Failed to register cron job: %v", err)
+	}
+
+	// Hapus quote ongkir kedaluwarsa yang tidak dipakai pesanan, setiap hari 02:30.
+	jobQueueService.RegisterHandler(models.JobTypeShippingQuotePurge, services.ShippingQuotePurgeJobHandler(shippingService), services.JobHandlerOptions{MaxAttempts: 1})
+	if err := jobQueueService.RegisterCron("shipping-quote-purge", "30 2 * * *", models.JobTypeShippingQuotePurge); err != nil {
+		log.Fatalf("Failed to register cron job: %v", err)
+	}
+
+	// Poll tracking Forwarder (tidak selalu mengirim webhook), setiap 30 menit.
+	jobQueueService.RegisterHandler(models.JobTypeShippingTrackingPoll, services.ShippingTrackingPollJobHandler(shippingService), services.JobHandlerOptions{MaxAttempts: 1, Timeout: 10 * time.Minute})
+	if err := jobQueueService.RegisterCron("shipping-tracking-poll", "*/30 * * * *", models.JobTypeShippingTrackingPoll); err != nil {
+		log.Fatalf("Failed to register cron job: %v", err)
+	}
+
+	// Cek hasil refund yang masih diproses payment gateway, setiap 30 menit.
+	jobQueueService.RegisterHandler(models.JobTypePaymentRefundSync, services.RefundSyncJobHandler(refundService), services.JobHandlerOptions{MaxAttempts: 1, Timeout: 10 * time.Minute})
+	if err := jobQueueService.RegisterCron("payment-refund-sync", "15,45 * * * *", models.JobTypePaymentRefundSync); err != nil {
+		log.Fatalf("Failed to register cron job: %v", err)
+	}
 
 	// Auth V2 services
-	authV2Service := services.NewAuthV2Service(authRepo, activityLogRepo)
-	roleService := services.NewRoleService(roleRepo)
+	authV2Service := services.NewAuthV2Service(authRepo, activityLogRepo, refreshTokenRepo, sessionService, loginThrottleService, permissionCache, passwordResetRepo, emailService)
+	roleService := services.NewRoleService(roleRepo, permissionCache)
 	permissionService := services.NewPermissionService(permissionRepo)
 
 	// Initialize controllers
@@ -193,13 +261,29 @@ func main() {
 	dasborController := controllers.NewDasborController(dasborService)
 	internalUploadController := controllers.NewInternalUploadController(cfg)
 	assetMigrationController := controllers.NewAssetMigrationController(db, cfg)
-	delivereeWebhookService := services.NewDelivereeWebhookService(pesananRepo, db)
-	delivereeWebhookController := controllers.NewDelivereeWebhookController(delivereeWebhookService, cfg.DelivereeWebhookAuthorization)
-	forwarderWebhookService := services.NewForwarderWebhookService(pesananRepo, db)
-	forwarderWebhookController := controllers.NewForwarderWebhookController(forwarderWebhookService, cfg.ForwarderWebhookAuthorization)
+	delivereeWebhookService := services.NewDelivereeWebhookService(pesananRepo, pesananPengirimanRepo, pesananPengirimanEventRepo, orderMachine, db)
+	forwarderWebhookService := services.NewForwarderWebhookService(pesananRepo, pesananPengirimanRepo, pesananPengirimanEventRepo, orderMachine, db)
+	providerWebhookLogService := services.NewProviderWebhookLogService(providerWebhookLogRepo, shippingService, delivereeWebhookService, forwarderWebhookService, cfg)
+	delivereeWebhookController := controllers.NewDelivereeWebhookController(providerWebhookLogService)
+	forwarderWebhookController := controllers.NewForwarderWebhookController(providerWebhookLogService)
+	webhookLogController := controllers.NewWebhookLogController(providerWebhookLogService, activityLogService)
+	if cfg.ShippingProviderMode == services.ShippingModeSandbox {
+		// Webhook simulasi provider sandbox diproses handler webhook yang asli
+		jobQueueService.RegisterHandler(models.JobTypeShippingSandboxWebhook, services.SandboxWebhookJobHandler(shippingService, delivereeWebhookService, forwarderWebhookService), services.JobHandlerOptions{})
+	}
 	delivereeVehicleTypeController := controllers.NewDelivereeVehicleTypeController(delivereeVehicleTypeService, activityLogService)
 	forwarderMappingController := controllers.NewForwarderMappingController(forwarderMappingService, activityLogService)
+	shippingCoverageController := controllers.NewShippingCoverageController(shippingCoverageService, activityLogService)
+	shippingQuoteController := controllers.NewShippingQuoteController(shippingService)
 	wmsController := controllers.NewWMSController(wmsService)
+	outboxController := controllers.NewOutboxController(outboxService, activityLogService)
+	jobController := controllers.NewJobController(jobQueueService, activityLogService)
+	pickupAppointmentController := controllers.NewPickupAppointmentController(pickupAppointmentService, activityLogService)
+	refundController := controllers.NewRefundController(refundService, activityLogService)
+	pesananDokumenController := controllers.NewPesananDokumenController(pesananDokumenService, activityLogService)
+	efakturController := controllers.NewEFakturController(efakturService, activityLogService)
+	pesananPanelController := controllers.NewPesananPanelController(pesananPanelService, activityLogService)
+	pembayaranManualController := controllers.NewPembayaranManualController(pembayaranManualService, activityLogService)
 
 	// Auth V2 controllers
 	authV2Controller := controllers.NewAuthV2Controller(authV2Service, adminService, buyerService)
@@ -245,7 +329,18 @@ func main() {
 		forwarderWebhookController,
 		delivereeVehicleTypeController,
 		forwarderMappingController,
+		shippingCoverageController,
+		shippingQuoteController,
 		wmsController,
+		outboxController,
+		jobController,
+		webhookLogController,
+		pickupAppointmentController,
+		refundController,
+		pesananDokumenController,
+		efakturController,
+		pesananPanelController,
+		pembayaranManualController,
 	)
 
 	// Setup Auth V2 routes (new authentication system with roles & permissions)
@@ -272,9 +367,26 @@ func main() {
 		}
 	}()
 
-	// Jalankan scheduler auto-archive produk terjual setiap 1 jam sampai server shutdown.
-	autoArchiveCtx, stopAutoArchive := context.WithCancel(context.Background())
-	go produkAutoArchiveService.StartScheduler(autoArchiveCtx, 1*time.Hour)
+	// Scheduler berjalan sampai server shutdown.
+	schedulerCtx, stopSchedulers := context.WithCancel(context.Background())
+
+	// Worker job queue (booking pengiriman, transcode video, job cron).
+	// Bisa dimatikan di instance yang hanya melayani HTTP.
+	if cfg.JobWorkerEnabled {
+		go jobQueueService.StartWorkers(schedulerCtx)
+	}
+
+	// Bersihkan session login yang sudah expired setiap 6 jam.
+	go sessionService.StartCleanupScheduler(schedulerCtx, 6*time.Hour)
+
+	// Bersihkan counter login gagal yang sudah tidak aktif setiap 1 jam.
+	go loginThrottleService.StartCleanupScheduler(schedulerCtx, 1*time.Hour)
+
+	// Kirim pesan outbox (notifikasi storefront, email) setiap 10 detik.
+	go outboxService.StartDispatcher(schedulerCtx, 10*time.Second)
+
+	// Hapus Idempotency-Key yang sudah melewati TTL setiap 1 jam.
+	go idempotencyService.StartCleanupScheduler(schedulerCtx, 1*time.Hour)
 
 	// Graceful shutdown: tunggu sinyal SIGTERM/SIGINT, lalu stop server
 	// setelah request yang sedang berjalan (termasuk upload video) selesai
@@ -283,7 +395,7 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server gracefully...")
-	stopAutoArchive()
+	stopSchedulers()
 	if err := router.ShutdownWithContext(context.Background()); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
@@ -16,6 +16,8 @@ type Config struct {
 	DBName                        string
 	JWTSecret                     string
 	JWTAccessDuration             time.Duration
+	JWTRefreshDuration            time.Duration
+	TwoFactorEncryptionKey        string
 	BcryptCost                    int
 	UploadPath                    string
 	BaseURL                       string
@@ -23,15 +25,42 @@ type Config struct {
 	WMSAPIKey                     string
 	DelivereeWebhookAuthorization string
 	ForwarderWebhookAuthorization string
+	DelivereeWebhookSecret        string
+	ForwarderWebhookSecret        string
+	WebhookTimestampTolerance     time.Duration
 	WMSBaseURL                    string
 	WMSClientID                   string
 	WMSClientSecret               string
 	StorefrontBaseURL             string
+	PanelBaseURL                  string
+	PasswordResetDuration         time.Duration
+	JobWorkerEnabled              bool
+	IdempotencyKeyTTL             time.Duration
+	DelivereeBaseURL              string
+	DelivereeAPIKey               string
+	ForwarderAPIURL               string
+	ForwarderClientName           string
+	ForwarderUsername             string
+	ForwarderPassword             string
+	ShippingProviderMode          string
+	ShippingSandboxStep           time.Duration
+	ShippingQuoteTTL              time.Duration
+	PaymentGatewayMode            string
+	XenditBaseURL                 string
+	XenditSecretKey               string
+	DokumenStoragePath            string
+	InvoicePenjualNama            string
+	InvoicePenjualNPWP            string
+	InvoicePenjualAlamat          string
+	TransferBankNama              string
+	TransferBankNomorRekening     string
+	TransferBankAtasNama          string
 }
 
 func LoadConfig() *Config {
-	// Parse JWT access duration (24 hours for single token)
-	accessDuration := parseDuration(getEnv("JWT_ACCESS_EXPIRY", "24h"), 24*time.Hour)
+	// Access token dibuat pendek, diperpanjang lewat refresh token (rotasi)
+	accessDuration := parseDuration(getEnv("JWT_ACCESS_EXPIRY", "15m"), 15*time.Minute)
+	refreshDuration := parseDuration(getEnv("JWT_REFRESH_EXPIRY", "168h"), 7*24*time.Hour)
 
 	// Get bcrypt cost based on environment
 	bcryptCost := getBcryptCost(getEnv("APP_ENV", "development"))
@@ -46,6 +75,8 @@ func LoadConfig() *Config {
 		DBName:                        getEnv("DB_NAME", "bulky_db"),
 		JWTSecret:                     getEnv("JWT_SECRET", "your-secret-key-minimum-32-characters-long"),
 		JWTAccessDuration:             accessDuration,
+		JWTRefreshDuration:            refreshDuration,
+		TwoFactorEncryptionKey:        getEnv("TWO_FACTOR_ENCRYPTION_KEY", getEnv("JWT_SECRET", "your-secret-key-minimum-32-characters-long")),
 		BcryptCost:                    bcryptCost,
 		UploadPath:                    getEnv("UPLOAD_PATH", "./uploads"),
 		BaseURL:                       getEnv("BASE_URL", "http://localhost:8080"),
@@ -53,10 +84,36 @@ func LoadConfig() *Config {
 		WMSAPIKey:                     getEnv("WMS_API_KEY", ""),
 		DelivereeWebhookAuthorization: getEnv("DELIVEREE_WEBHOOK_AUTHORIZATION", ""),
 		ForwarderWebhookAuthorization: getEnv("FORWARDER_WEBHOOK_AUTHORIZATION", ""),
+		DelivereeWebhookSecret:        getEnv("DELIVEREE_WEBHOOK_SECRET", ""),
+		ForwarderWebhookSecret:        getEnv("FORWARDER_WEBHOOK_SECRET", ""),
+		WebhookTimestampTolerance:     parseDuration(getEnv("WEBHOOK_TIMESTAMP_TOLERANCE", "5m"), 5*time.Minute),
 		WMSBaseURL:                    getEnv("WMS_BASE_URL", ""),
 		WMSClientID:                   getEnv("WMS_CLIENT_ID", ""),
 		WMSClientSecret:               getEnv("WMS_CLIENT_SECRET", ""),
 		StorefrontBaseURL:             getEnv("STOREFRONT_BASE_URL", ""),
+		PanelBaseURL:                  getEnv("PANEL_BASE_URL", "http://localhost:3000"),
+		PasswordResetDuration:         parseDuration(getEnv("PASSWORD_RESET_EXPIRY", "30m"), 30*time.Minute),
+		JobWorkerEnabled:              getEnv("JOB_WORKER_ENABLED", "true") != "false",
+		IdempotencyKeyTTL:             parseDuration(getEnv("IDEMPOTENCY_KEY_TTL", "24h"), 24*time.Hour),
+		DelivereeBaseURL:              getEnv("DELIVEREE_BASE_URL", ""),
+		DelivereeAPIKey:               getEnv("DELIVEREE_API_KEY", ""),
+		ForwarderAPIURL:               getEnv("FORWARDER_API_URL", getEnv("FORWARDER_BASE_URL", "")),
+		ForwarderClientName:           getEnv("FORWARDER_CLIENT_NAME", ""),
+		ForwarderUsername:             getEnv("FORWARDER_USERNAME", ""),
+		ForwarderPassword:             getEnv("FORWARDER_PASSWORD", ""),
+		ShippingProviderMode:          getEnv("SHIPPING_PROVIDER_MODE", "live"),
+		ShippingSandboxStep:           parseDuration(getEnv("SHIPPING_SANDBOX_STEP", "1m"), time.Minute),
+		ShippingQuoteTTL:              parseDuration(getEnv("SHIPPING_QUOTE_TTL", "30m"), 30*time.Minute),
+		PaymentGatewayMode:            getEnv("PAYMENT_GATEWAY_MODE", "live"),
+		XenditBaseURL:                 getEnv("XENDIT_BASE_URL", "https://api.xendit.co"),
+		XenditSecretKey:               getEnv("XENDIT_SECRET_KEY", ""),
+		DokumenStoragePath:            getEnv("DOKUMEN_STORAGE_PATH", "./storage/dokumen"),
+		InvoicePenjualNama:            getEnv("INVOICE_PENJUAL_NAMA", "Bulky"),
+		InvoicePenjualNPWP:            getEnv("INVOICE_PENJUAL_NPWP", ""),
+		InvoicePenjualAlamat:          getEnv("INVOICE_PENJUAL_ALAMAT", ""),
+		TransferBankNama:              getEnv("TRANSFER_BANK_NAMA", ""),
+		TransferBankNomorRekening:     getEnv("TRANSFER_BANK_NOMOR_REKENING", ""),
+		TransferBankAtasNama:          getEnv("TRANSFER_BANK_ATAS_NAMA", ""),
 	}
 }
 
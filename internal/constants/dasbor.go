@@ -11,6 +11,11 @@ const (
 	// untuk metrik uang (revenue, total belanja).
 	TransaksiPaidPaymentStatus = "PAID"
 
+	// TransaksiRefundedPaymentStatus adalah pesanan lunas yang seluruh
+	// pembayarannya sudah di-refund. Tetap dihitung di revenue kotor karena
+	// refund-nya dikurangkan terpisah (basis tanggal refund).
+	TransaksiRefundedPaymentStatus = "REFUNDED"
+
 	// TransaksiCompletedOrderStatus adalah status pesanan yang dianggap
 	// "benar-benar diselesaikan" untuk metrik penjualan selesai
 	// (penjualan-per-buyer, user-transaction).
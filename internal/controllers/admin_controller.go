@@ -8,6 +8,7 @@ import (
 	"project-bulky-be/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type AdminController struct {
@@ -138,3 +139,39 @@ func (c *AdminController) ResetPassword(ctx *fiber.Ctx) error {
 	c.activityLog.Log(ctx, models.ActionUpdate, "admin", "Password admin berhasil direset")
 	return utils.SuccessResponse(ctx, "Password admin berhasil direset", nil)
 }
+
+func (c *AdminController) Unlock(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+
+	result, err := c.service.Unlock(ctx.UserContext(), id)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "admin tidak ditemukan" {
+			status = http.StatusNotFound
+		}
+		return utils.ErrorResponse(ctx, status, err.Error(), nil)
+	}
+
+	entityID, _ := uuid.Parse(result.ID)
+	c.activityLog.Log(ctx, models.ActionUnlock, "admin", "Kunci login admin "+result.Email+" berhasil dibuka",
+		services.WithEntity("admin", entityID))
+	return utils.SuccessResponse(ctx, "Kunci login admin berhasil dibuka", result)
+}
+
+func (c *AdminController) ResetTwoFactor(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+
+	result, err := c.service.ResetTwoFactor(ctx.UserContext(), id)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "admin tidak ditemukan" {
+			status = http.StatusNotFound
+		}
+		return utils.ErrorResponse(ctx, status, err.Error(), nil)
+	}
+
+	entityID, _ := uuid.Parse(result.ID)
+	c.activityLog.Log(ctx, models.ActionUpdate, "admin", "2FA admin "+result.Email+" direset",
+		services.WithEntity("admin", entityID))
+	return utils.SuccessResponse(ctx, "2FA admin berhasil direset", result)
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"project-bulky-be/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordWithTokenRequest struct {
+	Token           string `json:"token" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+	ConfirmPassword string `json:"confirm_password" binding:"required"`
+}
+
+// POST /api/panel/auth/forgot-password
+func (c *AuthV2Controller) ForgotPassword(ctx *fiber.Ctx) error {
+	var req ForgotPasswordRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	if err := c.authService.ForgotPassword(ctx.UserContext(), req.Email, ctx.IP(), ctx.Get("User-Agent")); err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal memproses permintaan reset password",
+		})
+	}
+
+	// Pesan sama untuk email terdaftar maupun tidak
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Jika email terdaftar, link reset password telah dikirim ke email tersebut",
+	})
+}
+
+// POST /api/panel/auth/reset-password
+func (c *AuthV2Controller) ResetPasswordWithToken(ctx *fiber.Ctx) error {
+	var req ResetPasswordWithTokenRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	if req.NewPassword != req.ConfirmPassword {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Konfirmasi password tidak cocok",
+		})
+	}
+
+	if err := c.authService.ResetPasswordWithToken(ctx.UserContext(), req.Token, req.NewPassword, ctx.IP(), ctx.Get("User-Agent")); err != nil {
+		if errors.Is(err, services.ErrPasswordResetTokenInvalid) {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal mereset password",
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Password berhasil direset, silakan login dengan password baru",
+	})
+}
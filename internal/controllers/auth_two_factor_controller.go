@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"project-bulky-be/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TwoFactorLoginRequest struct {
+	TwoFactorToken string `json:"two_factor_token" binding:"required"`
+	// Code berisi kode 6 digit dari authenticator atau salah satu recovery code
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// POST /api/panel/auth/login/2fa
+func (c *AuthV2Controller) AdminLoginTwoFactor(ctx *fiber.Ctx) error {
+	var req TwoFactorLoginRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	result, err := c.authService.AdminLoginTwoFactor(ctx.UserContext(), req.TwoFactorToken, req.Code, ctx.IP(), ctx.Get("User-Agent"))
+	if err != nil {
+		var lockedErr *services.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			return loginLockedResponse(ctx, lockedErr)
+		}
+		if errors.Is(err, services.ErrTwoFactorInvalidCode) || errors.Is(err, services.ErrTwoFactorChallengeInvalid) {
+			return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"message": err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal memproses login",
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Login berhasil",
+		"data":    result,
+	})
+}
+
+// POST /api/panel/auth/2fa/setup
+func (c *AuthV2Controller) SetupTwoFactor(ctx *fiber.Ctx) error {
+	adminID, err := uuid.Parse(localsString(ctx, "user_id"))
+	if err != nil {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"message": "User ID tidak valid",
+		})
+	}
+
+	result, err := c.authService.SetupTwoFactor(ctx.UserContext(), adminID)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Scan QR code dengan aplikasi authenticator, lalu konfirmasi dengan kode yang muncul",
+		"data":    result,
+	})
+}
+
+// POST /api/panel/auth/2fa/confirm
+func (c *AuthV2Controller) ConfirmTwoFactor(ctx *fiber.Ctx) error {
+	adminID, err := uuid.Parse(localsString(ctx, "user_id"))
+	if err != nil {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"message": "User ID tidak valid",
+		})
+	}
+
+	var req TwoFactorCodeRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	codes, err := c.authService.ConfirmTwoFactor(ctx.UserContext(), adminID, req.Code, ctx.IP(), ctx.Get("User-Agent"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "2FA berhasil diaktifkan. Simpan recovery code di tempat aman, kode hanya ditampilkan sekali",
+		"data": fiber.Map{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// POST /api/panel/auth/2fa/disable
+func (c *AuthV2Controller) DisableTwoFactor(ctx *fiber.Ctx) error {
+	adminID, err := uuid.Parse(localsString(ctx, "user_id"))
+	if err != nil {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"message": "User ID tidak valid",
+		})
+	}
+
+	var req TwoFactorDisableRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	if err := c.authService.DisableTwoFactor(ctx.UserContext(), adminID, req.Password, req.Code, ctx.IP(), ctx.Get("User-Agent")); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "2FA berhasil dinonaktifkan",
+	})
+}
+
+// POST /api/panel/auth/2fa/recovery-codes
+func (c *AuthV2Controller) RegenerateRecoveryCodes(ctx *fiber.Ctx) error {
+	adminID, err := uuid.Parse(localsString(ctx, "user_id"))
+	if err != nil {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"message": "User ID tidak valid",
+		})
+	}
+
+	var req TwoFactorCodeRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	codes, err := c.authService.RegenerateRecoveryCodes(ctx.UserContext(), adminID, req.Code, ctx.IP(), ctx.Get("User-Agent"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Recovery code berhasil dibuat ulang, recovery code lama tidak berlaku lagi",
+		"data": fiber.Map{
+			"recovery_codes": codes,
+		},
+	})
+}
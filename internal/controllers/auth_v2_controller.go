@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -47,6 +48,10 @@ func (c *AuthV2Controller) AdminLogin(ctx *fiber.Ctx) error {
 
 	result, err := c.authService.AdminLogin(ctx.UserContext(), req.Email, req.Password, ctx.IP(), ctx.Get("User-Agent"))
 	if err != nil {
+		var lockedErr *services.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			return loginLockedResponse(ctx, lockedErr)
+		}
 		// Check error type for proper status code
 		if err.Error() == "akun Anda tidak aktif. Silakan hubungi admin" {
 			return ctx.Status(http.StatusForbidden).JSON(fiber.Map{
@@ -60,9 +65,14 @@ func (c *AuthV2Controller) AdminLogin(ctx *fiber.Ctx) error {
 		})
 	}
 
+	message := "Login berhasil"
+	if result.TwoFactorRequired {
+		message = "Masukkan kode 2FA untuk melanjutkan login"
+	}
+
 	return ctx.Status(http.StatusOK).JSON(fiber.Map{
 		"success": true,
-		"message": "Login berhasil",
+		"message": message,
 		"data":    result,
 	})
 }
@@ -79,6 +89,10 @@ func (c *AuthV2Controller) BuyerLogin(ctx *fiber.Ctx) error {
 
 	result, err := c.authService.BuyerLogin(ctx.UserContext(), req.Email, req.Password, ctx.IP(), ctx.Get("User-Agent"))
 	if err != nil {
+		var lockedErr *services.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			return loginLockedResponse(ctx, lockedErr)
+		}
 		// Check error type for proper status code
 		if err.Error() == "akun Anda tidak aktif. Silakan hubungi admin" {
 			return ctx.Status(http.StatusForbidden).JSON(fiber.Map{
@@ -99,8 +113,69 @@ func (c *AuthV2Controller) BuyerLogin(ctx *fiber.Ctx) error {
 	})
 }
 
+// loginLockedResponse mengembalikan 423 Locked beserta kapan client boleh mencoba lagi
+func loginLockedResponse(ctx *fiber.Ctx, lockedErr *services.LoginLockedError) error {
+	retryAfter := lockedErr.RetryAfterSeconds()
+	ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return ctx.Status(http.StatusLocked).JSON(fiber.Map{
+		"success": false,
+		"message": lockedErr.Error(),
+		"data": fiber.Map{
+			"retry_after": retryAfter,
+			"retry_at":    lockedErr.RetryAt,
+			"locked":      lockedErr.Locked,
+		},
+	})
+}
+
+// POST /api/panel/auth/refresh
+func (c *AuthV2Controller) RefreshToken(ctx *fiber.Ctx) error {
+	var req models.RefreshTokenRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Data tidak valid: " + err.Error(),
+		})
+	}
+
+	result, err := c.authService.RefreshToken(ctx.UserContext(), req.RefreshToken, ctx.IP(), ctx.Get("User-Agent"))
+	if err != nil {
+		if errors.Is(err, services.ErrRefreshTokenInvalid) || errors.Is(err, services.ErrRefreshTokenReused) {
+			return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"message": err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal memperbarui token",
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Token berhasil diperbarui",
+		"data":    result,
+	})
+}
+
 // POST /api/auth/logout
 func (c *AuthV2Controller) Logout(ctx *fiber.Ctx) error {
+	uid, err := uuid.Parse(localsString(ctx, "user_id"))
+	if err != nil {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"message": "Token tidak valid atau sudah expired",
+		})
+	}
+
+	if err := c.authService.Logout(ctx.UserContext(), uid, localsString(ctx, "user_type"), localsString(ctx, "session_id"), ctx.IP(), ctx.Get("User-Agent")); err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal logout",
+		})
+	}
+
 	return ctx.Status(http.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"message": "Logout berhasil",
@@ -375,7 +450,7 @@ func (c *AuthV2Controller) ChangePassword(ctx *fiber.Ctx) error {
 		})
 	}
 
-	err = c.authService.ChangePassword(ctx.UserContext(), uid, userType.(string), req.CurrentPassword, req.NewPassword, ctx.IP(), ctx.Get("User-Agent"))
+	err = c.authService.ChangePassword(ctx.UserContext(), uid, userType.(string), req.CurrentPassword, req.NewPassword, localsString(ctx, "session_id"), ctx.IP(), ctx.Get("User-Agent"))
 	if err != nil {
 		// Check for specific error messages
 		if err.Error() == "password saat ini salah" {
@@ -580,6 +655,9 @@ func (c *RoleController) Create(ctx *fiber.Ctx) error {
 	if req.IsActive != nil {
 		role.IsActive = *req.IsActive
 	}
+	if req.RequireTwoFactor != nil {
+		role.RequireTwoFactor = *req.RequireTwoFactor
+	}
 
 	if err := c.service.Create(role, permissionIDs); err != nil {
 		if strings.Contains(err.Error(), "kode role sudah digunakan") {
@@ -620,6 +698,9 @@ func (c *RoleController) Update(ctx *fiber.Ctx) error {
 	if req.IsActive != nil {
 		existing.IsActive = *req.IsActive
 	}
+	if req.RequireTwoFactor != nil {
+		existing.RequireTwoFactor = *req.RequireTwoFactor
+	}
 
 	// permission_ids tidak dikirim → pertahankan permissions existing
 	var permissionIDs []uuid.UUID
@@ -1,10 +1,12 @@
 package controllers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 
 	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
 	"project-bulky-be/internal/services"
 	"project-bulky-be/pkg/utils"
 
@@ -14,17 +16,15 @@ import (
 // DelivereeWebhookController menerima event webhook dari Deliveree.
 // Endpoint ini dipanggil oleh provider (inbound), bukan oleh admin,
 // sehingga tidak memakai middleware auth admin — verifikasi dilakukan
-// via header Authorization yang dibandingkan dengan
-// DELIVEREE_WEBHOOK_AUTHORIZATION.
+// ProviderWebhookLogService: header Authorization (DELIVEREE_WEBHOOK_AUTHORIZATION)
+// dan/atau signature HMAC (DELIVEREE_WEBHOOK_SECRET).
 type DelivereeWebhookController struct {
-	webhookService services.DelivereeWebhookService
-	authKey        string
+	webhookLogService services.ProviderWebhookLogService
 }
 
-func NewDelivereeWebhookController(webhookService services.DelivereeWebhookService, authKey string) *DelivereeWebhookController {
+func NewDelivereeWebhookController(webhookLogService services.ProviderWebhookLogService) *DelivereeWebhookController {
 	return &DelivereeWebhookController{
-		webhookService: webhookService,
-		authKey:        authKey,
+		webhookLogService: webhookLogService,
 	}
 }
 
@@ -37,44 +37,57 @@ func NewDelivereeWebhookController(webhookService services.DelivereeWebhookServi
 //   - tracking_url: opsional, link tracking dari provider
 //
 // Selalu merespons 200 selama payload valid, bahkan jika booking ID tidak
-// ditemukan (idempotent) — provider tidak perlu mengirim ulang event lama.
+// ditemukan atau event sudah pernah diterima — provider tidak perlu mengirim
+// ulang event lama.
 func (c *DelivereeWebhookController) Handle(ctx *fiber.Ctx) error {
-	// Verifikasi Authorization header
-	key := ctx.Get("Authorization")
-	if c.authKey == "" {
+	_, err := c.webhookLogService.Receive(ctx.UserContext(), webhookRequest(ctx, models.WebhookProviderDeliveree))
+	if err != nil {
+		return webhookErrorResponse(ctx, "deliveree", err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(dto.DelivereeWebhookResponse{
+		Success: true,
+		Message: "Webhook diproses",
+	})
+}
+
+// webhookRequest menyalin request mentah untuk diverifikasi & dicatat
+func webhookRequest(ctx *fiber.Ctx, provider string) *services.WebhookRequest {
+	return &services.WebhookRequest{
+		Provider: provider,
+		Headers:  ctx.GetReqHeaders(),
+		Body:     append([]byte(nil), ctx.Body()...),
+		RemoteIP: ctx.IP(),
+	}
+}
+
+// webhookErrorResponse memetakan hasil verifikasi/pemrosesan webhook ke respons HTTP
+func webhookErrorResponse(ctx *fiber.Ctx, provider string, err error) error {
+	var payloadErr *services.WebhookPayloadError
+	switch {
+	case errors.Is(err, services.ErrWebhookReplay):
+		// Event sudah diterima: 200 agar provider berhenti mengirim ulang
+		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+			"success": true,
+			"message": "Webhook sudah diterima sebelumnya",
+		})
+	case errors.Is(err, services.ErrWebhookNotConfigured):
 		return ctx.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
 			"success": false,
-			"message": "Webhook Deliveree belum dikonfigurasi (DELIVEREE_WEBHOOK_AUTHORIZATION kosong)",
+			"message": "Webhook " + provider + " belum dikonfigurasi",
 		})
-	}
-	if key != c.authKey {
+	case errors.Is(err, services.ErrWebhookUnauthorized),
+		errors.Is(err, services.ErrWebhookInvalidSignature),
+		errors.Is(err, services.ErrWebhookStaleTimestamp):
+		log.Printf("[%s-webhook] ditolak: ip=%s err=%v", provider, ctx.IP(), err)
 		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"success": false,
 			"message": "Unauthorized",
 		})
-	}
-
-	var req dto.DelivereeWebhookRequest
-	if err := BindJSON(ctx, &req); err != nil {
-		log.Printf("[deliveree-webhook] parse body gagal: err=%v raw=%s", err, string(ctx.Body()))
-		return utils.ErrorResponse(ctx, http.StatusUnprocessableEntity, "Validasi gagal", parseValidationErrors(err))
-	}
-
-	// Validasi manual: status wajib, dan id/no_booking minimal salah satu
-	if req.Status == "" {
-		return utils.SimpleErrorResponse(ctx, http.StatusUnprocessableEntity, "Validasi gagal", "field status wajib diisi")
-	}
-	if req.ID == "" && req.NoBooking == "" {
-		return utils.SimpleErrorResponse(ctx, http.StatusUnprocessableEntity, "Validasi gagal", "field id atau no_booking wajib diisi")
-	}
-
-	_, err := c.webhookService.Handle(ctx.UserContext(), &req)
-	if err != nil {
+	case errors.As(err, &payloadErr):
+		log.Printf("[%s-webhook] payload tidak valid: err=%v raw=%s", provider, err, string(ctx.Body()))
+		return utils.SimpleErrorResponse(ctx, http.StatusUnprocessableEntity, "Validasi gagal", payloadErr.Detail)
+	default:
 		return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal memproses webhook", err.Error())
 	}
-
-	return ctx.Status(fiber.StatusOK).JSON(dto.DelivereeWebhookResponse{
-		Success: true,
-		Message: "Webhook diproses",
-	})
 }
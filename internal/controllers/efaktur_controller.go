@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/services"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// EFakturController melayani profil pajak buyer (buyer & admin) dan data
+// e-Faktur pesanan ber-PPN untuk finance
+type EFakturController struct {
+	service     services.EFakturService
+	activityLog services.ActivityLogService
+}
+
+func NewEFakturController(service services.EFakturService, activityLog services.ActivityLogService) *EFakturController {
+	return &EFakturController{service: service, activityLog: activityLog}
+}
+
+// ========================================
+// Profil pajak - Buyer
+// ========================================
+
+func (c *EFakturController) GetProfilPajak(ctx *fiber.Ctx) error {
+	result, err := c.service.GetProfilPajak(ctx.UserContext(), localsString(ctx, "buyer_id"))
+	if err != nil {
+		return efakturErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Profil pajak berhasil diambil", result)
+}
+
+func (c *EFakturController) UpsertProfilPajak(ctx *fiber.Ctx) error {
+	var req dto.UpsertProfilPajakRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	result, err := c.service.UpsertProfilPajak(ctx.UserContext(), localsString(ctx, "buyer_id"), &req)
+	if err != nil {
+		return efakturErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Profil pajak berhasil disimpan", result)
+}
+
+func (c *EFakturController) DeleteProfilPajak(ctx *fiber.Ctx) error {
+	if err := c.service.DeleteProfilPajak(ctx.UserContext(), localsString(ctx, "buyer_id")); err != nil {
+		return efakturErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Profil pajak berhasil dihapus", nil)
+}
+
+// ========================================
+// Profil pajak - Admin (per buyer)
+// ========================================
+
+func (c *EFakturController) AdminGetProfilPajak(ctx *fiber.Ctx) error {
+	result, err := c.service.GetProfilPajak(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return efakturErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Profil pajak buyer berhasil diambil", result)
+}
+
+func (c *EFakturController) AdminUpsertProfilPajak(ctx *fiber.Ctx) error {
+	var req dto.UpsertProfilPajakRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	result, err := c.service.UpsertProfilPajak(ctx.UserContext(), ctx.Params("id"), &req)
+	if err != nil {
+		return efakturErrorResponse(ctx, err)
+	}
+
+	buyerID, _ := uuid.Parse(ctx.Params("id"))
+	c.activityLog.Log(ctx, models.ActionUpdate, "buyer", "Profil pajak buyer diperbarui", services.WithEntity("buyer", buyerID))
+	return utils.SuccessResponse(ctx, "Profil pajak buyer berhasil disimpan", result)
+}
+
+// ========================================
+// e-Faktur pesanan - Admin finance
+// ========================================
+
+func (c *EFakturController) FindAll(ctx *fiber.Ctx) error {
+	var params dto.EFakturQueryParams
+	if err := ctx.QueryParser(&params); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Parameter tidak valid", nil)
+	}
+
+	items, meta, err := c.service.GetAll(ctx.UserContext(), &params)
+	if err != nil {
+		return efakturErrorResponse(ctx, err)
+	}
+
+	return utils.PaginatedSuccessResponse(ctx, "Data e-Faktur berhasil diambil", items, *meta)
+}
+
+// Export mengunduh file impor e-Faktur (format=csv untuk aplikasi e-Faktur,
+// format=xml untuk Coretax) pesanan yang lunas pada rentang tanggal
+func (c *EFakturController) Export(ctx *fiber.Ctx) error {
+	var params dto.EFakturQueryParams
+	if err := ctx.QueryParser(&params); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Parameter tidak valid", nil)
+	}
+
+	file, err := c.service.Export(ctx.UserContext(), &params)
+	if err != nil {
+		return efakturErrorResponse(ctx, err)
+	}
+
+	c.activityLog.Log(ctx, models.ActionExport, "finance", fmt.Sprintf("Export e-Faktur %s (%d faktur)", file.NamaFile, file.Jumlah))
+	ctx.Set("Content-Type", file.ContentType)
+	ctx.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, file.NamaFile))
+	ctx.Set("X-Export-Total", fmt.Sprintf("%d", file.Jumlah))
+	return ctx.Send(file.Data)
+}
+
+func (c *EFakturController) UpdateStatus(ctx *fiber.Ctx) error {
+	var req dto.UpdateEFakturRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	adminID, err := uuid.Parse(localsString(ctx, "admin_id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusUnauthorized, "Admin tidak valid", err.Error())
+	}
+
+	result, err := c.service.UpdateStatus(ctx.UserContext(), ctx.Params("id"), &req, adminID)
+	if err != nil {
+		return efakturErrorResponse(ctx, err)
+	}
+
+	pesananID, _ := uuid.Parse(result.PesananID)
+	c.activityLog.Log(ctx, models.ActionUpdate, "finance", "Status e-Faktur pesanan "+result.KodePesanan+" menjadi "+result.EFakturStatus, services.WithEntity("pesanan", pesananID))
+	return utils.SuccessResponse(ctx, "Status e-Faktur berhasil diperbarui", result)
+}
+
+func efakturErrorResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrProfilPajakNotFound), errors.Is(err, services.ErrEFakturBuyerNotFound),
+		errors.Is(err, services.ErrEFakturPesananNotFound):
+		return utils.ErrorResponse(ctx, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, services.ErrNPWPTidakValid), errors.Is(err, services.ErrEFakturTidakBerPPN),
+		errors.Is(err, services.ErrEFakturStatusInvalid):
+		return utils.ErrorResponse(ctx, http.StatusUnprocessableEntity, err.Error(), nil)
+	case errors.Is(err, services.ErrEFakturFilterInvalid):
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, services.ErrNPWPPenjualInvalid):
+		return utils.ErrorResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+	}
+	// Error lain (database, dsb.) tidak diteruskan apa adanya ke klien
+	log.Printf("[efaktur] %s %s gagal: %v", ctx.Method(), ctx.Path(), err)
+	return utils.ErrorResponse(ctx, http.StatusInternalServerError, "Terjadi kesalahan pada server", nil)
+}
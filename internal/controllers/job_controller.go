@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/services"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type JobController struct {
+	service     services.JobQueueService
+	activityLog services.ActivityLogService
+}
+
+func NewJobController(service services.JobQueueService, activityLog services.ActivityLogService) *JobController {
+	return &JobController{service: service, activityLog: activityLog}
+}
+
+// GetAll menampilkan job background, filter status=DEAD untuk job yang gagal permanen
+func (c *JobController) GetAll(ctx *fiber.Ctx) error {
+	var params dto.JobQueryParams
+	if err := ctx.QueryParser(&params); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Parameter tidak valid", parseValidationErrors(err))
+	}
+	params.SetDefaults()
+
+	jobs, meta, err := c.service.GetAll(ctx.UserContext(), &params)
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal mengambil data job", err.Error())
+	}
+
+	return utils.PaginatedSuccessResponse(ctx, "Data job berhasil diambil", jobs, *meta)
+}
+
+// GetSummary menampilkan jumlah job per jenis dan status
+func (c *JobController) GetSummary(ctx *fiber.Ctx) error {
+	summary, err := c.service.GetSummary(ctx.UserContext())
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal mengambil ringkasan job", err.Error())
+	}
+
+	return utils.SuccessResponse(ctx, "Ringkasan job berhasil diambil", summary)
+}
+
+// Retry menjadwalkan ulang job berstatus DEAD
+func (c *JobController) Retry(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, "ID tidak valid", err.Error())
+	}
+
+	job, err := c.service.Retry(ctx.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.SimpleErrorResponse(ctx, http.StatusNotFound, "Job tidak ditemukan", "")
+		}
+		if errors.Is(err, services.ErrJobNotRetryable) {
+			return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, err.Error(), "")
+		}
+		return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal menjadwalkan ulang job", err.Error())
+	}
+
+	c.activityLog.Log(ctx, models.ActionUpdate, "job", "Retry job "+job.JobType, services.WithEntity("background_job", job.ID))
+	return utils.SuccessResponse(ctx, "Job dijadwalkan ulang", job)
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/services"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OutboxController struct {
+	service     services.OutboxService
+	activityLog services.ActivityLogService
+}
+
+func NewOutboxController(service services.OutboxService, activityLog services.ActivityLogService) *OutboxController {
+	return &OutboxController{service: service, activityLog: activityLog}
+}
+
+// GetAll menampilkan pesan outbox, filter status=DEAD untuk pengiriman yang gagal permanen
+func (c *OutboxController) GetAll(ctx *fiber.Ctx) error {
+	var params dto.OutboxQueryParams
+	if err := ctx.QueryParser(&params); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Parameter tidak valid", parseValidationErrors(err))
+	}
+	params.SetDefaults()
+
+	messages, meta, err := c.service.GetAll(ctx.UserContext(), &params)
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal mengambil data outbox", err.Error())
+	}
+
+	return utils.PaginatedSuccessResponse(ctx, "Data outbox berhasil diambil", messages, *meta)
+}
+
+// Replay menjadwalkan ulang pesan outbox berstatus DEAD
+func (c *OutboxController) Replay(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, "ID tidak valid", err.Error())
+	}
+
+	msg, err := c.service.Replay(ctx.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.SimpleErrorResponse(ctx, http.StatusNotFound, "Pesan outbox tidak ditemukan", "")
+		}
+		if errors.Is(err, services.ErrOutboxNotReplayable) {
+			return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, err.Error(), "")
+		}
+		return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal replay pesan outbox", err.Error())
+	}
+
+	c.activityLog.Log(ctx, models.ActionUpdate, "outbox", "Replay pesan outbox "+msg.EventType, services.WithEntity("outbox_message", msg.ID))
+	return utils.SuccessResponse(ctx, "Pesan outbox dijadwalkan ulang", msg)
+}
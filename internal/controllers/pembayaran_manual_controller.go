@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/services"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// maxMutasiCSVSize batas ukuran file CSV mutasi rekening
+const maxMutasiCSVSize = 2 * 1024 * 1024
+
+// PembayaranManualController melayani transfer bank manual: instruksi &
+// upload bukti transfer oleh buyer, review bukti dan impor mutasi rekening
+// oleh finance
+type PembayaranManualController struct {
+	service     services.PembayaranManualService
+	activityLog services.ActivityLogService
+}
+
+func NewPembayaranManualController(service services.PembayaranManualService, activityLog services.ActivityLogService) *PembayaranManualController {
+	return &PembayaranManualController{service: service, activityLog: activityLog}
+}
+
+func (c *PembayaranManualController) BuyerInstruksi(ctx *fiber.Ctx) error {
+	result, err := c.service.InstruksiTransfer(ctx.UserContext(), localsString(ctx, "buyer_id"), ctx.Params("id"))
+	if err != nil {
+		return pembayaranManualErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Instruksi transfer berhasil diambil", result)
+}
+
+// BuyerUploadBukti menerima multipart: file (gambar/PDF), jumlah,
+// bank_pengirim, nama_pengirim, tanggal_transfer (YYYY-MM-DD), catatan
+func (c *PembayaranManualController) BuyerUploadBukti(ctx *fiber.Ctx) error {
+	jumlah, err := decimal.NewFromString(strings.TrimSpace(ctx.FormValue("jumlah")))
+	if err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "jumlah tidak valid", nil)
+	}
+	tanggal, err := time.Parse("2006-01-02", ctx.FormValue("tanggal_transfer"))
+	if err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "tanggal_transfer harus berformat YYYY-MM-DD", nil)
+	}
+	req := dto.UploadBuktiTransferRequest{
+		Jumlah:          jumlah,
+		BankPengirim:    ctx.FormValue("bank_pengirim"),
+		NamaPengirim:    ctx.FormValue("nama_pengirim"),
+		TanggalTransfer: tanggal,
+	}
+	if catatan := strings.TrimSpace(ctx.FormValue("catatan")); catatan != "" {
+		req.Catatan = &catatan
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "File bukti transfer wajib diunggah", nil)
+	}
+	if !utils.IsValidImageType(file) && !utils.IsValidDocumentType(file) {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "File harus berupa gambar (JPG, PNG, WEBP) atau PDF", nil)
+	}
+	if file.Size > utils.MaxImageSize {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Ukuran file maksimal 5MB", nil)
+	}
+	data, err := bacaFileUpload(file)
+	if err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Gagal membaca file", nil)
+	}
+
+	result, err := c.service.UploadBukti(ctx.UserContext(), localsString(ctx, "buyer_id"), ctx.Params("id"), &req, file.Header.Get("Content-Type"), data)
+	if err != nil {
+		return pembayaranManualErrorResponse(ctx, err)
+	}
+
+	return utils.CreatedResponse(ctx, "Bukti transfer berhasil diunggah, menunggu konfirmasi finance", result)
+}
+
+func (c *PembayaranManualController) FindAll(ctx *fiber.Ctx) error {
+	var params dto.BuktiTransferQueryParams
+	if err := ctx.QueryParser(&params); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Parameter tidak valid", nil)
+	}
+
+	items, meta, err := c.service.GetAll(ctx.UserContext(), &params)
+	if err != nil {
+		return pembayaranManualErrorResponse(ctx, err)
+	}
+
+	return utils.PaginatedSuccessResponse(ctx, "Data bukti transfer berhasil diambil", items, *meta)
+}
+
+func (c *PembayaranManualController) FindByID(ctx *fiber.Ctx) error {
+	result, err := c.service.GetByID(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return pembayaranManualErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Detail bukti transfer berhasil diambil", result)
+}
+
+func (c *PembayaranManualController) DownloadFile(ctx *fiber.Ctx) error {
+	file, err := c.service.DownloadFile(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return pembayaranManualErrorResponse(ctx, err)
+	}
+
+	ctx.Set("Content-Type", file.ContentType)
+	ctx.Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, file.NamaFile))
+	return ctx.Send(file.Data)
+}
+
+func (c *PembayaranManualController) Approve(ctx *fiber.Ctx) error {
+	var req dto.ApproveBuktiTransferRequest
+	if len(ctx.Body()) > 0 {
+		if err := BindJSON(ctx, &req); err != nil {
+			return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+		}
+	}
+
+	adminID, err := uuid.Parse(localsString(ctx, "admin_id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusUnauthorized, "Admin tidak valid", err.Error())
+	}
+
+	result, err := c.service.Approve(ctx.UserContext(), ctx.Params("id"), &req, adminID)
+	if err != nil {
+		return pembayaranManualErrorResponse(ctx, err)
+	}
+
+	c.activityLog.Log(ctx, models.ActionApprove, "finance", "Bukti transfer pesanan "+result.KodePesanan+" disetujui", services.WithEntity("pesanan_bukti_transfer", result.ID))
+	return utils.SuccessResponse(ctx, "Bukti transfer disetujui, pembayaran tercatat", result)
+}
+
+func (c *PembayaranManualController) Reject(ctx *fiber.Ctx) error {
+	var req dto.RejectBuktiTransferRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	adminID, err := uuid.Parse(localsString(ctx, "admin_id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusUnauthorized, "Admin tidak valid", err.Error())
+	}
+
+	result, err := c.service.Reject(ctx.UserContext(), ctx.Params("id"), &req, adminID)
+	if err != nil {
+		return pembayaranManualErrorResponse(ctx, err)
+	}
+
+	c.activityLog.Log(ctx, models.ActionReject, "finance", "Bukti transfer pesanan "+result.KodePesanan+" ditolak", services.WithEntity("pesanan_bukti_transfer", result.ID))
+	return utils.SuccessResponse(ctx, "Bukti transfer ditolak", result)
+}
+
+func (c *PembayaranManualController) FindMutasi(ctx *fiber.Ctx) error {
+	var params dto.MutasiBankQueryParams
+	if err := ctx.QueryParser(&params); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Parameter tidak valid", nil)
+	}
+
+	items, meta, err := c.service.GetMutasi(ctx.UserContext(), &params)
+	if err != nil {
+		return pembayaranManualErrorResponse(ctx, err)
+	}
+
+	return utils.PaginatedSuccessResponse(ctx, "Data mutasi rekening berhasil diambil", items, *meta)
+}
+
+// ImportMutasi menerima CSV mutasi rekening di field "file"
+func (c *PembayaranManualController) ImportMutasi(ctx *fiber.Ctx) error {
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "File CSV mutasi wajib diunggah", nil)
+	}
+	// Content-Type CSV dari browser tidak konsisten (text/csv, application/vnd.ms-excel)
+	if !strings.EqualFold(filepath.Ext(file.Filename), ".csv") {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "File harus berformat .csv", nil)
+	}
+	if file.Size > maxMutasiCSVSize {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Ukuran file maksimal 2MB", nil)
+	}
+	data, err := bacaFileUpload(file)
+	if err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Gagal membaca file", nil)
+	}
+
+	adminID, err := uuid.Parse(localsString(ctx, "admin_id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusUnauthorized, "Admin tidak valid", err.Error())
+	}
+
+	result, err := c.service.ImportMutasi(ctx.UserContext(), filepath.Base(file.Filename), data, adminID)
+	if err != nil {
+		return pembayaranManualErrorResponse(ctx, err)
+	}
+
+	c.activityLog.Log(ctx, models.ActionCreate, "finance", fmt.Sprintf("Impor mutasi rekening %s: %d cocok, %d belum cocok", file.Filename, result.Cocok, result.BelumCocok))
+	return utils.SuccessResponse(ctx, "Mutasi rekening berhasil diimpor", result)
+}
+
+func bacaFileUpload(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	return io.ReadAll(src)
+}
+
+func pembayaranManualErrorResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrPembayaranManualPesananNotFound), errors.Is(err, services.ErrBuktiTransferNotFound):
+		return utils.ErrorResponse(ctx, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, services.ErrBuktiTransferStatus), errors.Is(err, services.ErrPembayaranManualTidakDiizinkan),
+		errors.Is(err, services.ErrPembayaranManualBatas):
+		return utils.ErrorResponse(ctx, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, services.ErrBuktiTransferInvalid), errors.Is(err, services.ErrMutasiCSVInvalid):
+		return utils.ErrorResponse(ctx, http.StatusUnprocessableEntity, err.Error(), nil)
+	}
+	return utils.ErrorResponse(ctx, http.StatusInternalServerError, "Gagal memproses pembayaran manual", nil)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"project-bulky-be/internal/dto"
 	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/orderstate"
 	"project-bulky-be/internal/services"
 	"project-bulky-be/pkg/utils"
 	"strings"
@@ -88,10 +89,8 @@ func (c *PesananAdminController) UpdateStatus(ctx *fiber.Ctx) error {
 		if err.Error() == "pesanan tidak ditemukan" {
 			return utils.SimpleErrorResponse(ctx, http.StatusNotFound, "Pesanan tidak ditemukan", "")
 		}
-		// Check if it's a validation error for status transition
-		if err.Error() == "tidak dapat mengubah status dari COMPLETED" ||
-			err.Error() == "tidak dapat mengubah status dari CANCELLED" ||
-			len(err.Error()) > 25 && err.Error()[:25] == "tidak dapat mengubah status" {
+		var transitionErr *orderstate.TransitionError
+		if errors.As(err, &transitionErr) {
 			return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, "Transisi status tidak valid", err.Error())
 		}
 		return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal update status pesanan", err.Error())
@@ -101,7 +100,8 @@ func (c *PesananAdminController) UpdateStatus(ctx *fiber.Ctx) error {
 	return utils.SuccessResponse(ctx, "Status pesanan berhasil diupdate", result)
 }
 
-// CancelOrder membatalkan pesanan dan me-restore is_sold produk (staging/debug only)
+// CancelOrder membatalkan booking pengiriman di provider, membatalkan pesanan
+// dan me-restore is_sold produk
 func (c *PesananAdminController) CancelOrder(ctx *fiber.Ctx) error {
 	idParam := ctx.Params("id")
 	id, err := uuid.Parse(idParam)
@@ -122,15 +122,20 @@ func (c *PesananAdminController) CancelOrder(ctx *fiber.Ctx) error {
 
 	result, err := c.pesananService.CancelOrder(ctx.UserContext(), id, &req, adminID)
 	if err != nil {
-		switch err.Error() {
-		case "pesanan tidak ditemukan":
+		var transitionErr *orderstate.TransitionError
+		if errors.As(err, &transitionErr) {
+			return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, "Pesanan tidak dapat dibatalkan", err.Error())
+		}
+		msg := err.Error()
+		switch {
+		case msg == "pesanan tidak ditemukan":
 			return utils.SimpleErrorResponse(ctx, http.StatusNotFound, "Pesanan tidak ditemukan", "")
-		case "pesanan dengan status COMPLETED tidak dapat dibatalkan":
-			return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, "Pesanan sudah selesai dan tidak dapat dibatalkan", "")
-		case "pesanan sudah berstatus CANCELLED":
+		case msg == "pesanan sudah berstatus CANCELLED":
 			return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, "Pesanan sudah berstatus CANCELLED", "")
+		case strings.HasPrefix(msg, "shipment:"):
+			return shipmentErrorResponse(ctx, msg, "Gagal membatalkan booking pengiriman, pesanan belum dibatalkan")
 		default:
-			return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal membatalkan pesanan", err.Error())
+			return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal membatalkan pesanan", msg)
 		}
 	}
 
@@ -211,7 +216,84 @@ func (c *PesananAdminController) RetryBooking(ctx *fiber.Ctx) error {
 	return utils.SuccessResponse(ctx, "Booking pengiriman berhasil dibuat", result)
 }
 
-// TrackDelivery retrieves live tracking info from shipping provider
+// CancelShipment membatalkan booking pengiriman di provider tanpa membatalkan pesanan
+func (c *PesananAdminController) CancelShipment(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, "ID tidak valid", err.Error())
+	}
+
+	var req dto.CancelShipmentRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	adminID, err := uuid.Parse(localsString(ctx, "admin_id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusUnauthorized, "Admin tidak valid", err.Error())
+	}
+
+	result, err := c.pesananService.CancelShipment(ctx.UserContext(), id, &req, adminID)
+	if err != nil {
+		if err.Error() == "pesanan tidak ditemukan" {
+			return utils.SimpleErrorResponse(ctx, http.StatusNotFound, "Pesanan tidak ditemukan", "")
+		}
+		return shipmentErrorResponse(ctx, err.Error(), "Gagal membatalkan booking pengiriman")
+	}
+
+	c.activityLog.Log(ctx, models.ActionUpdate, "pesanan", "Booking pengiriman pesanan "+result.Kode+" dibatalkan")
+	return utils.SuccessResponse(ctx, "Booking pengiriman berhasil dibatalkan", result)
+}
+
+// RebookShipment membooking ulang satu pengiriman dengan kendaraan/jalur pengganti
+func (c *PesananAdminController) RebookShipment(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, "ID tidak valid", err.Error())
+	}
+
+	var req dto.RebookShipmentRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	adminID, err := uuid.Parse(localsString(ctx, "admin_id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusUnauthorized, "Admin tidak valid", err.Error())
+	}
+
+	result, err := c.pesananService.RebookShipment(ctx.UserContext(), id, &req, adminID)
+	if err != nil {
+		if err.Error() == "pesanan tidak ditemukan" {
+			return utils.SimpleErrorResponse(ctx, http.StatusNotFound, "Pesanan tidak ditemukan", "")
+		}
+		return shipmentErrorResponse(ctx, err.Error(), "Gagal booking ulang pengiriman")
+	}
+
+	c.activityLog.Log(ctx, models.ActionUpdate, "pesanan", "Pengiriman pesanan "+result.Kode+" di-booking ulang")
+	return utils.SuccessResponse(ctx, "Pengiriman berhasil di-booking ulang", result)
+}
+
+// shipmentErrorResponse memetakan error berprefix "shipment:<jenis>:" dari
+// PesananAdminService ke status HTTP
+func shipmentErrorResponse(ctx *fiber.Ctx, msg, fallback string) error {
+	parts := strings.SplitN(strings.TrimPrefix(msg, "shipment:"), ":", 2)
+	if len(parts) != 2 {
+		return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, fallback, msg)
+	}
+	switch parts[0] {
+	case "bad_request":
+		return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, parts[1], "")
+	case "in_progress":
+		return utils.SimpleErrorResponse(ctx, http.StatusConflict, parts[1], "")
+	case "provider_error":
+		return utils.SimpleErrorResponse(ctx, http.StatusBadGateway, fallback, parts[1])
+	default:
+		return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, fallback, parts[1])
+	}
+}
+
+// TrackDelivery retrieves live tracking info for every shipment of the pesanan
 func (c *PesananAdminController) TrackDelivery(ctx *fiber.Ctx) error {
 	idParam := ctx.Params("id")
 	id, err := uuid.Parse(idParam)
@@ -245,7 +327,18 @@ func (c *PesananAdminController) GetDelivereeDetail(ctx *fiber.Ctx) error {
 		return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, "ID tidak valid", err.Error())
 	}
 
-	result, err := c.pesananService.GetDelivereeDetail(ctx.UserContext(), id)
+	// pengiriman_id opsional untuk pesanan yang dikirim dari beberapa gudang;
+	// default pengiriman Deliveree pertama
+	var pengirimanID *uuid.UUID
+	if raw := ctx.Query("pengiriman_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, "pengiriman_id tidak valid", err.Error())
+		}
+		pengirimanID = &parsed
+	}
+
+	result, err := c.pesananService.GetDelivereeDetail(ctx.UserContext(), id, pengirimanID)
 	if err != nil {
 		msg := err.Error()
 		switch {
@@ -286,6 +379,51 @@ func (c *PesananAdminController) GetForwarderInvoice(ctx *fiber.Ctx) error {
 	return utils.SuccessResponse(ctx, "Invoice berhasil diambil", result)
 }
 
+// GetProofOfDelivery menampilkan bukti pengiriman Deliveree yang tersimpan
+// (penerima, tanda tangan, waktu tiba & biaya tambahan)
+func (c *PesananAdminController) GetProofOfDelivery(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, "ID tidak valid", err.Error())
+	}
+
+	result, err := c.pesananService.GetProofOfDelivery(ctx.UserContext(), id)
+	if err != nil {
+		return proofOfDeliveryErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Bukti pengiriman berhasil diambil", result)
+}
+
+// CaptureProofOfDelivery mengambil ulang bukti pengiriman dari Deliveree,
+// mis. jika job otomatis saat COMPLETED gagal
+func (c *PesananAdminController) CaptureProofOfDelivery(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, "ID tidak valid", err.Error())
+	}
+
+	result, err := c.pesananService.CaptureProofOfDelivery(ctx.UserContext(), id)
+	if err != nil {
+		return proofOfDeliveryErrorResponse(ctx, err)
+	}
+
+	c.activityLog.Log(ctx, models.ActionUpdate, "pesanan", "Bukti pengiriman "+result.Kode+" diambil ulang dari Deliveree", services.WithEntity("pesanan", result.PesananID))
+	return utils.SuccessResponse(ctx, "Bukti pengiriman berhasil disimpan", result)
+}
+
+func proofOfDeliveryErrorResponse(ctx *fiber.Ctx, err error) error {
+	msg := err.Error()
+	switch {
+	case msg == "pesanan tidak ditemukan":
+		return utils.SimpleErrorResponse(ctx, http.StatusNotFound, "Pesanan tidak ditemukan", "")
+	case strings.HasPrefix(msg, "deliveree:not_applicable:"):
+		return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, msg[25:], "")
+	default:
+		return utils.SimpleErrorResponse(ctx, http.StatusBadGateway, "Gagal mengambil bukti pengiriman", msg)
+	}
+}
+
 // GetStatistics retrieves pesanan statistics
 func (c *PesananAdminController) GetStatistics(ctx *fiber.Ctx) error {
 	var params dto.StatisticsQueryParams
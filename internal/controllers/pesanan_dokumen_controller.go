@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/services"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// PesananDokumenController melayani invoice & nota kredit PDF pesanan untuk
+// admin (panel pesanan) dan buyer (pemilik/pembayar pesanan)
+type PesananDokumenController struct {
+	service     services.PesananDokumenService
+	activityLog services.ActivityLogService
+}
+
+func NewPesananDokumenController(service services.PesananDokumenService, activityLog services.ActivityLogService) *PesananDokumenController {
+	return &PesananDokumenController{service: service, activityLog: activityLog}
+}
+
+func (c *PesananDokumenController) GetAll(ctx *fiber.Ctx) error {
+	result, err := c.service.List(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return pesananDokumenErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Dokumen pesanan berhasil diambil", result)
+}
+
+// IssueInvoice menerbitkan invoice pesanan lunas; jika sudah terbit,
+// invoice yang sama dikembalikan
+func (c *PesananDokumenController) IssueInvoice(ctx *fiber.Ctx) error {
+	adminID, err := uuid.Parse(localsString(ctx, "admin_id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusUnauthorized, "Admin tidak valid", err.Error())
+	}
+
+	result, err := c.service.IssueInvoice(ctx.UserContext(), ctx.Params("id"), adminID)
+	if err != nil {
+		return pesananDokumenErrorResponse(ctx, err)
+	}
+
+	c.activityLog.Log(ctx, models.ActionCreate, "pesanan", "Invoice "+result.Nomor+" diterbitkan", services.WithEntity("pesanan_dokumen", result.ID))
+	return utils.SuccessResponse(ctx, "Invoice berhasil diterbitkan", result)
+}
+
+func (c *PesananDokumenController) Download(ctx *fiber.Ctx) error {
+	file, err := c.service.Download(ctx.UserContext(), ctx.Params("id"), ctx.Params("dokumen_id"))
+	if err != nil {
+		return pesananDokumenErrorResponse(ctx, err)
+	}
+
+	return sendDokumenPDF(ctx, file)
+}
+
+// DownloadArsip mengunduh ZIP invoice beserta bukti pengiriman Deliveree
+func (c *PesananDokumenController) DownloadArsip(ctx *fiber.Ctx) error {
+	file, err := c.service.DownloadArsip(ctx.UserContext(), ctx.Params("id"), ctx.Params("dokumen_id"))
+	if err != nil {
+		return pesananDokumenErrorResponse(ctx, err)
+	}
+
+	ctx.Set("Content-Type", "application/zip")
+	ctx.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, file.NamaFile))
+	return ctx.Send(file.Data)
+}
+
+func (c *PesananDokumenController) BuyerGetAll(ctx *fiber.Ctx) error {
+	result, err := c.service.BuyerList(ctx.UserContext(), localsString(ctx, "buyer_id"), ctx.Params("id"))
+	if err != nil {
+		return pesananDokumenErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Dokumen pesanan berhasil diambil", result)
+}
+
+func (c *PesananDokumenController) BuyerIssueInvoice(ctx *fiber.Ctx) error {
+	result, err := c.service.BuyerIssueInvoice(ctx.UserContext(), localsString(ctx, "buyer_id"), ctx.Params("id"))
+	if err != nil {
+		return pesananDokumenErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Invoice berhasil diterbitkan", result)
+}
+
+func (c *PesananDokumenController) BuyerDownload(ctx *fiber.Ctx) error {
+	file, err := c.service.BuyerDownload(ctx.UserContext(), localsString(ctx, "buyer_id"), ctx.Params("id"), ctx.Params("dokumen_id"))
+	if err != nil {
+		return pesananDokumenErrorResponse(ctx, err)
+	}
+
+	return sendDokumenPDF(ctx, file)
+}
+
+func sendDokumenPDF(ctx *fiber.Ctx, file *services.DokumenFile) error {
+	ctx.Set("Content-Type", "application/pdf")
+	ctx.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, file.NamaFile))
+	return ctx.Send(file.Data)
+}
+
+func pesananDokumenErrorResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrDokumenPesananNotFound), errors.Is(err, services.ErrDokumenNotFound),
+		errors.Is(err, services.ErrBuktiPengirimanKosong):
+		return utils.ErrorResponse(ctx, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, services.ErrInvoiceBelumLunas), errors.Is(err, services.ErrArsipBukanInvoice):
+		return utils.ErrorResponse(ctx, http.StatusUnprocessableEntity, err.Error(), nil)
+	case errors.Is(err, services.ErrDokumenRusak):
+		return utils.ErrorResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+	}
+	return utils.ErrorResponse(ctx, http.StatusInternalServerError, "Gagal memproses dokumen pesanan", nil)
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/services"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// PesananPanelController melayani pembuatan pesanan oleh admin dari panel
+// (penjualan offline / partai besar)
+type PesananPanelController struct {
+	service     services.PesananPanelService
+	activityLog services.ActivityLogService
+}
+
+func NewPesananPanelController(service services.PesananPanelService, activityLog services.ActivityLogService) *PesananPanelController {
+	return &PesananPanelController{service: service, activityLog: activityLog}
+}
+
+func (c *PesananPanelController) Create(ctx *fiber.Ctx) error {
+	var req models.CreatePesananRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	adminID, err := uuid.Parse(localsString(ctx, "admin_id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusUnauthorized, "Admin tidak valid", err.Error())
+	}
+
+	result, err := c.service.Create(ctx.UserContext(), &req, adminID)
+	if err != nil {
+		return pesananPanelErrorResponse(ctx, err)
+	}
+
+	c.activityLog.Log(ctx, models.ActionCreate, "pesanan", "Membuat pesanan "+result.Kode+" untuk "+result.NamaBuyer+" ("+req.MetodeBayar+")", services.WithEntity("pesanan", result.ID))
+	return utils.CreatedResponse(ctx, "Pesanan berhasil dibuat", result)
+}
+
+func pesananPanelErrorResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrPesananPanelBuyerNotFound), errors.Is(err, services.ErrPesananPanelSubmissionNotFound):
+		return utils.ErrorResponse(ctx, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, services.ErrPesananPanelProdukTidakTersedia):
+		return utils.ErrorResponse(ctx, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, services.ErrPesananPanelInvalid):
+		return utils.ErrorResponse(ctx, http.StatusUnprocessableEntity, err.Error(), nil)
+	case errors.Is(err, services.ErrPesananPanelPaymentLink):
+		return utils.ErrorResponse(ctx, http.StatusBadGateway, err.Error(), nil)
+	}
+	return utils.ErrorResponse(ctx, http.StatusInternalServerError, "Gagal membuat pesanan", nil)
+}
@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/services"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// PickupAppointmentController melayani janji pickup gudang: buyer memesan,
+// menjadwal ulang & membatalkan slot untuk pesanan PICKUP, admin melihat
+// kalender janji per gudang dan mengelola pengecualian jadwal (libur/tutup).
+type PickupAppointmentController struct {
+	service     services.PickupAppointmentService
+	activityLog services.ActivityLogService
+}
+
+func NewPickupAppointmentController(service services.PickupAppointmentService, activityLog services.ActivityLogService) *PickupAppointmentController {
+	return &PickupAppointmentController{service: service, activityLog: activityLog}
+}
+
+// ========================================
+// Buyer Endpoints
+// ========================================
+
+// GetSlots menampilkan slot pickup satu tanggal (default hari ini) di gudang asal item pesanan
+func (c *PickupAppointmentController) GetSlots(ctx *fiber.Ctx) error {
+	var params dto.PickupSlotQueryParams
+	if err := ctx.QueryParser(&params); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Parameter tidak valid", nil)
+	}
+
+	result, err := c.service.GetSlots(ctx.UserContext(), localsString(ctx, "buyer_id"), ctx.Params("id"), &params)
+	if err != nil {
+		return pickupAppointmentErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Slot pickup berhasil diambil", result)
+}
+
+// GetAppointments menampilkan janji pickup aktif pesanan
+func (c *PickupAppointmentController) GetAppointments(ctx *fiber.Ctx) error {
+	result, err := c.service.GetAppointments(ctx.UserContext(), localsString(ctx, "buyer_id"), ctx.Params("id"))
+	if err != nil {
+		return pickupAppointmentErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Janji pickup berhasil diambil", result)
+}
+
+func (c *PickupAppointmentController) Book(ctx *fiber.Ctx) error {
+	var req dto.BookPickupAppointmentRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	result, err := c.service.Book(ctx.UserContext(), localsString(ctx, "buyer_id"), ctx.Params("id"), &req)
+	if err != nil {
+		return pickupAppointmentErrorResponse(ctx, err)
+	}
+
+	return utils.CreatedResponse(ctx, "Janji pickup berhasil dibuat", result)
+}
+
+// Reschedule memindahkan janji ke slot lain; janji lama dibatalkan hanya jika slot baru berhasil dipesan
+func (c *PickupAppointmentController) Reschedule(ctx *fiber.Ctx) error {
+	var req dto.ReschedulePickupAppointmentRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	result, err := c.service.Reschedule(ctx.UserContext(), localsString(ctx, "buyer_id"), ctx.Params("id"), ctx.Params("appointment_id"), &req)
+	if err != nil {
+		return pickupAppointmentErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Janji pickup berhasil dijadwal ulang", result)
+}
+
+func (c *PickupAppointmentController) Cancel(ctx *fiber.Ctx) error {
+	var req dto.CancelPickupAppointmentRequest
+	if len(ctx.Body()) > 0 {
+		if err := BindJSON(ctx, &req); err != nil {
+			return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+		}
+	}
+
+	if err := c.service.Cancel(ctx.UserContext(), localsString(ctx, "buyer_id"), ctx.Params("id"), ctx.Params("appointment_id"), &req); err != nil {
+		return pickupAppointmentErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Janji pickup berhasil dibatalkan", nil)
+}
+
+// ========================================
+// Admin Endpoints
+// ========================================
+
+// Calendar menampilkan janji pickup per tanggal per gudang (tanggal_dari/tanggal_sampai, maks 31 hari)
+func (c *PickupAppointmentController) Calendar(ctx *fiber.Ctx) error {
+	var params dto.PickupKalenderQueryParams
+	if err := ctx.QueryParser(&params); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Parameter tidak valid", nil)
+	}
+
+	result, err := c.service.Calendar(ctx.UserContext(), &params)
+	if err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+	}
+
+	return utils.SuccessResponse(ctx, "Kalender janji pickup berhasil diambil", result)
+}
+
+func (c *PickupAppointmentController) GetPengecualian(ctx *fiber.Ctx) error {
+	var params dto.JadwalGudangPengecualianQueryParams
+	if err := ctx.QueryParser(&params); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Parameter tidak valid", nil)
+	}
+
+	result, err := c.service.GetPengecualian(ctx.UserContext(), &params)
+	if err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+	}
+
+	return utils.SuccessResponse(ctx, "Pengecualian jadwal gudang berhasil diambil", result)
+}
+
+func (c *PickupAppointmentController) CreatePengecualian(ctx *fiber.Ctx) error {
+	var req dto.JadwalGudangPengecualianRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	result, err := c.service.CreatePengecualian(ctx.UserContext(), &req)
+	if err != nil {
+		return pengecualianErrorResponse(ctx, err)
+	}
+
+	c.activityLog.Log(ctx, models.ActionCreate, "operasional", "Pengecualian jadwal gudang "+result.Tanggal+" berhasil dibuat", services.WithEntity("jadwal_gudang_pengecualian", uuid.MustParse(result.ID)))
+	return utils.CreatedResponse(ctx, "Pengecualian jadwal gudang berhasil dibuat", result)
+}
+
+func (c *PickupAppointmentController) UpdatePengecualian(ctx *fiber.Ctx) error {
+	var req dto.JadwalGudangPengecualianRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	result, err := c.service.UpdatePengecualian(ctx.UserContext(), ctx.Params("id"), &req)
+	if err != nil {
+		return pengecualianErrorResponse(ctx, err)
+	}
+
+	c.activityLog.Log(ctx, models.ActionUpdate, "operasional", "Pengecualian jadwal gudang "+result.Tanggal+" berhasil diupdate", services.WithEntity("jadwal_gudang_pengecualian", uuid.MustParse(result.ID)))
+	return utils.SuccessResponse(ctx, "Pengecualian jadwal gudang berhasil diupdate", result)
+}
+
+func (c *PickupAppointmentController) DeletePengecualian(ctx *fiber.Ctx) error {
+	if err := c.service.DeletePengecualian(ctx.UserContext(), ctx.Params("id")); err != nil {
+		return pengecualianErrorResponse(ctx, err)
+	}
+
+	// ID sudah tervalidasi service saat penghapusan berhasil
+	id, _ := uuid.Parse(ctx.Params("id"))
+	c.activityLog.Log(ctx, models.ActionDelete, "operasional", "Pengecualian jadwal gudang berhasil dihapus", services.WithEntity("jadwal_gudang_pengecualian", id))
+	return utils.SuccessResponse(ctx, "Pengecualian jadwal gudang berhasil dihapus", nil)
+}
+
+func pickupAppointmentErrorResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrPickupPesananNotFound), errors.Is(err, services.ErrPickupAppointmentNotFound):
+		return utils.ErrorResponse(ctx, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, services.ErrPickupNotAllowed):
+		return utils.ErrorResponse(ctx, http.StatusUnprocessableEntity, err.Error(), nil)
+	case errors.Is(err, services.ErrPickupSlotFull),
+		errors.Is(err, services.ErrPickupAppointmentExists),
+		errors.Is(err, services.ErrPickupAppointmentNotActive):
+		return utils.ErrorResponse(ctx, http.StatusConflict, err.Error(), nil)
+	}
+	return utils.ErrorResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+}
+
+func pengecualianErrorResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrJadwalPengecualianNotFound):
+		return utils.ErrorResponse(ctx, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, services.ErrJadwalPengecualianDuplicate):
+		return utils.ErrorResponse(ctx, http.StatusConflict, err.Error(), nil)
+	}
+	return utils.ErrorResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+}
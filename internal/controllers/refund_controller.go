@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/services"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RefundController melayani refund pesanan: admin pesanan mengajukan refund,
+// finance (permission refund:approve) menyetujui atau menolaknya.
+type RefundController struct {
+	service     services.RefundService
+	activityLog services.ActivityLogService
+}
+
+func NewRefundController(service services.RefundService, activityLog services.ActivityLogService) *RefundController {
+	return &RefundController{service: service, activityLog: activityLog}
+}
+
+// GetByPesanan menampilkan refund pesanan beserta sisa dana per pembayaran
+func (c *RefundController) GetByPesanan(ctx *fiber.Ctx) error {
+	result, err := c.service.GetByPesanan(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return refundErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Refund pesanan berhasil diambil", result)
+}
+
+// Create mengajukan refund pesanan; pesanan SPLIT bisa per pembayar lewat items
+func (c *RefundController) Create(ctx *fiber.Ctx) error {
+	var req dto.CreateRefundRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	adminID, err := uuid.Parse(localsString(ctx, "admin_id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusUnauthorized, "Admin tidak valid", err.Error())
+	}
+
+	result, err := c.service.Create(ctx.UserContext(), ctx.Params("id"), &req, adminID)
+	if err != nil {
+		return refundErrorResponse(ctx, err)
+	}
+
+	pesananID, _ := uuid.Parse(ctx.Params("id"))
+	c.activityLog.Log(ctx, models.ActionCreate, "finance", "Refund pesanan diajukan", services.WithEntity("pesanan", pesananID))
+	return utils.CreatedResponse(ctx, "Refund berhasil diajukan, menunggu persetujuan finance", result)
+}
+
+func (c *RefundController) FindAll(ctx *fiber.Ctx) error {
+	var params dto.RefundQueryParams
+	if err := ctx.QueryParser(&params); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Parameter tidak valid", nil)
+	}
+
+	items, meta, err := c.service.GetAll(ctx.UserContext(), &params)
+	if err != nil {
+		return refundErrorResponse(ctx, err)
+	}
+
+	return utils.PaginatedSuccessResponse(ctx, "Data refund berhasil diambil", items, *meta)
+}
+
+func (c *RefundController) FindByID(ctx *fiber.Ctx) error {
+	result, err := c.service.GetByID(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return refundErrorResponse(ctx, err)
+	}
+
+	return utils.SuccessResponse(ctx, "Detail refund berhasil diambil", result)
+}
+
+// Approve menyetujui refund: diteruskan ke payment gateway atau dicatat
+// selesai jika dana dikembalikan manual oleh finance
+func (c *RefundController) Approve(ctx *fiber.Ctx) error {
+	var req dto.ApproveRefundRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	adminID, err := uuid.Parse(localsString(ctx, "admin_id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusUnauthorized, "Admin tidak valid", err.Error())
+	}
+
+	result, err := c.service.Approve(ctx.UserContext(), ctx.Params("id"), &req, adminID)
+	if err != nil {
+		return refundErrorResponse(ctx, err)
+	}
+
+	c.activityLog.Log(ctx, models.ActionApprove, "finance", "Refund pesanan "+result.KodePesanan+" disetujui", services.WithEntity("pesanan_refund", result.ID))
+	return utils.SuccessResponse(ctx, "Refund berhasil disetujui", result)
+}
+
+func (c *RefundController) Reject(ctx *fiber.Ctx) error {
+	var req dto.RejectRefundRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	adminID, err := uuid.Parse(localsString(ctx, "admin_id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusUnauthorized, "Admin tidak valid", err.Error())
+	}
+
+	result, err := c.service.Reject(ctx.UserContext(), ctx.Params("id"), &req, adminID)
+	if err != nil {
+		return refundErrorResponse(ctx, err)
+	}
+
+	c.activityLog.Log(ctx, models.ActionReject, "finance", "Refund pesanan "+result.KodePesanan+" ditolak", services.WithEntity("pesanan_refund", result.ID))
+	return utils.SuccessResponse(ctx, "Refund berhasil ditolak", result)
+}
+
+func refundErrorResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrRefundPesananNotFound), errors.Is(err, services.ErrRefundNotFound):
+		return utils.ErrorResponse(ctx, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, services.ErrRefundNothingToRefund), errors.Is(err, services.ErrRefundInvalid):
+		return utils.ErrorResponse(ctx, http.StatusUnprocessableEntity, err.Error(), nil)
+	case errors.Is(err, services.ErrRefundStatusNotAllowed):
+		return utils.ErrorResponse(ctx, http.StatusConflict, err.Error(), nil)
+	}
+	return utils.ErrorResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+}
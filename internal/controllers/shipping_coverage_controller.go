@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"net/http"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/services"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ShippingCoverageController mengelola zona coverage pengiriman per provider
+// di admin panel, plus endpoint tes alamat untuk melihat provider & jalur
+// (LTL/LCL) yang akan didapat satu alamat.
+type ShippingCoverageController struct {
+	service     services.ShippingCoverageService
+	activityLog services.ActivityLogService
+}
+
+func NewShippingCoverageController(service services.ShippingCoverageService, activityLog services.ActivityLogService) *ShippingCoverageController {
+	return &ShippingCoverageController{service: service, activityLog: activityLog}
+}
+
+func (c *ShippingCoverageController) GetAll(ctx *fiber.Ctx) error {
+	var params models.ShippingCoverageZoneFilterRequest
+	if err := ctx.QueryParser(&params); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Parameter tidak valid", nil)
+	}
+	params.SetDefaults()
+
+	items, meta, err := c.service.GetAll(ctx.UserContext(), &params)
+	if err != nil {
+		return utils.ErrorResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+	}
+
+	return utils.PaginatedSuccessResponse(ctx, "Data zona coverage berhasil diambil", items, *meta)
+}
+
+func (c *ShippingCoverageController) GetByID(ctx *fiber.Ctx) error {
+	result, err := c.service.GetByID(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(ctx, http.StatusNotFound, err.Error(), nil)
+	}
+
+	return utils.SuccessResponse(ctx, "Detail zona coverage berhasil diambil", result)
+}
+
+func (c *ShippingCoverageController) Create(ctx *fiber.Ctx) error {
+	var req models.CreateShippingCoverageZoneRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	result, err := c.service.Create(ctx.UserContext(), &req)
+	if err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+	}
+
+	c.activityLog.Log(ctx, models.ActionCreate, "shipping_coverage", "Zona coverage "+result.Provider+" "+result.Provinsi+" berhasil dibuat")
+	return utils.CreatedResponse(ctx, "Zona coverage berhasil dibuat", result)
+}
+
+func (c *ShippingCoverageController) Update(ctx *fiber.Ctx) error {
+	var req models.UpdateShippingCoverageZoneRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	result, err := c.service.Update(ctx.UserContext(), ctx.Params("id"), &req)
+	if err != nil {
+		if err.Error() == "Zona coverage tidak ditemukan" {
+			return utils.ErrorResponse(ctx, http.StatusNotFound, err.Error(), nil)
+		}
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+	}
+
+	c.activityLog.Log(ctx, models.ActionUpdate, "shipping_coverage", "Zona coverage "+result.Provider+" "+result.Provinsi+" berhasil diupdate")
+	return utils.SuccessResponse(ctx, "Zona coverage berhasil diupdate", result)
+}
+
+func (c *ShippingCoverageController) Delete(ctx *fiber.Ctx) error {
+	if err := c.service.Delete(ctx.UserContext(), ctx.Params("id")); err != nil {
+		if err.Error() == "Zona coverage tidak ditemukan" {
+			return utils.ErrorResponse(ctx, http.StatusNotFound, err.Error(), nil)
+		}
+		if err.Error() == "ID zona coverage tidak valid" {
+			return utils.ErrorResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		}
+		return utils.ErrorResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+	}
+
+	c.activityLog.Log(ctx, models.ActionDelete, "shipping_coverage", "Zona coverage berhasil dihapus")
+	return utils.SuccessResponse(ctx, "Zona coverage berhasil dihapus", nil)
+}
+
+// TestAddress menampilkan provider & jalur yang akan didapat satu alamat
+// berdasarkan zona aktif saat ini. Tidak mengubah data apa pun.
+func (c *ShippingCoverageController) TestAddress(ctx *fiber.Ctx) error {
+	var req models.TestShippingCoverageRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	result, err := c.service.TestAddress(ctx.UserContext(), &req)
+	if err != nil {
+		if err.Error() == "Alamat buyer tidak ditemukan" {
+			return utils.ErrorResponse(ctx, http.StatusNotFound, err.Error(), nil)
+		}
+		if err.Error() == "Isi alamat_buyer_id atau provinsi/kota yang akan dites" {
+			return utils.ErrorResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		}
+		return utils.ErrorResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+	}
+
+	return utils.SuccessResponse(ctx, "Hasil tes coverage alamat", result)
+}
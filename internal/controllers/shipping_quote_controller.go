@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/services"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ShippingQuoteController melayani quote ongkir untuk admin panel dan
+// storefront BE (via X-Internal-Key). ID quote di response disimpan storefront
+// ke pesanan.shipping_quote_id saat checkout.
+type ShippingQuoteController struct {
+	service services.ShippingService
+}
+
+func NewShippingQuoteController(service services.ShippingService) *ShippingQuoteController {
+	return &ShippingQuoteController{service: service}
+}
+
+// AdminQuote menghitung quote ongkir dari admin panel
+func (c *ShippingQuoteController) AdminQuote(ctx *fiber.Ctx) error {
+	return c.quote(ctx, models.ShippingQuoteSourceAdmin)
+}
+
+// StorefrontQuote menghitung quote ongkir untuk checkout storefront
+func (c *ShippingQuoteController) StorefrontQuote(ctx *fiber.Ctx) error {
+	return c.quote(ctx, models.ShippingQuoteSourceStorefront)
+}
+
+func (c *ShippingQuoteController) quote(ctx *fiber.Ctx, source string) error {
+	var req models.ShippingQuoteRequest
+	if err := BindJSON(ctx, &req); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Validasi gagal", parseValidationErrors(err))
+	}
+
+	result, err := c.service.Quote(ctx.UserContext(), &req, source)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case msg == "Alamat buyer tidak ditemukan", strings.HasPrefix(msg, "Produk ") && strings.HasSuffix(msg, "tidak ditemukan"):
+			return utils.ErrorResponse(ctx, http.StatusNotFound, msg, nil)
+		case msg == "Isi alamat_buyer_id atau provinsi & kota tujuan":
+			return utils.ErrorResponse(ctx, http.StatusBadRequest, msg, nil)
+		}
+		return utils.ErrorResponse(ctx, http.StatusInternalServerError, msg, nil)
+	}
+
+	return utils.SuccessResponse(ctx, "Quote ongkir berhasil dihitung", result)
+}
@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -15,7 +14,6 @@ import (
 	"project-bulky-be/internal/dto"
 	"project-bulky-be/internal/models"
 	"project-bulky-be/internal/services"
-	"project-bulky-be/pkg/transcoder"
 	"project-bulky-be/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -28,7 +26,6 @@ type VideoController struct {
 	kategoriVideoService services.KategoriVideoService
 	cfg                  *config.Config
 	activityLog          services.ActivityLogService
-	transcodeSem         chan struct{}
 }
 
 func NewVideoController(
@@ -42,7 +39,6 @@ func NewVideoController(
 		kategoriVideoService: kategoriVideoService,
 		cfg:                  cfg,
 		activityLog:          activityLog,
-		transcodeSem:         make(chan struct{}, 2),
 	}
 }
 
@@ -169,7 +165,7 @@ func (c *VideoController) Create(ctx *fiber.Ctx) error {
 			// If external video URL and no thumbnail provided, thumbnailURL remains nil
 		}
 
-		// Async transcode path: video file was uploaded → create draft + goroutine
+		// Async transcode path: video file was uploaded → create draft + job transcode
 		if uploadedFilePath != "" {
 			video, err := c.videoService.CreateDraft(ctx.UserContext(), &req)
 			if err != nil {
@@ -183,7 +179,9 @@ func (c *VideoController) Create(ctx *fiber.Ctx) error {
 				return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal membuat data video", err.Error())
 			}
 
-			go c.runTranscode(video.ID, uploadedFilePath)
+			if err := c.videoService.EnqueueTranscode(ctx.UserContext(), video.ID, uploadedFilePath, ""); err != nil {
+				return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal memulai proses transcode", err.Error())
+			}
 
 			c.activityLog.Log(ctx, models.ActionCreate, "video", "Video sedang diproses (transcode)")
 			return utils.SimpleSuccessResponse(ctx, http.StatusAccepted, "Video sedang diproses", fiber.Map{
@@ -301,7 +299,7 @@ func (c *VideoController) Update(ctx *fiber.Ctx) error {
 			}
 			uploadedFilePath = savedPath
 			oldVideoURL = rawPath
-			// Jangan set req.VideoURL dan jangan hapus file lama sekarang — ditangani async via MarkProcessing + runTranscodeUpdate
+			// Jangan set req.VideoURL dan jangan hapus file lama sekarang — ditangani async via MarkProcessing + job transcode
 		} else {
 			// Check for video_url string
 			if videoURL := ctx.FormValue("video_url"); videoURL != "" {
@@ -363,7 +361,9 @@ func (c *VideoController) Update(ctx *fiber.Ctx) error {
 				utils.DeleteFile(*oldThumbnail, c.cfg)
 			}
 
-			go c.runTranscodeUpdate(id, uploadedFilePath, oldVideoURL)
+			if err := c.videoService.EnqueueTranscode(ctx.UserContext(), id, uploadedFilePath, oldVideoURL); err != nil {
+				return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal memulai proses transcode", err.Error())
+			}
 
 			c.activityLog.Log(ctx, models.ActionUpdate, "video", "Video sedang diproses (transcode)")
 			return utils.SimpleSuccessResponse(ctx, http.StatusAccepted, "Video sedang diproses", fiber.Map{
@@ -737,7 +737,9 @@ func (c *VideoController) FinalizeChunk(ctx *fiber.Ctx) error {
 		return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal membuat data video", err.Error())
 	}
 
-	go c.runTranscode(video.ID, relativeVideoPath)
+	if err := c.videoService.EnqueueTranscode(ctx.UserContext(), video.ID, relativeVideoPath, ""); err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal memulai proses transcode", err.Error())
+	}
 
 	c.activityLog.Log(ctx, models.ActionCreate, "video", "Video sedang diproses (chunk upload)")
 	return utils.SimpleSuccessResponse(ctx, http.StatusAccepted, "Video sedang diproses", fiber.Map{
@@ -876,7 +878,9 @@ func (c *VideoController) FinalizeChunkUpdate(ctx *fiber.Ctx) error {
 		return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal memulai proses transcode", err.Error())
 	}
 
-	go c.runTranscodeUpdate(id, relativeVideoPath, oldVideoURL)
+	if err := c.videoService.EnqueueTranscode(ctx.UserContext(), id, relativeVideoPath, oldVideoURL); err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal memulai proses transcode", err.Error())
+	}
 
 	c.activityLog.Log(ctx, models.ActionUpdate, "video", "Video sedang diproses (chunk upload update)")
 	return utils.SimpleSuccessResponse(ctx, http.StatusAccepted, "Video sedang diproses", fiber.Map{
@@ -884,57 +888,3 @@ func (c *VideoController) FinalizeChunkUpdate(ctx *fiber.Ctx) error {
 		"transcode_status": "processing",
 	})
 }
-
-// runTranscodeUpdate dijalankan di goroutine background setelah Update menerima file video baru.
-// Sama dengan runTranscode, tetapi setelah berhasil juga menghapus file video lama.
-func (c *VideoController) runTranscodeUpdate(videoID uuid.UUID, rawRelativePath string, oldVideoURL string) {
-	c.transcodeSem <- struct{}{}
-	defer func() { <-c.transcodeSem }()
-
-	rawAbsPath := filepath.Join(c.cfg.UploadPath, filepath.FromSlash(rawRelativePath))
-
-	result, err := transcoder.Transcode(rawAbsPath)
-	transcoder.Cleanup(rawAbsPath)
-
-	if err != nil {
-		_ = c.videoService.MarkFailed(context.Background(), videoID, err.Error())
-		return
-	}
-
-	dir := filepath.Dir(rawRelativePath)
-	base := strings.TrimSuffix(filepath.Base(rawRelativePath), filepath.Ext(rawRelativePath))
-	relativeStreamURL := filepath.ToSlash(filepath.Join(dir, "stream_"+base+".mp4"))
-
-	_ = c.videoService.MarkReady(context.Background(), videoID, relativeStreamURL, result.DurasiDetik)
-
-	// Hapus file video lama setelah transcode berhasil (raw relative path, langsung ke DeleteFile)
-	if oldVideoURL != "" {
-		utils.DeleteFile(oldVideoURL, c.cfg)
-	}
-}
-
-// runTranscode dijalankan di goroutine background setelah Create menerima file upload.
-// Ia melakukan transcode, lalu mengupdate DB dengan hasilnya.
-// Semaphore transcodeSem membatasi maksimal 2 job transcode berjalan bersamaan.
-func (c *VideoController) runTranscode(videoID uuid.UUID, rawRelativePath string) {
-	c.transcodeSem <- struct{}{}
-	defer func() { <-c.transcodeSem }()
-
-	rawAbsPath := filepath.Join(c.cfg.UploadPath, filepath.FromSlash(rawRelativePath))
-
-	result, err := transcoder.Transcode(rawAbsPath)
-	// Hapus file raw setelah proses transcode (sukses maupun gagal)
-	transcoder.Cleanup(rawAbsPath)
-
-	if err != nil {
-		_ = c.videoService.MarkFailed(context.Background(), videoID, err.Error())
-		return
-	}
-
-	// Derive relative URL dari rawRelativePath (konsisten dengan SaveUploadedFile yang menyimpan tanpa prefix "uploads/")
-	dir := filepath.Dir(rawRelativePath)
-	base := strings.TrimSuffix(filepath.Base(rawRelativePath), filepath.Ext(rawRelativePath))
-	relativeStreamURL := filepath.ToSlash(filepath.Join(dir, "stream_"+base+".mp4"))
-
-	_ = c.videoService.MarkReady(context.Background(), videoID, relativeStreamURL, result.DurasiDetik)
-}
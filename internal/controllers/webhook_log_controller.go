@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/services"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WebhookLogController struct {
+	service     services.ProviderWebhookLogService
+	activityLog services.ActivityLogService
+}
+
+func NewWebhookLogController(service services.ProviderWebhookLogService, activityLog services.ActivityLogService) *WebhookLogController {
+	return &WebhookLogController{service: service, activityLog: activityLog}
+}
+
+// GetAll menampilkan log webhook provider, filter provider/verification/process_status/booking_ref
+func (c *WebhookLogController) GetAll(ctx *fiber.Ctx) error {
+	var params dto.WebhookLogQueryParams
+	if err := ctx.QueryParser(&params); err != nil {
+		return utils.ErrorResponse(ctx, http.StatusBadRequest, "Parameter tidak valid", parseValidationErrors(err))
+	}
+	params.SetDefaults()
+
+	logs, meta, err := c.service.GetAll(ctx.UserContext(), &params)
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal mengambil log webhook", err.Error())
+	}
+
+	return utils.PaginatedSuccessResponse(ctx, "Log webhook berhasil diambil", logs, *meta)
+}
+
+// GetByID menampilkan satu log webhook beserta header & body mentahnya
+func (c *WebhookLogController) GetByID(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, "ID tidak valid", err.Error())
+	}
+
+	webhookLog, err := c.service.GetByID(ctx.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.SimpleErrorResponse(ctx, http.StatusNotFound, "Log webhook tidak ditemukan", "")
+		}
+		return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal mengambil log webhook", err.Error())
+	}
+
+	return utils.SuccessResponse(ctx, "Log webhook berhasil diambil", webhookLog)
+}
+
+// Reprocess memproses ulang body webhook tersimpan yang lolos verifikasi
+func (c *WebhookLogController) Reprocess(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, "ID tidak valid", err.Error())
+	}
+
+	webhookLog, err := c.service.Reprocess(ctx.UserContext(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return utils.SimpleErrorResponse(ctx, http.StatusNotFound, "Log webhook tidak ditemukan", "")
+		case errors.Is(err, services.ErrWebhookNotReprocessable):
+			return utils.SimpleErrorResponse(ctx, http.StatusBadRequest, err.Error(), "")
+		case errors.Is(err, services.ErrWebhookReplay):
+			return utils.SimpleErrorResponse(ctx, http.StatusConflict, "Event sudah diterima lewat webhook lain", "")
+		case webhookLog == nil:
+			return utils.SimpleErrorResponse(ctx, http.StatusInternalServerError, "Gagal memproses ulang webhook", err.Error())
+		}
+		// Handler provider gagal: log tersimpan sebagai FAILED dan bisa diproses ulang lagi
+		return utils.SimpleErrorResponse(ctx, http.StatusBadGateway, "Webhook gagal diproses ulang", err.Error())
+	}
+
+	c.activityLog.Log(ctx, models.ActionUpdate, "webhook_log", "Proses ulang webhook "+webhookLog.Provider, services.WithEntity("provider_webhook_log", webhookLog.ID))
+	return utils.SuccessResponse(ctx, "Webhook diproses ulang", webhookLog)
+}
@@ -72,10 +72,18 @@ type DasborChartRevenueResponse struct {
 	Labels           []string                 `json:"labels"`
 	Series           DasborChartRevenueSeries `json:"series"`
 	TotalKeseluruhan float64                  `json:"total_keseluruhan"`
+
+	// TotalRefund refund COMPLETED di periode (basis refunded_at);
+	// TotalBersih = TotalKeseluruhan - TotalRefund
+	TotalRefund float64 `json:"total_refund"`
+	TotalBersih float64 `json:"total_bersih"`
 }
 
 type DasborChartRevenueSeries struct {
 	TotalPenjualan []float64 `json:"total_penjualan"`
+
+	Refund           []float64 `json:"refund"`
+	PendapatanBersih []float64 `json:"pendapatan_bersih"`
 }
 
 // DasborChartKategoriResponse for GET /chart-transaksi-per-kategori
@@ -97,6 +105,11 @@ type DasborKPIResponse struct {
 	StokPaletbox    int64   `json:"stok_paletbox"`
 	PaletboxTerjual int64   `json:"paletbox_terjual"`
 	Revenue         float64 `json:"revenue"`
+
+	// Refund refund COMPLETED pesanan yang tidak dibatalkan di periode;
+	// RevenueBersih = Revenue - Refund
+	Refund        float64 `json:"refund"`
+	RevenueBersih float64 `json:"revenue_bersih"`
 }
 
 // DasborStokPerKategoriResponse for GET /stok-per-kategori
@@ -0,0 +1,80 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ========================================
+// Profil pajak buyer
+// ========================================
+
+// UpsertProfilPajakRequest - NPWP boleh memakai tanda baca (01.234.567.8-901.000),
+// disimpan sebagai 15/16 digit
+type UpsertProfilPajakRequest struct {
+	NPWP           string `json:"npwp" validate:"required,max=30"`
+	NamaPerusahaan string `json:"nama_perusahaan" validate:"required,max=255"`
+	AlamatPajak    string `json:"alamat_pajak" validate:"required,max=1000"`
+}
+
+type ProfilPajakResponse struct {
+	BuyerID        string    `json:"buyer_id"`
+	NPWP           string    `json:"npwp"`
+	NamaPerusahaan string    `json:"nama_perusahaan"`
+	AlamatPajak    string    `json:"alamat_pajak"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ========================================
+// e-Faktur pesanan (admin finance)
+// ========================================
+
+// EFakturQueryParams - rentang tanggal bayar YYYY-MM-DD (WIB), default hari ini.
+// Format hanya dipakai export: csv (e-Faktur desktop) atau xml (Coretax).
+type EFakturQueryParams struct {
+	Page          int    `query:"page"`
+	PerPage       int    `query:"per_page"`
+	TanggalDari   string `query:"tanggal_dari"`
+	TanggalSampai string `query:"tanggal_sampai"`
+	Status        string `query:"status"`
+	Format        string `query:"format"`
+}
+
+// SetDefaults sets default values for query params
+func (p *EFakturQueryParams) SetDefaults() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PerPage < 1 {
+		p.PerPage = 20
+	}
+	if p.PerPage > 100 {
+		p.PerPage = 100
+	}
+}
+
+// UpdateEFakturRequest mencatat progres faktur pajak pesanan. nomor_faktur
+// wajib untuk status NOMOR_TERBIT/DIUNGGAH jika belum pernah dicatat.
+type UpdateEFakturRequest struct {
+	Status      string  `json:"status" validate:"required,oneof=BELUM NOMOR_TERBIT DIUNGGAH"`
+	NomorFaktur *string `json:"nomor_faktur" validate:"omitempty,max=30"`
+}
+
+type EFakturPesananResponse struct {
+	PesananID         string          `json:"pesanan_id"`
+	KodePesanan       string          `json:"kode_pesanan"`
+	PaidAt            *time.Time      `json:"paid_at"`
+	NamaBuyer         string          `json:"nama_buyer"`
+	NPWP              *string         `json:"npwp"`
+	NamaPerusahaan    *string         `json:"nama_perusahaan"`
+	AlamatPajak       *string         `json:"alamat_pajak"`
+	DPP               decimal.Decimal `json:"dpp"`
+	PPN               decimal.Decimal `json:"ppn"`
+	Total             decimal.Decimal `json:"total"`
+	EFakturStatus     string          `json:"efaktur_status"`
+	EFakturNomor      *string         `json:"efaktur_nomor"`
+	EFakturNomorAt    *time.Time      `json:"efaktur_nomor_at"`
+	EFakturUploadedAt *time.Time      `json:"efaktur_uploaded_at"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+// JobQueryParams query parameters untuk daftar job background (admin)
+type JobQueryParams struct {
+	Page    int    `query:"page"`
+	PerPage int    `query:"per_page"`
+	Status  string `query:"status"`
+	JobType string `query:"job_type"`
+}
+
+// SetDefaults sets default values for query params
+func (p *JobQueryParams) SetDefaults() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PerPage < 1 {
+		p.PerPage = 20
+	}
+	if p.PerPage > 100 {
+		p.PerPage = 100
+	}
+}
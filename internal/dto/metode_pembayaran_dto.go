@@ -8,6 +8,8 @@ type PaymentMethodResponse struct {
 	LogoValue *string `json:"logo_value"`
 	Urutan    int     `json:"urutan,omitempty"`    // Only for admin
 	IsActive  bool    `json:"is_active,omitempty"` // Only for admin
+	// IsManual: buyer transfer ke rekening & unggah bukti, bukan invoice Xendit
+	IsManual bool `json:"is_manual"`
 }
 
 // PaymentMethodGroupResponse - grouped response
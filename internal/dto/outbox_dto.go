@@ -0,0 +1,22 @@
+package dto
+
+// OutboxQueryParams query parameters untuk daftar pesan outbox (admin)
+type OutboxQueryParams struct {
+	Page      int    `query:"page"`
+	PerPage   int    `query:"per_page"`
+	Status    string `query:"status"`
+	EventType string `query:"event_type"`
+}
+
+// SetDefaults sets default values for query params
+func (p *OutboxQueryParams) SetDefaults() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PerPage < 1 {
+		p.PerPage = 20
+	}
+	if p.PerPage > 100 {
+		p.PerPage = 100
+	}
+}
@@ -0,0 +1,136 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// BuktiTransferQueryParams query parameters antrean review bukti transfer
+// (admin finance). Status kosong = MENUNGGU; "ALL" untuk semua status.
+type BuktiTransferQueryParams struct {
+	Page    int    `query:"page"`
+	PerPage int    `query:"per_page"`
+	Status  string `query:"status"`
+	Search  string `query:"search"`
+}
+
+// SetDefaults sets default values for query params
+func (p *BuktiTransferQueryParams) SetDefaults() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PerPage < 1 {
+		p.PerPage = 20
+	}
+	if p.PerPage > 100 {
+		p.PerPage = 100
+	}
+	if p.Status == "" {
+		p.Status = "MENUNGGU"
+	}
+}
+
+// UploadBuktiTransferRequest field form multipart upload bukti transfer buyer
+// (file dikirim terpisah di field "file")
+type UploadBuktiTransferRequest struct {
+	Jumlah          decimal.Decimal
+	BankPengirim    string
+	NamaPengirim    string
+	TanggalTransfer time.Time
+	Catatan         *string
+}
+
+// ApproveBuktiTransferRequest - jumlah diisi jika dana yang masuk ke rekening
+// berbeda dengan yang dilaporkan buyer
+type ApproveBuktiTransferRequest struct {
+	Jumlah *decimal.Decimal `json:"jumlah"`
+}
+
+type RejectBuktiTransferRequest struct {
+	Alasan string `json:"alasan" validate:"required,max=500"`
+}
+
+type BuktiTransferResponse struct {
+	ID              uuid.UUID        `json:"id"`
+	PesananID       uuid.UUID        `json:"pesanan_id"`
+	KodePesanan     string           `json:"kode_pesanan,omitempty"`
+	TotalPesanan    *decimal.Decimal `json:"total_pesanan,omitempty"`
+	BuyerID         uuid.UUID        `json:"buyer_id"`
+	NamaBuyer       string           `json:"nama_buyer,omitempty"`
+	Jumlah          decimal.Decimal  `json:"jumlah"`
+	BankPengirim    string           `json:"bank_pengirim"`
+	NamaPengirim    string           `json:"nama_pengirim"`
+	TanggalTransfer string           `json:"tanggal_transfer"`
+	Catatan         *string          `json:"catatan"`
+	ContentType     string           `json:"content_type"`
+	Status          string           `json:"status"`
+	AlasanPenolakan *string          `json:"alasan_penolakan"`
+	ReviewedBy      *uuid.UUID       `json:"reviewed_by"`
+	ReviewedAt      *time.Time       `json:"reviewed_at"`
+	PembayaranID    *uuid.UUID       `json:"pembayaran_id"`
+	CreatedAt       time.Time        `json:"created_at"`
+}
+
+// InstruksiTransferResponse rekening tujuan & sisa tagihan yang ditampilkan ke
+// buyer. Kode referensi (kode pesanan) dicantumkan di berita transfer agar
+// mutasi bank bisa dicocokkan otomatis.
+type InstruksiTransferResponse struct {
+	PesananID     uuid.UUID               `json:"pesanan_id"`
+	KodeReferensi string                  `json:"kode_referensi"`
+	Bank          string                  `json:"bank"`
+	NomorRekening string                  `json:"nomor_rekening"`
+	AtasNama      string                  `json:"atas_nama"`
+	Total         decimal.Decimal         `json:"total"`
+	SudahDibayar  decimal.Decimal         `json:"sudah_dibayar"`
+	SisaTagihan   decimal.Decimal         `json:"sisa_tagihan"`
+	PaymentStatus string                  `json:"payment_status"`
+	BisaUnggah    bool                    `json:"bisa_unggah"`
+	BuktiTransfer []BuktiTransferResponse `json:"bukti_transfer"`
+}
+
+// MutasiBankQueryParams query parameters daftar mutasi hasil impor
+type MutasiBankQueryParams struct {
+	Page    int    `query:"page"`
+	PerPage int    `query:"per_page"`
+	Status  string `query:"status"`
+}
+
+// SetDefaults sets default values for query params
+func (p *MutasiBankQueryParams) SetDefaults() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PerPage < 1 {
+		p.PerPage = 20
+	}
+	if p.PerPage > 100 {
+		p.PerPage = 100
+	}
+}
+
+type MutasiBankResponse struct {
+	ID                uuid.UUID       `json:"id"`
+	Tanggal           string          `json:"tanggal"`
+	Keterangan        string          `json:"keterangan"`
+	Jumlah            decimal.Decimal `json:"jumlah"`
+	Status            string          `json:"status"`
+	PesananID         *uuid.UUID      `json:"pesanan_id"`
+	KodePesanan       *string         `json:"kode_pesanan"`
+	BuktiTransferID   *uuid.UUID      `json:"bukti_transfer_id"`
+	PembayaranID      *uuid.UUID      `json:"pembayaran_id"`
+	CatatanPencocokan *string         `json:"catatan_pencocokan"`
+	NamaFile          *string         `json:"nama_file"`
+	CreatedAt         time.Time       `json:"created_at"`
+}
+
+// ImportMutasiBankResponse ringkasan satu kali impor CSV mutasi rekening
+type ImportMutasiBankResponse struct {
+	TotalBaris int                  `json:"total_baris"`
+	Dilewati   int                  `json:"dilewati"`
+	Duplikat   int                  `json:"duplikat"`
+	Cocok      int                  `json:"cocok"`
+	BelumCocok int                  `json:"belum_cocok"`
+	Mutasi     []MutasiBankResponse `json:"mutasi"`
+}
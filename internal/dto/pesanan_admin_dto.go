@@ -3,6 +3,8 @@ package dto
 import (
 	"time"
 
+	"project-bulky-be/internal/models"
+
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 )
@@ -76,6 +78,30 @@ type PesananAdminDetailResponse struct {
 	CatatanAdmin     *string                             `json:"catatan_admin"`
 	CreatedAt        time.Time                           `json:"created_at"`
 	UpdatedAt        time.Time                           `json:"updated_at"`
+
+	// Refund semua pengajuan refund pesanan (termasuk yang ditolak)
+	Refund []RefundResponse `json:"refund"`
+
+	// Pajak profil pajak buyer saat pesanan dibuat & progres e-Faktur
+	Pajak PesananAdminPajakResponse `json:"pajak"`
+
+	// Sumber STOREFRONT atau PANEL (dibuat admin, beserta submission formulir
+	// partai besar asalnya jika ada)
+	Sumber               string     `json:"sumber"`
+	DibuatOleh           *uuid.UUID `json:"dibuat_oleh"`
+	FormulirSubmissionID *uuid.UUID `json:"formulir_submission_id"`
+}
+
+// PesananAdminPajakResponse - npwp null jika buyer belum punya profil pajak
+// saat pesanan dibuat
+type PesananAdminPajakResponse struct {
+	NPWP              *string    `json:"npwp"`
+	NamaPerusahaan    *string    `json:"nama_perusahaan"`
+	AlamatPajak       *string    `json:"alamat_pajak"`
+	EFakturStatus     string     `json:"efaktur_status"`
+	EFakturNomor      *string    `json:"efaktur_nomor"`
+	EFakturNomorAt    *time.Time `json:"efaktur_nomor_at"`
+	EFakturUploadedAt *time.Time `json:"efaktur_uploaded_at"`
 }
 
 // PesananShippingInfo shipping booking info
@@ -85,6 +111,36 @@ type PesananShippingInfo struct {
 	TrackingNo    *string `json:"tracking_no"`
 	BookingStatus string  `json:"booking_status"`
 	BookingError  *string `json:"booking_error"`
+	// Shipments berisi satu pengiriman per gudang asal; booking_id/tracking_no
+	// di atas adalah milik pengiriman utama
+	Shipments []PesananShipmentResponse `json:"shipments"`
+}
+
+// PesananShipmentResponse satu pengiriman pesanan dari satu gudang asal
+type PesananShipmentResponse struct {
+	ID            uuid.UUID                        `json:"id"`
+	Urutan        int                              `json:"urutan"`
+	Warehouse     PesananShipmentWarehouseResponse `json:"warehouse"`
+	DeliveryType  string                           `json:"delivery_type"`
+	BookingRef    *string                          `json:"booking_ref"`
+	BookingStatus *string                          `json:"booking_status"`
+	BookingError  *string                          `json:"booking_error"`
+	TrackingURL   *string                          `json:"tracking_url"`
+	BookedAt      *time.Time                       `json:"booked_at"`
+	ShippedAt     *time.Time                       `json:"shipped_at"`
+	CompletedAt   *time.Time                       `json:"completed_at"`
+	ItemIDs       []uuid.UUID                      `json:"item_ids"`
+
+	// RencanaMuat kendaraan Deliveree terpilih & pembagian muatannya; null
+	// untuk Forwarder atau pengiriman yang belum pernah di-booking
+	RencanaMuat *models.RencanaMuat `json:"rencana_muat"`
+}
+
+// PesananShipmentWarehouseResponse gudang asal pengiriman
+type PesananShipmentWarehouseResponse struct {
+	ID   uuid.UUID `json:"id"`
+	Nama string    `json:"nama"`
+	Kota *string   `json:"kota"`
 }
 
 // RetryBookingResponse response for retry-booking endpoint
@@ -93,6 +149,8 @@ type RetryBookingResponse struct {
 	DeliveryType string  `json:"delivery_type"`
 	BookingID    *string `json:"booking_id"`
 	TrackingNo   *string `json:"tracking_no"`
+	// Shipments adalah kondisi semua pengiriman setelah retry
+	Shipments []PesananShipmentResponse `json:"shipments"`
 }
 
 // PesananAdminBuyerResponse buyer info for list
@@ -133,6 +191,7 @@ type PesananAdminItemResponse struct {
 	HargaSatuan  decimal.Decimal                `json:"harga_satuan"`
 	DiskonSatuan decimal.Decimal                `json:"diskon_satuan"`
 	Subtotal     decimal.Decimal                `json:"subtotal"`
+	PengirimanID *uuid.UUID                     `json:"pengiriman_id"`
 }
 
 // PesananAdminItemProdukResponse produk info in item
@@ -153,6 +212,11 @@ type PesananAdminPembayaranResponse struct {
 	Status           string                               `json:"status"`
 	PaidAt           *time.Time                           `json:"paid_at"`
 	XenditInvoiceID  *string                              `json:"xendit_invoice_id"`
+
+	// Payment link invoice Xendit & data transfer bank yang dicatat manual
+	XenditPaymentURL  *string `json:"xendit_payment_url"`
+	IsManual          bool    `json:"is_manual"`
+	ReferensiTransfer *string `json:"referensi_transfer"`
 }
 
 // PesananAdminMetodePembayaranResponse metode pembayaran info
@@ -202,6 +266,62 @@ type CancelPesananResponse struct {
 	RestoredProduk  int       `json:"restored_produk_count"`
 	CancelledAt     time.Time `json:"cancelled_at"`
 	CancelledBy     uuid.UUID `json:"cancelled_by"`
+	// CancelledShipments jumlah booking pengiriman yang dibatalkan di provider
+	CancelledShipments int `json:"cancelled_shipment_count"`
+}
+
+// CancelShipmentRequest request body untuk batal booking pengiriman tanpa
+// membatalkan pesanan
+type CancelShipmentRequest struct {
+	// PengirimanID kosong berarti semua pengiriman yang belum berjalan
+	PengirimanID *string `json:"pengiriman_id" validate:"omitempty,uuid"`
+	Reason       *string `json:"reason" validate:"omitempty,max=500"`
+}
+
+// RebookShipmentRequest request body untuk booking ulang satu pengiriman
+// dengan kendaraan Deliveree atau jalur Forwarder pengganti
+type RebookShipmentRequest struct {
+	PengirimanID  string  `json:"pengiriman_id" validate:"required,uuid"`
+	VehicleTypeID *int    `json:"vehicle_type_id" validate:"omitempty,min=1"`
+	DeliveryType  *string `json:"delivery_type" validate:"omitempty,oneof=FORWARDER FORWARDER_LCL"`
+	Reason        *string `json:"reason" validate:"omitempty,max=500"`
+}
+
+// ShipmentActionResponse kondisi pengiriman pesanan setelah batal/booking ulang
+type ShipmentActionResponse struct {
+	PesananID uuid.UUID                 `json:"pesanan_id"`
+	Kode      string                    `json:"kode"`
+	Shipments []PesananShipmentResponse `json:"shipments"`
+}
+
+// ProofOfDeliveryResponse bukti pengiriman Deliveree yang tersimpan untuk satu pesanan
+type ProofOfDeliveryResponse struct {
+	PesananID uuid.UUID                 `json:"pesanan_id"`
+	Kode      string                    `json:"kode"`
+	Bukti     []BuktiPengirimanResponse `json:"bukti_pengiriman"`
+}
+
+// BuktiPengirimanResponse bukti pengiriman satu booking Deliveree
+type BuktiPengirimanResponse struct {
+	ID                   uuid.UUID       `json:"id"`
+	PesananPengirimanID  uuid.UUID       `json:"pesanan_pengiriman_id"`
+	BookingRef           string          `json:"booking_ref"`
+	DeliveryStatus       *string         `json:"delivery_status"`
+	RecipientName        *string         `json:"recipient_name"`
+	RecipientPhone       *string         `json:"recipient_phone"`
+	FailedDeliveryReason *string         `json:"failed_delivery_reason"`
+	SignatureURL         *string         `json:"signature_url"`
+	DriverName           *string         `json:"driver_name"`
+	DriverPhone          *string         `json:"driver_phone"`
+	ArrivedAt            *time.Time      `json:"arrived_at"`
+	LeftAt               *time.Time      `json:"left_at"`
+	CompletedAt          *time.Time      `json:"completed_at"`
+	ParkingFees          decimal.Decimal `json:"parking_fees"`
+	TollsFees            decimal.Decimal `json:"tolls_fees"`
+	WaitingTimeFees      decimal.Decimal `json:"waiting_time_fees"`
+	TotalFees            decimal.Decimal `json:"total_fees"`
+	Currency             *string         `json:"currency"`
+	CapturedAt           time.Time       `json:"captured_at"`
 }
 
 // PesananStatisticsResponse response for pesanan statistics
@@ -0,0 +1,24 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// PesananDokumenResponse metadata invoice/nota kredit; file PDF diunduh lewat
+// endpoint download
+type PesananDokumenResponse struct {
+	ID             uuid.UUID       `json:"id"`
+	PesananID      uuid.UUID       `json:"pesanan_id"`
+	Tipe           string          `json:"tipe"`
+	Nomor          string          `json:"nomor"`
+	TahunFiskal    int             `json:"tahun_fiskal"`
+	NomorReferensi *string         `json:"nomor_referensi"`
+	Total          decimal.Decimal `json:"total"`
+	Checksum       string          `json:"checksum"`
+	Ukuran         int64           `json:"ukuran"`
+	DiterbitkanBy  *uuid.UUID      `json:"diterbitkan_by"`
+	DiterbitkanAt  time.Time       `json:"diterbitkan_at"`
+}
@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// PesananPanelResponse hasil pembuatan pesanan oleh admin dari panel. Payment
+// link (PAYMENT_LINK) dikirim admin ke buyer; pesanan TRANSFER_MANUAL langsung
+// lunas dan menunggu diproses seperti pesanan storefront.
+type PesananPanelResponse struct {
+	ID                   uuid.UUID                      `json:"id"`
+	Kode                 string                         `json:"kode"`
+	BuyerID              uuid.UUID                      `json:"buyer_id"`
+	NamaBuyer            string                         `json:"nama_buyer"`
+	FormulirSubmissionID *uuid.UUID                     `json:"formulir_submission_id"`
+	DeliveryType         string                         `json:"delivery_type"`
+	PaymentStatus        string                         `json:"payment_status"`
+	OrderStatus          string                         `json:"order_status"`
+	Items                []PesananPanelItemResponse     `json:"items"`
+	BiayaProduk          decimal.Decimal                `json:"biaya_produk"`
+	BiayaPengiriman      decimal.Decimal                `json:"biaya_pengiriman"`
+	BiayaPPN             decimal.Decimal                `json:"biaya_ppn"`
+	BiayaLainnya         decimal.Decimal                `json:"biaya_lainnya"`
+	Total                decimal.Decimal                `json:"total"`
+	ExpiredAt            *time.Time                     `json:"expired_at"`
+	Pembayaran           PesananPanelPembayaranResponse `json:"pembayaran"`
+	CreatedAt            time.Time                      `json:"created_at"`
+}
+
+// PesananPanelItemResponse - harga_normal adalah harga_sesudah_diskon produk
+// saat pesanan dibuat, sebagai pembanding harga negosiasi
+type PesananPanelItemResponse struct {
+	ProdukID    uuid.UUID       `json:"produk_id"`
+	NamaProduk  string          `json:"nama_produk"`
+	Qty         int             `json:"qty"`
+	HargaNormal decimal.Decimal `json:"harga_normal"`
+	HargaSatuan decimal.Decimal `json:"harga_satuan"`
+	Subtotal    decimal.Decimal `json:"subtotal"`
+}
+
+type PesananPanelPembayaranResponse struct {
+	ID                uuid.UUID       `json:"id"`
+	MetodeBayar       string          `json:"metode_bayar"`
+	Status            string          `json:"status"`
+	Jumlah            decimal.Decimal `json:"jumlah"`
+	PaymentURL        *string         `json:"payment_url"`
+	ReferensiTransfer *string         `json:"referensi_transfer"`
+	ExpiredAt         *time.Time      `json:"expired_at"`
+	PaidAt            *time.Time      `json:"paid_at"`
+}
@@ -0,0 +1,131 @@
+package dto
+
+import "time"
+
+// ========================================
+// Buyer - janji pickup
+// ========================================
+
+// PickupSlotQueryParams - tanggal format YYYY-MM-DD (WIB); warehouse_id kosong = semua gudang asal item pesanan
+type PickupSlotQueryParams struct {
+	Tanggal     string `query:"tanggal"`
+	WarehouseID string `query:"warehouse_id"`
+}
+
+// BookPickupAppointmentRequest - slot_mulai RFC3339, atau "2006-01-02 15:04" sebagai WIB.
+// warehouse_id wajib jika item pesanan berasal dari lebih dari satu gudang.
+type BookPickupAppointmentRequest struct {
+	WarehouseID *string `json:"warehouse_id" validate:"omitempty,uuid"`
+	SlotMulai   string  `json:"slot_mulai" validate:"required"`
+	Catatan     *string `json:"catatan" validate:"omitempty,max=500"`
+}
+
+type ReschedulePickupAppointmentRequest struct {
+	SlotMulai string  `json:"slot_mulai" validate:"required"`
+	Catatan   *string `json:"catatan" validate:"omitempty,max=500"`
+}
+
+type CancelPickupAppointmentRequest struct {
+	Alasan *string `json:"alasan" validate:"omitempty,max=255"`
+}
+
+type PickupSlotResponse struct {
+	Mulai     time.Time `json:"mulai"`
+	Selesai   time.Time `json:"selesai"`
+	Kapasitas int       `json:"kapasitas"`
+	Terisi    int       `json:"terisi"`
+	Sisa      int       `json:"sisa"`
+	Tersedia  bool      `json:"tersedia"`
+}
+
+// PickupSlotHariResponse - slot pickup satu gudang pada satu tanggal
+type PickupSlotHariResponse struct {
+	WarehouseID   string               `json:"warehouse_id"`
+	NamaWarehouse string               `json:"nama_warehouse"`
+	Tanggal       string               `json:"tanggal"`
+	IsTutup       bool                 `json:"is_tutup"`
+	Keterangan    *string              `json:"keterangan"`
+	Slot          []PickupSlotResponse `json:"slot"`
+}
+
+type PickupAppointmentResponse struct {
+	ID            string     `json:"id"`
+	PesananID     string     `json:"pesanan_id"`
+	WarehouseID   string     `json:"warehouse_id"`
+	NamaWarehouse string     `json:"nama_warehouse"`
+	SlotMulai     time.Time  `json:"slot_mulai"`
+	SlotSelesai   time.Time  `json:"slot_selesai"`
+	Status        string     `json:"status"`
+	Catatan       *string    `json:"catatan"`
+	AlasanBatal   *string    `json:"alasan_batal"`
+	CancelledAt   *time.Time `json:"cancelled_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ========================================
+// Admin - kalender & pengecualian jadwal
+// ========================================
+
+// PickupKalenderQueryParams - rentang tanggal YYYY-MM-DD (WIB), default hari ini, maksimal 31 hari
+type PickupKalenderQueryParams struct {
+	TanggalDari   string `query:"tanggal_dari"`
+	TanggalSampai string `query:"tanggal_sampai"`
+	WarehouseID   string `query:"warehouse_id"`
+	Status        string `query:"status"`
+}
+
+// PickupKalenderHariResponse - janji pickup satu gudang pada satu tanggal
+type PickupKalenderHariResponse struct {
+	Tanggal       string                       `json:"tanggal"`
+	WarehouseID   string                       `json:"warehouse_id"`
+	NamaWarehouse string                       `json:"nama_warehouse"`
+	IsTutup       bool                         `json:"is_tutup"`
+	Keterangan    *string                      `json:"keterangan"`
+	JamBuka       *string                      `json:"jam_buka"`
+	JamTutup      *string                      `json:"jam_tutup"`
+	TotalJanji    int                          `json:"total_janji"`
+	Janji         []PickupKalenderItemResponse `json:"janji"`
+}
+
+type PickupKalenderItemResponse struct {
+	ID           string    `json:"id"`
+	PesananID    string    `json:"pesanan_id"`
+	KodePesanan  string    `json:"kode_pesanan"`
+	NamaBuyer    string    `json:"nama_buyer"`
+	TeleponBuyer string    `json:"telepon_buyer"`
+	SlotMulai    time.Time `json:"slot_mulai"`
+	SlotSelesai  time.Time `json:"slot_selesai"`
+	Status       string    `json:"status"`
+	Catatan      *string   `json:"catatan"`
+}
+
+type JadwalGudangPengecualianQueryParams struct {
+	WarehouseID   string `query:"warehouse_id"`
+	TanggalDari   string `query:"tanggal_dari"`
+	TanggalSampai string `query:"tanggal_sampai"`
+}
+
+// JadwalGudangPengecualianRequest - is_tutup=true menutup gudang seharian;
+// jika false, jam_buka & jam_tutup (HH:MM) wajib dan kapasitas_slot opsional
+type JadwalGudangPengecualianRequest struct {
+	WarehouseID   string  `json:"warehouse_id" validate:"required,uuid"`
+	Tanggal       string  `json:"tanggal" validate:"required"`
+	IsTutup       bool    `json:"is_tutup"`
+	JamBuka       *string `json:"jam_buka"`
+	JamTutup      *string `json:"jam_tutup"`
+	KapasitasSlot *int    `json:"kapasitas_slot" validate:"omitempty,min=0"`
+	Keterangan    *string `json:"keterangan" validate:"omitempty,max=255"`
+}
+
+type JadwalGudangPengecualianResponse struct {
+	ID            string    `json:"id"`
+	WarehouseID   string    `json:"warehouse_id"`
+	Tanggal       string    `json:"tanggal"`
+	IsTutup       bool      `json:"is_tutup"`
+	JamBuka       *string   `json:"jam_buka"`
+	JamTutup      *string   `json:"jam_tutup"`
+	KapasitasSlot *int      `json:"kapasitas_slot"`
+	Keterangan    *string   `json:"keterangan"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
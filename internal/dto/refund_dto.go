@@ -0,0 +1,101 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// RefundQueryParams query parameters untuk daftar refund (admin finance)
+type RefundQueryParams struct {
+	Page      int    `query:"page"`
+	PerPage   int    `query:"per_page"`
+	Status    string `query:"status"`
+	PesananID string `query:"pesanan_id"`
+	BuyerID   string `query:"buyer_id"`
+}
+
+// SetDefaults sets default values for query params
+func (p *RefundQueryParams) SetDefaults() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PerPage < 1 {
+		p.PerPage = 20
+	}
+	if p.PerPage > 100 {
+		p.PerPage = 100
+	}
+}
+
+// CreateRefundRequest mengajukan refund pesanan. Items kosong berarti refund
+// penuh atas sisa semua pembayaran yang sudah lunas; pesanan SPLIT bisa
+// me-refund per pembayar lewat items.
+type CreateRefundRequest struct {
+	Alasan string                    `json:"alasan" validate:"required,max=1000"`
+	Items  []CreateRefundItemRequest `json:"items" validate:"omitempty,dive"`
+}
+
+// CreateRefundItemRequest - jumlah kosong berarti refund penuh sisa pembayaran
+type CreateRefundItemRequest struct {
+	PesananPembayaranID string           `json:"pesanan_pembayaran_id" validate:"required,uuid"`
+	Jumlah              *decimal.Decimal `json:"jumlah"`
+}
+
+// ApproveRefundRequest - manual=true jika dana sudah dikembalikan finance di
+// luar payment gateway (mis. transfer bank); refund langsung COMPLETED
+type ApproveRefundRequest struct {
+	Manual  bool    `json:"manual"`
+	Catatan *string `json:"catatan" validate:"omitempty,max=1000"`
+}
+
+type RejectRefundRequest struct {
+	Catatan string `json:"catatan" validate:"required,max=1000"`
+}
+
+type RefundResponse struct {
+	ID                  uuid.UUID       `json:"id"`
+	PesananID           uuid.UUID       `json:"pesanan_id"`
+	KodePesanan         string          `json:"kode_pesanan,omitempty"`
+	PesananPembayaranID uuid.UUID       `json:"pesanan_pembayaran_id"`
+	BuyerID             uuid.UUID       `json:"buyer_id"`
+	NamaBuyer           string          `json:"nama_buyer"`
+	Tipe                string          `json:"tipe"`
+	Jumlah              decimal.Decimal `json:"jumlah"`
+	Alasan              string          `json:"alasan"`
+	Status              string          `json:"status"`
+	IsManual            bool            `json:"is_manual"`
+	Gateway             *string         `json:"gateway"`
+	GatewayRefundID     *string         `json:"gateway_refund_id"`
+	GatewayStatus       *string         `json:"gateway_status"`
+	GatewayError        *string         `json:"gateway_error"`
+	CatatanFinance      *string         `json:"catatan_finance"`
+	RequestedBy         *uuid.UUID      `json:"requested_by"`
+	ApprovedBy          *uuid.UUID      `json:"approved_by"`
+	ApprovedAt          *time.Time      `json:"approved_at"`
+	RejectedBy          *uuid.UUID      `json:"rejected_by"`
+	RejectedAt          *time.Time      `json:"rejected_at"`
+	RefundedAt          *time.Time      `json:"refunded_at"`
+	CreatedAt           time.Time       `json:"created_at"`
+}
+
+// PesananRefundSummaryResponse refund satu pesanan beserta sisa dana per pembayaran
+type PesananRefundSummaryResponse struct {
+	PesananID     uuid.UUID                  `json:"pesanan_id"`
+	PaymentStatus string                     `json:"payment_status"`
+	TotalRefund   decimal.Decimal            `json:"total_refund"`
+	Pembayaran    []RefundPembayaranResponse `json:"pembayaran"`
+	Refund        []RefundResponse           `json:"refund"`
+}
+
+// RefundPembayaranResponse sisa dana yang masih bisa di-refund per pembayaran
+type RefundPembayaranResponse struct {
+	ID           uuid.UUID       `json:"id"`
+	BuyerID      uuid.UUID       `json:"buyer_id"`
+	NamaPembayar string          `json:"nama_pembayar"`
+	Status       string          `json:"status"`
+	Jumlah       decimal.Decimal `json:"jumlah"`
+	Direfund     decimal.Decimal `json:"direfund"`
+	SisaRefund   decimal.Decimal `json:"sisa_refund"`
+}
@@ -8,20 +8,22 @@ import (
 
 // CreateRoleRequest DTO untuk membuat role baru
 type CreateRoleRequest struct {
-	Nama          string   `json:"nama" binding:"required,min=3,max=100"`
-	Kode          string   `json:"kode" binding:"required,min=3,max=50,uppercase_snake"`
-	Deskripsi     string   `json:"deskripsi" binding:"max=500"`
-	IsActive      *bool    `json:"is_active"`
-	PermissionIDs []string `json:"permission_ids" binding:"dive,uuid"`
+	Nama             string   `json:"nama" binding:"required,min=3,max=100"`
+	Kode             string   `json:"kode" binding:"required,min=3,max=50,uppercase_snake"`
+	Deskripsi        string   `json:"deskripsi" binding:"max=500"`
+	IsActive         *bool    `json:"is_active"`
+	RequireTwoFactor *bool    `json:"require_two_factor"` // wajibkan 2FA untuk admin dengan role ini
+	PermissionIDs    []string `json:"permission_ids" binding:"dive,uuid"`
 }
 
 // UpdateRoleRequest DTO untuk update role
 type UpdateRoleRequest struct {
-	Nama          string    `json:"nama" binding:"omitempty,min=3,max=100"`
-	Kode          string    `json:"kode" binding:"omitempty,min=3,max=50,uppercase_snake"`
-	Deskripsi     *string   `json:"deskripsi" binding:"omitempty,max=500"`
-	IsActive      *bool     `json:"is_active"`
-	PermissionIDs *[]string `json:"permission_ids" binding:"omitempty,dive,uuid"`
+	Nama             string    `json:"nama" binding:"omitempty,min=3,max=100"`
+	Kode             string    `json:"kode" binding:"omitempty,min=3,max=50,uppercase_snake"`
+	Deskripsi        *string   `json:"deskripsi" binding:"omitempty,max=500"`
+	IsActive         *bool     `json:"is_active"`
+	RequireTwoFactor *bool     `json:"require_two_factor"` // wajibkan 2FA untuk admin dengan role ini
+	PermissionIDs    *[]string `json:"permission_ids" binding:"omitempty,dive,uuid"`
 }
 
 // RoleQueryParams untuk query parameter list role
@@ -8,6 +8,10 @@ type JadwalGudangResponse struct {
 	JamBuka  *string `json:"jam_buka"`
 	JamTutup *string `json:"jam_tutup"`
 	IsBuka   bool    `json:"is_buka"`
+
+	// Pengaturan slot janji pickup
+	DurasiSlotMenit int `json:"durasi_slot_menit"`
+	KapasitasSlot   int `json:"kapasitas_slot"`
 }
 
 // WarehouseResponse - untuk admin panel (simplified - removed slug, telepon, is_active)
@@ -69,4 +73,8 @@ type JadwalItem struct {
 	JamBuka  *string `json:"jam_buka"`
 	JamTutup *string `json:"jam_tutup"`
 	IsBuka   bool    `json:"is_buka"`
+
+	// Opsional; default 60 menit & 5 pesanan per slot
+	DurasiSlotMenit *int `json:"durasi_slot_menit"`
+	KapasitasSlot   *int `json:"kapasitas_slot"`
 }
@@ -0,0 +1,24 @@
+package dto
+
+// WebhookLogQueryParams query parameters untuk daftar log webhook provider (admin)
+type WebhookLogQueryParams struct {
+	Page          int    `query:"page"`
+	PerPage       int    `query:"per_page"`
+	Provider      string `query:"provider"`
+	Verification  string `query:"verification"`
+	ProcessStatus string `query:"process_status"`
+	BookingRef    string `query:"booking_ref"`
+}
+
+// SetDefaults sets default values for query params
+func (p *WebhookLogQueryParams) SetDefaults() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PerPage < 1 {
+		p.PerPage = 20
+	}
+	if p.PerPage > 100 {
+		p.PerPage = 100
+	}
+}
@@ -1,14 +1,45 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"strings"
 
+	"project-bulky-be/internal/models"
 	"project-bulky-be/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// SessionValidator memeriksa apakah session yang terikat ke token (claim sid)
+// masih aktif. Diimplementasikan oleh services.SessionService.
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, claims *utils.JWTClaims) error
+}
+
+var sessionValidator SessionValidator
+
+// SetSessionValidator mendaftarkan validator session yang dipakai AuthMiddleware.
+// Dipanggil sekali saat startup (lihat cmd/api/main.go).
+func SetSessionValidator(v SessionValidator) {
+	sessionValidator = v
+}
+
+// PermissionResolver me-resolve role & permission admin secara live dari
+// database (lewat cache). Diimplementasikan oleh services.PermissionCache.
+type PermissionResolver interface {
+	ResolveAdminAccess(ctx context.Context, adminID string) (*models.AdminAccess, error)
+}
+
+var permissionResolver PermissionResolver
+
+// SetPermissionResolver mendaftarkan resolver permission yang dipakai
+// RequirePermission dan turunannya. Dipanggil sekali saat startup.
+func SetPermissionResolver(r PermissionResolver) {
+	permissionResolver = r
+}
+
 // AuthMiddleware validates JWT token and sets user context
 func AuthMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -39,17 +70,22 @@ func AuthMiddleware() fiber.Handler {
 			})
 		}
 
+		// Tolak token yang session-nya sudah di-revoke (logout, ganti password,
+		// akun dinonaktifkan/dihapus) walaupun JWT-nya belum expired.
+		if sessionValidator != nil {
+			if err := sessionValidator.ValidateSession(c.UserContext(), claims); err != nil {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"success": false,
+					"message": "Sesi tidak valid atau sudah berakhir, silakan login ulang",
+				})
+			}
+		}
+
 		// Set user context
 		c.Locals("user_id", claims.UserID)
 		c.Locals("user_type", claims.UserType)
 		c.Locals("user_email", claims.Email)
-
-		// Set permissions dan role untuk Admin
-		if claims.UserType == "ADMIN" {
-			c.Locals("user_role_id", claims.RoleID)
-			c.Locals("user_role_kode", claims.RoleKode)
-			c.Locals("user_permissions", claims.Permissions)
-		}
+		c.Locals("session_id", claims.SessionID)
 
 		// Set legacy context for backward compatibility
 		if claims.AdminID != "" {
@@ -113,32 +149,21 @@ func RequirePermission(permission string) fiber.Handler {
 			})
 		}
 
-		// 2. Get permissions dari context
-		permissions := c.Locals("user_permissions")
-		if permissions == nil {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"success": false,
-				"message": "Akses ditolak. Permission tidak ditemukan.",
-			})
+		// 2. Resolve permission live dari role admin
+		access, err := resolveAdminAccess(c)
+		if err != nil {
+			return permissionUnavailable(c, err)
 		}
-
-		// 3. Cek apakah punya permission yang dibutuhkan
-		perms, ok := permissions.([]string)
-		if !ok {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"success": false,
-				"message": "Error parsing permissions",
-			})
+		if access.TwoFactorSetupRequired {
+			return twoFactorSetupRequired(c)
 		}
 
-		// 4. Loop cek permission
-		for _, p := range perms {
-			if p == permission {
-				return c.Next()
-			}
+		// 3. Cek apakah punya permission yang dibutuhkan
+		if access.HasPermission(permission) {
+			return c.Next()
 		}
 
-		// 5. Permission tidak ditemukan
+		// 4. Permission tidak ditemukan
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"success": false,
 			"message": fmt.Sprintf("Akses ditolak. Anda tidak memiliki permission: %s", permission),
@@ -158,23 +183,19 @@ func RequireAnyPermission(permissions ...string) fiber.Handler {
 			})
 		}
 
-		// 2. Get permissions dari context
-		userPermissions := c.Locals("user_permissions")
-		if userPermissions == nil {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"success": false,
-				"message": "Akses ditolak. Permission tidak ditemukan.",
-			})
+		// 2. Resolve permission live dari role admin
+		access, err := resolveAdminAccess(c)
+		if err != nil {
+			return permissionUnavailable(c, err)
+		}
+		if access.TwoFactorSetupRequired {
+			return twoFactorSetupRequired(c)
 		}
-
-		perms := userPermissions.([]string)
 
 		// 3. Cek apakah punya salah satu permission
 		for _, requiredPerm := range permissions {
-			for _, userPerm := range perms {
-				if userPerm == requiredPerm {
-					return c.Next()
-				}
+			if access.HasPermission(requiredPerm) {
+				return c.Next()
 			}
 		}
 
@@ -207,25 +228,19 @@ func RequireAllPermissions(requiredPermissions ...string) fiber.Handler {
 			})
 		}
 
-		// 2. Get permissions dari context
-		permissions := c.Locals("user_permissions")
-		if permissions == nil {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"success": false,
-				"message": "Akses ditolak. Permission tidak ditemukan.",
-			})
+		// 2. Resolve permission live dari role admin
+		access, err := resolveAdminAccess(c)
+		if err != nil {
+			return permissionUnavailable(c, err)
 		}
-
-		perms := permissions.([]string)
-		permMap := make(map[string]bool)
-		for _, p := range perms {
-			permMap[p] = true
+		if access.TwoFactorSetupRequired {
+			return twoFactorSetupRequired(c)
 		}
 
 		// 3. Cek semua permission harus ada
 		var missingPerms []string
 		for _, required := range requiredPermissions {
-			if !permMap[required] {
+			if !access.HasPermission(required) {
 				missingPerms = append(missingPerms, required)
 			}
 		}
@@ -266,8 +281,15 @@ func SuperAdminOnly() fiber.Handler {
 			})
 		}
 
-		roleKode := c.Locals("user_role_kode")
-		if roleKode == nil || roleKode.(string) != "SUPER_ADMIN" {
+		access, err := resolveAdminAccess(c)
+		if err != nil {
+			return permissionUnavailable(c, err)
+		}
+		if access.TwoFactorSetupRequired {
+			return twoFactorSetupRequired(c)
+		}
+
+		if access.RoleKode != models.RoleSuperAdmin {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"success": false,
 				"message": "Akses ditolak. Endpoint ini hanya dapat diakses oleh Super Admin.",
@@ -277,3 +299,46 @@ func SuperAdminOnly() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// resolveAdminAccess me-resolve role & permission admin yang sedang login.
+// Hasilnya disimpan di Locals agar beberapa middleware dalam satu request
+// hanya me-resolve sekali.
+func resolveAdminAccess(c *fiber.Ctx) (*models.AdminAccess, error) {
+	if access, ok := c.Locals("admin_access").(*models.AdminAccess); ok {
+		return access, nil
+	}
+	if permissionResolver == nil {
+		return nil, fmt.Errorf("permission resolver belum dikonfigurasi")
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	access, err := permissionResolver.ResolveAdminAccess(c.UserContext(), userID)
+	if err != nil {
+		return nil, err
+	}
+	c.Locals("admin_access", access)
+	return access, nil
+}
+
+// permissionUnavailable dipakai saat permission admin gagal di-resolve
+// (admin nonaktif/terhapus atau database error).
+func permissionUnavailable(c *fiber.Ctx, err error) error {
+	log.Printf("[auth] gagal resolve permission admin %v: %v", c.Locals("user_id"), err)
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"success": false,
+		"message": "Akses ditolak. Permission tidak ditemukan.",
+	})
+}
+
+// twoFactorSetupRequired menolak admin yang role-nya mewajibkan 2FA tetapi
+// belum mengaktifkannya. Endpoint /auth/2fa/* tidak memakai guard permission
+// sehingga setup tetap bisa dilakukan.
+func twoFactorSetupRequired(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"success": false,
+		"message": "Akses ditolak. Role Anda mewajibkan 2FA, silakan aktifkan 2FA terlebih dahulu.",
+		"data": fiber.Map{
+			"two_factor_setup_required": true,
+		},
+	})
+}
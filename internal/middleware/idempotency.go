@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// IdempotencyKeyHeader adalah header yang dikirim client untuk menandai
+// request yang aman diulang
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyReplayedHeader ditambahkan pada respons yang dikirim ulang dari
+// penyimpanan, bukan hasil memproses request
+const IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyStore menyimpan respons pertama per Idempotency-Key.
+// Diimplementasikan oleh services.IdempotencyService.
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, record *models.IdempotencyKey) (existing *models.IdempotencyKey, err error)
+	Complete(ctx context.Context, id uuid.UUID, responseStatus int, contentType string, body []byte) error
+	Release(ctx context.Context, id uuid.UUID) error
+}
+
+var idempotencyStore IdempotencyStore
+
+// SetIdempotencyStore mendaftarkan store yang dipakai Idempotency dan
+// RunIdempotent. Dipanggil sekali saat startup (lihat cmd/api/main.go).
+func SetIdempotencyStore(s IdempotencyStore) {
+	idempotencyStore = s
+}
+
+// Idempotency menyimpan respons request POST/PUT/PATCH/DELETE yang membawa
+// header Idempotency-Key, lalu mengirim ulang respons tersebut untuk request
+// berikutnya dengan key yang sama (per user + route) selama TTL. Key yang
+// sama dengan body berbeda ditolak 422. Request tanpa header diproses biasa.
+// Dipasang setelah AuthMiddleware agar key terikat ke user, dan setelah
+// RequirePermission agar request yang ditolak tidak pernah memesan key.
+// Jangan dipasang pada route yang responsnya berisi rahasia (setup 2FA,
+// recovery code, password) karena respons disimpan apa adanya.
+func Idempotency() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := strings.TrimSpace(c.Get(IdempotencyKeyHeader))
+		if key == "" || !isMutatingMethod(c.Method()) {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": fmt.Sprintf("Idempotency-Key maksimal %d karakter", maxIdempotencyKeyLength),
+			})
+		}
+
+		scope := fmt.Sprintf("%v:%v", c.Locals("user_type"), c.Locals("user_id"))
+		return RunIdempotent(c, scope, key, hashRequestBody(c.Body()), c.Next)
+	}
+}
+
+// RunIdempotent menjalankan handler paling banyak sekali per (scope, method,
+// route, key). fingerprint mewakili isi request; key yang sama dengan
+// fingerprint berbeda ditolak. Respons 5xx, 401/403 dan error tidak disimpan
+// agar request bisa diulang (misalnya setelah permission diberikan).
+func RunIdempotent(c *fiber.Ctx, scope, key, fingerprint string, handler func() error) error {
+	if idempotencyStore == nil || key == "" {
+		return handler()
+	}
+
+	ctx := c.UserContext()
+	record := &models.IdempotencyKey{
+		Scope:       scope,
+		Method:      c.Method(),
+		Route:       c.Path(),
+		RequestKey:  key,
+		RequestHash: fingerprint,
+	}
+
+	existing, err := idempotencyStore.Reserve(ctx, record)
+	if err != nil {
+		log.Printf("[idempotency] gagal reserve key %s: %v", key, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Gagal memeriksa Idempotency-Key",
+		})
+	}
+	if existing != nil {
+		return replayIdempotent(c, existing, fingerprint)
+	}
+
+	if err := handler(); err != nil {
+		releaseIdempotent(ctx, record.ID)
+		return err
+	}
+
+	status := c.Response().StatusCode()
+	if status >= fiber.StatusInternalServerError || status == fiber.StatusUnauthorized || status == fiber.StatusForbidden {
+		releaseIdempotent(ctx, record.ID)
+		return nil
+	}
+
+	body := append([]byte(nil), c.Response().Body()...)
+	contentType := string(c.Response().Header.ContentType())
+	if err := idempotencyStore.Complete(ctx, record.ID, status, contentType, body); err != nil {
+		log.Printf("[idempotency] gagal menyimpan respons key %s: %v", key, err)
+	}
+	return nil
+}
+
+func replayIdempotent(c *fiber.Ctx, existing *models.IdempotencyKey, fingerprint string) error {
+	if existing.RequestHash != fingerprint {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"success": false,
+			"message": "Idempotency-Key sudah dipakai untuk request yang berbeda",
+		})
+	}
+	if existing.Status != models.IdempotencyStatusCompleted || existing.ResponseStatus == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"message": "Request dengan Idempotency-Key yang sama masih diproses",
+		})
+	}
+
+	c.Set(IdempotencyReplayedHeader, "true")
+	if existing.ResponseContentType != nil && *existing.ResponseContentType != "" {
+		c.Set(fiber.HeaderContentType, *existing.ResponseContentType)
+	}
+	return c.Status(*existing.ResponseStatus).Send(existing.ResponseBody)
+}
+
+func releaseIdempotent(ctx context.Context, id uuid.UUID) {
+	if err := idempotencyStore.Release(ctx, id); err != nil {
+		log.Printf("[idempotency] gagal melepas key %s: %v", id, err)
+	}
+}
+
+func hashRequestBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// memoryIdempotencyStore menyimpan key di memori, cukup untuk satu test
+type memoryIdempotencyStore struct {
+	records map[string]*models.IdempotencyKey
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, record *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	k := record.Scope + record.Method + record.Route + record.RequestKey
+	if existing, ok := s.records[k]; ok {
+		return existing, nil
+	}
+	record.ID = uuid.New()
+	record.Status = models.IdempotencyStatusInProgress
+	s.records[k] = record
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, id uuid.UUID, responseStatus int, contentType string, body []byte) error {
+	for _, r := range s.records {
+		if r.ID == id {
+			r.Status = models.IdempotencyStatusCompleted
+			r.ResponseStatus = &responseStatus
+			r.ResponseContentType = &contentType
+			r.ResponseBody = body
+		}
+	}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, id uuid.UUID) error {
+	for k, r := range s.records {
+		if r.ID == id {
+			delete(s.records, k)
+		}
+	}
+	return nil
+}
+
+func TestIdempotencyReplaysFirstResponse(t *testing.T) {
+	SetIdempotencyStore(&memoryIdempotencyStore{records: map[string]*models.IdempotencyKey{}})
+	defer SetIdempotencyStore(nil)
+
+	calls := 0
+	app := fiber.New()
+	app.Post("/retry-booking", func(c *fiber.Ctx) error {
+		c.Locals("user_type", "admin")
+		c.Locals("user_id", "admin-1")
+		return c.Next()
+	}, Idempotency(), func(c *fiber.Ctx) error {
+		calls++
+		if calls > 1 {
+			return c.Status(fiber.StatusConflict).SendString("booking sudah berjalan")
+		}
+		return c.Status(fiber.StatusAccepted).SendString("booking dijadwalkan")
+	})
+
+	send := func(key, body string) (int, string, string) {
+		req := httptest.NewRequest(fiber.MethodPost, "/retry-booking", strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, key)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(b), resp.Header.Get(IdempotencyReplayedHeader)
+	}
+
+	status, body, _ := send("klik-1", `{"a":1}`)
+	if status != fiber.StatusAccepted || body != "booking dijadwalkan" {
+		t.Fatalf("request pertama harus diproses, got %d %q", status, body)
+	}
+
+	// Double-click: handler tidak dipanggil lagi, respons pertama dikirim ulang
+	status, body, replayed := send("klik-1", `{"a":1}`)
+	if calls != 1 || status != fiber.StatusAccepted || body != "booking dijadwalkan" || replayed != "true" {
+		t.Fatalf("request ulang harus mendapat respons tersimpan, got calls=%d %d %q replayed=%q", calls, status, body, replayed)
+	}
+
+	// Key yang sama dengan body berbeda ditolak
+	if status, _, _ := send("klik-1", `{"a":2}`); status != fiber.StatusUnprocessableEntity {
+		t.Fatalf("key dengan body berbeda harus ditolak 422, got %d", status)
+	}
+
+	// Key baru diproses normal
+	if status, _, _ := send("klik-2", `{"a":1}`); status != fiber.StatusConflict || calls != 2 {
+		t.Fatalf("key baru harus diproses handler, got %d calls=%d", status, calls)
+	}
+}
+
+func TestIdempotencySkipsForbiddenResponse(t *testing.T) {
+	SetIdempotencyStore(&memoryIdempotencyStore{records: map[string]*models.IdempotencyKey{}})
+	defer SetIdempotencyStore(nil)
+
+	allowed := false
+	app := fiber.New()
+	app.Post("/pesanan", func(c *fiber.Ctx) error {
+		c.Locals("user_type", "admin")
+		c.Locals("user_id", "admin-1")
+		return c.Next()
+	}, Idempotency(), func(c *fiber.Ctx) error {
+		if !allowed {
+			return c.Status(fiber.StatusForbidden).SendString("tidak punya akses")
+		}
+		return c.Status(fiber.StatusCreated).SendString("dibuat")
+	})
+
+	send := func() int {
+		req := httptest.NewRequest(fiber.MethodPost, "/pesanan", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "klik-1")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := send(); status != fiber.StatusForbidden {
+		t.Fatalf("request tanpa akses harus 403, got %d", status)
+	}
+	// Setelah permission diberikan, key yang sama diproses, bukan 403 tersimpan
+	allowed = true
+	if status := send(); status != fiber.StatusCreated {
+		t.Fatalf("respons 403 tidak boleh disimpan, got %d", status)
+	}
+}
@@ -13,6 +13,10 @@ const (
 	ActionLogin        ActivityAction = "LOGIN"
 	ActionLogout       ActivityAction = "LOGOUT"
 	ActionLoginFailed  ActivityAction = "LOGIN_FAILED"
+	ActionTokenRefresh ActivityAction = "TOKEN_REFRESH"
+	ActionTokenReuse   ActivityAction = "TOKEN_REUSE"
+	ActionLock         ActivityAction = "LOCK"
+	ActionUnlock       ActivityAction = "UNLOCK"
 	ActionCreate       ActivityAction = "CREATE"
 	ActionUpdate       ActivityAction = "UPDATE"
 	ActionDelete       ActivityAction = "DELETE"
@@ -19,6 +19,13 @@ type Admin struct {
 	UpdatedAt   time.Time      `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"type:timestamptz;index" json:"-"`
 
+	// Two-factor authentication (TOTP)
+	TwoFactorEnabled       bool       `gorm:"not null;default:false" json:"two_factor_enabled"`
+	TwoFactorSecret        *string    `gorm:"type:varchar(255)" json:"-"` // terenkripsi, lihat utils.EncryptString
+	TwoFactorConfirmedAt   *time.Time `gorm:"type:timestamptz" json:"two_factor_confirmed_at"`
+	TwoFactorRecoveryCodes StringList `gorm:"type:jsonb" json:"-"` // SHA-256 hex recovery code yang belum dipakai
+	TwoFactorLastStep      *int64     `gorm:"type:bigint" json:"-"`
+
 	// Relations
 	Role *Role `gorm:"foreignKey:RoleID" json:"role,omitempty"`
 }
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Alasan revoke session
+const (
+	SessionRevokeLogout             = "LOGOUT"
+	SessionRevokePasswordChanged    = "PASSWORD_CHANGED"
+	SessionRevokePasswordReset      = "PASSWORD_RESET"
+	SessionRevokeAccountDeactivated = "ACCOUNT_DEACTIVATED"
+	SessionRevokeAccountDeleted     = "ACCOUNT_DELETED"
+	SessionRevokeRefreshTokenReuse  = "REFRESH_TOKEN_REUSE"
+	SessionRevokeTwoFactorReset     = "TWO_FACTOR_RESET"
+)
+
+// AuthSession adalah session login server-side. Setiap access token membawa
+// claim sid = ID session ini, sehingga token bisa dicabut sebelum expired.
+type AuthSession struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserType      UserType   `gorm:"type:varchar(20);not null" json:"user_type"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	IPAddress     *string    `gorm:"type:varchar(45)" json:"ip_address"`
+	UserAgent     *string    `gorm:"type:varchar(500)" json:"user_agent"`
+	ExpiresAt     time.Time  `gorm:"type:timestamptz;not null" json:"expires_at"`
+	RevokedAt     *time.Time `gorm:"type:timestamptz" json:"revoked_at"`
+	RevokedReason *string    `gorm:"type:varchar(50)" json:"revoked_reason"`
+	CreatedAt     time.Time  `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+}
+
+func (AuthSession) TableName() string {
+	return "auth_session"
+}
+
+// IsActive true jika session belum di-revoke dan belum expired
+func (s *AuthSession) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type JobStatus string
+
+const (
+	JobStatusPending JobStatus = "PENDING"
+	JobStatusRunning JobStatus = "RUNNING"
+	JobStatusDone    JobStatus = "DONE"
+	JobStatusDead    JobStatus = "DEAD"
+)
+
+// Jenis job background. Setiap jenis punya satu handler di job queue.
+const (
+	// JobTypeShippingBooking: booking pengiriman ke provider (Deliveree/Forwarder)
+	JobTypeShippingBooking = "shipping.booking"
+	// JobTypeVideoTranscode: transcode video upload ke MP4 streamable
+	JobTypeVideoTranscode = "video.transcode"
+	// JobTypeProdukAutoArchive: arsipkan produk terjual (job cron)
+	JobTypeProdukAutoArchive = "produk.auto_archive"
+	// JobTypeShippingSandboxWebhook: webhook simulasi provider sandbox
+	JobTypeShippingSandboxWebhook = "shipping.sandbox_webhook"
+	// JobTypeShippingQuotePurge: hapus quote ongkir kedaluwarsa (job cron)
+	JobTypeShippingQuotePurge = "shipping.quote_purge"
+	// JobTypeShippingTrackingPoll: poll tracking pengiriman Forwarder (job cron)
+	JobTypeShippingTrackingPoll = "shipping.tracking_poll"
+	// JobTypeShippingProofOfDelivery: simpan bukti pengiriman Deliveree saat pesanan COMPLETED
+	JobTypeShippingProofOfDelivery = "shipping.proof_of_delivery"
+	// JobTypePaymentRefundSync: cek status refund PROCESSING ke payment gateway (job cron)
+	JobTypePaymentRefundSync = "payment.refund_sync"
+	// JobTypeDokumenCreditNote: terbitkan nota kredit pesanan yang dibatalkan setelah invoice terbit
+	JobTypeDokumenCreditNote = "dokumen.credit_note"
+	// JobTypePaymentLinkInvoice: buat invoice payment link pesanan panel yang gagal dibuat setelah commit
+	JobTypePaymentLinkInvoice = "payment.link_invoice"
+	// JobTypePembayaranManualProses: pindahkan pesanan yang lunas lewat transfer manual ke PROCESSING
+	JobTypePembayaranManualProses = "payment.manual_process_order"
+)
+
+// BackgroundJob adalah satu job di antrean Postgres
+type BackgroundJob struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	JobType     string          `gorm:"type:varchar(100);not null" json:"job_type"`
+	Payload     json.RawMessage `gorm:"type:jsonb;not null" json:"payload"`
+	Status      JobStatus       `gorm:"type:varchar(20);not null;default:PENDING" json:"status"`
+	Attempts    int             `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int             `gorm:"not null;default:5" json:"max_attempts"`
+	RunAt       time.Time       `gorm:"type:timestamptz;not null" json:"run_at"`
+	LockedUntil *time.Time      `gorm:"type:timestamptz" json:"locked_until"`
+	LockedBy    *string         `gorm:"type:varchar(100)" json:"locked_by"`
+	UniqueKey   *string         `gorm:"type:varchar(200);unique" json:"unique_key"`
+	LastError   *string         `gorm:"type:text" json:"last_error"`
+	StartedAt   *time.Time      `gorm:"type:timestamptz" json:"started_at"`
+	FinishedAt  *time.Time      `gorm:"type:timestamptz" json:"finished_at"`
+	CreatedAt   time.Time       `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time       `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
+}
+
+func (BackgroundJob) TableName() string {
+	return "background_job"
+}
+
+// IsLastAttempt true jika job gagal lagi akan menjadi DEAD. Handler memakainya
+// untuk cleanup yang hanya boleh dilakukan sekali (mis. hapus file mentah).
+func (j *BackgroundJob) IsLastAttempt() bool {
+	return j.Attempts >= j.MaxAttempts
+}
+
+// JobStatusCount adalah jumlah job per jenis & status (endpoint status panel)
+type JobStatusCount struct {
+	JobType string    `json:"job_type"`
+	Status  JobStatus `json:"status"`
+	Total   int64     `json:"total"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BuyerProfilPajak adalah identitas pajak buyer PKP. Disalin ke pesanan saat
+// pesanan dibuat (trigger DB), sehingga faktur memakai data saat transaksi.
+type BuyerProfilPajak struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	BuyerID        uuid.UUID `gorm:"type:uuid;not null;unique" json:"buyer_id"`
+	NPWP           string    `gorm:"type:varchar(16);not null" json:"npwp"`
+	NamaPerusahaan string    `gorm:"type:varchar(255);not null" json:"nama_perusahaan"`
+	AlamatPajak    string    `gorm:"type:text;not null" json:"alamat_pajak"`
+	CreatedAt      time.Time `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
+}
+
+func (BuyerProfilPajak) TableName() string {
+	return "buyer_profil_pajak"
+}
+
+type EFakturStatus string
+
+const (
+	EFakturStatusBelum EFakturStatus = "BELUM"
+	// EFakturStatusNomorTerbit nomor seri faktur pajak dari DJP sudah dicatat
+	EFakturStatusNomorTerbit EFakturStatus = "NOMOR_TERBIT"
+	// EFakturStatusDiunggah faktur sudah diunggah & disetujui di e-Faktur
+	EFakturStatusDiunggah EFakturStatus = "DIUNGGAH"
+)
@@ -34,6 +34,30 @@ func (dl DualLanguage) Value() (driver.Value, error) {
 	return json.Marshal(dl)
 }
 
+// StringList untuk field JSONB berisi array string
+type StringList []string
+
+// Scan implements sql.Scanner interface
+func (sl *StringList) Scan(value interface{}) error {
+	if value == nil {
+		*sl = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, sl)
+}
+
+// Value implements driver.Valuer interface
+func (sl StringList) Value() (driver.Value, error) {
+	if sl == nil {
+		return nil, nil
+	}
+	return json.Marshal(sl)
+}
+
 // TranslatableString untuk field dual bahasa (nama)
 type TranslatableString struct {
 	ID string  `json:"id"`
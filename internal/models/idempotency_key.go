@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyStatusInProgress IdempotencyStatus = "IN_PROGRESS"
+	IdempotencyStatusCompleted  IdempotencyStatus = "COMPLETED"
+)
+
+// IdempotencyKey menyimpan respons pertama sebuah request ber-Idempotency-Key
+// agar request yang diulang tidak diproses dua kali.
+type IdempotencyKey struct {
+	ID                  uuid.UUID         `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Scope               string            `gorm:"type:varchar(100);not null;uniqueIndex:uq_idempotency_key" json:"scope"`
+	Method              string            `gorm:"type:varchar(10);not null;uniqueIndex:uq_idempotency_key" json:"method"`
+	Route               string            `gorm:"type:varchar(500);not null;uniqueIndex:uq_idempotency_key" json:"route"`
+	RequestKey          string            `gorm:"type:varchar(255);not null;uniqueIndex:uq_idempotency_key" json:"request_key"`
+	RequestHash         string            `gorm:"type:varchar(64);not null" json:"request_hash"`
+	Status              IdempotencyStatus `gorm:"type:varchar(20);not null;default:IN_PROGRESS" json:"status"`
+	ResponseStatus      *int              `json:"response_status"`
+	ResponseContentType *string           `gorm:"type:varchar(255)" json:"response_content_type"`
+	ResponseBody        []byte            `gorm:"type:bytea" json:"-"`
+	ExpiresAt           time.Time         `gorm:"type:timestamptz;not null" json:"expires_at"`
+	CreatedAt           time.Time         `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time         `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_key"
+}
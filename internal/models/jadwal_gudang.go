@@ -15,6 +15,10 @@ type JadwalGudang struct {
 	IsBuka      bool      `gorm:"default:false" json:"is_buka"`
 	CreatedAt   time.Time `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
+
+	// Slot janji pickup dibentuk dari jam buka-tutup dengan durasi & kapasitas ini
+	DurasiSlotMenit int `gorm:"not null;default:60" json:"durasi_slot_menit"`
+	KapasitasSlot   int `gorm:"not null;default:5" json:"kapasitas_slot"`
 }
 
 func (JadwalGudang) TableName() string {
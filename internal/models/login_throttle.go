@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scope counter login gagal
+const (
+	LoginThrottleScopeAccount = "ACCOUNT"
+	LoginThrottleScopeIP      = "IP"
+)
+
+// LoginThrottle menyimpan counter login gagal per akun atau per IP
+type LoginThrottle struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Scope        string     `gorm:"type:varchar(10);not null" json:"scope"`
+	UserType     UserType   `gorm:"type:varchar(20);not null" json:"user_type"`
+	Identifier   string     `gorm:"type:varchar(255);not null" json:"identifier"`
+	FailedCount  int        `gorm:"not null;default:0" json:"failed_count"`
+	LastFailedAt *time.Time `gorm:"type:timestamptz" json:"last_failed_at"`
+	BlockedUntil *time.Time `gorm:"type:timestamptz" json:"blocked_until"`
+	LockedAt     *time.Time `gorm:"type:timestamptz" json:"locked_at"`
+	CreatedAt    time.Time  `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
+}
+
+func (LoginThrottle) TableName() string {
+	return "login_throttle"
+}
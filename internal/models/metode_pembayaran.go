@@ -19,6 +19,9 @@ type MetodePembayaran struct {
 	UpdatedAt time.Time      `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"type:timestamptz;index" json:"-"`
 
+	// IsManual metode di luar Xendit (transfer bank yang dikonfirmasi finance)
+	IsManual bool `gorm:"not null;default:false" json:"is_manual"`
+
 	// Relations
 	Group MetodePembayaranGroup `gorm:"foreignKey:GroupID" json:"group,omitempty"`
 }
@@ -27,6 +30,9 @@ func (MetodePembayaran) TableName() string {
 	return "metode_pembayaran"
 }
 
+// KodeMetodeTransferManual adalah kode metode pembayaran transfer bank manual
+const KodeMetodeTransferManual = "TRANSFER_MANUAL"
+
 // Request DTOs
 type UpdateMetodePembayaranRequest struct {
 	GroupID   *string `json:"group_id" binding:"omitempty,uuid"`
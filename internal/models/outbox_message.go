@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending OutboxStatus = "PENDING"
+	OutboxStatusSent    OutboxStatus = "SENT"
+	OutboxStatusDead    OutboxStatus = "DEAD"
+)
+
+// Jenis event outbox. Setiap event punya satu handler di dispatcher.
+const (
+	// OutboxEventStorefrontSetReady: notifikasi WA buyer PICKUP lewat storefront BE
+	OutboxEventStorefrontSetReady = "storefront.pesanan_set_ready"
+	// OutboxEventEmail: kirim email berbasis template
+	OutboxEventEmail = "email.send"
+)
+
+// OutboxMessage adalah efek samping eksternal yang ditulis dalam transaksi
+// yang sama dengan perubahan data, lalu dikirim dispatcher dengan retry.
+type OutboxMessage struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	EventType     string          `gorm:"type:varchar(100);not null" json:"event_type"`
+	AggregateType string          `gorm:"type:varchar(50);not null" json:"aggregate_type"`
+	AggregateID   *uuid.UUID      `gorm:"type:uuid" json:"aggregate_id"`
+	Payload       json.RawMessage `gorm:"type:jsonb;not null" json:"payload"`
+	Status        OutboxStatus    `gorm:"type:varchar(20);not null;default:PENDING" json:"status"`
+	Attempts      int             `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts   int             `gorm:"not null;default:8" json:"max_attempts"`
+	NextAttemptAt time.Time       `gorm:"type:timestamptz;not null" json:"next_attempt_at"`
+	LockedUntil   *time.Time      `gorm:"type:timestamptz" json:"-"`
+	LastError     *string         `gorm:"type:text" json:"last_error"`
+	SentAt        *time.Time      `gorm:"type:timestamptz" json:"sent_at"`
+	CreatedAt     time.Time       `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time       `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox_message"
+}
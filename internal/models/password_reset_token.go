@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordResetToken adalah token forgot-password sekali pakai. Hanya hash
+// token yang disimpan; token mentah dikirim ke email pemilik akun.
+type PasswordResetToken struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserType    UserType   `gorm:"type:varchar(20);not null" json:"user_type"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	TokenHash   string     `gorm:"type:varchar(64);not null;unique" json:"-"`
+	ExpiresAt   time.Time  `gorm:"type:timestamptz;not null" json:"expires_at"`
+	UsedAt      *time.Time `gorm:"type:timestamptz" json:"used_at"`
+	RequestedIP *string    `gorm:"type:varchar(45)" json:"requested_ip"`
+	CreatedAt   time.Time  `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+}
+
+func (PasswordResetToken) TableName() string {
+	return "password_reset_token"
+}
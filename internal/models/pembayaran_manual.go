@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type BuktiTransferStatus string
+
+const (
+	BuktiTransferStatusMenunggu  BuktiTransferStatus = "MENUNGGU"
+	BuktiTransferStatusDisetujui BuktiTransferStatus = "DISETUJUI"
+	BuktiTransferStatusDitolak   BuktiTransferStatus = "DITOLAK"
+)
+
+// PesananBuktiTransfer adalah bukti transfer bank yang diunggah buyer. Setelah
+// disetujui finance tercatat sebagai PesananPembayaran manual (PembayaranID).
+type PesananBuktiTransfer struct {
+	ID              uuid.UUID           `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	PesananID       uuid.UUID           `gorm:"type:uuid;not null" json:"pesanan_id"`
+	BuyerID         uuid.UUID           `gorm:"type:uuid;not null" json:"buyer_id"`
+	Jumlah          decimal.Decimal     `gorm:"type:decimal(15,2);not null" json:"jumlah"`
+	BankPengirim    string              `gorm:"type:varchar(100);not null" json:"bank_pengirim"`
+	NamaPengirim    string              `gorm:"type:varchar(100);not null" json:"nama_pengirim"`
+	TanggalTransfer time.Time           `gorm:"type:date;not null" json:"tanggal_transfer"`
+	Catatan         *string             `gorm:"type:text" json:"catatan"`
+	FilePath        string              `gorm:"type:varchar(500);not null" json:"-"`
+	ContentType     string              `gorm:"type:varchar(50);not null" json:"content_type"`
+	Status          BuktiTransferStatus `gorm:"type:varchar(20);not null;default:'MENUNGGU'" json:"status"`
+	AlasanPenolakan *string             `gorm:"type:text" json:"alasan_penolakan"`
+	ReviewedBy      *uuid.UUID          `gorm:"type:uuid" json:"reviewed_by"`
+	ReviewedAt      *time.Time          `gorm:"type:timestamptz" json:"reviewed_at"`
+	PembayaranID    *uuid.UUID          `gorm:"type:uuid" json:"pembayaran_id"`
+	CreatedAt       time.Time           `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time           `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Pesanan Pesanan `gorm:"foreignKey:PesananID" json:"pesanan,omitempty"`
+	Buyer   Buyer   `gorm:"foreignKey:BuyerID" json:"buyer,omitempty"`
+}
+
+func (PesananBuktiTransfer) TableName() string {
+	return "pesanan_bukti_transfer"
+}
+
+type MutasiBankStatus string
+
+const (
+	// MutasiBankStatusCocok sudah tercatat sebagai pembayaran pesanan
+	MutasiBankStatusCocok MutasiBankStatus = "COCOK"
+	// MutasiBankStatusBelumCocok perlu dicek finance secara manual
+	MutasiBankStatusBelumCocok MutasiBankStatus = "BELUM_COCOK"
+)
+
+// MutasiBank adalah satu baris kredit mutasi rekening hasil impor CSV
+type MutasiBank struct {
+	ID                uuid.UUID        `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Tanggal           time.Time        `gorm:"type:date;not null" json:"tanggal"`
+	Keterangan        string           `gorm:"type:text;not null" json:"keterangan"`
+	Jumlah            decimal.Decimal  `gorm:"type:decimal(15,2);not null" json:"jumlah"`
+	Sidik             string           `gorm:"type:varchar(64);not null;unique" json:"-"`
+	Status            MutasiBankStatus `gorm:"type:varchar(20);not null" json:"status"`
+	PesananID         *uuid.UUID       `gorm:"type:uuid" json:"pesanan_id"`
+	BuktiTransferID   *uuid.UUID       `gorm:"type:uuid" json:"bukti_transfer_id"`
+	PembayaranID      *uuid.UUID       `gorm:"type:uuid" json:"pembayaran_id"`
+	CatatanPencocokan *string          `gorm:"type:text" json:"catatan_pencocokan"`
+	NamaFile          *string          `gorm:"type:varchar(255)" json:"nama_file"`
+	DiimporOleh       *uuid.UUID       `gorm:"type:uuid" json:"diimpor_oleh"`
+	CreatedAt         time.Time        `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+
+	// Relations
+	Pesanan *Pesanan `gorm:"foreignKey:PesananID" json:"pesanan,omitempty"`
+}
+
+func (MutasiBank) TableName() string {
+	return "mutasi_bank"
+}
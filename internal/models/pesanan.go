@@ -12,6 +12,7 @@ type DeliveryType string
 type PaymentType string
 type PaymentStatus string
 type OrderStatus string
+type PesananSumber string
 
 const (
 	DeliveryTypePickup       DeliveryType = "PICKUP"
@@ -35,6 +36,9 @@ const (
 	OrderStatusShipped    OrderStatus = "SHIPPED"
 	OrderStatusCompleted  OrderStatus = "COMPLETED"
 	OrderStatusCancelled  OrderStatus = "CANCELLED"
+
+	PesananSumberStorefront PesananSumber = "STOREFRONT"
+	PesananSumberPanel      PesananSumber = "PANEL"
 )
 
 type Pesanan struct {
@@ -80,27 +84,72 @@ type Pesanan struct {
 	UpdatedAt              time.Time      `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
 	DeletedAt              gorm.DeletedAt `gorm:"type:timestamptz;index" json:"-"`
 
+	// ShippingQuoteID adalah quote ongkir yang dipakai saat checkout; booking
+	// mencocokkan biaya_pengiriman dengan harga quote ini.
+	ShippingQuoteID *uuid.UUID `gorm:"type:uuid" json:"shipping_quote_id,omitempty"`
+
+	// Profil pajak buyer saat pesanan dibuat (disalin trigger dari
+	// buyer_profil_pajak) & status faktur pajak e-Faktur pesanan.
+	NPWPSnapshot           *string       `gorm:"column:npwp_snapshot;type:varchar(16)" json:"npwp_snapshot,omitempty"`
+	NamaPerusahaanSnapshot *string       `gorm:"column:nama_perusahaan_snapshot;type:varchar(255)" json:"nama_perusahaan_snapshot,omitempty"`
+	AlamatPajakSnapshot    *string       `gorm:"column:alamat_pajak_snapshot;type:text" json:"alamat_pajak_snapshot,omitempty"`
+	EFakturStatus          EFakturStatus `gorm:"column:efaktur_status;type:varchar(20);not null;default:'BELUM'" json:"efaktur_status"`
+	EFakturNomor           *string       `gorm:"column:efaktur_nomor;type:varchar(30)" json:"efaktur_nomor,omitempty"`
+	EFakturNomorAt         *time.Time    `gorm:"column:efaktur_nomor_at;type:timestamptz" json:"efaktur_nomor_at,omitempty"`
+	EFakturUploadedAt      *time.Time    `gorm:"column:efaktur_uploaded_at;type:timestamptz" json:"efaktur_uploaded_at,omitempty"`
+	EFakturUpdatedBy       *uuid.UUID    `gorm:"column:efaktur_updated_by;type:uuid" json:"efaktur_updated_by,omitempty"`
+
+	// Asal pesanan: checkout storefront atau dibuat admin dari panel
+	// (penjualan offline / partai besar) beserta submission formulir asalnya.
+	Sumber               PesananSumber `gorm:"type:varchar(20);not null;default:'STOREFRONT'" json:"sumber"`
+	DibuatOleh           *uuid.UUID    `gorm:"type:uuid" json:"dibuat_oleh,omitempty"`
+	FormulirSubmissionID *uuid.UUID    `gorm:"type:uuid" json:"formulir_submission_id,omitempty"`
+
 	// Relations
 	Buyer       Buyer               `gorm:"foreignKey:BuyerID" json:"buyer,omitempty"`
 	AlamatBuyer *AlamatBuyer        `gorm:"foreignKey:AlamatBuyerID" json:"alamat_buyer,omitempty"`
 	Items       []PesananItem       `gorm:"foreignKey:PesananID" json:"items,omitempty"`
 	Pembayaran  []PesananPembayaran `gorm:"foreignKey:PesananID" json:"pembayaran,omitempty"`
+	Pengiriman  []PesananPengiriman `gorm:"foreignKey:PesananID" json:"pengiriman,omitempty"`
 }
 
 func (Pesanan) TableName() string {
 	return "pesanan"
 }
 
+// Metode pembayaran pesanan yang dibuat admin dari panel
+const (
+	// MetodeBayarPaymentLink membuat invoice Xendit yang link-nya dikirim ke buyer
+	MetodeBayarPaymentLink = "PAYMENT_LINK"
+	// MetodeBayarTransferManual mencatat transfer bank yang sudah diterima
+	MetodeBayarTransferManual = "TRANSFER_MANUAL"
+)
+
 // Request DTOs
+
+// CreatePesananRequest adalah pesanan yang dibuat admin dari panel untuk buyer
+// terdaftar (penjualan offline / partai besar) dengan harga hasil negosiasi.
 type CreatePesananRequest struct {
-	BuyerID         string                     `json:"buyer_id" binding:"required,uuid"`
-	DeliveryType    string                     `json:"delivery_type" binding:"required,oneof=PICKUP DELIVEREE FORWARDER FORWARDER_LCL"`
-	AlamatBuyerID   *string                    `json:"alamat_buyer_id" binding:"omitempty,uuid"`
-	PaymentType     string                     `json:"payment_type" binding:"required,oneof=REGULAR SPLIT"`
-	Items           []CreatePesananItemRequest `json:"items" binding:"required,min=1,dive"`
-	BiayaPengiriman float64                    `json:"biaya_pengiriman"`
-	BiayaLainnya    float64                    `json:"biaya_lainnya"`
-	Catatan         *string                    `json:"catatan"`
+	BuyerID              string                     `json:"buyer_id" validate:"required,uuid"`
+	FormulirSubmissionID *string                    `json:"formulir_submission_id" validate:"omitempty,uuid"`
+	DeliveryType         string                     `json:"delivery_type" validate:"required,oneof=PICKUP DELIVEREE FORWARDER FORWARDER_LCL"`
+	AlamatBuyerID        *string                    `json:"alamat_buyer_id" validate:"omitempty,uuid"`
+	PaymentType          string                     `json:"payment_type" validate:"omitempty,oneof=REGULAR"`
+	Items                []CreatePesananItemRequest `json:"items" validate:"required,min=1,max=100,dive"`
+	BiayaPengiriman      float64                    `json:"biaya_pengiriman" validate:"min=0"`
+	BiayaLainnya         float64                    `json:"biaya_lainnya" validate:"min=0"`
+	Catatan              *string                    `json:"catatan"`
+	CatatanAdmin         *string                    `json:"catatan_admin"`
+	// ShippingQuoteID quote ongkir (POST /shipping/quote) untuk item & alamat
+	// pesanan; biaya_pengiriman tidak boleh lebih kecil dari harga quote
+	ShippingQuoteID *string `json:"shipping_quote_id" validate:"omitempty,uuid"`
+
+	// Pembayaran: PAYMENT_LINK (invoice Xendit) atau TRANSFER_MANUAL (dana
+	// sudah diterima di rekening, pesanan langsung lunas)
+	MetodeBayar        string     `json:"metode_bayar" validate:"required,oneof=PAYMENT_LINK TRANSFER_MANUAL"`
+	MetodePembayaranID *string    `json:"metode_pembayaran_id" validate:"omitempty,uuid"`
+	ReferensiTransfer  *string    `json:"referensi_transfer" validate:"required_if=MetodeBayar TRANSFER_MANUAL,omitempty,max=100"`
+	DibayarAt          *time.Time `json:"dibayar_at"`
 }
 
 type UpdatePesananRequest struct {
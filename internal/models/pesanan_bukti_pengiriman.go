@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// PesananBuktiPengiriman adalah bukti pengiriman (proof of delivery) satu
+// pengiriman Deliveree, disimpan permanen saat pesanan COMPLETED.
+type PesananBuktiPengiriman struct {
+	ID                   uuid.UUID       `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	PesananPengirimanID  uuid.UUID       `gorm:"type:uuid;not null" json:"pesanan_pengiriman_id"`
+	PesananID            uuid.UUID       `gorm:"type:uuid;not null" json:"pesanan_id"`
+	BookingRef           string          `gorm:"type:varchar(100);not null" json:"booking_ref"`
+	DeliveryStatus       *string         `gorm:"type:varchar(50)" json:"delivery_status"`
+	RecipientName        *string         `gorm:"type:varchar(255)" json:"recipient_name"`
+	RecipientPhone       *string         `gorm:"type:varchar(50)" json:"recipient_phone"`
+	FailedDeliveryReason *string         `gorm:"type:text" json:"failed_delivery_reason"`
+	SignatureSourceURL   *string         `gorm:"type:text" json:"signature_source_url"`
+	SignaturePath        *string         `gorm:"type:varchar(500)" json:"signature_path"`
+	DriverName           *string         `gorm:"type:varchar(255)" json:"driver_name"`
+	DriverPhone          *string         `gorm:"type:varchar(50)" json:"driver_phone"`
+	ArrivedAt            *time.Time      `gorm:"type:timestamptz" json:"arrived_at"`
+	LeftAt               *time.Time      `gorm:"type:timestamptz" json:"left_at"`
+	CompletedAt          *time.Time      `gorm:"type:timestamptz" json:"completed_at"`
+	ParkingFees          decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"parking_fees"`
+	TollsFees            decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"tolls_fees"`
+	WaitingTimeFees      decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"waiting_time_fees"`
+	TotalFees            decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"total_fees"`
+	Currency             *string         `gorm:"type:varchar(10)" json:"currency"`
+	RawDetail            json.RawMessage `gorm:"type:jsonb;not null" json:"raw_detail"`
+	CapturedAt           time.Time       `gorm:"type:timestamptz;not null" json:"captured_at"`
+	CreatedAt            time.Time       `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time       `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
+}
+
+func (PesananBuktiPengiriman) TableName() string {
+	return "pesanan_bukti_pengiriman"
+}
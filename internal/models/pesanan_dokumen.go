@@ -0,0 +1,130 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type DokumenTipe string
+
+const (
+	DokumenTipeInvoice DokumenTipe = "INVOICE"
+	// DokumenTipeCreditNote nota kredit yang membatalkan invoice pesanan
+	// yang dibatalkan setelah invoice terbit
+	DokumenTipeCreditNote DokumenTipe = "CREDIT_NOTE"
+)
+
+// Prefix menentukan awalan nomor dokumen (INV/2026/000001)
+func (t DokumenTipe) Prefix() string {
+	if t == DokumenTipeCreditNote {
+		return "CN"
+	}
+	return "INV"
+}
+
+// DokumenNomorUrut adalah counter nomor dokumen per tipe & tahun fiskal
+type DokumenNomorUrut struct {
+	Tipe          DokumenTipe `gorm:"type:varchar(20);primaryKey" json:"tipe"`
+	Tahun         int         `gorm:"primaryKey" json:"tahun"`
+	NomorTerakhir int         `gorm:"not null;default:0" json:"nomor_terakhir"`
+	UpdatedAt     time.Time   `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
+}
+
+func (DokumenNomorUrut) TableName() string {
+	return "dokumen_nomor_urut"
+}
+
+// PesananDokumen adalah invoice/nota kredit PDF yang sudah diterbitkan.
+// File & snapshot tidak diubah setelah terbit; unduhan ulang memakai file yang sama.
+type PesananDokumen struct {
+	ID            uuid.UUID        `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	PesananID     uuid.UUID        `gorm:"type:uuid;not null" json:"pesanan_id"`
+	Tipe          DokumenTipe      `gorm:"type:varchar(20);not null" json:"tipe"`
+	Nomor         string           `gorm:"type:varchar(30);not null;unique" json:"nomor"`
+	TahunFiskal   int              `gorm:"not null" json:"tahun_fiskal"`
+	Urutan        int              `gorm:"not null" json:"urutan"`
+	ReferensiID   *uuid.UUID       `gorm:"type:uuid" json:"referensi_id"`
+	Total         decimal.Decimal  `gorm:"type:decimal(15,2);not null" json:"total"`
+	FilePath      string           `gorm:"type:text;not null" json:"-"`
+	Checksum      string           `gorm:"type:varchar(64);not null" json:"checksum"`
+	Ukuran        int64            `gorm:"not null" json:"ukuran"`
+	Snapshot      *DokumenSnapshot `gorm:"type:jsonb;not null" json:"snapshot"`
+	DiterbitkanBy *uuid.UUID       `gorm:"type:uuid" json:"diterbitkan_by"`
+	DiterbitkanAt time.Time        `gorm:"type:timestamptz;not null" json:"diterbitkan_at"`
+	CreatedAt     time.Time        `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+}
+
+func (PesananDokumen) TableName() string {
+	return "pesanan_dokumen"
+}
+
+// DokumenSnapshot adalah data yang dicetak di PDF saat dokumen terbit
+type DokumenSnapshot struct {
+	Penjual        DokumenPihak        `json:"penjual"`
+	Pembeli        DokumenPihak        `json:"pembeli"`
+	KodePesanan    string              `json:"kode_pesanan"`
+	TanggalPesanan time.Time           `json:"tanggal_pesanan"`
+	PaymentType    string              `json:"payment_type"`
+	DeliveryType   string              `json:"delivery_type"`
+	Items          []DokumenItem       `json:"items"`
+	Kupon          []DokumenKupon      `json:"kupon"`
+	Pembayaran     []DokumenPembayaran `json:"pembayaran"`
+	BiayaProduk    decimal.Decimal     `json:"biaya_produk"`
+	PotonganKupon  decimal.Decimal     `json:"potongan_kupon"`
+	BiayaKirim     decimal.Decimal     `json:"biaya_pengiriman"`
+	BiayaLainnya   decimal.Decimal     `json:"biaya_lainnya"`
+	PPNPersentase  *decimal.Decimal    `json:"ppn_persentase"`
+	BiayaPPN       decimal.Decimal     `json:"biaya_ppn"`
+	Total          decimal.Decimal     `json:"total"`
+	NomorReferensi string              `json:"nomor_referensi,omitempty"`
+	AlasanBatal    string              `json:"alasan_batal,omitempty"`
+}
+
+// DokumenPihak identitas penjual/pembeli di dokumen
+type DokumenPihak struct {
+	Nama    string `json:"nama"`
+	NPWP    string `json:"npwp,omitempty"`
+	Alamat  string `json:"alamat,omitempty"`
+	Email   string `json:"email,omitempty"`
+	Telepon string `json:"telepon,omitempty"`
+}
+
+type DokumenItem struct {
+	NamaProduk   string          `json:"nama_produk"`
+	SKU          string          `json:"sku,omitempty"`
+	Qty          int             `json:"qty"`
+	HargaSatuan  decimal.Decimal `json:"harga_satuan"`
+	DiskonSatuan decimal.Decimal `json:"diskon_satuan"`
+	Subtotal     decimal.Decimal `json:"subtotal"`
+}
+
+type DokumenKupon struct {
+	Kode          string          `json:"kode"`
+	NilaiPotongan decimal.Decimal `json:"nilai_potongan"`
+}
+
+type DokumenPembayaran struct {
+	NamaPembayar string          `json:"nama_pembayar"`
+	Metode       string          `json:"metode"`
+	Jumlah       decimal.Decimal `json:"jumlah"`
+	Status       string          `json:"status"`
+	PaidAt       *time.Time      `json:"paid_at"`
+}
+
+// Scan implements sql.Scanner interface
+func (s *DokumenSnapshot) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// Value implements driver.Valuer interface
+func (s DokumenSnapshot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
@@ -20,6 +20,9 @@ type PesananItem struct {
 	CreatedAt    time.Time       `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
 	UpdatedAt    time.Time       `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
 
+	// PesananPengirimanID diisi saat booking: pengiriman (per gudang) yang membawa item ini
+	PesananPengirimanID *uuid.UUID `gorm:"type:uuid" json:"pesanan_pengiriman_id"`
+
 	// Relations
 	Pesanan Pesanan `gorm:"foreignKey:PesananID" json:"pesanan,omitempty"`
 	Produk  Produk  `gorm:"foreignKey:ProdukID" json:"produk,omitempty"`
@@ -31,8 +34,11 @@ func (PesananItem) TableName() string {
 
 // Request DTOs
 type CreatePesananItemRequest struct {
-	ProdukID string `json:"produk_id" binding:"required,uuid"`
-	Qty      int    `json:"qty" binding:"required,min=1"`
+	ProdukID string `json:"produk_id" validate:"required,uuid"`
+	// Qty selalu 1: setiap produk adalah satu unit (paletbox) yang ditandai is_sold
+	Qty int `json:"qty" validate:"omitempty,eq=1"`
+	// HargaSatuan harga hasil negosiasi; kosong = harga_sesudah_diskon produk
+	HargaSatuan *float64 `json:"harga_satuan" validate:"omitempty,gt=0"`
 }
 
 // Response DTOs
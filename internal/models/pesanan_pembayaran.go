@@ -23,6 +23,11 @@ type PesananPembayaran struct {
 	CreatedAt           time.Time       `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
 	UpdatedAt           time.Time       `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
 
+	// Pembayaran transfer bank yang dicatat admin (bukan lewat Xendit)
+	IsManual          bool       `gorm:"not null;default:false" json:"is_manual"`
+	ReferensiTransfer *string    `gorm:"type:varchar(100)" json:"referensi_transfer,omitempty"`
+	DicatatOleh       *uuid.UUID `gorm:"type:uuid" json:"dicatat_oleh,omitempty"`
+
 	// Relations
 	Buyer            Buyer             `gorm:"foreignKey:BuyerID" json:"buyer,omitempty"`
 	MetodePembayaran *MetodePembayaran `gorm:"foreignKey:MetodePembayaranID" json:"metode_pembayaran,omitempty"`
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PesananPengiriman adalah satu pengiriman pesanan dari satu gudang asal.
+// Item pesanan dikelompokkan per warehouse produk; setiap kelompok di-booking
+// ke provider secara terpisah.
+type PesananPengiriman struct {
+	ID            uuid.UUID    `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	PesananID     uuid.UUID    `gorm:"type:uuid;not null" json:"pesanan_id"`
+	WarehouseID   uuid.UUID    `gorm:"type:uuid;not null" json:"warehouse_id"`
+	Urutan        int          `gorm:"not null;default:1" json:"urutan"`
+	DeliveryType  DeliveryType `gorm:"type:delivery_type;not null" json:"delivery_type"`
+	BookingRef    *string      `gorm:"type:varchar(100)" json:"booking_ref"`
+	BookingStatus *string      `gorm:"type:varchar(50)" json:"booking_status"`
+	BookingError  *string      `gorm:"type:text" json:"booking_error"`
+	TrackingURL   *string      `gorm:"type:text" json:"tracking_url"`
+	BookedAt      *time.Time   `gorm:"type:timestamptz" json:"booked_at"`
+	ShippedAt     *time.Time   `gorm:"type:timestamptz" json:"shipped_at"`
+	CompletedAt   *time.Time   `gorm:"type:timestamptz" json:"completed_at"`
+	// CancelRequestedAt diisi sebelum pembatalan dikirim ke provider dan
+	// dikosongkan setelah booking dilepas; terisi berarti pembatalan terputus
+	CancelRequestedAt *time.Time `gorm:"type:timestamptz" json:"cancel_requested_at"`
+	CreatedAt         time.Time  `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time  `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
+
+	// RencanaMuat diisi sebelum booking Deliveree (nil untuk Forwarder)
+	RencanaMuat *RencanaMuat `gorm:"type:jsonb" json:"rencana_muat"`
+
+	// Relations
+	Warehouse Warehouse     `gorm:"foreignKey:WarehouseID" json:"warehouse,omitempty"`
+	Items     []PesananItem `gorm:"foreignKey:PesananPengirimanID" json:"items,omitempty"`
+}
+
+func (PesananPengiriman) TableName() string {
+	return "pesanan_pengiriman"
+}
+
+// IsBooked mengembalikan true jika pengiriman sudah punya booking di provider
+func (p *PesananPengiriman) IsBooked() bool {
+	return p.BookingRef != nil && *p.BookingRef != ""
+}
+
+// RencanaMuat adalah hasil perencanaan muatan Deliveree satu pengiriman: unit
+// barang dicek per dimensi terhadap ruang kargo lalu dibagi ke kendaraan.
+// Lebih dari satu kendaraan berarti muatan tidak muat dalam satu booking.
+type RencanaMuat struct {
+	Environment   string                 `json:"environment"`
+	TotalKubikasi float64                `json:"total_kubikasi"`
+	TotalBerat    float64                `json:"total_berat"`
+	Kendaraan     []RencanaMuatKendaraan `json:"kendaraan"`
+	// TanpaDimensi berisi nama produk yang dimensinya belum diisi sehingga
+	// tidak ikut dicek terhadap ruang kargo
+	TanpaDimensi []string  `json:"tanpa_dimensi,omitempty"`
+	DibuatAt     time.Time `json:"dibuat_at"`
+}
+
+// RencanaMuatKendaraan satu kendaraan dalam rencana muat beserta muatannya
+type RencanaMuatKendaraan struct {
+	VehicleTypeID int               `json:"vehicle_type_id"`
+	Nama          string            `json:"nama"`
+	Kubikasi      float64           `json:"kubikasi"`
+	Berat         float64           `json:"berat"`
+	Items         []RencanaMuatItem `json:"items"`
+}
+
+type RencanaMuatItem struct {
+	PesananItemID uuid.UUID `json:"pesanan_item_id"`
+	NamaProduk    string    `json:"nama_produk"`
+	Qty           int       `json:"qty"`
+}
+
+// Scan implements sql.Scanner interface
+func (r *RencanaMuat) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, r)
+}
+
+// Value implements driver.Valuer interface
+func (r RencanaMuat) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// IsSplit mengembalikan true jika muatan butuh lebih dari satu kendaraan
+func (r *RencanaMuat) IsSplit() bool {
+	return len(r.Kendaraan) > 1
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sumber event tracking pengiriman
+const (
+	ShipmentEventSourceWebhook = "WEBHOOK"
+	// ShipmentEventSourcePolling dipakai poller terjadwal dan fetch live di panel
+	ShipmentEventSourcePolling = "POLLING"
+)
+
+// PesananPengirimanEvent adalah satu event tracking pengiriman. Event yang sama
+// dari webhook/polling dideduplikasi lewat ProviderEventID per booking ref.
+type PesananPengirimanEvent struct {
+	ID                  uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	PesananPengirimanID uuid.UUID `gorm:"type:uuid;not null" json:"pesanan_pengiriman_id"`
+	PesananID           uuid.UUID `gorm:"type:uuid;not null" json:"pesanan_id"`
+	BookingRef          string    `gorm:"type:varchar(100);not null" json:"booking_ref"`
+	ProviderEventID     string    `gorm:"type:varchar(150);not null" json:"provider_event_id"`
+	Source              string    `gorm:"type:varchar(20);not null" json:"source"`
+	Status              string    `gorm:"type:varchar(255);not null" json:"status"`
+	TrackingURL         *string   `gorm:"type:text" json:"tracking_url"`
+	OccurredAt          time.Time `gorm:"type:timestamptz;not null" json:"occurred_at"`
+	CreatedAt           time.Time `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+}
+
+func (PesananPengirimanEvent) TableName() string {
+	return "pesanan_pengiriman_event"
+}
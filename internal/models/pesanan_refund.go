@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type RefundStatus string
+
+const (
+	// RefundStatusRequested menunggu persetujuan finance
+	RefundStatusRequested RefundStatus = "REQUESTED"
+	RefundStatusRejected  RefundStatus = "REJECTED"
+	// RefundStatusProcessing sudah dikirim ke payment gateway, menunggu hasil
+	RefundStatusProcessing RefundStatus = "PROCESSING"
+	RefundStatusCompleted  RefundStatus = "COMPLETED"
+	// RefundStatusFailed ditolak gateway; bisa disetujui ulang atau ditolak
+	RefundStatusFailed RefundStatus = "FAILED"
+)
+
+type RefundTipe string
+
+const (
+	RefundTipeFull    RefundTipe = "FULL"
+	RefundTipePartial RefundTipe = "PARTIAL"
+)
+
+// PesananRefund adalah refund satu pembayaran pesanan. Pesanan SPLIT punya
+// refund per pembayar karena setiap pembayar punya PesananPembayaran sendiri.
+type PesananRefund struct {
+	ID                  uuid.UUID       `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	PesananID           uuid.UUID       `gorm:"type:uuid;not null" json:"pesanan_id"`
+	PesananPembayaranID uuid.UUID       `gorm:"type:uuid;not null" json:"pesanan_pembayaran_id"`
+	BuyerID             uuid.UUID       `gorm:"type:uuid;not null" json:"buyer_id"`
+	Tipe                RefundTipe      `gorm:"type:varchar(20);not null" json:"tipe"`
+	Jumlah              decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"jumlah"`
+	Alasan              string          `gorm:"type:text;not null" json:"alasan"`
+	Status              RefundStatus    `gorm:"type:varchar(20);not null;default:'REQUESTED'" json:"status"`
+	IsManual            bool            `gorm:"not null;default:false" json:"is_manual"`
+	Gateway             *string         `gorm:"type:varchar(30)" json:"gateway"`
+	GatewayRefundID     *string         `gorm:"type:varchar(100)" json:"gateway_refund_id"`
+	GatewayStatus       *string         `gorm:"type:varchar(30)" json:"gateway_status"`
+	GatewayError        *string         `gorm:"type:text" json:"gateway_error"`
+	Percobaan           int             `gorm:"not null;default:0" json:"percobaan"`
+	CatatanFinance      *string         `gorm:"type:text" json:"catatan_finance"`
+	RequestedBy         *uuid.UUID      `gorm:"type:uuid" json:"requested_by"`
+	ApprovedBy          *uuid.UUID      `gorm:"type:uuid" json:"approved_by"`
+	ApprovedAt          *time.Time      `gorm:"type:timestamptz" json:"approved_at"`
+	RejectedBy          *uuid.UUID      `gorm:"type:uuid" json:"rejected_by"`
+	RejectedAt          *time.Time      `gorm:"type:timestamptz" json:"rejected_at"`
+	RefundedAt          *time.Time      `gorm:"type:timestamptz" json:"refunded_at"`
+	CreatedAt           time.Time       `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time       `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Pesanan    *Pesanan           `gorm:"foreignKey:PesananID" json:"pesanan,omitempty"`
+	Pembayaran *PesananPembayaran `gorm:"foreignKey:PesananPembayaranID" json:"pembayaran,omitempty"`
+	Buyer      *Buyer             `gorm:"foreignKey:BuyerID" json:"buyer,omitempty"`
+}
+
+func (PesananRefund) TableName() string {
+	return "pesanan_refund"
+}
+
+// IsReserved mengembalikan true jika jumlah refund masih memotong sisa dana
+// yang bisa di-refund (semua status kecuali REJECTED)
+func (r *PesananRefund) IsReserved() bool {
+	return r.Status != RefundStatusRejected
+}
@@ -11,6 +11,9 @@ type StatusHistoryType string
 const (
 	StatusHistoryTypeOrder   StatusHistoryType = "ORDER"
 	StatusHistoryTypePayment StatusHistoryType = "PAYMENT"
+	// StatusHistoryTypeShipment mencatat aksi pengiriman (batal booking,
+	// booking ulang) per pengiriman
+	StatusHistoryTypeShipment StatusHistoryType = "SHIPMENT"
 )
 
 type PesananStatusHistory struct {
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PickupAppointmentStatus string
+
+const (
+	PickupAppointmentBooked    PickupAppointmentStatus = "BOOKED"
+	PickupAppointmentCancelled PickupAppointmentStatus = "CANCELLED"
+	PickupAppointmentCompleted PickupAppointmentStatus = "COMPLETED"
+)
+
+// PickupAppointment adalah janji pickup buyer di satu gudang untuk pesanan PICKUP
+type PickupAppointment struct {
+	ID          uuid.UUID               `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	PesananID   uuid.UUID               `gorm:"type:uuid;not null" json:"pesanan_id"`
+	WarehouseID uuid.UUID               `gorm:"type:uuid;not null" json:"warehouse_id"`
+	BuyerID     uuid.UUID               `gorm:"type:uuid;not null" json:"buyer_id"`
+	SlotMulai   time.Time               `gorm:"type:timestamptz;not null" json:"slot_mulai"`
+	SlotSelesai time.Time               `gorm:"type:timestamptz;not null" json:"slot_selesai"`
+	Status      PickupAppointmentStatus `gorm:"type:varchar(20);not null;default:BOOKED" json:"status"`
+	Catatan     *string                 `gorm:"type:varchar(500)" json:"catatan"`
+	AlasanBatal *string                 `gorm:"type:varchar(255)" json:"alasan_batal"`
+	CancelledAt *time.Time              `gorm:"type:timestamptz" json:"cancelled_at"`
+	CreatedAt   time.Time               `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time               `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
+
+	Pesanan   *Pesanan   `gorm:"foreignKey:PesananID" json:"pesanan,omitempty"`
+	Warehouse *Warehouse `gorm:"foreignKey:WarehouseID" json:"warehouse,omitempty"`
+	Buyer     *Buyer     `gorm:"foreignKey:BuyerID" json:"buyer,omitempty"`
+}
+
+func (PickupAppointment) TableName() string {
+	return "pickup_appointment"
+}
+
+// JadwalGudangPengecualian menimpa jadwal_gudang pada satu tanggal: gudang
+// tutup seharian, atau buka dengan jam/kapasitas khusus
+type JadwalGudangPengecualian struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	WarehouseID   uuid.UUID `gorm:"type:uuid;not null" json:"warehouse_id"`
+	Tanggal       time.Time `gorm:"type:date;not null" json:"tanggal"`
+	IsTutup       bool      `gorm:"not null" json:"is_tutup"`
+	JamBuka       *string   `gorm:"type:time" json:"jam_buka"`
+	JamTutup      *string   `gorm:"type:time" json:"jam_tutup"`
+	KapasitasSlot *int      `json:"kapasitas_slot"`
+	Keterangan    *string   `gorm:"type:varchar(255)" json:"keterangan"`
+	CreatedAt     time.Time `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
+}
+
+func (JadwalGudangPengecualian) TableName() string {
+	return "jadwal_gudang_pengecualian"
+}
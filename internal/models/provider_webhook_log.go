@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Provider pengirim webhook
+const (
+	WebhookProviderDeliveree = "DELIVEREE"
+	WebhookProviderForwarder = "FORWARDER"
+)
+
+// WebhookVerification adalah hasil verifikasi request webhook
+type WebhookVerification string
+
+const (
+	WebhookVerificationAccepted         WebhookVerification = "ACCEPTED"
+	WebhookVerificationReplay           WebhookVerification = "REPLAY"
+	WebhookVerificationUnauthorized     WebhookVerification = "UNAUTHORIZED"
+	WebhookVerificationInvalidSignature WebhookVerification = "INVALID_SIGNATURE"
+	WebhookVerificationStaleTimestamp   WebhookVerification = "STALE_TIMESTAMP"
+	WebhookVerificationInvalidPayload   WebhookVerification = "INVALID_PAYLOAD"
+)
+
+// WebhookProcessStatus adalah hasil pemrosesan webhook yang lolos verifikasi
+type WebhookProcessStatus string
+
+const (
+	WebhookProcessPending WebhookProcessStatus = "PENDING"
+	// WebhookProcessProcessed: ada pesanan yang cocok & diperbarui
+	WebhookProcessProcessed WebhookProcessStatus = "PROCESSED"
+	// WebhookProcessIgnored: status tidak dikenal atau booking tidak ditemukan
+	WebhookProcessIgnored WebhookProcessStatus = "IGNORED"
+	WebhookProcessFailed  WebhookProcessStatus = "FAILED"
+	// WebhookProcessSkipped: request tidak lolos verifikasi, tidak diproses
+	WebhookProcessSkipped WebhookProcessStatus = "SKIPPED"
+)
+
+// ProviderWebhookLog adalah satu request webhook provider yang disimpan mentah
+// untuk audit. Baris ACCEPTED yang belum FAILED menjadi penanda event yang
+// sudah diterima (lihat unique index uq_provider_webhook_log_event).
+type ProviderWebhookLog struct {
+	ID             uuid.UUID            `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Provider       string               `gorm:"type:varchar(20);not null" json:"provider"`
+	EventKey       *string              `gorm:"type:varchar(128)" json:"event_key"`
+	BookingRef     *string              `gorm:"type:varchar(100)" json:"booking_ref"`
+	Status         *string              `gorm:"type:varchar(100)" json:"status"`
+	Headers        json.RawMessage      `gorm:"type:jsonb;not null" json:"headers"`
+	Body           string               `gorm:"type:text;not null" json:"body"`
+	RemoteIP       *string              `gorm:"type:varchar(64)" json:"remote_ip"`
+	Verification   WebhookVerification  `gorm:"type:varchar(30);not null" json:"verification"`
+	ProcessStatus  WebhookProcessStatus `gorm:"type:varchar(20);not null;default:SKIPPED" json:"process_status"`
+	ProcessError   *string              `gorm:"type:text" json:"process_error"`
+	ReprocessCount int                  `gorm:"not null;default:0" json:"reprocess_count"`
+	ReceivedAt     time.Time            `gorm:"type:timestamptz;not null" json:"received_at"`
+	ProcessedAt    *time.Time           `gorm:"type:timestamptz" json:"processed_at"`
+}
+
+func (ProviderWebhookLog) TableName() string {
+	return "provider_webhook_log"
+}
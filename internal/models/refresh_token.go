@@ -14,20 +14,26 @@ const (
 	UserTypeSystem UserType = "SYSTEM"
 )
 
-// RefreshToken untuk token revocation & multi-device support
+// RefreshToken adalah refresh token sekali pakai. Semua token hasil rotasi dari
+// satu login berbagi SessionID yang sama (token family = auth_session).
 type RefreshToken struct {
-	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	UserType   UserType   `gorm:"type:varchar(20);not null" json:"user_type"`
-	UserID     uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
-	Token      string     `gorm:"type:varchar(500);not null;unique" json:"token"`
-	DeviceInfo *string    `gorm:"type:varchar(255)" json:"device_info"`
-	IPAddress  *string    `gorm:"type:varchar(50)" json:"ip_address"`
-	ExpiredAt  time.Time  `gorm:"type:timestamptz;not null" json:"expired_at"`
-	IsRevoked  bool       `gorm:"default:false" json:"is_revoked"`
-	RevokedAt  *time.Time `gorm:"type:timestamptz" json:"revoked_at"`
-	CreatedAt  time.Time  `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	SessionID uuid.UUID  `gorm:"type:uuid;not null" json:"session_id"`
+	UserType  UserType   `gorm:"type:varchar(20);not null" json:"user_type"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	TokenHash string     `gorm:"type:varchar(64);not null;unique" json:"-"`
+	ParentID  *uuid.UUID `gorm:"type:uuid" json:"parent_id"`
+	ExpiresAt time.Time  `gorm:"type:timestamptz;not null" json:"expires_at"`
+	RotatedAt *time.Time `gorm:"type:timestamptz" json:"rotated_at"`
+	RevokedAt *time.Time `gorm:"type:timestamptz" json:"revoked_at"`
+	CreatedAt time.Time  `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
 }
 
 func (RefreshToken) TableName() string {
 	return "refresh_token"
 }
+
+// IsUsed true jika token sudah pernah ditukar atau family-nya sudah di-revoke
+func (t *RefreshToken) IsUsed() bool {
+	return t.RotatedAt != nil || t.RevokedAt != nil
+}
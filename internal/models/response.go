@@ -549,8 +549,11 @@ type AdminResponse struct {
 	RoleID      string     `json:"role_id"`
 	IsActive    bool       `json:"is_active"`
 	LastLoginAt *time.Time `json:"last_login_at"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"` // login diblokir (brute-force) sampai waktu ini
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
 }
 
 type AdminListResponse struct {
@@ -767,6 +770,18 @@ type FormulirSubmissionDetailResponse struct {
 	EmailSent   bool               `json:"email_sent"`
 	EmailSentAt *time.Time         `json:"email_sent_at"`
 	CreatedAt   time.Time          `json:"created_at"`
+
+	// Pesanan yang dibuat admin dari submission ini
+	Pesanan []FormulirSubmissionPesananResponse `json:"pesanan"`
+}
+
+type FormulirSubmissionPesananResponse struct {
+	ID            string    `json:"id"`
+	Kode          string    `json:"kode"`
+	OrderStatus   string    `json:"order_status"`
+	PaymentStatus string    `json:"payment_status"`
+	Total         float64   `json:"total"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 type FormulirOptionsResponse struct {
@@ -9,14 +9,15 @@ import (
 
 // Role untuk admin users
 type Role struct {
-	ID        uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	Nama      string         `gorm:"type:varchar(50);not null" json:"nama"`
-	Kode      string         `gorm:"type:varchar(30);not null;unique" json:"kode"`
-	Deskripsi *string        `gorm:"type:text" json:"deskripsi"`
-	IsActive  bool           `gorm:"default:true" json:"is_active"`
-	CreatedAt time.Time      `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time      `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"type:timestamptz;index" json:"-"`
+	ID               uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Nama             string         `gorm:"type:varchar(50);not null" json:"nama"`
+	Kode             string         `gorm:"type:varchar(30);not null;unique" json:"kode"`
+	Deskripsi        *string        `gorm:"type:text" json:"deskripsi"`
+	IsActive         bool           `gorm:"default:true" json:"is_active"`
+	RequireTwoFactor bool           `gorm:"not null;default:false" json:"require_two_factor"` // admin dengan role ini wajib 2FA
+	CreatedAt        time.Time      `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time      `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"type:timestamptz;index" json:"-"`
 
 	// Relations
 	Permissions []Permission `gorm:"many2many:role_permission;" json:"permissions,omitempty"`
@@ -24,19 +25,21 @@ type Role struct {
 
 // Response format untuk role (array sederhana)
 type RoleResponseFormat struct {
-	ID        string  `json:"id"`
-	Nama      string  `json:"nama"`
-	Kode      string  `json:"kode"`
-	Deskripsi *string `json:"deskripsi"`
+	ID               string  `json:"id"`
+	Nama             string  `json:"nama"`
+	Kode             string  `json:"kode"`
+	Deskripsi        *string `json:"deskripsi"`
+	RequireTwoFactor bool    `json:"require_two_factor"`
 }
 
 // Response format untuk role detail dengan permissions
 type RoleDetailResponse struct {
-	ID          string                     `json:"id"`
-	Nama        string                     `json:"nama"`
-	Kode        string                     `json:"kode"`
-	Deskripsi   *string                    `json:"deskripsi"`
-	Permissions []PermissionSimpleResponse `json:"permissions"`
+	ID               string                     `json:"id"`
+	Nama             string                     `json:"nama"`
+	Kode             string                     `json:"kode"`
+	Deskripsi        *string                    `json:"deskripsi"`
+	RequireTwoFactor bool                       `json:"require_two_factor"`
+	Permissions      []PermissionSimpleResponse `json:"permissions"`
 }
 
 func (Role) TableName() string {
@@ -51,3 +54,24 @@ const (
 	RoleFinance    = "FINANCE"
 	RoleMarketing  = "MARKETING"
 )
+
+// AdminAccess adalah role & permission admin yang di-resolve live dari tabel
+// role/permission (bukan dari claim JWT), dipakai middleware RequirePermission.
+type AdminAccess struct {
+	AdminID     uuid.UUID
+	RoleID      uuid.UUID
+	RoleKode    string
+	Permissions []string
+	// TwoFactorSetupRequired: role mewajibkan 2FA tetapi admin belum mendaftar
+	TwoFactorSetupRequired bool
+}
+
+// HasPermission true jika admin memiliki permission dengan kode tersebut
+func (a *AdminAccess) HasPermission(kode string) bool {
+	for _, p := range a.Permissions {
+		if p == kode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShippingCoverageZone adalah wilayah yang dilayani satu provider pengiriman.
+// Kota kosong berarti seluruh provinsi. Provider FORWARDER berarti jalur darat
+// (LTL); alamat di luar zona FORWARDER dikirim via laut (FORWARDER_LCL).
+type ShippingCoverageZone struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Provider  DeliveryType   `gorm:"type:delivery_type;not null" json:"provider"`
+	Provinsi  string         `gorm:"type:varchar(100);not null" json:"provinsi"`
+	Kota      *string        `gorm:"type:varchar(100)" json:"kota"`
+	Aliases   StringList     `gorm:"type:jsonb;not null;default:'[]'" json:"aliases"`
+	IsActive  bool           `gorm:"not null;default:true" json:"is_active"`
+	Catatan   *string        `gorm:"type:text" json:"catatan"`
+	CreatedAt time.Time      `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"type:timestamptz;autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"type:timestamptz;index" json:"-"`
+}
+
+func (ShippingCoverageZone) TableName() string {
+	return "shipping_coverage_zone"
+}
+
+// Request DTOs
+type ShippingCoverageZoneFilterRequest struct {
+	PaginationRequest
+	Provider string `query:"provider"`
+}
+
+type CreateShippingCoverageZoneRequest struct {
+	Provider string   `json:"provider" validate:"required,oneof=DELIVEREE FORWARDER"`
+	Provinsi string   `json:"provinsi" validate:"required,max=100"`
+	Kota     *string  `json:"kota" validate:"omitempty,max=100"`
+	Aliases  []string `json:"aliases" validate:"omitempty,dive,max=100"`
+	IsActive *bool    `json:"is_active"`
+	Catatan  *string  `json:"catatan"`
+}
+
+type UpdateShippingCoverageZoneRequest struct {
+	Provider *string  `json:"provider" validate:"omitempty,oneof=DELIVEREE FORWARDER"`
+	Provinsi *string  `json:"provinsi" validate:"omitempty,max=100"`
+	Kota     *string  `json:"kota" validate:"omitempty,max=100"`
+	Aliases  []string `json:"aliases" validate:"omitempty,dive,max=100"`
+	IsActive *bool    `json:"is_active"`
+	Catatan  *string  `json:"catatan"`
+}
+
+// TestShippingCoverageRequest menguji routing satu alamat: pakai alamat buyer
+// yang tersimpan (alamat_buyer_id) atau isi wilayah secara manual.
+type TestShippingCoverageRequest struct {
+	AlamatBuyerID *string `json:"alamat_buyer_id" validate:"omitempty,uuid"`
+	Provinsi      string  `json:"provinsi" validate:"max=100"`
+	Kota          string  `json:"kota" validate:"max=100"`
+	Kecamatan     *string `json:"kecamatan" validate:"omitempty,max=100"`
+}
+
+// Response DTOs
+type ShippingCoverageZoneResponse struct {
+	ID        string    `json:"id"`
+	Provider  string    `json:"provider"`
+	Provinsi  string    `json:"provinsi"`
+	Kota      *string   `json:"kota"`
+	Aliases   []string  `json:"aliases"`
+	IsActive  bool      `json:"is_active"`
+	Catatan   *string   `json:"catatan"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ShippingCoverageMatch hasil pencocokan alamat ke zona satu provider.
+// MatchedOn bernilai "kota" atau "provinsi" sesuai level zona yang cocok.
+type ShippingCoverageMatch struct {
+	Covered   bool                          `json:"covered"`
+	MatchedOn *string                       `json:"matched_on"`
+	Zone      *ShippingCoverageZoneResponse `json:"zone"`
+}
+
+// ShippingCoverageResult provider & jalur yang didapat satu alamat.
+type ShippingCoverageResult struct {
+	AlamatBuyerID *string `json:"alamat_buyer_id"`
+	Provinsi      string  `json:"provinsi"`
+	Kota          string  `json:"kota"`
+	Kecamatan     *string `json:"kecamatan"`
+	// ForwarderCity adalah mapping kota Forwarder yang dipakai saat booking;
+	// null berarti booking Forwarder akan gagal karena kota belum ter-mapping.
+	ForwarderCity *ForwarderCityMappingResponse `json:"forwarder_city"`
+	Deliveree     ShippingCoverageMatch         `json:"deliveree"`
+	Forwarder     ShippingCoverageMatch         `json:"forwarder"`
+	// ForwarderDeliveryType adalah delivery type efektif pesanan Forwarder:
+	// FORWARDER (darat/LTL) atau FORWARDER_LCL (laut).
+	ForwarderDeliveryType string `json:"forwarder_delivery_type"`
+	ForwarderRoute        string `json:"forwarder_route"`
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Sumber permintaan quote ongkir
+const (
+	ShippingQuoteSourceAdmin      = "ADMIN"
+	ShippingQuoteSourceStorefront = "STOREFRONT"
+)
+
+// ShippingQuote adalah hasil quote ongkir untuk item + alamat tujuan. Baris
+// yang sama dipakai ulang selama belum kedaluwarsa (cache tarif provider).
+type ShippingQuote struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	RequestHash   string          `gorm:"type:varchar(64);not null" json:"request_hash"`
+	Source        string          `gorm:"type:varchar(20);not null" json:"source"`
+	AlamatBuyerID *uuid.UUID      `gorm:"type:uuid" json:"alamat_buyer_id"`
+	Provinsi      string          `gorm:"type:varchar(100);not null;default:''" json:"provinsi"`
+	Kota          string          `gorm:"type:varchar(100);not null;default:''" json:"kota"`
+	Kecamatan     *string         `gorm:"type:varchar(100)" json:"kecamatan"`
+	Items         json.RawMessage `gorm:"type:jsonb;not null" json:"items"`
+	Options       json.RawMessage `gorm:"type:jsonb;not null" json:"options"`
+	ExpiresAt     time.Time       `gorm:"type:timestamptz;not null" json:"expires_at"`
+	CreatedAt     time.Time       `gorm:"type:timestamptz;autoCreateTime" json:"created_at"`
+}
+
+func (ShippingQuote) TableName() string {
+	return "shipping_quote"
+}
+
+// Request DTOs
+
+// ShippingQuoteRequest meminta quote untuk item ke satu alamat: alamat buyer
+// tersimpan (alamat_buyer_id) atau alamat yang diisi manual.
+type ShippingQuoteRequest struct {
+	AlamatBuyerID *string                    `json:"alamat_buyer_id" validate:"omitempty,uuid"`
+	Provinsi      string                     `json:"provinsi" validate:"max=100"`
+	Kota          string                     `json:"kota" validate:"max=100"`
+	Kecamatan     *string                    `json:"kecamatan" validate:"omitempty,max=100"`
+	AlamatLengkap string                     `json:"alamat_lengkap"`
+	Latitude      *float64                   `json:"latitude"`
+	Longitude     *float64                   `json:"longitude"`
+	Items         []ShippingQuoteItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+type ShippingQuoteItemRequest struct {
+	ProdukID string `json:"produk_id" validate:"required,uuid"`
+	Qty      int    `json:"qty" validate:"required,min=1"`
+}
+
+// Response DTOs
+
+type ShippingQuoteResponse struct {
+	ID        string    `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Cached bernilai true jika quote diambil dari cache (tanpa memanggil provider)
+	Cached  bool                  `json:"cached"`
+	Options []ShippingQuoteOption `json:"options"`
+}
+
+// ShippingQuoteOption adalah opsi pengiriman satu delivery type. Harga null
+// berarti provider belum menyediakan tarif via API (ongkir dihitung manual).
+type ShippingQuoteOption struct {
+	DeliveryType string  `json:"delivery_type"`
+	Provider     string  `json:"provider"`
+	Route        *string `json:"route"`
+	Available    bool    `json:"available"`
+	Reason       *string `json:"reason"`
+	// Harga adalah total ongkir semua pengiriman (satu per gudang asal)
+	Harga           *decimal.Decimal        `json:"harga"`
+	Currency        string                  `json:"currency"`
+	EstimasiHariMin int                     `json:"estimasi_hari_min"`
+	EstimasiHariMax int                     `json:"estimasi_hari_max"`
+	Shipments       []ShippingQuoteShipment `json:"shipments"`
+}
+
+// ShippingQuoteShipment rincian quote satu pengiriman dari satu gudang asal
+type ShippingQuoteShipment struct {
+	WarehouseID     uuid.UUID        `json:"warehouse_id"`
+	WarehouseNama   string           `json:"warehouse_nama"`
+	TotalKubikasi   float64          `json:"total_kubikasi"`
+	TotalBerat      float64          `json:"total_berat"`
+	BeratVolumetrik float64          `json:"berat_volumetrik"`
+	BeratTertagih   float64          `json:"berat_tertagih"`
+	VehicleTypeID   *int             `json:"vehicle_type_id"`
+	VehicleName     string           `json:"vehicle_name"`
+	Harga           *decimal.Decimal `json:"harga"`
+	Error           *string          `json:"error,omitempty"`
+}
@@ -0,0 +1,218 @@
+package orderstate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Request adalah permintaan perubahan status satu pesanan
+type Request struct {
+	PesananID uuid.UUID
+	To        models.OrderStatus
+	Trigger   Trigger
+	// ChangedBy = admin pelaku; nil untuk webhook/sistem
+	ChangedBy *uuid.UUID
+	Note      *string
+	// Extra adalah kolom pendukung yang di-update dalam transaksi yang sama
+	// (mis. booking_status/tracking_url dari webhook)
+	Extra map[string]interface{}
+}
+
+// Result adalah hasil Transition. Changed false jika pesanan sudah berada di
+// status tujuan (idempotent, hanya Extra yang diterapkan).
+type Result struct {
+	Pesanan *models.Pesanan
+	From    models.OrderStatus
+	Changed bool
+}
+
+// Hook dijalankan setelah transaksi commit saat pesanan masuk ke suatu status.
+// Hook bersifat best-effort: kegagalannya tidak membatalkan perubahan status.
+type Hook func(ctx context.Context, pesanan *models.Pesanan, from models.OrderStatus, trigger Trigger)
+
+// TxHook dijalankan di dalam transaksi perubahan status (mis. menulis pesan
+// outbox). Error dari TxHook membatalkan seluruh transisi.
+type TxHook func(tx *gorm.DB, pesanan *models.Pesanan, from models.OrderStatus, trigger Trigger) error
+
+// Machine menjalankan transisi sesuai tabel rules beserta efek sampingnya:
+// timestamp status, baris pesanan_status_history, restore produk saat batal,
+// hook in-transaction (outbox) dan hook after-commit.
+type Machine struct {
+	db      *gorm.DB
+	mu      sync.RWMutex
+	hooks   map[models.OrderStatus][]Hook
+	txHooks map[models.OrderStatus][]TxHook
+}
+
+func NewMachine(db *gorm.DB) *Machine {
+	return &Machine{
+		db:      db,
+		hooks:   map[models.OrderStatus][]Hook{},
+		txHooks: map[models.OrderStatus][]TxHook{},
+	}
+}
+
+// OnEnter mendaftarkan hook yang dipanggil setiap kali pesanan masuk ke status
+func (m *Machine) OnEnter(status models.OrderStatus, hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks[status] = append(m.hooks[status], hook)
+}
+
+// OnEnterTx mendaftarkan hook yang dipanggil di dalam transaksi saat pesanan
+// masuk ke status. Dipakai untuk efek samping yang harus atomik dengan
+// perubahan status, seperti menulis pesan outbox.
+func (m *Machine) OnEnterTx(status models.OrderStatus, hook TxHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.txHooks[status] = append(m.txHooks[status], hook)
+}
+
+// Transition memvalidasi dan menerapkan perubahan status dalam satu transaksi
+// (baris pesanan dikunci FOR UPDATE agar request paralel tidak saling timpa).
+func (m *Machine) Transition(ctx context.Context, req Request) (*Result, error) {
+	var result Result
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var pesanan models.Pesanan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pesanan, "id = ?", req.PesananID).Error; err != nil {
+			return err
+		}
+		result.From = pesanan.OrderStatus
+
+		// Idempotent: event yang sama bisa dikirim ulang provider
+		if pesanan.OrderStatus == req.To {
+			if len(req.Extra) > 0 {
+				if err := tx.Model(&models.Pesanan{}).Where("id = ?", pesanan.ID).UpdateColumns(req.Extra).Error; err != nil {
+					return err
+				}
+			}
+			result.Pesanan = &pesanan
+			return nil
+		}
+
+		if err := Check(&pesanan, req.To, req.Trigger); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"order_status": req.To}
+		for column, value := range req.Extra {
+			updates[column] = value
+		}
+		now := time.Now()
+		switch req.To {
+		case models.OrderStatusProcessing:
+			updates["processed_at"] = now
+		case models.OrderStatusReady:
+			updates["ready_at"] = now
+		case models.OrderStatusShipped:
+			updates["shipped_at"] = now
+		case models.OrderStatusCompleted:
+			updates["completed_at"] = now
+		case models.OrderStatusCancelled:
+			updates["cancelled_at"] = now
+			if req.Note != nil && *req.Note != "" {
+				updates["cancelled_reason"] = *req.Note
+			}
+		}
+
+		if err := tx.Model(&models.Pesanan{}).Where("id = ?", pesanan.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		statusFrom := string(pesanan.OrderStatus)
+		history := models.PesananStatusHistory{
+			PesananID:  pesanan.ID,
+			StatusFrom: &statusFrom,
+			StatusTo:   string(req.To),
+			StatusType: models.StatusHistoryTypeOrder,
+			ChangedBy:  req.ChangedBy,
+			Note:       req.Note,
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+
+		if req.To == models.OrderStatusCancelled {
+			if err := restoreProduk(tx, pesanan.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.First(&pesanan, "id = ?", pesanan.ID).Error; err != nil {
+			return err
+		}
+		if err := m.runTxHooks(tx, &pesanan, result.From, req.Trigger); err != nil {
+			return err
+		}
+		result.Pesanan = &pesanan
+		result.Changed = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Changed {
+		m.runHooks(ctx, result.Pesanan, result.From, req.Trigger)
+	}
+	return &result, nil
+}
+
+// runTxHooks menjalankan TxHook status tujuan secara berurutan; error pertama
+// menghentikan sisanya dan me-rollback transisi
+func (m *Machine) runTxHooks(tx *gorm.DB, pesanan *models.Pesanan, from models.OrderStatus, trigger Trigger) error {
+	m.mu.RLock()
+	hooks := append([]TxHook(nil), m.txHooks[pesanan.OrderStatus]...)
+	m.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(tx, pesanan, from, trigger); err != nil {
+			return fmt.Errorf("hook %s pesanan %s: %w", pesanan.OrderStatus, pesanan.Kode, err)
+		}
+	}
+	return nil
+}
+
+func (m *Machine) runHooks(ctx context.Context, pesanan *models.Pesanan, from models.OrderStatus, trigger Trigger) {
+	m.mu.RLock()
+	hooks := append([]Hook(nil), m.hooks[pesanan.OrderStatus]...)
+	m.mu.RUnlock()
+
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("[orderstate] hook %s panic: pesanan=%s err=%v", pesanan.OrderStatus, pesanan.Kode, r)
+				}
+			}()
+			hook(ctx, pesanan, from, trigger)
+		}()
+	}
+}
+
+// restoreProduk mengembalikan produk pesanan yang dibatalkan agar bisa dijual
+// lagi. is_active ikut direstore karena produk yang sudah SHIPPED/COMPLETED bisa
+// saja sempat diarsipkan otomatis (auto-archive job) sebelum pesanan dibatalkan.
+func restoreProduk(tx *gorm.DB, pesananID uuid.UUID) error {
+	var produkIDs []uuid.UUID
+	if err := tx.Model(&models.PesananItem{}).Where("pesanan_id = ?", pesananID).Pluck("produk_id", &produkIDs).Error; err != nil {
+		return err
+	}
+	if len(produkIDs) == 0 {
+		return nil
+	}
+	return tx.Model(&models.Produk{}).Where("id IN ?", produkIDs).Updates(map[string]interface{}{
+		"is_sold":   false,
+		"is_active": true,
+	}).Error
+}
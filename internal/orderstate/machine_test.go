@@ -0,0 +1,81 @@
+package orderstate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/testutil/fakedb"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func readyPesanan(id uuid.UUID) models.Pesanan {
+	return models.Pesanan{
+		ID: id, Kode: "ORD-20261017-0001", OrderStatus: models.OrderStatusReady,
+		PaymentStatus: models.PaymentStatusPaid, DeliveryType: models.DeliveryTypePickup,
+	}
+}
+
+func TestTransitionRunsTxHooks(t *testing.T) {
+	id := uuid.New()
+	db, fake := fakedb.OpenPesanan(t, readyPesanan(id), nil)
+	machine := NewMachine(db)
+
+	var txHookStatus models.OrderStatus
+	var txHookFrom models.OrderStatus
+	afterCommit := 0
+	machine.OnEnterTx(models.OrderStatusCompleted, func(tx *gorm.DB, pesanan *models.Pesanan, from models.OrderStatus, trigger Trigger) error {
+		// Hook harus berjalan sebelum commit, di transaksi yang sama
+		if fake.Commits() != 0 {
+			t.Error("tx hook dijalankan setelah commit")
+		}
+		txHookStatus, txHookFrom = pesanan.OrderStatus, from
+		return tx.Exec("UPDATE pesanan_side_effect SET x = 1").Error
+	})
+	machine.OnEnter(models.OrderStatusCompleted, func(context.Context, *models.Pesanan, models.OrderStatus, Trigger) {
+		afterCommit++
+	})
+
+	result, err := machine.Transition(context.Background(), Request{PesananID: id, To: models.OrderStatusCompleted, Trigger: TriggerAdmin})
+	if err != nil || !result.Changed {
+		t.Fatalf("transisi READY → COMPLETED harus berhasil: %v", err)
+	}
+	if txHookStatus != models.OrderStatusCompleted || txHookFrom != models.OrderStatusReady {
+		t.Fatalf("tx hook tidak dijalankan dengan pesanan COMPLETED dari READY: %s dari %s", txHookStatus, txHookFrom)
+	}
+	var sideEffectInTx bool
+	for _, q := range fake.Queries() {
+		if q.SQL == "UPDATE pesanan_side_effect SET x = 1" {
+			sideEffectInTx = q.InTx
+		}
+	}
+	if !sideEffectInTx || fake.Commits() != 1 || afterCommit != 1 {
+		t.Fatalf("efek samping tx hook harus di dalam transaksi yang di-commit (inTx=%v commit=%d afterCommit=%d)", sideEffectInTx, fake.Commits(), afterCommit)
+	}
+}
+
+func TestTransitionTxHookErrorAborts(t *testing.T) {
+	id := uuid.New()
+	db, fake := fakedb.OpenPesanan(t, readyPesanan(id), nil)
+	machine := NewMachine(db)
+
+	hookErr := errors.New("outbox penuh")
+	afterCommit := 0
+	machine.OnEnterTx(models.OrderStatusCompleted, func(*gorm.DB, *models.Pesanan, models.OrderStatus, Trigger) error {
+		return hookErr
+	})
+	machine.OnEnter(models.OrderStatusCompleted, func(context.Context, *models.Pesanan, models.OrderStatus, Trigger) {
+		afterCommit++
+	})
+
+	_, err := machine.Transition(context.Background(), Request{PesananID: id, To: models.OrderStatusCompleted, Trigger: TriggerAdmin})
+	if !errors.Is(err, hookErr) {
+		t.Fatalf("error tx hook harus dikembalikan Transition, got %v", err)
+	}
+	if fake.Commits() != 0 || fake.Rollbacks() != 1 || afterCommit != 0 {
+		t.Fatalf("transisi harus di-rollback tanpa hook after-commit (commit=%d rollback=%d afterCommit=%d)", fake.Commits(), fake.Rollbacks(), afterCommit)
+	}
+}
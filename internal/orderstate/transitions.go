@@ -0,0 +1,193 @@
+// Package orderstate adalah satu-satunya tempat aturan perubahan order_status
+// pesanan. Semua mutasi status (update admin, cancel, webhook Deliveree/Forwarder)
+// lewat Machine.Transition sehingga transisi ilegal ditolak secara konsisten.
+package orderstate
+
+import (
+	"fmt"
+	"strings"
+
+	"project-bulky-be/internal/models"
+)
+
+// Trigger adalah sumber perubahan status. Satu transisi bisa sah untuk admin
+// tapi tidak untuk webhook (atau sebaliknya).
+type Trigger string
+
+const (
+	TriggerAdmin   Trigger = "ADMIN"
+	TriggerWebhook Trigger = "WEBHOOK"
+	TriggerSystem  Trigger = "SYSTEM"
+)
+
+// Guard adalah syarat tambahan sebuah transisi selain status asal/tujuan.
+// Check mengembalikan alasan penolakan, atau string kosong jika lolos.
+type Guard struct {
+	Name  string
+	Check func(p *models.Pesanan) string
+}
+
+// Rule adalah satu baris tabel transisi
+type Rule struct {
+	From     models.OrderStatus
+	To       models.OrderStatus
+	Triggers []Trigger
+	Guards   []Guard
+}
+
+var (
+	// GuardPaymentPaid: barang baru diproses gudang setelah pembayaran lunas
+	GuardPaymentPaid = Guard{Name: "payment_paid", Check: func(p *models.Pesanan) string {
+		if p.PaymentStatus != models.PaymentStatusPaid {
+			return fmt.Sprintf("pembayaran pesanan belum lunas (payment_status %s)", p.PaymentStatus)
+		}
+		return ""
+	}}
+
+	// GuardPickupOnly: PICKUP langsung READY → COMPLETED (buyer ambil sendiri)
+	GuardPickupOnly = Guard{Name: "pickup_only", Check: func(p *models.Pesanan) string {
+		if p.DeliveryType != models.DeliveryTypePickup {
+			return "pesanan yang dikirim harus melalui status SHIPPED sebelum COMPLETED"
+		}
+		return ""
+	}}
+
+	// GuardShippable: PICKUP tidak memiliki status SHIPPED
+	GuardShippable = Guard{Name: "shippable", Check: func(p *models.Pesanan) string {
+		if p.DeliveryType == models.DeliveryTypePickup {
+			return "pesanan tipe PICKUP tidak memiliki status SHIPPED"
+		}
+		return ""
+	}}
+)
+
+var (
+	adminOrSystem = []Trigger{TriggerAdmin, TriggerSystem}
+	adminOnly     = []Trigger{TriggerAdmin}
+	webhookOnly   = []Trigger{TriggerWebhook}
+	adminWebhook  = []Trigger{TriggerAdmin, TriggerWebhook}
+)
+
+// rules adalah tabel transisi order_status.
+//
+//	PENDING → PROCESSING → READY → SHIPPED → COMPLETED
+//	                         └──────(PICKUP)──→ COMPLETED
+//	semua status non-final → CANCELLED
+//
+// Webhook provider hanya bisa memajukan status pengiriman (SHIPPED/COMPLETED),
+// tidak pernah memundurkannya. Booking bisa terjadi saat PROCESSING (retry), jadi
+// webhook boleh melompat dari PROCESSING.
+var rules = []Rule{
+	{From: models.OrderStatusPending, To: models.OrderStatusProcessing, Triggers: adminOrSystem, Guards: []Guard{GuardPaymentPaid}},
+	{From: models.OrderStatusPending, To: models.OrderStatusCancelled, Triggers: adminOrSystem},
+
+	{From: models.OrderStatusProcessing, To: models.OrderStatusReady, Triggers: adminOnly, Guards: []Guard{GuardPaymentPaid}},
+	{From: models.OrderStatusProcessing, To: models.OrderStatusShipped, Triggers: webhookOnly, Guards: []Guard{GuardShippable}},
+	{From: models.OrderStatusProcessing, To: models.OrderStatusCompleted, Triggers: webhookOnly, Guards: []Guard{GuardShippable}},
+	{From: models.OrderStatusProcessing, To: models.OrderStatusCancelled, Triggers: adminOrSystem},
+
+	{From: models.OrderStatusReady, To: models.OrderStatusShipped, Triggers: adminWebhook, Guards: []Guard{GuardShippable}},
+	{From: models.OrderStatusReady, To: models.OrderStatusCompleted, Triggers: adminOnly, Guards: []Guard{GuardPickupOnly}},
+	// Provider bisa langsung mengirim delivery_completed tanpa delivery_in_progress
+	{From: models.OrderStatusReady, To: models.OrderStatusCompleted, Triggers: webhookOnly, Guards: []Guard{GuardShippable}},
+	{From: models.OrderStatusReady, To: models.OrderStatusCancelled, Triggers: adminOrSystem},
+
+	{From: models.OrderStatusShipped, To: models.OrderStatusCompleted, Triggers: adminWebhook},
+	{From: models.OrderStatusShipped, To: models.OrderStatusCancelled, Triggers: adminOnly},
+}
+
+// handedOverStatuses adalah status di mana barang sudah keluar dari gudang
+// (dipakai job auto-archive produk terjual).
+var handedOverStatuses = []models.OrderStatus{models.OrderStatusShipped, models.OrderStatusCompleted}
+
+// HandedOverStatuses mengembalikan salinan daftar status "barang sudah keluar gudang"
+func HandedOverStatuses() []models.OrderStatus {
+	return append([]models.OrderStatus(nil), handedOverStatuses...)
+}
+
+// IsFinal true untuk status yang tidak punya transisi keluar
+func IsFinal(status models.OrderStatus) bool {
+	for _, rule := range rules {
+		if rule.From == status {
+			return false
+		}
+	}
+	return true
+}
+
+// TransitionError dikembalikan saat transisi tidak ada di tabel atau guard-nya
+// gagal. Allowed berisi status berikutnya yang sah untuk pesanan & trigger ini.
+type TransitionError struct {
+	From    models.OrderStatus
+	To      models.OrderStatus
+	Allowed []models.OrderStatus
+	// Reason diisi jika transisi ada di tabel tapi ditolak guard
+	Reason string
+}
+
+func (e *TransitionError) Error() string {
+	msg := fmt.Sprintf("tidak dapat mengubah status dari %s ke %s", e.From, e.To)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	if len(e.Allowed) == 0 {
+		return msg + ". Tidak ada status berikutnya yang diizinkan"
+	}
+	allowed := make([]string, len(e.Allowed))
+	for i, status := range e.Allowed {
+		allowed[i] = string(status)
+	}
+	return msg + ". Status berikutnya yang diizinkan: " + strings.Join(allowed, ", ")
+}
+
+// Check memvalidasi transisi pesanan ke status `to` oleh `trigger`.
+// Mengembalikan *TransitionError jika tidak sah.
+func Check(p *models.Pesanan, to models.OrderStatus, trigger Trigger) error {
+	var reason string
+	for _, rule := range rules {
+		if rule.From != p.OrderStatus || rule.To != to || !hasTrigger(rule, trigger) {
+			continue
+		}
+		if failed := failedGuard(rule, p); failed != "" {
+			reason = failed
+			continue
+		}
+		return nil
+	}
+	return &TransitionError{From: p.OrderStatus, To: to, Allowed: AllowedNext(p, trigger), Reason: reason}
+}
+
+// AllowedNext mengembalikan status berikutnya yang sah untuk pesanan saat ini
+func AllowedNext(p *models.Pesanan, trigger Trigger) []models.OrderStatus {
+	var allowed []models.OrderStatus
+	seen := map[models.OrderStatus]bool{}
+	for _, rule := range rules {
+		if rule.From != p.OrderStatus || seen[rule.To] || !hasTrigger(rule, trigger) {
+			continue
+		}
+		if failedGuard(rule, p) != "" {
+			continue
+		}
+		seen[rule.To] = true
+		allowed = append(allowed, rule.To)
+	}
+	return allowed
+}
+
+func hasTrigger(rule Rule, trigger Trigger) bool {
+	for _, t := range rule.Triggers {
+		if t == trigger {
+			return true
+		}
+	}
+	return false
+}
+
+func failedGuard(rule Rule, p *models.Pesanan) string {
+	for _, guard := range rule.Guards {
+		if reason := guard.Check(p); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
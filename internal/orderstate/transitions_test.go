@@ -0,0 +1,39 @@
+package orderstate
+
+import (
+	"errors"
+	"testing"
+
+	"project-bulky-be/internal/models"
+)
+
+func TestCheckRejectsIllegalTransitions(t *testing.T) {
+	pickup := &models.Pesanan{OrderStatus: models.OrderStatusReady, DeliveryType: models.DeliveryTypePickup, PaymentStatus: models.PaymentStatusPaid}
+	if err := Check(pickup, models.OrderStatusShipped, TriggerAdmin); err == nil {
+		t.Fatal("pesanan PICKUP tidak boleh masuk SHIPPED")
+	}
+	if err := Check(pickup, models.OrderStatusCompleted, TriggerAdmin); err != nil {
+		t.Fatalf("PICKUP READY → COMPLETED harus sah: %v", err)
+	}
+
+	// Webhook terlambat tidak boleh memundurkan status
+	shipped := &models.Pesanan{OrderStatus: models.OrderStatusShipped, DeliveryType: models.DeliveryTypeDeliveree, PaymentStatus: models.PaymentStatusPaid}
+	err := Check(shipped, models.OrderStatusReady, TriggerWebhook)
+	var transitionErr *TransitionError
+	if !errors.As(err, &transitionErr) {
+		t.Fatalf("harus *TransitionError, got %v", err)
+	}
+	if len(transitionErr.Allowed) != 1 || transitionErr.Allowed[0] != models.OrderStatusCompleted {
+		t.Fatalf("status berikutnya untuk webhook harus [COMPLETED], got %v", transitionErr.Allowed)
+	}
+
+	// Guard pembayaran: belum lunas tidak bisa diproses
+	pending := &models.Pesanan{OrderStatus: models.OrderStatusPending, DeliveryType: models.DeliveryTypeDeliveree, PaymentStatus: models.PaymentStatusPending}
+	err = Check(pending, models.OrderStatusProcessing, TriggerAdmin)
+	if !errors.As(err, &transitionErr) || transitionErr.Reason == "" {
+		t.Fatalf("PENDING → PROCESSING tanpa pembayaran harus ditolak guard, got %v", err)
+	}
+	if IsFinal(models.OrderStatusShipped) || !IsFinal(models.OrderStatusCancelled) {
+		t.Fatal("IsFinal tidak sesuai tabel transisi")
+	}
+}
@@ -15,6 +15,8 @@ type AuthRepository interface {
 	FindAdminWithRole(id uuid.UUID) (*models.Admin, error)
 	UpdateAdminLastLogin(id uuid.UUID) error
 	UpdateAdmin(admin *models.Admin) error
+	// UpdateAdminTwoFactor hanya menyimpan kolom 2FA (tanpa menyentuh relasi role)
+	UpdateAdminTwoFactor(admin *models.Admin) error
 
 	// Buyer
 	FindBuyerByEmail(email string) (*models.Buyer, error)
@@ -22,13 +24,6 @@ type AuthRepository interface {
 	UpdateBuyerLastLogin(id uuid.UUID) error
 	UpdateBuyer(buyer *models.Buyer) error
 
-	// Refresh Token
-	CreateRefreshToken(token *models.RefreshToken) error
-	FindRefreshToken(token string) (*models.RefreshToken, error)
-	RevokeRefreshToken(token string) error
-	RevokeAllUserTokens(userType models.UserType, userID uuid.UUID) error
-	CleanupExpiredTokens() error
-
 	// Role & Permission
 	GetRoleWithPermissions(roleID uuid.UUID) (*models.Role, error)
 	GetPermissionsByRoleID(roleID uuid.UUID) ([]models.Permission, error)
@@ -74,6 +69,12 @@ func (r *authRepository) UpdateAdmin(admin *models.Admin) error {
 	return r.db.Save(admin).Error
 }
 
+func (r *authRepository) UpdateAdminTwoFactor(admin *models.Admin) error {
+	return r.db.Model(admin).
+		Select("two_factor_enabled", "two_factor_secret", "two_factor_confirmed_at", "two_factor_recovery_codes", "two_factor_last_step", "updated_at").
+		Updates(admin).Error
+}
+
 // Buyer methods
 func (r *authRepository) FindBuyerByEmail(email string) (*models.Buyer, error) {
 	var buyer models.Buyer
@@ -98,43 +99,6 @@ func (r *authRepository) UpdateBuyer(buyer *models.Buyer) error {
 	return r.db.Save(buyer).Error
 }
 
-// Refresh Token methods
-func (r *authRepository) CreateRefreshToken(token *models.RefreshToken) error {
-	return r.db.Create(token).Error
-}
-
-func (r *authRepository) FindRefreshToken(token string) (*models.RefreshToken, error) {
-	var refreshToken models.RefreshToken
-	err := r.db.Where("token = ? AND is_revoked = false AND expired_at > NOW()", token).
-		First(&refreshToken).Error
-	return &refreshToken, err
-}
-
-func (r *authRepository) RevokeRefreshToken(token string) error {
-	now := time.Now()
-	return r.db.Model(&models.RefreshToken{}).
-		Where("token = ?", token).
-		Updates(map[string]interface{}{
-			"is_revoked": true,
-			"revoked_at": now,
-		}).Error
-}
-
-func (r *authRepository) RevokeAllUserTokens(userType models.UserType, userID uuid.UUID) error {
-	now := time.Now()
-	return r.db.Model(&models.RefreshToken{}).
-		Where("user_type = ? AND user_id = ? AND is_revoked = false", userType, userID).
-		Updates(map[string]interface{}{
-			"is_revoked": true,
-			"revoked_at": now,
-		}).Error
-}
-
-func (r *authRepository) CleanupExpiredTokens() error {
-	return r.db.Where("expired_at < NOW() OR is_revoked = true").
-		Delete(&models.RefreshToken{}).Error
-}
-
 // Role & Permission methods
 func (r *authRepository) GetRoleWithPermissions(roleID uuid.UUID) (*models.Role, error) {
 	var role models.Role
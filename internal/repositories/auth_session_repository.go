@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AuthSessionRepository interface {
+	Create(ctx context.Context, session *models.AuthSession) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.AuthSession, error)
+	FindActiveByUser(ctx context.Context, userType models.UserType, userID uuid.UUID) ([]models.AuthSession, error)
+	UpdateExpiresAt(ctx context.Context, id uuid.UUID, expiresAt time.Time) error
+	Revoke(ctx context.Context, id uuid.UUID, reason string) error
+	// RevokeByUser me-revoke semua session aktif milik user (kecuali exceptID
+	// jika diisi) dan mengembalikan ID session yang di-revoke.
+	RevokeByUser(ctx context.Context, userType models.UserType, userID uuid.UUID, reason string, exceptID *uuid.UUID) ([]uuid.UUID, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type authSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthSessionRepository(db *gorm.DB) AuthSessionRepository {
+	return &authSessionRepository{db: db}
+}
+
+func (r *authSessionRepository) Create(ctx context.Context, session *models.AuthSession) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *authSessionRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.AuthSession, error) {
+	var session models.AuthSession
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *authSessionRepository) FindActiveByUser(ctx context.Context, userType models.UserType, userID uuid.UUID) ([]models.AuthSession, error) {
+	var sessions []models.AuthSession
+	err := r.db.WithContext(ctx).
+		Where("user_type = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > NOW()", userType, userID).
+		Order("created_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *authSessionRepository) UpdateExpiresAt(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("expires_at", expiresAt).Error
+}
+
+func (r *authSessionRepository) Revoke(ctx context.Context, id uuid.UUID, reason string) error {
+	return r.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		}).Error
+}
+
+func (r *authSessionRepository) RevokeByUser(ctx context.Context, userType models.UserType, userID uuid.UUID, reason string, exceptID *uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&models.AuthSession{}).
+			Where("user_type = ? AND user_id = ? AND revoked_at IS NULL", userType, userID)
+		if exceptID != nil {
+			query = query.Where("id <> ?", *exceptID)
+		}
+		if err := query.Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&models.AuthSession{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"revoked_at":     time.Now(),
+				"revoked_reason": reason,
+			}).Error
+	})
+	return ids, err
+}
+
+func (r *authSessionRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.AuthSession{})
+	return result.RowsAffected, result.Error
+}
@@ -17,6 +17,8 @@ type DasborRepository interface {
 	GetKPIPaletboxAvailable() (int64, error)
 	GetKPIPaletboxSold(periode string) (int64, error)
 	GetKPIRevenue(periode string) (float64, error)
+	GetChartRefund(periode string) ([]dto.DasborDateAmount, float64, error)
+	GetKPIRefund(periode string) (float64, error)
 	GetStokPerKategori() ([]dto.DasborKategoriStok, error)
 	GetPenjualanPerBuyer(periode string, limit int) ([]dto.DasborBuyerPenjualan, error)
 	GetTabelTransaksi(periode string, page, perPage int, statusFilter []string) ([]dto.DasborTabelRow, int64, error)
@@ -36,6 +38,10 @@ func NewDasborRepository(db *gorm.DB) DasborRepository {
 
 const jakartaTZ = "Asia/Jakarta"
 
+// revenuePaymentStatuses pesanan yang pernah lunas untuk revenue kotor;
+// refund dikurangkan lewat GetChartRefund/GetKPIRefund
+const revenuePaymentStatuses = "('" + constants.TransaksiPaidPaymentStatus + "', '" + constants.TransaksiRefundedPaymentStatus + "')"
+
 // buildPeriodeWhereClause returns a SQL WHERE fragment for the periode filter.
 // tableAlias.column should be the timestamptz column reference.
 func buildPeriodeWhereClause(col, periode string) string {
@@ -99,7 +105,7 @@ func (r *dasborRepository) GetChartTransaksiCancel(periode string) ([]dto.Dasbor
 	return rows, nil
 }
 
-// GetChartRevenue returns daily revenue from PAID orders.
+// GetChartRevenue returns daily gross revenue from paid (incl. refunded) orders.
 func (r *dasborRepository) GetChartRevenue(periode string) ([]dto.DasborDateAmount, float64, error) {
 	periodeCond := buildPeriodeWhereClause("paid_at", periode)
 	query := `
@@ -107,7 +113,7 @@ func (r *dasborRepository) GetChartRevenue(periode string) ([]dto.DasborDateAmou
 			DATE(paid_at AT TIME ZONE $1)::text AS tanggal,
 			SUM(total) AS total_penjualan
 		FROM pesanan
-		WHERE payment_status = 'PAID'
+		WHERE payment_status IN ` + revenuePaymentStatuses + `
 		  AND deleted_at IS NULL
 		  AND paid_at IS NOT NULL`
 	if periodeCond != "" {
@@ -189,13 +195,13 @@ func (r *dasborRepository) GetKPIPaletboxSold(periode string) (int64, error) {
 	return total, nil
 }
 
-// GetKPIRevenue returns total revenue from PAID & non-cancelled orders in the given periode.
+// GetKPIRevenue returns total gross revenue from paid (incl. refunded) & non-cancelled orders in the given periode.
 func (r *dasborRepository) GetKPIRevenue(periode string) (float64, error) {
 	periodeCond := buildPeriodeWhereClause("p.paid_at", periode)
 	query := `
 		SELECT COALESCE(SUM(p.total), 0)
 		FROM pesanan p
-		WHERE p.payment_status = '` + constants.TransaksiPaidPaymentStatus + `'
+		WHERE p.payment_status IN ` + revenuePaymentStatuses + `
 		  AND p.order_status != '` + constants.TransaksiExcludedOrderStatus + `'
 		  AND p.deleted_at IS NULL
 		  AND p.paid_at IS NOT NULL`
@@ -210,6 +216,59 @@ func (r *dasborRepository) GetKPIRevenue(periode string) (float64, error) {
 	return total, nil
 }
 
+// GetChartRefund returns daily COMPLETED refunds by refunded_at. Refund pesanan
+// yang dibatalkan ikut dihitung karena revenue kotor chart tidak mengecualikannya.
+func (r *dasborRepository) GetChartRefund(periode string) ([]dto.DasborDateAmount, float64, error) {
+	periodeCond := buildPeriodeWhereClause("rf.refunded_at", periode)
+	query := `
+		SELECT
+			DATE(rf.refunded_at AT TIME ZONE $1)::text AS tanggal,
+			SUM(rf.jumlah) AS total_penjualan
+		FROM pesanan_refund rf
+		JOIN pesanan p ON p.id = rf.pesanan_id
+		WHERE rf.status = 'COMPLETED'
+		  AND p.deleted_at IS NULL
+		  AND rf.refunded_at IS NOT NULL`
+	if periodeCond != "" {
+		query += " AND " + periodeCond
+	}
+	query += " GROUP BY tanggal ORDER BY tanggal"
+
+	var rows []dto.DasborDateAmount
+	if err := r.db.Raw(query, jakartaTZ).Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var total float64
+	for _, row := range rows {
+		total += row.TotalPenjualan
+	}
+	return rows, total, nil
+}
+
+// GetKPIRefund returns total COMPLETED refunds of non-cancelled orders in the
+// given periode, sejalan dengan filter GetKPIRevenue.
+func (r *dasborRepository) GetKPIRefund(periode string) (float64, error) {
+	periodeCond := buildPeriodeWhereClause("rf.refunded_at", periode)
+	query := `
+		SELECT COALESCE(SUM(rf.jumlah), 0)
+		FROM pesanan_refund rf
+		JOIN pesanan p ON p.id = rf.pesanan_id
+		WHERE rf.status = 'COMPLETED'
+		  AND p.order_status != '` + constants.TransaksiExcludedOrderStatus + `'
+		  AND p.deleted_at IS NULL
+		  AND rf.refunded_at IS NOT NULL`
+	if periodeCond != "" {
+		query += " AND " + periodeCond
+	}
+
+	var total float64
+	if err := r.db.Raw(query).Scan(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
 // GetStokPerKategori returns current available palet count per kategori (all categories, zero if none).
 func (r *dasborRepository) GetStokPerKategori() ([]dto.DasborKategoriStok, error) {
 	var rows []dto.DasborKategoriStok
@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EFakturFilter - pesanan ber-PPN yang lunas dengan paid_at di [Dari, Sampai)
+type EFakturFilter struct {
+	Dari   time.Time
+	Sampai time.Time
+	Status string
+}
+
+type EFakturRepository interface {
+	BuyerExists(ctx context.Context, buyerID uuid.UUID) (bool, error)
+	FindProfilPajak(ctx context.Context, buyerID uuid.UUID) (*models.BuyerProfilPajak, error)
+	// UpsertProfilPajak membuat atau mengganti profil pajak buyer (satu per buyer)
+	UpsertProfilPajak(ctx context.Context, profil *models.BuyerProfilPajak) error
+	DeleteProfilPajak(ctx context.Context, buyerID uuid.UUID) (bool, error)
+
+	FindPesanan(ctx context.Context, filter EFakturFilter, page, perPage int) ([]models.Pesanan, int64, error)
+	// FindPesananForExport mengembalikan semua pesanan filter beserta buyer & item
+	FindPesananForExport(ctx context.Context, filter EFakturFilter) ([]models.Pesanan, error)
+	FindKuponUsage(ctx context.Context, pesananIDs []uuid.UUID) ([]models.KuponUsage, error)
+	FindPesananByID(ctx context.Context, id uuid.UUID) (*models.Pesanan, error)
+	UpdatePesananEFaktur(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error
+}
+
+type efakturRepository struct {
+	db *gorm.DB
+}
+
+func NewEFakturRepository(db *gorm.DB) EFakturRepository {
+	return &efakturRepository{db: db}
+}
+
+func (r *efakturRepository) BuyerExists(ctx context.Context, buyerID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Buyer{}).Where("id = ?", buyerID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *efakturRepository) FindProfilPajak(ctx context.Context, buyerID uuid.UUID) (*models.BuyerProfilPajak, error) {
+	var profil models.BuyerProfilPajak
+	err := r.db.WithContext(ctx).First(&profil, "buyer_id = ?", buyerID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &profil, nil
+}
+
+func (r *efakturRepository) UpsertProfilPajak(ctx context.Context, profil *models.BuyerProfilPajak) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "buyer_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"npwp", "nama_perusahaan", "alamat_pajak", "updated_at"}),
+	}).Create(profil).Error
+}
+
+func (r *efakturRepository) DeleteProfilPajak(ctx context.Context, buyerID uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Where("buyer_id = ?", buyerID).Delete(&models.BuyerProfilPajak{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *efakturRepository) pesananQuery(ctx context.Context, filter EFakturFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&models.Pesanan{}).
+		Where("biaya_ppn > 0").
+		Where("payment_status = ? AND order_status <> ?", models.PaymentStatusPaid, models.OrderStatusCancelled).
+		Where("paid_at >= ? AND paid_at < ?", filter.Dari, filter.Sampai)
+	if filter.Status != "" {
+		query = query.Where("efaktur_status = ?", filter.Status)
+	}
+	return query
+}
+
+func (r *efakturRepository) FindPesanan(ctx context.Context, filter EFakturFilter, page, perPage int) ([]models.Pesanan, int64, error) {
+	var pesanan []models.Pesanan
+	var total int64
+
+	query := r.pesananQuery(ctx, filter)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	err := query.Preload("Buyer").Order("paid_at ASC, kode ASC").Offset(offset).Limit(perPage).Find(&pesanan).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return pesanan, total, nil
+}
+
+func (r *efakturRepository) FindPesananForExport(ctx context.Context, filter EFakturFilter) ([]models.Pesanan, error) {
+	var pesanan []models.Pesanan
+	err := r.pesananQuery(ctx, filter).
+		Preload("Buyer").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Order("paid_at ASC, kode ASC").
+		Find(&pesanan).Error
+	return pesanan, err
+}
+
+func (r *efakturRepository) FindKuponUsage(ctx context.Context, pesananIDs []uuid.UUID) ([]models.KuponUsage, error) {
+	var usages []models.KuponUsage
+	if len(pesananIDs) == 0 {
+		return usages, nil
+	}
+	err := r.db.WithContext(ctx).Where("pesanan_id IN ?", pesananIDs).Order("created_at ASC").Find(&usages).Error
+	return usages, err
+}
+
+func (r *efakturRepository) FindPesananByID(ctx context.Context, id uuid.UUID) (*models.Pesanan, error) {
+	var pesanan models.Pesanan
+	if err := r.db.WithContext(ctx).Preload("Buyer").First(&pesanan, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &pesanan, nil
+}
+
+func (r *efakturRepository) UpdatePesananEFaktur(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&models.Pesanan{}).Where("id = ?", id).Updates(fields).Error
+}
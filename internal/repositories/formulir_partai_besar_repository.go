@@ -27,6 +27,7 @@ type FormulirPartaiBesarRepository interface {
 	FindAllSubmission(ctx context.Context, params *models.FormulirSubmissionFilterRequest) ([]models.FormulirPartaiBesarSubmission, int64, error)
 	FindSubmissionByID(ctx context.Context, id uuid.UUID) (*models.FormulirPartaiBesarSubmission, error)
 	UpdateSubmission(ctx context.Context, submission *models.FormulirPartaiBesarSubmission) error
+	FindPesananBySubmissionID(ctx context.Context, submissionID uuid.UUID) ([]models.Pesanan, error)
 }
 
 type formulirPartaiBesarRepository struct {
@@ -203,3 +204,13 @@ func (r *formulirPartaiBesarRepository) FindSubmissionByID(ctx context.Context,
 func (r *formulirPartaiBesarRepository) UpdateSubmission(ctx context.Context, submission *models.FormulirPartaiBesarSubmission) error {
 	return r.db.WithContext(ctx).Save(submission).Error
 }
+
+// FindPesananBySubmissionID mengambil pesanan panel yang dibuat dari submission
+func (r *formulirPartaiBesarRepository) FindPesananBySubmissionID(ctx context.Context, submissionID uuid.UUID) ([]models.Pesanan, error) {
+	var pesanan []models.Pesanan
+	err := r.db.WithContext(ctx).
+		Where("formulir_submission_id = ?", submissionID).
+		Order("created_at DESC").
+		Find(&pesanan).Error
+	return pesanan, err
+}
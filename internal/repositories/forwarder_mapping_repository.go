@@ -18,6 +18,13 @@ type ForwarderMappingRepository interface {
 	// UpsertSubdistricts menyimpan/update mapping kecamatan hasil sync berdasarkan
 	// unique constraint (kecamatan_pattern, forwarder_city_id). Return (created, updated).
 	UpsertSubdistricts(ctx context.Context, items []models.ForwarderSubdistrictMapping) (int, int, error)
+	// FindCityByPattern mencari mapping kota dari kota_pattern yang sudah dinormalisasi
+	FindCityByPattern(ctx context.Context, kotaPattern string) (*models.ForwarderCityMapping, error)
+	// FindCitiesByForwarderCityID mengembalikan semua pattern kota untuk satu city_id Forwarder
+	FindCitiesByForwarderCityID(ctx context.Context, forwarderCityID int) ([]models.ForwarderCityMapping, error)
+	// FindSubdistrictsByPattern mencari mapping kecamatan dari kecamatan_pattern
+	// yang sudah dinormalisasi (bisa lebih dari satu kota)
+	FindSubdistrictsByPattern(ctx context.Context, kecamatanPattern string) ([]models.ForwarderSubdistrictMapping, error)
 }
 
 type forwarderMappingRepository struct {
@@ -165,3 +172,26 @@ func (r *forwarderMappingRepository) UpsertSubdistricts(ctx context.Context, ite
 	})
 	return created, updated, err
 }
+
+func (r *forwarderMappingRepository) FindCityByPattern(ctx context.Context, kotaPattern string) (*models.ForwarderCityMapping, error) {
+	var item models.ForwarderCityMapping
+	if err := r.db.WithContext(ctx).Where("kota_pattern = ?", kotaPattern).First(&item).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *forwarderMappingRepository) FindCitiesByForwarderCityID(ctx context.Context, forwarderCityID int) ([]models.ForwarderCityMapping, error) {
+	var items []models.ForwarderCityMapping
+	err := r.db.WithContext(ctx).
+		Where("forwarder_city_id = ?", forwarderCityID).
+		Order("kota_pattern ASC").
+		Find(&items).Error
+	return items, err
+}
+
+func (r *forwarderMappingRepository) FindSubdistrictsByPattern(ctx context.Context, kecamatanPattern string) ([]models.ForwarderSubdistrictMapping, error) {
+	var items []models.ForwarderSubdistrictMapping
+	err := r.db.WithContext(ctx).Where("kecamatan_pattern = ?", kecamatanPattern).Find(&items).Error
+	return items, err
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type IdempotencyKeyRepository interface {
+	// Reserve menyimpan record IN_PROGRESS. Record lama dengan key yang sama
+	// ditimpa jika sudah expired atau masih IN_PROGRESS sejak sebelum
+	// staleBefore (proses pertama mati). reserved false berarti key sudah
+	// dipakai request lain.
+	Reserve(ctx context.Context, record *models.IdempotencyKey, staleBefore time.Time) (reserved bool, err error)
+	FindByKey(ctx context.Context, scope, method, route, requestKey string) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, id uuid.UUID, responseStatus int, contentType string, body []byte) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type idempotencyKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyKeyRepository(db *gorm.DB) IdempotencyKeyRepository {
+	return &idempotencyKeyRepository{db: db}
+}
+
+func (r *idempotencyKeyRepository) Reserve(ctx context.Context, record *models.IdempotencyKey, staleBefore time.Time) (bool, error) {
+	var rows []models.IdempotencyKey
+	now := time.Now()
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO idempotency_key (scope, method, route, request_key, request_hash, status, expires_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (scope, method, route, request_key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+		    status = EXCLUDED.status,
+		    response_status = NULL,
+		    response_content_type = NULL,
+		    response_body = NULL,
+		    expires_at = EXCLUDED.expires_at,
+		    created_at = EXCLUDED.created_at,
+		    updated_at = EXCLUDED.updated_at
+		WHERE idempotency_key.expires_at < ?
+		   OR (idempotency_key.status = ? AND idempotency_key.updated_at < ?)
+		RETURNING *
+	`, record.Scope, record.Method, record.Route, record.RequestKey, record.RequestHash,
+		models.IdempotencyStatusInProgress, record.ExpiresAt, now, now,
+		now, models.IdempotencyStatusInProgress, staleBefore).Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return false, err
+	}
+	*record = rows[0]
+	return true, nil
+}
+
+func (r *idempotencyKeyRepository) FindByKey(ctx context.Context, scope, method, route, requestKey string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	err := r.db.WithContext(ctx).
+		Where("scope = ? AND method = ? AND route = ? AND request_key = ?", scope, method, route, requestKey).
+		First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *idempotencyKeyRepository) Complete(ctx context.Context, id uuid.UUID, responseStatus int, contentType string, body []byte) error {
+	return r.db.WithContext(ctx).Model(&models.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":                models.IdempotencyStatusCompleted,
+		"response_status":       responseStatus,
+		"response_content_type": contentType,
+		"response_body":         body,
+	}).Error
+}
+
+func (r *idempotencyKeyRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.IdempotencyKey{}).Error
+}
+
+func (r *idempotencyKeyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type JadwalGudangPengecualianRepository interface {
+	Create(ctx context.Context, pengecualian *models.JadwalGudangPengecualian) error
+	Update(ctx context.Context, pengecualian *models.JadwalGudangPengecualian) error
+	Delete(ctx context.Context, pengecualian *models.JadwalGudangPengecualian) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.JadwalGudangPengecualian, error)
+	// FindByRange mengembalikan pengecualian tanggal [dari, sampai]; warehouseID nil = semua gudang
+	FindByRange(ctx context.Context, warehouseID *uuid.UUID, dari, sampai time.Time) ([]models.JadwalGudangPengecualian, error)
+}
+
+type jadwalGudangPengecualianRepository struct {
+	db *gorm.DB
+}
+
+func NewJadwalGudangPengecualianRepository(db *gorm.DB) JadwalGudangPengecualianRepository {
+	return &jadwalGudangPengecualianRepository{db: db}
+}
+
+func (r *jadwalGudangPengecualianRepository) Create(ctx context.Context, pengecualian *models.JadwalGudangPengecualian) error {
+	return r.db.WithContext(ctx).Create(pengecualian).Error
+}
+
+func (r *jadwalGudangPengecualianRepository) Update(ctx context.Context, pengecualian *models.JadwalGudangPengecualian) error {
+	return r.db.WithContext(ctx).Save(pengecualian).Error
+}
+
+func (r *jadwalGudangPengecualianRepository) Delete(ctx context.Context, pengecualian *models.JadwalGudangPengecualian) error {
+	return r.db.WithContext(ctx).Delete(pengecualian).Error
+}
+
+func (r *jadwalGudangPengecualianRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.JadwalGudangPengecualian, error) {
+	var pengecualian models.JadwalGudangPengecualian
+	if err := r.db.WithContext(ctx).First(&pengecualian, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &pengecualian, nil
+}
+
+func (r *jadwalGudangPengecualianRepository) FindByRange(ctx context.Context, warehouseID *uuid.UUID, dari, sampai time.Time) ([]models.JadwalGudangPengecualian, error) {
+	query := r.db.WithContext(ctx).
+		Where("tanggal BETWEEN ? AND ?", dari.Format("2006-01-02"), sampai.Format("2006-01-02"))
+	if warehouseID != nil {
+		query = query.Where("warehouse_id = ?", *warehouseID)
+	}
+
+	var list []models.JadwalGudangPengecualian
+	err := query.Order("tanggal ASC").Find(&list).Error
+	return list, err
+}
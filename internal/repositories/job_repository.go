@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository interface {
+	// WithTx mengembalikan repository yang menulis lewat transaksi pemanggil
+	WithTx(tx *gorm.DB) JobRepository
+	// Create menyimpan job baru. Job dengan unique_key yang sudah ada diabaikan
+	// (created false).
+	Create(ctx context.Context, job *models.BackgroundJob) (created bool, err error)
+	// Claim mengambil satu job siap jalan untuk jobType: PENDING yang run_at-nya
+	// lewat, atau RUNNING yang visibility timeout-nya habis (worker mati).
+	// attempts dinaikkan saat claim. Mengembalikan nil jika tidak ada job.
+	Claim(ctx context.Context, jobType, workerID string, visibility time.Duration) (*models.BackgroundJob, error)
+	// MarkDone & MarkFailed hanya mengubah job yang masih dipegang claim
+	// pemanggil (locked_by & attempts saat Claim). False berarti visibility
+	// timeout habis dan job sudah di-claim ulang; hasil claim lama diabaikan.
+	MarkDone(ctx context.Context, id uuid.UUID, lockedBy string, attempts int) (bool, error)
+	// MarkFailed menjadwalkan ulang job pada runAt, atau menjadikannya DEAD
+	MarkFailed(ctx context.Context, id uuid.UUID, lockedBy string, attempts int, runAt time.Time, lastError string, dead bool) (bool, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.BackgroundJob, error)
+	FindAll(ctx context.Context, status, jobType string, page, perPage int) ([]models.BackgroundJob, int64, error)
+	CountByStatus(ctx context.Context) ([]models.JobStatusCount, error)
+	// Retry mengembalikan job DEAD ke antrean dengan percobaan dari nol
+	Retry(ctx context.Context, id uuid.UUID) (bool, error)
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type jobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+func (r *jobRepository) WithTx(tx *gorm.DB) JobRepository {
+	return &jobRepository{db: tx}
+}
+
+func (r *jobRepository) Create(ctx context.Context, job *models.BackgroundJob) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *jobRepository) Claim(ctx context.Context, jobType, workerID string, visibility time.Duration) (*models.BackgroundJob, error) {
+	var jobs []models.BackgroundJob
+	now := time.Now()
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE background_job
+		SET status = ?, attempts = attempts + 1, locked_until = ?, locked_by = ?, started_at = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM background_job
+			WHERE job_type = ?
+			  AND ((status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?))
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, models.JobStatusRunning, now.Add(visibility), workerID, now, now,
+		jobType, models.JobStatusPending, now, models.JobStatusRunning, now).Scan(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+func (r *jobRepository) MarkDone(ctx context.Context, id uuid.UUID, lockedBy string, attempts int) (bool, error) {
+	result := r.claimed(ctx, id, lockedBy, attempts).Updates(map[string]interface{}{
+		"status":       models.JobStatusDone,
+		"finished_at":  time.Now(),
+		"locked_until": nil,
+		"last_error":   nil,
+	})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *jobRepository) MarkFailed(ctx context.Context, id uuid.UUID, lockedBy string, attempts int, runAt time.Time, lastError string, dead bool) (bool, error) {
+	updates := map[string]interface{}{
+		"status":       models.JobStatusPending,
+		"run_at":       runAt,
+		"locked_until": nil,
+		"last_error":   lastError,
+	}
+	if dead {
+		updates["status"] = models.JobStatusDead
+		updates["finished_at"] = time.Now()
+	}
+	result := r.claimed(ctx, id, lockedBy, attempts).Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// claimed memilih job RUNNING yang masih milik claim worker lockedBy ke-attempts
+func (r *jobRepository) claimed(ctx context.Context, id uuid.UUID, lockedBy string, attempts int) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&models.BackgroundJob{}).
+		Where("id = ? AND status = ? AND locked_by = ? AND attempts = ?", id, models.JobStatusRunning, lockedBy, attempts)
+}
+
+func (r *jobRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.BackgroundJob, error) {
+	var job models.BackgroundJob
+	if err := r.db.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *jobRepository) FindAll(ctx context.Context, status, jobType string, page, perPage int) ([]models.BackgroundJob, int64, error) {
+	var jobs []models.BackgroundJob
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.BackgroundJob{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if jobType != "" {
+		query = query.Where("job_type = ?", jobType)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	if err := query.Order("created_at DESC").Offset(offset).Limit(perPage).Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+func (r *jobRepository) CountByStatus(ctx context.Context) ([]models.JobStatusCount, error) {
+	var counts []models.JobStatusCount
+	err := r.db.WithContext(ctx).Model(&models.BackgroundJob{}).
+		Select("job_type, status, COUNT(*) AS total").
+		Group("job_type, status").
+		Order("job_type, status").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *jobRepository) Retry(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.BackgroundJob{}).
+		Where("id = ? AND status = ?", id, models.JobStatusDead).
+		Updates(map[string]interface{}{
+			"status":       models.JobStatusPending,
+			"attempts":     0,
+			"run_at":       time.Now(),
+			"locked_until": nil,
+			"finished_at":  nil,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *jobRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status = ? AND finished_at < ?", models.JobStatusDone, before).
+		Delete(&models.BackgroundJob{})
+	return result.RowsAffected, result.Error
+}
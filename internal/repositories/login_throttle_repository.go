@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type LoginThrottleRepository interface {
+	Find(ctx context.Context, scope string, userType models.UserType, identifier string) (*models.LoginThrottle, error)
+	// IncrementFailure menambah counter login gagal secara atomik. Counter
+	// dimulai ulang dari 1 jika kegagalan terakhir lebih lama dari resetBefore.
+	IncrementFailure(ctx context.Context, scope string, userType models.UserType, identifier string, now, resetBefore time.Time) (*models.LoginThrottle, error)
+	SetBlocked(ctx context.Context, id uuid.UUID, blockedUntil time.Time, lockedAt *time.Time) error
+	Delete(ctx context.Context, scope string, userType models.UserType, identifier string) error
+	DeleteStale(ctx context.Context, before time.Time) (int64, error)
+}
+
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository(db *gorm.DB) LoginThrottleRepository {
+	return &loginThrottleRepository{db: db}
+}
+
+func (r *loginThrottleRepository) Find(ctx context.Context, scope string, userType models.UserType, identifier string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := r.db.WithContext(ctx).
+		Where("scope = ? AND user_type = ? AND identifier = ?", scope, userType, identifier).
+		First(&throttle).Error
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r *loginThrottleRepository) IncrementFailure(ctx context.Context, scope string, userType models.UserType, identifier string, now, resetBefore time.Time) (*models.LoginThrottle, error) {
+	query := `
+		INSERT INTO login_throttle (scope, user_type, identifier, failed_count, last_failed_at, created_at, updated_at)
+		VALUES (?, ?, ?, 1, ?, ?, ?)
+		ON CONFLICT (scope, user_type, identifier) DO UPDATE SET
+			failed_count = CASE
+				WHEN login_throttle.last_failed_at IS NULL OR login_throttle.last_failed_at < ? THEN 1
+				ELSE login_throttle.failed_count + 1
+			END,
+			locked_at = CASE
+				WHEN login_throttle.last_failed_at IS NULL OR login_throttle.last_failed_at < ? THEN NULL
+				ELSE login_throttle.locked_at
+			END,
+			last_failed_at = EXCLUDED.last_failed_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING *`
+
+	var throttle models.LoginThrottle
+	err := r.db.WithContext(ctx).
+		Raw(query, scope, userType, identifier, now, now, now, resetBefore, resetBefore).
+		Scan(&throttle).Error
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r *loginThrottleRepository) SetBlocked(ctx context.Context, id uuid.UUID, blockedUntil time.Time, lockedAt *time.Time) error {
+	updates := map[string]interface{}{
+		"blocked_until": blockedUntil,
+		"updated_at":    time.Now(),
+	}
+	if lockedAt != nil {
+		updates["locked_at"] = *lockedAt
+	}
+	return r.db.WithContext(ctx).Model(&models.LoginThrottle{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *loginThrottleRepository) Delete(ctx context.Context, scope string, userType models.UserType, identifier string) error {
+	return r.db.WithContext(ctx).
+		Where("scope = ? AND user_type = ? AND identifier = ?", scope, userType, identifier).
+		Delete(&models.LoginThrottle{}).Error
+}
+
+// DeleteStale menghapus counter yang sudah tidak memblokir dan tidak ada
+// kegagalan baru sejak before.
+func (r *loginThrottleRepository) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("last_failed_at < ? AND (blocked_until IS NULL OR blocked_until < ?)", before, before).
+		Delete(&models.LoginThrottle{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OutboxRepository interface {
+	// WithTx mengembalikan repository yang menulis lewat transaksi pemanggil,
+	// agar pesan outbox ikut commit/rollback bersama perubahan datanya.
+	WithTx(tx *gorm.DB) OutboxRepository
+	Create(ctx context.Context, msg *models.OutboxMessage) error
+	// ClaimDue mengambil pesan PENDING yang jatuh tempo dan memasang lease
+	// sampai `lease` agar tidak diambil dispatcher lain (FOR UPDATE SKIP LOCKED).
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxMessage, error)
+	MarkSent(ctx context.Context, id uuid.UUID) error
+	// MarkFailed mencatat percobaan gagal. Jika dead, status menjadi DEAD;
+	// selain itu pesan dijadwalkan ulang pada nextAttemptAt.
+	MarkFailed(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string, dead bool) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.OutboxMessage, error)
+	FindAll(ctx context.Context, status, eventType string, page, perPage int) ([]models.OutboxMessage, int64, error)
+	// Replay mengembalikan pesan DEAD ke antrean dengan percobaan dari nol.
+	// Mengembalikan false jika pesan tidak berstatus DEAD.
+	Replay(ctx context.Context, id uuid.UUID) (bool, error)
+	DeleteSentBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) WithTx(tx *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: tx}
+}
+
+func (r *outboxRepository) Create(ctx context.Context, msg *models.OutboxMessage) error {
+	return r.db.WithContext(ctx).Create(msg).Error
+}
+
+func (r *outboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+	now := time.Now()
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE outbox_message SET locked_until = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM outbox_message
+			WHERE status = ? AND next_attempt_at <= ? AND (locked_until IS NULL OR locked_until < ?)
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, now.Add(lease), now, models.OutboxStatusPending, now, now, limit).Scan(&messages).Error
+	return messages, err
+}
+
+func (r *outboxRepository) MarkSent(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       models.OutboxStatusSent,
+		"attempts":     gorm.Expr("attempts + 1"),
+		"sent_at":      now,
+		"locked_until": nil,
+		"last_error":   nil,
+	}).Error
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string, dead bool) error {
+	status := models.OutboxStatusPending
+	if dead {
+		status = models.OutboxStatusDead
+	}
+	return r.db.WithContext(ctx).Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          status,
+		"attempts":        attempts,
+		"next_attempt_at": nextAttemptAt,
+		"locked_until":    nil,
+		"last_error":      lastError,
+	}).Error
+}
+
+func (r *outboxRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.OutboxMessage, error) {
+	var msg models.OutboxMessage
+	if err := r.db.WithContext(ctx).First(&msg, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (r *outboxRepository) FindAll(ctx context.Context, status, eventType string, page, perPage int) ([]models.OutboxMessage, int64, error) {
+	var messages []models.OutboxMessage
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.OutboxMessage{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	if err := query.Order("created_at DESC").Offset(offset).Limit(perPage).Find(&messages).Error; err != nil {
+		return nil, 0, err
+	}
+	return messages, total, nil
+}
+
+func (r *outboxRepository) Replay(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.OutboxMessage{}).
+		Where("id = ? AND status = ?", id, models.OutboxStatusDead).
+		Updates(map[string]interface{}{
+			"status":          models.OutboxStatusPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+			"locked_until":    nil,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *outboxRepository) DeleteSentBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status = ? AND sent_at < ?", models.OutboxStatusSent, before).
+		Delete(&models.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
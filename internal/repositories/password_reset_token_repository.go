@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PasswordResetTokenRepository interface {
+	// Create menyimpan token baru dan menandai token lama user yang belum
+	// terpakai sebagai used, sehingga hanya link terakhir yang berlaku.
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	FindLatest(ctx context.Context, userType models.UserType, userID uuid.UUID) (*models.PasswordResetToken, error)
+	// MarkUsed mengembalikan false jika token sudah lebih dulu dipakai
+	// (request paralel dengan token yang sama).
+	MarkUsed(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+type passwordResetTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetTokenRepository(db *gorm.DB) PasswordResetTokenRepository {
+	return &passwordResetTokenRepository{db: db}
+}
+
+func (r *passwordResetTokenRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_type = ? AND user_id = ? AND used_at IS NULL", token.UserType, token.UserID).
+			Update("used_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func (r *passwordResetTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *passwordResetTokenRepository) FindLatest(ctx context.Context, userType models.UserType, userID uuid.UUID) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := r.db.WithContext(ctx).
+		Where("user_type = ? AND user_id = ?", userType, userID).
+		Order("created_at DESC").
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *passwordResetTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,241 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BuktiTransferFilter - Search mencocokkan kode pesanan atau nama pengirim
+type BuktiTransferFilter struct {
+	Status string
+	Search string
+}
+
+// PembayaranManualRepository menyimpan bukti transfer buyer, mutasi rekening
+// hasil impor dan pembayaran manual yang dicatat dari keduanya
+type PembayaranManualRepository interface {
+	WithTx(tx *gorm.DB) PembayaranManualRepository
+
+	FindPesanan(ctx context.Context, id uuid.UUID) (*models.Pesanan, error)
+	FindPesananByKode(ctx context.Context, kode string) (*models.Pesanan, error)
+	// LockPesanan mengunci pesanan FOR UPDATE (dalam transaksi)
+	LockPesanan(ctx context.Context, id uuid.UUID) (*models.Pesanan, error)
+	UpdatePesanan(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error
+	// SumPembayaranLunas mengembalikan total pembayaran PAID & jumlah semua baris pembayaran pesanan
+	SumPembayaranLunas(ctx context.Context, pesananID uuid.UUID) (decimal.Decimal, int64, error)
+	// HasInvoiceAktif true jika pesanan masih punya invoice Xendit PENDING yang
+	// belum kedaluwarsa (buyer masih bisa membayar lewat payment gateway)
+	HasInvoiceAktif(ctx context.Context, pesananID uuid.UUID) (bool, error)
+	CreatePembayaran(ctx context.Context, pembayaran *models.PesananPembayaran) error
+	FindMetodeByKode(ctx context.Context, kode string) (*models.MetodePembayaran, error)
+
+	CreateBukti(ctx context.Context, bukti *models.PesananBuktiTransfer) error
+	FindBukti(ctx context.Context, filter BuktiTransferFilter, page, perPage int) ([]models.PesananBuktiTransfer, int64, error)
+	FindBuktiByID(ctx context.Context, id uuid.UUID) (*models.PesananBuktiTransfer, error)
+	FindBuktiByPesanan(ctx context.Context, pesananID uuid.UUID) ([]models.PesananBuktiTransfer, error)
+	CountBuktiMenunggu(ctx context.Context, pesananID uuid.UUID) (int64, error)
+	LockBukti(ctx context.Context, id uuid.UUID) (*models.PesananBuktiTransfer, error)
+	// LockBuktiMenunggu mengunci bukti MENUNGGU dengan jumlah tepat, untuk satu
+	// pesanan (pesananID terisi) atau bertanggal transfer di [dari, sampai]
+	LockBuktiMenunggu(ctx context.Context, pesananID *uuid.UUID, jumlah decimal.Decimal, dari, sampai time.Time) ([]models.PesananBuktiTransfer, error)
+	UpdateBukti(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error
+
+	MutasiExists(ctx context.Context, sidik string) (bool, error)
+	CreateMutasi(ctx context.Context, mutasi *models.MutasiBank) error
+	FindMutasi(ctx context.Context, status string, page, perPage int) ([]models.MutasiBank, int64, error)
+}
+
+type pembayaranManualRepository struct {
+	db *gorm.DB
+}
+
+func NewPembayaranManualRepository(db *gorm.DB) PembayaranManualRepository {
+	return &pembayaranManualRepository{db: db}
+}
+
+func (r *pembayaranManualRepository) WithTx(tx *gorm.DB) PembayaranManualRepository {
+	return &pembayaranManualRepository{db: tx}
+}
+
+func (r *pembayaranManualRepository) FindPesanan(ctx context.Context, id uuid.UUID) (*models.Pesanan, error) {
+	var pesanan models.Pesanan
+	if err := r.db.WithContext(ctx).First(&pesanan, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &pesanan, nil
+}
+
+func (r *pembayaranManualRepository) LockPesanan(ctx context.Context, id uuid.UUID) (*models.Pesanan, error) {
+	var pesanan models.Pesanan
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&pesanan, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &pesanan, nil
+}
+
+func (r *pembayaranManualRepository) FindPesananByKode(ctx context.Context, kode string) (*models.Pesanan, error) {
+	var pesanan models.Pesanan
+	if err := r.db.WithContext(ctx).First(&pesanan, "kode = ?", kode).Error; err != nil {
+		return nil, err
+	}
+	return &pesanan, nil
+}
+
+func (r *pembayaranManualRepository) UpdatePesanan(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&models.Pesanan{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *pembayaranManualRepository) SumPembayaranLunas(ctx context.Context, pesananID uuid.UUID) (decimal.Decimal, int64, error) {
+	var row struct {
+		Lunas  decimal.Decimal
+		Jumlah int64
+	}
+	err := r.db.WithContext(ctx).Model(&models.PesananPembayaran{}).
+		Select("COALESCE(SUM(jumlah) FILTER (WHERE status = ?), 0) AS lunas, COUNT(*) AS jumlah", models.PaymentStatusPaid).
+		Where("pesanan_id = ?", pesananID).
+		Scan(&row).Error
+	return row.Lunas, row.Jumlah, err
+}
+
+func (r *pembayaranManualRepository) HasInvoiceAktif(ctx context.Context, pesananID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.PesananPembayaran{}).
+		Where("pesanan_id = ? AND is_manual = false AND status = ? AND xendit_invoice_id IS NOT NULL", pesananID, models.PaymentStatusPending).
+		Where("expired_at IS NULL OR expired_at > ?", time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *pembayaranManualRepository) CreatePembayaran(ctx context.Context, pembayaran *models.PesananPembayaran) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(pembayaran).Error
+}
+
+func (r *pembayaranManualRepository) FindMetodeByKode(ctx context.Context, kode string) (*models.MetodePembayaran, error) {
+	var metode models.MetodePembayaran
+	if err := r.db.WithContext(ctx).First(&metode, "kode = ?", kode).Error; err != nil {
+		return nil, err
+	}
+	return &metode, nil
+}
+
+func (r *pembayaranManualRepository) CreateBukti(ctx context.Context, bukti *models.PesananBuktiTransfer) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(bukti).Error
+}
+
+func (r *pembayaranManualRepository) FindBukti(ctx context.Context, filter BuktiTransferFilter, page, perPage int) ([]models.PesananBuktiTransfer, int64, error) {
+	var bukti []models.PesananBuktiTransfer
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.PesananBuktiTransfer{}).
+		Joins("JOIN pesanan ON pesanan.id = pesanan_bukti_transfer.pesanan_id")
+	if filter.Status != "" {
+		query = query.Where("pesanan_bukti_transfer.status = ?", filter.Status)
+	}
+	if filter.Search != "" {
+		search := "%" + filter.Search + "%"
+		query = query.Where("pesanan.kode ILIKE ? OR pesanan_bukti_transfer.nama_pengirim ILIKE ?", search, search)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Antrean: yang paling lama menunggu di atas
+	offset := (page - 1) * perPage
+	err := query.Preload("Pesanan").Preload("Buyer").
+		Order("pesanan_bukti_transfer.created_at ASC").
+		Offset(offset).Limit(perPage).
+		Find(&bukti).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return bukti, total, nil
+}
+
+func (r *pembayaranManualRepository) FindBuktiByID(ctx context.Context, id uuid.UUID) (*models.PesananBuktiTransfer, error) {
+	var bukti models.PesananBuktiTransfer
+	if err := r.db.WithContext(ctx).Preload("Pesanan").Preload("Buyer").
+		First(&bukti, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &bukti, nil
+}
+
+func (r *pembayaranManualRepository) FindBuktiByPesanan(ctx context.Context, pesananID uuid.UUID) ([]models.PesananBuktiTransfer, error) {
+	var bukti []models.PesananBuktiTransfer
+	err := r.db.WithContext(ctx).Where("pesanan_id = ?", pesananID).Order("created_at DESC").Find(&bukti).Error
+	return bukti, err
+}
+
+func (r *pembayaranManualRepository) CountBuktiMenunggu(ctx context.Context, pesananID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.PesananBuktiTransfer{}).
+		Where("pesanan_id = ? AND status = ?", pesananID, models.BuktiTransferStatusMenunggu).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *pembayaranManualRepository) LockBukti(ctx context.Context, id uuid.UUID) (*models.PesananBuktiTransfer, error) {
+	var bukti models.PesananBuktiTransfer
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&bukti, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &bukti, nil
+}
+
+func (r *pembayaranManualRepository) LockBuktiMenunggu(ctx context.Context, pesananID *uuid.UUID, jumlah decimal.Decimal, dari, sampai time.Time) ([]models.PesananBuktiTransfer, error) {
+	var bukti []models.PesananBuktiTransfer
+	query := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("status = ? AND jumlah = ?", models.BuktiTransferStatusMenunggu, jumlah)
+	if pesananID != nil {
+		query = query.Where("pesanan_id = ?", *pesananID)
+	} else {
+		query = query.Where("tanggal_transfer BETWEEN ? AND ?", dari.Format("2006-01-02"), sampai.Format("2006-01-02"))
+	}
+	err := query.Order("created_at ASC").Find(&bukti).Error
+	return bukti, err
+}
+
+func (r *pembayaranManualRepository) UpdateBukti(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&models.PesananBuktiTransfer{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *pembayaranManualRepository) MutasiExists(ctx context.Context, sidik string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.MutasiBank{}).Where("sidik = ?", sidik).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *pembayaranManualRepository) CreateMutasi(ctx context.Context, mutasi *models.MutasiBank) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(mutasi).Error
+}
+
+func (r *pembayaranManualRepository) FindMutasi(ctx context.Context, status string, page, perPage int) ([]models.MutasiBank, int64, error) {
+	var mutasi []models.MutasiBank
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.MutasiBank{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	err := query.Preload("Pesanan").Order("tanggal DESC, created_at DESC").
+		Offset(offset).Limit(perPage).
+		Find(&mutasi).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return mutasi, total, nil
+}
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PesananBuktiPengirimanRepository interface {
+	// Upsert menyimpan bukti pengiriman; bukti booking yang sama ditimpa
+	Upsert(ctx context.Context, bukti *models.PesananBuktiPengiriman) error
+	// FindByBooking mengembalikan nil jika bukti booking belum pernah disimpan
+	FindByBooking(ctx context.Context, pengirimanID uuid.UUID, bookingRef string) (*models.PesananBuktiPengiriman, error)
+	FindByPesananID(ctx context.Context, pesananID uuid.UUID) ([]models.PesananBuktiPengiriman, error)
+}
+
+type pesananBuktiPengirimanRepository struct {
+	db *gorm.DB
+}
+
+func NewPesananBuktiPengirimanRepository(db *gorm.DB) PesananBuktiPengirimanRepository {
+	return &pesananBuktiPengirimanRepository{db: db}
+}
+
+func (r *pesananBuktiPengirimanRepository) Upsert(ctx context.Context, bukti *models.PesananBuktiPengiriman) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "pesanan_pengiriman_id"}, {Name: "booking_ref"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"delivery_status", "recipient_name", "recipient_phone", "failed_delivery_reason",
+			"signature_source_url", "signature_path", "driver_name", "driver_phone",
+			"arrived_at", "left_at", "completed_at", "parking_fees", "tolls_fees",
+			"waiting_time_fees", "total_fees", "currency", "raw_detail", "captured_at", "updated_at",
+		}),
+	}).Create(bukti).Error
+}
+
+func (r *pesananBuktiPengirimanRepository) FindByBooking(ctx context.Context, pengirimanID uuid.UUID, bookingRef string) (*models.PesananBuktiPengiriman, error) {
+	var bukti models.PesananBuktiPengiriman
+	err := r.db.WithContext(ctx).
+		Where("pesanan_pengiriman_id = ? AND booking_ref = ?", pengirimanID, bookingRef).
+		First(&bukti).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bukti, nil
+}
+
+func (r *pesananBuktiPengirimanRepository) FindByPesananID(ctx context.Context, pesananID uuid.UUID) ([]models.PesananBuktiPengiriman, error) {
+	var list []models.PesananBuktiPengiriman
+	err := r.db.WithContext(ctx).
+		Where("pesanan_id = ?", pesananID).
+		Order("captured_at ASC").
+		Find(&list).Error
+	return list, err
+}
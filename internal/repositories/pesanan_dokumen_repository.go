@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PesananDokumenRepository interface {
+	// WithTx mengembalikan repository yang menulis lewat transaksi pemanggil
+	WithTx(tx *gorm.DB) PesananDokumenRepository
+	// NextNomor menaikkan counter tipe & tahun lalu mengembalikan nomor baru.
+	// Baris counter terkunci sampai transaksi selesai, jadi nomor hanya
+	// terpakai jika transaksi commit (tanpa celah).
+	NextNomor(ctx context.Context, tipe models.DokumenTipe, tahun int) (int, error)
+	Create(ctx context.Context, dokumen *models.PesananDokumen) error
+	FindByID(ctx context.Context, pesananID, id uuid.UUID) (*models.PesananDokumen, error)
+	FindByPesananID(ctx context.Context, pesananID uuid.UUID) ([]models.PesananDokumen, error)
+	// FindByTipe mengembalikan nil tanpa error jika dokumen belum terbit
+	FindByTipe(ctx context.Context, pesananID uuid.UUID, tipe models.DokumenTipe) (*models.PesananDokumen, error)
+}
+
+type pesananDokumenRepository struct {
+	db *gorm.DB
+}
+
+func NewPesananDokumenRepository(db *gorm.DB) PesananDokumenRepository {
+	return &pesananDokumenRepository{db: db}
+}
+
+func (r *pesananDokumenRepository) WithTx(tx *gorm.DB) PesananDokumenRepository {
+	return &pesananDokumenRepository{db: tx}
+}
+
+func (r *pesananDokumenRepository) NextNomor(ctx context.Context, tipe models.DokumenTipe, tahun int) (int, error) {
+	var nomor int
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO dokumen_nomor_urut (tipe, tahun, nomor_terakhir, updated_at)
+		VALUES (?, ?, 1, NOW())
+		ON CONFLICT (tipe, tahun) DO UPDATE
+		SET nomor_terakhir = dokumen_nomor_urut.nomor_terakhir + 1, updated_at = NOW()
+		RETURNING nomor_terakhir
+	`, tipe, tahun).Scan(&nomor).Error
+	return nomor, err
+}
+
+func (r *pesananDokumenRepository) Create(ctx context.Context, dokumen *models.PesananDokumen) error {
+	return r.db.WithContext(ctx).Create(dokumen).Error
+}
+
+func (r *pesananDokumenRepository) FindByID(ctx context.Context, pesananID, id uuid.UUID) (*models.PesananDokumen, error) {
+	var dokumen models.PesananDokumen
+	err := r.db.WithContext(ctx).First(&dokumen, "id = ? AND pesanan_id = ?", id, pesananID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &dokumen, nil
+}
+
+func (r *pesananDokumenRepository) FindByPesananID(ctx context.Context, pesananID uuid.UUID) ([]models.PesananDokumen, error) {
+	var dokumen []models.PesananDokumen
+	err := r.db.WithContext(ctx).
+		Where("pesanan_id = ?", pesananID).
+		Order("diterbitkan_at ASC").
+		Find(&dokumen).Error
+	return dokumen, err
+}
+
+func (r *pesananDokumenRepository) FindByTipe(ctx context.Context, pesananID uuid.UUID, tipe models.DokumenTipe) (*models.PesananDokumen, error) {
+	var dokumen models.PesananDokumen
+	err := r.db.WithContext(ctx).First(&dokumen, "pesanan_id = ? AND tipe = ?", pesananID, tipe).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dokumen, nil
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"context"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PesananPengirimanEventRepository interface {
+	// Record menyimpan event yang belum ada; event dengan provider_event_id yang
+	// sama untuk booking yang sama diabaikan. Mengembalikan jumlah event baru.
+	Record(ctx context.Context, events []models.PesananPengirimanEvent) (int64, error)
+	// FindByPengirimanID mengembalikan timeline booking pengiriman, urut waktu event
+	FindByPengirimanID(ctx context.Context, pengirimanID uuid.UUID, bookingRef string) ([]models.PesananPengirimanEvent, error)
+}
+
+type pesananPengirimanEventRepository struct {
+	db *gorm.DB
+}
+
+func NewPesananPengirimanEventRepository(db *gorm.DB) PesananPengirimanEventRepository {
+	return &pesananPengirimanEventRepository{db: db}
+}
+
+func (r *pesananPengirimanEventRepository) Record(ctx context.Context, events []models.PesananPengirimanEvent) (int64, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&events)
+	return result.RowsAffected, result.Error
+}
+
+func (r *pesananPengirimanEventRepository) FindByPengirimanID(ctx context.Context, pengirimanID uuid.UUID, bookingRef string) ([]models.PesananPengirimanEvent, error) {
+	var events []models.PesananPengirimanEvent
+	err := r.db.WithContext(ctx).
+		Where("pesanan_pengiriman_id = ? AND booking_ref = ?", pengirimanID, bookingRef).
+		Order("occurred_at ASC, created_at ASC").
+		Find(&events).Error
+	return events, err
+}
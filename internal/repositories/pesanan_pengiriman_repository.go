@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PesananPengirimanRepository interface {
+	// WithTx mengembalikan repository yang menulis lewat transaksi pemanggil
+	WithTx(tx *gorm.DB) PesananPengirimanRepository
+	Create(ctx context.Context, pengiriman *models.PesananPengiriman) error
+	// FindByPesananID mengembalikan semua pengiriman pesanan beserta gudang dan
+	// item-nya, diurutkan dari pengiriman utama
+	FindByPesananID(ctx context.Context, pesananID uuid.UUID) ([]models.PesananPengiriman, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.PesananPengiriman, error)
+	// FindByBookingRef mencari pengiriman dari booking ref provider untuk
+	// delivery type tertentu
+	FindByBookingRef(ctx context.Context, bookingRef string, deliveryTypes []models.DeliveryType) (*models.PesananPengiriman, error)
+	UpdateFields(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error
+	// AssignItems menautkan item pesanan ke pengiriman yang membawanya
+	AssignItems(ctx context.Context, pengirimanID uuid.UUID, itemIDs []uuid.UUID) error
+	// CountNotInStatus menghitung pengiriman pesanan yang booking_status-nya
+	// bukan status tertentu (termasuk yang belum punya status)
+	CountNotInStatus(ctx context.Context, pesananID uuid.UUID, bookingStatus string) (int64, error)
+	// ClearBookings mengosongkan booking pengiriman pesanan agar bisa di-booking
+	// ulang, kecuali pengiriman yang booking_status-nya ada di keepStatuses
+	ClearBookings(ctx context.Context, pesananID uuid.UUID, keepStatuses []string) (int64, error)
+	// FindPollable mengembalikan pengiriman ter-booking dengan delivery type
+	// tertentu yang belum selesai dan di-booking setelah since
+	FindPollable(ctx context.Context, deliveryTypes []models.DeliveryType, since time.Time, limit int) ([]models.PesananPengiriman, error)
+}
+
+type pesananPengirimanRepository struct {
+	db *gorm.DB
+}
+
+func NewPesananPengirimanRepository(db *gorm.DB) PesananPengirimanRepository {
+	return &pesananPengirimanRepository{db: db}
+}
+
+func (r *pesananPengirimanRepository) WithTx(tx *gorm.DB) PesananPengirimanRepository {
+	return &pesananPengirimanRepository{db: tx}
+}
+
+func (r *pesananPengirimanRepository) Create(ctx context.Context, pengiriman *models.PesananPengiriman) error {
+	return r.db.WithContext(ctx).Create(pengiriman).Error
+}
+
+func (r *pesananPengirimanRepository) FindByPesananID(ctx context.Context, pesananID uuid.UUID) ([]models.PesananPengiriman, error) {
+	var pengiriman []models.PesananPengiriman
+	err := r.db.WithContext(ctx).
+		Preload("Warehouse").
+		Preload("Items").
+		Preload("Items.Produk").
+		Where("pesanan_id = ?", pesananID).
+		Order("urutan ASC").
+		Find(&pengiriman).Error
+	return pengiriman, err
+}
+
+func (r *pesananPengirimanRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.PesananPengiriman, error) {
+	var pengiriman models.PesananPengiriman
+	err := r.db.WithContext(ctx).
+		Preload("Warehouse").
+		Preload("Items").
+		Preload("Items.Produk").
+		First(&pengiriman, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &pengiriman, nil
+}
+
+func (r *pesananPengirimanRepository) FindByBookingRef(ctx context.Context, bookingRef string, deliveryTypes []models.DeliveryType) (*models.PesananPengiriman, error) {
+	var pengiriman models.PesananPengiriman
+	err := r.db.WithContext(ctx).
+		Where("booking_ref = ? AND delivery_type IN ?", bookingRef, deliveryTypes).
+		Order("created_at DESC").
+		First(&pengiriman).Error
+	if err != nil {
+		return nil, err
+	}
+	return &pengiriman, nil
+}
+
+func (r *pesananPengirimanRepository) UpdateFields(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Model(&models.PesananPengiriman{}).
+		Where("id = ?", id).
+		UpdateColumns(fields).Error
+}
+
+func (r *pesananPengirimanRepository) AssignItems(ctx context.Context, pengirimanID uuid.UUID, itemIDs []uuid.UUID) error {
+	if len(itemIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Model(&models.PesananItem{}).
+		Where("id IN ?", itemIDs).
+		UpdateColumn("pesanan_pengiriman_id", pengirimanID).Error
+}
+
+func (r *pesananPengirimanRepository) CountNotInStatus(ctx context.Context, pesananID uuid.UUID, bookingStatus string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.PesananPengiriman{}).
+		Where("pesanan_id = ? AND booking_status IS DISTINCT FROM ?", pesananID, bookingStatus).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *pesananPengirimanRepository) ClearBookings(ctx context.Context, pesananID uuid.UUID, keepStatuses []string) (int64, error) {
+	query := r.db.WithContext(ctx).
+		Model(&models.PesananPengiriman{}).
+		Where("pesanan_id = ? AND booking_ref IS NOT NULL", pesananID)
+	if len(keepStatuses) > 0 {
+		query = query.Where("(booking_status IS NULL OR booking_status NOT IN ?)", keepStatuses)
+	}
+	result := query.UpdateColumns(map[string]interface{}{
+		"booking_ref":    nil,
+		"booking_status": nil,
+		"booking_error":  nil,
+		"tracking_url":   nil,
+		"booked_at":      nil,
+	})
+	return result.RowsAffected, result.Error
+}
+
+func (r *pesananPengirimanRepository) FindPollable(ctx context.Context, deliveryTypes []models.DeliveryType, since time.Time, limit int) ([]models.PesananPengiriman, error) {
+	var pengiriman []models.PesananPengiriman
+	err := r.db.WithContext(ctx).
+		Joins("JOIN pesanan p ON p.id = pesanan_pengiriman.pesanan_id AND p.deleted_at IS NULL").
+		Where("pesanan_pengiriman.delivery_type IN ?", deliveryTypes).
+		Where("pesanan_pengiriman.booking_ref IS NOT NULL AND pesanan_pengiriman.completed_at IS NULL").
+		Where("pesanan_pengiriman.booked_at >= ?", since).
+		Where("p.order_status <> ?", models.OrderStatusCancelled).
+		Order("pesanan_pengiriman.booked_at ASC").
+		Limit(limit).
+		Find(&pengiriman).Error
+	return pengiriman, err
+}
@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"context"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PesananRefundFilter filter daftar refund (kosong = semua)
+type PesananRefundFilter struct {
+	Status    string
+	PesananID *uuid.UUID
+	BuyerID   *uuid.UUID
+}
+
+type PesananRefundRepository interface {
+	// WithTx mengembalikan repository yang menulis lewat transaksi pemanggil
+	WithTx(tx *gorm.DB) PesananRefundRepository
+	Create(ctx context.Context, refunds []models.PesananRefund) error
+	// FindByID mengembalikan refund beserta pesanan, pembayaran & buyer
+	FindByID(ctx context.Context, id uuid.UUID) (*models.PesananRefund, error)
+	FindByPesananID(ctx context.Context, pesananID uuid.UUID) ([]models.PesananRefund, error)
+	FindAll(ctx context.Context, filter PesananRefundFilter, page, perPage int) ([]models.PesananRefund, int64, error)
+	// FindProcessing mengembalikan refund yang masih menunggu hasil payment gateway
+	FindProcessing(ctx context.Context, limit int) ([]models.PesananRefund, error)
+	// UpdateStatus mengubah refund hanya jika statusnya masih salah satu from;
+	// false jika status sudah diubah proses lain
+	UpdateStatus(ctx context.Context, id uuid.UUID, from []models.RefundStatus, fields map[string]interface{}) (bool, error)
+	// LockPembayaran mengunci pembayaran pesanan (SELECT ... FOR UPDATE) agar
+	// pengajuan refund paralel tidak melebihi jumlah yang dibayar
+	LockPembayaran(ctx context.Context, pesananID uuid.UUID) ([]models.PesananPembayaran, error)
+}
+
+type pesananRefundRepository struct {
+	db *gorm.DB
+}
+
+func NewPesananRefundRepository(db *gorm.DB) PesananRefundRepository {
+	return &pesananRefundRepository{db: db}
+}
+
+func (r *pesananRefundRepository) WithTx(tx *gorm.DB) PesananRefundRepository {
+	return &pesananRefundRepository{db: tx}
+}
+
+func (r *pesananRefundRepository) Create(ctx context.Context, refunds []models.PesananRefund) error {
+	return r.db.WithContext(ctx).Create(&refunds).Error
+}
+
+func (r *pesananRefundRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.PesananRefund, error) {
+	var refund models.PesananRefund
+	err := r.db.WithContext(ctx).
+		Preload("Pesanan").
+		Preload("Pembayaran").
+		Preload("Buyer").
+		First(&refund, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *pesananRefundRepository) FindByPesananID(ctx context.Context, pesananID uuid.UUID) ([]models.PesananRefund, error) {
+	var refunds []models.PesananRefund
+	err := r.db.WithContext(ctx).
+		Preload("Buyer").
+		Where("pesanan_id = ?", pesananID).
+		Order("created_at ASC").
+		Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *pesananRefundRepository) FindAll(ctx context.Context, filter PesananRefundFilter, page, perPage int) ([]models.PesananRefund, int64, error) {
+	var refunds []models.PesananRefund
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.PesananRefund{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.PesananID != nil {
+		query = query.Where("pesanan_id = ?", *filter.PesananID)
+	}
+	if filter.BuyerID != nil {
+		query = query.Where("buyer_id = ?", *filter.BuyerID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	err := query.Preload("Pesanan").Preload("Buyer").
+		Order("created_at DESC").Offset(offset).Limit(perPage).Find(&refunds).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return refunds, total, nil
+}
+
+func (r *pesananRefundRepository) FindProcessing(ctx context.Context, limit int) ([]models.PesananRefund, error) {
+	var refunds []models.PesananRefund
+	err := r.db.WithContext(ctx).
+		Preload("Pembayaran").
+		Where("status = ?", models.RefundStatusProcessing).
+		Order("approved_at ASC").
+		Limit(limit).
+		Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *pesananRefundRepository) UpdateStatus(ctx context.Context, id uuid.UUID, from []models.RefundStatus, fields map[string]interface{}) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.PesananRefund{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(fields)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *pesananRefundRepository) LockPembayaran(ctx context.Context, pesananID uuid.UUID) ([]models.PesananPembayaran, error) {
+	var pembayaran []models.PesananPembayaran
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("pesanan_id = ?", pesananID).
+		Order("created_at ASC").
+		Find(&pembayaran).Error
+	return pembayaran, err
+}
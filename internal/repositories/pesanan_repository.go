@@ -17,22 +17,18 @@ type PesananRepository interface {
 	// Admin methods
 	AdminFindAll(filters map[string]interface{}, page, perPage int, sortBy, sortOrder string) ([]models.Pesanan, int64, error)
 	AdminFindByID(id uuid.UUID) (*models.Pesanan, error)
-	UpdateStatus(id uuid.UUID, orderStatus models.OrderStatus, note *string, adminID uuid.UUID) error
 	UpdateBookingResult(id uuid.UUID, delivereeBookingID *string, forwarderTrackingNo *string, bookingError *string) error
 	ClearBookingResult(id uuid.UUID) error
-	// UpdateFromWebhook memperbarui status pesanan dari event webhook provider
-	// (Deliveree/Forwarder). Tidak memvalidasi transisi status karena event
-	// webhook bisa datang kapan saja dan berulang (idempotent).
-	UpdateFromWebhook(id uuid.UUID, orderStatus models.OrderStatus, extraUpdates map[string]interface{}) error
+	// UpdateBookingInfo memperbarui kolom pendukung booking dari webhook provider
+	// (booking_status, tracking_url) tanpa menyentuh order_status. Perubahan
+	// order_status selalu lewat orderstate.Machine.
+	UpdateBookingInfo(id uuid.UUID, columns map[string]interface{}) error
 	Delete(id uuid.UUID) error
 	GetStatistics(tanggalDari, tanggalSampai *time.Time) (map[string]interface{}, error)
 	GetChartData(dari, sampai *time.Time, groupBy string) ([]models.ChartRawPoint, error)
 	// CountPaidNotProcessed menghitung pesanan yang sudah dibayar (PAID) tapi
 	// masih PROCESSING — belum di-set admin ke READY/SHIPPED.
 	CountPaidNotProcessed() (int64, error)
-
-	// Cancel order & restore produk
-	CancelOrder(id uuid.UUID, reason *string, adminID uuid.UUID) error
 }
 
 type pesananRepository struct {
@@ -129,6 +125,9 @@ func (r *pesananRepository) AdminFindByID(id uuid.UUID) (*models.Pesanan, error)
 		Preload("Pembayaran").
 		Preload("Pembayaran.MetodePembayaran").
 		Preload("Pembayaran.Buyer").
+		Preload("Pengiriman", func(db *gorm.DB) *gorm.DB { return db.Order("urutan ASC") }).
+		Preload("Pengiriman.Warehouse").
+		Preload("Pengiriman.Items").
 		First(&pesanan, "id = ?", id).Error
 
 	if err != nil {
@@ -138,131 +137,6 @@ func (r *pesananRepository) AdminFindByID(id uuid.UUID) (*models.Pesanan, error)
 	return &pesanan, nil
 }
 
-func (r *pesananRepository) UpdateStatus(id uuid.UUID, orderStatus models.OrderStatus, note *string, adminID uuid.UUID) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		// Get current pesanan
-		var pesanan models.Pesanan
-		if err := tx.First(&pesanan, "id = ?", id).Error; err != nil {
-			return err
-		}
-
-		// Validate status transition
-		if !isValidStatusTransition(pesanan.DeliveryType, pesanan.OrderStatus, orderStatus) {
-			return fmt.Errorf("tidak dapat mengubah status dari %s ke %s", pesanan.OrderStatus, orderStatus)
-		}
-
-		// Update pesanan status
-		updates := map[string]interface{}{
-			"order_status": orderStatus,
-		}
-
-		// Set timestamp based on status
-		now := time.Now()
-		switch orderStatus {
-		case models.OrderStatusProcessing:
-			updates["processed_at"] = now
-		case models.OrderStatusReady:
-			updates["ready_at"] = now
-		case models.OrderStatusShipped:
-			updates["shipped_at"] = now
-		case models.OrderStatusCompleted:
-			updates["completed_at"] = now
-		case models.OrderStatusCancelled:
-			updates["cancelled_at"] = now
-			if note != nil {
-				updates["cancelled_reason"] = *note
-			}
-		}
-
-		// Capture statusFrom BEFORE update — GORM mutates the struct in memory after Updates()
-		statusFrom := string(pesanan.OrderStatus)
-
-		if err := tx.Model(&pesanan).Where("id = ?", id).Updates(updates).Error; err != nil {
-			return err
-		}
-
-		// Create status history
-		history := models.PesananStatusHistory{
-			PesananID:  id,
-			StatusFrom: &statusFrom,
-			StatusTo:   string(orderStatus),
-			StatusType: models.StatusHistoryTypeOrder,
-			ChangedBy:  &adminID,
-			Note:       note,
-		}
-
-		if err := tx.Create(&history).Error; err != nil {
-			return err
-		}
-
-		return nil
-	})
-}
-
-// CancelOrder sets order status to CANCELLED, records history, and restores is_sold on all produk items.
-func (r *pesananRepository) CancelOrder(id uuid.UUID, reason *string, adminID uuid.UUID) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		// 1. Load pesanan
-		var pesanan models.Pesanan
-		if err := tx.Preload("Items").First(&pesanan, "id = ?", id).Error; err != nil {
-			return err
-		}
-
-		// 2. Validate: only non-terminal statuses can be cancelled
-		if pesanan.OrderStatus == models.OrderStatusCompleted {
-			return fmt.Errorf("pesanan dengan status COMPLETED tidak dapat dibatalkan")
-		}
-		if pesanan.OrderStatus == models.OrderStatusCancelled {
-			return fmt.Errorf("pesanan sudah berstatus CANCELLED")
-		}
-
-		// 3. Update pesanan → CANCELLED
-		now := time.Now()
-		updates := map[string]interface{}{
-			"order_status": models.OrderStatusCancelled,
-			"cancelled_at": now,
-		}
-		if reason != nil && *reason != "" {
-			updates["cancelled_reason"] = *reason
-		}
-		statusFrom := string(pesanan.OrderStatus)
-		if err := tx.Model(&pesanan).Where("id = ?", id).Updates(updates).Error; err != nil {
-			return err
-		}
-
-		// 4. Record status history
-		history := models.PesananStatusHistory{
-			PesananID:  id,
-			StatusFrom: &statusFrom,
-			StatusTo:   string(models.OrderStatusCancelled),
-			StatusType: models.StatusHistoryTypeOrder,
-			ChangedBy:  &adminID,
-			Note:       reason,
-		}
-		if err := tx.Create(&history).Error; err != nil {
-			return err
-		}
-
-		// 5. Restore is_sold = false dan is_active = true untuk semua produk di order ini.
-		// is_active juga direstore karena produk yang sudah SHIPPED/COMPLETED bisa saja
-		// sempat diarsipkan otomatis (auto-archive job) sebelum order ini dibatalkan.
-		produkIDs := make([]uuid.UUID, 0, len(pesanan.Items))
-		for _, item := range pesanan.Items {
-			produkIDs = append(produkIDs, item.ProdukID)
-		}
-		if len(produkIDs) > 0 {
-			if err := tx.Model(&models.Produk{}).Where("id IN ?", produkIDs).Updates(map[string]interface{}{
-				"is_sold":   false,
-				"is_active": true,
-			}).Error; err != nil {
-				return err
-			}
-		}
-
-		return nil
-	})
-}
-
 func (r *pesananRepository) ClearBookingResult(id uuid.UUID) error {
 	return r.db.Model(&models.Pesanan{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
 		"deliveree_booking_id":  nil,
@@ -272,86 +146,11 @@ func (r *pesananRepository) ClearBookingResult(id uuid.UUID) error {
 	}).Error
 }
 
-// UpdateFromWebhook memperbarui status pesanan dari event webhook provider.
-//
-// Berbeda dari UpdateStatus (yang memvalidasi transisi status dan butuh adminID),
-// method ini langsung mengubah status ke nilai yang diberikan oleh provider karena:
-// 1. Event webhook bisa datang di luar urutan transisi normal (mis. timeout → in_progress).
-// 2. Deliveree bisa mengirim ulang event yang sama (retry) — method ini idempotent.
-//
-// extraUpdates dipakai untuk kolom pendukung seperti booking_status/tracking_url
-// yang dikirim provider bersama event.
-func (r *pesananRepository) UpdateFromWebhook(id uuid.UUID, orderStatus models.OrderStatus, extraUpdates map[string]interface{}) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		var pesanan models.Pesanan
-		if err := tx.First(&pesanan, "id = ?", id).Error; err != nil {
-			return err
-		}
-
-		// Idempotent: jika status sudah sama, hanya update kolom pendukung.
-		if pesanan.OrderStatus == orderStatus {
-			if len(extraUpdates) > 0 {
-				return tx.Model(&models.Pesanan{}).Where("id = ?", id).UpdateColumns(extraUpdates).Error
-			}
-			return nil
-		}
-
-		// Anti-regresi: event provider bisa datang terlambat / di luar urutan.
-		// Status terminal (COMPLETED) dan status yang sudah melewati tahap
-		// pengiriman (SHIPPED) tidak boleh diturunkan oleh webhook.
-		// Webhook tetap meng-update kolom pendukung (booking_status, tracking_url)
-		// tapi order_status dibiarkan apa adanya.
-		if pesanan.OrderStatus == models.OrderStatusCompleted ||
-			pesanan.OrderStatus == models.OrderStatusShipped {
-			if len(extraUpdates) > 0 {
-				return tx.Model(&models.Pesanan{}).Where("id = ?", id).UpdateColumns(extraUpdates).Error
-			}
-			return nil
-		}
-
-		updates := map[string]interface{}{
-			"order_status": orderStatus,
-		}
-		for k, v := range extraUpdates {
-			updates[k] = v
-		}
-
-		// Set timestamp berdasarkan status
-		now := time.Now()
-		switch orderStatus {
-		case models.OrderStatusProcessing:
-			updates["processed_at"] = now
-		case models.OrderStatusShipped:
-			updates["shipped_at"] = now
-		case models.OrderStatusCompleted:
-			updates["completed_at"] = now
-		case models.OrderStatusCancelled:
-			updates["cancelled_at"] = now
-		}
-
-		statusFrom := string(pesanan.OrderStatus)
-		if err := tx.Model(&pesanan).Where("id = ?", id).Updates(updates).Error; err != nil {
-			return err
-		}
-
-		// Catat riwayat status — ChangedBy nil karena bukan aksi admin
-		history := models.PesananStatusHistory{
-			PesananID:  id,
-			StatusFrom: &statusFrom,
-			StatusTo:   string(orderStatus),
-			StatusType: models.StatusHistoryTypeOrder,
-			Note:       ptrString("Update otomatis dari webhook provider"),
-		}
-		if err := tx.Create(&history).Error; err != nil {
-			return err
-		}
-
+func (r *pesananRepository) UpdateBookingInfo(id uuid.UUID, columns map[string]interface{}) error {
+	if len(columns) == 0 {
 		return nil
-	})
-}
-
-func ptrString(s string) *string {
-	return &s
+	}
+	return r.db.Model(&models.Pesanan{}).Where("id = ?", id).UpdateColumns(columns).Error
 }
 
 func (r *pesananRepository) UpdateBookingResult(id uuid.UUID, delivereeBookingID *string, forwarderTrackingNo *string, bookingError *string) error {
@@ -498,51 +297,3 @@ func (r *pesananRepository) GetChartData(dari, sampai *time.Time, groupBy string
 	}
 	return results, nil
 }
-
-// Helper function to validate status transitions.
-// READY → COMPLETED hanya diizinkan untuk tipe PICKUP (barang diambil sendiri
-// buyer, tanpa tahap SHIPPED). Untuk tipe kirim (DELIVEREE/FORWARDER/…),
-// READY harus lanjut ke SHIPPED dulu sebelum COMPLETED.
-func isValidStatusTransition(deliveryType models.DeliveryType, from, to models.OrderStatus) bool {
-	validTransitions := map[models.OrderStatus][]models.OrderStatus{
-		models.OrderStatusPending: {
-			models.OrderStatusProcessing,
-			models.OrderStatusCancelled,
-		},
-		models.OrderStatusProcessing: {
-			models.OrderStatusReady,
-			models.OrderStatusCancelled,
-		},
-		models.OrderStatusReady: {
-			models.OrderStatusShipped,
-			models.OrderStatusCancelled,
-		},
-		models.OrderStatusShipped: {
-			models.OrderStatusCompleted,
-			models.OrderStatusCancelled,
-		},
-		models.OrderStatusCompleted: {},
-		models.OrderStatusCancelled: {},
-	}
-
-	// PICKUP: langsung READY → COMPLETED tanpa SHIPPED (buyer ambil sendiri)
-	if deliveryType == models.DeliveryTypePickup {
-		validTransitions[models.OrderStatusReady] = append(
-			validTransitions[models.OrderStatusReady],
-			models.OrderStatusCompleted,
-		)
-	}
-
-	allowedTransitions, ok := validTransitions[from]
-	if !ok {
-		return false
-	}
-
-	for _, allowed := range allowedTransitions {
-		if allowed == to {
-			return true
-		}
-	}
-
-	return false
-}
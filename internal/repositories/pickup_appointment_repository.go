@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// errPickupSlotPenuh membatalkan transaksi Book agar pembatalan janji lama
+// (reschedule) ikut di-rollback saat slot baru penuh
+var errPickupSlotPenuh = errors.New("slot pickup penuh")
+
+type PickupAppointmentFilter struct {
+	WarehouseID *uuid.UUID
+	// Status kosong = semua janji kecuali CANCELLED
+	Status string
+}
+
+type PickupAppointmentRepository interface {
+	// Book menyimpan janji baru jika slot masih punya kapasitas. Booking per
+	// gudang dikunci (advisory lock) selama transaksi agar booking paralel tidak
+	// melebihi kapasitas. replaceID diisi saat reschedule: janji lama dibatalkan
+	// di transaksi yang sama (gorm.ErrRecordNotFound jika sudah tidak BOOKED).
+	// Mengembalikan false jika slot penuh.
+	Book(ctx context.Context, appointment *models.PickupAppointment, kapasitas int, replaceID *uuid.UUID) (bool, error)
+	// Cancel membatalkan janji yang masih BOOKED; false jika status sudah berubah
+	Cancel(ctx context.Context, id uuid.UUID, alasan *string) (bool, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.PickupAppointment, error)
+	FindActiveByPesananID(ctx context.Context, pesananID uuid.UUID) ([]models.PickupAppointment, error)
+	// FindBooked mengembalikan janji BOOKED yang bertumpuk dengan rentang [dari, sampai)
+	FindBooked(ctx context.Context, warehouseID uuid.UUID, dari, sampai time.Time) ([]models.PickupAppointment, error)
+	// FindByRange mengembalikan janji di rentang [dari, sampai) beserta pesanan,
+	// buyer & gudang untuk kalender admin
+	FindByRange(ctx context.Context, filter PickupAppointmentFilter, dari, sampai time.Time) ([]models.PickupAppointment, error)
+}
+
+type pickupAppointmentRepository struct {
+	db *gorm.DB
+}
+
+func NewPickupAppointmentRepository(db *gorm.DB) PickupAppointmentRepository {
+	return &pickupAppointmentRepository{db: db}
+}
+
+func (r *pickupAppointmentRepository) Book(ctx context.Context, appointment *models.PickupAppointment, kapasitas int, replaceID *uuid.UUID) (bool, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "pickup_appointment|"+appointment.WarehouseID.String()).Error; err != nil {
+			return err
+		}
+
+		if replaceID != nil {
+			result := tx.Model(&models.PickupAppointment{}).
+				Where("id = ? AND status = ?", *replaceID, models.PickupAppointmentBooked).
+				Updates(map[string]interface{}{
+					"status":       models.PickupAppointmentCancelled,
+					"alasan_batal": "Dijadwal ulang",
+					"cancelled_at": time.Now(),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+		}
+
+		// Slot yang bertumpuk ikut dihitung agar perubahan durasi slot di
+		// jadwal tidak membuat janji lama terlewat dari kapasitas
+		var terisi int64
+		if err := tx.Model(&models.PickupAppointment{}).
+			Where("warehouse_id = ? AND status = ? AND slot_mulai < ? AND slot_selesai > ?",
+				appointment.WarehouseID, models.PickupAppointmentBooked, appointment.SlotSelesai, appointment.SlotMulai).
+			Count(&terisi).Error; err != nil {
+			return err
+		}
+		if int(terisi) >= kapasitas {
+			return errPickupSlotPenuh
+		}
+
+		return tx.Create(appointment).Error
+	})
+	if errors.Is(err, errPickupSlotPenuh) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *pickupAppointmentRepository) Cancel(ctx context.Context, id uuid.UUID, alasan *string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.PickupAppointment{}).
+		Where("id = ? AND status = ?", id, models.PickupAppointmentBooked).
+		Updates(map[string]interface{}{
+			"status":       models.PickupAppointmentCancelled,
+			"alasan_batal": alasan,
+			"cancelled_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *pickupAppointmentRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.PickupAppointment, error) {
+	var appointment models.PickupAppointment
+	err := r.db.WithContext(ctx).
+		Preload("Warehouse").
+		First(&appointment, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+func (r *pickupAppointmentRepository) FindActiveByPesananID(ctx context.Context, pesananID uuid.UUID) ([]models.PickupAppointment, error) {
+	var list []models.PickupAppointment
+	err := r.db.WithContext(ctx).
+		Preload("Warehouse").
+		Where("pesanan_id = ? AND status = ?", pesananID, models.PickupAppointmentBooked).
+		Order("slot_mulai ASC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *pickupAppointmentRepository) FindBooked(ctx context.Context, warehouseID uuid.UUID, dari, sampai time.Time) ([]models.PickupAppointment, error) {
+	var list []models.PickupAppointment
+	err := r.db.WithContext(ctx).
+		Where("warehouse_id = ? AND status = ? AND slot_mulai < ? AND slot_selesai > ?",
+			warehouseID, models.PickupAppointmentBooked, sampai, dari).
+		Find(&list).Error
+	return list, err
+}
+
+func (r *pickupAppointmentRepository) FindByRange(ctx context.Context, filter PickupAppointmentFilter, dari, sampai time.Time) ([]models.PickupAppointment, error) {
+	query := r.db.WithContext(ctx).
+		Preload("Pesanan").
+		Preload("Buyer").
+		Preload("Warehouse").
+		Where("slot_mulai >= ? AND slot_mulai < ?", dari, sampai)
+	if filter.WarehouseID != nil {
+		query = query.Where("warehouse_id = ?", *filter.WarehouseID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	} else {
+		query = query.Where("status <> ?", models.PickupAppointmentCancelled)
+	}
+
+	var list []models.PickupAppointment
+	err := query.Order("slot_mulai ASC, created_at ASC").Find(&list).Error
+	return list, err
+}
@@ -11,6 +11,7 @@ import (
 )
 
 type ProdukRepository interface {
+	WithTx(tx *gorm.DB) ProdukRepository
 	Create(ctx context.Context, produk *models.Produk) error
 	FindByID(ctx context.Context, id string) (*models.Produk, error)
 	FindBySlug(ctx context.Context, slug string) (*models.Produk, error)
@@ -20,7 +21,7 @@ type ProdukRepository interface {
 	ExistsBySlug(ctx context.Context, slug string, excludeID *string) (bool, error)
 	ExistsByIDCargo(ctx context.Context, idCargo string, excludeID *string) (bool, error)
 	UpdateIsSoldBatch(ctx context.Context, ids []uuid.UUID, isSold bool) error
-	FindSoldProdukToArchive(ctx context.Context, threshold time.Time) ([]models.Produk, error)
+	FindSoldProdukToArchive(ctx context.Context, statuses []models.OrderStatus, threshold time.Time) ([]models.Produk, error)
 	ArchiveProduk(ctx context.Context, id uuid.UUID) error
 }
 
@@ -32,6 +33,10 @@ func NewProdukRepository(db *gorm.DB) ProdukRepository {
 	return &produkRepository{db: db}
 }
 
+func (r *produkRepository) WithTx(tx *gorm.DB) ProdukRepository {
+	return &produkRepository{db: tx}
+}
+
 func (r *produkRepository) Create(ctx context.Context, produk *models.Produk) error {
 	return r.db.WithContext(ctx).Create(produk).Error
 }
@@ -244,13 +249,14 @@ func (r *produkRepository) UpdateIsSoldBatch(ctx context.Context, ids []uuid.UUI
 }
 
 // FindSoldProdukToArchive mengembalikan produk yang sudah terjual (is_sold=true, is_active=true)
-// dari order yang statusnya SHIPPED atau COMPLETED, dan sudah melewati threshold waktu yang
-// dihitung dari COALESCE(shipped_at, completed_at) milik order tersebut.
+// dari order yang statusnya termasuk `statuses` (orderstate.HandedOverStatuses: SHIPPED/COMPLETED),
+// dan sudah melewati threshold waktu yang dihitung dari COALESCE(shipped_at, completed_at) milik
+// order tersebut.
 //
 // Catatan: order dengan delivery_type=PICKUP tidak pernah memiliki status SHIPPED (langsung
 // READY -> COMPLETED), sehingga COALESCE(shipped_at, completed_at) otomatis jatuh ke completed_at
 // untuk kasus tersebut tanpa perlu pengecekan delivery_type terpisah.
-func (r *produkRepository) FindSoldProdukToArchive(ctx context.Context, threshold time.Time) ([]models.Produk, error) {
+func (r *produkRepository) FindSoldProdukToArchive(ctx context.Context, statuses []models.OrderStatus, threshold time.Time) ([]models.Produk, error) {
 	var produk []models.Produk
 	query := `
 		SELECT DISTINCT p.*
@@ -259,10 +265,10 @@ func (r *produkRepository) FindSoldProdukToArchive(ctx context.Context, threshol
 		JOIN pesanan pe ON pe.id = pi.pesanan_id
 		WHERE p.is_sold = true
 		  AND p.is_active = true
-		  AND pe.order_status IN ('SHIPPED', 'COMPLETED')
+		  AND pe.order_status IN ?
 		  AND COALESCE(pe.shipped_at, pe.completed_at) <= ?
 	`
-	err := r.db.WithContext(ctx).Raw(query, threshold).Scan(&produk).Error
+	err := r.db.WithContext(ctx).Raw(query, statuses, threshold).Scan(&produk).Error
 	return produk, err
 }
 
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProviderWebhookLogRepository interface {
+	// Create menyimpan log webhook. Log ACCEPTED dengan event yang sudah
+	// diterima sebelumnya (belum FAILED) tidak disimpan (created false).
+	Create(ctx context.Context, log *models.ProviderWebhookLog) (created bool, err error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.ProviderWebhookLog, error)
+	FindAll(ctx context.Context, filter ProviderWebhookLogFilter, page, perPage int) ([]models.ProviderWebhookLog, int64, error)
+	// ExistsAccepted true jika event sudah diterima lewat log lain yang belum FAILED
+	ExistsAccepted(ctx context.Context, provider, eventKey string, excludeID uuid.UUID) (bool, error)
+	// MarkResult menyimpan hasil pemrosesan; reprocess menaikkan reprocess_count
+	MarkResult(ctx context.Context, id uuid.UUID, status models.WebhookProcessStatus, processError *string, reprocess bool) error
+}
+
+// ProviderWebhookLogFilter filter daftar log webhook (kosong = semua)
+type ProviderWebhookLogFilter struct {
+	Provider      string
+	Verification  string
+	ProcessStatus string
+	BookingRef    string
+}
+
+type providerWebhookLogRepository struct {
+	db *gorm.DB
+}
+
+func NewProviderWebhookLogRepository(db *gorm.DB) ProviderWebhookLogRepository {
+	return &providerWebhookLogRepository{db: db}
+}
+
+func (r *providerWebhookLogRepository) Create(ctx context.Context, log *models.ProviderWebhookLog) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(log)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *providerWebhookLogRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.ProviderWebhookLog, error) {
+	var log models.ProviderWebhookLog
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&log).Error; err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+func (r *providerWebhookLogRepository) FindAll(ctx context.Context, filter ProviderWebhookLogFilter, page, perPage int) ([]models.ProviderWebhookLog, int64, error) {
+	var logs []models.ProviderWebhookLog
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.ProviderWebhookLog{})
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	if filter.Verification != "" {
+		query = query.Where("verification = ?", filter.Verification)
+	}
+	if filter.ProcessStatus != "" {
+		query = query.Where("process_status = ?", filter.ProcessStatus)
+	}
+	if filter.BookingRef != "" {
+		query = query.Where("booking_ref = ?", filter.BookingRef)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	if err := query.Order("received_at DESC").Offset(offset).Limit(perPage).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+func (r *providerWebhookLogRepository) ExistsAccepted(ctx context.Context, provider, eventKey string, excludeID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.ProviderWebhookLog{}).
+		Where("provider = ? AND event_key = ? AND verification = ? AND process_status <> ? AND id <> ?",
+			provider, eventKey, models.WebhookVerificationAccepted, models.WebhookProcessFailed, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *providerWebhookLogRepository) MarkResult(ctx context.Context, id uuid.UUID, status models.WebhookProcessStatus, processError *string, reprocess bool) error {
+	updates := map[string]interface{}{
+		"process_status": status,
+		"process_error":  processError,
+		"processed_at":   time.Now(),
+	}
+	if reprocess {
+		updates["reprocess_count"] = gorm.Expr("reprocess_count + 1")
+	}
+	return r.db.WithContext(ctx).Model(&models.ProviderWebhookLog{}).Where("id = ?", id).Updates(updates).Error
+}
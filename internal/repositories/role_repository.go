@@ -47,10 +47,11 @@ func (r *roleRepository) FindAll() ([]models.RoleResponseFormat, error) {
 	var result []models.RoleResponseFormat
 	for _, role := range roles {
 		result = append(result, models.RoleResponseFormat{
-			ID:        role.ID.String(),
-			Nama:      role.Nama,
-			Kode:      role.Kode,
-			Deskripsi: role.Deskripsi,
+			ID:               role.ID.String(),
+			Nama:             role.Nama,
+			Kode:             role.Kode,
+			Deskripsi:        role.Deskripsi,
+			RequireTwoFactor: role.RequireTwoFactor,
 		})
 	}
 
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ShippingCoverageZoneRepository interface {
+	FindAll(ctx context.Context, params *models.ShippingCoverageZoneFilterRequest) ([]models.ShippingCoverageZone, int64, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.ShippingCoverageZone, error)
+	// FindActive mengembalikan semua zona aktif, dipakai resolver coverage
+	FindActive(ctx context.Context) ([]models.ShippingCoverageZone, error)
+	Create(ctx context.Context, zone *models.ShippingCoverageZone) error
+	Update(ctx context.Context, zone *models.ShippingCoverageZone) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type shippingCoverageZoneRepository struct {
+	db *gorm.DB
+}
+
+func NewShippingCoverageZoneRepository(db *gorm.DB) ShippingCoverageZoneRepository {
+	return &shippingCoverageZoneRepository{db: db}
+}
+
+func (r *shippingCoverageZoneRepository) FindAll(ctx context.Context, params *models.ShippingCoverageZoneFilterRequest) ([]models.ShippingCoverageZone, int64, error) {
+	var zones []models.ShippingCoverageZone
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.ShippingCoverageZone{})
+
+	if params.Provider != "" {
+		query = query.Where("provider = ?", params.Provider)
+	}
+	if params.IsActive != nil {
+		query = query.Where("is_active = ?", *params.IsActive)
+	}
+	if params.Search != "" {
+		search := "%" + params.Search + "%"
+		query = query.Where("provinsi ILIKE ? OR kota ILIKE ? OR aliases::text ILIKE ?", search, search, search)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	validSortFields := map[string]bool{
+		"provider":   true,
+		"provinsi":   true,
+		"kota":       true,
+		"is_active":  true,
+		"created_at": true,
+		"updated_at": true,
+	}
+	sortBy := params.SortBy
+	if !validSortFields[sortBy] {
+		sortBy = "provinsi"
+	}
+	order := params.Order
+	if order != "asc" && order != "desc" {
+		order = "asc"
+	}
+
+	query = query.Order(sortBy + " " + order).Order("kota ASC NULLS FIRST")
+	query = query.Offset(params.GetOffset()).Limit(params.PerPage)
+
+	if err := query.Find(&zones).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return zones, total, nil
+}
+
+func (r *shippingCoverageZoneRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.ShippingCoverageZone, error) {
+	var zone models.ShippingCoverageZone
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&zone).Error
+	if err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+func (r *shippingCoverageZoneRepository) FindActive(ctx context.Context) ([]models.ShippingCoverageZone, error) {
+	var zones []models.ShippingCoverageZone
+	err := r.db.WithContext(ctx).
+		Where("is_active = ?", true).
+		Order("provider ASC, provinsi ASC").
+		Find(&zones).Error
+	return zones, err
+}
+
+func (r *shippingCoverageZoneRepository) Create(ctx context.Context, zone *models.ShippingCoverageZone) error {
+	return r.db.WithContext(ctx).Create(zone).Error
+}
+
+func (r *shippingCoverageZoneRepository) Update(ctx context.Context, zone *models.ShippingCoverageZone) error {
+	return r.db.WithContext(ctx).Save(zone).Error
+}
+
+func (r *shippingCoverageZoneRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.ShippingCoverageZone{}).Error
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ShippingQuoteRepository interface {
+	Create(ctx context.Context, quote *models.ShippingQuote) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.ShippingQuote, error)
+	// FindValidByHash mengembalikan quote terbaru untuk request yang sama yang
+	// belum kedaluwarsa pada waktu now
+	FindValidByHash(ctx context.Context, requestHash string, now time.Time) (*models.ShippingQuote, error)
+	// DeleteExpired menghapus quote yang kedaluwarsa sebelum cutoff dan tidak
+	// dipakai pesanan mana pun
+	DeleteExpired(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type shippingQuoteRepository struct {
+	db *gorm.DB
+}
+
+func NewShippingQuoteRepository(db *gorm.DB) ShippingQuoteRepository {
+	return &shippingQuoteRepository{db: db}
+}
+
+func (r *shippingQuoteRepository) Create(ctx context.Context, quote *models.ShippingQuote) error {
+	return r.db.WithContext(ctx).Create(quote).Error
+}
+
+func (r *shippingQuoteRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.ShippingQuote, error) {
+	var quote models.ShippingQuote
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&quote).Error; err != nil {
+		return nil, err
+	}
+	return &quote, nil
+}
+
+func (r *shippingQuoteRepository) FindValidByHash(ctx context.Context, requestHash string, now time.Time) (*models.ShippingQuote, error) {
+	var quote models.ShippingQuote
+	err := r.db.WithContext(ctx).
+		Where("request_hash = ? AND expires_at > ?", requestHash, now).
+		Order("expires_at DESC").
+		First(&quote).Error
+	if err != nil {
+		return nil, err
+	}
+	return &quote, nil
+}
+
+func (r *shippingQuoteRepository) DeleteExpired(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("expires_at < ?", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM pesanan p WHERE p.shipping_quote_id = shipping_quote.id)").
+		Delete(&models.ShippingQuote{})
+	return result.RowsAffected, result.Error
+}
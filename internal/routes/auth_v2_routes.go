@@ -23,8 +23,17 @@ func SetupAuthV2Routes(
 	panelAuth := api.Group("/panel/auth")
 	// Public - Admin Login
 	panelAuth.Post("/login", authV2Controller.AdminLogin)
+	// Public - Langkah kedua login untuk admin dengan 2FA aktif
+	panelAuth.Post("/login/2fa", authV2Controller.AdminLoginTwoFactor)
+	// Public - Tukar refresh token (admin & buyer) dengan pasangan token baru
+	panelAuth.Post("/refresh", authV2Controller.RefreshToken)
+	// Public - Lupa password (link reset dikirim via email)
+	panelAuth.Post("/forgot-password", authV2Controller.ForgotPassword)
+	panelAuth.Post("/reset-password", authV2Controller.ResetPasswordWithToken)
 
-	// Protected Panel Auth Routes
+	// Protected Panel Auth Routes. Tanpa middleware.Idempotency(): respons
+	// setup 2FA & recovery code berisi rahasia yang tidak boleh disimpan, dan
+	// perubahan profil/password tidak perlu dikirim ulang.
 	panelAuthProtected := api.Group("/panel/auth",
 		middleware.AuthMiddleware(),
 		middleware.AdminOnly(),
@@ -34,12 +43,19 @@ func SetupAuthV2Routes(
 	panelAuthProtected.Post("/logout", authV2Controller.Logout)
 	panelAuthProtected.Put("/profile", authV2Controller.UpdateProfile)
 	panelAuthProtected.Put("/change-password", authV2Controller.ChangePassword)
+	// Two-factor authentication (sengaja tanpa guard permission agar admin yang
+	// wajib 2FA tetap bisa menyelesaikan setup)
+	panelAuthProtected.Post("/2fa/setup", authV2Controller.SetupTwoFactor)
+	panelAuthProtected.Post("/2fa/confirm", authV2Controller.ConfirmTwoFactor)
+	panelAuthProtected.Post("/2fa/disable", authV2Controller.DisableTwoFactor)
+	panelAuthProtected.Post("/2fa/recovery-codes", authV2Controller.RegenerateRecoveryCodes)
 
 	// Role Management Routes (Admin Only)
 	roleAdmin := api.Group("/panel/role",
 		middleware.AuthMiddleware(),
 		middleware.AdminOnly(),
 		middleware.RequirePermission("role:manage"),
+		middleware.Idempotency(),
 	)
 	roleAdmin.Get("", roleController.GetAll)
 	roleAdmin.Post("", roleController.Create)
@@ -52,6 +68,7 @@ func SetupAuthV2Routes(
 		middleware.AuthMiddleware(),
 		middleware.AdminOnly(),
 		middleware.RequirePermission("role:manage"),
+		middleware.Idempotency(),
 	)
 	permissionAdmin.Get("", permissionController.GetAll)
 
@@ -60,6 +77,7 @@ func SetupAuthV2Routes(
 		middleware.AuthMiddleware(),
 		middleware.AdminOnly(),
 		middleware.RequirePermission("activity_log:read"),
+		middleware.Idempotency(),
 	)
 	activityLogAdmin.Get("", activityLogController.GetLogs)
 	activityLogAdmin.Get("/:id", activityLogController.GetLogByID)
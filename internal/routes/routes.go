@@ -56,7 +56,18 @@ func SetupRoutes(
 	forwarderWebhookController *controllers.ForwarderWebhookController,
 	delivereeVehicleTypeController *controllers.DelivereeVehicleTypeController,
 	forwarderMappingController *controllers.ForwarderMappingController,
+	shippingCoverageController *controllers.ShippingCoverageController,
+	shippingQuoteController *controllers.ShippingQuoteController,
 	wmsController *controllers.WMSController,
+	outboxController *controllers.OutboxController,
+	jobController *controllers.JobController,
+	webhookLogController *controllers.WebhookLogController,
+	pickupAppointmentController *controllers.PickupAppointmentController,
+	refundController *controllers.RefundController,
+	pesananDokumenController *controllers.PesananDokumenController,
+	efakturController *controllers.EFakturController,
+	pesananPanelController *controllers.PesananPanelController,
+	pembayaranManualController *controllers.PembayaranManualController,
 ) {
 	// Health check
 	router.Get("/api/health", func(c *fiber.Ctx) error {
@@ -73,11 +84,17 @@ func SetupRoutes(
 	// API routes
 	v1 := router.Group("/api")
 
+	// Setiap route mutasi panel memasang middleware.Idempotency() setelah auth
+	// & RequirePermission (di grup atau per route): request mutasi dengan
+	// header Idempotency-Key yang diulang mendapat respons pertama tanpa
+	// diproses ulang, dan request yang ditolak tidak ikut tersimpan.
+
 	// Dasbor Admin Routes
 	dasbor := v1.Group("/panel/dasbor",
 		middleware.AuthMiddleware(),
 		middleware.AdminOnly(),
 		middleware.RequirePermission("dashboard:read"),
+		middleware.Idempotency(),
 	)
 	dasbor.Get("/chart-transaksi", dasborController.GetChartTransaksi)
 	dasbor.Get("/chart-revenue", dasborController.GetChartRevenue)
@@ -94,6 +111,7 @@ func SetupRoutes(
 		middleware.AuthMiddleware(),
 		middleware.AdminOnly(),
 		middleware.RequirePermission("admin:manage"),
+		middleware.Idempotency(),
 	)
 	admin.Get("", adminController.FindAll)
 	admin.Get("/:id", adminController.FindByID)
@@ -102,6 +120,8 @@ func SetupRoutes(
 	admin.Delete("/:id", adminController.Delete)
 	admin.Patch("/:id/toggle-status", adminController.ToggleStatus)
 	admin.Put("/:id/reset-password", adminController.ResetPassword)
+	admin.Patch("/:id/unlock", adminController.Unlock)
+	admin.Patch("/:id/reset-2fa", adminController.ResetTwoFactor)
 
 	// Buyer Management Routes (Admin Side)
 	buyerManagement := v1.Group("/panel/buyer",
@@ -112,8 +132,10 @@ func SetupRoutes(
 	buyerManagement.Get("/statistik", middleware.RequirePermission("buyer:read"), buyerController.GetStatistik)
 	buyerManagement.Get("/chart", middleware.RequirePermission("buyer:read"), buyerController.GetChart)
 	buyerManagement.Get("/:id", middleware.RequirePermission("buyer:read"), buyerController.FindByID)
-	buyerManagement.Delete("/:id", middleware.RequirePermission("buyer:manage"), buyerController.Delete)
-	buyerManagement.Put("/:id/reset-password", middleware.RequirePermission("buyer:manage"), buyerController.ResetPassword)
+	buyerManagement.Delete("/:id", middleware.RequirePermission("buyer:manage"), middleware.Idempotency(), buyerController.Delete)
+	buyerManagement.Put("/:id/reset-password", middleware.RequirePermission("buyer:manage"), middleware.Idempotency(), buyerController.ResetPassword)
+	buyerManagement.Get("/:id/profil-pajak", middleware.RequirePermission("buyer:read"), efakturController.AdminGetProfilPajak)
+	buyerManagement.Put("/:id/profil-pajak", middleware.RequirePermission("buyer:manage"), middleware.Idempotency(), efakturController.AdminUpsertProfilPajak)
 
 	// Alamat Buyer Routes (Buyer Only)
 	alamatBuyer := v1.Group("/buyer/alamat",
@@ -127,6 +149,15 @@ func SetupRoutes(
 	alamatBuyer.Delete("/:id", alamatBuyerController.Delete)
 	alamatBuyer.Patch("/:id/set-default", alamatBuyerController.SetDefault)
 
+	// Profil pajak - Buyer (NPWP untuk faktur pajak pesanan berikutnya)
+	profilPajakBuyer := v1.Group("/buyer/profil-pajak",
+		middleware.AuthMiddleware(),
+		middleware.BuyerOnly(),
+	)
+	profilPajakBuyer.Get("", efakturController.GetProfilPajak)
+	profilPajakBuyer.Put("", efakturController.UpsertProfilPajak)
+	profilPajakBuyer.Delete("", efakturController.DeleteProfilPajak)
+
 	// Kategori Produk - Public
 	kategoriPublic := v1.Group("/kategori-produk")
 	kategoriPublic.Get("", kategoriController.FindAll)
@@ -141,10 +172,10 @@ func SetupRoutes(
 	kategoriAdmin.Get("", middleware.RequirePermission("kategori:read"), kategoriController.FindAll)
 	kategoriAdmin.Get("/dropdown", middleware.RequirePermission("kategori:read"), kategoriController.Dropdown)
 	kategoriAdmin.Get("/:id", middleware.RequirePermission("kategori:read"), kategoriController.FindByID)
-	kategoriAdmin.Post("", middleware.RequirePermission("kategori:manage"), kategoriController.Create)
-	kategoriAdmin.Put("/:id", middleware.RequirePermission("kategori:manage"), kategoriController.Update)
-	kategoriAdmin.Delete("/:id", middleware.RequirePermission("kategori:manage"), kategoriController.Delete)
-	kategoriAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("kategori:manage"), kategoriController.ToggleStatus)
+	kategoriAdmin.Post("", middleware.RequirePermission("kategori:manage"), middleware.Idempotency(), kategoriController.Create)
+	kategoriAdmin.Put("/:id", middleware.RequirePermission("kategori:manage"), middleware.Idempotency(), kategoriController.Update)
+	kategoriAdmin.Delete("/:id", middleware.RequirePermission("kategori:manage"), middleware.Idempotency(), kategoriController.Delete)
+	kategoriAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("kategori:manage"), middleware.Idempotency(), kategoriController.ToggleStatus)
 
 	// Merek Produk - Public
 	merekPublic := v1.Group("/merek-produk")
@@ -160,10 +191,10 @@ func SetupRoutes(
 	merekAdmin.Get("", middleware.RequirePermission("brand:read"), merekController.FindAll)
 	merekAdmin.Get("/dropdown", middleware.RequirePermission("brand:read"), merekController.Dropdown)
 	merekAdmin.Get("/:id", middleware.RequirePermission("brand:read"), merekController.FindByID)
-	merekAdmin.Post("", middleware.RequirePermission("brand:manage"), merekController.Create)
-	merekAdmin.Put("/:id", middleware.RequirePermission("brand:manage"), merekController.Update)
-	merekAdmin.Delete("/:id", middleware.RequirePermission("brand:manage"), merekController.Delete)
-	merekAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("brand:manage"), merekController.ToggleStatus)
+	merekAdmin.Post("", middleware.RequirePermission("brand:manage"), middleware.Idempotency(), merekController.Create)
+	merekAdmin.Put("/:id", middleware.RequirePermission("brand:manage"), middleware.Idempotency(), merekController.Update)
+	merekAdmin.Delete("/:id", middleware.RequirePermission("brand:manage"), middleware.Idempotency(), merekController.Delete)
+	merekAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("brand:manage"), middleware.Idempotency(), merekController.ToggleStatus)
 
 	// Kondisi Produk - Public
 	kondisiPublic := v1.Group("/kondisi-produk")
@@ -179,12 +210,12 @@ func SetupRoutes(
 	kondisiAdmin.Get("", middleware.RequirePermission("kondisi:read"), kondisiController.FindAll)
 	kondisiAdmin.Get("/dropdown", middleware.RequirePermission("kondisi:read"), kondisiController.Dropdown)
 	kondisiAdmin.Get("/:id", middleware.RequirePermission("kondisi:read"), kondisiController.FindByID)
-	kondisiAdmin.Post("", middleware.RequirePermission("kondisi:manage"), kondisiController.Create)
-	kondisiAdmin.Put("/:id", middleware.RequirePermission("kondisi:manage"), kondisiController.Update)
-	kondisiAdmin.Delete("/:id", middleware.RequirePermission("kondisi:manage"), kondisiController.Delete)
-	kondisiAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("kondisi:manage"), kondisiController.ToggleStatus)
-	kondisiAdmin.Put("/reorder", middleware.RequirePermission("kondisi:manage"), kondisiController.Reorder)
-	kondisiAdmin.Patch("/:id/reorder", middleware.RequirePermission("kondisi:manage"), kondisiController.ReorderByDirection)
+	kondisiAdmin.Post("", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), kondisiController.Create)
+	kondisiAdmin.Put("/:id", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), kondisiController.Update)
+	kondisiAdmin.Delete("/:id", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), kondisiController.Delete)
+	kondisiAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), kondisiController.ToggleStatus)
+	kondisiAdmin.Put("/reorder", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), kondisiController.Reorder)
+	kondisiAdmin.Patch("/:id/reorder", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), kondisiController.ReorderByDirection)
 
 	// Kondisi Paket - Public
 	paketPublic := v1.Group("/kondisi-paket")
@@ -200,12 +231,12 @@ func SetupRoutes(
 	paketAdmin.Get("", middleware.RequirePermission("kondisi:read"), kondisiPaketController.FindAll)
 	paketAdmin.Get("/dropdown", middleware.RequirePermission("kondisi:read"), kondisiPaketController.Dropdown)
 	paketAdmin.Get("/:id", middleware.RequirePermission("kondisi:read"), kondisiPaketController.FindByID)
-	paketAdmin.Post("", middleware.RequirePermission("kondisi:manage"), kondisiPaketController.Create)
-	paketAdmin.Put("/:id", middleware.RequirePermission("kondisi:manage"), kondisiPaketController.Update)
-	paketAdmin.Delete("/:id", middleware.RequirePermission("kondisi:manage"), kondisiPaketController.Delete)
-	paketAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("kondisi:manage"), kondisiPaketController.ToggleStatus)
-	paketAdmin.Put("/reorder", middleware.RequirePermission("kondisi:manage"), kondisiPaketController.Reorder)
-	paketAdmin.Patch("/:id/reorder", middleware.RequirePermission("kondisi:manage"), kondisiPaketController.ReorderByDirection)
+	paketAdmin.Post("", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), kondisiPaketController.Create)
+	paketAdmin.Put("/:id", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), kondisiPaketController.Update)
+	paketAdmin.Delete("/:id", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), kondisiPaketController.Delete)
+	paketAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), kondisiPaketController.ToggleStatus)
+	paketAdmin.Put("/reorder", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), kondisiPaketController.Reorder)
+	paketAdmin.Patch("/:id/reorder", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), kondisiPaketController.ReorderByDirection)
 
 	// Sumber Produk - Public
 	sumberPublic := v1.Group("/sumber-produk")
@@ -221,10 +252,10 @@ func SetupRoutes(
 	sumberAdmin.Get("", middleware.RequirePermission("kondisi:read"), sumberController.FindAll)
 	sumberAdmin.Get("/dropdown", middleware.RequirePermission("kondisi:read"), sumberController.Dropdown)
 	sumberAdmin.Get("/:id", middleware.RequirePermission("kondisi:read"), sumberController.FindByID)
-	sumberAdmin.Post("", middleware.RequirePermission("kondisi:manage"), sumberController.Create)
-	sumberAdmin.Put("/:id", middleware.RequirePermission("kondisi:manage"), sumberController.Update)
-	sumberAdmin.Delete("/:id", middleware.RequirePermission("kondisi:manage"), sumberController.Delete)
-	sumberAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("kondisi:manage"), sumberController.ToggleStatus)
+	sumberAdmin.Post("", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), sumberController.Create)
+	sumberAdmin.Put("/:id", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), sumberController.Update)
+	sumberAdmin.Delete("/:id", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), sumberController.Delete)
+	sumberAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("kondisi:manage"), middleware.Idempotency(), sumberController.ToggleStatus)
 
 	// Warehouse - Public
 	v1.Get("/public/warehouse", warehouseController.GetPublic)
@@ -236,7 +267,7 @@ func SetupRoutes(
 	)
 	warehouseAdmin.Get("", middleware.RequirePermission("operasional:read"), warehouseController.Get)
 	warehouseAdmin.Get("/dropdown", middleware.RequirePermission("operasional:read"), warehouseController.Dropdown)
-	warehouseAdmin.Put("", middleware.RequirePermission("operasional:manage"), warehouseController.UpdateSingleton)
+	warehouseAdmin.Put("", middleware.RequirePermission("operasional:manage"), middleware.Idempotency(), warehouseController.UpdateSingleton)
 
 	// Tipe Produk - Public
 	tipeProdukPublic := v1.Group("/tipe-produk")
@@ -265,10 +296,10 @@ func SetupRoutes(
 	)
 	diskonKategoriAdmin.Get("", middleware.RequirePermission("diskon:read"), diskonKategoriController.FindAll)
 	diskonKategoriAdmin.Get("/:id", middleware.RequirePermission("diskon:read"), diskonKategoriController.FindByID)
-	diskonKategoriAdmin.Post("", middleware.RequirePermission("diskon:manage"), diskonKategoriController.Create)
-	diskonKategoriAdmin.Put("/:id", middleware.RequirePermission("diskon:manage"), diskonKategoriController.Update)
-	diskonKategoriAdmin.Delete("/:id", middleware.RequirePermission("diskon:manage"), diskonKategoriController.Delete)
-	diskonKategoriAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("diskon:manage"), diskonKategoriController.ToggleStatus)
+	diskonKategoriAdmin.Post("", middleware.RequirePermission("diskon:manage"), middleware.Idempotency(), diskonKategoriController.Create)
+	diskonKategoriAdmin.Put("/:id", middleware.RequirePermission("diskon:manage"), middleware.Idempotency(), diskonKategoriController.Update)
+	diskonKategoriAdmin.Delete("/:id", middleware.RequirePermission("diskon:manage"), middleware.Idempotency(), diskonKategoriController.Delete)
+	diskonKategoriAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("diskon:manage"), middleware.Idempotency(), diskonKategoriController.ToggleStatus)
 
 	// Kupon - Admin
 	kuponAdmin := v1.Group("/panel/kupon",
@@ -279,11 +310,11 @@ func SetupRoutes(
 	kuponAdmin.Get("/dropdown/kategori", middleware.RequirePermission("kupon:read"), kuponController.GetKategoriDropdown)
 	kuponAdmin.Get("/:id", middleware.RequirePermission("kupon:read"), kuponController.GetByID)
 	kuponAdmin.Get("/:id/usages", middleware.RequirePermission("kupon:read"), kuponController.GetUsages)
-	kuponAdmin.Post("", middleware.RequirePermission("kupon:manage"), kuponController.Create)
-	kuponAdmin.Post("/generate-kode", middleware.RequirePermission("kupon:manage"), kuponController.GenerateKode)
-	kuponAdmin.Put("/:id", middleware.RequirePermission("kupon:manage"), kuponController.Update)
-	kuponAdmin.Delete("/:id", middleware.RequirePermission("kupon:manage"), kuponController.Delete)
-	kuponAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("kupon:manage"), kuponController.ToggleStatus)
+	kuponAdmin.Post("", middleware.RequirePermission("kupon:manage"), middleware.Idempotency(), kuponController.Create)
+	kuponAdmin.Post("/generate-kode", middleware.RequirePermission("kupon:manage"), middleware.Idempotency(), kuponController.GenerateKode)
+	kuponAdmin.Put("/:id", middleware.RequirePermission("kupon:manage"), middleware.Idempotency(), kuponController.Update)
+	kuponAdmin.Delete("/:id", middleware.RequirePermission("kupon:manage"), middleware.Idempotency(), kuponController.Delete)
+	kuponAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("kupon:manage"), middleware.Idempotency(), kuponController.ToggleStatus)
 
 	// Banner Tipe Produk - Public
 	bannerTipeProdukPublic := v1.Group("/banner-tipe-produk")
@@ -298,12 +329,12 @@ func SetupRoutes(
 	)
 	bannerTipeProdukAdmin.Get("", middleware.RequirePermission("marketing:read"), bannerTipeProdukController.FindAll)
 	bannerTipeProdukAdmin.Get("/:id", middleware.RequirePermission("marketing:read"), bannerTipeProdukController.FindByID)
-	bannerTipeProdukAdmin.Post("", middleware.RequirePermission("marketing:manage"), bannerTipeProdukController.Create)
-	bannerTipeProdukAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), bannerTipeProdukController.Update)
-	bannerTipeProdukAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), bannerTipeProdukController.Delete)
-	bannerTipeProdukAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("marketing:manage"), bannerTipeProdukController.ToggleStatus)
-	bannerTipeProdukAdmin.Put("/reorder", middleware.RequirePermission("marketing:manage"), bannerTipeProdukController.Reorder)
-	bannerTipeProdukAdmin.Patch("/:id/reorder", middleware.RequirePermission("marketing:manage"), bannerTipeProdukController.ReorderByDirection)
+	bannerTipeProdukAdmin.Post("", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), bannerTipeProdukController.Create)
+	bannerTipeProdukAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), bannerTipeProdukController.Update)
+	bannerTipeProdukAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), bannerTipeProdukController.Delete)
+	bannerTipeProdukAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), bannerTipeProdukController.ToggleStatus)
+	bannerTipeProdukAdmin.Put("/reorder", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), bannerTipeProdukController.Reorder)
+	bannerTipeProdukAdmin.Patch("/:id/reorder", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), bannerTipeProdukController.ReorderByDirection)
 
 	// Produk - Public
 	produkPublic := v1.Group("/produk")
@@ -318,17 +349,17 @@ func SetupRoutes(
 	)
 	produkAdmin.Get("", middleware.RequirePermission("produk:read"), produkController.FindAll)
 	produkAdmin.Get("/:id", middleware.RequirePermission("produk:read"), produkController.FindByID)
-	produkAdmin.Post("", middleware.RequirePermission("produk:create"), produkController.Create)
-	produkAdmin.Put("/:id", middleware.RequirePermission("produk:update"), produkController.Update)
-	produkAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("produk:update"), produkController.ToggleStatus)
-	produkAdmin.Patch("/:id/toggle-sale", middleware.RequirePermission("produk:update"), produkController.ToggleSale)
-	produkAdmin.Patch("/:id/toggle-qc-pass", middleware.RequirePermission("produk:update"), produkController.ToggleQcPass)
-	produkAdmin.Delete("/:id", middleware.RequirePermission("produk:delete"), produkController.Delete)
-	produkAdmin.Post("/:id/gambar", middleware.RequirePermission("produk:update"), produkController.AddGambar)
-	produkAdmin.Delete("/:id/gambar/:gambar_id", middleware.RequirePermission("produk:update"), produkController.DeleteGambar)
-	produkAdmin.Patch("/:id/gambar/:gambar_id/reorder", middleware.RequirePermission("produk:update"), produkController.ReorderGambar)
-	produkAdmin.Post("/:id/dokumen", middleware.RequirePermission("produk:update"), produkController.AddDokumen)
-	produkAdmin.Delete("/:id/dokumen/:dokumen_id", middleware.RequirePermission("produk:update"), produkController.DeleteDokumen)
+	produkAdmin.Post("", middleware.RequirePermission("produk:create"), middleware.Idempotency(), produkController.Create)
+	produkAdmin.Put("/:id", middleware.RequirePermission("produk:update"), middleware.Idempotency(), produkController.Update)
+	produkAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("produk:update"), middleware.Idempotency(), produkController.ToggleStatus)
+	produkAdmin.Patch("/:id/toggle-sale", middleware.RequirePermission("produk:update"), middleware.Idempotency(), produkController.ToggleSale)
+	produkAdmin.Patch("/:id/toggle-qc-pass", middleware.RequirePermission("produk:update"), middleware.Idempotency(), produkController.ToggleQcPass)
+	produkAdmin.Delete("/:id", middleware.RequirePermission("produk:delete"), middleware.Idempotency(), produkController.Delete)
+	produkAdmin.Post("/:id/gambar", middleware.RequirePermission("produk:update"), middleware.Idempotency(), produkController.AddGambar)
+	produkAdmin.Delete("/:id/gambar/:gambar_id", middleware.RequirePermission("produk:update"), middleware.Idempotency(), produkController.DeleteGambar)
+	produkAdmin.Patch("/:id/gambar/:gambar_id/reorder", middleware.RequirePermission("produk:update"), middleware.Idempotency(), produkController.ReorderGambar)
+	produkAdmin.Post("/:id/dokumen", middleware.RequirePermission("produk:update"), middleware.Idempotency(), produkController.AddDokumen)
+	produkAdmin.Delete("/:id/dokumen/:dokumen_id", middleware.RequirePermission("produk:update"), middleware.Idempotency(), produkController.DeleteDokumen)
 
 	// Master (Dropdown)
 	v1.Get("/master/dropdown", masterController.GetDropdown)
@@ -341,10 +372,10 @@ func SetupRoutes(
 	heroSectionAdmin.Get("", middleware.RequirePermission("marketing:read"), heroSectionController.FindAll)
 	heroSectionAdmin.Get("/schedule", middleware.RequirePermission("marketing:read"), heroSectionController.GetSchedules)
 	heroSectionAdmin.Get("/:id", middleware.RequirePermission("marketing:read"), heroSectionController.FindByID)
-	heroSectionAdmin.Post("", middleware.RequirePermission("marketing:manage"), heroSectionController.Create)
-	heroSectionAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), heroSectionController.Update)
-	heroSectionAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), heroSectionController.Delete)
-	heroSectionAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("marketing:manage"), heroSectionController.ToggleStatus)
+	heroSectionAdmin.Post("", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), heroSectionController.Create)
+	heroSectionAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), heroSectionController.Update)
+	heroSectionAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), heroSectionController.Delete)
+	heroSectionAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), heroSectionController.ToggleStatus)
 
 	// Hero Section - Public
 	v1.Get("/hero-section/active", heroSectionController.GetActive)
@@ -356,12 +387,12 @@ func SetupRoutes(
 	)
 	bannerEventPromoAdmin.Get("", middleware.RequirePermission("marketing:read"), bannerEventPromoController.FindAll)
 	bannerEventPromoAdmin.Get("/:id", middleware.RequirePermission("marketing:read"), bannerEventPromoController.FindByID)
-	bannerEventPromoAdmin.Post("", middleware.RequirePermission("marketing:manage"), bannerEventPromoController.Create)
-	bannerEventPromoAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), bannerEventPromoController.Update)
-	bannerEventPromoAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), bannerEventPromoController.Delete)
-	bannerEventPromoAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("marketing:manage"), bannerEventPromoController.ToggleStatus)
-	bannerEventPromoAdmin.Put("/reorder", middleware.RequirePermission("marketing:manage"), bannerEventPromoController.Reorder)
-	bannerEventPromoAdmin.Patch("/:id/reorder", middleware.RequirePermission("marketing:manage"), bannerEventPromoController.ReorderByDirection)
+	bannerEventPromoAdmin.Post("", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), bannerEventPromoController.Create)
+	bannerEventPromoAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), bannerEventPromoController.Update)
+	bannerEventPromoAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), bannerEventPromoController.Delete)
+	bannerEventPromoAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), bannerEventPromoController.ToggleStatus)
+	bannerEventPromoAdmin.Put("/reorder", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), bannerEventPromoController.Reorder)
+	bannerEventPromoAdmin.Patch("/:id/reorder", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), bannerEventPromoController.ReorderByDirection)
 
 	// Banner Event Promo - Public
 	v1.Get("/banner-event-promo/active", bannerEventPromoController.GetActive)
@@ -373,10 +404,10 @@ func SetupRoutes(
 	)
 	ulasanAdmin.Get("", middleware.RequirePermission("ulasan:read"), ulasanAdminController.GetAll)
 	ulasanAdmin.Get("/:id", middleware.RequirePermission("ulasan:read"), ulasanAdminController.GetByID)
-	ulasanAdmin.Patch("/:id/approve", middleware.RequirePermission("ulasan:manage"), ulasanAdminController.Approve)
-	ulasanAdmin.Patch("/:id/reject", middleware.RequirePermission("ulasan:manage"), ulasanAdminController.Reject)
-	ulasanAdmin.Patch("/bulk-approve", middleware.RequirePermission("ulasan:manage"), ulasanAdminController.BulkApprove)
-	ulasanAdmin.Delete("/:id", middleware.RequirePermission("ulasan:manage"), ulasanAdminController.Delete)
+	ulasanAdmin.Patch("/:id/approve", middleware.RequirePermission("ulasan:manage"), middleware.Idempotency(), ulasanAdminController.Approve)
+	ulasanAdmin.Patch("/:id/reject", middleware.RequirePermission("ulasan:manage"), middleware.Idempotency(), ulasanAdminController.Reject)
+	ulasanAdmin.Patch("/bulk-approve", middleware.RequirePermission("ulasan:manage"), middleware.Idempotency(), ulasanAdminController.BulkApprove)
+	ulasanAdmin.Delete("/:id", middleware.RequirePermission("ulasan:manage"), middleware.Idempotency(), ulasanAdminController.Delete)
 
 	// Pesanan - Admin
 	pesananAdmin := v1.Group("/panel/pesanan",
@@ -384,16 +415,60 @@ func SetupRoutes(
 		middleware.AdminOnly(),
 	)
 	pesananAdmin.Get("", middleware.RequirePermission("pesanan:read"), pesananAdminController.GetAll)
+	// Pesanan dibuat admin untuk buyer (penjualan offline / partai besar)
+	pesananAdmin.Post("", middleware.RequirePermission("pesanan:create"), middleware.Idempotency(), pesananPanelController.Create)
 	pesananAdmin.Get("/statistics", middleware.RequirePermission("pesanan:read"), pesananAdminController.GetStatistics)
 	pesananAdmin.Get("/count-paid-not-processed", middleware.RequirePermission("pesanan:read"), pesananAdminController.CountPaidNotProcessed)
 	pesananAdmin.Get("/:id", middleware.RequirePermission("pesanan:read"), pesananAdminController.GetByID)
-	pesananAdmin.Patch("/:id/update-status", middleware.RequirePermission("pesanan:update_status"), pesananAdminController.UpdateStatus)
-	pesananAdmin.Post("/:id/retry-booking", middleware.RequirePermission("pesanan:update_status"), pesananAdminController.RetryBooking)
+	pesananAdmin.Patch("/:id/update-status", middleware.RequirePermission("pesanan:update_status"), middleware.Idempotency(), pesananAdminController.UpdateStatus)
+	pesananAdmin.Post("/:id/retry-booking", middleware.RequirePermission("pesanan:update_status"), middleware.Idempotency(), pesananAdminController.RetryBooking)
 	pesananAdmin.Get("/:id/tracking", middleware.RequirePermission("pesanan:read"), pesananAdminController.TrackDelivery)
 	pesananAdmin.Get("/:id/deliveree-detail", middleware.RequirePermission("pesanan:read"), pesananAdminController.GetDelivereeDetail)
 	pesananAdmin.Get("/:id/invoice", middleware.RequirePermission("pesanan:read"), pesananAdminController.GetForwarderInvoice)
-	pesananAdmin.Delete("/:id", middleware.RequirePermission("pesanan:delete"), pesananAdminController.Delete)
-	pesananAdmin.Post("/:id/cancel", middleware.StagingOnly(cfg.AppEnv), middleware.RequirePermission("pesanan:update_status"), pesananAdminController.CancelOrder)
+	pesananAdmin.Get("/:id/proof-of-delivery", middleware.RequirePermission("pesanan:read"), pesananAdminController.GetProofOfDelivery)
+	pesananAdmin.Post("/:id/proof-of-delivery", middleware.RequirePermission("pesanan:update_status"), middleware.Idempotency(), pesananAdminController.CaptureProofOfDelivery)
+	pesananAdmin.Delete("/:id", middleware.RequirePermission("pesanan:delete"), middleware.Idempotency(), pesananAdminController.Delete)
+	pesananAdmin.Post("/:id/cancel", middleware.StagingOnly(cfg.AppEnv), middleware.RequirePermission("pesanan:update_status"), middleware.Idempotency(), pesananAdminController.CancelOrder)
+	pesananAdmin.Post("/:id/cancel-shipment", middleware.RequirePermission("pesanan:update_status"), middleware.Idempotency(), pesananAdminController.CancelShipment)
+	pesananAdmin.Post("/:id/rebook-shipment", middleware.RequirePermission("pesanan:update_status"), middleware.Idempotency(), pesananAdminController.RebookShipment)
+	pesananAdmin.Get("/:id/refund", middleware.RequirePermission("pesanan:read"), refundController.GetByPesanan)
+	pesananAdmin.Post("/:id/refund", middleware.RequirePermission("pesanan:update_status"), middleware.Idempotency(), refundController.Create)
+	pesananAdmin.Get("/:id/dokumen", middleware.RequirePermission("pesanan:read"), pesananDokumenController.GetAll)
+	pesananAdmin.Post("/:id/dokumen/invoice", middleware.RequirePermission("pesanan:update_status"), middleware.Idempotency(), pesananDokumenController.IssueInvoice)
+	pesananAdmin.Get("/:id/dokumen/:dokumen_id/pdf", middleware.RequirePermission("pesanan:read"), pesananDokumenController.Download)
+	pesananAdmin.Get("/:id/dokumen/:dokumen_id/bukti-pengiriman", middleware.RequirePermission("pesanan:read"), pesananDokumenController.DownloadArsip)
+
+	// Refund - Admin (persetujuan finance)
+	refundAdmin := v1.Group("/panel/refund",
+		middleware.AuthMiddleware(),
+		middleware.AdminOnly(),
+	)
+	refundAdmin.Get("", middleware.RequirePermission("refund:read"), refundController.FindAll)
+	refundAdmin.Get("/:id", middleware.RequirePermission("refund:read"), refundController.FindByID)
+	refundAdmin.Post("/:id/approve", middleware.RequirePermission("refund:approve"), middleware.Idempotency(), refundController.Approve)
+	refundAdmin.Post("/:id/reject", middleware.RequirePermission("refund:approve"), middleware.Idempotency(), refundController.Reject)
+
+	// Transfer Manual - Admin (review bukti transfer & impor mutasi rekening)
+	pembayaranManualAdmin := v1.Group("/panel/pembayaran-manual",
+		middleware.AuthMiddleware(),
+		middleware.AdminOnly(),
+	)
+	pembayaranManualAdmin.Get("/mutasi", middleware.RequirePermission("pembayaran:read"), pembayaranManualController.FindMutasi)
+	pembayaranManualAdmin.Post("/mutasi/import", middleware.RequirePermission("pembayaran:manage"), middleware.Idempotency(), pembayaranManualController.ImportMutasi)
+	pembayaranManualAdmin.Get("", middleware.RequirePermission("pembayaran:read"), pembayaranManualController.FindAll)
+	pembayaranManualAdmin.Get("/:id", middleware.RequirePermission("pembayaran:read"), pembayaranManualController.FindByID)
+	pembayaranManualAdmin.Get("/:id/file", middleware.RequirePermission("pembayaran:read"), pembayaranManualController.DownloadFile)
+	pembayaranManualAdmin.Post("/:id/approve", middleware.RequirePermission("pembayaran:manage"), middleware.Idempotency(), pembayaranManualController.Approve)
+	pembayaranManualAdmin.Post("/:id/reject", middleware.RequirePermission("pembayaran:manage"), middleware.Idempotency(), pembayaranManualController.Reject)
+
+	// e-Faktur - Admin (faktur pajak pesanan ber-PPN)
+	efakturAdmin := v1.Group("/panel/efaktur",
+		middleware.AuthMiddleware(),
+		middleware.AdminOnly(),
+	)
+	efakturAdmin.Get("", middleware.RequirePermission("efaktur:read"), efakturController.FindAll)
+	efakturAdmin.Get("/export", middleware.RequirePermission("efaktur:read"), efakturController.Export)
+	efakturAdmin.Patch("/pesanan/:id", middleware.RequirePermission("efaktur:manage"), middleware.Idempotency(), efakturController.UpdateStatus)
 
 	// Ulasan - Buyer
 	ulasanBuyer := v1.Group("/buyer/ulasan",
@@ -441,10 +516,10 @@ func SetupRoutes(
 	)
 	ppnAdmin.Get("", middleware.RequirePermission("system:read"), ppnController.GetAll)
 	ppnAdmin.Get("/:id", middleware.RequirePermission("system:read"), ppnController.GetByID)
-	ppnAdmin.Post("", middleware.RequirePermission("system:manage"), ppnController.Create)
-	ppnAdmin.Put("/:id", middleware.RequirePermission("system:manage"), ppnController.Update)
-	ppnAdmin.Delete("/:id", middleware.RequirePermission("system:manage"), ppnController.Delete)
-	ppnAdmin.Patch("/:id/set-active", middleware.RequirePermission("system:manage"), ppnController.SetActive)
+	ppnAdmin.Post("", middleware.RequirePermission("system:manage"), middleware.Idempotency(), ppnController.Create)
+	ppnAdmin.Put("/:id", middleware.RequirePermission("system:manage"), middleware.Idempotency(), ppnController.Update)
+	ppnAdmin.Delete("/:id", middleware.RequirePermission("system:manage"), middleware.Idempotency(), ppnController.Delete)
+	ppnAdmin.Patch("/:id/set-active", middleware.RequirePermission("system:manage"), middleware.Idempotency(), ppnController.SetActive)
 
 	// Metode Pembayaran - Admin
 	metodePembayaranAdmin := v1.Group("/panel/metode-pembayaran",
@@ -452,8 +527,8 @@ func SetupRoutes(
 		middleware.AdminOnly(),
 	)
 	metodePembayaranAdmin.Get("", middleware.RequirePermission("pembayaran:read"), metodePembayaranController.GetAllGrouped)
-	metodePembayaranAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("pembayaran:manage"), metodePembayaranController.ToggleMethodStatus)
-	metodePembayaranAdmin.Patch("/group/:urutan/toggle-status", middleware.RequirePermission("pembayaran:manage"), metodePembayaranController.ToggleGroupStatus)
+	metodePembayaranAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("pembayaran:manage"), middleware.Idempotency(), metodePembayaranController.ToggleMethodStatus)
+	metodePembayaranAdmin.Patch("/group/:urutan/toggle-status", middleware.RequirePermission("pembayaran:manage"), middleware.Idempotency(), metodePembayaranController.ToggleGroupStatus)
 
 	// Metode Pembayaran - Public
 	v1.Get("/public/metode-pembayaran", metodePembayaranController.GetAllGroupedPublic)
@@ -465,7 +540,7 @@ func SetupRoutes(
 	)
 	dokumenKebijakanAdmin.Get("", middleware.RequirePermission("system:read"), dokumenKebijakanController.GetAll)
 	dokumenKebijakanAdmin.Get("/:id", middleware.RequirePermission("system:read"), dokumenKebijakanController.GetByID)
-	dokumenKebijakanAdmin.Put("/:id", middleware.RequirePermission("system:manage"), dokumenKebijakanController.Update)
+	dokumenKebijakanAdmin.Put("/:id", middleware.RequirePermission("system:manage"), middleware.Idempotency(), dokumenKebijakanController.Update)
 
 	// Dokumen Kebijakan - Public
 	dokumenKebijakanPublic := v1.Group("/public/dokumen-kebijakan")
@@ -482,11 +557,11 @@ func SetupRoutes(
 	)
 	faqAdmin.Get("", middleware.RequirePermission("operasional:read"), faqController.GetAll)
 	faqAdmin.Get("/:id", middleware.RequirePermission("operasional:read"), faqController.GetByID)
-	faqAdmin.Post("", middleware.RequirePermission("operasional:manage"), faqController.Create)
-	faqAdmin.Put("/:id", middleware.RequirePermission("operasional:manage"), faqController.Update)
-	faqAdmin.Delete("/:id", middleware.RequirePermission("operasional:manage"), faqController.Delete)
-	faqAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("operasional:manage"), faqController.ToggleStatus)
-	faqAdmin.Patch("/:id/reorder", middleware.RequirePermission("operasional:manage"), faqController.Reorder)
+	faqAdmin.Post("", middleware.RequirePermission("operasional:manage"), middleware.Idempotency(), faqController.Create)
+	faqAdmin.Put("/:id", middleware.RequirePermission("operasional:manage"), middleware.Idempotency(), faqController.Update)
+	faqAdmin.Delete("/:id", middleware.RequirePermission("operasional:manage"), middleware.Idempotency(), faqController.Delete)
+	faqAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("operasional:manage"), middleware.Idempotency(), faqController.ToggleStatus)
+	faqAdmin.Patch("/:id/reorder", middleware.RequirePermission("operasional:manage"), middleware.Idempotency(), faqController.Reorder)
 
 	// Disclaimer - Admin
 	disclaimerAdmin := v1.Group("/panel/disclaimer",
@@ -495,10 +570,10 @@ func SetupRoutes(
 	)
 	disclaimerAdmin.Get("", middleware.RequirePermission("system:read"), disclaimerController.FindAll)
 	disclaimerAdmin.Get("/:id", middleware.RequirePermission("system:read"), disclaimerController.FindByID)
-	disclaimerAdmin.Post("", middleware.RequirePermission("system:manage"), disclaimerController.Create)
-	disclaimerAdmin.Put("/:id", middleware.RequirePermission("system:manage"), disclaimerController.Update)
-	disclaimerAdmin.Delete("/:id", middleware.RequirePermission("system:manage"), disclaimerController.Delete)
-	disclaimerAdmin.Patch("/:id/set-active", middleware.RequirePermission("system:manage"), disclaimerController.SetActive)
+	disclaimerAdmin.Post("", middleware.RequirePermission("system:manage"), middleware.Idempotency(), disclaimerController.Create)
+	disclaimerAdmin.Put("/:id", middleware.RequirePermission("system:manage"), middleware.Idempotency(), disclaimerController.Update)
+	disclaimerAdmin.Delete("/:id", middleware.RequirePermission("system:manage"), middleware.Idempotency(), disclaimerController.Delete)
+	disclaimerAdmin.Patch("/:id/set-active", middleware.RequirePermission("system:manage"), middleware.Idempotency(), disclaimerController.SetActive)
 
 	// Disclaimer - Public
 	v1.Get("/public/disclaimer", disclaimerController.GetActive)
@@ -517,7 +592,7 @@ func SetupRoutes(
 		middleware.AdminOnly(),
 	)
 	formulirConfigAdmin.Get("", middleware.RequirePermission("system:read"), formulirPartaiBesarController.GetConfig)
-	formulirConfigAdmin.Put("", middleware.RequirePermission("system:manage"), formulirPartaiBesarController.UpdateConfig)
+	formulirConfigAdmin.Put("", middleware.RequirePermission("system:manage"), middleware.Idempotency(), formulirPartaiBesarController.UpdateConfig)
 
 	// Formulir Partai Besar - Anggaran (Admin)
 	formulirAnggaranAdmin := v1.Group("/panel/formulir-partai-besar/anggaran",
@@ -526,11 +601,11 @@ func SetupRoutes(
 	)
 	formulirAnggaranAdmin.Get("", middleware.RequirePermission("system:read"), formulirPartaiBesarController.GetAnggaranList)
 	formulirAnggaranAdmin.Get("/:id", middleware.RequirePermission("system:read"), formulirPartaiBesarController.GetAnggaranByID)
-	formulirAnggaranAdmin.Post("", middleware.RequirePermission("system:manage"), formulirPartaiBesarController.CreateAnggaran)
-	formulirAnggaranAdmin.Put("/:id", middleware.RequirePermission("system:manage"), formulirPartaiBesarController.UpdateAnggaran)
-	formulirAnggaranAdmin.Delete("/:id", middleware.RequirePermission("system:manage"), formulirPartaiBesarController.DeleteAnggaran)
-	formulirAnggaranAdmin.Put("/reorder", middleware.RequirePermission("system:manage"), formulirPartaiBesarController.ReorderAnggaran)
-	formulirAnggaranAdmin.Patch("/:id/reorder", middleware.RequirePermission("system:manage"), formulirPartaiBesarController.ReorderAnggaranByDirection)
+	formulirAnggaranAdmin.Post("", middleware.RequirePermission("system:manage"), middleware.Idempotency(), formulirPartaiBesarController.CreateAnggaran)
+	formulirAnggaranAdmin.Put("/:id", middleware.RequirePermission("system:manage"), middleware.Idempotency(), formulirPartaiBesarController.UpdateAnggaran)
+	formulirAnggaranAdmin.Delete("/:id", middleware.RequirePermission("system:manage"), middleware.Idempotency(), formulirPartaiBesarController.DeleteAnggaran)
+	formulirAnggaranAdmin.Put("/reorder", middleware.RequirePermission("system:manage"), middleware.Idempotency(), formulirPartaiBesarController.ReorderAnggaran)
+	formulirAnggaranAdmin.Patch("/:id/reorder", middleware.RequirePermission("system:manage"), middleware.Idempotency(), formulirPartaiBesarController.ReorderAnggaranByDirection)
 
 	// Formulir Partai Besar - Submission (Admin)
 	formulirSubmissionAdmin := v1.Group("/panel/formulir-partai-besar/submission",
@@ -539,7 +614,7 @@ func SetupRoutes(
 	)
 	formulirSubmissionAdmin.Get("", middleware.RequirePermission("system:read"), formulirPartaiBesarController.GetSubmissionList)
 	formulirSubmissionAdmin.Get("/:id", middleware.RequirePermission("system:read"), formulirPartaiBesarController.GetSubmissionDetail)
-	formulirSubmissionAdmin.Post("/:id/resend-email", middleware.RequirePermission("system:manage"), formulirPartaiBesarController.ResendEmail)
+	formulirSubmissionAdmin.Post("/:id/resend-email", middleware.RequirePermission("system:manage"), middleware.Idempotency(), formulirPartaiBesarController.ResendEmail)
 
 	// Formulir Partai Besar - Buyer
 	formulirBuyer := v1.Group("/buyer/formulir-partai-besar",
@@ -555,7 +630,7 @@ func SetupRoutes(
 		middleware.AdminOnly(),
 	)
 	whatsappAdmin.Get("", middleware.RequirePermission("system:read"), whatsappHandlerController.Get)
-	whatsappAdmin.Put("", middleware.RequirePermission("system:manage"), whatsappHandlerController.Update)
+	whatsappAdmin.Put("", middleware.RequirePermission("system:manage"), middleware.Idempotency(), whatsappHandlerController.Update)
 
 	// WhatsApp Handler - Public
 	v1.Get("/public/whatsapp-handler", whatsappHandlerController.GetActive)
@@ -570,7 +645,40 @@ func SetupRoutes(
 	)
 	informasiPickupAdmin.Get("", middleware.RequirePermission("operasional:read"), warehouseController.Get)
 	informasiPickupAdmin.Get("/jadwal", middleware.RequirePermission("operasional:read"), warehouseController.GetJadwal)
-	informasiPickupAdmin.Put("/jadwal", middleware.RequirePermission("operasional:manage"), warehouseController.UpdateJadwal)
+	informasiPickupAdmin.Put("/jadwal", middleware.RequirePermission("operasional:manage"), middleware.Idempotency(), warehouseController.UpdateJadwal)
+
+	// Pesanan - Buyer (pickup, dokumen & transfer manual per pesanan)
+	pesananBuyer := v1.Group("/buyer/pesanan/:id",
+		middleware.AuthMiddleware(),
+		middleware.BuyerOnly(),
+	)
+
+	// Janji Pickup - Buyer
+	pesananBuyer.Get("/pickup-slot", pickupAppointmentController.GetSlots)
+	pesananBuyer.Get("/pickup-appointment", pickupAppointmentController.GetAppointments)
+	pesananBuyer.Post("/pickup-appointment", pickupAppointmentController.Book)
+	pesananBuyer.Put("/pickup-appointment/:appointment_id", pickupAppointmentController.Reschedule)
+	pesananBuyer.Delete("/pickup-appointment/:appointment_id", pickupAppointmentController.Cancel)
+
+	// Invoice & nota kredit - Buyer
+	pesananBuyer.Get("/dokumen", pesananDokumenController.BuyerGetAll)
+	pesananBuyer.Post("/dokumen/invoice", pesananDokumenController.BuyerIssueInvoice)
+	pesananBuyer.Get("/dokumen/:dokumen_id/pdf", pesananDokumenController.BuyerDownload)
+
+	// Transfer Manual - Buyer
+	pesananBuyer.Get("/transfer-manual", pembayaranManualController.BuyerInstruksi)
+	pesananBuyer.Post("/bukti-transfer", pembayaranManualController.BuyerUploadBukti)
+
+	// Janji Pickup - Admin (kalender & pengecualian jadwal gudang)
+	pickupAdmin := v1.Group("/panel/pickup-appointment",
+		middleware.AuthMiddleware(),
+		middleware.AdminOnly(),
+	)
+	pickupAdmin.Get("/kalender", middleware.RequirePermission("operasional:read"), pickupAppointmentController.Calendar)
+	pickupAdmin.Get("/pengecualian", middleware.RequirePermission("operasional:read"), pickupAppointmentController.GetPengecualian)
+	pickupAdmin.Post("/pengecualian", middleware.RequirePermission("operasional:manage"), middleware.Idempotency(), pickupAppointmentController.CreatePengecualian)
+	pickupAdmin.Put("/pengecualian/:id", middleware.RequirePermission("operasional:manage"), middleware.Idempotency(), pickupAppointmentController.UpdatePengecualian)
+	pickupAdmin.Delete("/pengecualian/:id", middleware.RequirePermission("operasional:manage"), middleware.Idempotency(), pickupAppointmentController.DeletePengecualian)
 
 	// Blog - Admin
 	blogAdmin := v1.Group("/panel/blog",
@@ -581,10 +689,10 @@ func SetupRoutes(
 	blogAdmin.Get("/statistik", middleware.RequirePermission("marketing:read"), blogController.GetStatistics)
 	blogAdmin.Get("/search", middleware.RequirePermission("marketing:read"), blogController.Search)
 	blogAdmin.Get("/:id", middleware.RequirePermission("marketing:read"), blogController.GetByID)
-	blogAdmin.Post("", middleware.RequirePermission("marketing:manage"), blogController.Create)
-	blogAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), blogController.Update)
-	blogAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), blogController.Delete)
-	blogAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("marketing:manage"), blogController.ToggleStatus)
+	blogAdmin.Post("", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), blogController.Create)
+	blogAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), blogController.Update)
+	blogAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), blogController.Delete)
+	blogAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), blogController.ToggleStatus)
 
 	// Blog - Public
 	blogPublic := v1.Group("/public/blog")
@@ -601,11 +709,11 @@ func SetupRoutes(
 	kategoriBlogAdmin.Get("", middleware.RequirePermission("marketing:read"), kategoriBlogController.GetAll)
 	kategoriBlogAdmin.Get("/dropdown", middleware.RequirePermission("marketing:read"), kategoriBlogController.GetDropdownOptions)
 	kategoriBlogAdmin.Get("/:id", middleware.RequirePermission("marketing:read"), kategoriBlogController.GetByID)
-	kategoriBlogAdmin.Post("", middleware.RequirePermission("marketing:manage"), kategoriBlogController.Create)
-	kategoriBlogAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), kategoriBlogController.Update)
-	kategoriBlogAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), kategoriBlogController.Delete)
-	kategoriBlogAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("marketing:manage"), kategoriBlogController.ToggleStatus)
-	kategoriBlogAdmin.Patch("/:id/reorder", middleware.RequirePermission("marketing:manage"), kategoriBlogController.Reorder)
+	kategoriBlogAdmin.Post("", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), kategoriBlogController.Create)
+	kategoriBlogAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), kategoriBlogController.Update)
+	kategoriBlogAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), kategoriBlogController.Delete)
+	kategoriBlogAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), kategoriBlogController.ToggleStatus)
+	kategoriBlogAdmin.Patch("/:id/reorder", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), kategoriBlogController.Reorder)
 
 	// Kategori Blog - Public
 	v1.Get("/public/kategori-blog", kategoriBlogController.GetAllPublic)
@@ -618,10 +726,10 @@ func SetupRoutes(
 	labelBlogAdmin.Get("", middleware.RequirePermission("marketing:read"), labelBlogController.GetAll)
 	labelBlogAdmin.Get("/dropdown", middleware.RequirePermission("marketing:read"), labelBlogController.GetDropdownOptions)
 	labelBlogAdmin.Get("/:id", middleware.RequirePermission("marketing:read"), labelBlogController.GetByID)
-	labelBlogAdmin.Post("", middleware.RequirePermission("marketing:manage"), labelBlogController.Create)
-	labelBlogAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), labelBlogController.Update)
-	labelBlogAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), labelBlogController.Delete)
-	labelBlogAdmin.Patch("/:id/reorder", middleware.RequirePermission("marketing:manage"), labelBlogController.Reorder)
+	labelBlogAdmin.Post("", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), labelBlogController.Create)
+	labelBlogAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), labelBlogController.Update)
+	labelBlogAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), labelBlogController.Delete)
+	labelBlogAdmin.Patch("/:id/reorder", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), labelBlogController.Reorder)
 
 	// Label Blog - Public
 	v1.Get("/public/label-blog", labelBlogController.GetAllPublic)
@@ -637,13 +745,13 @@ func SetupRoutes(
 	videoAdmin.Get("/search", middleware.RequirePermission("marketing:read"), videoController.Search)
 	videoAdmin.Get("/:id", middleware.RequirePermission("marketing:read"), videoController.GetByID)
 	videoAdmin.Get("/:id/transcode-status", middleware.RequirePermission("marketing:read"), videoController.GetTranscodeStatus)
-	videoAdmin.Post("", middleware.RequirePermission("marketing:manage"), videoController.Create)
-	videoAdmin.Post("/upload-chunk", middleware.RequirePermission("marketing:manage"), videoController.UploadChunk)
-	videoAdmin.Post("/finalize-chunk", middleware.RequirePermission("marketing:manage"), videoController.FinalizeChunk)
-	videoAdmin.Post("/:id/finalize-chunk", middleware.RequirePermission("marketing:manage"), videoController.FinalizeChunkUpdate)
-	videoAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), videoController.Update)
-	videoAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), videoController.Delete)
-	videoAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("marketing:manage"), videoController.ToggleStatus)
+	videoAdmin.Post("", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), videoController.Create)
+	videoAdmin.Post("/upload-chunk", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), videoController.UploadChunk)
+	videoAdmin.Post("/finalize-chunk", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), videoController.FinalizeChunk)
+	videoAdmin.Post("/:id/finalize-chunk", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), videoController.FinalizeChunkUpdate)
+	videoAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), videoController.Update)
+	videoAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), videoController.Delete)
+	videoAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), videoController.ToggleStatus)
 
 	// Video - Public
 	videoPublic := v1.Group("/public/video")
@@ -660,11 +768,11 @@ func SetupRoutes(
 	kategoriVideoAdmin.Get("", middleware.RequirePermission("marketing:read"), kategoriVideoController.GetAll)
 	kategoriVideoAdmin.Get("/dropdown", middleware.RequirePermission("marketing:read"), kategoriVideoController.GetDropdownOptions)
 	kategoriVideoAdmin.Get("/:id", middleware.RequirePermission("marketing:read"), kategoriVideoController.GetByID)
-	kategoriVideoAdmin.Post("", middleware.RequirePermission("marketing:manage"), kategoriVideoController.Create)
-	kategoriVideoAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), kategoriVideoController.Update)
-	kategoriVideoAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), kategoriVideoController.Delete)
-	kategoriVideoAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("marketing:manage"), kategoriVideoController.ToggleStatus)
-	kategoriVideoAdmin.Patch("/:id/reorder", middleware.RequirePermission("marketing:manage"), kategoriVideoController.Reorder)
+	kategoriVideoAdmin.Post("", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), kategoriVideoController.Create)
+	kategoriVideoAdmin.Put("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), kategoriVideoController.Update)
+	kategoriVideoAdmin.Delete("/:id", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), kategoriVideoController.Delete)
+	kategoriVideoAdmin.Patch("/:id/toggle-status", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), kategoriVideoController.ToggleStatus)
+	kategoriVideoAdmin.Patch("/:id/reorder", middleware.RequirePermission("marketing:manage"), middleware.Idempotency(), kategoriVideoController.Reorder)
 
 	// Kategori Video - Public
 	v1.Get("/public/kategori-video", kategoriVideoController.GetAllPublic)
@@ -717,12 +825,14 @@ func SetupRoutes(
 	internalMaster.Get("/sumber-produk", sumberController.ListActive)
 
 	// Webhook Deliveree — dipanggil provider (inbound), verifikasi via
-	// Authorization header vs DELIVEREE_WEBHOOK_AUTHORIZATION di controller.
+	// Authorization header (DELIVEREE_WEBHOOK_AUTHORIZATION) dan/atau signature
+	// HMAC (DELIVEREE_WEBHOOK_SECRET); setiap request dicatat di log webhook.
 	webhook := v1.Group("/webhook")
 	webhook.Post("/deliveree", delivereeWebhookController.Handle)
 
 	// Webhook Forwarder — dipanggil provider (inbound), verifikasi via
-	// Authorization header vs FORWARDER_WEBHOOK_AUTHORIZATION di controller.
+	// Authorization header (FORWARDER_WEBHOOK_AUTHORIZATION) dan/atau signature
+	// HMAC (FORWARDER_WEBHOOK_SECRET); setiap request dicatat di log webhook.
 	// Format payload sama dengan Deliveree (platform on-demand yang sama).
 	webhook.Post("/forwarder", forwarderWebhookController.Handle)
 
@@ -733,9 +843,9 @@ func SetupRoutes(
 	)
 	delivereeVehicleAdmin.Get("", middleware.RequirePermission("deliveree_vehicle:read"), delivereeVehicleTypeController.FindAll)
 	delivereeVehicleAdmin.Get("/:id", middleware.RequirePermission("deliveree_vehicle:read"), delivereeVehicleTypeController.FindByID)
-	delivereeVehicleAdmin.Put("/:id", middleware.RequirePermission("deliveree_vehicle:manage"), delivereeVehicleTypeController.Update)
-	delivereeVehicleAdmin.Post("/bulk-status", middleware.RequirePermission("deliveree_vehicle:manage"), delivereeVehicleTypeController.BulkUpdateStatus)
-	delivereeVehicleAdmin.Post("/sync", middleware.RequirePermission("deliveree_vehicle:manage"), delivereeVehicleTypeController.Sync)
+	delivereeVehicleAdmin.Put("/:id", middleware.RequirePermission("deliveree_vehicle:manage"), middleware.Idempotency(), delivereeVehicleTypeController.Update)
+	delivereeVehicleAdmin.Post("/bulk-status", middleware.RequirePermission("deliveree_vehicle:manage"), middleware.Idempotency(), delivereeVehicleTypeController.BulkUpdateStatus)
+	delivereeVehicleAdmin.Post("/sync", middleware.RequirePermission("deliveree_vehicle:manage"), middleware.Idempotency(), delivereeVehicleTypeController.Sync)
 
 	// Forwarder Mapping - Admin (master data kota & kecamatan Forwarder, sync dari API)
 	forwarderMappingAdmin := v1.Group("/panel/forwarder-mapping",
@@ -744,7 +854,34 @@ func SetupRoutes(
 	)
 	forwarderMappingAdmin.Get("/cities", middleware.RequirePermission("forwarder_mapping:read"), forwarderMappingController.FindCities)
 	forwarderMappingAdmin.Get("/subdistricts", middleware.RequirePermission("forwarder_mapping:read"), forwarderMappingController.FindSubdistricts)
-	forwarderMappingAdmin.Post("/sync", middleware.RequirePermission("forwarder_mapping:manage"), forwarderMappingController.Sync)
+	forwarderMappingAdmin.Post("/sync", middleware.RequirePermission("forwarder_mapping:manage"), middleware.Idempotency(), forwarderMappingController.Sync)
+
+	// Shipping Coverage - Admin (zona provinsi/kota yang dilayani Deliveree &
+	// Forwarder darat, plus tes routing satu alamat)
+	shippingCoverageAdmin := v1.Group("/panel/shipping-coverage",
+		middleware.AuthMiddleware(),
+		middleware.AdminOnly(),
+	)
+	shippingCoverageAdmin.Get("", middleware.RequirePermission("shipping_coverage:read"), shippingCoverageController.GetAll)
+	shippingCoverageAdmin.Post("/test-address", middleware.RequirePermission("shipping_coverage:read"), middleware.Idempotency(), shippingCoverageController.TestAddress)
+	shippingCoverageAdmin.Get("/:id", middleware.RequirePermission("shipping_coverage:read"), shippingCoverageController.GetByID)
+	shippingCoverageAdmin.Post("", middleware.RequirePermission("shipping_coverage:manage"), middleware.Idempotency(), shippingCoverageController.Create)
+	shippingCoverageAdmin.Put("/:id", middleware.RequirePermission("shipping_coverage:manage"), middleware.Idempotency(), shippingCoverageController.Update)
+	shippingCoverageAdmin.Delete("/:id", middleware.RequirePermission("shipping_coverage:manage"), middleware.Idempotency(), shippingCoverageController.Delete)
+
+	// Shipping Quote - Admin (opsi ongkir per provider untuk item + alamat)
+	shippingQuoteAdmin := v1.Group("/panel/shipping-quote",
+		middleware.AuthMiddleware(),
+		middleware.AdminOnly(),
+	)
+	shippingQuoteAdmin.Post("", middleware.RequirePermission("pesanan:read"), middleware.Idempotency(), shippingQuoteController.AdminQuote)
+
+	// Shipping Quote - Internal (dipanggil storefront BE saat checkout via
+	// X-Internal-Key; ID quote disimpan ke pesanan.shipping_quote_id)
+	internalShipping := v1.Group("/internal/shipping",
+		middleware.InternalKeyMiddleware(cfg.InternalAPIKey),
+	)
+	internalShipping.Post("/quote", shippingQuoteController.StorefrontQuote)
 
 	// WMS Integration - Admin (OAuth token exchange + cek koneksi, fondasi sync
 	// produk palet dari inventory WMS jadi cargo online)
@@ -752,16 +889,44 @@ func SetupRoutes(
 		middleware.AuthMiddleware(),
 		middleware.AdminOnly(),
 	)
-	wmsAdmin.Post("/test-connection", middleware.RequirePermission("wms_integration:manage"), wmsController.TestConnection)
+	wmsAdmin.Post("/test-connection", middleware.RequirePermission("wms_integration:manage"), middleware.Idempotency(), wmsController.TestConnection)
 	wmsAdmin.Get("/cargos/ready-to-price", middleware.RequirePermission("wms_integration:manage"), wmsController.ListReadyToPriceCargos)
 	wmsAdmin.Get("/cargos/ready-to-price/count", middleware.RequirePermission("wms_integration:manage"), wmsController.CountReadyToPriceCargos)
-	wmsAdmin.Post("/cargos/:id/price", middleware.RequirePermission("wms_integration:manage"), wmsController.SetCargoPrice)
+	wmsAdmin.Post("/cargos/:id/price", middleware.RequirePermission("wms_integration:manage"), middleware.Idempotency(), wmsController.SetCargoPrice)
 	// Dropdown "ID Cargo", download PDF harga, & konfirmasi sinkron dipakai
 	// dari form create produk, jadi diizinkan juga untuk admin dengan
 	// permission produk:create/update (bukan hanya wms_integration:manage).
 	wmsAdmin.Get("/cargos/already-priced", middleware.RequireAnyPermission("wms_integration:manage", "produk:create", "produk:update"), wmsController.ListAlreadyPricedCargos)
 	wmsAdmin.Get("/cargos/:id/pricing-pdf", middleware.RequireAnyPermission("wms_integration:manage", "produk:create", "produk:update"), wmsController.DownloadCargoPricingPDF)
-	wmsAdmin.Post("/cargos/:id/status", middleware.RequireAnyPermission("wms_integration:manage", "produk:create", "produk:update"), wmsController.MarkCargoSynced)
+	wmsAdmin.Post("/cargos/:id/status", middleware.RequireAnyPermission("wms_integration:manage", "produk:create", "produk:update"), middleware.Idempotency(), wmsController.MarkCargoSynced)
+
+	// Outbox - Admin (monitoring & replay pengiriman efek samping eksternal
+	// yang gagal: notifikasi storefront, email)
+	outboxAdmin := v1.Group("/panel/outbox",
+		middleware.AuthMiddleware(),
+		middleware.AdminOnly(),
+	)
+	outboxAdmin.Get("", middleware.RequirePermission("system:read"), outboxController.GetAll)
+	outboxAdmin.Post("/:id/replay", middleware.RequirePermission("system:manage"), middleware.Idempotency(), outboxController.Replay)
+
+	// Job Queue - Admin (status worker background: booking pengiriman,
+	// transcode video, job cron; retry job yang DEAD)
+	jobAdmin := v1.Group("/panel/jobs",
+		middleware.AuthMiddleware(),
+		middleware.AdminOnly(),
+	)
+	jobAdmin.Get("", middleware.RequirePermission("system:read"), jobController.GetAll)
+	jobAdmin.Get("/summary", middleware.RequirePermission("system:read"), jobController.GetSummary)
+	jobAdmin.Post("/:id/retry", middleware.RequirePermission("system:manage"), middleware.Idempotency(), jobController.Retry)
+
+	// Webhook Log - Admin (audit webhook provider pengiriman mentah & proses ulang)
+	webhookLogAdmin := v1.Group("/panel/webhook-log",
+		middleware.AuthMiddleware(),
+		middleware.AdminOnly(),
+	)
+	webhookLogAdmin.Get("", middleware.RequirePermission("webhook_log:read"), webhookLogController.GetAll)
+	webhookLogAdmin.Get("/:id", middleware.RequirePermission("webhook_log:read"), webhookLogController.GetByID)
+	webhookLogAdmin.Post("/:id/reprocess", middleware.RequirePermission("webhook_log:reprocess"), middleware.Idempotency(), webhookLogController.Reprocess)
 
 	// Routes list endpoint
 	router.Get("/api/routes", func(c *fiber.Ctx) error {
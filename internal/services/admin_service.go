@@ -20,23 +20,32 @@ type AdminService interface {
 	Delete(ctx context.Context, id, currentAdminID string) error
 	ToggleStatus(ctx context.Context, id, currentAdminID string) (*models.ToggleStatusResponse, error)
 	ResetPassword(ctx context.Context, id string, req *models.ResetPasswordRequest) error
+	// Unlock membuka kunci login (brute-force lockout) admin sebelum waktunya
+	Unlock(ctx context.Context, id string) (*models.AdminResponse, error)
+	// ResetTwoFactor menghapus 2FA admin yang kehilangan perangkat authenticator
+	// dan recovery code-nya. Semua sesi admin tersebut ikut dicabut.
+	ResetTwoFactor(ctx context.Context, id string) (*models.AdminResponse, error)
 	UpdateProfile(ctx context.Context, id string, nama, email string) (*models.Admin, error)
 	IsEmailExistExcludeID(ctx context.Context, email, excludeID string) (bool, error)
 }
 
 type adminService struct {
-	repo        repositories.AdminRepository
-	sessionRepo repositories.AdminSessionRepository
-	roleRepo    repositories.RoleRepository
-	cfg         *config.Config
+	repo            repositories.AdminRepository
+	sessionService  SessionService
+	permissionCache PermissionCache
+	loginThrottle   LoginThrottleService
+	roleRepo        repositories.RoleRepository
+	cfg             *config.Config
 }
 
-func NewAdminService(repo repositories.AdminRepository, sessionRepo repositories.AdminSessionRepository, roleRepo repositories.RoleRepository) AdminService {
+func NewAdminService(repo repositories.AdminRepository, sessionService SessionService, permissionCache PermissionCache, loginThrottle LoginThrottleService, roleRepo repositories.RoleRepository) AdminService {
 	return &adminService{
-		repo:        repo,
-		sessionRepo: sessionRepo,
-		roleRepo:    roleRepo,
-		cfg:         config.LoadConfig(),
+		repo:            repo,
+		sessionService:  sessionService,
+		permissionCache: permissionCache,
+		loginThrottle:   loginThrottle,
+		roleRepo:        roleRepo,
+		cfg:             config.LoadConfig(),
 	}
 }
 
@@ -79,7 +88,13 @@ func (s *adminService) FindByID(ctx context.Context, id string) (*models.AdminRe
 	if err != nil {
 		return nil, errors.New("admin tidak ditemukan")
 	}
-	return s.toResponse(admin), nil
+
+	result := s.toResponse(admin)
+	result.LockedUntil, err = s.loginThrottle.LockedUntil(ctx, models.UserTypeAdmin, admin.Email)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 func (s *adminService) FindAll(ctx context.Context, params *models.AdminFilterRequest) ([]models.AdminListResponse, *models.PaginationMeta, error) {
@@ -133,7 +148,9 @@ func (s *adminService) Update(ctx context.Context, id string, req *models.Update
 	if req.Nama != nil {
 		admin.Nama = *req.Nama
 	}
+	deactivated := false
 	if req.IsActive != nil {
+		deactivated = admin.IsActive && !*req.IsActive
 		admin.IsActive = *req.IsActive
 	}
 
@@ -160,6 +177,15 @@ func (s *adminService) Update(ctx context.Context, id string, req *models.Update
 		return nil, err
 	}
 
+	// Perubahan role/status langsung berlaku di request berikutnya
+	s.permissionCache.InvalidateAdmin(admin.ID)
+
+	if deactivated {
+		if err := s.sessionService.RevokeAllForUser(ctx, models.UserTypeAdmin, admin.ID, models.SessionRevokeAccountDeactivated, nil); err != nil {
+			return nil, err
+		}
+	}
+
 	return s.toResponse(admin), nil
 }
 
@@ -173,15 +199,18 @@ func (s *adminService) Delete(ctx context.Context, id, currentAdminID string) er
 		return errors.New("tidak dapat menghapus admin terakhir")
 	}
 
-	_, err := s.repo.FindByID(ctx, id)
+	admin, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return errors.New("admin tidak ditemukan")
 	}
 
-	// Delete all sessions
-	s.sessionRepo.DeleteByAdminID(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.permissionCache.InvalidateAdmin(admin.ID)
 
-	return s.repo.Delete(ctx, id)
+	// Revoke all sessions
+	return s.sessionService.RevokeAllForUser(ctx, models.UserTypeAdmin, admin.ID, models.SessionRevokeAccountDeleted, nil)
 }
 
 func (s *adminService) ToggleStatus(ctx context.Context, id, currentAdminID string) (*models.ToggleStatusResponse, error) {
@@ -198,10 +227,13 @@ func (s *adminService) ToggleStatus(ctx context.Context, id, currentAdminID stri
 	if err := s.repo.Update(ctx, admin); err != nil {
 		return nil, err
 	}
+	s.permissionCache.InvalidateAdmin(admin.ID)
 
-	// If deactivated, delete all sessions
+	// If deactivated, revoke all sessions
 	if !admin.IsActive {
-		s.sessionRepo.DeleteByAdminID(ctx, id)
+		if err := s.sessionService.RevokeAllForUser(ctx, models.UserTypeAdmin, admin.ID, models.SessionRevokeAccountDeactivated, nil); err != nil {
+			return nil, err
+		}
 	}
 
 	return &models.ToggleStatusResponse{
@@ -228,10 +260,48 @@ func (s *adminService) ResetPassword(ctx context.Context, id string, req *models
 		return err
 	}
 
-	// Delete all sessions to force re-login
-	s.sessionRepo.DeleteByAdminID(ctx, id)
+	// Revoke all sessions to force re-login
+	return s.sessionService.RevokeAllForUser(ctx, models.UserTypeAdmin, admin.ID, models.SessionRevokePasswordReset, nil)
+}
+
+func (s *adminService) Unlock(ctx context.Context, id string) (*models.AdminResponse, error) {
+	admin, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("admin tidak ditemukan")
+	}
+
+	locked, err := s.loginThrottle.Unlock(ctx, models.UserTypeAdmin, admin.Email)
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, errors.New("akun admin tidak sedang terkunci")
+	}
+
+	return s.toResponse(admin), nil
+}
+
+func (s *adminService) ResetTwoFactor(ctx context.Context, id string) (*models.AdminResponse, error) {
+	admin, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("admin tidak ditemukan")
+	}
+	if !admin.TwoFactorEnabled && admin.TwoFactorSecret == nil {
+		return nil, errors.New("2FA admin belum aktif")
+	}
+
+	clearTwoFactor(admin)
+	if err := s.repo.Update(ctx, admin); err != nil {
+		return nil, err
+	}
 
-	return nil
+	// Role yang wajib 2FA akan kembali meminta setup di login berikutnya
+	s.permissionCache.InvalidateAdmin(admin.ID)
+	if err := s.sessionService.RevokeAllForUser(ctx, models.UserTypeAdmin, admin.ID, models.SessionRevokeTwoFactorReset, nil); err != nil {
+		return nil, err
+	}
+
+	return s.toResponse(admin), nil
 }
 
 func (s *adminService) toResponse(a *models.Admin) *models.AdminResponse {
@@ -244,6 +314,8 @@ func (s *adminService) toResponse(a *models.Admin) *models.AdminResponse {
 		LastLoginAt: a.LastLoginAt,
 		CreatedAt:   a.CreatedAt,
 		UpdatedAt:   a.UpdatedAt,
+
+		TwoFactorEnabled: a.TwoFactorEnabled,
 	}
 }
 
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// passwordResetCooldown mencegah satu email dibanjiri link reset
+const passwordResetCooldown = time.Minute
+
+var ErrPasswordResetTokenInvalid = errors.New("link reset password tidak valid atau sudah kadaluarsa")
+
+// ForgotPassword mengirim link reset password ke email admin. Selalu sukses
+// dari sisi pemanggil (termasuk jika email tidak terdaftar) agar endpoint
+// tidak bisa dipakai untuk mengecek email mana yang terdaftar.
+func (s *authV2Service) ForgotPassword(ctx context.Context, email, ipAddress, userAgent string) error {
+	admin, err := s.authRepo.FindAdminByEmail(strings.TrimSpace(email))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !admin.IsActive {
+		return nil
+	}
+
+	latest, err := s.passwordResetRepo.FindLatest(ctx, models.UserTypeAdmin, admin.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < passwordResetCooldown {
+		return nil
+	}
+
+	rawToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+	token := &models.PasswordResetToken{
+		UserType:  models.UserTypeAdmin,
+		UserID:    admin.ID,
+		TokenHash: utils.HashToken(rawToken),
+		ExpiresAt: time.Now().Add(s.cfg.PasswordResetDuration),
+	}
+	if ipAddress != "" {
+		token.RequestedIP = &ipAddress
+	}
+	if err := s.passwordResetRepo.Create(ctx, token); err != nil {
+		return err
+	}
+
+	resetURL := strings.TrimRight(s.cfg.PanelBaseURL, "/") + "/reset-password?token=" + url.QueryEscape(rawToken)
+	data := map[string]interface{}{
+		"Nama":      admin.Nama,
+		"ResetURL":  resetURL,
+		"ExpiresAt": token.ExpiresAt.Format("02 Jan 2006 15:04 MST"),
+	}
+	// Dikirim di background agar waktu respons tidak membedakan email terdaftar/tidak
+	go func() {
+		if err := s.emailService.SendTemplate(admin.Email, "Reset Password Bulky Panel", EmailTemplatePasswordReset, data); err != nil {
+			log.Printf("[password-reset] gagal mengirim email ke admin %s: %v", admin.ID, err)
+		}
+	}()
+
+	s.logActivity(ctx, &admin.ID, "ADMIN", models.ActionCreate, "security", "Meminta link reset password", ipAddress, userAgent)
+	return nil
+}
+
+// ResetPasswordWithToken menukar token dari email dengan password baru, lalu
+// mencabut semua sesi login admin tersebut.
+func (s *authV2Service) ResetPasswordWithToken(ctx context.Context, rawToken, newPassword, ipAddress, userAgent string) error {
+	token, err := s.passwordResetRepo.FindByHash(ctx, utils.HashToken(strings.TrimSpace(rawToken)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPasswordResetTokenInvalid
+		}
+		return err
+	}
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) || token.UserType != models.UserTypeAdmin {
+		return ErrPasswordResetTokenInvalid
+	}
+
+	admin, err := s.authRepo.FindAdminByID(token.UserID)
+	if err != nil || !admin.IsActive {
+		return ErrPasswordResetTokenInvalid
+	}
+
+	hashedPassword, err := utils.HashPasswordWithCost(newPassword, s.cfg.BcryptCost)
+	if err != nil {
+		return errors.New("gagal meng-hash password")
+	}
+
+	// Tandai terpakai sebelum password diganti: dari request paralel dengan
+	// token yang sama hanya satu yang lolos
+	used, err := s.passwordResetRepo.MarkUsed(ctx, token.ID)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrPasswordResetTokenInvalid
+	}
+
+	admin.Password = hashedPassword
+	if err := s.authRepo.UpdateAdmin(admin); err != nil {
+		return err
+	}
+
+	if err := s.sessionService.RevokeAllForUser(ctx, models.UserTypeAdmin, admin.ID, models.SessionRevokePasswordReset, nil); err != nil {
+		return err
+	}
+	// Pemilik email terbukti, lockout brute-force akun ikut dibuka
+	s.loginThrottle.RecordSuccess(ctx, models.UserTypeAdmin, admin.Email)
+
+	s.logActivity(ctx, &admin.ID, "ADMIN", models.ActionUpdate, "security", "Reset password lewat link email", ipAddress, userAgent)
+	return nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/config"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type fakePasswordResetTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]*models.PasswordResetToken
+}
+
+func (r *fakePasswordResetTokenRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token.ID = uuid.New()
+	token.CreatedAt = time.Now()
+	r.tokens[token.ID] = token
+	return nil
+}
+
+func (r *fakePasswordResetTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakePasswordResetTokenRepository) FindLatest(ctx context.Context, userType models.UserType, userID uuid.UUID) (*models.PasswordResetToken, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakePasswordResetTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[id]
+	if !ok || token.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	return true, nil
+}
+
+// fakeLoginThrottleService tidak pernah memblokir
+type fakeLoginThrottleService struct {
+	LoginThrottleService
+}
+
+func (s *fakeLoginThrottleService) RecordSuccess(ctx context.Context, userType models.UserType, email string) error {
+	return nil
+}
+
+func (r *fakeAuthRepository) FindAdminByID(id uuid.UUID) (*models.Admin, error) {
+	return r.FindAdminWithRole(id)
+}
+
+func (r *fakeAuthRepository) UpdateAdmin(admin *models.Admin) error {
+	copied := *admin
+	r.admins[admin.ID] = &copied
+	return nil
+}
+
+func TestResetPasswordWithTokenSingleUse(t *testing.T) {
+	ctx := context.Background()
+
+	admin := &models.Admin{ID: uuid.New(), Email: "admin@bulky.id", Password: "hash-lama", IsActive: true}
+	authRepo := &fakeAuthRepository{admins: map[uuid.UUID]*models.Admin{admin.ID: admin}}
+	sessionRepo := &fakeAuthSessionRepository{sessions: map[uuid.UUID]*models.AuthSession{}}
+	resetRepo := &fakePasswordResetTokenRepository{tokens: map[uuid.UUID]*models.PasswordResetToken{}}
+	sessionService := NewSessionService(sessionRepo, authRepo)
+
+	svc := &authV2Service{
+		authRepo:          authRepo,
+		activityRepo:      &fakeActivityLogRepository{},
+		sessionService:    sessionService,
+		loginThrottle:     &fakeLoginThrottleService{},
+		passwordResetRepo: resetRepo,
+		cfg:               &config.Config{BcryptCost: 4},
+	}
+
+	session, _ := sessionService.Create(ctx, models.UserTypeAdmin, admin.ID, "127.0.0.1", "test", time.Now().Add(time.Hour))
+	resetRepo.Create(ctx, &models.PasswordResetToken{
+		UserType: models.UserTypeAdmin, UserID: admin.ID,
+		TokenHash: utils.HashToken("token-valid"), ExpiresAt: time.Now().Add(time.Minute),
+	})
+	resetRepo.Create(ctx, &models.PasswordResetToken{
+		UserType: models.UserTypeAdmin, UserID: admin.ID,
+		TokenHash: utils.HashToken("token-expired"), ExpiresAt: time.Now().Add(-time.Minute),
+	})
+
+	if err := svc.ResetPasswordWithToken(ctx, "token-expired", "password-baru", "127.0.0.1", "test"); err != ErrPasswordResetTokenInvalid {
+		t.Fatalf("token kadaluarsa harus ditolak, got %v", err)
+	}
+
+	if err := svc.ResetPasswordWithToken(ctx, "token-valid", "password-baru", "127.0.0.1", "test"); err != nil {
+		t.Fatalf("token valid ditolak: %v", err)
+	}
+	if !utils.CheckPassword("password-baru", authRepo.admins[admin.ID].Password) {
+		t.Fatal("password admin harus diganti")
+	}
+	claims := &utils.JWTClaims{UserID: admin.ID.String(), UserType: "ADMIN", SessionID: session.ID.String()}
+	if err := sessionService.ValidateSession(ctx, claims); err != ErrSessionRevoked {
+		t.Fatalf("sesi lama harus dicabut setelah reset password, got %v", err)
+	}
+
+	// Token yang sama tidak bisa dipakai dua kali
+	if err := svc.ResetPasswordWithToken(ctx, "token-valid", "password-lain", "127.0.0.1", "test"); err != ErrPasswordResetTokenInvalid {
+		t.Fatalf("token yang sudah dipakai harus ditolak, got %v", err)
+	}
+}
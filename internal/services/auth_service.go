@@ -21,16 +21,16 @@ type AuthService interface {
 }
 
 type authService struct {
-	adminRepo   repositories.AdminRepository
-	sessionRepo repositories.AdminSessionRepository
-	cfg         *config.Config
+	adminRepo      repositories.AdminRepository
+	sessionService SessionService
+	cfg            *config.Config
 }
 
-func NewAuthService(adminRepo repositories.AdminRepository, sessionRepo repositories.AdminSessionRepository) AuthService {
+func NewAuthService(adminRepo repositories.AdminRepository, sessionService SessionService) AuthService {
 	return &authService{
-		adminRepo:   adminRepo,
-		sessionRepo: sessionRepo,
-		cfg:         config.LoadConfig(),
+		adminRepo:      adminRepo,
+		sessionService: sessionService,
+		cfg:            config.LoadConfig(),
 	}
 }
 
@@ -52,13 +52,16 @@ func (s *authService) Login(ctx context.Context, req *models.LoginRequest, ipAdd
 	// Use AuthV2Service instead which supports roles & permissions
 	// This service no longer supports refresh tokens (single 24h token only)
 
+	session, err := s.sessionService.Create(ctx, models.UserTypeAdmin, admin.ID, ipAddress, userAgent, time.Now().Add(utils.GetAccessTokenTTL()))
+	if err != nil {
+		return nil, err
+	}
+
 	accessToken, err := utils.GenerateAccessToken(
 		admin.ID,
+		session.ID,
 		"ADMIN",
 		admin.Email,
-		"",  // roleID empty for legacy auth
-		"",  // roleKode empty for legacy auth
-		nil, // no permissions for legacy auth
 	)
 	if err != nil {
 		return nil, err
@@ -145,7 +148,11 @@ func (s *authService) ChangePassword(ctx context.Context, adminID string, req *m
 	}
 
 	admin.Password = hashedPassword
-	return s.adminRepo.Update(ctx, admin)
+	if err := s.adminRepo.Update(ctx, admin); err != nil {
+		return err
+	}
+
+	return s.sessionService.RevokeAllForUser(ctx, models.UserTypeAdmin, admin.ID, models.SessionRevokePasswordChanged, nil)
 }
 
 func (s *authService) toAdminResponse(a *models.Admin) *models.AdminResponse {
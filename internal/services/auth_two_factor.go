@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	twoFactorIssuer         = "Bulky Panel"
+	twoFactorChallengeTTL   = 5 * time.Minute
+	twoFactorRecoveryCount  = 10
+	twoFactorRecoveryLength = 10
+)
+
+var (
+	ErrTwoFactorInvalidCode      = errors.New("kode verifikasi 2FA salah")
+	ErrTwoFactorChallengeInvalid = errors.New("sesi verifikasi 2FA tidak valid atau sudah kadaluarsa, silakan login ulang")
+)
+
+// TwoFactorSetupResult berisi secret yang harus didaftarkan ke aplikasi
+// authenticator (otpauth_url dirender frontend sebagai QR code).
+type TwoFactorSetupResult struct {
+	Secret     string `json:"secret"`
+	OtpauthURL string `json:"otpauth_url"`
+}
+
+// SetupTwoFactor membuat secret TOTP baru (belum aktif sampai dikonfirmasi).
+// Memanggil ulang sebelum konfirmasi akan mengganti secret sebelumnya.
+func (s *authV2Service) SetupTwoFactor(ctx context.Context, adminID uuid.UUID) (*TwoFactorSetupResult, error) {
+	admin, err := s.authRepo.FindAdminByID(adminID)
+	if err != nil {
+		return nil, errors.New("admin tidak ditemukan")
+	}
+	if admin.TwoFactorEnabled {
+		return nil, errors.New("2FA sudah aktif")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := utils.EncryptString(s.cfg.TwoFactorEncryptionKey, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	admin.TwoFactorSecret = &encrypted
+	admin.TwoFactorConfirmedAt = nil
+	admin.TwoFactorLastStep = nil
+	if err := s.authRepo.UpdateAdminTwoFactor(admin); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorSetupResult{
+		Secret:     secret,
+		OtpauthURL: utils.TOTPProvisioningURI(twoFactorIssuer, admin.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor mengaktifkan 2FA setelah kode pertama dari authenticator
+// benar, lalu mengembalikan recovery code (plaintext hanya ditampilkan sekali).
+func (s *authV2Service) ConfirmTwoFactor(ctx context.Context, adminID uuid.UUID, code, ipAddress, userAgent string) ([]string, error) {
+	admin, err := s.authRepo.FindAdminByID(adminID)
+	if err != nil {
+		return nil, errors.New("admin tidak ditemukan")
+	}
+	if admin.TwoFactorEnabled {
+		return nil, errors.New("2FA sudah aktif")
+	}
+	if admin.TwoFactorSecret == nil {
+		return nil, errors.New("setup 2FA belum dimulai")
+	}
+
+	step, ok := s.validateTOTP(admin, code)
+	if !ok {
+		return nil, ErrTwoFactorInvalidCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	admin.TwoFactorEnabled = true
+	admin.TwoFactorConfirmedAt = &now
+	admin.TwoFactorLastStep = &step
+	admin.TwoFactorRecoveryCodes = hashes
+	if err := s.authRepo.UpdateAdminTwoFactor(admin); err != nil {
+		return nil, err
+	}
+
+	// Admin yang wajib 2FA langsung bisa mengakses endpoint ber-permission
+	s.permissionCache.InvalidateAdmin(admin.ID)
+	s.logActivity(ctx, &admin.ID, "ADMIN", models.ActionUpdate, "security", "Mengaktifkan 2FA", ipAddress, userAgent)
+
+	return codes, nil
+}
+
+// DisableTwoFactor menonaktifkan 2FA. Butuh password dan kode 2FA (atau
+// recovery code), dan ditolak jika role admin mewajibkan 2FA.
+func (s *authV2Service) DisableTwoFactor(ctx context.Context, adminID uuid.UUID, password, code, ipAddress, userAgent string) error {
+	admin, err := s.authRepo.FindAdminWithRole(adminID)
+	if err != nil {
+		return errors.New("admin tidak ditemukan")
+	}
+	if !admin.TwoFactorEnabled {
+		return errors.New("2FA belum aktif")
+	}
+	if admin.Role != nil && admin.Role.RequireTwoFactor {
+		return errors.New("role Anda mewajibkan 2FA, tidak dapat dinonaktifkan")
+	}
+	if !utils.CheckPassword(password, admin.Password) {
+		return errors.New("password saat ini salah")
+	}
+	if err := s.verifyTwoFactor(admin, code); err != nil {
+		return err
+	}
+
+	clearTwoFactor(admin)
+	if err := s.authRepo.UpdateAdminTwoFactor(admin); err != nil {
+		return err
+	}
+
+	s.permissionCache.InvalidateAdmin(admin.ID)
+	s.logActivity(ctx, &admin.ID, "ADMIN", models.ActionUpdate, "security", "Menonaktifkan 2FA", ipAddress, userAgent)
+	return nil
+}
+
+// RegenerateRecoveryCodes mengganti semua recovery code (yang lama tidak berlaku lagi)
+func (s *authV2Service) RegenerateRecoveryCodes(ctx context.Context, adminID uuid.UUID, code, ipAddress, userAgent string) ([]string, error) {
+	admin, err := s.authRepo.FindAdminByID(adminID)
+	if err != nil {
+		return nil, errors.New("admin tidak ditemukan")
+	}
+	if !admin.TwoFactorEnabled {
+		return nil, errors.New("2FA belum aktif")
+	}
+
+	step, ok := s.validateTOTP(admin, code)
+	if !ok {
+		return nil, ErrTwoFactorInvalidCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	admin.TwoFactorLastStep = &step
+	admin.TwoFactorRecoveryCodes = hashes
+	if err := s.authRepo.UpdateAdminTwoFactor(admin); err != nil {
+		return nil, err
+	}
+
+	s.logActivity(ctx, &admin.ID, "ADMIN", models.ActionUpdate, "security", "Membuat ulang recovery code 2FA", ipAddress, userAgent)
+	return codes, nil
+}
+
+// AdminLoginTwoFactor adalah langkah kedua login admin yang mengaktifkan 2FA:
+// token challenge dari AdminLogin ditukar bersama kode TOTP atau recovery code.
+func (s *authV2Service) AdminLoginTwoFactor(ctx context.Context, challengeToken, code, ipAddress, userAgent string) (*LoginResultSimplified, error) {
+	adminID, err := utils.ValidateTwoFactorChallengeToken(challengeToken)
+	if err != nil {
+		return nil, ErrTwoFactorChallengeInvalid
+	}
+
+	admin, err := s.authRepo.FindAdminWithRole(adminID)
+	if err != nil || !admin.IsActive || !admin.TwoFactorEnabled {
+		return nil, ErrTwoFactorChallengeInvalid
+	}
+
+	// Kode 2FA ikut dibatasi proteksi brute-force yang sama dengan password
+	if err := s.loginThrottle.Check(ctx, models.UserTypeAdmin, admin.Email, ipAddress); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyTwoFactor(admin, code); err != nil {
+		s.recordLoginFailure(ctx, &admin.ID, models.UserTypeAdmin, admin.Email, "Login gagal: kode 2FA salah", ipAddress, userAgent)
+		return nil, err
+	}
+	if err := s.authRepo.UpdateAdminTwoFactor(admin); err != nil {
+		return nil, err
+	}
+
+	return s.completeAdminLogin(ctx, admin, ipAddress, userAgent)
+}
+
+// verifyTwoFactor menerima kode TOTP 6 digit atau recovery code. Perubahan
+// (last step / recovery code terpakai) hanya diset di struct, pemanggil yang menyimpan.
+func (s *authV2Service) verifyTwoFactor(admin *models.Admin, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == utils.TOTPDigits {
+		step, ok := s.validateTOTP(admin, code)
+		if !ok {
+			return ErrTwoFactorInvalidCode
+		}
+		admin.TwoFactorLastStep = &step
+		return nil
+	}
+
+	hash := utils.HashToken(normalizeRecoveryCode(code))
+	for i, stored := range admin.TwoFactorRecoveryCodes {
+		if stored == hash {
+			// Recovery code hanya bisa dipakai sekali
+			remaining := append(models.StringList{}, admin.TwoFactorRecoveryCodes[:i]...)
+			admin.TwoFactorRecoveryCodes = append(remaining, admin.TwoFactorRecoveryCodes[i+1:]...)
+			return nil
+		}
+	}
+	return ErrTwoFactorInvalidCode
+}
+
+// validateTOTP memeriksa kode terhadap secret admin dan menolak time-step yang
+// sudah pernah dipakai (mencegah kode yang sama di-replay dalam 30 detik).
+func (s *authV2Service) validateTOTP(admin *models.Admin, code string) (int64, bool) {
+	if admin.TwoFactorSecret == nil {
+		return 0, false
+	}
+	secret, err := utils.DecryptString(s.cfg.TwoFactorEncryptionKey, *admin.TwoFactorSecret)
+	if err != nil {
+		return 0, false
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now(), 1)
+	if !ok {
+		return 0, false
+	}
+	if admin.TwoFactorLastStep != nil && step <= *admin.TwoFactorLastStep {
+		return 0, false
+	}
+	return step, true
+}
+
+// generateRecoveryCodes membuat recovery code format XXXXX-XXXXX beserta hash-nya
+func generateRecoveryCodes() ([]string, models.StringList, error) {
+	codes := make([]string, 0, twoFactorRecoveryCount)
+	hashes := make(models.StringList, 0, twoFactorRecoveryCount)
+	for i := 0; i < twoFactorRecoveryCount; i++ {
+		raw, err := utils.GenerateTOTPSecret()
+		if err != nil {
+			return nil, nil, err
+		}
+		code := raw[:twoFactorRecoveryLength/2] + "-" + raw[twoFactorRecoveryLength/2:twoFactorRecoveryLength]
+		codes = append(codes, code)
+		hashes = append(hashes, utils.HashToken(normalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
+
+// clearTwoFactor menghapus seluruh konfigurasi 2FA admin
+func clearTwoFactor(admin *models.Admin) {
+	admin.TwoFactorEnabled = false
+	admin.TwoFactorSecret = nil
+	admin.TwoFactorConfirmedAt = nil
+	admin.TwoFactorRecoveryCodes = nil
+	admin.TwoFactorLastStep = nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/config"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/pkg/utils"
+)
+
+func TestVerifyTwoFactorRejectsReplay(t *testing.T) {
+	cfg := &config.Config{TwoFactorEncryptionKey: "test-encryption-key"}
+	svc := &authV2Service{cfg: cfg}
+
+	secret, _ := utils.GenerateTOTPSecret()
+	encrypted, err := utils.EncryptString(cfg.TwoFactorEncryptionKey, secret)
+	if err != nil {
+		t.Fatalf("EncryptString: %v", err)
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("generateRecoveryCodes: %v", err)
+	}
+	admin := &models.Admin{TwoFactorEnabled: true, TwoFactorSecret: &encrypted, TwoFactorRecoveryCodes: hashes}
+
+	// Kode TOTP yang sama tidak boleh dipakai dua kali
+	code, _ := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+	if err := svc.verifyTwoFactor(admin, code); err != nil {
+		t.Fatalf("kode TOTP valid ditolak: %v", err)
+	}
+	if err := svc.verifyTwoFactor(admin, code); err != ErrTwoFactorInvalidCode {
+		t.Fatalf("kode TOTP yang sudah dipakai harus ditolak, got %v", err)
+	}
+
+	// Recovery code diterima meski ada spasi di sekitarnya, dan hanya sekali
+	if err := svc.verifyTwoFactor(admin, " "+codes[0]+" "); err != nil {
+		t.Fatalf("recovery code valid ditolak: %v", err)
+	}
+	if len(admin.TwoFactorRecoveryCodes) != twoFactorRecoveryCount-1 {
+		t.Fatalf("recovery code terpakai harus dihapus, sisa %d", len(admin.TwoFactorRecoveryCodes))
+	}
+	if err := svc.verifyTwoFactor(admin, codes[0]); err != ErrTwoFactorInvalidCode {
+		t.Fatalf("recovery code yang sudah dipakai harus ditolak, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"log"
 	"time"
 
 	"project-bulky-be/internal/config"
@@ -18,46 +19,98 @@ type AuthV2Service interface {
 	// Authentication - Separated by user type
 	AdminLogin(ctx context.Context, email, password, ipAddress, userAgent string) (*LoginResultSimplified, error)
 	BuyerLogin(ctx context.Context, email, password, ipAddress, userAgent string) (*LoginResultSimplified, error)
+	// RefreshToken menukar refresh token dengan pasangan access + refresh token baru
+	// (rotasi). Token yang sudah pernah ditukar me-revoke seluruh family-nya.
+	RefreshToken(ctx context.Context, refreshToken, ipAddress, userAgent string) (*LoginResultSimplified, error)
+
+	// Two-factor authentication (admin panel)
+	AdminLoginTwoFactor(ctx context.Context, challengeToken, code, ipAddress, userAgent string) (*LoginResultSimplified, error)
+	SetupTwoFactor(ctx context.Context, adminID uuid.UUID) (*TwoFactorSetupResult, error)
+	ConfirmTwoFactor(ctx context.Context, adminID uuid.UUID, code, ipAddress, userAgent string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, adminID uuid.UUID, password, code, ipAddress, userAgent string) error
+	RegenerateRecoveryCodes(ctx context.Context, adminID uuid.UUID, code, ipAddress, userAgent string) ([]string, error)
 
 	// Profile
 	GetAdminWithPermissions(ctx context.Context, userID uuid.UUID) (interface{}, error)
 	GetBuyer(ctx context.Context, userID uuid.UUID) (interface{}, error)
-	ChangePassword(ctx context.Context, userID uuid.UUID, userType, currentPassword, newPassword, ipAddress, userAgent string) error
+	ChangePassword(ctx context.Context, userID uuid.UUID, userType, currentPassword, newPassword, currentSessionID, ipAddress, userAgent string) error
+	Logout(ctx context.Context, userID uuid.UUID, userType, sessionID, ipAddress, userAgent string) error
+
+	// Lupa password (admin panel)
+	ForgotPassword(ctx context.Context, email, ipAddress, userAgent string) error
+	ResetPasswordWithToken(ctx context.Context, token, newPassword, ipAddress, userAgent string) error
 }
 
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token tidak valid atau sudah expired, silakan login ulang")
+	ErrRefreshTokenReused  = errors.New("refresh token sudah pernah dipakai, semua sesi terkait dicabut. Silakan login ulang")
+)
+
 type LoginResultSimplified struct {
-	User        interface{} `json:"user"`
-	AccessToken string      `json:"access_token"`
-	Permissions []string    `json:"permissions,omitempty"`
-	RoleKode    string      `json:"role_kode,omitempty"`
+	User         interface{} `json:"user,omitempty"`
+	AccessToken  string      `json:"access_token,omitempty"`
+	RefreshToken string      `json:"refresh_token,omitempty"`
+	ExpiresIn    int         `json:"expires_in,omitempty"` // detik, masa berlaku access token
+	Permissions  []string    `json:"permissions,omitempty"`
+	RoleKode     string      `json:"role_kode,omitempty"`
+
+	// TwoFactorRequired: password benar tapi login harus dilanjutkan ke
+	// /login/2fa dengan TwoFactorToken (token lain di atas kosong).
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	TwoFactorToken    string `json:"two_factor_token,omitempty"`
+	// TwoFactorSetupRequired: role mewajibkan 2FA tapi admin belum mengaktifkannya,
+	// endpoint ber-permission ditolak sampai setup selesai.
+	TwoFactorSetupRequired bool `json:"two_factor_setup_required,omitempty"`
 }
 
 type authV2Service struct {
-	authRepo     repositories.AuthRepository
-	activityRepo repositories.ActivityLogRepository
-	cfg          *config.Config
+	authRepo          repositories.AuthRepository
+	activityRepo      repositories.ActivityLogRepository
+	refreshTokenRepo  repositories.RefreshTokenRepository
+	sessionService    SessionService
+	loginThrottle     LoginThrottleService
+	permissionCache   PermissionCache
+	passwordResetRepo repositories.PasswordResetTokenRepository
+	emailService      EmailService
+	cfg               *config.Config
 }
 
 func NewAuthV2Service(
 	authRepo repositories.AuthRepository,
 	activityRepo repositories.ActivityLogRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	sessionService SessionService,
+	loginThrottle LoginThrottleService,
+	permissionCache PermissionCache,
+	passwordResetRepo repositories.PasswordResetTokenRepository,
+	emailService EmailService,
 ) AuthV2Service {
 	return &authV2Service{
-		authRepo:     authRepo,
-		activityRepo: activityRepo,
-		cfg:          config.LoadConfig(),
+		authRepo:          authRepo,
+		activityRepo:      activityRepo,
+		refreshTokenRepo:  refreshTokenRepo,
+		sessionService:    sessionService,
+		loginThrottle:     loginThrottle,
+		permissionCache:   permissionCache,
+		passwordResetRepo: passwordResetRepo,
+		emailService:      emailService,
+		cfg:               config.LoadConfig(),
 	}
 }
 
 func (s *authV2Service) AdminLogin(ctx context.Context, email, password, ipAddress, userAgent string) (*LoginResultSimplified, error) {
 
+	// Tolak lebih awal jika akun/IP sedang backoff atau terkunci
+	if err := s.loginThrottle.Check(ctx, models.UserTypeAdmin, email, ipAddress); err != nil {
+		return nil, err
+	}
+
 	// Find admin by email
 	admin, err := s.authRepo.FindAdminByEmail(email)
 	if err != nil {
-		// Log failed login
-		s.logActivity(ctx, nil, "ADMIN", models.ActionLoginFailed, "auth", "Login gagal: email tidak ditemukan", ipAddress, userAgent)
-
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Log failed login
+			s.recordLoginFailure(ctx, nil, models.UserTypeAdmin, email, "Login gagal: email tidak ditemukan", ipAddress, userAgent)
 			return nil, errors.New("email atau password salah")
 		}
 		return nil, err
@@ -66,7 +119,7 @@ func (s *authV2Service) AdminLogin(ctx context.Context, email, password, ipAddre
 	// Check password
 	if !utils.CheckPassword(password, admin.Password) {
 		// Log failed login
-		s.logActivity(ctx, &admin.ID, "ADMIN", models.ActionLoginFailed, "auth", "Login gagal: password salah", ipAddress, userAgent)
+		s.recordLoginFailure(ctx, &admin.ID, models.UserTypeAdmin, email, "Login gagal: password salah", ipAddress, userAgent)
 		return nil, errors.New("email atau password salah")
 	}
 
@@ -81,57 +134,62 @@ func (s *authV2Service) AdminLogin(ctx context.Context, email, password, ipAddre
 		return nil, errors.New("gagal memuat data role")
 	}
 
-	// Extract permissions
-	var permissions []string
-	if admin.Role != nil {
-		for _, perm := range admin.Role.Permissions {
-			permissions = append(permissions, perm.Kode)
+	// Admin dengan 2FA aktif harus menyelesaikan langkah kedua (AdminLoginTwoFactor).
+	// Counter login gagal sengaja belum di-reset agar kode 2FA tidak bisa di-brute-force.
+	if admin.TwoFactorEnabled {
+		challengeToken, err := utils.GenerateTwoFactorChallengeToken(admin.ID, twoFactorChallengeTTL)
+		if err != nil {
+			return nil, errors.New("gagal membuat sesi login")
 		}
+		return &LoginResultSimplified{
+			TwoFactorRequired: true,
+			TwoFactorToken:    challengeToken,
+		}, nil
+	}
+
+	return s.completeAdminLogin(ctx, admin, ipAddress, userAgent)
+}
+
+// completeAdminLogin membuat sesi dan token setelah semua faktor autentikasi lolos
+func (s *authV2Service) completeAdminLogin(ctx context.Context, admin *models.Admin, ipAddress, userAgent string) (*LoginResultSimplified, error) {
+	session, err := s.sessionService.Create(ctx, models.UserTypeAdmin, admin.ID, ipAddress, userAgent, time.Now().Add(s.cfg.JWTRefreshDuration))
+	if err != nil {
+		return nil, errors.New("gagal membuat sesi login")
 	}
 
-	// Generate token (24 hours)
-	accessToken, err := utils.GenerateAccessToken(
-		admin.ID,
-		"ADMIN",
-		admin.Email,
-		admin.Role.ID.String(),
-		admin.Role.Kode,
-		permissions,
-	)
+	refreshToken, err := s.issueRefreshToken(ctx, session, nil)
+	if err != nil {
+		return nil, errors.New("gagal membuat sesi login")
+	}
+
+	result, err := s.adminTokenResult(admin, session.ID, refreshToken)
 	if err != nil {
 		return nil, err
 	}
 
 	// Update last login
 	s.authRepo.UpdateAdminLastLogin(admin.ID)
+	s.loginThrottle.RecordSuccess(ctx, models.UserTypeAdmin, admin.Email)
 
 	// Log successful login
 	s.logActivity(ctx, &admin.ID, "ADMIN", models.ActionLogin, "auth", "Login berhasil", ipAddress, userAgent)
 
-	// Simplified response
-	result := &LoginResultSimplified{
-		User: map[string]interface{}{
-			"id":    admin.ID.String(),
-			"nama":  admin.Nama,
-			"email": admin.Email,
-		},
-		AccessToken: accessToken,
-		Permissions: permissions,
-		RoleKode:    admin.Role.Kode,
-	}
-
 	return result, nil
 }
 
 func (s *authV2Service) BuyerLogin(ctx context.Context, email, password, ipAddress, userAgent string) (*LoginResultSimplified, error) {
 
+	// Tolak lebih awal jika akun/IP sedang backoff atau terkunci
+	if err := s.loginThrottle.Check(ctx, models.UserTypeBuyer, email, ipAddress); err != nil {
+		return nil, err
+	}
+
 	// Find buyer by email
 	buyer, err := s.authRepo.FindBuyerByEmail(email)
 	if err != nil {
-		// Log failed login
-		s.logActivity(ctx, nil, "BUYER", models.ActionLoginFailed, "auth", "Login gagal: email tidak ditemukan", ipAddress, userAgent)
-
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Log failed login
+			s.recordLoginFailure(ctx, nil, models.UserTypeBuyer, email, "Login gagal: email tidak ditemukan", ipAddress, userAgent)
 			return nil, errors.New("email atau password salah")
 		}
 		return nil, err
@@ -140,7 +198,7 @@ func (s *authV2Service) BuyerLogin(ctx context.Context, email, password, ipAddre
 	// Check password
 	if buyer.Password == nil || !utils.CheckPassword(password, *buyer.Password) {
 		// Log failed login
-		s.logActivity(ctx, &buyer.ID, "BUYER", models.ActionLoginFailed, "auth", "Login gagal: password salah", ipAddress, userAgent)
+		s.recordLoginFailure(ctx, &buyer.ID, models.UserTypeBuyer, email, "Login gagal: password salah", ipAddress, userAgent)
 		return nil, errors.New("email atau password salah")
 	}
 
@@ -149,41 +207,202 @@ func (s *authV2Service) BuyerLogin(ctx context.Context, email, password, ipAddre
 		return nil, errors.New("akun Anda tidak aktif. Silakan hubungi admin")
 	}
 
-	// Generate token (24 hours)
-	buyerEmail := ""
-	if buyer.Email != nil {
-		buyerEmail = *buyer.Email
+	session, err := s.sessionService.Create(ctx, models.UserTypeBuyer, buyer.ID, ipAddress, userAgent, time.Now().Add(s.cfg.JWTRefreshDuration))
+	if err != nil {
+		return nil, errors.New("gagal membuat sesi login")
 	}
-	accessToken, err := utils.GenerateAccessToken(
-		buyer.ID,
-		"BUYER",
-		buyerEmail,
-		"",
-		"",
-		nil,
-	)
+
+	refreshToken, err := s.issueRefreshToken(ctx, session, nil)
+	if err != nil {
+		return nil, errors.New("gagal membuat sesi login")
+	}
+
+	result, err := s.buyerTokenResult(buyer, session.ID, refreshToken)
 	if err != nil {
 		return nil, err
 	}
 
 	// Update last login
 	s.authRepo.UpdateBuyerLastLogin(buyer.ID)
+	s.loginThrottle.RecordSuccess(ctx, models.UserTypeBuyer, email)
 
 	// Log successful login
 	s.logActivity(ctx, &buyer.ID, "BUYER", models.ActionLogin, "auth", "Login berhasil", ipAddress, userAgent)
 
+	return result, nil
+}
+
+func (s *authV2Service) RefreshToken(ctx context.Context, refreshToken, ipAddress, userAgent string) (*LoginResultSimplified, error) {
+	if refreshToken == "" {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	current, err := s.refreshTokenRepo.FindByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRefreshTokenInvalid
+		}
+		return nil, err
+	}
+
+	// Token yang sudah ditukar dipakai lagi → kemungkinan dicuri, cabut seluruh family
+	if current.IsUsed() {
+		return nil, s.revokeTokenFamily(ctx, current, ipAddress, userAgent)
+	}
+	if !time.Now().Before(current.ExpiresAt) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	session, err := s.sessionService.Extend(ctx, current.SessionID, time.Now().Add(s.cfg.JWTRefreshDuration))
+	if err != nil {
+		if errors.Is(err, ErrSessionRevoked) {
+			return nil, ErrRefreshTokenInvalid
+		}
+		return nil, err
+	}
+
+	nextToken, err := s.issueRefreshToken(ctx, session, current)
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			return nil, s.revokeTokenFamily(ctx, current, ipAddress, userAgent)
+		}
+		return nil, err
+	}
+
+	var result *LoginResultSimplified
+	switch current.UserType {
+	case models.UserTypeAdmin:
+		admin, err := s.authRepo.FindAdminWithRole(current.UserID)
+		if err != nil || !admin.IsActive {
+			return nil, ErrRefreshTokenInvalid
+		}
+		result, err = s.adminTokenResult(admin, session.ID, nextToken)
+		if err != nil {
+			return nil, err
+		}
+	case models.UserTypeBuyer:
+		buyer, err := s.authRepo.FindBuyerByID(current.UserID)
+		if err != nil || !buyer.IsActive {
+			return nil, ErrRefreshTokenInvalid
+		}
+		result, err = s.buyerTokenResult(buyer, session.ID, nextToken)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	s.logActivity(ctx, &current.UserID, string(current.UserType), models.ActionTokenRefresh, "auth", "Refresh token berhasil", ipAddress, userAgent)
+
+	return result, nil
+}
+
+// issueRefreshToken membuat refresh token baru untuk session. Jika parent diisi,
+// parent ditandai sudah ditukar secara atomik; ErrRefreshTokenReused berarti
+// parent sudah lebih dulu ditukar oleh request lain.
+func (s *authV2Service) issueRefreshToken(ctx context.Context, session *models.AuthSession, parent *models.RefreshToken) (string, error) {
+	raw, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	token := &models.RefreshToken{
+		ID:        uuid.New(),
+		SessionID: session.ID,
+		UserType:  session.UserType,
+		UserID:    session.UserID,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: session.ExpiresAt,
+	}
+
+	if parent == nil {
+		if err := s.refreshTokenRepo.Create(ctx, token); err != nil {
+			return "", err
+		}
+		return raw, nil
+	}
+
+	token.ParentID = &parent.ID
+	rotated, err := s.refreshTokenRepo.Rotate(ctx, parent.ID, token)
+	if err != nil {
+		return "", err
+	}
+	if !rotated {
+		return "", ErrRefreshTokenReused
+	}
+	return raw, nil
+}
+
+// revokeTokenFamily me-revoke session (dan semua access token-nya) beserta
+// seluruh refresh token dalam family yang sama.
+func (s *authV2Service) revokeTokenFamily(ctx context.Context, token *models.RefreshToken, ipAddress, userAgent string) error {
+	if err := s.sessionService.Revoke(ctx, token.SessionID, models.SessionRevokeRefreshTokenReuse); err != nil {
+		return err
+	}
+	if err := s.refreshTokenRepo.RevokeFamily(ctx, token.SessionID); err != nil {
+		return err
+	}
+
+	s.logActivity(ctx, &token.UserID, string(token.UserType), models.ActionTokenReuse, "auth",
+		"Refresh token lama dipakai ulang, sesi "+token.SessionID.String()+" dicabut", ipAddress, userAgent)
+
+	return ErrRefreshTokenReused
+}
+
+func (s *authV2Service) adminTokenResult(admin *models.Admin, sessionID uuid.UUID, refreshToken string) (*LoginResultSimplified, error) {
+	// Extract permissions
+	var permissions []string
+	if admin.Role != nil {
+		for _, perm := range admin.Role.Permissions {
+			permissions = append(permissions, perm.Kode)
+		}
+	}
+
+	accessToken, err := utils.GenerateAccessToken(admin.ID, sessionID, "ADMIN", admin.Email)
+	if err != nil {
+		return nil, err
+	}
+
 	// Simplified response
-	result := &LoginResultSimplified{
+	return &LoginResultSimplified{
+		User: map[string]interface{}{
+			"id":    admin.ID.String(),
+			"nama":  admin.Nama,
+			"email": admin.Email,
+		},
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    utils.GetAccessTokenDuration(),
+		Permissions:  permissions,
+		RoleKode:     admin.Role.Kode,
+		// Role wajib 2FA tapi admin belum setup: frontend mengarahkan ke halaman setup
+		TwoFactorSetupRequired: admin.Role.RequireTwoFactor && !admin.TwoFactorEnabled,
+	}, nil
+}
+
+func (s *authV2Service) buyerTokenResult(buyer *models.Buyer, sessionID uuid.UUID, refreshToken string) (*LoginResultSimplified, error) {
+	buyerEmail := ""
+	if buyer.Email != nil {
+		buyerEmail = *buyer.Email
+	}
+	accessToken, err := utils.GenerateAccessToken(buyer.ID, sessionID, "BUYER", buyerEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	// Simplified response
+	return &LoginResultSimplified{
 		User: map[string]interface{}{
 			"id":      buyer.ID.String(),
 			"nama":    buyer.Nama,
 			"email":   buyer.Email,
 			"telepon": buyer.Telepon,
 		},
-		AccessToken: accessToken,
-	}
-
-	return result, nil
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    utils.GetAccessTokenDuration(),
+	}, nil
 }
 
 func (s *authV2Service) GetAdminWithPermissions(ctx context.Context, userID uuid.UUID) (interface{}, error) {
@@ -204,9 +423,11 @@ func (s *authV2Service) GetAdminWithPermissions(ctx context.Context, userID uuid
 		"nama":  admin.Nama,
 		"email": admin.Email,
 		"role": map[string]interface{}{
-			"nama": admin.Role.Nama,
+			"nama":               admin.Role.Nama,
+			"require_two_factor": admin.Role.RequireTwoFactor,
 		},
-		"permissions": permissions,
+		"permissions":        permissions,
+		"two_factor_enabled": admin.TwoFactorEnabled,
 	}, nil
 }
 
@@ -225,6 +446,26 @@ func (s *authV2Service) GetBuyer(ctx context.Context, userID uuid.UUID) (interfa
 	}, nil
 }
 
+// recordLoginFailure mencatat LOGIN_FAILED, menaikkan counter brute-force, dan
+// mencatat event LOCK jika kegagalan ini memicu lockout akun/IP.
+func (s *authV2Service) recordLoginFailure(ctx context.Context, userID *uuid.UUID, userType models.UserType, email, deskripsi, ipAddress, userAgent string) {
+	s.logActivity(ctx, userID, string(userType), models.ActionLoginFailed, "auth", deskripsi, ipAddress, userAgent)
+
+	result, err := s.loginThrottle.RecordFailure(ctx, userType, email, ipAddress)
+	if err != nil {
+		log.Printf("[login-throttle] gagal mencatat login gagal: %v", err)
+		return
+	}
+	if result.AccountLocked {
+		s.logActivity(ctx, userID, string(userType), models.ActionLock, "auth",
+			"Akun "+email+" dikunci sementara karena terlalu banyak login gagal", ipAddress, userAgent)
+	}
+	if result.IPLocked {
+		s.logActivity(ctx, nil, string(userType), models.ActionLock, "auth",
+			"IP "+ipAddress+" diblokir sementara karena terlalu banyak login gagal", ipAddress, userAgent)
+	}
+}
+
 // Helper to log activity
 func (s *authV2Service) logActivity(ctx context.Context, userID *uuid.UUID, userType string, action models.ActivityAction, modul, deskripsi, ipAddress, userAgent string) {
 	log := &models.ActivityLog{
@@ -242,8 +483,33 @@ func (s *authV2Service) logActivity(ctx context.Context, userID *uuid.UUID, user
 	go s.activityRepo.Create(log)
 }
 
+// Logout me-revoke session yang terikat ke token saat ini
+func (s *authV2Service) Logout(ctx context.Context, userID uuid.UUID, userType, sessionID, ipAddress, userAgent string) error {
+	sid, err := uuid.Parse(sessionID)
+	if err != nil {
+		return errors.New("sesi tidak valid")
+	}
+
+	if err := s.sessionService.Revoke(ctx, sid, models.SessionRevokeLogout); err != nil {
+		return err
+	}
+
+	s.logActivity(ctx, &userID, userType, models.ActionLogout, "auth", "Logout berhasil", ipAddress, userAgent)
+	return nil
+}
+
+// revokeOtherSessions me-revoke semua session user selain session yang sedang
+// dipakai, agar perangkat lain wajib login ulang setelah password berubah.
+func (s *authV2Service) revokeOtherSessions(ctx context.Context, userType models.UserType, userID uuid.UUID, currentSessionID string) error {
+	var exceptID *uuid.UUID
+	if sid, err := uuid.Parse(currentSessionID); err == nil {
+		exceptID = &sid
+	}
+	return s.sessionService.RevokeAllForUser(ctx, userType, userID, models.SessionRevokePasswordChanged, exceptID)
+}
+
 // ChangePassword changes user password
-func (s *authV2Service) ChangePassword(ctx context.Context, userID uuid.UUID, userType, currentPassword, newPassword, ipAddress, userAgent string) error {
+func (s *authV2Service) ChangePassword(ctx context.Context, userID uuid.UUID, userType, currentPassword, newPassword, currentSessionID, ipAddress, userAgent string) error {
 
 	if userType == "ADMIN" {
 		admin, err := s.authRepo.FindAdminByID(userID)
@@ -274,6 +540,10 @@ func (s *authV2Service) ChangePassword(ctx context.Context, userID uuid.UUID, us
 			return err
 		}
 
+		if err := s.revokeOtherSessions(ctx, models.UserTypeAdmin, userID, currentSessionID); err != nil {
+			return err
+		}
+
 		// Log activity
 		s.logActivity(ctx, &userID, "ADMIN", models.ActionUpdate, "security",
 			"Mengubah password", ipAddress, userAgent)
@@ -309,6 +579,10 @@ func (s *authV2Service) ChangePassword(ctx context.Context, userID uuid.UUID, us
 			return err
 		}
 
+		if err := s.revokeOtherSessions(ctx, models.UserTypeBuyer, userID, currentSessionID); err != nil {
+			return err
+		}
+
 		// Log activity
 		s.logActivity(ctx, &userID, "BUYER", models.ActionUpdate, "security",
 			"Mengubah password", ipAddress, userAgent)
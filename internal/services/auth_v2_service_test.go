@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/config"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeRefreshTokenRepository menyimpan refresh token di memori dengan semantik
+// Rotate yang sama seperti UPDATE bersyarat di repository asli.
+type fakeRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]*models.RefreshToken
+}
+
+func (r *fakeRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token.ID] = token
+	return nil
+}
+
+func (r *fakeRefreshTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRefreshTokenRepository) Rotate(ctx context.Context, oldID uuid.UUID, next *models.RefreshToken) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.tokens[oldID]
+	if !ok || old.IsUsed() {
+		return false, nil
+	}
+	now := time.Now()
+	old.RotatedAt = &now
+	r.tokens[next.ID] = next
+	return true, nil
+}
+
+func (r *fakeRefreshTokenRepository) RevokeFamily(ctx context.Context, sessionID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.SessionID == sessionID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// fakeAuthRepository hanya mengimplementasikan method yang dipakai test
+type fakeAuthRepository struct {
+	repositories.AuthRepository
+	buyers map[uuid.UUID]*models.Buyer
+	admins map[uuid.UUID]*models.Admin
+}
+
+func (r *fakeAuthRepository) FindAdminWithRole(id uuid.UUID) (*models.Admin, error) {
+	admin, ok := r.admins[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *admin
+	return &copied, nil
+}
+
+func (r *fakeAuthRepository) FindBuyerByID(id uuid.UUID) (*models.Buyer, error) {
+	buyer, ok := r.buyers[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return buyer, nil
+}
+
+type fakeActivityLogRepository struct {
+	repositories.ActivityLogRepository
+}
+
+func (r *fakeActivityLogRepository) Create(log *models.ActivityLog) error {
+	return nil
+}
+
+func TestRefreshTokenRotationReuseDetection(t *testing.T) {
+	ctx := context.Background()
+	utils.SetJWTConfig("test-secret-minimum-32-characters-long", time.Minute)
+
+	buyer := &models.Buyer{ID: uuid.New(), Nama: "Buyer Test", IsActive: true}
+	sessionRepo := &fakeAuthSessionRepository{sessions: map[uuid.UUID]*models.AuthSession{}}
+	refreshRepo := &fakeRefreshTokenRepository{tokens: map[uuid.UUID]*models.RefreshToken{}}
+	authRepo := &fakeAuthRepository{buyers: map[uuid.UUID]*models.Buyer{buyer.ID: buyer}}
+	sessionService := NewSessionService(sessionRepo, authRepo)
+
+	svc := &authV2Service{
+		authRepo:         authRepo,
+		activityRepo:     &fakeActivityLogRepository{},
+		refreshTokenRepo: refreshRepo,
+		sessionService:   sessionService,
+		cfg:              &config.Config{JWTRefreshDuration: time.Hour},
+	}
+
+	session, _ := sessionService.Create(ctx, models.UserTypeBuyer, buyer.ID, "127.0.0.1", "test", time.Now().Add(time.Hour))
+	first, err := svc.issueRefreshToken(ctx, session, nil)
+	if err != nil {
+		t.Fatalf("issueRefreshToken: %v", err)
+	}
+
+	// Rotasi normal: token lama ditukar dengan token baru pada session yang sama
+	result, err := svc.RefreshToken(ctx, first, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("refresh pertama ditolak: %v", err)
+	}
+	if result.RefreshToken == "" || result.RefreshToken == first {
+		t.Fatalf("refresh token harus diganti, got %q", result.RefreshToken)
+	}
+	claims, err := utils.ValidateJWT(result.AccessToken)
+	if err != nil || claims.SessionID != session.ID.String() {
+		t.Fatalf("access token baru harus terikat ke session yang sama, got %+v (%v)", claims, err)
+	}
+	second := result.RefreshToken
+
+	// Token lama dipakai ulang → seluruh family dicabut
+	if _, err := svc.RefreshToken(ctx, first, "10.0.0.1", "attacker"); err != ErrRefreshTokenReused {
+		t.Fatalf("token yang sudah di-rotate harus terdeteksi reuse, got %v", err)
+	}
+	if err := sessionService.ValidateSession(ctx, claims); err != ErrSessionRevoked {
+		t.Fatalf("access token dari family yang di-revoke harus ditolak, got %v", err)
+	}
+	if _, err := svc.RefreshToken(ctx, second, "127.0.0.1", "test"); err == nil {
+		t.Fatal("refresh token terbaru dalam family yang di-revoke harus ditolak")
+	}
+
+	// Token yang tidak dikenal
+	if _, err := svc.RefreshToken(ctx, "tidak-ada", "127.0.0.1", "test"); err != ErrRefreshTokenInvalid {
+		t.Fatalf("token tidak dikenal harus ditolak, got %v", err)
+	}
+}
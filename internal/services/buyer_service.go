@@ -27,16 +27,18 @@ type BuyerService interface {
 }
 
 type buyerService struct {
-	repo       repositories.BuyerRepository
-	alamatRepo repositories.AlamatBuyerRepository
-	cfg        *config.Config
+	repo           repositories.BuyerRepository
+	alamatRepo     repositories.AlamatBuyerRepository
+	sessionService SessionService
+	cfg            *config.Config
 }
 
-func NewBuyerService(repo repositories.BuyerRepository, alamatRepo repositories.AlamatBuyerRepository) BuyerService {
+func NewBuyerService(repo repositories.BuyerRepository, alamatRepo repositories.AlamatBuyerRepository, sessionService SessionService) BuyerService {
 	return &buyerService{
-		repo:       repo,
-		alamatRepo: alamatRepo,
-		cfg:        config.LoadConfig(),
+		repo:           repo,
+		alamatRepo:     alamatRepo,
+		sessionService: sessionService,
+		cfg:            config.LoadConfig(),
 	}
 }
 
@@ -102,7 +104,9 @@ func (s *buyerService) Update(ctx context.Context, id string, req *models.Update
 	if req.Telepon != nil {
 		buyer.Telepon = *req.Telepon
 	}
+	deactivated := false
 	if req.IsActive != nil {
+		deactivated = buyer.IsActive && !*req.IsActive
 		buyer.IsActive = *req.IsActive
 	}
 
@@ -110,16 +114,25 @@ func (s *buyerService) Update(ctx context.Context, id string, req *models.Update
 		return nil, err
 	}
 
+	if deactivated {
+		if err := s.sessionService.RevokeAllForUser(ctx, models.UserTypeBuyer, buyer.ID, models.SessionRevokeAccountDeactivated, nil); err != nil {
+			return nil, err
+		}
+	}
+
 	buyer, _ = s.repo.FindByIDWithAlamat(ctx, id)
 	return s.toDetailResponse(buyer), nil
 }
 
 func (s *buyerService) Delete(ctx context.Context, id string) error {
-	_, err := s.repo.FindByID(ctx, id)
+	buyer, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return errors.New("buyer tidak ditemukan")
 	}
-	return s.repo.Delete(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.sessionService.RevokeAllForUser(ctx, models.UserTypeBuyer, buyer.ID, models.SessionRevokeAccountDeleted, nil)
 }
 
 func (s *buyerService) ToggleStatus(ctx context.Context, id string) (*models.ToggleStatusResponse, error) {
@@ -133,6 +146,12 @@ func (s *buyerService) ToggleStatus(ctx context.Context, id string) (*models.Tog
 		return nil, err
 	}
 
+	if !buyer.IsActive {
+		if err := s.sessionService.RevokeAllForUser(ctx, models.UserTypeBuyer, buyer.ID, models.SessionRevokeAccountDeactivated, nil); err != nil {
+			return nil, err
+		}
+	}
+
 	return &models.ToggleStatusResponse{
 		ID:        buyer.ID.String(),
 		IsActive:  buyer.IsActive,
@@ -153,7 +172,12 @@ func (s *buyerService) ResetPassword(ctx context.Context, id string, req *models
 	}
 
 	buyer.Password = &hashedPassword
-	return s.repo.Update(ctx, buyer)
+	if err := s.repo.Update(ctx, buyer); err != nil {
+		return err
+	}
+
+	// Revoke all sessions to force re-login
+	return s.sessionService.RevokeAllForUser(ctx, models.UserTypeBuyer, buyer.ID, models.SessionRevokePasswordReset, nil)
 }
 
 func (s *buyerService) GetStatistik(ctx context.Context) (*models.BuyerStatistikResponse, error) {
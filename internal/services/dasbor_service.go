@@ -101,6 +101,10 @@ func (s *dasborService) GetChartRevenue(ctx context.Context, periode string) (*d
 	if err != nil {
 		return nil, err
 	}
+	refundRows, totalRefund, err := s.repo.GetChartRefund(periode)
+	if err != nil {
+		return nil, err
+	}
 
 	labels, dates := generateDailyLabels(periode)
 
@@ -108,19 +112,31 @@ func (s *dasborService) GetChartRevenue(ctx context.Context, periode string) (*d
 	for _, r := range rows {
 		revenueMap[r.Tanggal] = r.TotalPenjualan
 	}
+	refundMap := make(map[string]float64, len(refundRows))
+	for _, r := range refundRows {
+		refundMap[r.Tanggal] = r.TotalPenjualan
+	}
 
 	revenueData := make([]float64, len(dates))
+	refundData := make([]float64, len(dates))
+	bersihData := make([]float64, len(dates))
 	for i, d := range dates {
 		revenueData[i] = revenueMap[d]
+		refundData[i] = refundMap[d]
+		bersihData[i] = revenueData[i] - refundData[i]
 	}
 
 	return &dto.DasborChartRevenueResponse{
 		Periode: periode,
 		Labels:  labels,
 		Series: dto.DasborChartRevenueSeries{
-			TotalPenjualan: revenueData,
+			TotalPenjualan:   revenueData,
+			Refund:           refundData,
+			PendapatanBersih: bersihData,
 		},
 		TotalKeseluruhan: grandTotal,
+		TotalRefund:      totalRefund,
+		TotalBersih:      grandTotal - totalRefund,
 	}, nil
 }
 
@@ -194,12 +210,18 @@ func (s *dasborService) GetKPI(ctx context.Context, periode string) (*dto.Dasbor
 	if err != nil {
 		return nil, err
 	}
+	refund, err := s.repo.GetKPIRefund(periode)
+	if err != nil {
+		return nil, err
+	}
 
 	return &dto.DasborKPIResponse{
 		Periode:         periode,
 		StokPaletbox:    available,
 		PaletboxTerjual: sold,
 		Revenue:         revenue,
+		Refund:          refund,
+		RevenueBersih:   revenue - refund,
 	}, nil
 }
 
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrMuatanMelebihiKendaraan dikembalikan jika ada unit barang yang lebih
+	// besar/berat dari ruang kargo kendaraan Deliveree yang dipakai
+	ErrMuatanMelebihiKendaraan = errors.New("barang tidak muat di ruang kargo kendaraan Deliveree")
+	// ErrMuatanPerluBeberapaKendaraan dikembalikan saat booking jika rencana muat
+	// butuh lebih dari satu kendaraan; satu booking Deliveree hanya satu kendaraan
+	ErrMuatanPerluBeberapaKendaraan = errors.New("muatan butuh lebih dari satu kendaraan Deliveree")
+)
+
+// toleransiDimensi menyerap pembulatan konversi cm ke meter
+const toleransiDimensi = 1e-6
+
+// muatUnit satu unit barang (qty 1) dalam meter, m3 dan kg
+type muatUnit struct {
+	item     *models.PesananItem
+	panjang  float64
+	lebar    float64
+	tinggi   float64
+	kubikasi float64
+	berat    float64
+}
+
+type muatKendaraan struct {
+	vehicle  *models.DelivereeVehicleType
+	units    []muatUnit
+	kubikasi float64
+	berat    float64
+}
+
+// newMuatUnit mengonversi dimensi produk (cm) ke meter. Produk yang dimensinya
+// belum lengkap dianggap tanpa dimensi dan hanya dihitung beratnya.
+func newMuatUnit(item *models.PesananItem) (muatUnit, bool) {
+	p := item.Produk
+	unit := muatUnit{item: item, berat: p.Berat}
+	if p.Panjang <= 0 || p.Lebar <= 0 || p.Tinggi <= 0 {
+		return unit, false
+	}
+	unit.panjang = p.Panjang / 100
+	unit.lebar = p.Lebar / 100
+	unit.tinggi = p.Tinggi / 100
+	unit.kubikasi = p.Panjang * p.Lebar * p.Tinggi / 1_000_000
+	return unit, true
+}
+
+// unitFitsCargo mengecek satu unit terhadap ruang kargo kendaraan. Panjang &
+// lebar boleh diputar di lantai kargo, tinggi tidak (palet tidak direbahkan).
+// Dimensi kargo yang belum diketahui (kosong/0) tidak membatasi.
+func unitFitsCargo(unit muatUnit, v *models.DelivereeVehicleType) bool {
+	if unit.berat > v.BeratMax+toleransiDimensi || unit.kubikasi > v.KubikasiMax+toleransiDimensi {
+		return false
+	}
+	panjang, lebar, tinggi := derefFloat(v.CargoLength), derefFloat(v.CargoWidth), derefFloat(v.CargoHeight)
+	if tinggi > 0 && unit.tinggi > tinggi+toleransiDimensi {
+		return false
+	}
+	fits := func(a, b float64) bool {
+		return (panjang <= 0 || a <= panjang+toleransiDimensi) && (lebar <= 0 || b <= lebar+toleransiDimensi)
+	}
+	return fits(unit.panjang, unit.lebar) || fits(unit.lebar, unit.panjang)
+}
+
+// vehicleCanCarry sama dengan aturan SelectVehicle: kapasitas fisik harus
+// cukup, dan jika withThreshold total juga harus dalam batas aman ops
+func vehicleCanCarry(v *models.DelivereeVehicleType, kubikasi, berat float64, withThreshold bool) bool {
+	if v.KubikasiMax < kubikasi || v.BeratMax < berat {
+		return false
+	}
+	return !withThreshold || (kubikasi <= v.ThresholdKubikasi && berat <= v.ThresholdBerat)
+}
+
+// unfitItem mengembalikan item pertama yang tidak muat di ruang kargo kendaraan
+func unfitItem(v *models.DelivereeVehicleType, items []models.PesananItem) *models.PesananItem {
+	for i := range items {
+		if unit, _ := newMuatUnit(&items[i]); !unitFitsCargo(unit, v) {
+			return &items[i]
+		}
+	}
+	return nil
+}
+
+// smallestVehicleFor memilih kendaraan terkecil (vehicles urut kubikasi_max
+// naik) yang memuat semua unit; threshold diutamakan seperti SelectVehicle
+func smallestVehicleFor(vehicles []models.DelivereeVehicleType, units []muatUnit, kubikasi, berat float64) *models.DelivereeVehicleType {
+	for _, withThreshold := range []bool{true, false} {
+	next:
+		for i := range vehicles {
+			v := &vehicles[i]
+			if !vehicleCanCarry(v, kubikasi, berat, withThreshold) {
+				continue
+			}
+			for _, unit := range units {
+				if !unitFitsCargo(unit, v) {
+					continue next
+				}
+			}
+			return v
+		}
+	}
+	return nil
+}
+
+// planDelivereeLoad menyusun rencana muat item pengiriman ke kendaraan aktif.
+// Setiap unit harus muat di ruang kargo minimal satu kendaraan. Jika satu
+// kendaraan tidak cukup, unit dibagi first-fit decreasing ke kendaraan
+// terbesar yang memuatnya, lalu tiap kendaraan diperkecil seperlunya.
+// Kubikasi dihitung per volume; susunan 3D di dalam kargo tidak disimulasikan.
+func planDelivereeLoad(items []models.PesananItem, vehicles []models.DelivereeVehicleType) (*models.RencanaMuat, error) {
+	if len(vehicles) == 0 {
+		return nil, errors.New("belum ada master kendaraan Deliveree aktif")
+	}
+	sorted := make([]models.DelivereeVehicleType, len(vehicles))
+	copy(sorted, vehicles)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].KubikasiMax < sorted[j].KubikasiMax
+	})
+
+	plan := &models.RencanaMuat{DibuatAt: time.Now()}
+	var units []muatUnit
+	for i := range items {
+		item := &items[i]
+		unit, hasDimensi := newMuatUnit(item)
+		if !hasDimensi {
+			plan.TanpaDimensi = append(plan.TanpaDimensi, item.NamaProduk)
+		}
+		fits := false
+		for j := range sorted {
+			if unitFitsCargo(unit, &sorted[j]) {
+				fits = true
+				break
+			}
+		}
+		if !fits {
+			p := item.Produk
+			return nil, fmt.Errorf("%w: %s (%.0fx%.0fx%.0f cm, %.0f kg) melebihi kendaraan terbesar", ErrMuatanMelebihiKendaraan, item.NamaProduk, p.Panjang, p.Lebar, p.Tinggi, p.Berat)
+		}
+		for q := 0; q < item.Qty; q++ {
+			units = append(units, unit)
+			plan.TotalKubikasi += unit.kubikasi
+			plan.TotalBerat += unit.berat
+		}
+	}
+
+	var bins []muatKendaraan
+	if v := smallestVehicleFor(sorted, units, plan.TotalKubikasi, plan.TotalBerat); v != nil {
+		bins = []muatKendaraan{{vehicle: v, units: units, kubikasi: plan.TotalKubikasi, berat: plan.TotalBerat}}
+	} else {
+		bins = packMuatan(sorted, units)
+	}
+
+	for _, bin := range bins {
+		vehicle := bin.vehicle
+		if v := smallestVehicleFor(sorted, bin.units, bin.kubikasi, bin.berat); v != nil {
+			vehicle = v
+		}
+		plan.Kendaraan = append(plan.Kendaraan, models.RencanaMuatKendaraan{
+			VehicleTypeID: vehicle.IDDeliveree,
+			Nama:          vehicle.Nama,
+			Kubikasi:      bin.kubikasi,
+			Berat:         bin.berat,
+			Items:         groupMuatItems(bin.units),
+		})
+	}
+	return plan, nil
+}
+
+// packMuatan membagi unit (terbesar dulu) ke kendaraan pertama yang masih
+// punya ruang; kendaraan baru memakai kendaraan terbesar yang memuat unit itu
+func packMuatan(vehicles []models.DelivereeVehicleType, units []muatUnit) []muatKendaraan {
+	ordered := make([]muatUnit, len(units))
+	copy(ordered, units)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].kubikasi != ordered[j].kubikasi {
+			return ordered[i].kubikasi > ordered[j].kubikasi
+		}
+		return ordered[i].berat > ordered[j].berat
+	})
+
+	var bins []muatKendaraan
+	for _, unit := range ordered {
+		placed := false
+		for i := range bins {
+			bin := &bins[i]
+			if unitFitsCargo(unit, bin.vehicle) && vehicleCanCarry(bin.vehicle, bin.kubikasi+unit.kubikasi, bin.berat+unit.berat, false) {
+				bin.units = append(bin.units, unit)
+				bin.kubikasi += unit.kubikasi
+				bin.berat += unit.berat
+				placed = true
+				break
+			}
+		}
+		if placed {
+			continue
+		}
+		for i := len(vehicles) - 1; i >= 0; i-- {
+			if unitFitsCargo(unit, &vehicles[i]) {
+				bins = append(bins, muatKendaraan{vehicle: &vehicles[i], units: []muatUnit{unit}, kubikasi: unit.kubikasi, berat: unit.berat})
+				break
+			}
+		}
+	}
+	return bins
+}
+
+// groupMuatItems menjumlahkan unit per item pesanan, urut kemunculan
+func groupMuatItems(units []muatUnit) []models.RencanaMuatItem {
+	var result []models.RencanaMuatItem
+	index := map[uuid.UUID]int{}
+	for _, unit := range units {
+		if i, ok := index[unit.item.ID]; ok {
+			result[i].Qty++
+			continue
+		}
+		index[unit.item.ID] = len(result)
+		result = append(result, models.RencanaMuatItem{PesananItemID: unit.item.ID, NamaProduk: unit.item.NamaProduk, Qty: 1})
+	}
+	return result
+}
+
+// describeRencanaMuat meringkas kendaraan rencana muat untuk pesan error
+func describeRencanaMuat(plan *models.RencanaMuat) string {
+	names := make([]string, len(plan.Kendaraan))
+	for i, k := range plan.Kendaraan {
+		names[i] = k.Nama
+	}
+	return fmt.Sprintf("%d kendaraan (%s)", len(plan.Kendaraan), strings.Join(names, ", "))
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func testVehicle(id int, nama string, kubikasi, berat, panjang, lebar, tinggi float64) models.DelivereeVehicleType {
+	return models.DelivereeVehicleType{
+		IDDeliveree: id, Nama: nama,
+		KubikasiMax: kubikasi, BeratMax: berat, ThresholdKubikasi: kubikasi, ThresholdBerat: berat,
+		CargoLength: &panjang, CargoWidth: &lebar, CargoHeight: &tinggi,
+	}
+}
+
+func testMuatItem(nama string, qty int, panjang, lebar, tinggi, berat float64) models.PesananItem {
+	return models.PesananItem{
+		ID: uuid.New(), NamaProduk: nama, Qty: qty,
+		Produk: models.Produk{Panjang: panjang, Lebar: lebar, Tinggi: tinggi, Berat: berat},
+	}
+}
+
+func TestPlanDelivereeLoad(t *testing.T) {
+	vehicles := []models.DelivereeVehicleType{
+		testVehicle(2, "CDE Box", 14, 2000, 3.1, 1.7, 1.7),
+		testVehicle(1, "Van", 3, 600, 2.0, 1.2, 1.2),
+	}
+
+	// Kubikasi kecil tapi palet 2,5 m tidak muat di Van; panjang & lebar boleh diputar
+	plan, err := planDelivereeLoad([]models.PesananItem{testMuatItem("Rak panjang", 1, 60, 250, 50, 40)}, vehicles)
+	if err != nil || plan.IsSplit() || plan.Kendaraan[0].VehicleTypeID != 2 {
+		t.Fatalf("palet panjang harus naik ke CDE Box, got %+v err=%v", plan, err)
+	}
+
+	// Palet terlalu tinggi untuk semua kendaraan ditolak
+	_, err = planDelivereeLoad([]models.PesananItem{testMuatItem("Mesin", 1, 100, 100, 200, 300)}, vehicles)
+	if !errors.Is(err, ErrMuatanMelebihiKendaraan) {
+		t.Fatalf("palet 2 m harus ditolak, got %v", err)
+	}
+
+	// 5 palet x 3,6 m3 melebihi satu CDE Box (14 m3): dibagi ke dua kendaraan
+	plan, err = planDelivereeLoad([]models.PesananItem{testMuatItem("Palet", 5, 150, 150, 160, 500)}, vehicles)
+	if err != nil || !plan.IsSplit() || len(plan.Kendaraan) != 2 {
+		t.Fatalf("5 palet harus dibagi ke 2 kendaraan, got %+v err=%v", plan, err)
+	}
+	if plan.Kendaraan[0].Items[0].Qty != 3 || plan.Kendaraan[1].Items[0].Qty != 2 {
+		t.Fatalf("pembagian palet harus 3 + 2, got %+v", plan.Kendaraan)
+	}
+
+	// Produk tanpa dimensi tidak dicek ruang kargo tapi dicatat
+	plan, err = planDelivereeLoad([]models.PesananItem{testMuatItem("Tanpa ukuran", 2, 0, 0, 0, 10)}, vehicles)
+	if err != nil || plan.Kendaraan[0].VehicleTypeID != 1 || len(plan.TanpaDimensi) != 1 {
+		t.Fatalf("produk tanpa dimensi harus pakai Van, got %+v err=%v", plan, err)
+	}
+}
+
+// fakeVehicleTypeService master kendaraan dalam memori untuk seleksi Deliveree
+type fakeVehicleTypeService struct {
+	DelivereeVehicleTypeService
+	vehicles []models.DelivereeVehicleType
+}
+
+func (s *fakeVehicleTypeService) FindActiveByIDDeliveree(_ context.Context, id int, _ string) (*models.DelivereeVehicleType, error) {
+	for i := range s.vehicles {
+		if s.vehicles[i].IDDeliveree == id {
+			return &s.vehicles[i], nil
+		}
+	}
+	return nil, errors.New("tidak ditemukan")
+}
+
+func (s *fakeVehicleTypeService) PlanLoad(_ context.Context, _ string, items []models.PesananItem) (*models.RencanaMuat, error) {
+	return planDelivereeLoad(items, s.vehicles)
+}
+
+func TestSelectVehicleRejectsSplitPlan(t *testing.T) {
+	vehicles := &fakeVehicleTypeService{vehicles: []models.DelivereeVehicleType{
+		testVehicle(2, "CDE Box", 14, 2000, 3.1, 1.7, 1.7),
+		testVehicle(1, "Van", 3, 600, 2.0, 1.2, 1.2),
+	}}
+	provider := newDelivereeProvider("https://api.sandbox.deliveree.com", "key", vehicles)
+	req := &ShipmentRequest{
+		Pesanan: &models.Pesanan{Kode: "ORD-20261017-0006"},
+		Items:   []models.PesananItem{testMuatItem("Palet", 5, 150, 150, 160, 500)},
+	}
+
+	// Muatan butuh dua CDE Box: satu booking tidak boleh memuat semuanya
+	if _, _, err := provider.selectVehicle(context.Background(), req); !errors.Is(err, ErrMuatanPerluBeberapaKendaraan) {
+		t.Fatalf("rencana muat terpecah harus ditolak, got %v", err)
+	}
+	// Quote memakai seleksi yang sama sehingga tidak dihargai sebagai satu kendaraan
+	if _, err := provider.Quote(context.Background(), req); !errors.Is(err, ErrMuatanPerluBeberapaKendaraan) {
+		t.Fatalf("quote muatan terpecah harus ditolak, got %v", err)
+	}
+
+	// Muatan satu kendaraan memakai kendaraan rencana muat
+	req.Items = []models.PesananItem{testMuatItem("Palet", 2, 150, 150, 160, 500)}
+	if vehicleTypeID, _, err := provider.selectVehicle(context.Background(), req); err != nil || vehicleTypeID != 2 {
+		t.Fatalf("dua palet harus memakai satu CDE Box, got id=%d err=%v", vehicleTypeID, err)
+	}
+
+	// Unit yang tidak muat di kendaraan mana pun tetap ditolak
+	req.Items = []models.PesananItem{testMuatItem("Mesin", 1, 100, 100, 200, 300)}
+	if _, _, err := provider.selectVehicle(context.Background(), req); !errors.Is(err, ErrMuatanMelebihiKendaraan) {
+		t.Fatalf("palet 2 m harus ditolak, got %v", err)
+	}
+}
@@ -46,6 +46,11 @@ type DelivereeVehicleTypeService interface {
 	// di atas currentIDDeliveree pada environment yang sama. Dipakai untuk retry otomatis
 	// saat booking ke kendaraan yang dipilih gagal (mis. tidak ada driver tersedia).
 	NextLargerVehicle(ctx context.Context, environment string, currentIDDeliveree int) (*models.DelivereeVehicleType, error)
+	// PlanLoad menyusun rencana muat item ke kendaraan aktif environment tertentu
+	// dengan mengecek dimensi setiap unit terhadap ruang kargo kendaraan.
+	// Mengembalikan ErrMuatanMelebihiKendaraan jika ada unit yang tidak muat di
+	// kendaraan mana pun.
+	PlanLoad(ctx context.Context, environment string, items []models.PesananItem) (*models.RencanaMuat, error)
 }
 
 type delivereeVehicleTypeService struct {
@@ -365,6 +370,20 @@ func (s *delivereeVehicleTypeService) NextLargerVehicle(ctx context.Context, env
 	return nil, fmt.Errorf("tidak ada kendaraan Deliveree (%s) yang lebih besar dari id_deliveree=%d", environment, currentIDDeliveree)
 }
 
+func (s *delivereeVehicleTypeService) PlanLoad(ctx context.Context, environment string, items []models.PesananItem) (*models.RencanaMuat, error) {
+	vehicles, err := s.repo.FindActiveByEnvironment(ctx, environment)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := planDelivereeLoad(items, vehicles)
+	if err != nil {
+		return nil, err
+	}
+	plan.Environment = environment
+	return plan, nil
+}
+
 // findExisting mencari kendaraan existing berdasarkan id_deliveree+environment.
 // Termasuk yang is_active=false agar saat Sync kendaraan yang sebelumnya
 // dinonaktifkan tetap dihitung "updated" (bukan "created") dan ID-nya dipertahankan.
@@ -3,7 +3,7 @@ package services
 import (
 	"context"
 
-	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/orderstate"
 	"project-bulky-be/internal/repositories"
 
 	"gorm.io/gorm"
@@ -17,26 +17,22 @@ import (
 // on-demand yang sama — format status identik, hanya endpoint dan kolom
 // identifikasi pesanan yang berbeda.
 type DelivereeWebhookService interface {
-	// Handle memproses payload webhook dan mengembalikan true jika ada pesanan
-	// yang cocok & diperbarui.
-	Handle(ctx context.Context, req *dto.DelivereeWebhookRequest) (bool, error)
+	// Handle memproses event hasil ShippingProvider.ParseWebhook dan
+	// mengembalikan true jika ada pesanan yang cocok & diperbarui.
+	Handle(ctx context.Context, event *ShippingWebhookEvent) (bool, error)
 }
 
 type delivereeWebhookService struct {
 	handler *ProviderWebhookHandler
 }
 
-func NewDelivereeWebhookService(pesananRepo repositories.PesananRepository, db *gorm.DB) DelivereeWebhookService {
+func NewDelivereeWebhookService(pesananRepo repositories.PesananRepository, pengirimanRepo repositories.PesananPengirimanRepository, eventRepo repositories.PesananPengirimanEventRepository, orderMachine *orderstate.Machine, db *gorm.DB) DelivereeWebhookService {
 	return &delivereeWebhookService{
-		handler: NewProviderWebhookHandler(pesananRepo, db, delivereeWebhookConfig()),
+		handler: NewProviderWebhookHandler(pesananRepo, pengirimanRepo, eventRepo, orderMachine, db, delivereeWebhookConfig()),
 	}
 }
 
-func (s *delivereeWebhookService) Handle(ctx context.Context, req *dto.DelivereeWebhookRequest) (bool, error) {
+func (s *delivereeWebhookService) Handle(ctx context.Context, event *ShippingWebhookEvent) (bool, error) {
 	// Cari pesanan berdasarkan deliveree_booking_id
-	identifier := string(req.ID)
-	if identifier == "" {
-		identifier = string(req.NoBooking)
-	}
-	return s.handler.Handle(ctx, identifier, req.Status, req.TrackingURL)
+	return s.handler.Handle(ctx, event.BookingRef, event.Status, event.TrackingURL)
 }
@@ -0,0 +1,363 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Nilai tetap file impor e-Faktur
+const (
+	// efakturKodeTransaksi 01: penyerahan kepada pihak lain selain pemungut PPN
+	efakturKodeTransaksi = "01"
+	// efakturKodeTransaksiCoretax 04: DPP nilai lain (11/12 x harga jual) untuk tarif 12%
+	efakturKodeTransaksiCoretax = "04"
+	efakturTarifPPNCoretax      = 12
+	// efakturKodeBarang kode barang/jasa umum Coretax
+	efakturKodeBarang = "000000"
+	// efakturKodeSatuan kode satuan Coretax untuk unit/buah
+	efakturKodeSatuan = "UM.0018"
+)
+
+// efakturFaktur satu faktur pajak (satu pesanan)
+type efakturFaktur struct {
+	KodePesanan string
+	NomorFaktur string
+	Tanggal     time.Time
+	NPWP        string
+	Nama        string
+	Alamat      string
+	Email       string
+	DPP         decimal.Decimal
+	PPN         decimal.Decimal
+	Baris       []efakturBaris
+}
+
+// efakturBaris satu objek faktur; nilai dalam rupiah bulat
+type efakturBaris struct {
+	Nama        string
+	Jasa        bool
+	HargaSatuan decimal.Decimal
+	Qty         int
+	HargaTotal  decimal.Decimal
+	Diskon      decimal.Decimal
+	DPP         decimal.Decimal
+	PPN         decimal.Decimal
+}
+
+// buildEFaktur memetakan pesanan ke faktur pajak. Item menjadi objek barang
+// (potongan kupon dibagi proporsional ke item sebagai diskon), ongkir & biaya
+// lain menjadi objek jasa. PPN faktur = biaya_ppn pesanan (dibulatkan ke
+// bawah), dibagi ke objek sebanding DPP agar total baris sama dengan header.
+func buildEFaktur(p *models.Pesanan, potonganKupon decimal.Decimal) efakturFaktur {
+	faktur := efakturFaktur{
+		KodePesanan: p.Kode,
+		Nama:        p.Buyer.Nama,
+		Alamat:      "-",
+	}
+	if p.PaidAt != nil {
+		faktur.Tanggal = p.PaidAt.In(jakartaLocation)
+	}
+	if p.EFakturNomor != nil {
+		faktur.NomorFaktur = *p.EFakturNomor
+	}
+	if p.Buyer.Email != nil {
+		faktur.Email = *p.Buyer.Email
+	}
+	if p.NPWPSnapshot != nil {
+		faktur.NPWP = *p.NPWPSnapshot
+		if p.NamaPerusahaanSnapshot != nil {
+			faktur.Nama = *p.NamaPerusahaanSnapshot
+		}
+		if p.AlamatPajakSnapshot != nil {
+			faktur.Alamat = *p.AlamatPajakSnapshot
+		}
+	} else if p.AlamatSnapshot != nil && strings.TrimSpace(*p.AlamatSnapshot) != "" {
+		faktur.Alamat = *p.AlamatSnapshot
+	}
+
+	var totalBarang decimal.Decimal
+	for _, item := range p.Items {
+		hargaSatuan := item.HargaSatuan.Round(0)
+		hargaTotal := hargaSatuan.Mul(decimal.NewFromInt(int64(item.Qty)))
+		dpp := item.Subtotal.Round(0)
+		faktur.Baris = append(faktur.Baris, efakturBaris{
+			Nama:        item.NamaProduk,
+			HargaSatuan: hargaSatuan,
+			Qty:         item.Qty,
+			HargaTotal:  hargaTotal,
+			Diskon:      hargaTotal.Sub(dpp),
+			DPP:         dpp,
+		})
+		totalBarang = totalBarang.Add(dpp)
+	}
+
+	// Potongan kupon dibagi sebanding subtotal item; sisa pembulatan ke item terakhir
+	potongan := decimal.Min(potonganKupon.Round(0), totalBarang)
+	if potongan.IsPositive() {
+		sisa := potongan
+		for i := range faktur.Baris {
+			bagian := sisa
+			if i < len(faktur.Baris)-1 {
+				bagian = potongan.Mul(faktur.Baris[i].DPP).Div(totalBarang).Floor()
+			}
+			faktur.Baris[i].Diskon = faktur.Baris[i].Diskon.Add(bagian)
+			faktur.Baris[i].DPP = faktur.Baris[i].DPP.Sub(bagian)
+			sisa = sisa.Sub(bagian)
+		}
+	}
+
+	for _, jasa := range []struct {
+		nama  string
+		nilai decimal.Decimal
+	}{
+		{"Biaya Pengiriman", p.BiayaPengiriman},
+		{"Biaya Lainnya", p.BiayaLainnya},
+	} {
+		nilai := jasa.nilai.Round(0)
+		if !nilai.IsPositive() {
+			continue
+		}
+		faktur.Baris = append(faktur.Baris, efakturBaris{
+			Nama: jasa.nama, Jasa: true, HargaSatuan: nilai, Qty: 1, HargaTotal: nilai, Diskon: decimal.Zero, DPP: nilai,
+		})
+	}
+
+	for _, b := range faktur.Baris {
+		faktur.DPP = faktur.DPP.Add(b.DPP)
+	}
+	faktur.PPN = p.BiayaPPN.Floor()
+
+	// PPN dibagi sebanding DPP; sisa pembulatan ke baris terakhir yang ber-DPP
+	last := -1
+	for i, b := range faktur.Baris {
+		if b.DPP.IsPositive() {
+			last = i
+		}
+	}
+	sisa := faktur.PPN
+	for i := range faktur.Baris {
+		faktur.Baris[i].PPN = decimal.Zero
+		if last < 0 || !faktur.Baris[i].DPP.IsPositive() {
+			continue
+		}
+		bagian := sisa
+		if i < last {
+			bagian = faktur.PPN.Mul(faktur.Baris[i].DPP).Div(faktur.DPP).Floor()
+		}
+		faktur.Baris[i].PPN = bagian
+		sisa = sisa.Sub(bagian)
+	}
+	return faktur
+}
+
+// renderEFakturCSV menghasilkan file impor CSV aplikasi e-Faktur (baris FK & OF)
+func renderEFakturCSV(fakturs []efakturFaktur) []byte {
+	var buf bytes.Buffer
+	csvRow(&buf, "FK", "KD_JENIS_TRANSAKSI", "FG_PENGGANTI", "NOMOR_FAKTUR", "MASA_PAJAK", "TAHUN_PAJAK", "TANGGAL_FAKTUR",
+		"NPWP", "NAMA", "ALAMAT_LENGKAP", "JUMLAH_DPP", "JUMLAH_PPN", "JUMLAH_PPNBM", "ID_KETERANGAN_TAMBAHAN",
+		"FG_UANG_MUKA", "UANG_MUKA_DPP", "UANG_MUKA_PPN", "UANG_MUKA_PPNBM", "REFERENSI", "KODE_DOKUMEN_PENDUKUNG")
+	csvRow(&buf, "LT", "NPWP", "NAMA", "JALAN", "BLOK", "NOMOR", "RT", "RW", "KECAMATAN", "KELURAHAN", "KABUPATEN",
+		"PROPINSI", "KODE_POS", "NOMOR_TELEPON")
+	csvRow(&buf, "OF", "KODE_OBJEK", "NAMA", "HARGA_SATUAN", "JUMLAH_BARANG", "HARGA_TOTAL", "DISKON", "DPP", "PPN",
+		"TARIF_PPNBM", "PPNBM")
+
+	for _, f := range fakturs {
+		csvRow(&buf, "FK", efakturKodeTransaksi, "0", nomorFakturCSV(f.NomorFaktur),
+			fmt.Sprintf("%d", int(f.Tanggal.Month())), fmt.Sprintf("%d", f.Tanggal.Year()), f.Tanggal.Format("02/01/2006"),
+			npwpCSV(f.NPWP), f.Nama, f.Alamat, f.DPP.StringFixed(0), f.PPN.StringFixed(0), "0", "",
+			"0", "0", "0", "0", f.KodePesanan, "")
+		for _, b := range f.Baris {
+			csvRow(&buf, "OF", "", b.Nama, b.HargaSatuan.StringFixed(0), fmt.Sprintf("%d", b.Qty), b.HargaTotal.StringFixed(0),
+				b.Diskon.StringFixed(0), b.DPP.StringFixed(0), b.PPN.StringFixed(0), "0", "0")
+		}
+	}
+	return buf.Bytes()
+}
+
+// csvRow menulis satu baris dengan semua kolom diberi tanda kutip seperti
+// template impor e-Faktur
+func csvRow(buf *bytes.Buffer, fields ...string) {
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		f = strings.NewReplacer("\r", " ", "\n", " ").Replace(f)
+		buf.WriteString(`"` + strings.ReplaceAll(f, `"`, `""`) + `"`)
+	}
+	buf.WriteString("\r\n")
+}
+
+// Struktur XML impor faktur keluaran Coretax
+type efakturXMLBulk struct {
+	XMLName  xml.Name         `xml:"TaxInvoiceBulk"`
+	XSI      string           `xml:"xmlns:xsi,attr"`
+	Schema   string           `xml:"xsi:noNamespaceSchemaLocation,attr"`
+	TIN      string           `xml:"TIN"`
+	Invoices []efakturXMLItem `xml:"ListOfTaxInvoice>TaxInvoice"`
+}
+
+type efakturXMLItem struct {
+	TaxInvoiceDate      string              `xml:"TaxInvoiceDate"`
+	TaxInvoiceOpt       string              `xml:"TaxInvoiceOpt"`
+	TrxCode             string              `xml:"TrxCode"`
+	AddInfo             string              `xml:"AddInfo"`
+	CustomDoc           string              `xml:"CustomDoc"`
+	RefDesc             string              `xml:"RefDesc"`
+	FacilityStamp       string              `xml:"FacilityStamp"`
+	SellerIDTKU         string              `xml:"SellerIDTKU"`
+	BuyerTin            string              `xml:"BuyerTin"`
+	BuyerDocument       string              `xml:"BuyerDocument"`
+	BuyerCountry        string              `xml:"BuyerCountry"`
+	BuyerDocumentNumber string              `xml:"BuyerDocumentNumber"`
+	BuyerName           string              `xml:"BuyerName"`
+	BuyerAdress         string              `xml:"BuyerAdress"`
+	BuyerEmail          string              `xml:"BuyerEmail"`
+	BuyerIDTKU          string              `xml:"BuyerIDTKU"`
+	GoodServices        []efakturXMLService `xml:"ListOfGoodService>GoodService"`
+}
+
+type efakturXMLService struct {
+	Opt           string `xml:"Opt"`
+	Code          string `xml:"Code"`
+	Name          string `xml:"Name"`
+	Unit          string `xml:"Unit"`
+	Price         string `xml:"Price"`
+	Qty           int    `xml:"Qty"`
+	TotalDiscount string `xml:"TotalDiscount"`
+	TaxBase       string `xml:"TaxBase"`
+	OtherTaxBase  string `xml:"OtherTaxBase"`
+	VATRate       int    `xml:"VATRate"`
+	VAT           string `xml:"VAT"`
+	STLGRate      int    `xml:"STLGRate"`
+	STLG          string `xml:"STLG"`
+}
+
+// renderEFakturXML menghasilkan file impor XML faktur keluaran Coretax.
+// npwpPenjual dipakai sebagai TIN & IDTKU (NPWP + 000000, kantor pusat).
+func renderEFakturXML(npwpPenjual string, fakturs []efakturFaktur) ([]byte, error) {
+	tinPenjual := npwp16(npwpPenjual)
+	bulk := efakturXMLBulk{
+		XSI:      "http://www.w3.org/2001/XMLSchema-instance",
+		Schema:   "TaxInvoice.xsd",
+		TIN:      tinPenjual,
+		Invoices: make([]efakturXMLItem, 0, len(fakturs)),
+	}
+	sebelas := decimal.NewFromInt(11)
+	duaBelas := decimal.NewFromInt(12)
+	for _, f := range fakturs {
+		invoice := efakturXMLItem{
+			TaxInvoiceDate: f.Tanggal.Format("2006-01-02"),
+			TaxInvoiceOpt:  "Normal",
+			TrxCode:        efakturKodeTransaksiCoretax,
+			RefDesc:        f.KodePesanan,
+			SellerIDTKU:    tinPenjual + "000000",
+			BuyerCountry:   "IDN",
+			BuyerName:      f.Nama,
+			BuyerAdress:    f.Alamat,
+			BuyerEmail:     f.Email,
+		}
+		if f.NPWP != "" {
+			invoice.BuyerTin = npwp16(f.NPWP)
+			invoice.BuyerDocument = "TIN"
+			invoice.BuyerDocumentNumber = "-"
+			invoice.BuyerIDTKU = invoice.BuyerTin + "000000"
+		} else {
+			// Pembeli tanpa NPWP: identitas lain dengan kode pesanan sebagai nomor dokumen
+			invoice.BuyerTin = strings.Repeat("0", 16)
+			invoice.BuyerDocument = "Other"
+			invoice.BuyerDocumentNumber = f.KodePesanan
+			invoice.BuyerIDTKU = strings.Repeat("0", 22)
+		}
+		for _, b := range f.Baris {
+			opt := "A"
+			if b.Jasa {
+				opt = "B"
+			}
+			invoice.GoodServices = append(invoice.GoodServices, efakturXMLService{
+				Opt:           opt,
+				Code:          efakturKodeBarang,
+				Name:          b.Nama,
+				Unit:          efakturKodeSatuan,
+				Price:         b.HargaSatuan.StringFixed(2),
+				Qty:           b.Qty,
+				TotalDiscount: b.Diskon.StringFixed(2),
+				TaxBase:       b.DPP.StringFixed(2),
+				OtherTaxBase:  b.DPP.Mul(sebelas).Div(duaBelas).StringFixed(2),
+				VATRate:       efakturTarifPPNCoretax,
+				VAT:           b.PPN.StringFixed(2),
+				STLG:          "0.00",
+			})
+		}
+		bulk.Invoices = append(bulk.Invoices, invoice)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(bulk); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// normalizeNPWP membuang tanda baca NPWP; valid jika 15 atau 16 digit
+func normalizeNPWP(value string) (string, bool) {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '.' || r == '-' || r == ' ':
+		default:
+			return "", false
+		}
+	}
+	npwp := b.String()
+	return npwp, len(npwp) == 15 || len(npwp) == 16
+}
+
+// npwpCSV - aplikasi e-Faktur desktop memakai NPWP 15 digit; NPWP 16 digit
+// badan (0 + NPWP lama) dikembalikan ke 15 digit, NIK tetap 16 digit
+func npwpCSV(npwp string) string {
+	if npwp == "" {
+		return strings.Repeat("0", 15)
+	}
+	if len(npwp) == 16 && npwp[0] == '0' {
+		return npwp[1:]
+	}
+	return npwp
+}
+
+// npwp16 - Coretax memakai NPWP 16 digit; NPWP lama 15 digit diawali 0
+func npwp16(npwp string) string {
+	if len(npwp) == 15 {
+		return "0" + npwp
+	}
+	return npwp
+}
+
+// nomorFakturCSV - kolom NOMOR_FAKTUR berisi 13 digit nomor seri tanpa kode
+// transaksi & status (010.000-26.00000001 -> 0002600000001)
+func nomorFakturCSV(nomor string) string {
+	var b strings.Builder
+	for _, r := range nomor {
+		if unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	if len(digits) > 13 {
+		return digits[len(digits)-13:]
+	}
+	return digits
+}
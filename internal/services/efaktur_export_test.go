@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func testEFakturPesanan() *models.Pesanan {
+	npwp := "012345678901000"
+	perusahaan := "PT Maju \"Jaya\""
+	alamat := "Jl. Industri 5, Bekasi"
+	paidAt := time.Date(2026, 3, 31, 20, 0, 0, 0, time.UTC) // 1 April WIB
+	return &models.Pesanan{
+		ID:                     uuid.New(),
+		Kode:                   "ORD-20260331-0001",
+		Buyer:                  models.Buyer{Nama: "Budi"},
+		PaidAt:                 &paidAt,
+		NPWPSnapshot:           &npwp,
+		NamaPerusahaanSnapshot: &perusahaan,
+		AlamatPajakSnapshot:    &alamat,
+		BiayaPengiriman:        decimal.NewFromInt(100000),
+		BiayaPPN:               decimal.RequireFromString("121000.90"),
+		Items: []models.PesananItem{
+			{NamaProduk: "Paket A", Qty: 2, HargaSatuan: decimal.NewFromInt(300000), DiskonSatuan: decimal.NewFromInt(50000), Subtotal: decimal.NewFromInt(500000)},
+			{NamaProduk: "Paket B", Qty: 1, HargaSatuan: decimal.NewFromInt(600000), Subtotal: decimal.NewFromInt(600000)},
+		},
+	}
+}
+
+func TestBuildEFaktur(t *testing.T) {
+	f := buildEFaktur(testEFakturPesanan(), decimal.NewFromInt(100000))
+
+	if f.Nama != "PT Maju \"Jaya\"" || f.NPWP != "012345678901000" || f.Tanggal.Day() != 1 {
+		t.Fatalf("identitas/tanggal faktur salah: %+v", f)
+	}
+	if len(f.Baris) != 3 || !f.Baris[2].Jasa {
+		t.Fatalf("harus 2 barang + 1 jasa ongkir: %+v", f.Baris)
+	}
+	// Kupon 100.000 dibagi 500k:600k -> 45.454 & 54.546
+	if !f.Baris[0].DPP.Equal(decimal.NewFromInt(454546)) || !f.Baris[0].Diskon.Equal(decimal.NewFromInt(145454)) {
+		t.Fatalf("diskon kupon baris 1 salah: %+v", f.Baris[0])
+	}
+	if !f.Baris[1].DPP.Equal(decimal.NewFromInt(545454)) {
+		t.Fatalf("diskon kupon baris 2 salah: %+v", f.Baris[1])
+	}
+
+	// Total baris harus sama dengan header
+	dpp, ppn := decimal.Zero, decimal.Zero
+	for _, b := range f.Baris {
+		dpp = dpp.Add(b.DPP)
+		ppn = ppn.Add(b.PPN)
+	}
+	if !dpp.Equal(f.DPP) || !f.DPP.Equal(decimal.NewFromInt(1100000)) {
+		t.Fatalf("DPP baris %s != header %s", dpp, f.DPP)
+	}
+	if !ppn.Equal(f.PPN) || !f.PPN.Equal(decimal.NewFromInt(121000)) {
+		t.Fatalf("PPN baris %s != header %s", ppn, f.PPN)
+	}
+}
+
+func TestRenderEFaktur(t *testing.T) {
+	nomor := "010.000-26.00000001"
+	p := testEFakturPesanan()
+	p.EFakturNomor = &nomor
+	f := buildEFaktur(p, decimal.Zero)
+
+	csv := string(renderEFakturCSV([]efakturFaktur{f}))
+	lines := strings.Split(strings.TrimSpace(csv), "\r\n")
+	if len(lines) != 3+1+3 {
+		t.Fatalf("harus 3 header + 1 FK + 3 OF, got %d baris", len(lines))
+	}
+	wantFK := `"FK","01","0","0002600000001","4","2026","01/04/2026","012345678901000","PT Maju ""Jaya""","Jl. Industri 5, Bekasi","1200000","121000"`
+	if !strings.HasPrefix(lines[3], wantFK) {
+		t.Fatalf("baris FK salah:\n%s\nwant prefix\n%s", lines[3], wantFK)
+	}
+
+	xmlData, err := renderEFakturXML("01.234.567.8-999.000", []efakturFaktur{f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	xml := string(xmlData)
+	for _, want := range []string{
+		"<BuyerTin>0012345678901000</BuyerTin>",
+		"<BuyerIDTKU>0012345678901000000000</BuyerIDTKU>",
+		"<TaxInvoiceDate>2026-04-01</TaxInvoiceDate>",
+		"<Opt>B</Opt>",
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("XML tidak memuat %s", want)
+		}
+	}
+}
+
+func TestNormalizeNPWP(t *testing.T) {
+	if npwp, ok := normalizeNPWP("01.234.567.8-901.000"); !ok || npwp != "012345678901000" {
+		t.Fatalf("NPWP 15 digit bertanda baca harus valid, got %q", npwp)
+	}
+	if _, ok := normalizeNPWP("3174012345678901"); !ok {
+		t.Fatalf("NPWP 16 digit harus valid")
+	}
+	for _, invalid := range []string{"12345", "01.234.567.8-901.00A", ""} {
+		if _, ok := normalizeNPWP(invalid); ok {
+			t.Errorf("%q seharusnya tidak valid", invalid)
+		}
+	}
+	if npwpCSV("0012345678901000") != "012345678901000" || npwp16("012345678901000") != "0012345678901000" {
+		t.Fatalf("konversi NPWP 15/16 digit salah")
+	}
+}
+
+func TestEFakturFilterInvalid(t *testing.T) {
+	cases := []dto.EFakturQueryParams{
+		{TanggalDari: "01-04-2026"},
+		{TanggalDari: "2026-04-02", TanggalSampai: "2026-04-01"},
+	}
+	for _, params := range cases {
+		if _, err := efakturFilter(&params); !errors.Is(err, ErrEFakturFilterInvalid) {
+			t.Errorf("%+v: tanggal salah harus ErrEFakturFilterInvalid (400), got %v", params, err)
+		}
+	}
+	if _, err := efakturFilter(&dto.EFakturQueryParams{TanggalDari: "2026-04-01", TanggalSampai: "2026-04-30"}); err != nil {
+		t.Fatalf("rentang valid ditolak: %v", err)
+	}
+}
+
+// fakeEFakturRepository menyimpan satu pesanan dan mencatat update e-Faktur
+type fakeEFakturRepository struct {
+	repositories.EFakturRepository
+	pesanan *models.Pesanan
+	updates int
+}
+
+func (r *fakeEFakturRepository) FindPesananByID(context.Context, uuid.UUID) (*models.Pesanan, error) {
+	pesanan := *r.pesanan
+	return &pesanan, nil
+}
+
+func (r *fakeEFakturRepository) UpdatePesananEFaktur(context.Context, uuid.UUID, map[string]interface{}) error {
+	r.updates++
+	return nil
+}
+
+func TestUpdateEFakturStatusRejectsCancelledOrRefunded(t *testing.T) {
+	nomor := "010.000-26.00000001"
+	req := &dto.UpdateEFakturRequest{Status: string(models.EFakturStatusNomorTerbit), NomorFaktur: &nomor}
+	cases := []struct {
+		name    string
+		order   models.OrderStatus
+		payment models.PaymentStatus
+		wantErr error
+	}{
+		{"lunas", models.OrderStatusCompleted, models.PaymentStatusPaid, nil},
+		{"dibatalkan", models.OrderStatusCancelled, models.PaymentStatusPaid, ErrEFakturTidakBerPPN},
+		{"di-refund", models.OrderStatusCompleted, models.PaymentStatusRefunded, ErrEFakturTidakBerPPN},
+	}
+	for _, tc := range cases {
+		pesanan := testEFakturPesanan()
+		pesanan.OrderStatus, pesanan.PaymentStatus = tc.order, tc.payment
+		repo := &fakeEFakturRepository{pesanan: pesanan}
+		service := &efakturService{repo: repo}
+
+		_, err := service.UpdateStatus(context.Background(), pesanan.ID.String(), req, uuid.New())
+		if !errors.Is(err, tc.wantErr) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.wantErr, err)
+		}
+		if tc.wantErr != nil && repo.updates != 0 {
+			t.Fatalf("%s: status e-Faktur tidak boleh diubah", tc.name)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/config"
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProfilPajakNotFound    = errors.New("profil pajak belum diisi")
+	ErrEFakturBuyerNotFound   = errors.New("buyer tidak ditemukan")
+	ErrNPWPTidakValid         = errors.New("NPWP harus 15 atau 16 digit angka")
+	ErrEFakturPesananNotFound = errors.New("pesanan tidak ditemukan")
+	ErrEFakturTidakBerPPN     = errors.New("pesanan tidak dikenai PPN, belum lunas, atau sudah dibatalkan/di-refund")
+	ErrEFakturStatusInvalid   = errors.New("status e-Faktur tidak valid")
+	ErrEFakturFilterInvalid   = errors.New("parameter export e-Faktur tidak valid")
+	// ErrNPWPPenjualInvalid konfigurasi INVOICE_PENJUAL_NPWP kosong/salah (export XML)
+	ErrNPWPPenjualInvalid = errors.New("NPWP penjual (INVOICE_PENJUAL_NPWP) belum dikonfigurasi atau tidak valid")
+)
+
+// maxEFakturExportHari batas rentang tanggal export (satu tahun pajak)
+const maxEFakturExportHari = 366
+
+// EFakturExport file impor e-Faktur siap unduh
+type EFakturExport struct {
+	NamaFile    string
+	ContentType string
+	Data        []byte
+	Jumlah      int
+}
+
+// EFakturService mengelola profil pajak buyer dan data faktur pajak pesanan
+// ber-PPN: daftar, export file impor DJP, dan status nomor/unggah faktur.
+type EFakturService interface {
+	GetProfilPajak(ctx context.Context, buyerID string) (*dto.ProfilPajakResponse, error)
+	UpsertProfilPajak(ctx context.Context, buyerID string, req *dto.UpsertProfilPajakRequest) (*dto.ProfilPajakResponse, error)
+	DeleteProfilPajak(ctx context.Context, buyerID string) error
+
+	GetAll(ctx context.Context, params *dto.EFakturQueryParams) ([]dto.EFakturPesananResponse, *models.PaginationMeta, error)
+	Export(ctx context.Context, params *dto.EFakturQueryParams) (*EFakturExport, error)
+	UpdateStatus(ctx context.Context, pesananID string, req *dto.UpdateEFakturRequest, adminID uuid.UUID) (*dto.EFakturPesananResponse, error)
+}
+
+type efakturService struct {
+	repo repositories.EFakturRepository
+	cfg  *config.Config
+}
+
+func NewEFakturService(repo repositories.EFakturRepository, cfg *config.Config) EFakturService {
+	return &efakturService{repo: repo, cfg: cfg}
+}
+
+func (s *efakturService) GetProfilPajak(ctx context.Context, buyerID string) (*dto.ProfilPajakResponse, error) {
+	id, err := uuid.Parse(buyerID)
+	if err != nil {
+		return nil, ErrProfilPajakNotFound
+	}
+	profil, err := s.repo.FindProfilPajak(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if profil == nil {
+		return nil, ErrProfilPajakNotFound
+	}
+	return toProfilPajakResponse(profil), nil
+}
+
+// UpsertProfilPajak hanya berlaku untuk pesanan baru; pesanan yang sudah
+// dibuat tetap memakai snapshot profil saat checkout
+func (s *efakturService) UpsertProfilPajak(ctx context.Context, buyerID string, req *dto.UpsertProfilPajakRequest) (*dto.ProfilPajakResponse, error) {
+	id, err := uuid.Parse(buyerID)
+	if err != nil {
+		return nil, ErrEFakturBuyerNotFound
+	}
+	exists, err := s.repo.BuyerExists(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrEFakturBuyerNotFound
+	}
+	npwp, ok := normalizeNPWP(req.NPWP)
+	if !ok {
+		return nil, ErrNPWPTidakValid
+	}
+
+	profil := &models.BuyerProfilPajak{
+		BuyerID:        id,
+		NPWP:           npwp,
+		NamaPerusahaan: strings.TrimSpace(req.NamaPerusahaan),
+		AlamatPajak:    strings.TrimSpace(req.AlamatPajak),
+	}
+	if err := s.repo.UpsertProfilPajak(ctx, profil); err != nil {
+		return nil, err
+	}
+	return s.GetProfilPajak(ctx, buyerID)
+}
+
+func (s *efakturService) DeleteProfilPajak(ctx context.Context, buyerID string) error {
+	id, err := uuid.Parse(buyerID)
+	if err != nil {
+		return ErrProfilPajakNotFound
+	}
+	deleted, err := s.repo.DeleteProfilPajak(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrProfilPajakNotFound
+	}
+	return nil
+}
+
+func (s *efakturService) GetAll(ctx context.Context, params *dto.EFakturQueryParams) ([]dto.EFakturPesananResponse, *models.PaginationMeta, error) {
+	params.SetDefaults()
+	filter, err := efakturFilter(params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pesanan, total, err := s.repo.FindPesanan(ctx, filter, params.Page, params.PerPage)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := make([]dto.EFakturPesananResponse, 0, len(pesanan))
+	for i := range pesanan {
+		items = append(items, toEFakturPesananResponse(&pesanan[i]))
+	}
+	meta := models.NewPaginationMeta(params.Page, params.PerPage, total)
+	return items, &meta, nil
+}
+
+// Export menyusun file impor e-Faktur semua pesanan ber-PPN yang lunas pada
+// rentang tanggal bayar. Export tidak mengubah status; finance mencatat nomor
+// faktur & status unggah lewat UpdateStatus setelah impor berhasil.
+func (s *efakturService) Export(ctx context.Context, params *dto.EFakturQueryParams) (*EFakturExport, error) {
+	format := strings.ToLower(params.Format)
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "xml" {
+		return nil, fmt.Errorf("%w: format export harus csv atau xml", ErrEFakturFilterInvalid)
+	}
+	filter, err := efakturFilter(params)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Sampai.Sub(filter.Dari) > maxEFakturExportHari*24*time.Hour {
+		return nil, fmt.Errorf("%w: rentang export maksimal %d hari", ErrEFakturFilterInvalid, maxEFakturExportHari)
+	}
+
+	pesanan, err := s.repo.FindPesananForExport(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, 0, len(pesanan))
+	for _, p := range pesanan {
+		ids = append(ids, p.ID)
+	}
+	usages, err := s.repo.FindKuponUsage(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	potongan := make(map[uuid.UUID]decimal.Decimal, len(usages))
+	for _, u := range usages {
+		potongan[u.PesananID] = potongan[u.PesananID].Add(decimal.NewFromFloat(u.NilaiPotongan))
+	}
+
+	fakturs := make([]efakturFaktur, 0, len(pesanan))
+	for i := range pesanan {
+		fakturs = append(fakturs, buildEFaktur(&pesanan[i], potongan[pesanan[i].ID]))
+	}
+
+	nama := fmt.Sprintf("efaktur-%s-%s", filter.Dari.Format("20060102"), filter.Sampai.AddDate(0, 0, -1).Format("20060102"))
+	result := &EFakturExport{Jumlah: len(fakturs)}
+	if format == "xml" {
+		npwpPenjual, ok := normalizeNPWP(s.cfg.InvoicePenjualNPWP)
+		if !ok {
+			return nil, ErrNPWPPenjualInvalid
+		}
+		if result.Data, err = renderEFakturXML(npwpPenjual, fakturs); err != nil {
+			return nil, err
+		}
+		result.NamaFile = nama + ".xml"
+		result.ContentType = "application/xml"
+		return result, nil
+	}
+	result.Data = renderEFakturCSV(fakturs)
+	result.NamaFile = nama + ".csv"
+	result.ContentType = "text/csv"
+	return result, nil
+}
+
+// UpdateStatus mencatat progres faktur pesanan: BELUM -> NOMOR_TERBIT
+// (nomor faktur wajib) -> DIUNGGAH. Kembali ke BELUM menghapus nomor, mis.
+// jika faktur dibatalkan di e-Faktur.
+func (s *efakturService) UpdateStatus(ctx context.Context, pesananID string, req *dto.UpdateEFakturRequest, adminID uuid.UUID) (*dto.EFakturPesananResponse, error) {
+	id, err := uuid.Parse(pesananID)
+	if err != nil {
+		return nil, ErrEFakturPesananNotFound
+	}
+	pesanan, err := s.repo.FindPesananByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEFakturPesananNotFound
+		}
+		return nil, err
+	}
+	if !efakturEligible(pesanan) {
+		return nil, ErrEFakturTidakBerPPN
+	}
+
+	status := models.EFakturStatus(req.Status)
+	nomor := pesanan.EFakturNomor
+	if req.NomorFaktur != nil {
+		if n := strings.TrimSpace(*req.NomorFaktur); n != "" {
+			nomor = &n
+		}
+	}
+
+	now := time.Now()
+	fields := map[string]interface{}{
+		"efaktur_status":     status,
+		"efaktur_updated_by": adminID,
+	}
+	switch status {
+	case models.EFakturStatusBelum:
+		fields["efaktur_nomor"] = nil
+		fields["efaktur_nomor_at"] = nil
+		fields["efaktur_uploaded_at"] = nil
+	case models.EFakturStatusNomorTerbit, models.EFakturStatusDiunggah:
+		if nomor == nil {
+			return nil, fmt.Errorf("%w: nomor_faktur wajib diisi", ErrEFakturStatusInvalid)
+		}
+		fields["efaktur_nomor"] = *nomor
+		if pesanan.EFakturNomor == nil || *pesanan.EFakturNomor != *nomor {
+			fields["efaktur_nomor_at"] = now
+		}
+		if status == models.EFakturStatusDiunggah {
+			if pesanan.EFakturUploadedAt == nil {
+				fields["efaktur_uploaded_at"] = now
+			}
+		} else {
+			fields["efaktur_uploaded_at"] = nil
+		}
+	default:
+		return nil, ErrEFakturStatusInvalid
+	}
+
+	if err := s.repo.UpdatePesananEFaktur(ctx, id, fields); err != nil {
+		return nil, err
+	}
+	pesanan, err = s.repo.FindPesananByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := toEFakturPesananResponse(pesanan)
+	return &resp, nil
+}
+
+// efakturEligible sama dengan filter daftar & export e-Faktur (lihat
+// EFakturRepository): ber-PPN, lunas (bukan REFUNDED) dan tidak dibatalkan
+func efakturEligible(p *models.Pesanan) bool {
+	return p.BiayaPPN.IsPositive() && p.PaidAt != nil &&
+		p.PaymentStatus == models.PaymentStatusPaid && p.OrderStatus != models.OrderStatusCancelled
+}
+
+// efakturFilter - tanggal_dari/tanggal_sampai inklusif (WIB)
+func efakturFilter(params *dto.EFakturQueryParams) (repositories.EFakturFilter, error) {
+	dari, sampai, err := parseRentangTanggal(params.TanggalDari, params.TanggalSampai, pickupToday())
+	if err != nil {
+		return repositories.EFakturFilter{}, fmt.Errorf("%w: %v", ErrEFakturFilterInvalid, err)
+	}
+	status := strings.ToUpper(params.Status)
+	switch models.EFakturStatus(status) {
+	case "", models.EFakturStatusBelum, models.EFakturStatusNomorTerbit, models.EFakturStatusDiunggah:
+	default:
+		return repositories.EFakturFilter{}, ErrEFakturStatusInvalid
+	}
+	return repositories.EFakturFilter{Dari: dari, Sampai: sampai.AddDate(0, 0, 1), Status: status}, nil
+}
+
+func toProfilPajakResponse(p *models.BuyerProfilPajak) *dto.ProfilPajakResponse {
+	return &dto.ProfilPajakResponse{
+		BuyerID:        p.BuyerID.String(),
+		NPWP:           p.NPWP,
+		NamaPerusahaan: p.NamaPerusahaan,
+		AlamatPajak:    p.AlamatPajak,
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
+
+func toEFakturPesananResponse(p *models.Pesanan) dto.EFakturPesananResponse {
+	return dto.EFakturPesananResponse{
+		PesananID:         p.ID.String(),
+		KodePesanan:       p.Kode,
+		PaidAt:            p.PaidAt,
+		NamaBuyer:         p.Buyer.Nama,
+		NPWP:              p.NPWPSnapshot,
+		NamaPerusahaan:    p.NamaPerusahaanSnapshot,
+		AlamatPajak:       p.AlamatPajakSnapshot,
+		DPP:               p.Total.Sub(p.BiayaPPN),
+		PPN:               p.BiayaPPN,
+		Total:             p.Total,
+		EFakturStatus:     string(p.EFakturStatus),
+		EFakturNomor:      p.EFakturNomor,
+		EFakturNomorAt:    p.EFakturNomorAt,
+		EFakturUploadedAt: p.EFakturUploadedAt,
+	}
+}
@@ -2,17 +2,31 @@ package services
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"net/smtp"
 	"os"
 	"strings"
+
+	"project-bulky-be/internal/models"
 )
 
 type EmailService interface {
 	SendFormulirNotification(to, subject string, data map[string]interface{}) error
+	// SendTemplate merender salah satu template HTML terdaftar lalu mengirimnya
+	SendTemplate(to, subject string, name EmailTemplate, data map[string]interface{}) error
 }
 
+// EmailTemplate adalah nama template HTML yang terdaftar di emailTemplates
+type EmailTemplate string
+
+const (
+	EmailTemplateFormulir      EmailTemplate = "formulir"
+	EmailTemplatePasswordReset EmailTemplate = "password_reset"
+)
+
 type emailService struct {
 	smtpHost     string
 	smtpPort     string
@@ -102,20 +116,67 @@ const formulirEmailTemplate = `
 </html>
 `
 
+const passwordResetEmailTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background: #f5a623; color: white; padding: 20px; text-align: center; border-radius: 5px 5px 0 0; }
+        .content { padding: 30px; background: #f9f9f9; border: 1px solid #ddd; border-top: none; border-radius: 0 0 5px 5px; }
+        .button { display: inline-block; padding: 12px 24px; background: #f5a623; color: white; text-decoration: none; border-radius: 5px; }
+        .footer { text-align: center; margin-top: 20px; color: #999; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h2 style="margin: 0;">Reset Password</h2>
+        </div>
+        <div class="content">
+            <p>Halo {{.Nama}},</p>
+            <p>Kami menerima permintaan reset password untuk akun Anda. Klik tombol di bawah untuk membuat password baru:</p>
+            <p style="text-align: center;"><a class="button" href="{{.ResetURL}}">Reset Password</a></p>
+            <p>Link ini hanya bisa dipakai sekali dan berlaku sampai {{.ExpiresAt}}.</p>
+            <p>Jika Anda tidak merasa meminta reset password, abaikan email ini. Password Anda tidak akan berubah.</p>
+        </div>
+        <div class="footer">
+            <p>Email ini dikirim otomatis oleh sistem Bulky Indonesia</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
+var emailTemplates = map[EmailTemplate]string{
+	EmailTemplateFormulir:      formulirEmailTemplate,
+	EmailTemplatePasswordReset: passwordResetEmailTemplate,
+}
+
 func (s *emailService) SendFormulirNotification(to, subject string, data map[string]interface{}) error {
+	// Convert kategori array to string
+	if kategori, ok := data["Kategori"].([]string); ok {
+		data["KategoriStr"] = strings.Join(kategori, ", ")
+	}
+
+	return s.SendTemplate(to, subject, EmailTemplateFormulir, data)
+}
+
+func (s *emailService) SendTemplate(to, subject string, name EmailTemplate, data map[string]interface{}) error {
 	// Skip if SMTP not configured
 	if s.smtpUsername == "" || s.smtpPassword == "" {
 		fmt.Println("SMTP not configured, skipping email send")
 		return nil
 	}
 
-	// Convert kategori array to string
-	if kategori, ok := data["Kategori"].([]string); ok {
-		data["KategoriStr"] = strings.Join(kategori, ", ")
+	source, ok := emailTemplates[name]
+	if !ok {
+		return fmt.Errorf("email template %q tidak ditemukan", name)
 	}
 
 	// Parse template
-	tmpl, err := template.New("email").Parse(formulirEmailTemplate)
+	tmpl, err := template.New(string(name)).Parse(source)
 	if err != nil {
 		return fmt.Errorf("failed to parse template: %v", err)
 	}
@@ -146,3 +207,23 @@ func (s *emailService) SendFormulirNotification(to, subject string, data map[str
 
 	return nil
 }
+
+// EmailOutboxPayload adalah payload pesan outbox models.OutboxEventEmail
+type EmailOutboxPayload struct {
+	To       string                 `json:"to"`
+	Subject  string                 `json:"subject"`
+	Template EmailTemplate          `json:"template"`
+	Data     map[string]interface{} `json:"data"`
+}
+
+// EmailOutboxHandler mengirim email dari pesan outbox. Jangan masukkan rahasia
+// (mis. token reset password) ke payload karena disimpan apa adanya di database.
+func EmailOutboxHandler(emailService EmailService) OutboxHandler {
+	return func(ctx context.Context, msg *models.OutboxMessage) error {
+		var payload EmailOutboxPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return emailService.SendTemplate(payload.To, payload.Subject, payload.Template, payload.Data)
+	}
+}
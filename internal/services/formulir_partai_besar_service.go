@@ -416,6 +416,24 @@ func (s *formulirPartaiBesarService) FindSubmissionByID(ctx context.Context, id
 		}
 	}
 
+	// Pesanan panel yang dibuat dari submission ini
+	pesanan, err := s.repo.FindPesananBySubmissionID(ctx, submission.ID)
+	if err != nil {
+		return nil, errors.New("gagal mengambil pesanan submission")
+	}
+	response.Pesanan = make([]models.FormulirSubmissionPesananResponse, 0, len(pesanan))
+	for _, p := range pesanan {
+		total, _ := p.Total.Float64()
+		response.Pesanan = append(response.Pesanan, models.FormulirSubmissionPesananResponse{
+			ID:            p.ID.String(),
+			Kode:          p.Kode,
+			OrderStatus:   string(p.OrderStatus),
+			PaymentStatus: string(p.PaymentStatus),
+			Total:         total,
+			CreatedAt:     p.CreatedAt,
+		})
+	}
+
 	return response, nil
 }
 
@@ -3,7 +3,7 @@ package services
 import (
 	"context"
 
-	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/orderstate"
 	"project-bulky-be/internal/repositories"
 
 	"gorm.io/gorm"
@@ -17,26 +17,22 @@ import (
 // on-demand yang sama — format status identik, hanya endpoint dan kolom
 // identifikasi pesanan yang berbeda.
 type ForwarderWebhookService interface {
-	// Handle memproses payload webhook dan mengembalikan true jika ada pesanan
-	// yang cocok & diperbarui.
-	Handle(ctx context.Context, req *dto.ForwarderWebhookRequest) (bool, error)
+	// Handle memproses event hasil ShippingProvider.ParseWebhook dan
+	// mengembalikan true jika ada pesanan yang cocok & diperbarui.
+	Handle(ctx context.Context, event *ShippingWebhookEvent) (bool, error)
 }
 
 type forwarderWebhookService struct {
 	handler *ProviderWebhookHandler
 }
 
-func NewForwarderWebhookService(pesananRepo repositories.PesananRepository, db *gorm.DB) ForwarderWebhookService {
+func NewForwarderWebhookService(pesananRepo repositories.PesananRepository, pengirimanRepo repositories.PesananPengirimanRepository, eventRepo repositories.PesananPengirimanEventRepository, orderMachine *orderstate.Machine, db *gorm.DB) ForwarderWebhookService {
 	return &forwarderWebhookService{
-		handler: NewProviderWebhookHandler(pesananRepo, db, forwarderWebhookConfig()),
+		handler: NewProviderWebhookHandler(pesananRepo, pengirimanRepo, eventRepo, orderMachine, db, forwarderWebhookConfig()),
 	}
 }
 
-func (s *forwarderWebhookService) Handle(ctx context.Context, req *dto.ForwarderWebhookRequest) (bool, error) {
+func (s *forwarderWebhookService) Handle(ctx context.Context, event *ShippingWebhookEvent) (bool, error) {
 	// Cari pesanan berdasarkan forwarder_tracking_no
-	identifier := string(req.ID)
-	if identifier == "" {
-		identifier = string(req.NoBooking)
-	}
-	return s.handler.Handle(ctx, identifier, req.Status, req.TrackingURL)
+	return s.handler.Handle(ctx, event.BookingRef, event.Status, event.TrackingURL)
 }
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// idempotencyStaleAfter adalah batas request pertama dianggap mati. Record
+// IN_PROGRESS yang lebih lama dari ini boleh diambil alih request berikutnya.
+const idempotencyStaleAfter = 15 * time.Minute
+
+// IdempotencyService menyimpan respons pertama per Idempotency-Key.
+// Implementasi middleware.IdempotencyStore.
+type IdempotencyService interface {
+	// Reserve mencatat request sebagai IN_PROGRESS. Jika key sudah dipakai,
+	// record lama dikembalikan (existing) dan request tidak boleh diproses.
+	Reserve(ctx context.Context, record *models.IdempotencyKey) (existing *models.IdempotencyKey, err error)
+	Complete(ctx context.Context, id uuid.UUID, responseStatus int, contentType string, body []byte) error
+	// Release menghapus key agar request berikutnya diproses ulang (mis. saat
+	// request pertama gagal dengan error server)
+	Release(ctx context.Context, id uuid.UUID) error
+	// StartCleanupScheduler menghapus key expired secara berkala sampai ctx dibatalkan.
+	StartCleanupScheduler(ctx context.Context, interval time.Duration)
+}
+
+type idempotencyService struct {
+	repo repositories.IdempotencyKeyRepository
+	ttl  time.Duration
+}
+
+func NewIdempotencyService(repo repositories.IdempotencyKeyRepository, ttl time.Duration) IdempotencyService {
+	return &idempotencyService{repo: repo, ttl: ttl}
+}
+
+func (s *idempotencyService) Reserve(ctx context.Context, record *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	// Dicoba dua kali: record lama bisa terhapus (Release) di antara insert
+	// yang bentrok dan pembacaan record lama
+	for i := 0; i < 2; i++ {
+		now := time.Now()
+		record.ExpiresAt = now.Add(s.ttl)
+		reserved, err := s.repo.Reserve(ctx, record, now.Add(-idempotencyStaleAfter))
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			return nil, nil
+		}
+
+		existing, err := s.repo.FindByKey(ctx, record.Scope, record.Method, record.Route, record.RequestKey)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+	return nil, errors.New("gagal menyimpan Idempotency-Key, silakan coba lagi")
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, id uuid.UUID, responseStatus int, contentType string, body []byte) error {
+	return s.repo.Complete(ctx, id, responseStatus, contentType, body)
+}
+
+func (s *idempotencyService) Release(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *idempotencyService) StartCleanupScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[idempotency] cleanup scheduler dihentikan")
+			return
+		case <-ticker.C:
+			deleted, err := s.repo.DeleteExpired(ctx, time.Now())
+			if err != nil {
+				log.Printf("[idempotency] gagal menghapus key expired: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("[idempotency] %d key expired dihapus", deleted)
+			}
+		}
+	}
+}
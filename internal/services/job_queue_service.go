@@ -0,0 +1,346 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	jobPollInterval      = 2 * time.Second
+	jobCronTick          = 30 * time.Second
+	jobDefaultTimeout    = 5 * time.Minute
+	jobDefaultAttempts   = 5
+	jobFinishedRetention = 7 * 24 * time.Hour
+)
+
+var ErrJobNotRetryable = errors.New("hanya job berstatus DEAD yang dapat dijalankan ulang")
+
+// JobHandler mengerjakan satu job. Error membuat job dijadwalkan ulang dengan
+// exponential backoff sampai max_attempts, lalu menjadi DEAD.
+type JobHandler func(ctx context.Context, job *models.BackgroundJob) error
+
+// JobHandlerOptions mengatur worker untuk satu jenis job
+type JobHandlerOptions struct {
+	// Concurrency adalah jumlah worker paralel (default 1)
+	Concurrency int
+	// Timeout adalah visibility timeout sekaligus batas durasi handler.
+	// Job RUNNING yang melewati batas ini diambil ulang worker lain.
+	Timeout time.Duration
+	// MaxAttempts dipakai untuk job yang di-enqueue (default 5)
+	MaxAttempts int
+	// OnDead dipanggil sekali saat job menjadi DEAD, termasuk saat worker mati
+	// di percobaan terakhir sehingga handler tidak sempat berjalan lagi. Dipakai
+	// untuk menandai entitas terkait gagal agar tidak tertahan "processing".
+	OnDead func(ctx context.Context, job *models.BackgroundJob, err error)
+}
+
+// EnqueueOptions adalah opsi tambahan saat enqueue job
+type EnqueueOptions struct {
+	// RunAt menunda job sampai waktu tertentu (default: sekarang)
+	RunAt time.Time
+	// UniqueKey mencegah job dobel; enqueue dengan key yang sama diabaikan
+	UniqueKey string
+}
+
+type JobQueueService interface {
+	RegisterHandler(jobType string, handler JobHandler, opts JobHandlerOptions)
+	// RegisterCron menjadwalkan jobType sesuai ekspresi cron 5 field. Slot yang
+	// terlewat saat semua instance mati tidak dijalankan susulan.
+	RegisterCron(name, expr, jobType string) error
+	// Enqueue menyimpan job. Jika tx tidak nil, job ditulis dalam transaksi
+	// tersebut sehingga ikut commit/rollback bersama perubahan data.
+	Enqueue(ctx context.Context, tx *gorm.DB, jobType string, payload interface{}, opts EnqueueOptions) error
+	// StartWorkers menjalankan worker setiap jenis job dan penjadwal cron
+	// sampai ctx dibatalkan
+	StartWorkers(ctx context.Context)
+	GetAll(ctx context.Context, params *dto.JobQueryParams) ([]models.BackgroundJob, *models.PaginationMeta, error)
+	GetSummary(ctx context.Context) ([]models.JobStatusCount, error)
+	Retry(ctx context.Context, id uuid.UUID) (*models.BackgroundJob, error)
+}
+
+type registeredJob struct {
+	handler JobHandler
+	opts    JobHandlerOptions
+}
+
+type cronJob struct {
+	name     string
+	jobType  string
+	schedule *utils.CronSchedule
+}
+
+type jobQueueService struct {
+	repo     repositories.JobRepository
+	workerID string
+	mu       sync.RWMutex
+	handlers map[string]registeredJob
+	crons    []cronJob
+}
+
+func NewJobQueueService(repo repositories.JobRepository) JobQueueService {
+	hostname, _ := os.Hostname()
+	return &jobQueueService{
+		repo:     repo,
+		workerID: fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		handlers: map[string]registeredJob{},
+	}
+}
+
+func (s *jobQueueService) RegisterHandler(jobType string, handler JobHandler, opts JobHandlerOptions) {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = jobDefaultTimeout
+	}
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = jobDefaultAttempts
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[jobType] = registeredJob{handler: handler, opts: opts}
+}
+
+func (s *jobQueueService) RegisterCron(name, expr, jobType string) error {
+	schedule, err := utils.ParseCron(expr)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.crons = append(s.crons, cronJob{name: name, jobType: jobType, schedule: schedule})
+	return nil
+}
+
+func (s *jobQueueService) Enqueue(ctx context.Context, tx *gorm.DB, jobType string, payload interface{}, opts EnqueueOptions) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	maxAttempts := jobDefaultAttempts
+	s.mu.RLock()
+	if registered, ok := s.handlers[jobType]; ok {
+		maxAttempts = registered.opts.MaxAttempts
+	}
+	s.mu.RUnlock()
+
+	job := &models.BackgroundJob{
+		JobType:     jobType,
+		Payload:     body,
+		Status:      models.JobStatusPending,
+		MaxAttempts: maxAttempts,
+		RunAt:       opts.RunAt,
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	if opts.UniqueKey != "" {
+		job.UniqueKey = &opts.UniqueKey
+	}
+
+	repo := s.repo
+	if tx != nil {
+		repo = s.repo.WithTx(tx)
+	}
+	_, err = repo.Create(ctx, job)
+	return err
+}
+
+func (s *jobQueueService) StartWorkers(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	s.mu.RLock()
+	for jobType, registered := range s.handlers {
+		for i := 0; i < registered.opts.Concurrency; i++ {
+			wg.Add(1)
+			go func(jobType string, registered registeredJob) {
+				defer wg.Done()
+				s.workLoop(ctx, jobType, registered)
+			}(jobType, registered)
+		}
+	}
+	crons := append([]cronJob(nil), s.crons...)
+	jobTypes := len(s.handlers)
+	s.mu.RUnlock()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.cronLoop(ctx, crons)
+	}()
+
+	log.Printf("[job] worker %s berjalan untuk %d jenis job, %d jadwal cron", s.workerID, jobTypes, len(crons))
+	wg.Wait()
+	log.Println("[job] worker dihentikan")
+}
+
+func (s *jobQueueService) workLoop(ctx context.Context, jobType string, registered registeredJob) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := s.repo.Claim(ctx, jobType, s.workerID, registered.opts.Timeout)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[job] gagal mengambil job %s: %v", jobType, err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(jobPollInterval):
+			}
+			continue
+		}
+
+		s.run(job, registered)
+	}
+}
+
+// run mengerjakan satu job yang sudah di-claim. Handler sengaja tidak memakai
+// ctx worker: saat shutdown job yang sedang jalan dibiarkan selesai atau, jika
+// proses keburu mati, diambil ulang setelah visibility timeout.
+func (s *jobQueueService) run(job *models.BackgroundJob, registered registeredJob) {
+	ctx := context.Background()
+
+	// Job diambil ulang setelah visibility timeout dan jatah percobaan habis
+	if job.Attempts > job.MaxAttempts {
+		s.fail(ctx, registered, job, errors.New("visibility timeout habis, worker tidak menyelesaikan job"), true)
+		return
+	}
+
+	err := runJobHandler(ctx, registered, job)
+	if err == nil {
+		marked, err := s.repo.MarkDone(ctx, job.ID, s.workerID, job.Attempts)
+		if err != nil {
+			log.Printf("[job] gagal menandai job %s selesai: %v", job.ID, err)
+		} else if !marked {
+			log.Printf("[job] job %s (%s) sudah di-claim ulang setelah visibility timeout, hasil percobaan ke-%d diabaikan", job.ID, job.JobType, job.Attempts)
+		}
+		return
+	}
+	s.fail(ctx, registered, job, err, job.IsLastAttempt())
+}
+
+func (s *jobQueueService) fail(ctx context.Context, registered registeredJob, job *models.BackgroundJob, err error, dead bool) {
+	if dead {
+		log.Printf("[job] job %s (%s) DEAD setelah %d percobaan: %v", job.ID, job.JobType, job.Attempts, err)
+	} else {
+		log.Printf("[job] job %s (%s) gagal, percobaan ke-%d: %v", job.ID, job.JobType, job.Attempts, err)
+	}
+	marked, markErr := s.repo.MarkFailed(ctx, job.ID, s.workerID, job.Attempts, time.Now().Add(retryBackoff(job.Attempts)), err.Error(), dead)
+	if markErr != nil {
+		log.Printf("[job] gagal mencatat kegagalan job %s: %v", job.ID, markErr)
+	} else if !marked {
+		log.Printf("[job] job %s (%s) sudah di-claim ulang setelah visibility timeout, kegagalan percobaan ke-%d diabaikan", job.ID, job.JobType, job.Attempts)
+	} else if dead && registered.opts.OnDead != nil {
+		registered.opts.OnDead(ctx, job, err)
+	}
+}
+
+// runJobHandler membatasi durasi handler sampai claim habis (locked_until,
+// paling lama visibility timeout) agar handler sudah dibatalkan sebelum worker
+// lain bisa meng-claim job yang sama, dan mengubah panic menjadi error
+func runJobHandler(ctx context.Context, registered registeredJob, job *models.BackgroundJob) (err error) {
+	deadline := time.Now().Add(registered.opts.Timeout)
+	if job.LockedUntil != nil && job.LockedUntil.Before(deadline) {
+		deadline = *job.LockedUntil
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return registered.handler(ctx, job)
+}
+
+// cronLoop meng-enqueue job cron saat slot jadwalnya tiba. unique_key per slot
+// mencegah job dobel jika beberapa instance API berjalan bersamaan.
+func (s *jobQueueService) cronLoop(ctx context.Context, crons []cronJob) {
+	ticker := time.NewTicker(jobCronTick)
+	defer ticker.Stop()
+
+	now := time.Now()
+	next := make([]time.Time, len(crons))
+	for i, c := range crons {
+		next[i] = c.schedule.Next(now)
+	}
+	lastCleanup := now
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+
+		for i, c := range crons {
+			if next[i].IsZero() || now.Before(next[i]) {
+				continue
+			}
+			slot := next[i]
+			next[i] = c.schedule.Next(now)
+			err := s.Enqueue(ctx, nil, c.jobType, map[string]interface{}{"cron": c.name, "slot": slot}, EnqueueOptions{
+				RunAt:     slot,
+				UniqueKey: fmt.Sprintf("cron:%s:%s", c.name, slot.UTC().Format(time.RFC3339)),
+			})
+			if err != nil {
+				log.Printf("[job] gagal menjadwalkan cron %s: %v", c.name, err)
+			}
+		}
+
+		if now.Sub(lastCleanup) >= time.Hour {
+			lastCleanup = now
+			deleted, err := s.repo.DeleteFinishedBefore(ctx, now.Add(-jobFinishedRetention))
+			if err != nil {
+				log.Printf("[job] gagal menghapus job selesai lama: %v", err)
+			} else if deleted > 0 {
+				log.Printf("[job] %d job selesai lama dihapus", deleted)
+			}
+		}
+	}
+}
+
+func (s *jobQueueService) GetAll(ctx context.Context, params *dto.JobQueryParams) ([]models.BackgroundJob, *models.PaginationMeta, error) {
+	jobs, total, err := s.repo.FindAll(ctx, params.Status, params.JobType, params.Page, params.PerPage)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta := models.NewPaginationMeta(params.Page, params.PerPage, total)
+	return jobs, &meta, nil
+}
+
+func (s *jobQueueService) GetSummary(ctx context.Context) ([]models.JobStatusCount, error) {
+	return s.repo.CountByStatus(ctx)
+}
+
+func (s *jobQueueService) Retry(ctx context.Context, id uuid.UUID) (*models.BackgroundJob, error) {
+	retried, err := s.repo.Retry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !retried {
+		if _, err := s.repo.FindByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrJobNotRetryable
+	}
+	return s.repo.FindByID(ctx, id)
+}
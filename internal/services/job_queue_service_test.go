@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+	"project-bulky-be/internal/testutil/fakedb"
+
+	"github.com/google/uuid"
+)
+
+func TestRunMarksOnlyOwnClaim(t *testing.T) {
+	for _, handlerErr := range []error{nil, errors.New("provider timeout")} {
+		var marks []fakedb.Query
+		db, _ := fakedb.Open(t, func(q fakedb.Query) (*fakedb.Result, error) {
+			if q.Is("UPDATE", "background_job") {
+				marks = append(marks, q)
+				// Job sudah di-claim ulang worker lain setelah visibility timeout
+				return &fakedb.Result{RowsAffected: 0}, nil
+			}
+			return nil, nil
+		})
+		s := &jobQueueService{repo: repositories.NewJobRepository(db), workerID: "api-1:42"}
+		job := &models.BackgroundJob{ID: uuid.New(), JobType: "test.job", Attempts: 2, MaxAttempts: 5}
+		registered := registeredJob{
+			handler: func(context.Context, *models.BackgroundJob) error { return handlerErr },
+			opts:    JobHandlerOptions{Timeout: time.Second},
+		}
+
+		s.run(job, registered)
+		if len(marks) != 1 {
+			t.Fatalf("harus ada satu UPDATE hasil job, got %d", len(marks))
+		}
+		q := marks[0]
+		if !strings.Contains(q.SQL, "locked_by =") || !strings.Contains(q.SQL, "attempts =") {
+			t.Fatalf("hasil job harus dibatasi claim pemanggil, got %s", q.SQL)
+		}
+		var lockedBy, attempts bool
+		for _, arg := range q.Args {
+			lockedBy = lockedBy || arg == "api-1:42"
+			attempts = attempts || arg == int64(2)
+		}
+		if !lockedBy || !attempts {
+			t.Fatalf("predikat claim harus memakai worker & attempts job, got %v", q.Args)
+		}
+	}
+}
+
+func TestRunCallsOnDeadWhenClaimExpiredOnLastAttempt(t *testing.T) {
+	for _, affected := range []int64{1, 0} {
+		db, _ := fakedb.Open(t, func(q fakedb.Query) (*fakedb.Result, error) {
+			if q.Is("UPDATE", "background_job") {
+				return &fakedb.Result{RowsAffected: affected}, nil
+			}
+			return nil, nil
+		})
+		s := &jobQueueService{repo: repositories.NewJobRepository(db), workerID: "api-1:42"}
+		// Worker percobaan terakhir mati: job di-claim lagi melebihi max_attempts
+		job := &models.BackgroundJob{ID: uuid.New(), JobType: "video.transcode", Attempts: 4, MaxAttempts: 3}
+		ran, dead := false, 0
+		registered := registeredJob{
+			handler: func(context.Context, *models.BackgroundJob) error { ran = true; return nil },
+			opts: JobHandlerOptions{Timeout: time.Second, OnDead: func(context.Context, *models.BackgroundJob, error) {
+				dead++
+			}},
+		}
+
+		s.run(job, registered)
+		if ran {
+			t.Fatal("handler tidak boleh dijalankan setelah percobaan habis")
+		}
+		// OnDead hanya dipanggil pemegang claim yang berhasil menandai DEAD
+		if want := int(affected); dead != want {
+			t.Fatalf("rows=%d: OnDead harus dipanggil %d kali, got %d", affected, want, dead)
+		}
+	}
+}
+
+func TestRunJobHandlerStopsBeforeClaimExpires(t *testing.T) {
+	lockedUntil := time.Now().Add(50 * time.Millisecond)
+	job := &models.BackgroundJob{ID: uuid.New(), LockedUntil: &lockedUntil}
+	registered := registeredJob{
+		handler: func(ctx context.Context, _ *models.BackgroundJob) error {
+			deadline, ok := ctx.Deadline()
+			if !ok || deadline.After(lockedUntil) {
+				t.Errorf("deadline handler harus <= locked_until, got %v", deadline)
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		opts: JobHandlerOptions{Timeout: time.Minute},
+	}
+	if err := runJobHandler(context.Background(), registered, job); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("handler harus dibatalkan saat claim habis, got %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// LoginLockedError dikembalikan saat login ditolak karena backoff/lockout.
+// Controller memetakannya ke HTTP 423 dengan header Retry-After.
+type LoginLockedError struct {
+	RetryAt time.Time
+	Locked  bool // true = lockout penuh, false = backoff sementara
+}
+
+func (e *LoginLockedError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("akun dikunci sementara karena terlalu banyak percobaan login gagal. Coba lagi dalam %d detik", e.RetryAfterSeconds())
+	}
+	return fmt.Sprintf("terlalu banyak percobaan login gagal. Coba lagi dalam %d detik", e.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds dibulatkan ke atas agar client tidak mencoba terlalu cepat
+func (e *LoginLockedError) RetryAfterSeconds() int {
+	seconds := int(math.Ceil(time.Until(e.RetryAt).Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// loginThrottlePolicy: freeAttempts kegagalan pertama tanpa jeda, setelah itu
+// jeda naik 2x setiap kegagalan (baseDelay..maxDelay), dan pada kegagalan ke
+// lockAfter akun/IP dikunci selama lockDuration.
+type loginThrottlePolicy struct {
+	freeAttempts int
+	lockAfter    int
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+	lockDuration time.Duration
+}
+
+var (
+	accountThrottlePolicy = loginThrottlePolicy{freeAttempts: 3, lockAfter: 10, baseDelay: 2 * time.Second, maxDelay: 5 * time.Minute, lockDuration: 30 * time.Minute}
+	// IP lebih longgar karena satu IP kantor/NAT bisa dipakai banyak user
+	ipThrottlePolicy = loginThrottlePolicy{freeAttempts: 10, lockAfter: 50, baseDelay: time.Second, maxDelay: 5 * time.Minute, lockDuration: time.Hour}
+)
+
+// loginFailureWindow: counter dimulai ulang jika tidak ada kegagalan selama ini
+const loginFailureWindow = time.Hour
+
+// blockFor mengembalikan lama blokir setelah kegagalan ke-failures, dan true
+// jika blokir tersebut adalah lockout penuh.
+func (p loginThrottlePolicy) blockFor(failures int) (time.Duration, bool) {
+	if failures >= p.lockAfter {
+		return p.lockDuration, true
+	}
+	if failures <= p.freeAttempts {
+		return 0, false
+	}
+
+	delay := p.baseDelay
+	for i := p.freeAttempts + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= p.maxDelay {
+			return p.maxDelay, false
+		}
+	}
+	return delay, false
+}
+
+// LoginFailureResult memberi tahu pemanggil apakah kegagalan ini memicu lockout,
+// agar event lock bisa dicatat di activity_log.
+type LoginFailureResult struct {
+	AccountLocked bool
+	IPLocked      bool
+}
+
+// LoginThrottleService membatasi percobaan login gagal per akun dan per IP.
+type LoginThrottleService interface {
+	// Check mengembalikan *LoginLockedError jika akun atau IP sedang diblokir.
+	Check(ctx context.Context, userType models.UserType, email, ipAddress string) error
+	RecordFailure(ctx context.Context, userType models.UserType, email, ipAddress string) (*LoginFailureResult, error)
+	// RecordSuccess me-reset counter akun (counter IP tetap, agar satu akun valid
+	// tidak bisa dipakai untuk me-reset blokir IP).
+	RecordSuccess(ctx context.Context, userType models.UserType, email string) error
+	// Unlock membuka blokir akun lebih awal. Mengembalikan true jika akun memang sedang diblokir.
+	Unlock(ctx context.Context, userType models.UserType, email string) (bool, error)
+	LockedUntil(ctx context.Context, userType models.UserType, email string) (*time.Time, error)
+	StartCleanupScheduler(ctx context.Context, interval time.Duration)
+}
+
+type loginThrottleService struct {
+	repo repositories.LoginThrottleRepository
+}
+
+func NewLoginThrottleService(repo repositories.LoginThrottleRepository) LoginThrottleService {
+	return &loginThrottleService{repo: repo}
+}
+
+func normalizeLoginIdentifier(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (s *loginThrottleService) Check(ctx context.Context, userType models.UserType, email, ipAddress string) error {
+	now := time.Now()
+	var blocked *LoginLockedError
+
+	for scope, identifier := range map[string]string{
+		models.LoginThrottleScopeAccount: normalizeLoginIdentifier(email),
+		models.LoginThrottleScopeIP:      ipAddress,
+	} {
+		if identifier == "" {
+			continue
+		}
+		throttle, err := s.repo.Find(ctx, scope, userType, identifier)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return err
+		}
+		if throttle.BlockedUntil == nil || !now.Before(*throttle.BlockedUntil) {
+			continue
+		}
+		// Ambil blokir yang paling lama
+		if blocked == nil || throttle.BlockedUntil.After(blocked.RetryAt) {
+			blocked = &LoginLockedError{RetryAt: *throttle.BlockedUntil, Locked: throttle.LockedAt != nil}
+		}
+	}
+
+	if blocked != nil {
+		return blocked
+	}
+	return nil
+}
+
+func (s *loginThrottleService) RecordFailure(ctx context.Context, userType models.UserType, email, ipAddress string) (*LoginFailureResult, error) {
+	result := &LoginFailureResult{}
+
+	accountLocked, err := s.recordFailure(ctx, models.LoginThrottleScopeAccount, userType, normalizeLoginIdentifier(email), accountThrottlePolicy)
+	if err != nil {
+		return nil, err
+	}
+	result.AccountLocked = accountLocked
+
+	if ipAddress != "" {
+		ipLocked, err := s.recordFailure(ctx, models.LoginThrottleScopeIP, userType, ipAddress, ipThrottlePolicy)
+		if err != nil {
+			return nil, err
+		}
+		result.IPLocked = ipLocked
+	}
+
+	return result, nil
+}
+
+func (s *loginThrottleService) recordFailure(ctx context.Context, scope string, userType models.UserType, identifier string, policy loginThrottlePolicy) (bool, error) {
+	now := time.Now()
+	throttle, err := s.repo.IncrementFailure(ctx, scope, userType, identifier, now, now.Add(-loginFailureWindow))
+	if err != nil {
+		return false, err
+	}
+
+	delay, locked := policy.blockFor(throttle.FailedCount)
+	if delay == 0 {
+		return false, nil
+	}
+
+	var lockedAt *time.Time
+	if locked {
+		lockedAt = &now
+	}
+	if err := s.repo.SetBlocked(ctx, throttle.ID, now.Add(delay), lockedAt); err != nil {
+		return false, err
+	}
+	// Event lock hanya dilaporkan sekali, saat batas lockout tercapai
+	return locked && throttle.FailedCount == policy.lockAfter, nil
+}
+
+func (s *loginThrottleService) RecordSuccess(ctx context.Context, userType models.UserType, email string) error {
+	return s.repo.Delete(ctx, models.LoginThrottleScopeAccount, userType, normalizeLoginIdentifier(email))
+}
+
+func (s *loginThrottleService) Unlock(ctx context.Context, userType models.UserType, email string) (bool, error) {
+	lockedUntil, err := s.LockedUntil(ctx, userType, email)
+	if err != nil {
+		return false, err
+	}
+	if err := s.repo.Delete(ctx, models.LoginThrottleScopeAccount, userType, normalizeLoginIdentifier(email)); err != nil {
+		return false, err
+	}
+	return lockedUntil != nil, nil
+}
+
+func (s *loginThrottleService) LockedUntil(ctx context.Context, userType models.UserType, email string) (*time.Time, error) {
+	throttle, err := s.repo.Find(ctx, models.LoginThrottleScopeAccount, userType, normalizeLoginIdentifier(email))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if throttle.BlockedUntil == nil || !time.Now().Before(*throttle.BlockedUntil) {
+		return nil, nil
+	}
+	return throttle.BlockedUntil, nil
+}
+
+func (s *loginThrottleService) StartCleanupScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[login-throttle] cleanup scheduler dihentikan")
+			return
+		case <-ticker.C:
+			deleted, err := s.repo.DeleteStale(ctx, time.Now().Add(-loginFailureWindow))
+			if err != nil {
+				log.Printf("[login-throttle] gagal menghapus counter lama: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("[login-throttle] %d counter login lama dihapus", deleted)
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginThrottlePolicyBlockFor(t *testing.T) {
+	policy := loginThrottlePolicy{freeAttempts: 3, lockAfter: 10, baseDelay: 2 * time.Second, maxDelay: 30 * time.Second, lockDuration: 30 * time.Minute}
+
+	cases := []struct {
+		failures int
+		delay    time.Duration
+		locked   bool
+	}{
+		{1, 0, false},
+		{3, 0, false},
+		{4, 2 * time.Second, false},
+		{5, 4 * time.Second, false},
+		{7, 16 * time.Second, false},
+		{8, 30 * time.Second, false}, // dibatasi maxDelay
+		{9, 30 * time.Second, false},
+		{10, 30 * time.Minute, true},
+		{15, 30 * time.Minute, true},
+	}
+	for _, tc := range cases {
+		delay, locked := policy.blockFor(tc.failures)
+		if delay != tc.delay || locked != tc.locked {
+			t.Errorf("kegagalan ke-%d: expected (%v, %v), got (%v, %v)", tc.failures, tc.delay, tc.locked, delay, locked)
+		}
+	}
+}
@@ -189,6 +189,7 @@ func (s *metodePembayaranService) GetAllGrouped(ctx context.Context, isAdmin boo
 				Nama:      method.Nama,
 				Kode:      method.Kode,
 				LogoValue: method.LogoValue,
+				IsManual:  method.IsManual,
 			}
 
 			// Include admin-only fields
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// barisMutasi satu baris kredit dari CSV mutasi rekening
+type barisMutasi struct {
+	Baris      int
+	Tanggal    time.Time
+	Keterangan string
+	Jumlah     decimal.Decimal
+}
+
+// Alias header kolom dari berbagai format ekspor internet banking. Urutan
+// berarti prioritas: kolom kredit lebih spesifik daripada "mutasi"/"jumlah".
+var (
+	headerTanggalMutasi    = []string{"tanggal", "tgl", "date", "tanggaltransaksi", "transactiondate"}
+	headerKeteranganMutasi = []string{"keterangan", "deskripsi", "description", "berita", "remark", "remarks", "uraian"}
+	headerJumlahMutasi     = []string{"kredit", "credit", "jumlah", "nominal", "amount", "mutasi"}
+	headerTipeMutasi       = []string{"dbcr", "tipe", "jenis", "type"}
+
+	formatTanggalMutasi = []string{"02/01/2006", "2/1/2006", "2006-01-02", "02-01-2006", "02 Jan 2006"}
+
+	// Kode pesanan ORD-YYYYMMDD-NNNN; bank sering membuang tanda hubung dari berita transfer
+	kodePesananMutasiRegex = regexp.MustCompile(`(?i)ORD[\s-]?(\d{8})[\s-]?(\d{4})(?:\D|$)`)
+)
+
+var errNominalMutasi = errors.New("nominal tidak valid")
+
+// parseMutasiCSV membaca baris kredit dari CSV mutasi rekening. Baris
+// sebelum header (info rekening) diabaikan; baris debit, saldo atau yang
+// tidak bisa dibaca dihitung sebagai dilewati.
+func parseMutasiCSV(data []byte) ([]barisMutasi, int, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = deteksiPemisahCSV(data)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	var (
+		rows     []barisMutasi
+		dilewati int
+		kolom    map[string]int
+		nomor    int
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrMutasiCSVInvalid, err)
+		}
+		nomor++
+		if kosongSemua(record) {
+			continue
+		}
+		if kolom == nil {
+			kolom = petakanHeaderMutasi(record)
+			continue
+		}
+
+		row, ok := bacaBarisMutasi(record, kolom)
+		if !ok {
+			dilewati++
+			continue
+		}
+		row.Baris = nomor
+		rows = append(rows, row)
+	}
+
+	if kolom == nil {
+		return nil, 0, fmt.Errorf("%w: header tanggal, keterangan dan jumlah/kredit tidak ditemukan", ErrMutasiCSVInvalid)
+	}
+	return rows, dilewati, nil
+}
+
+func deteksiPemisahCSV(data []byte) rune {
+	sample := data
+	if len(sample) > 4096 {
+		sample = sample[:4096]
+	}
+	if bytes.Count(sample, []byte(";")) > bytes.Count(sample, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+// petakanHeaderMutasi mengembalikan indeks kolom jika record adalah header,
+// atau nil jika bukan
+func petakanHeaderMutasi(record []string) map[string]int {
+	normal := make([]string, len(record))
+	for i, cell := range record {
+		normal[i] = normalisasiHeader(cell)
+	}
+	cari := func(alias []string) int {
+		for _, a := range alias {
+			for i, h := range normal {
+				if h == a {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+
+	kolom := map[string]int{
+		"tanggal":    cari(headerTanggalMutasi),
+		"keterangan": cari(headerKeteranganMutasi),
+		"jumlah":     cari(headerJumlahMutasi),
+		"tipe":       cari(headerTipeMutasi),
+	}
+	if kolom["tanggal"] < 0 || kolom["keterangan"] < 0 || kolom["jumlah"] < 0 {
+		return nil
+	}
+	return kolom
+}
+
+func bacaBarisMutasi(record []string, kolom map[string]int) (barisMutasi, bool) {
+	cell := func(nama string) string {
+		i := kolom[nama]
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	if tipe := strings.ToUpper(cell("tipe")); tipe == "DB" || tipe == "D" || tipe == "DEBIT" || tipe == "DEBET" {
+		return barisMutasi{}, false
+	}
+
+	// Format BCA: "1,500,000.00 CR" / "250,000.00 DB"
+	nominal := strings.ToUpper(cell("jumlah"))
+	if strings.HasSuffix(nominal, "DB") {
+		return barisMutasi{}, false
+	}
+	nominal = strings.TrimSpace(strings.TrimSuffix(nominal, "CR"))
+
+	jumlah, err := parseNominalMutasi(nominal)
+	if err != nil || !jumlah.IsPositive() {
+		return barisMutasi{}, false
+	}
+	tanggal, err := parseTanggalMutasi(cell("tanggal"))
+	if err != nil {
+		return barisMutasi{}, false
+	}
+	keterangan := strings.Join(strings.Fields(cell("keterangan")), " ")
+	if keterangan == "" {
+		return barisMutasi{}, false
+	}
+	return barisMutasi{Tanggal: tanggal, Keterangan: keterangan, Jumlah: jumlah}, true
+}
+
+// parseNominalMutasi menerima format Indonesia (1.500.000,50) maupun
+// internasional (1,500,000.50), dengan atau tanpa prefix Rp/IDR
+func parseNominalMutasi(s string) (decimal.Decimal, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "RP")
+	s = strings.TrimPrefix(s, "IDR")
+	s = strings.ReplaceAll(s, " ", "")
+	if s == "" {
+		return decimal.Zero, errNominalMutasi
+	}
+
+	titik, koma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	switch {
+	case titik >= 0 && koma >= 0:
+		// Pemisah yang muncul terakhir adalah pemisah desimal
+		if koma > titik {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case koma >= 0:
+		s = normalisasiPemisahTunggal(s, ",")
+	case titik >= 0:
+		s = normalisasiPemisahTunggal(s, ".")
+	}
+
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Zero, errNominalMutasi
+	}
+	return d, nil
+}
+
+// normalisasiPemisahTunggal: satu pemisah diikuti 1-2 digit dianggap desimal,
+// selain itu pemisah ribuan
+func normalisasiPemisahTunggal(s, sep string) string {
+	if strings.Count(s, sep) == 1 && len(s)-strings.Index(s, sep)-1 <= 2 {
+		return strings.Replace(s, sep, ".", 1)
+	}
+	return strings.ReplaceAll(s, sep, "")
+}
+
+func parseTanggalMutasi(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range formatTanggalMutasi {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("format tanggal tidak dikenali: %q", s)
+}
+
+// ekstrakKodePesanan mengambil kode pesanan dari berita transfer dalam bentuk
+// baku ORD-YYYYMMDD-NNNN, atau string kosong jika tidak ada
+func ekstrakKodePesanan(keterangan string) string {
+	m := kodePesananMutasiRegex.FindStringSubmatch(keterangan)
+	if m == nil {
+		return ""
+	}
+	return "ORD-" + m[1] + "-" + m[2]
+}
+
+// sidikMutasi identitas baris mutasi untuk mencegah impor ganda. ke adalah
+// urutan kemunculan baris identik dalam satu file, agar dua transfer sama
+// persis di hari yang sama tetap tercatat dua kali.
+func sidikMutasi(row barisMutasi, ke int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%d",
+		row.Tanggal.Format("2006-01-02"), strings.ToUpper(row.Keterangan), row.Jumlah.StringFixed(2), ke)))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalisasiHeader(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	var b strings.Builder
+	for _, r := range s {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func kosongSemua(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseMutasiCSV(t *testing.T) {
+	// Format ekspor BCA: info rekening di atas header, nominal dengan akhiran CR/DB
+	data := []byte("\xef\xbb\xbfNo. rekening : 1234567890\n" +
+		"Tanggal,Keterangan,Cabang,Jumlah,Saldo\n" +
+		"17/10/2026,TRSF E-BANKING CR ORD202610150007 PT MAJU,0000,\"3,063,598.50 CR\",\"10,000,000.00\"\n" +
+		"17/10/2026,BIAYA ADM,0000,\"10,000.00 DB\",\"9,990,000.00\"\n" +
+		"PEND,TRSF BELUM EFEKTIF,0000,\"50,000.00 CR\",\n" +
+		"\n")
+	rows, dilewati, err := parseMutasiCSV(data)
+	if err != nil {
+		t.Fatalf("CSV valid harus terbaca: %v", err)
+	}
+	if len(rows) != 1 || dilewati != 2 {
+		t.Fatalf("harus 1 baris kredit & 2 dilewati, got %d & %d", len(rows), dilewati)
+	}
+	if !rows[0].Jumlah.Equal(decimal.RequireFromString("3063598.50")) || rows[0].Tanggal.Format("2006-01-02") != "2026-10-17" {
+		t.Fatalf("baris kredit salah: %+v", rows[0])
+	}
+
+	// Pemisah titik koma, kolom kredit terpisah, format nominal Indonesia
+	data = []byte("Tgl;Uraian;Debet;Kredit\n2026-10-16;TRANSFER DARI BUDI;;1.500.000,00\n2026-10-16;TARIK TUNAI;200.000;\n")
+	rows, dilewati, err = parseMutasiCSV(data)
+	if err != nil || len(rows) != 1 || dilewati != 1 || !rows[0].Jumlah.Equal(decimal.NewFromInt(1500000)) {
+		t.Fatalf("CSV titik koma salah: %+v %d %v", rows, dilewati, err)
+	}
+
+	_, _, err = parseMutasiCSV([]byte("a,b,c\n1,2,3\n"))
+	if !errors.Is(err, ErrMutasiCSVInvalid) {
+		t.Fatalf("CSV tanpa header dikenal harus ditolak, got %v", err)
+	}
+}
+
+func TestParseNominalMutasi(t *testing.T) {
+	cases := map[string]string{
+		"Rp 1.500.000": "1500000",
+		"1.500.000,50": "1500000.5",
+		"1,500,000.50": "1500000.5",
+		"IDR 250,000":  "250000",
+		"750000.5":     "750000.5",
+		"1.250":        "1250",
+		"99,99":        "99.99",
+	}
+	for input, want := range cases {
+		got, err := parseNominalMutasi(input)
+		if err != nil || !got.Equal(decimal.RequireFromString(want)) {
+			t.Errorf("parseNominalMutasi(%q) = %s, %v; want %s", input, got, err, want)
+		}
+	}
+}
+
+func TestEkstrakKodePesanan(t *testing.T) {
+	cases := map[string]string{
+		"TRSF E-BANKING CR ORD-20261015-0007 PT MAJU": "ORD-20261015-0007",
+		"TRSF ord202610150007 maju":                   "ORD-20261015-0007",
+		"ORD 20261015 0012":                           "ORD-20261015-0012",
+		"TRANSFER DARI BUDI":                          "",
+		// Nomor terlalu panjang bukan kode pesanan
+		"ORD-20261015-00071": "",
+	}
+	for input, want := range cases {
+		if got := ekstrakKodePesanan(input); got != want {
+			t.Errorf("ekstrakKodePesanan(%q) = %q; want %q", input, got, want)
+		}
+	}
+}
+
+func TestStatusPembayaranManual(t *testing.T) {
+	total := decimal.NewFromInt(1000000)
+
+	status, err := statusPembayaranManual(total, decimal.Zero, decimal.NewFromInt(400000))
+	if err != nil || status != "PARTIAL" {
+		t.Fatalf("pembayaran sebagian harus PARTIAL, got %s %v", status, err)
+	}
+	status, err = statusPembayaranManual(total, decimal.NewFromInt(400000), decimal.NewFromInt(600000))
+	if err != nil || status != "PAID" {
+		t.Fatalf("pelunasan sisa tagihan harus PAID, got %s %v", status, err)
+	}
+	_, err = statusPembayaranManual(total, decimal.NewFromInt(400000), decimal.NewFromInt(700000))
+	if !errors.Is(err, ErrBuktiTransferInvalid) {
+		t.Fatalf("jumlah melebihi sisa tagihan harus ditolak, got %v", err)
+	}
+}
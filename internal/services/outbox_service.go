@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	outboxBatchSize = 20
+	// outboxLease juga menjadi batas waktu satu handler; pesan yang handler-nya
+	// macet bisa diambil ulang setelah lease habis.
+	outboxLease         = 2 * time.Minute
+	outboxSentRetention = 7 * 24 * time.Hour
+
+	// Backoff percobaan ulang outbox & job queue
+	retryBaseBackoff = 30 * time.Second
+	retryMaxBackoff  = time.Hour
+)
+
+var ErrOutboxNotReplayable = errors.New("hanya pesan outbox berstatus DEAD yang dapat di-replay")
+
+// OutboxHandler mengirim satu pesan outbox. Error membuat pesan dijadwalkan
+// ulang dengan exponential backoff sampai max_attempts, lalu menjadi DEAD.
+type OutboxHandler func(ctx context.Context, msg *models.OutboxMessage) error
+
+type OutboxService interface {
+	// RegisterHandler mendaftarkan handler untuk satu event type
+	RegisterHandler(eventType string, handler OutboxHandler)
+	// Enqueue menulis pesan outbox. Jika tx tidak nil, pesan ditulis dalam
+	// transaksi tersebut sehingga ikut commit/rollback bersama perubahan data.
+	Enqueue(ctx context.Context, tx *gorm.DB, eventType, aggregateType string, aggregateID *uuid.UUID, payload interface{}) error
+	// DispatchDue mengirim satu batch pesan yang jatuh tempo, mengembalikan jumlah yang terkirim
+	DispatchDue(ctx context.Context) int
+	// StartDispatcher menjalankan DispatchDue berkala sampai ctx dibatalkan
+	StartDispatcher(ctx context.Context, interval time.Duration)
+	GetAll(ctx context.Context, params *dto.OutboxQueryParams) ([]models.OutboxMessage, *models.PaginationMeta, error)
+	Replay(ctx context.Context, id uuid.UUID) (*models.OutboxMessage, error)
+}
+
+type outboxService struct {
+	repo     repositories.OutboxRepository
+	mu       sync.RWMutex
+	handlers map[string]OutboxHandler
+}
+
+func NewOutboxService(repo repositories.OutboxRepository) OutboxService {
+	return &outboxService{repo: repo, handlers: map[string]OutboxHandler{}}
+}
+
+func (s *outboxService) RegisterHandler(eventType string, handler OutboxHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[eventType] = handler
+}
+
+func (s *outboxService) Enqueue(ctx context.Context, tx *gorm.DB, eventType, aggregateType string, aggregateID *uuid.UUID, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	repo := s.repo
+	if tx != nil {
+		repo = s.repo.WithTx(tx)
+	}
+	return repo.Create(ctx, &models.OutboxMessage{
+		EventType:     eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       body,
+		Status:        models.OutboxStatusPending,
+		MaxAttempts:   8,
+		NextAttemptAt: time.Now(),
+	})
+}
+
+func (s *outboxService) DispatchDue(ctx context.Context) int {
+	messages, err := s.repo.ClaimDue(ctx, outboxBatchSize, outboxLease)
+	if err != nil {
+		log.Printf("[outbox] gagal mengambil pesan: %v", err)
+		return 0
+	}
+
+	sent := 0
+	for i := range messages {
+		if s.dispatch(ctx, &messages[i]) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// dispatch menjalankan handler satu pesan dan mencatat hasilnya
+func (s *outboxService) dispatch(ctx context.Context, msg *models.OutboxMessage) bool {
+	s.mu.RLock()
+	handler, ok := s.handlers[msg.EventType]
+	s.mu.RUnlock()
+
+	var err error
+	if !ok {
+		err = fmt.Errorf("handler untuk event %s tidak terdaftar", msg.EventType)
+	} else {
+		err = runOutboxHandler(ctx, handler, msg)
+	}
+
+	if err == nil {
+		if err := s.repo.MarkSent(ctx, msg.ID); err != nil {
+			log.Printf("[outbox] gagal menandai pesan %s terkirim: %v", msg.ID, err)
+		}
+		return true
+	}
+
+	attempts := msg.Attempts + 1
+	dead := !ok || attempts >= msg.MaxAttempts
+	if dead {
+		log.Printf("[outbox] pesan %s (%s) DEAD setelah %d percobaan: %v", msg.ID, msg.EventType, attempts, err)
+	} else {
+		log.Printf("[outbox] pesan %s (%s) gagal, percobaan ke-%d: %v", msg.ID, msg.EventType, attempts, err)
+	}
+	if markErr := s.repo.MarkFailed(ctx, msg.ID, attempts, time.Now().Add(retryBackoff(attempts)), err.Error(), dead); markErr != nil {
+		log.Printf("[outbox] gagal mencatat kegagalan pesan %s: %v", msg.ID, markErr)
+	}
+	return false
+}
+
+// runOutboxHandler membatasi durasi handler sebesar lease dan mengubah panic menjadi error
+func runOutboxHandler(ctx context.Context, handler OutboxHandler, msg *models.OutboxMessage) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, outboxLease)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, msg)
+}
+
+// retryBackoff: 30 detik, 1 menit, 2 menit, ... maksimal 1 jam
+func retryBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := retryBaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMaxBackoff {
+			return retryMaxBackoff
+		}
+	}
+	return delay
+}
+
+func (s *outboxService) StartDispatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastCleanup := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[outbox] dispatcher dihentikan")
+			return
+		case <-ticker.C:
+			// Kuras antrean selama batch terkirim penuh; sisanya di tick berikutnya
+			for ctx.Err() == nil && s.DispatchDue(ctx) == outboxBatchSize {
+				continue
+			}
+
+			if time.Since(lastCleanup) >= time.Hour {
+				lastCleanup = time.Now()
+				deleted, err := s.repo.DeleteSentBefore(ctx, time.Now().Add(-outboxSentRetention))
+				if err != nil {
+					log.Printf("[outbox] gagal menghapus pesan terkirim lama: %v", err)
+				} else if deleted > 0 {
+					log.Printf("[outbox] %d pesan terkirim lama dihapus", deleted)
+				}
+			}
+		}
+	}
+}
+
+func (s *outboxService) GetAll(ctx context.Context, params *dto.OutboxQueryParams) ([]models.OutboxMessage, *models.PaginationMeta, error) {
+	messages, total, err := s.repo.FindAll(ctx, params.Status, params.EventType, params.Page, params.PerPage)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta := models.NewPaginationMeta(params.Page, params.PerPage, total)
+	return messages, &meta, nil
+}
+
+func (s *outboxService) Replay(ctx context.Context, id uuid.UUID) (*models.OutboxMessage, error) {
+	replayed, err := s.repo.Replay(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !replayed {
+		if _, err := s.repo.FindByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrOutboxNotReplayable
+	}
+	return s.repo.FindByID(ctx, id)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// fakeOutboxRepository hanya mencatat hasil dispatch
+type fakeOutboxRepository struct {
+	repositories.OutboxRepository
+	sent   []uuid.UUID
+	failed map[uuid.UUID]bool // id → dead
+	next   map[uuid.UUID]time.Time
+}
+
+func (r *fakeOutboxRepository) MarkSent(ctx context.Context, id uuid.UUID) error {
+	r.sent = append(r.sent, id)
+	return nil
+}
+
+func (r *fakeOutboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string, dead bool) error {
+	r.failed[id] = dead
+	r.next[id] = nextAttemptAt
+	return nil
+}
+
+func TestOutboxDispatchRetriesThenDeadLetters(t *testing.T) {
+	repo := &fakeOutboxRepository{failed: map[uuid.UUID]bool{}, next: map[uuid.UUID]time.Time{}}
+	svc := &outboxService{repo: repo, handlers: map[string]OutboxHandler{}}
+	svc.RegisterHandler("ok", func(ctx context.Context, msg *models.OutboxMessage) error { return nil })
+	svc.RegisterHandler("down", func(ctx context.Context, msg *models.OutboxMessage) error { return errors.New("storefront down") })
+
+	ok := &models.OutboxMessage{ID: uuid.New(), EventType: "ok", MaxAttempts: 3}
+	retry := &models.OutboxMessage{ID: uuid.New(), EventType: "down", Attempts: 1, MaxAttempts: 3}
+	last := &models.OutboxMessage{ID: uuid.New(), EventType: "down", Attempts: 2, MaxAttempts: 3}
+	unknown := &models.OutboxMessage{ID: uuid.New(), EventType: "tidak-ada", MaxAttempts: 3}
+
+	before := time.Now()
+	for _, msg := range []*models.OutboxMessage{ok, retry, last, unknown} {
+		svc.dispatch(context.Background(), msg)
+	}
+
+	if len(repo.sent) != 1 || repo.sent[0] != ok.ID {
+		t.Fatalf("hanya pesan sukses yang ditandai terkirim, got %v", repo.sent)
+	}
+	if dead, found := repo.failed[retry.ID]; !found || dead {
+		t.Fatal("percobaan gagal sebelum max_attempts harus dijadwalkan ulang, bukan DEAD")
+	}
+	// Percobaan ke-2 → backoff 1 menit
+	if delay := repo.next[retry.ID].Sub(before); delay < time.Minute || delay > time.Minute+5*time.Second {
+		t.Fatalf("backoff percobaan ke-2 harus sekitar 1 menit, got %v", delay)
+	}
+	if !repo.failed[last.ID] {
+		t.Fatal("pesan yang mencapai max_attempts harus DEAD")
+	}
+	if !repo.failed[unknown.ID] {
+		t.Fatal("pesan tanpa handler harus langsung DEAD")
+	}
+	if retryBackoff(20) != retryMaxBackoff {
+		t.Fatal("backoff harus dibatasi retryMaxBackoff")
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// GatewayInvoiceRequest adalah invoice (payment link) yang dibuat di payment
+// gateway untuk pesanan yang dibuat admin dari panel
+type GatewayInvoiceRequest struct {
+	// ExternalID dipakai storefront BE untuk mencocokkan callback Xendit ke
+	// pesanan_pembayaran.xendit_external_id
+	ExternalID   string
+	Amount       decimal.Decimal
+	Description  string
+	PayerNama    string
+	PayerEmail   string
+	PayerTelepon string
+	Duration     time.Duration
+}
+
+// GatewayInvoiceResult adalah invoice yang berhasil dibuat payment gateway
+type GatewayInvoiceResult struct {
+	InvoiceID  string
+	InvoiceURL string
+	ExpiredAt  time.Time
+}
+
+// PaymentLinkGateway adalah adapter pembuatan payment link. Status bayar
+// invoice tetap diterima storefront BE lewat callback Xendit.
+type PaymentLinkGateway interface {
+	Code() string
+	CreateInvoice(ctx context.Context, req *GatewayInvoiceRequest) (*GatewayInvoiceResult, error)
+}
+
+// NewPaymentLinkGateway memilih adapter sesuai PAYMENT_GATEWAY_MODE
+func NewPaymentLinkGateway(mode, xenditBaseURL, xenditSecretKey string) PaymentLinkGateway {
+	if mode == PaymentGatewayModeSandbox {
+		log.Printf("[payment-link] mode sandbox aktif: invoice disimulasikan tanpa payment gateway")
+		return &localInvoiceGateway{}
+	}
+	return &xenditInvoiceGateway{
+		baseURL:    strings.TrimRight(xenditBaseURL, "/"),
+		secretKey:  xenditSecretKey,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ========================================
+// Xendit
+// ========================================
+
+type xenditInvoiceGateway struct {
+	baseURL    string
+	secretKey  string
+	httpClient *http.Client
+}
+
+type xenditInvoiceCustomer struct {
+	GivenNames   string `json:"given_names,omitempty"`
+	Email        string `json:"email,omitempty"`
+	MobileNumber string `json:"mobile_number,omitempty"`
+}
+
+type xenditInvoiceRequest struct {
+	ExternalID      string                `json:"external_id"`
+	Amount          float64               `json:"amount"`
+	Currency        string                `json:"currency"`
+	Description     string                `json:"description"`
+	PayerEmail      string                `json:"payer_email,omitempty"`
+	InvoiceDuration int64                 `json:"invoice_duration"`
+	Customer        xenditInvoiceCustomer `json:"customer"`
+}
+
+type xenditInvoiceResponse struct {
+	ID         string    `json:"id"`
+	InvoiceURL string    `json:"invoice_url"`
+	ExpiryDate time.Time `json:"expiry_date"`
+	ErrorCode  string    `json:"error_code"`
+	Message    string    `json:"message"`
+}
+
+func (g *xenditInvoiceGateway) Code() string {
+	return "XENDIT"
+}
+
+func (g *xenditInvoiceGateway) CreateInvoice(ctx context.Context, req *GatewayInvoiceRequest) (*GatewayInvoiceResult, error) {
+	if g.baseURL == "" || g.secretKey == "" {
+		return nil, fmt.Errorf("konfigurasi Xendit tidak lengkap")
+	}
+	amount, _ := req.Amount.Float64()
+	body, err := json.Marshal(xenditInvoiceRequest{
+		ExternalID:      req.ExternalID,
+		Amount:          amount,
+		Currency:        "IDR",
+		Description:     req.Description,
+		PayerEmail:      req.PayerEmail,
+		InvoiceDuration: int64(req.Duration.Seconds()),
+		Customer: xenditInvoiceCustomer{
+			GivenNames:   req.PayerNama,
+			Email:        req.PayerEmail,
+			MobileNumber: req.PayerTelepon,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat request body: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+"/v2/invoices", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat HTTP request: %w", err)
+	}
+	httpReq.SetBasicAuth(g.secretKey, "")
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghubungi Xendit: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	log.Printf("[payment-link] <-- POST /v2/invoices external_id=%s status=%d body=%s", req.ExternalID, resp.StatusCode, string(respBody))
+
+	var result xenditInvoiceResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("gagal parse response Xendit (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("Xendit menolak invoice (status %d): %s %s", resp.StatusCode, result.ErrorCode, result.Message)
+	}
+	if result.ID == "" || result.InvoiceURL == "" {
+		return nil, fmt.Errorf("response Xendit tidak memuat invoice")
+	}
+	return &GatewayInvoiceResult{InvoiceID: result.ID, InvoiceURL: result.InvoiceURL, ExpiredAt: result.ExpiryDate}, nil
+}
+
+// ========================================
+// Sandbox
+// ========================================
+
+// localInvoiceGateway membuat invoice tiruan; link-nya tidak bisa dibayar
+type localInvoiceGateway struct{}
+
+func (g *localInvoiceGateway) Code() string {
+	return "SANDBOX"
+}
+
+func (g *localInvoiceGateway) CreateInvoice(ctx context.Context, req *GatewayInvoiceRequest) (*GatewayInvoiceResult, error) {
+	id := "sandbox-inv-" + uuid.NewSHA1(uuid.NameSpaceOID, []byte(req.ExternalID)).String()
+	return &GatewayInvoiceResult{
+		InvoiceID:  id,
+		InvoiceURL: "https://sandbox.invalid/invoices/" + id,
+		ExpiredAt:  time.Now().Add(req.Duration),
+	}, nil
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Mode payment gateway untuk refund & payment link (PAYMENT_GATEWAY_MODE)
+const (
+	// PaymentGatewayModeLive memakai API refund & invoice Xendit
+	PaymentGatewayModeLive = "live"
+	// PaymentGatewayModeSandbox memakai gateway tiruan lokal: refund langsung
+	// berhasil dan payment link tiruan, tanpa memanggil API luar (local/staging)
+	PaymentGatewayModeSandbox = "sandbox"
+)
+
+// Status refund di payment gateway, mengikuti format Xendit
+const (
+	GatewayRefundPending   = "PENDING"
+	GatewayRefundSucceeded = "SUCCEEDED"
+	GatewayRefundFailed    = "FAILED"
+)
+
+// GatewayRefundRequest adalah satu refund yang diteruskan ke payment gateway
+type GatewayRefundRequest struct {
+	// IdempotencyKey sama untuk percobaan ulang yang sama agar gateway tidak
+	// membuat refund ganda (mis. timeout setelah refund tercatat di gateway)
+	IdempotencyKey string
+	ReferenceID    string
+	InvoiceID      string
+	Amount         decimal.Decimal
+	// Reason salah satu alasan Xendit: CANCELLATION, REQUESTED_BY_CUSTOMER, OTHERS
+	Reason string
+}
+
+// GatewayRefundResult adalah status refund dari payment gateway
+type GatewayRefundResult struct {
+	RefundID string
+	Status   string
+	// FailureReason terisi jika Status FAILED
+	FailureReason string
+}
+
+// PaymentRefundGateway adalah adapter refund payment gateway. Error hanya
+// untuk kegagalan sementara (koneksi/5xx) yang aman diulang dengan idempotency
+// key yang sama; penolakan gateway dikembalikan sebagai Status FAILED.
+type PaymentRefundGateway interface {
+	Code() string
+	Refund(ctx context.Context, req *GatewayRefundRequest) (*GatewayRefundResult, error)
+	GetRefund(ctx context.Context, refundID string) (*GatewayRefundResult, error)
+}
+
+// NewPaymentRefundGateway memilih adapter sesuai PAYMENT_GATEWAY_MODE
+func NewPaymentRefundGateway(mode, xenditBaseURL, xenditSecretKey string) PaymentRefundGateway {
+	if mode == PaymentGatewayModeSandbox {
+		log.Printf("[refund] mode sandbox aktif: refund disimulasikan tanpa payment gateway")
+		return &localRefundGateway{}
+	}
+	return &xenditRefundGateway{
+		baseURL:    strings.TrimRight(xenditBaseURL, "/"),
+		secretKey:  xenditSecretKey,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ========================================
+// Xendit
+// ========================================
+
+type xenditRefundGateway struct {
+	baseURL    string
+	secretKey  string
+	httpClient *http.Client
+}
+
+type xenditRefundRequest struct {
+	InvoiceID   string  `json:"invoice_id"`
+	ReferenceID string  `json:"reference_id"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	Reason      string  `json:"reason"`
+}
+
+type xenditRefundResponse struct {
+	ID          string `json:"id"`
+	Status      string `json:"status"`
+	FailureCode string `json:"failure_code"`
+	ErrorCode   string `json:"error_code"`
+	Message     string `json:"message"`
+}
+
+func (g *xenditRefundGateway) Code() string {
+	return "XENDIT"
+}
+
+func (g *xenditRefundGateway) Refund(ctx context.Context, req *GatewayRefundRequest) (*GatewayRefundResult, error) {
+	if g.baseURL == "" || g.secretKey == "" {
+		return nil, fmt.Errorf("konfigurasi Xendit tidak lengkap")
+	}
+	if req.InvoiceID == "" {
+		return &GatewayRefundResult{Status: GatewayRefundFailed, FailureReason: "pembayaran tidak memiliki invoice Xendit, lakukan refund manual"}, nil
+	}
+	amount, _ := req.Amount.Float64()
+	body, err := json.Marshal(xenditRefundRequest{
+		InvoiceID:   req.InvoiceID,
+		ReferenceID: req.ReferenceID,
+		Amount:      amount,
+		Currency:    "IDR",
+		Reason:      req.Reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat request body: %w", err)
+	}
+	return g.do(ctx, http.MethodPost, "/refunds", body, req.IdempotencyKey)
+}
+
+func (g *xenditRefundGateway) GetRefund(ctx context.Context, refundID string) (*GatewayRefundResult, error) {
+	if g.baseURL == "" || g.secretKey == "" {
+		return nil, fmt.Errorf("konfigurasi Xendit tidak lengkap")
+	}
+	return g.do(ctx, http.MethodGet, "/refunds/"+refundID, nil, "")
+}
+
+func (g *xenditRefundGateway) do(ctx context.Context, method, path string, body []byte, idempotencyKey string) (*GatewayRefundResult, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, method, g.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat HTTP request: %w", err)
+	}
+	httpReq.SetBasicAuth(g.secretKey, "")
+	httpReq.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		httpReq.Header.Set("Idempotency-key", idempotencyKey)
+	}
+
+	resp, err := g.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghubungi Xendit: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	log.Printf("[refund] <-- %s %s status=%d body=%s", method, path, resp.StatusCode, string(respBody))
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("Xendit API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var result xenditRefundResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("gagal parse response Xendit: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		// Penolakan permanen (mis. metode pembayaran tidak mendukung refund)
+		return &GatewayRefundResult{Status: GatewayRefundFailed, FailureReason: fmt.Sprintf("%s: %s", result.ErrorCode, result.Message)}, nil
+	}
+
+	status := strings.ToUpper(result.Status)
+	if status != GatewayRefundSucceeded && status != GatewayRefundFailed {
+		status = GatewayRefundPending
+	}
+	return &GatewayRefundResult{RefundID: result.ID, Status: status, FailureReason: result.FailureCode}, nil
+}
+
+// ========================================
+// Sandbox
+// ========================================
+
+// localRefundGateway menyetujui setiap refund secara langsung
+type localRefundGateway struct{}
+
+func (g *localRefundGateway) Code() string {
+	return "SANDBOX"
+}
+
+func (g *localRefundGateway) Refund(ctx context.Context, req *GatewayRefundRequest) (*GatewayRefundResult, error) {
+	// ID deterministik dari idempotency key, sama seperti gateway sungguhan
+	id := uuid.NewSHA1(uuid.NameSpaceOID, []byte(req.IdempotencyKey))
+	return &GatewayRefundResult{RefundID: "sandbox-rfd-" + id.String(), Status: GatewayRefundSucceeded}, nil
+}
+
+func (g *localRefundGateway) GetRefund(ctx context.Context, refundID string) (*GatewayRefundResult, error) {
+	return &GatewayRefundResult{RefundID: refundID, Status: GatewayRefundSucceeded}, nil
+}
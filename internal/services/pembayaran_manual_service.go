@@ -0,0 +1,799 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/config"
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/orderstate"
+	"project-bulky-be/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	// maksBuktiMenunggu jumlah bukti transfer MENUNGGU per pesanan agar antrean
+	// finance tidak dibanjiri unggahan ulang
+	maksBuktiMenunggu = 3
+	// maksPembayaranPesanan mengikuti trigger batas baris pesanan_pembayaran
+	maksPembayaranPesanan = 5
+	// toleransiTanggalMutasi selisih hari antara tanggal mutasi dan tanggal
+	// transfer yang dilaporkan buyer saat mencari saran tanpa kode pesanan
+	toleransiTanggalMutasi = 3 * 24 * time.Hour
+	direktoriBuktiTransfer = "bukti-transfer"
+	// lanjutanPesananRetryDelay menunda job cadangan PENDING → PROCESSING agar
+	// tidak bersamaan dengan percobaan langsung setelah commit
+	lanjutanPesananRetryDelay = 2 * time.Minute
+)
+
+var (
+	ErrPembayaranManualPesananNotFound = errors.New("pesanan tidak ditemukan")
+	ErrBuktiTransferNotFound           = errors.New("bukti transfer tidak ditemukan")
+	ErrBuktiTransferInvalid            = errors.New("bukti transfer tidak valid")
+	ErrBuktiTransferStatus             = errors.New("bukti transfer sudah direview")
+	ErrPembayaranManualTidakDiizinkan  = errors.New("pesanan tidak bisa dibayar dengan transfer manual")
+	ErrPembayaranManualBatas           = errors.New("batas jumlah pembayaran pesanan sudah tercapai")
+	ErrMutasiCSVInvalid                = errors.New("file CSV mutasi rekening tidak valid")
+)
+
+// ekstensiBuktiTransfer content type yang diterima beserta ekstensi file simpanan
+var ekstensiBuktiTransfer = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// BuktiTransferFile adalah file bukti transfer yang siap diunduh finance
+type BuktiTransferFile struct {
+	NamaFile    string
+	ContentType string
+	Data        []byte
+}
+
+// PembayaranManualService mengelola pembayaran transfer bank di luar payment
+// gateway: bukti transfer dari buyer, review finance dan impor mutasi
+// rekening yang dicocokkan otomatis dengan kode pesanan dan nominal.
+type PembayaranManualService interface {
+	InstruksiTransfer(ctx context.Context, buyerID, pesananID string) (*dto.InstruksiTransferResponse, error)
+	UploadBukti(ctx context.Context, buyerID, pesananID string, req *dto.UploadBuktiTransferRequest, contentType string, data []byte) (*dto.BuktiTransferResponse, error)
+
+	GetAll(ctx context.Context, params *dto.BuktiTransferQueryParams) ([]dto.BuktiTransferResponse, *models.PaginationMeta, error)
+	GetByID(ctx context.Context, id string) (*dto.BuktiTransferResponse, error)
+	DownloadFile(ctx context.Context, id string) (*BuktiTransferFile, error)
+	// Approve mencatat pembayaran manual PAID, memperbarui payment_status
+	// pesanan (PARTIAL/PAID) dan memindahkan pesanan lunas ke PROCESSING
+	Approve(ctx context.Context, id string, req *dto.ApproveBuktiTransferRequest, adminID uuid.UUID) (*dto.BuktiTransferResponse, error)
+	Reject(ctx context.Context, id string, req *dto.RejectBuktiTransferRequest, adminID uuid.UUID) (*dto.BuktiTransferResponse, error)
+
+	ImportMutasi(ctx context.Context, namaFile string, data []byte, adminID uuid.UUID) (*dto.ImportMutasiBankResponse, error)
+	GetMutasi(ctx context.Context, params *dto.MutasiBankQueryParams) ([]dto.MutasiBankResponse, *models.PaginationMeta, error)
+}
+
+type pembayaranManualService struct {
+	db           *gorm.DB
+	repo         repositories.PembayaranManualRepository
+	orderMachine *orderstate.Machine
+	jobQueue     JobQueueService
+	cfg          *config.Config
+}
+
+func NewPembayaranManualService(db *gorm.DB, repo repositories.PembayaranManualRepository, orderMachine *orderstate.Machine, jobQueue JobQueueService, cfg *config.Config) PembayaranManualService {
+	s := &pembayaranManualService{
+		db:           db,
+		repo:         repo,
+		orderMachine: orderMachine,
+		jobQueue:     jobQueue,
+		cfg:          cfg,
+	}
+	jobQueue.RegisterHandler(models.JobTypePembayaranManualProses, s.handleLanjutkanPesanan, JobHandlerOptions{
+		Timeout: time.Minute,
+	})
+	return s
+}
+
+// lanjutanPesananPayload payload job models.JobTypePembayaranManualProses
+type lanjutanPesananPayload struct {
+	PesananID uuid.UUID `json:"pesanan_id"`
+	AdminID   uuid.UUID `json:"admin_id"`
+}
+
+func (s *pembayaranManualService) InstruksiTransfer(ctx context.Context, buyerID, pesananID string) (*dto.InstruksiTransferResponse, error) {
+	pesanan, err := s.buyerPesanan(ctx, buyerID, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	lunas, _, err := s.repo.SumPembayaranLunas(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	bukti, err := s.repo.FindBuktiByPesanan(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.InstruksiTransferResponse{
+		PesananID:     pesanan.ID,
+		KodeReferensi: pesanan.Kode,
+		Bank:          s.cfg.TransferBankNama,
+		NomorRekening: s.cfg.TransferBankNomorRekening,
+		AtasNama:      s.cfg.TransferBankAtasNama,
+		Total:         pesanan.Total,
+		SudahDibayar:  lunas,
+		SisaTagihan:   decimal.Max(pesanan.Total.Sub(lunas), decimal.Zero),
+		PaymentStatus: string(pesanan.PaymentStatus),
+		BisaUnggah:    bisaBayarManual(pesanan),
+		BuktiTransfer: make([]dto.BuktiTransferResponse, 0, len(bukti)),
+	}
+	for i := range bukti {
+		resp.BuktiTransfer = append(resp.BuktiTransfer, mapBuktiTransferResponse(&bukti[i]))
+	}
+	return resp, nil
+}
+
+func (s *pembayaranManualService) UploadBukti(ctx context.Context, buyerID, pesananID string, req *dto.UploadBuktiTransferRequest, contentType string, data []byte) (*dto.BuktiTransferResponse, error) {
+	pesanan, err := s.buyerPesanan(ctx, buyerID, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validasiUploadBukti(req); err != nil {
+		return nil, err
+	}
+	ext, ok := ekstensiBuktiTransfer[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: file harus berupa gambar (JPG, PNG, WEBP) atau PDF", ErrBuktiTransferInvalid)
+	}
+	if !bisaBayarManual(pesanan) {
+		return nil, ErrPembayaranManualTidakDiizinkan
+	}
+
+	lunas, _, err := s.repo.SumPembayaranLunas(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Jumlah.GreaterThan(pesanan.Total.Sub(lunas)) {
+		return nil, fmt.Errorf("%w: jumlah melebihi sisa tagihan", ErrBuktiTransferInvalid)
+	}
+	menunggu, err := s.repo.CountBuktiMenunggu(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	if menunggu >= maksBuktiMenunggu {
+		return nil, fmt.Errorf("%w: masih ada %d bukti transfer yang menunggu review", ErrPembayaranManualTidakDiizinkan, menunggu)
+	}
+
+	id := uuid.New()
+	filePath, err := s.saveFile(id, ext, data)
+	if err != nil {
+		return nil, err
+	}
+	bukti := &models.PesananBuktiTransfer{
+		ID:              id,
+		PesananID:       pesanan.ID,
+		BuyerID:         pesanan.BuyerID,
+		Jumlah:          req.Jumlah,
+		BankPengirim:    req.BankPengirim,
+		NamaPengirim:    req.NamaPengirim,
+		TanggalTransfer: req.TanggalTransfer,
+		Catatan:         req.Catatan,
+		FilePath:        filePath,
+		ContentType:     contentType,
+		Status:          models.BuktiTransferStatusMenunggu,
+	}
+	if err := s.repo.CreateBukti(ctx, bukti); err != nil {
+		// File tanpa baris bukti tidak akan pernah bisa diakses
+		os.Remove(filepath.Join(s.cfg.DokumenStoragePath, filepath.FromSlash(filePath)))
+		return nil, err
+	}
+
+	resp := mapBuktiTransferResponse(bukti)
+	return &resp, nil
+}
+
+func (s *pembayaranManualService) GetAll(ctx context.Context, params *dto.BuktiTransferQueryParams) ([]dto.BuktiTransferResponse, *models.PaginationMeta, error) {
+	params.SetDefaults()
+
+	filter := repositories.BuktiTransferFilter{Status: strings.ToUpper(params.Status), Search: strings.TrimSpace(params.Search)}
+	if filter.Status == "ALL" {
+		filter.Status = ""
+	}
+	bukti, total, err := s.repo.FindBukti(ctx, filter, params.Page, params.PerPage)
+	if err != nil {
+		return nil, nil, err
+	}
+	items := make([]dto.BuktiTransferResponse, 0, len(bukti))
+	for i := range bukti {
+		items = append(items, mapBuktiTransferResponse(&bukti[i]))
+	}
+	meta := models.NewPaginationMeta(params.Page, params.PerPage, total)
+	return items, &meta, nil
+}
+
+func (s *pembayaranManualService) GetByID(ctx context.Context, id string) (*dto.BuktiTransferResponse, error) {
+	bukti, err := s.findBukti(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := mapBuktiTransferResponse(bukti)
+	return &resp, nil
+}
+
+func (s *pembayaranManualService) DownloadFile(ctx context.Context, id string) (*BuktiTransferFile, error) {
+	bukti, err := s.findBukti(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(s.cfg.DokumenStoragePath, filepath.FromSlash(bukti.FilePath)))
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca file bukti transfer %s: %w", bukti.ID, err)
+	}
+	return &BuktiTransferFile{
+		NamaFile:    "bukti-transfer-" + bukti.Pesanan.Kode + "-" + bukti.ID.String()[:8] + filepath.Ext(bukti.FilePath),
+		ContentType: bukti.ContentType,
+		Data:        data,
+	}, nil
+}
+
+func (s *pembayaranManualService) Approve(ctx context.Context, id string, req *dto.ApproveBuktiTransferRequest, adminID uuid.UUID) (*dto.BuktiTransferResponse, error) {
+	buktiID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrBuktiTransferNotFound
+	}
+	if req.Jumlah != nil && !req.Jumlah.IsPositive() {
+		return nil, fmt.Errorf("%w: jumlah harus lebih dari 0", ErrBuktiTransferInvalid)
+	}
+
+	var hasil *pembayaranManualResult
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		bukti, err := repo.LockBukti(ctx, buktiID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBuktiTransferNotFound
+			}
+			return err
+		}
+		if bukti.Status != models.BuktiTransferStatusMenunggu {
+			return ErrBuktiTransferStatus
+		}
+		jumlah := bukti.Jumlah
+		if req.Jumlah != nil {
+			jumlah = *req.Jumlah
+		}
+		hasil, err = s.setujuiBukti(ctx, repo, bukti, jumlah, adminID)
+		if err != nil {
+			return err
+		}
+		return s.jadwalkanLanjutan(ctx, tx, hasil, adminID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.lanjutkanPesanan(ctx, hasil, adminID)
+	return s.GetByID(ctx, id)
+}
+
+func (s *pembayaranManualService) Reject(ctx context.Context, id string, req *dto.RejectBuktiTransferRequest, adminID uuid.UUID) (*dto.BuktiTransferResponse, error) {
+	buktiID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrBuktiTransferNotFound
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		bukti, err := repo.LockBukti(ctx, buktiID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBuktiTransferNotFound
+			}
+			return err
+		}
+		if bukti.Status != models.BuktiTransferStatusMenunggu {
+			return ErrBuktiTransferStatus
+		}
+		return repo.UpdateBukti(ctx, bukti.ID, map[string]interface{}{
+			"status":           models.BuktiTransferStatusDitolak,
+			"alasan_penolakan": strings.TrimSpace(req.Alasan),
+			"reviewed_by":      adminID,
+			"reviewed_at":      time.Now(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetByID(ctx, id)
+}
+
+// ImportMutasi mencatat baris kredit mutasi rekening. Setiap baris diproses
+// dalam transaksinya sendiri; baris yang sudah pernah diimpor dilewati.
+//
+// Pencocokan otomatis butuh kode pesanan di berita transfer + nominal sama
+// dengan bukti transfer MENUNGGU atau sisa tagihan. Tanpa kode, mutasi selalu
+// BELUM_COCOK; jika nominalnya sama dengan tepat satu bukti transfer MENUNGGU
+// di sekitar tanggal mutasi, bukti & pesanan itu disimpan sebagai saran untuk
+// dikonfirmasi finance.
+func (s *pembayaranManualService) ImportMutasi(ctx context.Context, namaFile string, data []byte, adminID uuid.UUID) (*dto.ImportMutasiBankResponse, error) {
+	rows, dilewati, err := parseMutasiCSV(data)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.ImportMutasiBankResponse{
+		TotalBaris: len(rows) + dilewati,
+		Dilewati:   dilewati,
+		Mutasi:     make([]dto.MutasiBankResponse, 0, len(rows)),
+	}
+	kemunculan := make(map[string]int)
+	for _, row := range rows {
+		kunci := sidikMutasi(row, 0)
+		kemunculan[kunci]++
+		sidik := sidikMutasi(row, kemunculan[kunci])
+
+		exists, err := s.repo.MutasiExists(ctx, sidik)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			resp.Duplikat++
+			continue
+		}
+
+		mutasi := &models.MutasiBank{
+			Tanggal:     row.Tanggal,
+			Keterangan:  row.Keterangan,
+			Jumlah:      row.Jumlah,
+			Sidik:       sidik,
+			Status:      models.MutasiBankStatusBelumCocok,
+			NamaFile:    &namaFile,
+			DiimporOleh: &adminID,
+		}
+		var hasil *pembayaranManualResult
+		err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			repo := s.repo.WithTx(tx)
+			h, err := s.cocokkanMutasi(ctx, repo, mutasi, adminID)
+			if err != nil {
+				return err
+			}
+			hasil = h
+			if err := repo.CreateMutasi(ctx, mutasi); err != nil {
+				return err
+			}
+			return s.jadwalkanLanjutan(ctx, tx, hasil, adminID)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("gagal memproses baris %d: %w", row.Baris, err)
+		}
+
+		item := mapMutasiBankResponse(mutasi)
+		if hasil != nil {
+			item.KodePesanan = &hasil.Pesanan.Kode
+			resp.Cocok++
+			s.lanjutkanPesanan(ctx, hasil, adminID)
+		} else {
+			resp.BelumCocok++
+		}
+		resp.Mutasi = append(resp.Mutasi, item)
+	}
+	return resp, nil
+}
+
+func (s *pembayaranManualService) GetMutasi(ctx context.Context, params *dto.MutasiBankQueryParams) ([]dto.MutasiBankResponse, *models.PaginationMeta, error) {
+	params.SetDefaults()
+
+	mutasi, total, err := s.repo.FindMutasi(ctx, strings.ToUpper(params.Status), params.Page, params.PerPage)
+	if err != nil {
+		return nil, nil, err
+	}
+	items := make([]dto.MutasiBankResponse, 0, len(mutasi))
+	for i := range mutasi {
+		items = append(items, mapMutasiBankResponse(&mutasi[i]))
+	}
+	meta := models.NewPaginationMeta(params.Page, params.PerPage, total)
+	return items, &meta, nil
+}
+
+// pembayaranManualResult pembayaran yang baru dicatat beserta pesanan setelah
+// payment_status diperbarui
+type pembayaranManualResult struct {
+	Pesanan    *models.Pesanan
+	Pembayaran *models.PesananPembayaran
+}
+
+// cocokkanMutasi mengisi hasil pencocokan ke mutasi. Pesanan yang tidak bisa
+// menerima pembayaran (lunas, batal, nominal melebihi sisa) tidak
+// menggagalkan impor, hanya dicatat sebagai BELUM_COCOK.
+func (s *pembayaranManualService) cocokkanMutasi(ctx context.Context, repo repositories.PembayaranManualRepository, mutasi *models.MutasiBank, adminID uuid.UUID) (*pembayaranManualResult, error) {
+	belumCocok := func(format string, args ...interface{}) (*pembayaranManualResult, error) {
+		catatan := fmt.Sprintf(format, args...)
+		mutasi.CatatanPencocokan = &catatan
+		return nil, nil
+	}
+
+	// Urutan kunci selalu bukti transfer lalu pesanan, sama seperti Approve
+	var bukti []models.PesananBuktiTransfer
+	kode := ekstrakKodePesanan(mutasi.Keterangan)
+	if kode != "" {
+		pesanan, err := repo.FindPesananByKode(ctx, kode)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return belumCocok("Kode pesanan %s tidak ditemukan", kode)
+		}
+		if err != nil {
+			return nil, err
+		}
+		mutasi.PesananID = &pesanan.ID
+		if bukti, err = repo.LockBuktiMenunggu(ctx, &pesanan.ID, mutasi.Jumlah, time.Time{}, time.Time{}); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		bukti, err = repo.LockBuktiMenunggu(ctx, nil, mutasi.Jumlah,
+			mutasi.Tanggal.Add(-toleransiTanggalMutasi), mutasi.Tanggal.Add(toleransiTanggalMutasi))
+		if err != nil {
+			return nil, err
+		}
+		switch len(bukti) {
+		case 0:
+			return belumCocok("Tidak ada kode pesanan di berita transfer dan tidak ada bukti transfer dengan nominal yang sama")
+		case 1:
+			// Nominal saja tidak cukup untuk menyetujui otomatis (transfer lain
+			// bisa bernominal sama); bukti & pesanannya disimpan sebagai saran
+			// untuk dikonfirmasi finance
+			pesanan, err := repo.FindPesanan(ctx, bukti[0].PesananID)
+			if err != nil {
+				return nil, err
+			}
+			mutasi.PesananID = &pesanan.ID
+			mutasi.BuktiTransferID = &bukti[0].ID
+			mutasi.Pesanan = pesanan
+			return belumCocok("Tidak ada kode pesanan di berita transfer. Saran: bukti transfer pesanan %s dengan nominal yang sama, setujui manual jika sesuai", pesanan.Kode)
+		default:
+			return belumCocok("Ada %d bukti transfer dengan nominal yang sama, cocokkan manual", len(bukti))
+		}
+	}
+
+	var (
+		hasil *pembayaranManualResult
+		err   error
+	)
+	if len(bukti) > 0 {
+		mutasi.BuktiTransferID = &bukti[0].ID
+		hasil, err = s.setujuiBukti(ctx, repo, &bukti[0], mutasi.Jumlah, adminID)
+	} else {
+		pesanan, lockErr := repo.LockPesanan(ctx, *mutasi.PesananID)
+		if lockErr != nil {
+			return nil, lockErr
+		}
+		lunas, _, sumErr := repo.SumPembayaranLunas(ctx, pesanan.ID)
+		if sumErr != nil {
+			return nil, sumErr
+		}
+		if !mutasi.Jumlah.Equal(pesanan.Total.Sub(lunas)) {
+			return belumCocok("Nominal tidak sama dengan sisa tagihan %s dan tidak ada bukti transfer yang cocok", pesanan.Total.Sub(lunas).StringFixed(0))
+		}
+		hasil, err = s.catatPembayaran(ctx, repo, pesanan, mutasi.Jumlah, "Mutasi "+mutasi.Tanggal.Format("02/01/2006"), adminID)
+	}
+	if errors.Is(err, ErrPembayaranManualTidakDiizinkan) || errors.Is(err, ErrPembayaranManualBatas) || errors.Is(err, ErrBuktiTransferInvalid) {
+		mutasi.BuktiTransferID = nil
+		return belumCocok("%s", err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	mutasi.Status = models.MutasiBankStatusCocok
+	mutasi.PembayaranID = &hasil.Pembayaran.ID
+	return hasil, nil
+}
+
+// setujuiBukti mencatat pembayaran dari bukti transfer yang sudah dikunci
+// lalu menandainya DISETUJUI
+func (s *pembayaranManualService) setujuiBukti(ctx context.Context, repo repositories.PembayaranManualRepository, bukti *models.PesananBuktiTransfer, jumlah decimal.Decimal, adminID uuid.UUID) (*pembayaranManualResult, error) {
+	pesanan, err := repo.LockPesanan(ctx, bukti.PesananID)
+	if err != nil {
+		return nil, err
+	}
+	referensi := bukti.BankPengirim + " a.n. " + bukti.NamaPengirim
+	hasil, err := s.catatPembayaran(ctx, repo, pesanan, jumlah, referensi, adminID)
+	if err != nil {
+		return nil, err
+	}
+	err = repo.UpdateBukti(ctx, bukti.ID, map[string]interface{}{
+		"status":        models.BuktiTransferStatusDisetujui,
+		"reviewed_by":   adminID,
+		"reviewed_at":   time.Now(),
+		"pembayaran_id": hasil.Pembayaran.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hasil, nil
+}
+
+// catatPembayaran menambah pembayaran manual PAID ke pesanan yang sudah
+// dikunci dan menghitung ulang payment_status dari total pembayaran lunas
+func (s *pembayaranManualService) catatPembayaran(ctx context.Context, repo repositories.PembayaranManualRepository, pesanan *models.Pesanan, jumlah decimal.Decimal, referensi string, adminID uuid.UUID) (*pembayaranManualResult, error) {
+	if pesanan.OrderStatus == models.OrderStatusCancelled ||
+		pesanan.PaymentStatus == models.PaymentStatusPaid ||
+		pesanan.PaymentStatus == models.PaymentStatusRefunded {
+		return nil, fmt.Errorf("%w: pesanan %s berstatus %s/%s", ErrPembayaranManualTidakDiizinkan, pesanan.Kode, pesanan.OrderStatus, pesanan.PaymentStatus)
+	}
+	lunas, count, err := repo.SumPembayaranLunas(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maksPembayaranPesanan {
+		return nil, ErrPembayaranManualBatas
+	}
+	// Invoice Xendit yang masih aktif tetap bisa dibayar buyer; pembayaran
+	// manual baru boleh dicatat setelah invoice kedaluwarsa agar tidak dobel
+	invoiceAktif, err := repo.HasInvoiceAktif(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	if invoiceAktif {
+		return nil, fmt.Errorf("%w: pesanan %s masih punya invoice Xendit aktif, tunggu invoice kedaluwarsa", ErrPembayaranManualTidakDiizinkan, pesanan.Kode)
+	}
+	status, err := statusPembayaranManual(pesanan.Total, lunas, jumlah)
+	if err != nil {
+		return nil, err
+	}
+
+	var metodeID *uuid.UUID
+	metode, err := repo.FindMetodeByKode(ctx, models.KodeMetodeTransferManual)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if metode != nil {
+		metodeID = &metode.ID
+	}
+
+	now := time.Now()
+	if len(referensi) > 100 {
+		referensi = referensi[:100]
+	}
+	pembayaran := &models.PesananPembayaran{
+		PesananID:          pesanan.ID,
+		BuyerID:            pesanan.BuyerID,
+		MetodePembayaranID: metodeID,
+		Jumlah:             jumlah,
+		Status:             models.PaymentStatusPaid,
+		PaidAt:             &now,
+		IsManual:           true,
+		ReferensiTransfer:  &referensi,
+		DicatatOleh:        &adminID,
+	}
+	if err := repo.CreatePembayaran(ctx, pembayaran); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{"payment_status": status}
+	if status == models.PaymentStatusPaid {
+		fields["paid_at"] = now
+		pesanan.PaidAt = &now
+	}
+	if err := repo.UpdatePesanan(ctx, pesanan.ID, fields); err != nil {
+		return nil, err
+	}
+	pesanan.PaymentStatus = status
+	return &pembayaranManualResult{Pesanan: pesanan, Pembayaran: pembayaran}, nil
+}
+
+// jadwalkanLanjutan meng-enqueue job cadangan PENDING → PROCESSING di
+// transaksi pembayaran yang sama, sehingga pesanan yang lunas tetap diproses
+// walaupun percobaan langsung setelah commit gagal atau proses mati.
+func (s *pembayaranManualService) jadwalkanLanjutan(ctx context.Context, tx *gorm.DB, hasil *pembayaranManualResult, adminID uuid.UUID) error {
+	if !perluDilanjutkan(hasil) {
+		return nil
+	}
+	return s.jobQueue.Enqueue(ctx, tx, models.JobTypePembayaranManualProses, lanjutanPesananPayload{PesananID: hasil.Pesanan.ID, AdminID: adminID}, EnqueueOptions{
+		RunAt:     time.Now().Add(lanjutanPesananRetryDelay),
+		UniqueKey: models.JobTypePembayaranManualProses + ":" + hasil.Pesanan.ID.String(),
+	})
+}
+
+// lanjutkanPesanan memindahkan pesanan yang baru lunas dari PENDING ke
+// PROCESSING lewat state machine, sama seperti perubahan status dari panel.
+// Kegagalan hanya di-log; job dari jadwalkanLanjutan mengulanginya.
+func (s *pembayaranManualService) lanjutkanPesanan(ctx context.Context, hasil *pembayaranManualResult, adminID uuid.UUID) {
+	if !perluDilanjutkan(hasil) {
+		return
+	}
+	if err := s.prosesPesanan(ctx, hasil.Pesanan.ID, adminID); err != nil {
+		log.Printf("[pembayaran-manual] gagal memproses pesanan %s setelah lunas, dicoba ulang lewat job: %v", hasil.Pesanan.Kode, err)
+	}
+}
+
+// handleLanjutkanPesanan adalah handler job models.JobTypePembayaranManualProses.
+// Pesanan yang sudah diproses, dibatalkan atau belum lunas dilewati.
+func (s *pembayaranManualService) handleLanjutkanPesanan(ctx context.Context, job *models.BackgroundJob) error {
+	var payload lanjutanPesananPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return err
+	}
+	pesanan, err := s.repo.FindPesanan(ctx, payload.PesananID)
+	if err != nil {
+		return err
+	}
+	if !perluDilanjutkan(&pembayaranManualResult{Pesanan: pesanan}) {
+		return nil
+	}
+	return s.prosesPesanan(ctx, pesanan.ID, payload.AdminID)
+}
+
+func (s *pembayaranManualService) prosesPesanan(ctx context.Context, pesananID, adminID uuid.UUID) error {
+	note := "Pembayaran transfer manual dikonfirmasi"
+	_, err := s.orderMachine.Transition(ctx, orderstate.Request{
+		PesananID: pesananID,
+		To:        models.OrderStatusProcessing,
+		Trigger:   orderstate.TriggerAdmin,
+		ChangedBy: &adminID,
+		Note:      &note,
+	})
+	return err
+}
+
+// perluDilanjutkan pesanan baru lunas dan masih menunggu diproses
+func perluDilanjutkan(hasil *pembayaranManualResult) bool {
+	return hasil != nil && hasil.Pesanan.PaymentStatus == models.PaymentStatusPaid &&
+		hasil.Pesanan.OrderStatus == models.OrderStatusPending
+}
+
+// statusPembayaranManual payment_status pesanan setelah pembayaran baru
+// sejumlah jumlah; jumlah tidak boleh melebihi sisa tagihan
+func statusPembayaranManual(total, lunas, jumlah decimal.Decimal) (models.PaymentStatus, error) {
+	if !jumlah.IsPositive() {
+		return "", fmt.Errorf("%w: jumlah harus lebih dari 0", ErrBuktiTransferInvalid)
+	}
+	sisa := total.Sub(lunas)
+	if jumlah.GreaterThan(sisa) {
+		return "", fmt.Errorf("%w: jumlah %s melebihi sisa tagihan %s", ErrBuktiTransferInvalid, jumlah.StringFixed(0), sisa.StringFixed(0))
+	}
+	if jumlah.Equal(sisa) {
+		return models.PaymentStatusPaid, nil
+	}
+	return models.PaymentStatusPartial, nil
+}
+
+// bisaBayarManual pesanan masih menunggu pembayaran; invoice Xendit yang
+// kedaluwarsa tetap boleh dilunasi dengan transfer selama pesanan belum batal
+func bisaBayarManual(pesanan *models.Pesanan) bool {
+	if pesanan.OrderStatus != models.OrderStatusPending {
+		return false
+	}
+	switch pesanan.PaymentStatus {
+	case models.PaymentStatusPending, models.PaymentStatusPartial, models.PaymentStatusExpired:
+		return true
+	}
+	return false
+}
+
+func validasiUploadBukti(req *dto.UploadBuktiTransferRequest) error {
+	req.BankPengirim = strings.TrimSpace(req.BankPengirim)
+	req.NamaPengirim = strings.TrimSpace(req.NamaPengirim)
+	switch {
+	case !req.Jumlah.IsPositive():
+		return fmt.Errorf("%w: jumlah harus lebih dari 0", ErrBuktiTransferInvalid)
+	case req.BankPengirim == "" || len(req.BankPengirim) > 100:
+		return fmt.Errorf("%w: bank pengirim wajib diisi (maks. 100 karakter)", ErrBuktiTransferInvalid)
+	case req.NamaPengirim == "" || len(req.NamaPengirim) > 100:
+		return fmt.Errorf("%w: nama pengirim wajib diisi (maks. 100 karakter)", ErrBuktiTransferInvalid)
+	case req.TanggalTransfer.IsZero() || req.TanggalTransfer.After(time.Now().Add(24*time.Hour)):
+		return fmt.Errorf("%w: tanggal transfer tidak valid", ErrBuktiTransferInvalid)
+	case req.Catatan != nil && len(*req.Catatan) > 500:
+		return fmt.Errorf("%w: catatan maksimal 500 karakter", ErrBuktiTransferInvalid)
+	}
+	return nil
+}
+
+func (s *pembayaranManualService) saveFile(id uuid.UUID, ext string, data []byte) (string, error) {
+	directory := filepath.Join(direktoriBuktiTransfer, fmt.Sprintf("%d", time.Now().Year()))
+	if err := os.MkdirAll(filepath.Join(s.cfg.DokumenStoragePath, directory), 0750); err != nil {
+		return "", fmt.Errorf("gagal membuat direktori bukti transfer: %w", err)
+	}
+	relativePath := filepath.Join(directory, id.String()+ext)
+	f, err := os.OpenFile(filepath.Join(s.cfg.DokumenStoragePath, relativePath), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0640)
+	if err != nil {
+		return "", fmt.Errorf("gagal menyimpan bukti transfer: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", fmt.Errorf("gagal menyimpan bukti transfer: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("gagal menyimpan bukti transfer: %w", err)
+	}
+	return filepath.ToSlash(relativePath), nil
+}
+
+// buyerPesanan hanya untuk pemilik pesanan; pesanan buyer lain diperlakukan
+// tidak ada agar ID pesanan tidak bisa ditebak
+func (s *pembayaranManualService) buyerPesanan(ctx context.Context, buyerID, pesananID string) (*models.Pesanan, error) {
+	id, err := uuid.Parse(pesananID)
+	if err != nil {
+		return nil, ErrPembayaranManualPesananNotFound
+	}
+	pesanan, err := s.repo.FindPesanan(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPembayaranManualPesananNotFound
+		}
+		return nil, err
+	}
+	if pesanan.BuyerID.String() != buyerID {
+		return nil, ErrPembayaranManualPesananNotFound
+	}
+	return pesanan, nil
+}
+
+func (s *pembayaranManualService) findBukti(ctx context.Context, id string) (*models.PesananBuktiTransfer, error) {
+	buktiID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrBuktiTransferNotFound
+	}
+	bukti, err := s.repo.FindBuktiByID(ctx, buktiID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBuktiTransferNotFound
+		}
+		return nil, err
+	}
+	return bukti, nil
+}
+
+func mapBuktiTransferResponse(b *models.PesananBuktiTransfer) dto.BuktiTransferResponse {
+	resp := dto.BuktiTransferResponse{
+		ID:              b.ID,
+		PesananID:       b.PesananID,
+		BuyerID:         b.BuyerID,
+		Jumlah:          b.Jumlah,
+		BankPengirim:    b.BankPengirim,
+		NamaPengirim:    b.NamaPengirim,
+		TanggalTransfer: b.TanggalTransfer.Format("2006-01-02"),
+		Catatan:         b.Catatan,
+		ContentType:     b.ContentType,
+		Status:          string(b.Status),
+		AlasanPenolakan: b.AlasanPenolakan,
+		ReviewedBy:      b.ReviewedBy,
+		ReviewedAt:      b.ReviewedAt,
+		PembayaranID:    b.PembayaranID,
+		CreatedAt:       b.CreatedAt,
+	}
+	if b.Pesanan.ID != uuid.Nil {
+		resp.KodePesanan = b.Pesanan.Kode
+		resp.TotalPesanan = &b.Pesanan.Total
+	}
+	resp.NamaBuyer = b.Buyer.Nama
+	return resp
+}
+
+func mapMutasiBankResponse(m *models.MutasiBank) dto.MutasiBankResponse {
+	resp := dto.MutasiBankResponse{
+		ID:                m.ID,
+		Tanggal:           m.Tanggal.Format("2006-01-02"),
+		Keterangan:        m.Keterangan,
+		Jumlah:            m.Jumlah,
+		Status:            string(m.Status),
+		PesananID:         m.PesananID,
+		BuktiTransferID:   m.BuktiTransferID,
+		PembayaranID:      m.PembayaranID,
+		CatatanPencocokan: m.CatatanPencocokan,
+		NamaFile:          m.NamaFile,
+		CreatedAt:         m.CreatedAt,
+	}
+	if m.Pesanan != nil {
+		resp.KodePesanan = &m.Pesanan.Kode
+	}
+	return resp
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/orderstate"
+	"project-bulky-be/internal/repositories"
+	"project-bulky-be/internal/testutil/fakedb"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// fakePembayaranManualRepository menyimpan satu pesanan beserta bukti transfer
+// MENUNGGU-nya dan mencatat pembayaran yang dibuat
+type fakePembayaranManualRepository struct {
+	repositories.PembayaranManualRepository
+	pesanan    models.Pesanan
+	bukti      []models.PesananBuktiTransfer
+	pembayaran []models.PesananPembayaran
+	// invoiceAktif pesanan masih punya invoice Xendit PENDING
+	invoiceAktif bool
+}
+
+func (r *fakePembayaranManualRepository) FindPesanan(_ context.Context, id uuid.UUID) (*models.Pesanan, error) {
+	if id != r.pesanan.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	pesanan := r.pesanan
+	return &pesanan, nil
+}
+
+func (r *fakePembayaranManualRepository) LockPesanan(ctx context.Context, id uuid.UUID) (*models.Pesanan, error) {
+	return r.FindPesanan(ctx, id)
+}
+
+func (r *fakePembayaranManualRepository) FindPesananByKode(_ context.Context, kode string) (*models.Pesanan, error) {
+	if kode != r.pesanan.Kode {
+		return nil, gorm.ErrRecordNotFound
+	}
+	pesanan := r.pesanan
+	return &pesanan, nil
+}
+
+func (r *fakePembayaranManualRepository) LockBuktiMenunggu(_ context.Context, pesananID *uuid.UUID, jumlah decimal.Decimal, _, _ time.Time) ([]models.PesananBuktiTransfer, error) {
+	var bukti []models.PesananBuktiTransfer
+	for _, b := range r.bukti {
+		if b.Jumlah.Equal(jumlah) && (pesananID == nil || *pesananID == b.PesananID) {
+			bukti = append(bukti, b)
+		}
+	}
+	return bukti, nil
+}
+
+func (r *fakePembayaranManualRepository) SumPembayaranLunas(context.Context, uuid.UUID) (decimal.Decimal, int64, error) {
+	return decimal.Zero, int64(len(r.pembayaran)), nil
+}
+
+func (r *fakePembayaranManualRepository) HasInvoiceAktif(context.Context, uuid.UUID) (bool, error) {
+	return r.invoiceAktif, nil
+}
+
+func (r *fakePembayaranManualRepository) FindMetodeByKode(context.Context, string) (*models.MetodePembayaran, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakePembayaranManualRepository) CreatePembayaran(_ context.Context, pembayaran *models.PesananPembayaran) error {
+	pembayaran.ID = uuid.New()
+	r.pembayaran = append(r.pembayaran, *pembayaran)
+	return nil
+}
+
+func (r *fakePembayaranManualRepository) UpdatePesanan(context.Context, uuid.UUID, map[string]interface{}) error {
+	return nil
+}
+
+func (r *fakePembayaranManualRepository) UpdateBukti(context.Context, uuid.UUID, map[string]interface{}) error {
+	return nil
+}
+
+func testPesananTransfer(total int64) models.Pesanan {
+	return models.Pesanan{
+		ID: uuid.New(), Kode: "ORD-20261017-0007", BuyerID: uuid.New(), Total: decimal.NewFromInt(total),
+		OrderStatus: models.OrderStatusPending, PaymentStatus: models.PaymentStatusPending,
+	}
+}
+
+func TestCocokkanMutasiRequiresKodePesanan(t *testing.T) {
+	pesanan := testPesananTransfer(1500000)
+	repo := &fakePembayaranManualRepository{
+		pesanan: pesanan,
+		bukti:   []models.PesananBuktiTransfer{{ID: uuid.New(), PesananID: pesanan.ID, Jumlah: pesanan.Total}},
+	}
+	service := &pembayaranManualService{repo: repo}
+
+	// Nominal sama tanpa kode pesanan: hanya saran, tidak ada pembayaran
+	mutasi := &models.MutasiBank{Tanggal: time.Now(), Keterangan: "TRF BCA BUDI", Jumlah: pesanan.Total, Status: models.MutasiBankStatusBelumCocok}
+	hasil, err := service.cocokkanMutasi(context.Background(), repo, mutasi, uuid.New())
+	if err != nil || hasil != nil || len(repo.pembayaran) != 0 {
+		t.Fatalf("mutasi tanpa kode tidak boleh disetujui otomatis, got hasil=%v err=%v pembayaran=%d", hasil, err, len(repo.pembayaran))
+	}
+	if mutasi.Status != models.MutasiBankStatusBelumCocok || mutasi.BuktiTransferID == nil || *mutasi.BuktiTransferID != repo.bukti[0].ID ||
+		mutasi.PesananID == nil || *mutasi.PesananID != pesanan.ID {
+		t.Fatalf("mutasi tanpa kode harus BELUM_COCOK dengan saran bukti transfer, got %+v", mutasi)
+	}
+
+	// Kode pesanan di berita transfer + nominal sama: disetujui otomatis
+	mutasi = &models.MutasiBank{Tanggal: time.Now(), Keterangan: "TRF " + pesanan.Kode, Jumlah: pesanan.Total, Status: models.MutasiBankStatusBelumCocok}
+	hasil, err = service.cocokkanMutasi(context.Background(), repo, mutasi, uuid.New())
+	if err != nil || hasil == nil || mutasi.Status != models.MutasiBankStatusCocok || len(repo.pembayaran) != 1 {
+		t.Fatalf("mutasi dengan kode pesanan harus COCOK, got status=%s err=%v pembayaran=%d", mutasi.Status, err, len(repo.pembayaran))
+	}
+}
+
+func TestCatatPembayaranRefusesLiveXenditInvoice(t *testing.T) {
+	pesanan := testPesananTransfer(1500000)
+	repo := &fakePembayaranManualRepository{pesanan: pesanan, invoiceAktif: true}
+	service := &pembayaranManualService{repo: repo}
+
+	// Buyer masih bisa membayar invoice Xendit: pembayaran manual ditolak
+	_, err := service.catatPembayaran(context.Background(), repo, &pesanan, pesanan.Total, "BCA a.n. Budi", uuid.New())
+	if !errors.Is(err, ErrPembayaranManualTidakDiizinkan) || len(repo.pembayaran) != 0 {
+		t.Fatalf("pembayaran manual harus ditolak selama invoice Xendit aktif, got err=%v pembayaran=%d", err, len(repo.pembayaran))
+	}
+
+	// Invoice sudah kedaluwarsa: pembayaran manual dicatat
+	repo.invoiceAktif = false
+	hasil, err := service.catatPembayaran(context.Background(), repo, &pesanan, pesanan.Total, "BCA a.n. Budi", uuid.New())
+	if err != nil || hasil.Pesanan.PaymentStatus != models.PaymentStatusPaid || len(repo.pembayaran) != 1 {
+		t.Fatalf("pembayaran manual setelah invoice kedaluwarsa harus lunas, got %v err=%v", hasil, err)
+	}
+}
+
+func TestLunasManualDiprosesLewatJob(t *testing.T) {
+	pesanan := testPesananTransfer(1500000)
+	pesanan.PaymentStatus = models.PaymentStatusPaid
+	repo := &fakePembayaranManualRepository{pesanan: pesanan}
+	db, fake := fakedb.OpenPesanan(t, pesanan, nil)
+	jobQueue := &fakeJobQueue{}
+	service := NewPembayaranManualService(db, repo, orderstate.NewMachine(db), jobQueue, nil).(*pembayaranManualService)
+
+	// Pelunasan sebagian tidak menjadwalkan apa pun; pelunasan penuh
+	// menjadwalkan job di transaksi pembayaran
+	partial := repo.pesanan
+	partial.PaymentStatus = models.PaymentStatusPartial
+	if err := service.jadwalkanLanjutan(context.Background(), db, &pembayaranManualResult{Pesanan: &partial}, uuid.New()); err != nil || len(jobQueue.jobs) != 0 {
+		t.Fatalf("pesanan PARTIAL tidak boleh dijadwalkan, got %v err=%v", jobQueue.jobs, err)
+	}
+	if err := service.jadwalkanLanjutan(context.Background(), db, &pembayaranManualResult{Pesanan: &pesanan}, uuid.New()); err != nil ||
+		len(jobQueue.jobs) != 1 || jobQueue.jobs[0] != models.JobTypePembayaranManualProses || !jobQueue.inTx[0] {
+		t.Fatalf("pesanan lunas harus dijadwalkan di transaksi pembayaran, got %v %v err=%v", jobQueue.jobs, jobQueue.inTx, err)
+	}
+
+	payload, _ := json.Marshal(lanjutanPesananPayload{PesananID: pesanan.ID, AdminID: uuid.New()})
+	job := &models.BackgroundJob{JobType: models.JobTypePembayaranManualProses, Payload: payload}
+
+	// Percobaan langsung sudah berhasil: job tidak mengubah apa pun
+	repo.pesanan.OrderStatus = models.OrderStatusProcessing
+	if err := service.handleLanjutkanPesanan(context.Background(), job); err != nil || len(fake.Queries()) != 0 {
+		t.Fatalf("pesanan yang sudah PROCESSING harus dilewati, got err=%v queries=%d", err, len(fake.Queries()))
+	}
+
+	// Percobaan langsung gagal: job memindahkan pesanan ke PROCESSING
+	repo.pesanan.OrderStatus = models.OrderStatusPending
+	if err := service.handleLanjutkanPesanan(context.Background(), job); err != nil {
+		t.Fatalf("job harus memproses pesanan lunas: %v", err)
+	}
+	for _, q := range fake.Queries() {
+		if v, ok := q.Set("order_status"); ok && q.Is("UPDATE", "pesanan") && fmt.Sprint(v) == string(models.OrderStatusProcessing) {
+			return
+		}
+	}
+	t.Fatalf("pesanan harus dipindahkan ke PROCESSING, got %v", fake.Queries())
+}
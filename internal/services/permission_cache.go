@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrAdminAccessDenied = errors.New("admin tidak ditemukan atau tidak aktif")
+
+// permissionCacheTTL membatasi umur cache permission. Perubahan dari instance
+// yang sama langsung terlihat lewat versi cache; perubahan dari instance lain
+// (multi-replica) paling lambat terlihat setelah TTL ini.
+const permissionCacheTTL = 30 * time.Second
+
+// PermissionCache me-resolve role & permission admin langsung dari tabel
+// role/permission, sehingga perubahan role berlaku di request berikutnya
+// tanpa perlu login ulang. JWT hanya membawa identitas.
+type PermissionCache interface {
+	ResolveAdminAccess(ctx context.Context, adminID string) (*models.AdminAccess, error)
+	// InvalidateRole dipanggil RoleService setelah role/permission-nya berubah.
+	InvalidateRole(roleID uuid.UUID)
+	// InvalidateAdmin dipanggil AdminService setelah role/status admin berubah.
+	InvalidateAdmin(adminID uuid.UUID)
+}
+
+type permissionCacheEntry struct {
+	access   *models.AdminAccess
+	version  uint64
+	cachedAt time.Time
+}
+
+// permissionCache memakai satu nomor versi global: setiap invalidasi menaikkan
+// versi sehingga semua entry lama otomatis basi. Versi dicatat sebelum query
+// DB, jadi hasil query yang berjalan bersamaan dengan invalidasi tidak pernah
+// dianggap segar.
+type permissionCache struct {
+	authRepo repositories.AuthRepository
+
+	mu      sync.RWMutex
+	version uint64
+	entries map[uuid.UUID]permissionCacheEntry
+}
+
+func NewPermissionCache(authRepo repositories.AuthRepository) PermissionCache {
+	return &permissionCache{
+		authRepo: authRepo,
+		entries:  make(map[uuid.UUID]permissionCacheEntry),
+	}
+}
+
+func (s *permissionCache) ResolveAdminAccess(ctx context.Context, adminID string) (*models.AdminAccess, error) {
+	id, err := uuid.Parse(adminID)
+	if err != nil {
+		return nil, ErrAdminAccessDenied
+	}
+
+	s.mu.RLock()
+	entry, ok := s.entries[id]
+	version := s.version
+	s.mu.RUnlock()
+	if ok && entry.version == version && time.Since(entry.cachedAt) <= permissionCacheTTL {
+		return entry.access, nil
+	}
+
+	admin, err := s.authRepo.FindAdminWithRole(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAdminAccessDenied
+		}
+		return nil, err
+	}
+	if !admin.IsActive {
+		return nil, ErrAdminAccessDenied
+	}
+
+	access := &models.AdminAccess{AdminID: admin.ID, RoleID: admin.RoleID}
+	// Role nonaktif/terhapus = tanpa permission
+	if admin.Role != nil && admin.Role.IsActive {
+		access.RoleKode = admin.Role.Kode
+		for _, perm := range admin.Role.Permissions {
+			access.Permissions = append(access.Permissions, perm.Kode)
+		}
+		access.TwoFactorSetupRequired = admin.Role.RequireTwoFactor && !admin.TwoFactorEnabled
+	}
+
+	s.mu.Lock()
+	s.entries[id] = permissionCacheEntry{access: access, version: version, cachedAt: time.Now()}
+	s.mu.Unlock()
+
+	return access, nil
+}
+
+func (s *permissionCache) InvalidateRole(roleID uuid.UUID) {
+	s.bump()
+}
+
+func (s *permissionCache) InvalidateAdmin(adminID uuid.UUID) {
+	s.bump()
+}
+
+// bump menaikkan versi dan membuang semua entry. Perubahan role/admin jarang
+// terjadi, jadi invalidasi global lebih sederhana dan tetap murah.
+func (s *permissionCache) bump() {
+	s.mu.Lock()
+	s.version++
+	s.entries = make(map[uuid.UUID]permissionCacheEntry)
+	s.mu.Unlock()
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestPermissionCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+
+	role := &models.Role{ID: uuid.New(), Kode: models.RoleStaff, IsActive: true,
+		Permissions: []models.Permission{{Kode: "produk:read"}}}
+	admin := &models.Admin{ID: uuid.New(), RoleID: role.ID, Role: role, IsActive: true}
+	repo := &fakeAuthRepository{admins: map[uuid.UUID]*models.Admin{admin.ID: admin}}
+	cache := NewPermissionCache(repo)
+
+	access, err := cache.ResolveAdminAccess(ctx, admin.ID.String())
+	if err != nil || !access.HasPermission("produk:read") {
+		t.Fatalf("permission awal tidak ter-resolve: %+v (%v)", access, err)
+	}
+
+	// Role diedit: tanpa invalidasi cache masih memakai data lama
+	role.Permissions = append(role.Permissions, models.Permission{Kode: "produk:manage"})
+	access, _ = cache.ResolveAdminAccess(ctx, admin.ID.String())
+	if access.HasPermission("produk:manage") {
+		t.Fatal("sebelum invalidasi seharusnya masih dari cache")
+	}
+
+	cache.InvalidateRole(role.ID)
+	access, _ = cache.ResolveAdminAccess(ctx, admin.ID.String())
+	if !access.HasPermission("produk:manage") {
+		t.Fatal("setelah InvalidateRole permission baru harus langsung berlaku")
+	}
+
+	// Role dinonaktifkan = tanpa permission
+	role.IsActive = false
+	cache.InvalidateRole(role.ID)
+	access, _ = cache.ResolveAdminAccess(ctx, admin.ID.String())
+	if len(access.Permissions) != 0 {
+		t.Fatalf("role nonaktif tidak boleh punya permission, got %v", access.Permissions)
+	}
+
+	// Admin dinonaktifkan
+	admin.IsActive = false
+	cache.InvalidateAdmin(admin.ID)
+	if _, err := cache.ResolveAdminAccess(ctx, admin.ID.String()); err != ErrAdminAccessDenied {
+		t.Fatalf("admin nonaktif harus ditolak, got %v", err)
+	}
+}
@@ -3,14 +3,18 @@ package services
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"project-bulky-be/internal/config"
 	"project-bulky-be/internal/dto"
 	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/orderstate"
 	"project-bulky-be/internal/repositories"
+	"project-bulky-be/pkg/utils"
 	"strings"
 	"time"
 
@@ -25,9 +29,15 @@ type PesananAdminService interface {
 	UpdateStatus(ctx context.Context, id uuid.UUID, req *dto.UpdatePesananStatusRequest, adminID uuid.UUID) (*dto.UpdatePesananStatusResponse, error)
 	CancelOrder(ctx context.Context, id uuid.UUID, req *dto.CancelPesananRequest, adminID uuid.UUID) (*dto.CancelPesananResponse, error)
 	RetryBooking(ctx context.Context, id uuid.UUID) (*dto.RetryBookingResponse, error)
-	TrackDelivery(ctx context.Context, id uuid.UUID) (*TrackingResult, error)
-	GetDelivereeDetail(ctx context.Context, id uuid.UUID) (*DelivereeDeliveryDetail, error)
+	CancelShipment(ctx context.Context, id uuid.UUID, req *dto.CancelShipmentRequest, adminID uuid.UUID) (*dto.ShipmentActionResponse, error)
+	RebookShipment(ctx context.Context, id uuid.UUID, req *dto.RebookShipmentRequest, adminID uuid.UUID) (*dto.ShipmentActionResponse, error)
+	TrackDelivery(ctx context.Context, id uuid.UUID) (*PesananTracking, error)
+	GetDelivereeDetail(ctx context.Context, id uuid.UUID, pengirimanID *uuid.UUID) (*DelivereeDeliveryDetail, error)
 	GetForwarderInvoice(ctx context.Context, id uuid.UUID) ([]ForwarderInvoice, error)
+	// GetProofOfDelivery mengembalikan bukti pengiriman Deliveree yang tersimpan
+	GetProofOfDelivery(ctx context.Context, id uuid.UUID) (*dto.ProofOfDeliveryResponse, error)
+	// CaptureProofOfDelivery mengambil ulang bukti pengiriman dari Deliveree
+	CaptureProofOfDelivery(ctx context.Context, id uuid.UUID) (*dto.ProofOfDeliveryResponse, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetStatistics(ctx context.Context, params *dto.StatisticsQueryParams) (*dto.PesananStatisticsResponse, error)
 	CountPaidNotProcessed(ctx context.Context) (int64, error)
@@ -36,17 +46,39 @@ type PesananAdminService interface {
 type pesananAdminService struct {
 	pesananRepo     repositories.PesananRepository
 	shippingService ShippingService
+	orderMachine    *orderstate.Machine
+	outboxService   OutboxService
+	jobQueue        JobQueueService
 	db              *gorm.DB
 	cfg             *config.Config
+	httpClient      *http.Client
 }
 
-func NewPesananAdminService(pesananRepo repositories.PesananRepository, shippingService ShippingService, db *gorm.DB, cfg *config.Config) PesananAdminService {
-	return &pesananAdminService{
+// NewPesananAdminService juga mendaftarkan hook status READY & COMPLETED ke
+// orderMachine serta handler job booking, bukti pengiriman & outbox notifikasi
+// storefront, jadi cukup dibuat sekali.
+func NewPesananAdminService(pesananRepo repositories.PesananRepository, shippingService ShippingService, orderMachine *orderstate.Machine, outboxService OutboxService, jobQueue JobQueueService, db *gorm.DB, cfg *config.Config) PesananAdminService {
+	s := &pesananAdminService{
 		pesananRepo:     pesananRepo,
 		shippingService: shippingService,
+		orderMachine:    orderMachine,
+		outboxService:   outboxService,
+		jobQueue:        jobQueue,
 		db:              db,
 		cfg:             cfg,
+		httpClient:      &http.Client{Timeout: 15 * time.Second},
 	}
+	orderMachine.OnEnterTx(models.OrderStatusReady, s.enqueueReadySideEffects)
+	orderMachine.OnEnterTx(models.OrderStatusCompleted, s.enqueueProofOfDelivery)
+	jobQueue.RegisterHandler(models.JobTypeShippingBooking, s.handleShippingBooking, JobHandlerOptions{
+		Concurrency: 2,
+		Timeout:     2 * time.Minute,
+	})
+	jobQueue.RegisterHandler(models.JobTypeShippingProofOfDelivery, s.handleProofOfDelivery, JobHandlerOptions{
+		Timeout: 2 * time.Minute,
+	})
+	outboxService.RegisterHandler(models.OutboxEventStorefrontSetReady, s.handleStorefrontSetReady)
+	return s
 }
 
 func (s *pesananAdminService) GetAll(ctx context.Context, params *dto.PesananAdminQueryParams) ([]dto.PesananAdminListResponse, *models.PaginationMeta, error) {
@@ -110,7 +142,27 @@ func (s *pesananAdminService) GetByID(ctx context.Context, id uuid.UUID) (*dto.P
 		return nil, err
 	}
 
-	return s.mapToDetailResponse(pesanan, statusHistory), nil
+	var refunds []models.PesananRefund
+	if err := s.db.Preload("Buyer").Where("pesanan_id = ?", id).Order("created_at ASC").Find(&refunds).Error; err != nil {
+		return nil, err
+	}
+
+	resp := s.mapToDetailResponse(pesanan, statusHistory)
+	resp.Refund = make([]dto.RefundResponse, 0, len(refunds))
+	for i := range refunds {
+		refunds[i].Pesanan = pesanan
+		resp.Refund = append(resp.Refund, mapRefundResponse(&refunds[i]))
+	}
+	resp.Pajak = dto.PesananAdminPajakResponse{
+		NPWP:              pesanan.NPWPSnapshot,
+		NamaPerusahaan:    pesanan.NamaPerusahaanSnapshot,
+		AlamatPajak:       pesanan.AlamatPajakSnapshot,
+		EFakturStatus:     string(pesanan.EFakturStatus),
+		EFakturNomor:      pesanan.EFakturNomor,
+		EFakturNomorAt:    pesanan.EFakturNomorAt,
+		EFakturUploadedAt: pesanan.EFakturUploadedAt,
+	}
+	return resp, nil
 }
 
 func (s *pesananAdminService) UpdateStatus(ctx context.Context, id uuid.UUID, req *dto.UpdatePesananStatusRequest, adminID uuid.UUID) (*dto.UpdatePesananStatusResponse, error) {
@@ -123,33 +175,17 @@ func (s *pesananAdminService) UpdateStatus(ctx context.Context, id uuid.UUID, re
 		return nil, err
 	}
 
-	previousStatus := string(pesanan.OrderStatus)
-
-	// Update status
-	orderStatus := models.OrderStatus(req.OrderStatus)
-
-	// PICKUP tidak punya status SHIPPED — buyer ambil sendiri ke gudang
-	if pesanan.DeliveryType == models.DeliveryTypePickup && orderStatus == models.OrderStatusShipped {
-		return nil, errors.New("pesanan tipe PICKUP tidak memiliki status SHIPPED")
-	}
-
-	if err := s.pesananRepo.UpdateStatus(id, orderStatus, req.Note, adminID); err != nil {
+	result, err := s.orderMachine.Transition(ctx, orderstate.Request{
+		PesananID: pesanan.ID,
+		To:        models.OrderStatus(req.OrderStatus),
+		Trigger:   orderstate.TriggerAdmin,
+		ChangedBy: &adminID,
+		Note:      req.Note,
+	})
+	if err != nil {
 		return nil, err
 	}
-
-	// Trigger booking async when status → READY for DELIVEREE/FORWARDER/FORWARDER_LCL
-	if orderStatus == models.OrderStatusReady &&
-		(pesanan.DeliveryType == models.DeliveryTypeDeliveree ||
-			pesanan.DeliveryType == models.DeliveryTypeForwarder ||
-			pesanan.DeliveryType == models.DeliveryTypeForwarderLCL) {
-		s.shippingService.TriggerBookingAsync(pesanan)
-	}
-
-	// Notifikasi WA ke buyer PICKUP saat pesanan siap diambil — dipanggil ke
-	// endpoint internal storefront BE, best-effort (tidak menggagalkan update status ini).
-	if orderStatus == models.OrderStatusReady && pesanan.DeliveryType == models.DeliveryTypePickup {
-		go s.notifyStorefrontSetReady(pesanan.Kode)
-	}
+	previousStatus := string(result.From)
 
 	return &dto.UpdatePesananStatusResponse{
 		ID:             id,
@@ -171,12 +207,44 @@ func (s *pesananAdminService) CancelOrder(ctx context.Context, id uuid.UUID, req
 		return nil, err
 	}
 
-	previousStatus := string(pesanan.OrderStatus)
+	if pesanan.OrderStatus == models.OrderStatusCancelled {
+		return nil, errors.New("pesanan sudah berstatus CANCELLED")
+	}
+	if err := orderstate.Check(pesanan, models.OrderStatusCancelled, orderstate.TriggerAdmin); err != nil {
+		return nil, err
+	}
+
+	// Batalkan booking di provider dulu agar pesanan batal tidak meninggalkan
+	// booking aktif yang tetap ditagih. Pengiriman yang sudah dibawa driver
+	// tidak bisa dibatalkan dan dibiarkan.
+	cancelledShipments := 0
+	if pesanan.DeliveryType != models.DeliveryTypePickup {
+		bookedBefore := countBookedShipments(pesanan)
+		shipments, err := s.shippingService.CancelShipments(ctx, pesanan, CancelShipmentOptions{
+			Reason:    req.Reason,
+			ChangedBy: &adminID,
+		})
+		if err != nil {
+			return nil, shipmentActionError(err)
+		}
+		cancelledShipments = bookedBefore - countBookedShipments(&models.Pesanan{Pengiriman: shipments})
+	}
 
-	// Jalankan cancel dalam satu transaksi (update status + restore is_sold)
-	if err := s.pesananRepo.CancelOrder(id, req.Reason, adminID); err != nil {
+	// Restore is_sold produk dijalankan state machine dalam transaksi yang sama
+	result, err := s.orderMachine.Transition(ctx, orderstate.Request{
+		PesananID: pesanan.ID,
+		To:        models.OrderStatusCancelled,
+		Trigger:   orderstate.TriggerAdmin,
+		ChangedBy: &adminID,
+		Note:      req.Reason,
+	})
+	if err != nil {
 		return nil, err
 	}
+	if !result.Changed {
+		return nil, errors.New("pesanan sudah berstatus CANCELLED")
+	}
+	previousStatus := string(result.From)
 
 	return &dto.CancelPesananResponse{
 		ID:              id,
@@ -187,9 +255,119 @@ func (s *pesananAdminService) CancelOrder(ctx context.Context, id uuid.UUID, req
 		RestoredProduk:  len(pesanan.Items),
 		CancelledAt:     time.Now().UTC(),
 		CancelledBy:     adminID,
+
+		CancelledShipments: cancelledShipments,
+	}, nil
+}
+
+// CancelShipment membatalkan booking pengiriman di provider tanpa membatalkan
+// pesanan (mis. driver tidak datang). Pengiriman bisa di-booking lagi lewat
+// retry-booking atau rebook-shipment.
+func (s *pesananAdminService) CancelShipment(ctx context.Context, id uuid.UUID, req *dto.CancelShipmentRequest, adminID uuid.UUID) (*dto.ShipmentActionResponse, error) {
+	pesanan, err := s.findShippablePesanan(id)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := CancelShipmentOptions{Reason: req.Reason, ChangedBy: &adminID}
+	if req.PengirimanID != nil {
+		pengirimanID, err := uuid.Parse(*req.PengirimanID)
+		if err != nil {
+			return nil, errors.New("shipment:bad_request:pengiriman_id tidak valid")
+		}
+		opts.PengirimanID = &pengirimanID
+	}
+	if opts.PengirimanID == nil && countBookedShipments(pesanan) == 0 {
+		return nil, errors.New("shipment:bad_request:Pesanan belum memiliki booking pengiriman")
+	}
+
+	shipments, err := s.shippingService.CancelShipments(ctx, pesanan, opts)
+	if err != nil {
+		return nil, shipmentActionError(err)
+	}
+	return &dto.ShipmentActionResponse{
+		PesananID: pesanan.ID,
+		Kode:      pesanan.Kode,
+		Shipments: mapShipmentResponses(shipments),
+	}, nil
+}
+
+// RebookShipment membooking ulang satu pengiriman dengan kendaraan Deliveree
+// atau jalur Forwarder (LTL/LCL) pengganti. Booking lama dibatalkan dulu.
+func (s *pesananAdminService) RebookShipment(ctx context.Context, id uuid.UUID, req *dto.RebookShipmentRequest, adminID uuid.UUID) (*dto.ShipmentActionResponse, error) {
+	pesanan, err := s.findShippablePesanan(id)
+	if err != nil {
+		return nil, err
+	}
+	pengirimanID, err := uuid.Parse(req.PengirimanID)
+	if err != nil {
+		return nil, errors.New("shipment:bad_request:pengiriman_id tidak valid")
+	}
+
+	opts := RebookShipmentOptions{
+		PengirimanID:  pengirimanID,
+		VehicleTypeID: req.VehicleTypeID,
+		Reason:        req.Reason,
+		ChangedBy:     &adminID,
+	}
+	if req.DeliveryType != nil {
+		deliveryType := models.DeliveryType(*req.DeliveryType)
+		opts.DeliveryType = &deliveryType
+	}
+
+	if _, err := s.shippingService.RebookShipment(ctx, pesanan, opts); err != nil {
+		return nil, shipmentActionError(err)
+	}
+	shipments, err := s.shippingService.Shipments(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ShipmentActionResponse{
+		PesananID: pesanan.ID,
+		Kode:      pesanan.Kode,
+		Shipments: mapShipmentResponses(shipments),
 	}, nil
 }
 
+// findShippablePesanan memuat pesanan delivery yang pengirimannya masih boleh
+// diubah admin (PROCESSING, READY, SHIPPED)
+func (s *pesananAdminService) findShippablePesanan(id uuid.UUID) (*models.Pesanan, error) {
+	pesanan, err := s.pesananRepo.AdminFindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("pesanan tidak ditemukan")
+		}
+		return nil, err
+	}
+	if pesanan.DeliveryType == models.DeliveryTypePickup {
+		return nil, errors.New("shipment:bad_request:Pesanan tipe PICKUP tidak memiliki booking pengiriman")
+	}
+	if pesanan.OrderStatus != models.OrderStatusProcessing &&
+		pesanan.OrderStatus != models.OrderStatusShipped &&
+		pesanan.OrderStatus != models.OrderStatusReady {
+		return nil, errors.New("shipment:bad_request:Pengiriman hanya bisa diubah pada pesanan berstatus PROCESSING, READY, atau SHIPPED")
+	}
+	return pesanan, nil
+}
+
+// shipmentActionError memberi prefix error aksi pengiriman untuk controller:
+// in_progress (lock booking dipegang proses lain), provider_error (API provider
+// gagal), bad_request (validasi), selain itu internal_error.
+func shipmentActionError(err error) error {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, ErrShipmentBusy):
+		return errors.New("shipment:in_progress:" + msg)
+	case strings.HasPrefix(msg, "gagal membatalkan booking"), strings.HasPrefix(msg, "booking ulang gagal"):
+		return errors.New("shipment:provider_error:" + msg)
+	case strings.HasPrefix(msg, "pengiriman "), strings.HasPrefix(msg, "pesanan tidak memiliki"),
+		strings.HasPrefix(msg, "jalur "), strings.HasPrefix(msg, "kendaraan "):
+		return errors.New("shipment:bad_request:" + msg)
+	default:
+		return errors.New("shipment:internal_error:" + msg)
+	}
+}
+
 func (s *pesananAdminService) RetryBooking(ctx context.Context, id uuid.UUID) (*dto.RetryBookingResponse, error) {
 	pesanan, err := s.pesananRepo.AdminFindByID(id)
 	if err != nil {
@@ -208,20 +386,26 @@ func (s *pesananAdminService) RetryBooking(ctx context.Context, id uuid.UUID) (*
 		return nil, errors.New("retry:bad_request:Retry hanya bisa dilakukan pada pesanan berstatus PROCESSING, READY, atau SHIPPED.")
 	}
 
-	// Already booked — Forwarder: block retry (tracking no is permanent)
-	if (pesanan.DeliveryType == models.DeliveryTypeForwarder || pesanan.DeliveryType == models.DeliveryTypeForwarderLCL) && pesanan.ForwarderTrackingNo != nil {
-		return nil, errors.New("retry:already_booked:" + *pesanan.ForwarderTrackingNo)
+	// Already booked — Forwarder: block retry jika semua pengiriman sudah punya
+	// booking number (tracking no is permanent). Pengiriman gudang lain yang
+	// gagal tetap bisa di-retry.
+	if (pesanan.DeliveryType == models.DeliveryTypeForwarder || pesanan.DeliveryType == models.DeliveryTypeForwarderLCL) && allShipmentsBooked(pesanan) {
+		return nil, errors.New("retry:already_booked:" + derefString(primaryBookingRef(pesanan)))
 	}
 
 	// Deliveree: allow retry even if booking_id exists (e.g. cancelled by provider)
-	// Clear old booking data so booking can be re-created during retry
-	if pesanan.DeliveryType == models.DeliveryTypeDeliveree && pesanan.DelivereeBookingID != nil {
+	// Clear old booking data so booking can be re-created during retry. Pengiriman
+	// yang sudah delivery_in_progress/completed tidak ikut di-booking ulang.
+	if pesanan.DeliveryType == models.DeliveryTypeDeliveree && allShipmentsBooked(pesanan) {
+		if _, err := s.shippingService.ResetBookings(ctx, id); err != nil {
+			return nil, err
+		}
 		if err := s.pesananRepo.ClearBookingResult(id); err != nil {
 			return nil, err
 		}
 	}
 
-	// Atomic claim: cegah tabrakan dengan TriggerBookingAsync (READY) yang masih
+	// Atomic claim: cegah tabrakan dengan job booking (READY) yang masih
 	// berjalan. Hanya satu proses yang boleh memanggil API provider untuk pesanan ini.
 	claimed, err := s.shippingService.ClaimBooking(id)
 	if err != nil {
@@ -231,13 +415,12 @@ func (s *pesananAdminService) RetryBooking(ctx context.Context, id uuid.UUID) (*
 		return nil, errors.New("retry:in_progress:Booking sedang diproses oleh proses lain. Silakan coba lagi beberapa saat lagi.")
 	}
 
-	// Run synchronous booking
-	delivereeID, trackingNo, bookErr := s.shippingService.BookDelivery(ctx, pesanan)
+	// Run synchronous booking; hasil per pengiriman disimpan oleh BookShipments
+	shipments, bookErr := s.shippingService.BookShipments(ctx, pesanan)
 	if bookErr != nil {
 		errMsg := bookErr.Error()
-		_ = s.pesananRepo.UpdateBookingResult(id, nil, nil, &errMsg)
 
-		// Re-fetch delivery_type — BookDelivery may have routed FORWARDER → FORWARDER_LCL
+		// Re-fetch delivery_type — BookShipments may have routed FORWARDER → FORWARDER_LCL
 		// before failing, so use the actual type for accurate error reporting.
 		actualDeliveryType := string(pesanan.DeliveryType)
 		var routedPesanan models.Pesanan
@@ -252,9 +435,7 @@ func (s *pesananAdminService) RetryBooking(ctx context.Context, id uuid.UUID) (*
 		return nil, errors.New("retry:provider_error:" + actualDeliveryType + ":" + errMsg)
 	}
 
-	_ = s.pesananRepo.UpdateBookingResult(id, delivereeID, trackingNo, nil)
-
-	// Re-fetch delivery_type from DB — bookForwarderLCL may have updated it
+	// Re-fetch delivery_type from DB — routing may have updated it
 	// from FORWARDER → FORWARDER_LCL during booking.
 	finalDeliveryType := string(pesanan.DeliveryType)
 	var updatedPesanan models.Pesanan
@@ -262,15 +443,22 @@ func (s *pesananAdminService) RetryBooking(ctx context.Context, id uuid.UUID) (*
 		finalDeliveryType = string(updatedPesanan.DeliveryType)
 	}
 
-	return &dto.RetryBookingResponse{
+	response := &dto.RetryBookingResponse{
 		PesananID:    id.String(),
 		DeliveryType: finalDeliveryType,
-		BookingID:    delivereeID,
-		TrackingNo:   trackingNo,
-	}, nil
+		Shipments:    mapShipmentResponses(shipments),
+	}
+	if len(shipments) > 0 {
+		if shipments[0].DeliveryType == models.DeliveryTypeDeliveree {
+			response.BookingID = shipments[0].BookingRef
+		} else {
+			response.TrackingNo = shipments[0].BookingRef
+		}
+	}
+	return response, nil
 }
 
-func (s *pesananAdminService) TrackDelivery(ctx context.Context, id uuid.UUID) (*TrackingResult, error) {
+func (s *pesananAdminService) TrackDelivery(ctx context.Context, id uuid.UUID) (*PesananTracking, error) {
 	pesanan, err := s.pesananRepo.AdminFindByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -286,7 +474,7 @@ func (s *pesananAdminService) TrackDelivery(ctx context.Context, id uuid.UUID) (
 	return s.shippingService.TrackDelivery(ctx, pesanan)
 }
 
-func (s *pesananAdminService) GetDelivereeDetail(ctx context.Context, id uuid.UUID) (*DelivereeDeliveryDetail, error) {
+func (s *pesananAdminService) GetDelivereeDetail(ctx context.Context, id uuid.UUID, pengirimanID *uuid.UUID) (*DelivereeDeliveryDetail, error) {
 	pesanan, err := s.pesananRepo.AdminFindByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -299,7 +487,7 @@ func (s *pesananAdminService) GetDelivereeDetail(ctx context.Context, id uuid.UU
 		return nil, errors.New("deliveree:not_applicable:Pesanan ini tidak menggunakan layanan Deliveree")
 	}
 
-	return s.shippingService.GetDelivereeDetail(ctx, pesanan)
+	return s.shippingService.GetDelivereeDetail(ctx, pesanan, pengirimanID)
 }
 
 func (s *pesananAdminService) GetForwarderInvoice(ctx context.Context, id uuid.UUID) ([]ForwarderInvoice, error) {
@@ -315,11 +503,25 @@ func (s *pesananAdminService) GetForwarderInvoice(ctx context.Context, id uuid.U
 		return nil, errors.New("invoice:not_applicable:Pesanan ini tidak menggunakan layanan Forwarder")
 	}
 
-	if pesanan.ForwarderTrackingNo == nil || *pesanan.ForwarderTrackingNo == "" {
+	// Invoice Forwarder diterbitkan per booking; gabungkan semua pengiriman
+	invoices := []ForwarderInvoice{}
+	booked := 0
+	for _, shipment := range pesanan.Pengiriman {
+		if !shipment.IsBooked() {
+			continue
+		}
+		booked++
+		result, err := s.shippingService.GetForwarderInvoice(ctx, *shipment.BookingRef)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, result...)
+	}
+	if booked == 0 {
 		return nil, errors.New("invoice:not_applicable:Pesanan belum memiliki booking number Forwarder")
 	}
 
-	return s.shippingService.GetForwarderInvoice(ctx, *pesanan.ForwarderTrackingNo)
+	return invoices, nil
 }
 
 func (s *pesananAdminService) Delete(ctx context.Context, id uuid.UUID) error {
@@ -432,33 +634,97 @@ func (s *pesananAdminService) CountPaidNotProcessed(ctx context.Context) (int64,
 	return s.pesananRepo.CountPaidNotProcessed()
 }
 
-// notifyStorefrontSetReady memanggil endpoint internal storefront BE agar buyer
-// PICKUP menerima notifikasi WA saat pesanan siap diambil. Best-effort: kegagalan
-// hanya di-log, tidak mempengaruhi update order_status yang sudah tersimpan.
-func (s *pesananAdminService) notifyStorefrontSetReady(kode string) {
+// pesananOutboxPayload adalah payload pesan outbox & job milik pesanan
+type pesananOutboxPayload struct {
+	PesananID uuid.UUID `json:"pesanan_id"`
+	Kode      string    `json:"kode"`
+}
+
+// enqueueReadySideEffects dijalankan dalam transaksi saat pesanan masuk READY:
+// pesanan yang dikirim provider dijadwalkan job booking, pesanan PICKUP
+// dijadwalkan notifikasi WA ke buyer lewat storefront BE (outbox).
+func (s *pesananAdminService) enqueueReadySideEffects(tx *gorm.DB, pesanan *models.Pesanan, from models.OrderStatus, trigger orderstate.Trigger) error {
+	payload := pesananOutboxPayload{PesananID: pesanan.ID, Kode: pesanan.Kode}
+	switch pesanan.DeliveryType {
+	case models.DeliveryTypeDeliveree, models.DeliveryTypeForwarder, models.DeliveryTypeForwarderLCL:
+		return s.jobQueue.Enqueue(tx.Statement.Context, tx, models.JobTypeShippingBooking, payload, EnqueueOptions{})
+	case models.DeliveryTypePickup:
+		return s.outboxService.Enqueue(tx.Statement.Context, tx, models.OutboxEventStorefrontSetReady, "pesanan", &pesanan.ID, payload)
+	}
+	return nil
+}
+
+// handleShippingBooking adalah handler job models.JobTypeShippingBooking
+func (s *pesananAdminService) handleShippingBooking(ctx context.Context, job *models.BackgroundJob) error {
+	var payload pesananOutboxPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return err
+	}
+	// Booking butuh relasi (alamat, item) yang tidak dimuat state machine
+	pesanan, err := s.pesananRepo.AdminFindByID(payload.PesananID)
+	if err != nil {
+		return err
+	}
+	// Pesanan sudah dibatalkan sebelum dispatcher sempat jalan
+	if pesanan.OrderStatus == models.OrderStatusCancelled {
+		return nil
+	}
+	return s.shippingService.BookAndRecord(ctx, pesanan)
+}
+
+// enqueueProofOfDelivery menjadwalkan penyimpanan bukti pengiriman saat
+// pesanan Deliveree COMPLETED (biasanya lewat webhook delivery_completed).
+// Detail Deliveree tidak diambil di dalam transaksi webhook.
+func (s *pesananAdminService) enqueueProofOfDelivery(tx *gorm.DB, pesanan *models.Pesanan, from models.OrderStatus, trigger orderstate.Trigger) error {
+	if pesanan.DeliveryType != models.DeliveryTypeDeliveree {
+		return nil
+	}
+	payload := pesananOutboxPayload{PesananID: pesanan.ID, Kode: pesanan.Kode}
+	return s.jobQueue.Enqueue(tx.Statement.Context, tx, models.JobTypeShippingProofOfDelivery, payload, EnqueueOptions{
+		UniqueKey: models.JobTypeShippingProofOfDelivery + ":" + pesanan.ID.String(),
+	})
+}
+
+// handleProofOfDelivery adalah handler job models.JobTypeShippingProofOfDelivery
+func (s *pesananAdminService) handleProofOfDelivery(ctx context.Context, job *models.BackgroundJob) error {
+	var payload pesananOutboxPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return err
+	}
+	_, err := s.shippingService.CaptureProofOfDelivery(ctx, &models.Pesanan{ID: payload.PesananID, Kode: payload.Kode})
+	return err
+}
+
+// handleStorefrontSetReady memanggil endpoint internal storefront BE agar buyer
+// PICKUP menerima notifikasi WA saat pesanan siap diambil. Error dikembalikan
+// agar dispatcher outbox mencoba ulang.
+func (s *pesananAdminService) handleStorefrontSetReady(ctx context.Context, msg *models.OutboxMessage) error {
+	var payload pesananOutboxPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return err
+	}
 	if s.cfg == nil || s.cfg.StorefrontBaseURL == "" || s.cfg.InternalAPIKey == "" {
-		log.Printf("[pesanan] lewati notifikasi set-ready storefront: kode=%s STOREFRONT_BASE_URL/INTERNAL_API_KEY belum dikonfigurasi", kode)
-		return
+		log.Printf("[pesanan] lewati notifikasi set-ready storefront: kode=%s STOREFRONT_BASE_URL/INTERNAL_API_KEY belum dikonfigurasi", payload.Kode)
+		return nil
 	}
 
-	url := strings.TrimRight(s.cfg.StorefrontBaseURL, "/") + "/internal/pesanan/" + kode + "/set-ready"
-	httpReq, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(nil))
+	url := strings.TrimRight(s.cfg.StorefrontBaseURL, "/") + "/internal/pesanan/" + payload.Kode + "/set-ready"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(nil))
 	if err != nil {
-		log.Printf("[pesanan] gagal membuat request notifikasi set-ready: kode=%s err=%v", kode, err)
-		return
+		return err
 	}
 	httpReq.Header.Set("X-Internal-Key", s.cfg.InternalAPIKey)
 
-	resp, err := http.DefaultClient.Do(httpReq)
+	resp, err := s.httpClient.Do(httpReq)
 	if err != nil {
-		log.Printf("[pesanan] gagal memanggil notifikasi set-ready storefront: kode=%s err=%v", kode, err)
-		return
+		return fmt.Errorf("gagal memanggil storefront: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("[pesanan] notifikasi set-ready storefront gagal: kode=%s status=%d", kode, resp.StatusCode)
+		return fmt.Errorf("storefront mengembalikan status %d", resp.StatusCode)
 	}
+	return nil
 }
 
 // derefString mengembalikan nilai string dari pointer, atau string kosong bila nil.
@@ -475,16 +741,18 @@ func buildShippingInfo(p *models.Pesanan) dto.PesananShippingInfo {
 		BookingID:    p.DelivereeBookingID,
 		TrackingNo:   p.ForwarderTrackingNo,
 		BookingError: p.BookingError,
+		Shipments:    mapShipmentResponses(p.Pengiriman),
 	}
 
+	booked := allShipmentsBooked(p)
 	switch {
 	case p.DeliveryType == models.DeliveryTypePickup:
 		info.BookingStatus = "NOT_APPLICABLE"
 	case p.BookingError != nil:
 		info.BookingStatus = "FAILED"
-	case p.OrderStatus == models.OrderStatusReady && p.DelivereeBookingID == nil && p.ForwarderTrackingNo == nil:
+	case p.OrderStatus == models.OrderStatusReady && !booked:
 		info.BookingStatus = "IN_PROGRESS"
-	case p.DelivereeBookingID != nil || p.ForwarderTrackingNo != nil:
+	case booked:
 		info.BookingStatus = "BOOKED"
 	default:
 		info.BookingStatus = "PENDING"
@@ -493,6 +761,153 @@ func buildShippingInfo(p *models.Pesanan) dto.PesananShippingInfo {
 	return info
 }
 
+// allShipmentsBooked mengembalikan true jika semua pengiriman pesanan sudah
+// punya booking ref. Pesanan tanpa data pengiriman memakai kolom booking lama.
+func allShipmentsBooked(p *models.Pesanan) bool {
+	if len(p.Pengiriman) == 0 {
+		return p.DelivereeBookingID != nil || p.ForwarderTrackingNo != nil
+	}
+	for _, shipment := range p.Pengiriman {
+		if !shipment.IsBooked() {
+			return false
+		}
+	}
+	return true
+}
+
+// countBookedShipments menghitung pengiriman pesanan yang punya booking ref.
+// Pesanan tanpa data pengiriman memakai kolom booking lama.
+func countBookedShipments(p *models.Pesanan) int {
+	if len(p.Pengiriman) == 0 {
+		if p.DelivereeBookingID != nil || p.ForwarderTrackingNo != nil {
+			return 1
+		}
+		return 0
+	}
+	count := 0
+	for _, shipment := range p.Pengiriman {
+		if shipment.IsBooked() {
+			count++
+		}
+	}
+	return count
+}
+
+// primaryBookingRef mengembalikan booking ref pengiriman utama
+func primaryBookingRef(p *models.Pesanan) *string {
+	if len(p.Pengiriman) > 0 {
+		return p.Pengiriman[0].BookingRef
+	}
+	if p.DelivereeBookingID != nil {
+		return p.DelivereeBookingID
+	}
+	return p.ForwarderTrackingNo
+}
+
+func (s *pesananAdminService) GetProofOfDelivery(ctx context.Context, id uuid.UUID) (*dto.ProofOfDeliveryResponse, error) {
+	pesanan, err := s.findDelivereePesanan(id)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.shippingService.ProofOfDelivery(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.proofOfDeliveryResponse(pesanan, list), nil
+}
+
+func (s *pesananAdminService) CaptureProofOfDelivery(ctx context.Context, id uuid.UUID) (*dto.ProofOfDeliveryResponse, error) {
+	pesanan, err := s.findDelivereePesanan(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.shippingService.CaptureProofOfDelivery(ctx, pesanan); err != nil {
+		return nil, err
+	}
+	// Kembalikan semua bukti tersimpan, termasuk booking lama sebelum booking ulang
+	list, err := s.shippingService.ProofOfDelivery(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.proofOfDeliveryResponse(pesanan, list), nil
+}
+
+func (s *pesananAdminService) findDelivereePesanan(id uuid.UUID) (*models.Pesanan, error) {
+	pesanan, err := s.pesananRepo.AdminFindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("pesanan tidak ditemukan")
+		}
+		return nil, err
+	}
+	if pesanan.DeliveryType != models.DeliveryTypeDeliveree {
+		return nil, errors.New("deliveree:not_applicable:Pesanan ini tidak menggunakan layanan Deliveree")
+	}
+	return pesanan, nil
+}
+
+func (s *pesananAdminService) proofOfDeliveryResponse(pesanan *models.Pesanan, list []models.PesananBuktiPengiriman) *dto.ProofOfDeliveryResponse {
+	result := &dto.ProofOfDeliveryResponse{
+		PesananID: pesanan.ID,
+		Kode:      pesanan.Kode,
+		Bukti:     make([]dto.BuktiPengirimanResponse, len(list)),
+	}
+	for i, bukti := range list {
+		result.Bukti[i] = dto.BuktiPengirimanResponse{
+			ID:                   bukti.ID,
+			PesananPengirimanID:  bukti.PesananPengirimanID,
+			BookingRef:           bukti.BookingRef,
+			DeliveryStatus:       bukti.DeliveryStatus,
+			RecipientName:        bukti.RecipientName,
+			RecipientPhone:       bukti.RecipientPhone,
+			FailedDeliveryReason: bukti.FailedDeliveryReason,
+			SignatureURL:         utils.GetFileURLPtr(bukti.SignaturePath, s.cfg),
+			DriverName:           bukti.DriverName,
+			DriverPhone:          bukti.DriverPhone,
+			ArrivedAt:            bukti.ArrivedAt,
+			LeftAt:               bukti.LeftAt,
+			CompletedAt:          bukti.CompletedAt,
+			ParkingFees:          bukti.ParkingFees,
+			TollsFees:            bukti.TollsFees,
+			WaitingTimeFees:      bukti.WaitingTimeFees,
+			TotalFees:            bukti.TotalFees,
+			Currency:             bukti.Currency,
+			CapturedAt:           bukti.CapturedAt,
+		}
+	}
+	return result
+}
+
+func mapShipmentResponses(shipments []models.PesananPengiriman) []dto.PesananShipmentResponse {
+	result := make([]dto.PesananShipmentResponse, len(shipments))
+	for i, shipment := range shipments {
+		itemIDs := make([]uuid.UUID, len(shipment.Items))
+		for j, item := range shipment.Items {
+			itemIDs[j] = item.ID
+		}
+		result[i] = dto.PesananShipmentResponse{
+			ID:     shipment.ID,
+			Urutan: shipment.Urutan,
+			Warehouse: dto.PesananShipmentWarehouseResponse{
+				ID:   shipment.WarehouseID,
+				Nama: shipment.Warehouse.Nama,
+				Kota: shipment.Warehouse.Kota,
+			},
+			DeliveryType:  string(shipment.DeliveryType),
+			BookingRef:    shipment.BookingRef,
+			BookingStatus: shipment.BookingStatus,
+			BookingError:  shipment.BookingError,
+			TrackingURL:   shipment.TrackingURL,
+			BookedAt:      shipment.BookedAt,
+			ShippedAt:     shipment.ShippedAt,
+			CompletedAt:   shipment.CompletedAt,
+			ItemIDs:       itemIDs,
+			RencanaMuat:   shipment.RencanaMuat,
+		}
+	}
+	return result
+}
+
 // buildChartData fills all periods in range with data (zero for missing periods)
 func buildChartData(rawPoints []models.ChartRawPoint, dari, sampai time.Time, groupBy string, isWeekFilter bool) []dto.ChartDataPoint {
 	dataMap := make(map[string]int64)
@@ -614,6 +1029,7 @@ func (s *pesananAdminService) mapToDetailResponse(p *models.Pesanan, statusHisto
 			HargaSatuan:  item.HargaSatuan,
 			DiskonSatuan: item.DiskonSatuan,
 			Subtotal:     item.Subtotal,
+			PengirimanID: item.PesananPengirimanID,
 		}
 	}
 
@@ -633,7 +1049,10 @@ func (s *pesananAdminService) mapToDetailResponse(p *models.Pesanan, statusHisto
 				t := bayar.PaidAt.UTC()
 				return &t
 			}(),
-			XenditInvoiceID: bayar.XenditInvoiceID,
+			XenditInvoiceID:   bayar.XenditInvoiceID,
+			XenditPaymentURL:  bayar.XenditPaymentURL,
+			IsManual:          bayar.IsManual,
+			ReferensiTransfer: bayar.ReferensiTransfer,
 		}
 
 		if bayar.MetodePembayaran != nil {
@@ -689,6 +1108,10 @@ func (s *pesananAdminService) mapToDetailResponse(p *models.Pesanan, statusHisto
 		CatatanAdmin:    p.CatatanAdmin,
 		CreatedAt:       p.CreatedAt.UTC(),
 		UpdatedAt:       p.UpdatedAt.UTC(),
+
+		Sumber:               string(p.Sumber),
+		DibuatOleh:           p.DibuatOleh,
+		FormulirSubmissionID: p.FormulirSubmissionID,
 	}
 
 	// Map alamat if exists
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"project-bulky-be/internal/config"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/orderstate"
+	"project-bulky-be/internal/testutil/fakedb"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeOutbox hanya menerima pendaftaran handler; pesan tidak dikirim
+type fakeOutbox struct {
+	OutboxService
+	events []string
+}
+
+func (o *fakeOutbox) RegisterHandler(string, OutboxHandler) {}
+
+func (o *fakeOutbox) Enqueue(_ context.Context, _ *gorm.DB, eventType, _ string, _ *uuid.UUID, _ interface{}) error {
+	o.events = append(o.events, eventType)
+	return nil
+}
+
+// fakeProofShipping mencatat pesanan yang bukti pengirimannya diambil
+type fakeProofShipping struct {
+	ShippingService
+	captured   []models.Pesanan
+	captureErr error
+}
+
+func (s *fakeProofShipping) CaptureProofOfDelivery(_ context.Context, pesanan *models.Pesanan) ([]models.PesananBuktiPengiriman, error) {
+	s.captured = append(s.captured, *pesanan)
+	return nil, s.captureErr
+}
+
+func TestCompletedDelivereePesananCapturesProofOfDelivery(t *testing.T) {
+	cases := []struct {
+		deliveryType models.DeliveryType
+		from         models.OrderStatus
+		trigger      orderstate.Trigger
+		jobs         int
+	}{
+		{models.DeliveryTypeDeliveree, models.OrderStatusShipped, orderstate.TriggerWebhook, 1},
+		// Bukti pengiriman hanya tersedia dari detail Deliveree
+		{models.DeliveryTypePickup, models.OrderStatusReady, orderstate.TriggerAdmin, 0},
+	}
+	for _, tc := range cases {
+		pesanan := models.Pesanan{
+			ID: uuid.New(), Kode: "ORD-20261017-0005", BuyerID: uuid.New(),
+			OrderStatus: tc.from, PaymentStatus: models.PaymentStatusPaid, DeliveryType: tc.deliveryType,
+		}
+		db, _ := fakedb.OpenPesanan(t, pesanan, nil)
+		machine := orderstate.NewMachine(db)
+		jobQueue := &fakeJobQueue{}
+		shipping := &fakeProofShipping{}
+		s := NewPesananAdminService(nil, shipping, machine, &fakeOutbox{}, jobQueue, db, &config.Config{}).(*pesananAdminService)
+
+		if _, err := machine.Transition(context.Background(), orderstate.Request{PesananID: pesanan.ID, To: models.OrderStatusCompleted, Trigger: tc.trigger}); err != nil {
+			t.Fatalf("%s: transisi ke COMPLETED harus berhasil: %v", tc.deliveryType, err)
+		}
+		if len(jobQueue.jobs) != tc.jobs {
+			t.Fatalf("%s: harus %d job bukti pengiriman, got %v", tc.deliveryType, tc.jobs, jobQueue.jobs)
+		}
+		if tc.jobs == 0 {
+			continue
+		}
+		if jobQueue.jobs[0] != models.JobTypeShippingProofOfDelivery || !jobQueue.inTx[0] {
+			t.Fatalf("%s: job bukti pengiriman harus di-enqueue di transaksi status, got %v %v", tc.deliveryType, jobQueue.jobs, jobQueue.inTx)
+		}
+		// Detail Deliveree diambil di job, bukan di transaksi webhook
+		if len(shipping.captured) != 0 {
+			t.Fatalf("%s: bukti pengiriman tidak boleh diambil sebelum job berjalan", tc.deliveryType)
+		}
+
+		payload, _ := json.Marshal(jobQueue.payloads[0])
+		job := &models.BackgroundJob{JobType: models.JobTypeShippingProofOfDelivery, Payload: payload}
+		if err := s.handleProofOfDelivery(context.Background(), job); err != nil {
+			t.Fatalf("%s: job bukti pengiriman harus berhasil: %v", tc.deliveryType, err)
+		}
+		if len(shipping.captured) != 1 || shipping.captured[0].ID != pesanan.ID || shipping.captured[0].Kode != pesanan.Kode {
+			t.Fatalf("%s: job harus mengambil bukti pengiriman pesanan %s, got %+v", tc.deliveryType, pesanan.Kode, shipping.captured)
+		}
+
+		// Detail Deliveree belum tersedia: error dikembalikan agar job diulang
+		shipping.captureErr = errors.New("deliveree: booking belum selesai")
+		if err := s.handleProofOfDelivery(context.Background(), job); !errors.Is(err, shipping.captureErr) {
+			t.Fatalf("%s: kegagalan mengambil bukti pengiriman harus dikembalikan, got %v", tc.deliveryType, err)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/pkg/pdf"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Tata letak dokumen (point, A4 portrait)
+const (
+	dokumenMarginX   = 40.0
+	dokumenKananX    = pdf.PageWidth - dokumenMarginX
+	dokumenBatasY    = pdf.PageHeight - 70
+	dokumenBarisItem = 13.0
+)
+
+// Kolom tabel item: kiri kolom teks, kanan kolom angka
+var (
+	kolomNo       = dokumenMarginX + 4
+	kolomProduk   = dokumenMarginX + 26
+	lebarProduk   = 210.0
+	kolomQty      = dokumenMarginX + 275
+	kolomHarga    = dokumenMarginX + 355
+	kolomDiskon   = dokumenMarginX + 430
+	kolomSubtotal = dokumenKananX - 4
+)
+
+// renderDokumenPDF mencetak invoice/nota kredit dari snapshot. Output
+// deterministik untuk input yang sama; PDF yang tersimpan adalah sumber
+// kebenaran setelah dokumen terbit.
+func renderDokumenPDF(tipe models.DokumenTipe, nomor string, tanggal time.Time, snap *models.DokumenSnapshot) ([]byte, error) {
+	doc := pdf.New(nomor, tanggal)
+	r := &dokumenRenderer{doc: doc, tipe: tipe, nomor: nomor}
+	doc.AddPage()
+
+	y := r.header(tanggal, snap)
+	y = r.pihak(y, snap)
+	y = r.items(y, snap.Items)
+	y = r.ringkasan(y, snap)
+	y = r.pembayaran(y, snap)
+	if tipe == models.DokumenTipeCreditNote {
+		r.catatanNotaKredit(y, snap)
+	}
+	r.footer()
+
+	return doc.Bytes()
+}
+
+type dokumenRenderer struct {
+	doc   *pdf.Document
+	tipe  models.DokumenTipe
+	nomor string
+}
+
+func (r *dokumenRenderer) judul() string {
+	if r.tipe == models.DokumenTipeCreditNote {
+		return "NOTA KREDIT"
+	}
+	return "INVOICE"
+}
+
+func (r *dokumenRenderer) header(tanggal time.Time, snap *models.DokumenSnapshot) float64 {
+	d := r.doc
+	d.SetFont(pdf.HelveticaBold, 16)
+	d.Text(dokumenMarginX, 60, snap.Penjual.Nama)
+	d.SetFont(pdf.HelveticaBold, 18)
+	d.TextRight(dokumenKananX, 60, r.judul())
+
+	y := 76.0
+	d.SetFont(pdf.Helvetica, 9)
+	for _, line := range d.WrapText(snap.Penjual.Alamat, 260) {
+		if line != "" {
+			d.Text(dokumenMarginX, y, line)
+			y += 11
+		}
+	}
+	if snap.Penjual.NPWP != "" {
+		d.Text(dokumenMarginX, y, "NPWP: "+snap.Penjual.NPWP)
+		y += 11
+	}
+
+	kanan := 78.0
+	info := [][2]string{{"Nomor", r.nomor}, {"Tanggal", tanggal.In(jakartaLocation).Format("02/01/2006")}}
+	if snap.NomorReferensi != "" {
+		info = append(info, [2]string{"Atas Invoice", snap.NomorReferensi})
+	}
+	for _, row := range info {
+		d.SetFont(pdf.Helvetica, 9)
+		d.TextRight(dokumenKananX-120, kanan, row[0])
+		d.SetFont(pdf.HelveticaBold, 9)
+		d.TextRight(dokumenKananX, kanan, row[1])
+		kanan += 12
+	}
+
+	y = max(y, kanan) + 6
+	d.Line(dokumenMarginX, y, dokumenKananX, y, 0.8)
+	return y + 18
+}
+
+func (r *dokumenRenderer) pihak(y float64, snap *models.DokumenSnapshot) float64 {
+	d := r.doc
+	d.SetFont(pdf.HelveticaBold, 9)
+	d.Text(dokumenMarginX, y, "Ditagihkan kepada")
+	d.Text(330, y, "Detail Pesanan")
+
+	kiri := y + 14
+	d.SetFont(pdf.HelveticaBold, 10)
+	d.Text(dokumenMarginX, kiri, snap.Pembeli.Nama)
+	kiri += 12
+	d.SetFont(pdf.Helvetica, 9)
+	if snap.Pembeli.NPWP != "" {
+		d.Text(dokumenMarginX, kiri, "NPWP: "+snap.Pembeli.NPWP)
+		kiri += 11
+	}
+	for _, line := range d.WrapText(snap.Pembeli.Alamat, 260) {
+		if line != "" {
+			d.Text(dokumenMarginX, kiri, line)
+			kiri += 11
+		}
+	}
+	for _, v := range []string{snap.Pembeli.Email, snap.Pembeli.Telepon} {
+		if v != "" {
+			d.Text(dokumenMarginX, kiri, v)
+			kiri += 11
+		}
+	}
+
+	kanan := y + 14
+	for _, row := range [][2]string{
+		{"Kode Pesanan", snap.KodePesanan},
+		{"Tanggal Pesanan", snap.TanggalPesanan.In(jakartaLocation).Format("02/01/2006")},
+		{"Pembayaran", snap.PaymentType},
+		{"Pengiriman", snap.DeliveryType},
+	} {
+		d.SetFont(pdf.Helvetica, 9)
+		d.Text(330, kanan, row[0])
+		d.SetFont(pdf.HelveticaBold, 9)
+		d.TextRight(dokumenKananX, kanan, row[1])
+		kanan += 12
+	}
+	return max(kiri, kanan) + 14
+}
+
+func (r *dokumenRenderer) itemHeader(y float64) float64 {
+	d := r.doc
+	d.FillRect(dokumenMarginX, y-11, dokumenKananX-dokumenMarginX, 16, 0.9)
+	d.SetFont(pdf.HelveticaBold, 9)
+	d.Text(kolomNo, y, "No")
+	d.Text(kolomProduk, y, "Produk")
+	d.TextRight(kolomQty, y, "Qty")
+	d.TextRight(kolomHarga, y, "Harga Satuan")
+	d.TextRight(kolomDiskon, y, "Diskon")
+	d.TextRight(kolomSubtotal, y, "Subtotal")
+	return y + 18
+}
+
+// newPage memindahkan penulisan ke halaman baru jika sisa ruang kurang dari need
+func (r *dokumenRenderer) newPage(y, need float64) (float64, bool) {
+	if y+need <= dokumenBatasY {
+		return y, false
+	}
+	r.doc.AddPage()
+	r.doc.SetFont(pdf.HelveticaBold, 10)
+	r.doc.Text(dokumenMarginX, 50, r.judul()+" "+r.nomor+" (lanjutan)")
+	return 72, true
+}
+
+func (r *dokumenRenderer) items(y float64, items []models.DokumenItem) float64 {
+	d := r.doc
+	y = r.itemHeader(y)
+	for i, item := range items {
+		d.SetFont(pdf.Helvetica, 9)
+		nama := d.WrapText(item.NamaProduk, lebarProduk)
+		if item.SKU != "" {
+			nama = append(nama, "SKU: "+item.SKU)
+		}
+		var baru bool
+		if y, baru = r.newPage(y, float64(len(nama))*dokumenBarisItem); baru {
+			y = r.itemHeader(y)
+		}
+
+		d.SetFont(pdf.Helvetica, 9)
+		d.Text(kolomNo, y, fmt.Sprintf("%d", i+1))
+		for j, line := range nama {
+			d.Text(kolomProduk, y+float64(j)*dokumenBarisItem, line)
+		}
+		d.TextRight(kolomQty, y, fmt.Sprintf("%d", item.Qty))
+		d.TextRight(kolomHarga, y, formatRupiah(item.HargaSatuan))
+		diskon := "-"
+		if item.DiskonSatuan.IsPositive() {
+			diskon = formatRupiah(item.DiskonSatuan)
+		}
+		d.TextRight(kolomDiskon, y, diskon)
+		d.TextRight(kolomSubtotal, y, formatRupiah(item.Subtotal))
+
+		y += float64(len(nama)) * dokumenBarisItem
+		d.Line(dokumenMarginX, y-8, dokumenKananX, y-8, 0.3)
+		y += 4
+	}
+	return y + 8
+}
+
+func (r *dokumenRenderer) ringkasan(y float64, snap *models.DokumenSnapshot) float64 {
+	d := r.doc
+	rows := [][2]string{{"Biaya Produk", formatRupiah(snap.BiayaProduk)}}
+	for _, k := range snap.Kupon {
+		rows = append(rows, [2]string{"Potongan Kupon " + k.Kode, "-" + formatRupiah(k.NilaiPotongan)})
+	}
+	rows = append(rows, [2]string{"Biaya Pengiriman", formatRupiah(snap.BiayaKirim)})
+	if !snap.BiayaLainnya.IsZero() {
+		rows = append(rows, [2]string{"Biaya Lainnya", formatRupiah(snap.BiayaLainnya)})
+	}
+	ppn := "PPN"
+	if snap.PPNPersentase != nil {
+		ppn = fmt.Sprintf("PPN %s%%", snap.PPNPersentase.String())
+	}
+	rows = append(rows, [2]string{ppn, formatRupiah(snap.BiayaPPN)})
+
+	y, _ = r.newPage(y, float64(len(rows)+1)*14+10)
+	for _, row := range rows {
+		d.SetFont(pdf.Helvetica, 9)
+		d.TextRight(kolomDiskon, y, row[0])
+		d.TextRight(kolomSubtotal, y, row[1])
+		y += 14
+	}
+	d.Line(330, y-8, dokumenKananX, y-8, 0.8)
+	d.SetFont(pdf.HelveticaBold, 11)
+	label := "Total"
+	if r.tipe == models.DokumenTipeCreditNote {
+		label = "Total Dikreditkan"
+	}
+	d.TextRight(kolomDiskon, y+6, label)
+	d.TextRight(kolomSubtotal, y+6, formatRupiah(snap.Total))
+	return y + 30
+}
+
+// pembayaran mencetak rincian pembayaran (per pembayar untuk pesanan SPLIT);
+// invoice yang semua pembayarannya lunas berfungsi sebagai kuitansi
+func (r *dokumenRenderer) pembayaran(y float64, snap *models.DokumenSnapshot) float64 {
+	if len(snap.Pembayaran) == 0 {
+		return y
+	}
+	d := r.doc
+	y, _ = r.newPage(y, float64(len(snap.Pembayaran)+2)*14+20)
+
+	d.SetFont(pdf.HelveticaBold, 10)
+	d.Text(dokumenMarginX, y, "Rincian Pembayaran")
+	lunas := true
+	for _, p := range snap.Pembayaran {
+		lunas = lunas && p.Status == string(models.PaymentStatusPaid)
+	}
+	if lunas && r.tipe == models.DokumenTipeInvoice {
+		d.TextRight(dokumenKananX, y, "LUNAS")
+	}
+	y += 16
+
+	d.FillRect(dokumenMarginX, y-11, dokumenKananX-dokumenMarginX, 16, 0.9)
+	d.SetFont(pdf.HelveticaBold, 9)
+	d.Text(kolomNo, y, "Pembayar")
+	d.Text(200, y, "Metode")
+	d.Text(320, y, "Status")
+	d.Text(390, y, "Tanggal Bayar")
+	d.TextRight(kolomSubtotal, y, "Jumlah")
+	y += 18
+
+	d.SetFont(pdf.Helvetica, 9)
+	for _, p := range snap.Pembayaran {
+		tanggal := "-"
+		if p.PaidAt != nil {
+			tanggal = p.PaidAt.In(jakartaLocation).Format("02/01/2006 15:04")
+		}
+		d.Text(kolomNo, y, p.NamaPembayar)
+		d.Text(200, y, p.Metode)
+		d.Text(320, y, p.Status)
+		d.Text(390, y, tanggal)
+		d.TextRight(kolomSubtotal, y, formatRupiah(p.Jumlah))
+		y += 14
+	}
+	return y + 10
+}
+
+func (r *dokumenRenderer) catatanNotaKredit(y float64, snap *models.DokumenSnapshot) {
+	d := r.doc
+	y, _ = r.newPage(y, 50)
+	d.SetFont(pdf.Helvetica, 9)
+	text := fmt.Sprintf("Nota kredit ini membatalkan invoice %s atas pesanan %s.", snap.NomorReferensi, snap.KodePesanan)
+	if snap.AlasanBatal != "" {
+		text += " Alasan pembatalan: " + snap.AlasanBatal
+	}
+	for _, line := range d.WrapText(text, dokumenKananX-dokumenMarginX) {
+		d.Text(dokumenMarginX, y, line)
+		y += 11
+	}
+}
+
+func (r *dokumenRenderer) footer() {
+	d := r.doc
+	total := d.PageCount()
+	for i := 1; i <= total; i++ {
+		d.SetPage(i)
+		d.Line(dokumenMarginX, pdf.PageHeight-50, dokumenKananX, pdf.PageHeight-50, 0.3)
+		d.SetFont(pdf.Helvetica, 8)
+		d.Text(dokumenMarginX, pdf.PageHeight-38, "Dokumen ini diterbitkan secara elektronik dan sah tanpa tanda tangan.")
+		d.TextRight(dokumenKananX, pdf.PageHeight-38, fmt.Sprintf("%s - Halaman %d dari %d", r.nomor, i, total))
+	}
+}
+
+// formatRupiah memformat nominal dengan pemisah ribuan titik (Rp 1.250.000);
+// sen hanya ditampilkan jika ada (Rp 1.250,50)
+func formatRupiah(v decimal.Decimal) string {
+	neg := v.IsNegative()
+	v = v.Abs().Round(2)
+	bulat := v.Truncate(0)
+	digits := bulat.String()
+
+	var b strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	if sen := v.Sub(bulat); !sen.IsZero() {
+		fmt.Fprintf(&b, ",%02d", sen.Mul(decimal.NewFromInt(100)).IntPart())
+	}
+
+	if neg {
+		return "-Rp " + b.String()
+	}
+	return "Rp " + b.String()
+}
+
+// renderBuktiPengirimanPDF mencetak bukti pengiriman tersimpan sebagai lampiran
+// invoice. PDF tidak memuat gambar; tandaTangan memetakan ID bukti ke nama file
+// tanda tangan yang ikut di arsip yang sama.
+func renderBuktiPengirimanPDF(nomorInvoice, kodePesanan string, tanggal time.Time, bukti []models.PesananBuktiPengiriman, tandaTangan map[uuid.UUID]string) ([]byte, error) {
+	judul := "BUKTI PENGIRIMAN"
+	doc := pdf.New(judul+" "+kodePesanan, tanggal)
+	doc.AddPage()
+
+	doc.SetFont(pdf.HelveticaBold, 18)
+	doc.Text(dokumenMarginX, 60, judul)
+	doc.SetFont(pdf.Helvetica, 9)
+	doc.Text(dokumenMarginX, 78, fmt.Sprintf("Lampiran invoice %s atas pesanan %s", nomorInvoice, kodePesanan))
+	doc.Line(dokumenMarginX, 90, dokumenKananX, 90, 0.8)
+
+	y := 112.0
+	for _, b := range bukti {
+		rows := buktiPengirimanRows(&b, tandaTangan[b.ID])
+		if y+float64(len(rows)+1)*13 > dokumenBatasY {
+			doc.AddPage()
+			doc.SetFont(pdf.HelveticaBold, 10)
+			doc.Text(dokumenMarginX, 50, judul+" "+kodePesanan+" (lanjutan)")
+			y = 72
+		}
+		doc.FillRect(dokumenMarginX, y-11, dokumenKananX-dokumenMarginX, 16, 0.9)
+		doc.SetFont(pdf.HelveticaBold, 10)
+		doc.Text(kolomNo, y, "Booking Deliveree "+b.BookingRef)
+		y += 20
+		for _, row := range rows {
+			doc.SetFont(pdf.Helvetica, 9)
+			doc.Text(kolomNo, y, row[0])
+			doc.SetFont(pdf.HelveticaBold, 9)
+			doc.Text(180, y, row[1])
+			y += 13
+		}
+		y += 14
+	}
+
+	total := doc.PageCount()
+	for i := 1; i <= total; i++ {
+		doc.SetPage(i)
+		doc.Line(dokumenMarginX, pdf.PageHeight-50, dokumenKananX, pdf.PageHeight-50, 0.3)
+		doc.SetFont(pdf.Helvetica, 8)
+		doc.Text(dokumenMarginX, pdf.PageHeight-38, "Data diambil dari detail pengiriman Deliveree saat pesanan selesai.")
+		doc.TextRight(dokumenKananX, pdf.PageHeight-38, fmt.Sprintf("%s - Halaman %d dari %d", kodePesanan, i, total))
+	}
+	return doc.Bytes()
+}
+
+func buktiPengirimanRows(b *models.PesananBuktiPengiriman, tandaTangan string) [][2]string {
+	waktu := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.In(jakartaLocation).Format("02/01/2006 15:04") + " WIB"
+	}
+	teks := func(v *string) string {
+		if v == nil || *v == "" {
+			return "-"
+		}
+		return *v
+	}
+	if tandaTangan == "" {
+		tandaTangan = "tidak tersedia"
+	}
+
+	rows := [][2]string{
+		{"Status", teks(b.DeliveryStatus)},
+		{"Penerima", teks(b.RecipientName)},
+		{"Telepon Penerima", teks(b.RecipientPhone)},
+	}
+	if b.FailedDeliveryReason != nil {
+		rows = append(rows, [2]string{"Alasan Gagal", *b.FailedDeliveryReason})
+	}
+	return append(rows,
+		[2]string{"Pengemudi", teks(b.DriverName)},
+		[2]string{"Telepon Pengemudi", teks(b.DriverPhone)},
+		[2]string{"Tiba di Tujuan", waktu(b.ArrivedAt)},
+		[2]string{"Meninggalkan Tujuan", waktu(b.LeftAt)},
+		[2]string{"Selesai", waktu(b.CompletedAt)},
+		[2]string{"Biaya Parkir", formatRupiah(b.ParkingFees)},
+		[2]string{"Biaya Tol", formatRupiah(b.TollsFees)},
+		[2]string{"Biaya Waktu Tunggu", formatRupiah(b.WaitingTimeFees)},
+		[2]string{"Total Biaya Tambahan", formatRupiah(b.TotalFees)},
+		[2]string{"Tanda Tangan", tandaTangan},
+		[2]string{"Dicatat", waktu(&b.CapturedAt)},
+	)
+}
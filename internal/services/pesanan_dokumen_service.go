@@ -0,0 +1,670 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/config"
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/orderstate"
+	"project-bulky-be/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tmpSuffixDokumen akhiran file PDF yang belum di-commit
+const tmpSuffixDokumen = ".tmp"
+
+var (
+	ErrDokumenPesananNotFound = errors.New("pesanan tidak ditemukan")
+	ErrDokumenNotFound        = errors.New("dokumen tidak ditemukan")
+	ErrInvoiceBelumLunas      = errors.New("invoice hanya bisa diterbitkan untuk pesanan yang sudah lunas dan tidak dibatalkan")
+	ErrDokumenRusak           = errors.New("file dokumen tidak cocok dengan checksum saat terbit")
+	ErrArsipBukanInvoice      = errors.New("bukti pengiriman hanya dilampirkan pada invoice")
+	ErrBuktiPengirimanKosong  = errors.New("bukti pengiriman pesanan belum tersimpan")
+)
+
+// DokumenFile adalah file PDF (atau arsip ZIP) yang siap diunduh
+type DokumenFile struct {
+	NamaFile string
+	Data     []byte
+}
+
+// PesananDokumenService menerbitkan invoice & nota kredit PDF pesanan. Nomor
+// berurutan tanpa celah per tahun fiskal (WIB) dan PDF yang terbit disimpan
+// apa adanya; unduhan ulang selalu mengembalikan file yang sama.
+type PesananDokumenService interface {
+	// Admin
+	List(ctx context.Context, pesananID string) ([]dto.PesananDokumenResponse, error)
+	IssueInvoice(ctx context.Context, pesananID string, adminID uuid.UUID) (*dto.PesananDokumenResponse, error)
+	Download(ctx context.Context, pesananID, dokumenID string) (*DokumenFile, error)
+	// DownloadArsip mengemas invoice beserta bukti pengiriman Deliveree (PDF
+	// ringkasan & gambar tanda tangan penerima) dalam satu ZIP
+	DownloadArsip(ctx context.Context, pesananID, dokumenID string) (*DokumenFile, error)
+	// Buyer (pemilik pesanan atau pembayar pesanan SPLIT)
+	BuyerList(ctx context.Context, buyerID, pesananID string) ([]dto.PesananDokumenResponse, error)
+	BuyerIssueInvoice(ctx context.Context, buyerID, pesananID string) (*dto.PesananDokumenResponse, error)
+	BuyerDownload(ctx context.Context, buyerID, pesananID, dokumenID string) (*DokumenFile, error)
+}
+
+type pesananDokumenService struct {
+	db          *gorm.DB
+	dokumenRepo repositories.PesananDokumenRepository
+	buktiRepo   repositories.PesananBuktiPengirimanRepository
+	jobQueue    JobQueueService
+	cfg         *config.Config
+}
+
+// NewPesananDokumenService juga mendaftarkan hook orderMachine: pesanan yang
+// dibatalkan setelah invoice terbit mendapat nota kredit lewat job queue.
+func NewPesananDokumenService(db *gorm.DB, dokumenRepo repositories.PesananDokumenRepository, buktiRepo repositories.PesananBuktiPengirimanRepository, orderMachine *orderstate.Machine, jobQueue JobQueueService, cfg *config.Config) PesananDokumenService {
+	s := &pesananDokumenService{
+		db:          db,
+		dokumenRepo: dokumenRepo,
+		buktiRepo:   buktiRepo,
+		jobQueue:    jobQueue,
+		cfg:         cfg,
+	}
+	orderMachine.OnEnterTx(models.OrderStatusCancelled, s.enqueueCreditNote)
+	jobQueue.RegisterHandler(models.JobTypeDokumenCreditNote, s.handleCreditNote, JobHandlerOptions{
+		Timeout: time.Minute,
+	})
+	return s
+}
+
+func (s *pesananDokumenService) List(ctx context.Context, pesananID string) ([]dto.PesananDokumenResponse, error) {
+	pesanan, err := s.findPesanan(ctx, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	return s.list(ctx, pesanan.ID)
+}
+
+func (s *pesananDokumenService) IssueInvoice(ctx context.Context, pesananID string, adminID uuid.UUID) (*dto.PesananDokumenResponse, error) {
+	pesanan, err := s.findPesanan(ctx, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	dokumen, err := s.issue(ctx, pesanan.ID, models.DokumenTipeInvoice, &adminID)
+	if err != nil {
+		return nil, err
+	}
+	resp := mapPesananDokumenResponse(dokumen)
+	return &resp, nil
+}
+
+func (s *pesananDokumenService) Download(ctx context.Context, pesananID, dokumenID string) (*DokumenFile, error) {
+	pesanan, err := s.findPesanan(ctx, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	return s.download(ctx, pesanan.ID, dokumenID)
+}
+
+func (s *pesananDokumenService) DownloadArsip(ctx context.Context, pesananID, dokumenID string) (*DokumenFile, error) {
+	pesanan, err := s.findPesanan(ctx, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	dokumen, data, err := s.load(ctx, pesanan.ID, dokumenID)
+	if err != nil {
+		return nil, err
+	}
+	if dokumen.Tipe != models.DokumenTipeInvoice {
+		return nil, ErrArsipBukanInvoice
+	}
+	bukti, err := s.buktiRepo.FindByPesananID(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(bukti) == 0 {
+		return nil, ErrBuktiPengirimanKosong
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := addArsipFile(zw, namaFileDokumen(dokumen.Nomor), data); err != nil {
+		return nil, err
+	}
+	// Tanda tangan yang gagal dibaca dilewati; PDF ringkasan mencatatnya tidak tersedia
+	tandaTangan := make(map[uuid.UUID]string, len(bukti))
+	for _, b := range bukti {
+		if b.SignaturePath == nil {
+			continue
+		}
+		image, err := os.ReadFile(filepath.Join(s.cfg.UploadPath, filepath.FromSlash(*b.SignaturePath)))
+		if err != nil {
+			log.Printf("[dokumen] tanda tangan bukti pengiriman tidak terbaca: bukti=%s error=%v", b.ID, err)
+			continue
+		}
+		nama := "tanda-tangan-" + b.BookingRef + filepath.Ext(*b.SignaturePath)
+		if err := addArsipFile(zw, nama, image); err != nil {
+			return nil, err
+		}
+		tandaTangan[b.ID] = nama
+	}
+	ringkasan, err := renderBuktiPengirimanPDF(dokumen.Nomor, pesanan.Kode, time.Now(), bukti, tandaTangan)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat PDF bukti pengiriman: %w", err)
+	}
+	if err := addArsipFile(zw, "bukti-pengiriman-"+pesanan.Kode+".pdf", ringkasan); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return &DokumenFile{NamaFile: strings.TrimSuffix(namaFileDokumen(dokumen.Nomor), ".pdf") + "-bukti-pengiriman.zip", Data: buf.Bytes()}, nil
+}
+
+func (s *pesananDokumenService) BuyerList(ctx context.Context, buyerID, pesananID string) ([]dto.PesananDokumenResponse, error) {
+	pesanan, err := s.buyerPesanan(ctx, buyerID, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	return s.list(ctx, pesanan.ID)
+}
+
+// BuyerIssueInvoice menerbitkan invoice saat buyer pertama kali memintanya;
+// permintaan berikutnya mengembalikan invoice yang sama
+func (s *pesananDokumenService) BuyerIssueInvoice(ctx context.Context, buyerID, pesananID string) (*dto.PesananDokumenResponse, error) {
+	pesanan, err := s.buyerPesanan(ctx, buyerID, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	dokumen, err := s.issue(ctx, pesanan.ID, models.DokumenTipeInvoice, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp := mapPesananDokumenResponse(dokumen)
+	return &resp, nil
+}
+
+func (s *pesananDokumenService) BuyerDownload(ctx context.Context, buyerID, pesananID, dokumenID string) (*DokumenFile, error) {
+	pesanan, err := s.buyerPesanan(ctx, buyerID, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	return s.download(ctx, pesanan.ID, dokumenID)
+}
+
+func (s *pesananDokumenService) list(ctx context.Context, pesananID uuid.UUID) ([]dto.PesananDokumenResponse, error) {
+	dokumen, err := s.dokumenRepo.FindByPesananID(ctx, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]dto.PesananDokumenResponse, 0, len(dokumen))
+	for i := range dokumen {
+		resp = append(resp, mapPesananDokumenResponse(&dokumen[i]))
+	}
+	return resp, nil
+}
+
+func (s *pesananDokumenService) download(ctx context.Context, pesananID uuid.UUID, dokumenID string) (*DokumenFile, error) {
+	dokumen, data, err := s.load(ctx, pesananID, dokumenID)
+	if err != nil {
+		return nil, err
+	}
+	return &DokumenFile{NamaFile: namaFileDokumen(dokumen.Nomor), Data: data}, nil
+}
+
+// load membaca PDF tersimpan dan memastikan isinya masih sama dengan saat terbit
+func (s *pesananDokumenService) load(ctx context.Context, pesananID uuid.UUID, dokumenID string) (*models.PesananDokumen, []byte, error) {
+	id, err := uuid.Parse(dokumenID)
+	if err != nil {
+		return nil, nil, ErrDokumenNotFound
+	}
+	dokumen, err := s.dokumenRepo.FindByID(ctx, pesananID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrDokumenNotFound
+		}
+		return nil, nil, err
+	}
+
+	data, err := os.ReadFile(s.dokumenFilePath(dokumen.FilePath))
+	if errors.Is(err, os.ErrNotExist) {
+		data, err = s.pulihkanFile(dokumen)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("gagal membaca file dokumen %s: %w", dokumen.Nomor, err)
+	}
+	if checksumDokumen(data) != dokumen.Checksum {
+		log.Printf("[dokumen] checksum tidak cocok: dokumen=%s nomor=%s", dokumen.ID, dokumen.Nomor)
+		return nil, nil, ErrDokumenRusak
+	}
+	return dokumen, data, nil
+}
+
+// issue menerbitkan dokumen tipe untuk pesanan, atau mengembalikan dokumen yang
+// sudah terbit. Pesanan dikunci agar penerbitan paralel tidak menghabiskan dua
+// nomor; nomor & baris dokumen disimpan dalam satu transaksi. PDF ditulis ke
+// file sementara dan baru diberi nama final setelah commit, sehingga transaksi
+// yang gagal tidak meninggalkan file bernomor yang memblokir penerbitan berikutnya.
+// Jika file gagal dipindahkan setelah commit, dokumen yang sudah terbit
+// ditulis ulang dari snapshot-nya pada permintaan berikutnya.
+func (s *pesananDokumenService) issue(ctx context.Context, pesananID uuid.UUID, tipe models.DokumenTipe, issuedBy *uuid.UUID) (*models.PesananDokumen, error) {
+	var result *models.PesananDokumen
+	var tmpPath string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.dokumenRepo.WithTx(tx)
+
+		var pesanan models.Pesanan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pesanan, "id = ?", pesananID).Error; err != nil {
+			return err
+		}
+		existing, err := repo.FindByTipe(ctx, pesananID, tipe)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			result = existing
+			return nil
+		}
+
+		var snapshot *models.DokumenSnapshot
+		var referensi *models.PesananDokumen
+		switch tipe {
+		case models.DokumenTipeCreditNote:
+			referensi, err = repo.FindByTipe(ctx, pesananID, models.DokumenTipeInvoice)
+			if err != nil {
+				return err
+			}
+			if referensi == nil || pesanan.OrderStatus != models.OrderStatusCancelled {
+				// Tidak ada invoice yang perlu dikoreksi
+				return nil
+			}
+			snapshot = creditNoteSnapshot(referensi, &pesanan)
+		default:
+			if pesanan.PaymentStatus != models.PaymentStatusPaid || pesanan.OrderStatus == models.OrderStatusCancelled {
+				return ErrInvoiceBelumLunas
+			}
+			if snapshot, err = s.invoiceSnapshot(tx, &pesanan); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now().In(jakartaLocation)
+		urutan, err := repo.NextNomor(ctx, tipe, now.Year())
+		if err != nil {
+			return err
+		}
+		nomor := formatNomorDokumen(tipe, now.Year(), urutan)
+
+		data, err := renderDokumenPDF(tipe, nomor, now, snapshot)
+		if err != nil {
+			return fmt.Errorf("gagal membuat PDF %s: %w", nomor, err)
+		}
+		path, err := s.saveTempFile(tipe, now.Year(), nomor, data)
+		if err != nil {
+			return err
+		}
+		tmpPath = path + tmpSuffixDokumen
+
+		dokumen := &models.PesananDokumen{
+			PesananID:     pesananID,
+			Tipe:          tipe,
+			Nomor:         nomor,
+			TahunFiskal:   now.Year(),
+			Urutan:        urutan,
+			Total:         snapshot.Total,
+			FilePath:      path,
+			Checksum:      checksumDokumen(data),
+			Ukuran:        int64(len(data)),
+			Snapshot:      snapshot,
+			DiterbitkanBy: issuedBy,
+			DiterbitkanAt: now,
+		}
+		if referensi != nil {
+			dokumen.ReferensiID = &referensi.ID
+		}
+		if err := repo.Create(ctx, dokumen); err != nil {
+			return err
+		}
+		result = dokumen
+		return nil
+	})
+	if err != nil {
+		// Nomor ikut rollback (termasuk saat commit gagal); file sementara dibuang
+		if tmpPath != "" {
+			_ = os.Remove(s.dokumenFilePath(tmpPath))
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDokumenPesananNotFound
+		}
+		return nil, err
+	}
+	if tmpPath != "" {
+		if err := os.Rename(s.dokumenFilePath(tmpPath), s.dokumenFilePath(result.FilePath)); err != nil {
+			log.Printf("[dokumen] gagal memindahkan file %s ke %s: %v", tmpPath, result.FilePath, err)
+			return nil, fmt.Errorf("gagal menyimpan dokumen %s: %w", result.Nomor, err)
+		}
+		return result, nil
+	}
+	if result != nil {
+		if _, err := os.Stat(s.dokumenFilePath(result.FilePath)); errors.Is(err, os.ErrNotExist) {
+			if _, err := s.pulihkanFile(result); err != nil {
+				return nil, fmt.Errorf("gagal menyimpan dokumen %s: %w", result.Nomor, err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// pulihkanFile menulis ulang PDF dokumen yang sudah terbit tetapi filenya
+// hilang (mis. rename setelah commit gagal). Render dari snapshot, nomor dan
+// tanggal terbit yang sama menghasilkan byte yang sama, jadi hasilnya harus
+// cocok dengan checksum saat terbit.
+func (s *pesananDokumenService) pulihkanFile(dokumen *models.PesananDokumen) ([]byte, error) {
+	if dokumen.Snapshot == nil {
+		return nil, fmt.Errorf("file dokumen %s hilang dan snapshot tidak tersedia", dokumen.Nomor)
+	}
+	data, err := renderDokumenPDF(dokumen.Tipe, dokumen.Nomor, dokumen.DiterbitkanAt.In(jakartaLocation), dokumen.Snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat PDF %s: %w", dokumen.Nomor, err)
+	}
+	if checksumDokumen(data) != dokumen.Checksum {
+		log.Printf("[dokumen] render ulang tidak cocok dengan checksum: dokumen=%s nomor=%s", dokumen.ID, dokumen.Nomor)
+		return nil, ErrDokumenRusak
+	}
+	path, err := s.saveTempFile(dokumen.Tipe, dokumen.TahunFiskal, dokumen.Nomor, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(s.dokumenFilePath(path+tmpSuffixDokumen), s.dokumenFilePath(dokumen.FilePath)); err != nil {
+		_ = os.Remove(s.dokumenFilePath(path + tmpSuffixDokumen))
+		return nil, err
+	}
+	log.Printf("[dokumen] file dokumen %s yang hilang ditulis ulang dari snapshot", dokumen.Nomor)
+	return data, nil
+}
+
+// invoiceSnapshot mengumpulkan data invoice: item, kupon (KuponUsage), PPN
+// aktif dan pembayaran per pembayar
+func (s *pesananDokumenService) invoiceSnapshot(tx *gorm.DB, pesanan *models.Pesanan) (*models.DokumenSnapshot, error) {
+	if err := tx.Model(pesanan).
+		Preload("Buyer").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Pembayaran", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Pembayaran.Buyer").
+		Preload("Pembayaran.MetodePembayaran").
+		First(pesanan).Error; err != nil {
+		return nil, err
+	}
+
+	var kupon []models.KuponUsage
+	if err := tx.Where("pesanan_id = ?", pesanan.ID).Order("created_at ASC").Find(&kupon).Error; err != nil {
+		return nil, err
+	}
+
+	var ppnPersentase *decimal.Decimal
+	var ppn models.PPN
+	err := tx.Where("is_active = ?", true).Order("updated_at DESC").First(&ppn).Error
+	if err == nil {
+		ppnPersentase = &ppn.Persentase
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return buildInvoiceSnapshot(pesanan, kupon, ppnPersentase, models.DokumenPihak{
+		Nama:   s.cfg.InvoicePenjualNama,
+		NPWP:   s.cfg.InvoicePenjualNPWP,
+		Alamat: s.cfg.InvoicePenjualAlamat,
+	}), nil
+}
+
+// buildInvoiceSnapshot memetakan pesanan ke data invoice. Total memakai
+// pesanan.Total (yang sudah dibayar buyer), bukan hasil hitung ulang.
+func buildInvoiceSnapshot(pesanan *models.Pesanan, kupon []models.KuponUsage, ppnPersentase *decimal.Decimal, penjual models.DokumenPihak) *models.DokumenSnapshot {
+	snap := &models.DokumenSnapshot{
+		Penjual: penjual,
+		Pembeli: models.DokumenPihak{
+			Nama:    pesanan.Buyer.Nama,
+			Telepon: pesanan.Buyer.Telepon,
+		},
+		KodePesanan:    pesanan.Kode,
+		TanggalPesanan: pesanan.CreatedAt,
+		PaymentType:    string(pesanan.PaymentType),
+		DeliveryType:   string(pesanan.DeliveryType),
+		Items:          make([]models.DokumenItem, 0, len(pesanan.Items)),
+		Kupon:          make([]models.DokumenKupon, 0, len(kupon)),
+		Pembayaran:     make([]models.DokumenPembayaran, 0, len(pesanan.Pembayaran)),
+		BiayaProduk:    pesanan.BiayaProduk,
+		PotonganKupon:  decimal.Zero,
+		BiayaKirim:     pesanan.BiayaPengiriman,
+		BiayaLainnya:   pesanan.BiayaLainnya,
+		PPNPersentase:  ppnPersentase,
+		BiayaPPN:       pesanan.BiayaPPN,
+		Total:          pesanan.Total,
+	}
+	if pesanan.Buyer.Email != nil {
+		snap.Pembeli.Email = *pesanan.Buyer.Email
+	}
+	if pesanan.NamaPenerimaSnap != nil && *pesanan.NamaPenerimaSnap != pesanan.Buyer.Nama {
+		snap.Pembeli.Alamat = "u.p. " + *pesanan.NamaPenerimaSnap + ", "
+	}
+	if pesanan.AlamatSnapshot != nil {
+		snap.Pembeli.Alamat += *pesanan.AlamatSnapshot
+	}
+	snap.Pembeli.Alamat = strings.TrimSuffix(snap.Pembeli.Alamat, ", ")
+	// Buyer PKP: invoice memakai identitas pajak yang disalin saat pesanan dibuat
+	if pesanan.NPWPSnapshot != nil {
+		snap.Pembeli.NPWP = *pesanan.NPWPSnapshot
+		if pesanan.NamaPerusahaanSnapshot != nil {
+			snap.Pembeli.Nama = *pesanan.NamaPerusahaanSnapshot
+		}
+		if pesanan.AlamatPajakSnapshot != nil {
+			snap.Pembeli.Alamat = *pesanan.AlamatPajakSnapshot
+		}
+	}
+
+	for _, item := range pesanan.Items {
+		sku := ""
+		if item.SKU != nil {
+			sku = *item.SKU
+		}
+		snap.Items = append(snap.Items, models.DokumenItem{
+			NamaProduk:   item.NamaProduk,
+			SKU:          sku,
+			Qty:          item.Qty,
+			HargaSatuan:  item.HargaSatuan,
+			DiskonSatuan: item.DiskonSatuan,
+			Subtotal:     item.Subtotal,
+		})
+	}
+	for _, k := range kupon {
+		nilai := decimal.NewFromFloat(k.NilaiPotongan).Round(2)
+		snap.Kupon = append(snap.Kupon, models.DokumenKupon{Kode: k.KodeKupon, NilaiPotongan: nilai})
+		snap.PotonganKupon = snap.PotonganKupon.Add(nilai)
+	}
+	for _, p := range pesanan.Pembayaran {
+		metode := "-"
+		if p.MetodePembayaran != nil {
+			metode = p.MetodePembayaran.Nama
+		} else if p.XenditPaymentMethod != nil {
+			metode = *p.XenditPaymentMethod
+		}
+		snap.Pembayaran = append(snap.Pembayaran, models.DokumenPembayaran{
+			NamaPembayar: p.Buyer.Nama,
+			Metode:       metode,
+			Jumlah:       p.Jumlah,
+			Status:       string(p.Status),
+			PaidAt:       p.PaidAt,
+		})
+	}
+	return snap
+}
+
+// creditNoteSnapshot menyalin data invoice apa adanya agar nota kredit
+// membatalkan tepat nilai yang ditagihkan
+func creditNoteSnapshot(invoice *models.PesananDokumen, pesanan *models.Pesanan) *models.DokumenSnapshot {
+	snap := *invoice.Snapshot
+	snap.NomorReferensi = invoice.Nomor
+	if pesanan.CancelledReason != nil {
+		snap.AlasanBatal = *pesanan.CancelledReason
+	}
+	return &snap
+}
+
+// enqueueCreditNote menjadwalkan nota kredit jika pesanan yang dibatalkan
+// sudah punya invoice. PDF dibuat di job agar kegagalan render/penyimpanan
+// file tidak menggagalkan pembatalan.
+func (s *pesananDokumenService) enqueueCreditNote(tx *gorm.DB, pesanan *models.Pesanan, from models.OrderStatus, trigger orderstate.Trigger) error {
+	var count int64
+	if err := tx.Model(&models.PesananDokumen{}).
+		Where("pesanan_id = ? AND tipe = ?", pesanan.ID, models.DokumenTipeInvoice).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+	payload := pesananOutboxPayload{PesananID: pesanan.ID, Kode: pesanan.Kode}
+	return s.jobQueue.Enqueue(tx.Statement.Context, tx, models.JobTypeDokumenCreditNote, payload, EnqueueOptions{
+		UniqueKey: models.JobTypeDokumenCreditNote + ":" + pesanan.ID.String(),
+	})
+}
+
+// handleCreditNote adalah handler job models.JobTypeDokumenCreditNote
+func (s *pesananDokumenService) handleCreditNote(ctx context.Context, job *models.BackgroundJob) error {
+	var payload pesananOutboxPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return err
+	}
+	dokumen, err := s.issue(ctx, payload.PesananID, models.DokumenTipeCreditNote, nil)
+	if err != nil {
+		return err
+	}
+	if dokumen != nil {
+		log.Printf("[dokumen] nota kredit %s terbit untuk pesanan %s", dokumen.Nomor, payload.Kode)
+	}
+	return nil
+}
+
+// saveTempFile menulis PDF ke <path final>.tmp dan mengembalikan path final
+// (relatif terhadap DokumenStoragePath); issue memindahkannya setelah commit
+func (s *pesananDokumenService) saveTempFile(tipe models.DokumenTipe, tahun int, nomor string, data []byte) (string, error) {
+	directory := filepath.Join(strings.ToLower(string(tipe)), fmt.Sprintf("%d", tahun))
+	if err := os.MkdirAll(filepath.Join(s.cfg.DokumenStoragePath, directory), 0750); err != nil {
+		return "", fmt.Errorf("gagal membuat direktori dokumen: %w", err)
+	}
+	relativePath := filepath.ToSlash(filepath.Join(directory, namaFileDokumen(nomor)))
+	// Dokumen yang sudah terbit tidak boleh ditimpa. Nomor dikunci transaksi,
+	// jadi file .tmp yang tersisa hanya sisa proses yang mati sebelum commit.
+	if _, err := os.Stat(s.dokumenFilePath(relativePath)); err == nil {
+		return "", fmt.Errorf("gagal menyimpan dokumen %s: file sudah ada", nomor)
+	}
+	f, err := os.OpenFile(s.dokumenFilePath(relativePath+tmpSuffixDokumen), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return "", fmt.Errorf("gagal menyimpan dokumen %s: %w", nomor, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", fmt.Errorf("gagal menyimpan dokumen %s: %w", nomor, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return "", fmt.Errorf("gagal menyimpan dokumen %s: %w", nomor, err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("gagal menyimpan dokumen %s: %w", nomor, err)
+	}
+	return relativePath, nil
+}
+
+func (s *pesananDokumenService) dokumenFilePath(relativePath string) string {
+	return filepath.Join(s.cfg.DokumenStoragePath, filepath.FromSlash(relativePath))
+}
+
+func (s *pesananDokumenService) findPesanan(ctx context.Context, pesananID string) (*models.Pesanan, error) {
+	id, err := uuid.Parse(pesananID)
+	if err != nil {
+		return nil, ErrDokumenPesananNotFound
+	}
+	var pesanan models.Pesanan
+	if err := s.db.WithContext(ctx).First(&pesanan, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDokumenPesananNotFound
+		}
+		return nil, err
+	}
+	return &pesanan, nil
+}
+
+// buyerPesanan memastikan buyer adalah pemilik atau salah satu pembayar pesanan
+func (s *pesananDokumenService) buyerPesanan(ctx context.Context, buyerID, pesananID string) (*models.Pesanan, error) {
+	pesanan, err := s.findPesanan(ctx, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	if pesanan.BuyerID.String() == buyerID {
+		return pesanan, nil
+	}
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.PesananPembayaran{}).
+		Where("pesanan_id = ? AND buyer_id = ?", pesanan.ID, buyerID).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	// Pesanan buyer lain diperlakukan tidak ada agar ID pesanan tidak bisa ditebak
+	if count == 0 {
+		return nil, ErrDokumenPesananNotFound
+	}
+	return pesanan, nil
+}
+
+func addArsipFile(zw *zip.Writer, nama string, data []byte) error {
+	w, err := zw.Create(nama)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// formatNomorDokumen menghasilkan nomor seperti INV/2026/000123
+func formatNomorDokumen(tipe models.DokumenTipe, tahun, urutan int) string {
+	return fmt.Sprintf("%s/%d/%06d", tipe.Prefix(), tahun, urutan)
+}
+
+func namaFileDokumen(nomor string) string {
+	return strings.ReplaceAll(nomor, "/", "-") + ".pdf"
+}
+
+func checksumDokumen(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func mapPesananDokumenResponse(d *models.PesananDokumen) dto.PesananDokumenResponse {
+	resp := dto.PesananDokumenResponse{
+		ID:            d.ID,
+		PesananID:     d.PesananID,
+		Tipe:          string(d.Tipe),
+		Nomor:         d.Nomor,
+		TahunFiskal:   d.TahunFiskal,
+		Total:         d.Total,
+		Checksum:      d.Checksum,
+		Ukuran:        d.Ukuran,
+		DiterbitkanBy: d.DiterbitkanBy,
+		DiterbitkanAt: d.DiterbitkanAt,
+	}
+	if d.Snapshot != nil && d.Snapshot.NomorReferensi != "" {
+		resp.NomorReferensi = &d.Snapshot.NomorReferensi
+	}
+	return resp
+}
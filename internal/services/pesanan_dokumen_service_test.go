@@ -0,0 +1,393 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/config"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/orderstate"
+	"project-bulky-be/internal/repositories"
+	"project-bulky-be/internal/testutil/fakedb"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func TestFormatNomorDokumen(t *testing.T) {
+	if got := formatNomorDokumen(models.DokumenTipeInvoice, 2026, 123); got != "INV/2026/000123" {
+		t.Fatalf("nomor invoice salah: %s", got)
+	}
+	if got := formatNomorDokumen(models.DokumenTipeCreditNote, 2026, 7); got != "CN/2026/000007" {
+		t.Fatalf("nomor nota kredit salah: %s", got)
+	}
+	if got := namaFileDokumen("INV/2026/000123"); got != "INV-2026-000123.pdf" {
+		t.Fatalf("nama file salah: %s", got)
+	}
+}
+
+func TestFormatRupiah(t *testing.T) {
+	cases := map[string]string{
+		"0":          "Rp 0",
+		"1250000":    "Rp 1.250.000",
+		"999":        "Rp 999",
+		"1000.05":    "Rp 1.000,05",
+		"-150000.50": "-Rp 150.000,50",
+	}
+	for in, want := range cases {
+		if got := formatRupiah(decimal.RequireFromString(in)); got != want {
+			t.Errorf("formatRupiah(%s) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBuildInvoiceSnapshot(t *testing.T) {
+	email := "buyer@example.com"
+	alamat := "Jl. Melati 1, Jakarta"
+	sku := "SKU-1"
+	paidAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	pesanan := &models.Pesanan{
+		ID:              uuid.New(),
+		Kode:            "ORD-1",
+		Buyer:           models.Buyer{Nama: "PT Maju", Email: &email},
+		AlamatSnapshot:  &alamat,
+		BiayaProduk:     decimal.NewFromInt(1000000),
+		BiayaPengiriman: decimal.NewFromInt(50000),
+		BiayaPPN:        decimal.NewFromInt(110000),
+		Total:           decimal.NewFromInt(1110000),
+		Items: []models.PesananItem{
+			{NamaProduk: "Paket A", SKU: &sku, Qty: 2, HargaSatuan: decimal.NewFromInt(500000), Subtotal: decimal.NewFromInt(1000000)},
+		},
+		Pembayaran: []models.PesananPembayaran{
+			{Buyer: models.Buyer{Nama: "PT Maju"}, Jumlah: decimal.NewFromInt(1110000), Status: models.PaymentStatusPaid, PaidAt: &paidAt},
+		},
+	}
+	kupon := []models.KuponUsage{{KodeKupon: "HEMAT50", NilaiPotongan: 50000}}
+	ppn := decimal.NewFromInt(11)
+
+	snap := buildInvoiceSnapshot(pesanan, kupon, &ppn, models.DokumenPihak{Nama: "Bulky"})
+	if snap.Pembeli.Email != email || snap.Pembeli.Alamat != alamat || snap.KodePesanan != "ORD-1" {
+		t.Fatalf("identitas pembeli salah: %+v", snap.Pembeli)
+	}
+	if len(snap.Items) != 1 || snap.Items[0].SKU != sku {
+		t.Fatalf("item salah: %+v", snap.Items)
+	}
+	// Total invoice mengikuti yang dibayar buyer, potongan kupon hanya rincian
+	if !snap.PotonganKupon.Equal(decimal.NewFromInt(50000)) || !snap.Total.Equal(pesanan.Total) {
+		t.Fatalf("ringkasan salah: kupon=%s total=%s", snap.PotonganKupon, snap.Total)
+	}
+	if len(snap.Pembayaran) != 1 || snap.Pembayaran[0].Metode != "-" {
+		t.Fatalf("pembayaran salah: %+v", snap.Pembayaran)
+	}
+
+	// Nota kredit menyalin invoice dan merujuk nomornya
+	invoice := &models.PesananDokumen{Nomor: "INV/2026/000001", Snapshot: snap}
+	reason := "stok rusak"
+	cn := creditNoteSnapshot(invoice, &models.Pesanan{CancelledReason: &reason})
+	if cn.NomorReferensi != invoice.Nomor || cn.AlasanBatal != reason || snap.NomorReferensi != "" {
+		t.Fatalf("snapshot nota kredit salah: %+v", cn)
+	}
+}
+
+func TestRenderDokumenPDF(t *testing.T) {
+	snap := &models.DokumenSnapshot{
+		Penjual:     models.DokumenPihak{Nama: "Bulky", NPWP: "01.234.567.8-901.000"},
+		Pembeli:     models.DokumenPihak{Nama: "PT Maju"},
+		KodePesanan: "ORD-1",
+		BiayaProduk: decimal.NewFromInt(6000000),
+		Total:       decimal.NewFromInt(6000000),
+	}
+	// Cukup banyak item agar tabel berlanjut ke halaman berikutnya
+	for i := 0; i < 60; i++ {
+		snap.Items = append(snap.Items, models.DokumenItem{
+			NamaProduk:  fmt.Sprintf("Paket elektronik campuran nomor %d", i+1),
+			Qty:         1,
+			HargaSatuan: decimal.NewFromInt(100000),
+			Subtotal:    decimal.NewFromInt(100000),
+		})
+	}
+	tanggal := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	a, err := renderDokumenPDF(models.DokumenTipeInvoice, "INV/2026/000001", tanggal, snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(a, []byte("%PDF-")) || bytes.Count(a, []byte("/Type /Page ")) < 2 {
+		t.Fatalf("PDF harus valid dan lebih dari satu halaman")
+	}
+	// Render ulang data yang sama menghasilkan byte yang sama
+	b, _ := renderDokumenPDF(models.DokumenTipeInvoice, "INV/2026/000001", tanggal, snap)
+	if !bytes.Equal(a, b) {
+		t.Fatalf("render tidak deterministik")
+	}
+}
+
+// fakeJobQueue mencatat job yang di-enqueue beserta payload-nya; handler
+// tidak pernah dijalankan
+type fakeJobQueue struct {
+	JobQueueService
+	jobs     []string
+	payloads []interface{}
+	inTx     []bool
+}
+
+func (q *fakeJobQueue) RegisterHandler(string, JobHandler, JobHandlerOptions) {}
+
+func (q *fakeJobQueue) Enqueue(_ context.Context, tx *gorm.DB, jobType string, payload interface{}, _ EnqueueOptions) error {
+	q.jobs = append(q.jobs, jobType)
+	q.payloads = append(q.payloads, payload)
+	q.inTx = append(q.inTx, tx != nil)
+	return nil
+}
+
+func TestCancelInvoicedPesananIssuesCreditNote(t *testing.T) {
+	for _, adaInvoice := range []bool{true, false} {
+		pesanan := models.Pesanan{
+			ID: uuid.New(), Kode: "ORD-20261017-0003", BuyerID: uuid.New(),
+			OrderStatus: models.OrderStatusProcessing, PaymentStatus: models.PaymentStatusPaid, DeliveryType: models.DeliveryTypeForwarder,
+		}
+		repo := &fakeDokumenRepository{}
+		if adaInvoice {
+			repo.dokumen = []models.PesananDokumen{{
+				ID: uuid.New(), PesananID: pesanan.ID, Tipe: models.DokumenTipeInvoice, Nomor: "INV/2026/000042", Total: decimal.NewFromInt(750000),
+				Snapshot: &models.DokumenSnapshot{KodePesanan: pesanan.Kode, Total: decimal.NewFromInt(750000)},
+			}}
+		}
+		db, _ := fakedb.OpenPesanan(t, pesanan, func(q fakedb.Query) (*fakedb.Result, error) {
+			if q.Is("SELECT", "pesanan_dokumen") {
+				return &fakedb.Result{Columns: []string{"count"}, Rows: [][]driver.Value{{int64(len(repo.dokumen))}}}, nil
+			}
+			return nil, nil
+		})
+		machine := orderstate.NewMachine(db)
+		jobQueue := &fakeJobQueue{}
+		s := NewPesananDokumenService(db, repo, nil, machine, jobQueue, &config.Config{DokumenStoragePath: t.TempDir()}).(*pesananDokumenService)
+
+		if _, err := machine.Transition(context.Background(), orderstate.Request{PesananID: pesanan.ID, To: models.OrderStatusCancelled, Trigger: orderstate.TriggerAdmin}); err != nil {
+			t.Fatalf("pembatalan pesanan harus berhasil: %v", err)
+		}
+		if !adaInvoice {
+			if len(jobQueue.jobs) != 0 {
+				t.Fatalf("pesanan tanpa invoice tidak butuh nota kredit, got %v", jobQueue.jobs)
+			}
+			continue
+		}
+		if len(jobQueue.jobs) != 1 || jobQueue.jobs[0] != models.JobTypeDokumenCreditNote || !jobQueue.inTx[0] {
+			t.Fatalf("pesanan ber-invoice harus menjadwalkan nota kredit di transaksi pembatalan, got %v %v", jobQueue.jobs, jobQueue.inTx)
+		}
+
+		// Job menerbitkan nota kredit bernomor seri CN yang merujuk invoice
+		payload, _ := json.Marshal(jobQueue.payloads[0])
+		if err := s.handleCreditNote(context.Background(), &models.BackgroundJob{JobType: models.JobTypeDokumenCreditNote, Payload: payload}); err != nil {
+			t.Fatalf("penerbitan nota kredit harus berhasil: %v", err)
+		}
+		invoice := repo.dokumen[0]
+		if len(repo.dokumen) != 2 {
+			t.Fatalf("harus terbit satu nota kredit, got %d dokumen", len(repo.dokumen))
+		}
+		creditNote := repo.dokumen[1]
+		nomor := fmt.Sprintf("CN/%d/000001", time.Now().In(jakartaLocation).Year())
+		if creditNote.Tipe != models.DokumenTipeCreditNote || creditNote.Nomor != nomor {
+			t.Fatalf("nota kredit harus bernomor %s, got %s %s", nomor, creditNote.Tipe, creditNote.Nomor)
+		}
+		if creditNote.ReferensiID == nil || *creditNote.ReferensiID != invoice.ID || creditNote.Snapshot.NomorReferensi != invoice.Nomor ||
+			!creditNote.Total.Equal(invoice.Total) {
+			t.Fatalf("nota kredit harus merujuk invoice %s senilai %s, got referensi=%v nomor=%s total=%s",
+				invoice.Nomor, invoice.Total, creditNote.ReferensiID, creditNote.Snapshot.NomorReferensi, creditNote.Total)
+		}
+	}
+}
+
+// fakeDokumenRepository menyimpan dokumen di memori. Counter nomor tidak
+// dinaikkan sebelum Create, meniru counter yang ikut rollback.
+type fakeDokumenRepository struct {
+	repositories.PesananDokumenRepository
+	dokumen []models.PesananDokumen
+}
+
+func (r *fakeDokumenRepository) WithTx(*gorm.DB) repositories.PesananDokumenRepository { return r }
+
+func (r *fakeDokumenRepository) NextNomor(_ context.Context, tipe models.DokumenTipe, _ int) (int, error) {
+	urutan := 1
+	for _, d := range r.dokumen {
+		if d.Tipe == tipe {
+			urutan++
+		}
+	}
+	return urutan, nil
+}
+
+func (r *fakeDokumenRepository) Create(_ context.Context, dokumen *models.PesananDokumen) error {
+	dokumen.ID = uuid.New()
+	r.dokumen = append(r.dokumen, *dokumen)
+	return nil
+}
+
+func (r *fakeDokumenRepository) FindByID(_ context.Context, pesananID, id uuid.UUID) (*models.PesananDokumen, error) {
+	for i := range r.dokumen {
+		if r.dokumen[i].PesananID == pesananID && r.dokumen[i].ID == id {
+			return &r.dokumen[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeDokumenRepository) FindByTipe(_ context.Context, pesananID uuid.UUID, tipe models.DokumenTipe) (*models.PesananDokumen, error) {
+	for i := range r.dokumen {
+		if r.dokumen[i].PesananID == pesananID && r.dokumen[i].Tipe == tipe {
+			return &r.dokumen[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func TestIssueCommitFailureLeavesNoFile(t *testing.T) {
+	pesanan := models.Pesanan{
+		ID: uuid.New(), Kode: "ORD-20261017-0004", BuyerID: uuid.New(),
+		OrderStatus: models.OrderStatusCancelled, PaymentStatus: models.PaymentStatusPaid, DeliveryType: models.DeliveryTypeForwarder,
+	}
+	db, fake := fakedb.OpenPesanan(t, pesanan, nil)
+	repo := &fakeDokumenRepository{dokumen: []models.PesananDokumen{{
+		ID: uuid.New(), PesananID: pesanan.ID, Tipe: models.DokumenTipeInvoice, Nomor: "INV/2026/000001",
+		Snapshot: &models.DokumenSnapshot{KodePesanan: pesanan.Kode, Total: decimal.NewFromInt(500000)},
+	}}}
+	storage := t.TempDir()
+	s := &pesananDokumenService{db: db, dokumenRepo: repo, cfg: &config.Config{DokumenStoragePath: storage}}
+
+	fake.FailCommit(errors.New("koneksi terputus"))
+	if _, err := s.issue(context.Background(), pesanan.ID, models.DokumenTipeCreditNote, nil); err == nil {
+		t.Fatal("commit gagal harus mengembalikan error")
+	}
+	files, _ := filepath.Glob(filepath.Join(storage, "*", "*", "*"))
+	if len(files) != 0 {
+		t.Fatalf("commit gagal tidak boleh meninggalkan file, got %v", files)
+	}
+
+	// Nomor yang sama terbit normal setelah commit berhasil
+	repo.dokumen = repo.dokumen[:1]
+	dokumen, err := s.issue(context.Background(), pesanan.ID, models.DokumenTipeCreditNote, nil)
+	if err != nil {
+		t.Fatalf("penerbitan ulang harus berhasil: %v", err)
+	}
+	data, err := os.ReadFile(s.dokumenFilePath(dokumen.FilePath))
+	if err != nil || checksumDokumen(data) != dokumen.Checksum {
+		t.Fatalf("file dokumen harus tersimpan di path final: %v", err)
+	}
+	if _, err := os.Stat(s.dokumenFilePath(dokumen.FilePath + tmpSuffixDokumen)); !os.IsNotExist(err) {
+		t.Fatalf("file sementara harus sudah dipindahkan, got %v", err)
+	}
+}
+
+func TestIssueRestoresMissingFile(t *testing.T) {
+	pesanan := models.Pesanan{
+		ID: uuid.New(), Kode: "ORD-20261017-0005", BuyerID: uuid.New(),
+		OrderStatus: models.OrderStatusCancelled, PaymentStatus: models.PaymentStatusPaid, DeliveryType: models.DeliveryTypeForwarder,
+	}
+	db, _ := fakedb.OpenPesanan(t, pesanan, nil)
+	repo := &fakeDokumenRepository{dokumen: []models.PesananDokumen{{
+		ID: uuid.New(), PesananID: pesanan.ID, Tipe: models.DokumenTipeInvoice, Nomor: "INV/2026/000001",
+		Snapshot: &models.DokumenSnapshot{KodePesanan: pesanan.Kode, Total: decimal.NewFromInt(500000)},
+	}}}
+	s := &pesananDokumenService{db: db, dokumenRepo: repo, cfg: &config.Config{DokumenStoragePath: t.TempDir()}}
+
+	dokumen, err := s.issue(context.Background(), pesanan.ID, models.DokumenTipeCreditNote, nil)
+	if err != nil {
+		t.Fatalf("penerbitan nota kredit harus berhasil: %v", err)
+	}
+	// Rename setelah commit gagal: baris dokumen ada, filenya tidak
+	if err := os.Remove(s.dokumenFilePath(dokumen.FilePath)); err != nil {
+		t.Fatal(err)
+	}
+
+	ulang, err := s.issue(context.Background(), pesanan.ID, models.DokumenTipeCreditNote, nil)
+	if err != nil || ulang.Nomor != dokumen.Nomor || len(repo.dokumen) != 2 {
+		t.Fatalf("penerbitan ulang harus memakai nomor yang sama, got %v err=%v dokumen=%d", ulang, err, len(repo.dokumen))
+	}
+	data, err := os.ReadFile(s.dokumenFilePath(dokumen.FilePath))
+	if err != nil || checksumDokumen(data) != dokumen.Checksum {
+		t.Fatalf("file yang hilang harus ditulis ulang sama persis, got err=%v", err)
+	}
+
+	// Unduhan juga memulihkan file yang hilang
+	_ = os.Remove(s.dokumenFilePath(dokumen.FilePath))
+	file, err := s.download(context.Background(), pesanan.ID, dokumen.ID.String())
+	if err != nil || checksumDokumen(file.Data) != dokumen.Checksum {
+		t.Fatalf("unduhan harus memulihkan file dari snapshot, got err=%v", err)
+	}
+}
+
+type fakeBuktiPengirimanRepository struct {
+	repositories.PesananBuktiPengirimanRepository
+	bukti []models.PesananBuktiPengiriman
+}
+
+func (r *fakeBuktiPengirimanRepository) FindByPesananID(context.Context, uuid.UUID) ([]models.PesananBuktiPengiriman, error) {
+	return r.bukti, nil
+}
+
+func TestDownloadArsipBundlesProofOfDelivery(t *testing.T) {
+	pesanan := models.Pesanan{
+		ID: uuid.New(), Kode: "ORD-20261017-0006", BuyerID: uuid.New(),
+		OrderStatus: models.OrderStatusCompleted, PaymentStatus: models.PaymentStatusPaid, DeliveryType: models.DeliveryTypeDeliveree,
+	}
+	db, _ := fakedb.OpenPesanan(t, pesanan, nil)
+	cfg := &config.Config{DokumenStoragePath: t.TempDir(), UploadPath: t.TempDir()}
+
+	invoicePDF := []byte("%PDF-1.4 invoice")
+	invoice := models.PesananDokumen{
+		ID: uuid.New(), PesananID: pesanan.ID, Tipe: models.DokumenTipeInvoice, Nomor: "INV/2026/000007",
+		FilePath: "invoice/2026/INV-2026-000007.pdf", Checksum: checksumDokumen(invoicePDF),
+	}
+	signature := "bukti-pengiriman/" + pesanan.ID.String() + "/ttd.png"
+	for dir, file := range map[string][2]string{
+		cfg.DokumenStoragePath: {invoice.FilePath, string(invoicePDF)},
+		cfg.UploadPath:         {signature, "png"},
+	} {
+		full := filepath.Join(dir, filepath.FromSlash(file[0]))
+		if err := os.MkdirAll(filepath.Dir(full), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(file[1]), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	penerima := "Budi"
+	buktiRepo := &fakeBuktiPengirimanRepository{}
+	s := &pesananDokumenService{db: db, dokumenRepo: &fakeDokumenRepository{dokumen: []models.PesananDokumen{invoice}}, buktiRepo: buktiRepo, cfg: cfg}
+
+	if _, err := s.DownloadArsip(context.Background(), pesanan.ID.String(), invoice.ID.String()); !errors.Is(err, ErrBuktiPengirimanKosong) {
+		t.Fatalf("pesanan tanpa bukti pengiriman harus ditolak, got %v", err)
+	}
+
+	buktiRepo.bukti = []models.PesananBuktiPengiriman{{
+		ID: uuid.New(), PesananID: pesanan.ID, BookingRef: "DLV-123", RecipientName: &penerima,
+		SignaturePath: &signature, CapturedAt: time.Now(),
+	}}
+	file, err := s.DownloadArsip(context.Background(), pesanan.ID.String(), invoice.ID.String())
+	if err != nil {
+		t.Fatalf("arsip invoice harus terbentuk: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(file.Data), int64(len(file.Data)))
+	if err != nil {
+		t.Fatalf("arsip harus ZIP valid: %v", err)
+	}
+	names := map[string]bool{}
+	for _, f := range zr.File {
+		names[f.Name] = true
+	}
+	for _, want := range []string{"INV-2026-000007.pdf", "tanda-tangan-DLV-123.png", "bukti-pengiriman-ORD-20261017-0006.pdf"} {
+		if !names[want] {
+			t.Fatalf("arsip harus berisi %s, got %v", want, names)
+		}
+	}
+}
@@ -0,0 +1,590 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// paymentLinkDuration masa berlaku payment link pesanan panel. Lebih panjang
+// dari checkout storefront karena buyer partai besar biasanya membayar setelah
+// persetujuan internal perusahaannya.
+const paymentLinkDuration = 72 * time.Hour
+
+// paymentLinkRetryDelay menunda job cadangan pembuatan payment link agar tidak
+// bersamaan dengan percobaan langsung setelah commit (timeout gateway 30 detik)
+const paymentLinkRetryDelay = 2 * time.Minute
+
+var (
+	ErrPesananPanelInvalid             = errors.New("pesanan tidak valid")
+	ErrPesananPanelBuyerNotFound       = errors.New("buyer tidak ditemukan")
+	ErrPesananPanelSubmissionNotFound  = errors.New("submission formulir partai besar tidak ditemukan")
+	ErrPesananPanelProdukTidakTersedia = errors.New("produk tidak tersedia")
+	ErrPesananPanelPaymentLink         = errors.New("gagal membuat payment link")
+)
+
+// PesananPanelService membuat pesanan dari panel untuk buyer terdaftar
+// (penjualan offline / partai besar dari formulir atau WhatsApp) dengan harga
+// negosiasi per item. Pesanan yang sudah dibuat diproses lewat alur pesanan
+// admin biasa (PesananAdminService).
+type PesananPanelService interface {
+	Create(ctx context.Context, req *models.CreatePesananRequest, adminID uuid.UUID) (*dto.PesananPanelResponse, error)
+}
+
+type pesananPanelService struct {
+	db          *gorm.DB
+	produkRepo  repositories.ProdukRepository
+	ppnRepo     repositories.PPNRepository
+	paymentLink PaymentLinkGateway
+	jobQueue    JobQueueService
+}
+
+func NewPesananPanelService(db *gorm.DB, produkRepo repositories.ProdukRepository, ppnRepo repositories.PPNRepository, paymentLink PaymentLinkGateway, jobQueue JobQueueService) PesananPanelService {
+	s := &pesananPanelService{
+		db:          db,
+		produkRepo:  produkRepo,
+		ppnRepo:     ppnRepo,
+		paymentLink: paymentLink,
+		jobQueue:    jobQueue,
+	}
+	jobQueue.RegisterHandler(models.JobTypePaymentLinkInvoice, s.handlePaymentLink, JobHandlerOptions{
+		Timeout: time.Minute,
+	})
+	return s
+}
+
+// paymentLinkPayload payload job models.JobTypePaymentLinkInvoice
+type paymentLinkPayload struct {
+	PembayaranID uuid.UUID `json:"pembayaran_id"`
+}
+
+// Create menyimpan pesanan, item, reservasi produk (is_sold) dan pembayarannya
+// dalam satu transaksi: payment link Xendit (PAYMENT_LINK) atau transfer bank
+// yang sudah diterima (TRANSFER_MANUAL, pesanan langsung lunas).
+//
+// Invoice payment link dibuat setelah commit agar panggilan ke gateway tidak
+// menahan lock pesanan & produk dan tidak ada invoice yatim saat transaksi
+// gagal. Jika gateway gagal, pesanan tetap tersimpan dengan pembayaran PENDING
+// tanpa link dan job payment link mencoba ulang.
+func (s *pesananPanelService) Create(ctx context.Context, req *models.CreatePesananRequest, adminID uuid.UUID) (*dto.PesananPanelResponse, error) {
+	buyerID, err := uuid.Parse(req.BuyerID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: buyer_id tidak valid", ErrPesananPanelInvalid)
+	}
+	produkIDs, err := parsePesananPanelProdukIDs(req.Items)
+	if err != nil {
+		return nil, err
+	}
+	deliveryType := models.DeliveryType(req.DeliveryType)
+	if deliveryType != models.DeliveryTypePickup && req.AlamatBuyerID == nil {
+		return nil, fmt.Errorf("%w: alamat_buyer_id wajib untuk pengiriman %s", ErrPesananPanelInvalid, req.DeliveryType)
+	}
+
+	persenPPN := decimal.Zero
+	ppn, err := s.ppnRepo.FindActive(ctx)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if ppn != nil {
+		persenPPN = ppn.Persentase
+	}
+
+	var pesanan models.Pesanan
+	var pembayaran models.PesananPembayaran
+	hargaNormal := make(map[uuid.UUID]decimal.Decimal, len(produkIDs))
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var buyer models.Buyer
+		if err := tx.First(&buyer, "id = ?", buyerID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPesananPanelBuyerNotFound
+			}
+			return err
+		}
+		if !buyer.IsActive {
+			return fmt.Errorf("%w: akun buyer tidak aktif", ErrPesananPanelInvalid)
+		}
+
+		submissionID, err := s.checkSubmission(tx, req.FormulirSubmissionID, buyerID)
+		if err != nil {
+			return err
+		}
+
+		pesanan = models.Pesanan{
+			BuyerID:              buyerID,
+			DeliveryType:         deliveryType,
+			PaymentType:          models.PaymentTypeRegular,
+			PaymentStatus:        models.PaymentStatusPending,
+			OrderStatus:          models.OrderStatusPending,
+			Catatan:              req.Catatan,
+			CatatanAdmin:         req.CatatanAdmin,
+			EFakturStatus:        models.EFakturStatusBelum,
+			Sumber:               models.PesananSumberPanel,
+			DibuatOleh:           &adminID,
+			FormulirSubmissionID: submissionID,
+		}
+		if deliveryType != models.DeliveryTypePickup {
+			if err := s.applyAlamat(tx, &pesanan, *req.AlamatBuyerID); err != nil {
+				return err
+			}
+		}
+
+		// Produk dikunci agar tidak terjual bersamaan lewat storefront
+		var produk []models.Produk
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", produkIDs).Find(&produk).Error; err != nil {
+			return err
+		}
+		produkByID := make(map[uuid.UUID]models.Produk, len(produk))
+		for _, p := range produk {
+			produkByID[p.ID] = p
+		}
+
+		items := make([]models.PesananItem, 0, len(req.Items))
+		harga := make([]decimal.Decimal, 0, len(req.Items))
+		for i, item := range req.Items {
+			p, ok := produkByID[produkIDs[i]]
+			if !ok {
+				return fmt.Errorf("%w: produk %s tidak ditemukan", ErrPesananPanelProdukTidakTersedia, item.ProdukID)
+			}
+			if p.IsSold || !p.IsActive {
+				return fmt.Errorf("%w: %s sudah terjual atau tidak aktif", ErrPesananPanelProdukTidakTersedia, p.NamaID)
+			}
+			normal := decimal.NewFromFloat(p.HargaSesudahDiskon).Round(2)
+			hargaNormal[p.ID] = normal
+			hargaSatuan := normal
+			if item.HargaSatuan != nil {
+				hargaSatuan = decimal.NewFromFloat(*item.HargaSatuan).Round(2)
+			}
+			harga = append(harga, hargaSatuan)
+			items = append(items, models.PesananItem{
+				ProdukID:     p.ID,
+				NamaProduk:   truncateMeta(p.NamaID, 196),
+				SKU:          p.IDCargo,
+				Qty:          1,
+				HargaSatuan:  hargaSatuan,
+				DiskonSatuan: decimal.Zero,
+				Subtotal:     hargaSatuan,
+			})
+		}
+
+		biaya := hitungBiayaPesananPanel(harga, decimal.NewFromFloat(req.BiayaPengiriman), decimal.NewFromFloat(req.BiayaLainnya), persenPPN)
+		pesanan.BiayaProduk = biaya.Produk
+		pesanan.BiayaPengiriman = biaya.Pengiriman
+		pesanan.BiayaLainnya = biaya.Lainnya
+		pesanan.BiayaPPN = biaya.PPN
+		pesanan.Total = biaya.Total
+
+		if req.ShippingQuoteID != nil && *req.ShippingQuoteID != "" {
+			if err := s.applyShippingQuote(tx, &pesanan, items, *req.ShippingQuoteID); err != nil {
+				return err
+			}
+		}
+
+		// Kode pesanan dibuat trigger trg_generate_order_code (hanya jika kode NULL)
+		if err := tx.Omit("Kode", clause.Associations).Create(&pesanan).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&pesanan, "id = ?", pesanan.ID).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].PesananID = pesanan.ID
+		}
+		if err := tx.Omit(clause.Associations).Create(&items).Error; err != nil {
+			return err
+		}
+		pesanan.Items = items
+		pesanan.Buyer = buyer
+
+		if err := s.produkRepo.WithTx(tx).UpdateIsSoldBatch(ctx, produkIDs, true); err != nil {
+			return err
+		}
+
+		note := "Pesanan dibuat dari panel"
+		if submissionID != nil {
+			note += " (formulir partai besar)"
+		}
+		if err := tx.Create(&models.PesananStatusHistory{
+			PesananID:  pesanan.ID,
+			StatusTo:   string(models.OrderStatusPending),
+			StatusType: models.StatusHistoryTypeOrder,
+			ChangedBy:  &adminID,
+			Note:       &note,
+		}).Error; err != nil {
+			return err
+		}
+
+		pembayaran, err = s.createPembayaran(ctx, tx, &pesanan, req, adminID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if pembayaran.Status == models.PaymentStatusPending {
+		if err := s.issuePaymentLink(ctx, &pesanan, &pembayaran); err != nil {
+			log.Printf("[pesanan-panel] payment link pesanan %s belum terbuat, dicoba ulang lewat job: %v", pesanan.Kode, err)
+		}
+	}
+
+	return mapPesananPanelResponse(&pesanan, &pembayaran, req.MetodeBayar, hargaNormal), nil
+}
+
+// checkSubmission memastikan submission formulir ada dan, jika dikirim oleh
+// buyer yang login, milik buyer yang sama
+func (s *pesananPanelService) checkSubmission(tx *gorm.DB, id *string, buyerID uuid.UUID) (*uuid.UUID, error) {
+	if id == nil || *id == "" {
+		return nil, nil
+	}
+	submissionID, err := uuid.Parse(*id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: formulir_submission_id tidak valid", ErrPesananPanelInvalid)
+	}
+	var submission models.FormulirPartaiBesarSubmission
+	if err := tx.First(&submission, "id = ?", submissionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPesananPanelSubmissionNotFound
+		}
+		return nil, err
+	}
+	if submission.BuyerID != nil && *submission.BuyerID != buyerID {
+		return nil, fmt.Errorf("%w: submission formulir milik buyer lain", ErrPesananPanelInvalid)
+	}
+	return &submissionID, nil
+}
+
+// applyAlamat menyalin alamat buyer ke snapshot pesanan seperti checkout storefront
+func (s *pesananPanelService) applyAlamat(tx *gorm.DB, pesanan *models.Pesanan, alamatID string) error {
+	id, err := uuid.Parse(alamatID)
+	if err != nil {
+		return fmt.Errorf("%w: alamat_buyer_id tidak valid", ErrPesananPanelInvalid)
+	}
+	var alamat models.AlamatBuyer
+	if err := tx.First(&alamat, "id = ? AND buyer_id = ?", id, pesanan.BuyerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: alamat bukan milik buyer", ErrPesananPanelInvalid)
+		}
+		return err
+	}
+
+	bagian := []string{alamat.AlamatLengkap}
+	for _, v := range []*string{alamat.Kelurahan, alamat.Kecamatan} {
+		if v != nil && *v != "" {
+			bagian = append(bagian, *v)
+		}
+	}
+	bagian = append(bagian, alamat.Kota, alamat.Provinsi)
+	snapshot := strings.Join(bagian, ", ")
+	if alamat.KodePos != nil && *alamat.KodePos != "" {
+		snapshot += " " + *alamat.KodePos
+	}
+
+	pesanan.AlamatBuyerID = &alamat.ID
+	pesanan.AlamatBuyer = &alamat
+	pesanan.AlamatSnapshot = &snapshot
+	pesanan.NamaPenerimaSnap = &alamat.NamaPenerima
+	pesanan.TeleponPenerimaSnap = &alamat.TeleponPenerima
+	pesanan.LatitudeSnapshot = alamat.Latitude
+	pesanan.LongitudeSnapshot = alamat.Longitude
+	return nil
+}
+
+// applyShippingQuote memastikan quote ongkir berlaku untuk item & alamat
+// pesanan dan biaya_pengiriman tidak lebih kecil dari harga quote, lalu
+// mencatat quote di pesanan untuk diverifikasi lagi saat booking
+func (s *pesananPanelService) applyShippingQuote(tx *gorm.DB, pesanan *models.Pesanan, items []models.PesananItem, quoteID string) error {
+	id, err := uuid.Parse(quoteID)
+	if err != nil {
+		return fmt.Errorf("%w: shipping_quote_id tidak valid", ErrPesananPanelInvalid)
+	}
+	if pesanan.DeliveryType == models.DeliveryTypePickup {
+		return fmt.Errorf("%w: pesanan PICKUP tidak memakai quote ongkir", ErrPesananPanelInvalid)
+	}
+	var quote models.ShippingQuote
+	if err := tx.First(&quote, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: quote ongkir tidak ditemukan", ErrPesananPanelInvalid)
+		}
+		return err
+	}
+
+	check := *pesanan
+	check.Items = items
+	if err := validateShippingQuote(&quote, &check, time.Now()); err != nil {
+		return fmt.Errorf("%w: %v", ErrPesananPanelInvalid, err)
+	}
+	var options []models.ShippingQuoteOption
+	if err := json.Unmarshal(quote.Options, &options); err != nil {
+		return fmt.Errorf("gagal membaca opsi quote: %w", err)
+	}
+	option := findQuoteOption(options, pesanan.DeliveryType)
+	if option == nil || !option.Available {
+		return fmt.Errorf("%w: quote tidak punya opsi %s yang tersedia", ErrPesananPanelInvalid, pesanan.DeliveryType)
+	}
+	if err := verifyQuotedShipping(option, pesanan.BiayaPengiriman); err != nil {
+		return fmt.Errorf("%w: %v", ErrPesananPanelInvalid, err)
+	}
+	pesanan.ShippingQuoteID = &quote.ID
+	return nil
+}
+
+// createPembayaran membuat satu pembayaran penuh pesanan sesuai metode bayar.
+// Pembayaran payment link disimpan PENDING tanpa invoice; invoice dibuat
+// issuePaymentLink setelah commit.
+func (s *pesananPanelService) createPembayaran(ctx context.Context, tx *gorm.DB, pesanan *models.Pesanan, req *models.CreatePesananRequest, adminID uuid.UUID) (models.PesananPembayaran, error) {
+	pembayaran := models.PesananPembayaran{
+		PesananID: pesanan.ID,
+		BuyerID:   pesanan.BuyerID,
+		Jumlah:    pesanan.Total,
+		Status:    models.PaymentStatusPending,
+	}
+	if req.MetodePembayaranID != nil && *req.MetodePembayaranID != "" {
+		id, err := uuid.Parse(*req.MetodePembayaranID)
+		if err != nil {
+			return pembayaran, fmt.Errorf("%w: metode_pembayaran_id tidak valid", ErrPesananPanelInvalid)
+		}
+		var metode models.MetodePembayaran
+		if err := tx.First(&metode, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return pembayaran, fmt.Errorf("%w: metode pembayaran tidak ditemukan", ErrPesananPanelInvalid)
+			}
+			return pembayaran, err
+		}
+		pembayaran.MetodePembayaranID = &metode.ID
+	}
+
+	if req.MetodeBayar == models.MetodeBayarTransferManual {
+		if pembayaran.MetodePembayaranID == nil {
+			var metode models.MetodePembayaran
+			err := tx.First(&metode, "kode = ?", models.KodeMetodeTransferManual).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return pembayaran, err
+			}
+			if err == nil {
+				pembayaran.MetodePembayaranID = &metode.ID
+			}
+		}
+		paidAt := time.Now()
+		if req.DibayarAt != nil {
+			if req.DibayarAt.After(paidAt) {
+				return pembayaran, fmt.Errorf("%w: dibayar_at tidak boleh di masa depan", ErrPesananPanelInvalid)
+			}
+			paidAt = *req.DibayarAt
+		}
+		referensi := strings.TrimSpace(*req.ReferensiTransfer)
+		pembayaran.Status = models.PaymentStatusPaid
+		pembayaran.PaidAt = &paidAt
+		pembayaran.IsManual = true
+		pembayaran.ReferensiTransfer = &referensi
+		pembayaran.DicatatOleh = &adminID
+		if err := tx.Omit(clause.Associations).Create(&pembayaran).Error; err != nil {
+			return pembayaran, err
+		}
+
+		// Update (bukan insert langsung PAID) agar trigger mencatat riwayat PAYMENT
+		if err := tx.Model(&models.Pesanan{}).Where("id = ?", pesanan.ID).Updates(map[string]interface{}{
+			"payment_status": models.PaymentStatusPaid,
+			"paid_at":        paidAt,
+		}).Error; err != nil {
+			return pembayaran, err
+		}
+		pesanan.PaymentStatus = models.PaymentStatusPaid
+		pesanan.PaidAt = &paidAt
+		return pembayaran, nil
+	}
+
+	externalID := pesanan.Kode
+	pembayaran.XenditExternalID = &externalID
+	if err := tx.Omit(clause.Associations).Create(&pembayaran).Error; err != nil {
+		return pembayaran, err
+	}
+	// Cadangan jika pembuatan invoice setelah commit gagal atau proses mati
+	return pembayaran, s.jobQueue.Enqueue(ctx, tx, models.JobTypePaymentLinkInvoice, paymentLinkPayload{PembayaranID: pembayaran.ID}, EnqueueOptions{
+		RunAt:     time.Now().Add(paymentLinkRetryDelay),
+		UniqueKey: models.JobTypePaymentLinkInvoice + ":" + pembayaran.ID.String(),
+	})
+}
+
+// issuePaymentLink membuat invoice Xendit untuk pembayaran PENDING yang sudah
+// di-commit lalu menyimpan link & masa berlakunya. pesanan.Buyer harus terisi.
+// Invoice yang sudah tersimpan tidak ditimpa.
+func (s *pesananPanelService) issuePaymentLink(ctx context.Context, pesanan *models.Pesanan, pembayaran *models.PesananPembayaran) error {
+	if pembayaran.XenditInvoiceID != nil {
+		return nil
+	}
+	buyer := pesanan.Buyer
+	email := ""
+	if buyer.Email != nil {
+		email = *buyer.Email
+	}
+	invoice, err := s.paymentLink.CreateInvoice(ctx, &GatewayInvoiceRequest{
+		ExternalID:   pesanan.Kode,
+		Amount:       pembayaran.Jumlah,
+		Description:  "Pembayaran pesanan " + pesanan.Kode,
+		PayerNama:    buyer.Nama,
+		PayerEmail:   email,
+		PayerTelepon: buyer.Telepon,
+		Duration:     paymentLinkDuration,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPesananPanelPaymentLink, err)
+	}
+
+	tersimpan := false
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.PesananPembayaran{}).
+			Where("id = ? AND xendit_invoice_id IS NULL", pembayaran.ID).
+			Updates(map[string]interface{}{
+				"xendit_invoice_id":  invoice.InvoiceID,
+				"xendit_payment_url": invoice.InvoiceURL,
+				"expired_at":         invoice.ExpiredAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		tersimpan = true
+		return tx.Model(&models.Pesanan{}).Where("id = ?", pesanan.ID).
+			Update("expired_at", invoice.ExpiredAt).Error
+	})
+	if err != nil {
+		return err
+	}
+	if !tersimpan {
+		// Percobaan lain sudah menyimpan invoice lebih dulu
+		log.Printf("[pesanan-panel] invoice %s pesanan %s tidak dipakai, pembayaran sudah punya invoice", invoice.InvoiceID, pesanan.Kode)
+		return nil
+	}
+	pembayaran.XenditInvoiceID = &invoice.InvoiceID
+	pembayaran.XenditPaymentURL = &invoice.InvoiceURL
+	pembayaran.ExpiredAt = &invoice.ExpiredAt
+	pesanan.ExpiredAt = &invoice.ExpiredAt
+	return nil
+}
+
+// handlePaymentLink adalah handler job models.JobTypePaymentLinkInvoice.
+// Pembayaran yang sudah punya invoice, sudah tidak PENDING atau pesanannya
+// sudah tidak menunggu pembayaran dilewati.
+func (s *pesananPanelService) handlePaymentLink(ctx context.Context, job *models.BackgroundJob) error {
+	var payload paymentLinkPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return err
+	}
+	var pembayaran models.PesananPembayaran
+	if err := s.db.WithContext(ctx).First(&pembayaran, "id = ?", payload.PembayaranID).Error; err != nil {
+		return err
+	}
+	if pembayaran.XenditInvoiceID != nil || pembayaran.Status != models.PaymentStatusPending {
+		return nil
+	}
+	var pesanan models.Pesanan
+	if err := s.db.WithContext(ctx).Preload("Buyer").First(&pesanan, "id = ?", pembayaran.PesananID).Error; err != nil {
+		return err
+	}
+	if pesanan.OrderStatus != models.OrderStatusPending {
+		return nil
+	}
+	return s.issuePaymentLink(ctx, &pesanan, &pembayaran)
+}
+
+// parsePesananPanelProdukIDs mem-parse produk_id item; setiap produk adalah
+// satu unit sehingga tidak boleh muncul dua kali
+func parsePesananPanelProdukIDs(items []models.CreatePesananItemRequest) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(items))
+	seen := make(map[uuid.UUID]bool, len(items))
+	for _, item := range items {
+		id, err := uuid.Parse(item.ProdukID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: produk_id %s tidak valid", ErrPesananPanelInvalid, item.ProdukID)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("%w: produk %s muncul lebih dari sekali", ErrPesananPanelInvalid, item.ProdukID)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+type biayaPesananPanel struct {
+	Produk     decimal.Decimal
+	Pengiriman decimal.Decimal
+	Lainnya    decimal.Decimal
+	PPN        decimal.Decimal
+	Total      decimal.Decimal
+}
+
+// hitungBiayaPesananPanel menghitung total pesanan; PPN dikenakan atas produk,
+// ongkir dan biaya lainnya (sama seperti baris jasa di e-Faktur), dibulatkan ke
+// bawah ke rupiah penuh
+func hitungBiayaPesananPanel(harga []decimal.Decimal, ongkir, lainnya, persenPPN decimal.Decimal) biayaPesananPanel {
+	b := biayaPesananPanel{
+		Produk:     decimal.Zero,
+		Pengiriman: ongkir.Round(2),
+		Lainnya:    lainnya.Round(2),
+	}
+	for _, h := range harga {
+		b.Produk = b.Produk.Add(h)
+	}
+	dpp := b.Produk.Add(b.Pengiriman).Add(b.Lainnya)
+	b.PPN = dpp.Mul(persenPPN).Div(decimal.NewFromInt(100)).Floor()
+	b.Total = dpp.Add(b.PPN)
+	return b
+}
+
+func mapPesananPanelResponse(p *models.Pesanan, bayar *models.PesananPembayaran, metodeBayar string, hargaNormal map[uuid.UUID]decimal.Decimal) *dto.PesananPanelResponse {
+	resp := &dto.PesananPanelResponse{
+		ID:                   p.ID,
+		Kode:                 p.Kode,
+		BuyerID:              p.BuyerID,
+		NamaBuyer:            p.Buyer.Nama,
+		FormulirSubmissionID: p.FormulirSubmissionID,
+		DeliveryType:         string(p.DeliveryType),
+		PaymentStatus:        string(p.PaymentStatus),
+		OrderStatus:          string(p.OrderStatus),
+		Items:                make([]dto.PesananPanelItemResponse, 0, len(p.Items)),
+		BiayaProduk:          p.BiayaProduk,
+		BiayaPengiriman:      p.BiayaPengiriman,
+		BiayaPPN:             p.BiayaPPN,
+		BiayaLainnya:         p.BiayaLainnya,
+		Total:                p.Total,
+		ExpiredAt:            p.ExpiredAt,
+		CreatedAt:            p.CreatedAt,
+		Pembayaran: dto.PesananPanelPembayaranResponse{
+			ID:                bayar.ID,
+			MetodeBayar:       metodeBayar,
+			Status:            string(bayar.Status),
+			Jumlah:            bayar.Jumlah,
+			PaymentURL:        bayar.XenditPaymentURL,
+			ReferensiTransfer: bayar.ReferensiTransfer,
+			ExpiredAt:         bayar.ExpiredAt,
+			PaidAt:            bayar.PaidAt,
+		},
+	}
+	for _, item := range p.Items {
+		resp.Items = append(resp.Items, dto.PesananPanelItemResponse{
+			ProdukID:    item.ProdukID,
+			NamaProduk:  item.NamaProduk,
+			Qty:         item.Qty,
+			HargaNormal: hargaNormal[item.ProdukID],
+			HargaSatuan: item.HargaSatuan,
+			Subtotal:    item.Subtotal,
+		})
+	}
+	return resp
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+	"project-bulky-be/internal/testutil/fakedb"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func TestHitungBiayaPesananPanel(t *testing.T) {
+	harga := []decimal.Decimal{decimal.NewFromInt(1500000), decimal.RequireFromString("999999.50")}
+	b := hitungBiayaPesananPanel(harga, decimal.NewFromInt(250000), decimal.NewFromInt(10000), decimal.NewFromInt(11))
+
+	if !b.Produk.Equal(decimal.RequireFromString("2499999.50")) {
+		t.Fatalf("biaya produk salah: %s", b.Produk)
+	}
+	// PPN 11% dari 2.759.999,50 = 303.599,945 -> dibulatkan ke bawah
+	if !b.PPN.Equal(decimal.NewFromInt(303599)) {
+		t.Fatalf("PPN salah: %s", b.PPN)
+	}
+	if !b.Total.Equal(decimal.RequireFromString("3063598.50")) {
+		t.Fatalf("total salah: %s", b.Total)
+	}
+
+	// Tanpa PPN aktif
+	b = hitungBiayaPesananPanel(harga, decimal.Zero, decimal.Zero, decimal.Zero)
+	if !b.PPN.IsZero() || !b.Total.Equal(b.Produk) {
+		t.Fatalf("tanpa PPN total harus sama dengan biaya produk: %+v", b)
+	}
+}
+
+func TestParsePesananPanelProdukIDs(t *testing.T) {
+	id := "7f3c1c1e-4b0a-4c55-9a43-5d6b2f0e8a11"
+	ids, err := parsePesananPanelProdukIDs([]models.CreatePesananItemRequest{{ProdukID: id}})
+	if err != nil || len(ids) != 1 || ids[0].String() != id {
+		t.Fatalf("produk_id valid harus ter-parse: %v %v", ids, err)
+	}
+
+	// Produk adalah unit tunggal, tidak boleh dipesan dua kali
+	_, err = parsePesananPanelProdukIDs([]models.CreatePesananItemRequest{{ProdukID: id}, {ProdukID: id}})
+	if !errors.Is(err, ErrPesananPanelInvalid) {
+		t.Fatalf("produk duplikat harus ditolak, got %v", err)
+	}
+}
+
+type fakePPNRepository struct {
+	repositories.PPNRepository
+}
+
+func (fakePPNRepository) FindActive(context.Context) (*models.PPN, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeProdukRepository struct {
+	repositories.ProdukRepository
+}
+
+func (r fakeProdukRepository) WithTx(*gorm.DB) repositories.ProdukRepository { return r }
+
+func (fakeProdukRepository) UpdateIsSoldBatch(context.Context, []uuid.UUID, bool) error { return nil }
+
+// fakePaymentLinkGateway mencatat apakah transaksi pesanan sudah di-commit
+// saat invoice dibuat
+type fakePaymentLinkGateway struct {
+	PaymentLinkGateway
+	db        *fakedb.DB
+	err       error
+	commitsAt []int
+}
+
+func (g *fakePaymentLinkGateway) CreateInvoice(context.Context, *GatewayInvoiceRequest) (*GatewayInvoiceResult, error) {
+	g.commitsAt = append(g.commitsAt, g.db.Commits())
+	if g.err != nil {
+		return nil, g.err
+	}
+	return &GatewayInvoiceResult{InvoiceID: "inv-1", InvoiceURL: "https://checkout.xendit.co/inv-1", ExpiredAt: time.Now().Add(paymentLinkDuration)}, nil
+}
+
+func TestCreatePaymentLinkAfterCommit(t *testing.T) {
+	for _, gatewayErr := range []error{nil, errors.New("gateway timeout")} {
+		buyerID, produkID, pesananID := uuid.New(), uuid.New(), uuid.New()
+		db, fake := fakedb.Open(t, func(q fakedb.Query) (*fakedb.Result, error) {
+			switch {
+			case q.Is("SELECT", "buyer"):
+				return &fakedb.Result{Columns: []string{"id", "nama", "is_active"}, Rows: [][]driver.Value{{buyerID.String(), "PT Maju", true}}}, nil
+			case q.Is("SELECT", "produk"):
+				return &fakedb.Result{
+					Columns: []string{"id", "nama_id", "harga_sesudah_diskon", "is_sold", "is_active"},
+					Rows:    [][]driver.Value{{produkID.String(), "Rak gudang", "1500000", false, true}},
+				}, nil
+			case q.Is("SELECT", "pesanan"):
+				return &fakedb.Result{
+					Columns: []string{"id", "kode", "buyer_id", "order_status", "payment_status", "total"},
+					Rows:    [][]driver.Value{{pesananID.String(), "ORD-20261017-0008", buyerID.String(), "PENDING", "PENDING", "1500000"}},
+				}, nil
+			}
+			return nil, nil
+		})
+		gateway := &fakePaymentLinkGateway{db: fake, err: gatewayErr}
+		jobQueue := &fakeJobQueue{}
+		service := NewPesananPanelService(db, fakeProdukRepository{}, fakePPNRepository{}, gateway, jobQueue)
+
+		result, err := service.Create(context.Background(), &models.CreatePesananRequest{
+			BuyerID:      buyerID.String(),
+			DeliveryType: string(models.DeliveryTypePickup),
+			Items:        []models.CreatePesananItemRequest{{ProdukID: produkID.String()}},
+			MetodeBayar:  models.MetodeBayarPaymentLink,
+		}, uuid.New())
+		if err != nil {
+			t.Fatalf("gateway err=%v: pesanan harus tetap tersimpan, got %v", gatewayErr, err)
+		}
+		// Invoice dibuat setelah transaksi pesanan commit, tidak di dalamnya
+		if len(gateway.commitsAt) != 1 || gateway.commitsAt[0] != 1 {
+			t.Fatalf("gateway err=%v: invoice harus dibuat tepat sekali setelah commit, got %v", gatewayErr, gateway.commitsAt)
+		}
+		if len(jobQueue.jobs) != 1 || jobQueue.jobs[0] != models.JobTypePaymentLinkInvoice || !jobQueue.inTx[0] {
+			t.Fatalf("gateway err=%v: job cadangan payment link harus di-enqueue di transaksi, got %v", gatewayErr, jobQueue.jobs)
+		}
+		if got := result.Pembayaran.PaymentURL != nil; got != (gatewayErr == nil) {
+			t.Fatalf("gateway err=%v: payment_url terisi=%v", gatewayErr, got)
+		}
+	}
+}
@@ -0,0 +1,883 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/orderstate"
+	"project-bulky-be/internal/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultDurasiSlotMenit = 60
+	defaultKapasitasSlot   = 5
+	minDurasiSlotMenit     = 15
+	maxDurasiSlotMenit     = 480
+
+	// pickupHorizonHari batas hari ke depan yang slotnya bisa dipesan buyer
+	pickupHorizonHari = 14
+	// maxKalenderHari rentang tanggal maksimal satu request kalender admin
+	maxKalenderHari = 31
+)
+
+var (
+	ErrPickupPesananNotFound       = errors.New("pesanan tidak ditemukan")
+	ErrPickupNotAllowed            = errors.New("janji pickup hanya untuk pesanan PICKUP berstatus READY")
+	ErrPickupSlotFull              = errors.New("slot pickup sudah penuh, silakan pilih slot lain")
+	ErrPickupAppointmentExists     = errors.New("pesanan sudah punya janji pickup di gudang ini, gunakan jadwal ulang")
+	ErrPickupAppointmentNotFound   = errors.New("janji pickup tidak ditemukan")
+	ErrPickupAppointmentNotActive  = errors.New("janji pickup sudah dibatalkan atau selesai")
+	ErrJadwalPengecualianNotFound  = errors.New("pengecualian jadwal tidak ditemukan")
+	ErrJadwalPengecualianDuplicate = errors.New("pengecualian untuk gudang & tanggal tersebut sudah ada")
+)
+
+// PickupAppointmentService mengelola janji pickup buyer di gudang. Slot
+// dibentuk dari jadwal_gudang (jam buka-tutup, durasi & kapasitas slot) dan
+// ditimpa jadwal_gudang_pengecualian untuk hari libur/tutup atau jam khusus.
+type PickupAppointmentService interface {
+	// Buyer
+	GetSlots(ctx context.Context, buyerID, pesananID string, params *dto.PickupSlotQueryParams) ([]dto.PickupSlotHariResponse, error)
+	GetAppointments(ctx context.Context, buyerID, pesananID string) ([]dto.PickupAppointmentResponse, error)
+	Book(ctx context.Context, buyerID, pesananID string, req *dto.BookPickupAppointmentRequest) (*dto.PickupAppointmentResponse, error)
+	Reschedule(ctx context.Context, buyerID, pesananID, appointmentID string, req *dto.ReschedulePickupAppointmentRequest) (*dto.PickupAppointmentResponse, error)
+	Cancel(ctx context.Context, buyerID, pesananID, appointmentID string, req *dto.CancelPickupAppointmentRequest) error
+	// Admin
+	Calendar(ctx context.Context, params *dto.PickupKalenderQueryParams) ([]dto.PickupKalenderHariResponse, error)
+	GetPengecualian(ctx context.Context, params *dto.JadwalGudangPengecualianQueryParams) ([]dto.JadwalGudangPengecualianResponse, error)
+	CreatePengecualian(ctx context.Context, req *dto.JadwalGudangPengecualianRequest) (*dto.JadwalGudangPengecualianResponse, error)
+	UpdatePengecualian(ctx context.Context, id string, req *dto.JadwalGudangPengecualianRequest) (*dto.JadwalGudangPengecualianResponse, error)
+	DeletePengecualian(ctx context.Context, id string) error
+}
+
+type pickupAppointmentService struct {
+	db               *gorm.DB
+	appointmentRepo  repositories.PickupAppointmentRepository
+	pengecualianRepo repositories.JadwalGudangPengecualianRepository
+	jadwalRepo       repositories.JadwalGudangRepository
+	warehouseRepo    repositories.WarehouseRepository
+}
+
+// NewPickupAppointmentService juga mendaftarkan hook orderMachine: janji yang
+// masih BOOKED ikut selesai saat pesanan COMPLETED dan batal saat CANCELLED.
+func NewPickupAppointmentService(db *gorm.DB, appointmentRepo repositories.PickupAppointmentRepository, pengecualianRepo repositories.JadwalGudangPengecualianRepository, jadwalRepo repositories.JadwalGudangRepository, warehouseRepo repositories.WarehouseRepository, orderMachine *orderstate.Machine) PickupAppointmentService {
+	s := &pickupAppointmentService{
+		db:               db,
+		appointmentRepo:  appointmentRepo,
+		pengecualianRepo: pengecualianRepo,
+		jadwalRepo:       jadwalRepo,
+		warehouseRepo:    warehouseRepo,
+	}
+	orderMachine.OnEnterTx(models.OrderStatusCompleted, s.closeAppointments)
+	orderMachine.OnEnterTx(models.OrderStatusCancelled, s.closeAppointments)
+	return s
+}
+
+// ========================================
+// Buyer
+// ========================================
+
+func (s *pickupAppointmentService) GetSlots(ctx context.Context, buyerID, pesananID string, params *dto.PickupSlotQueryParams) ([]dto.PickupSlotHariResponse, error) {
+	pesanan, err := s.buyerPesanan(ctx, buyerID, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	if pesanan.DeliveryType != models.DeliveryTypePickup {
+		return nil, ErrPickupNotAllowed
+	}
+
+	today := pickupToday()
+	tanggal := today
+	if params.Tanggal != "" {
+		if tanggal, err = parseTanggalWIB(params.Tanggal); err != nil {
+			return nil, err
+		}
+	}
+	if tanggal.Before(today) || tanggal.After(today.AddDate(0, 0, pickupHorizonHari)) {
+		return nil, fmt.Errorf("tanggal pickup harus antara hari ini dan %d hari ke depan", pickupHorizonHari)
+	}
+
+	warehouses, err := s.pesananWarehouses(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	if params.WarehouseID != "" {
+		warehouse, err := pickWarehouse(warehouses, &params.WarehouseID)
+		if err != nil {
+			return nil, err
+		}
+		warehouses = []models.Warehouse{*warehouse}
+	}
+
+	now := time.Now()
+	result := []dto.PickupSlotHariResponse{}
+	for _, warehouse := range warehouses {
+		hari, err := s.pickupHari(ctx, warehouse.ID, tanggal)
+		if err != nil {
+			return nil, err
+		}
+		booked, err := s.appointmentRepo.FindBooked(ctx, warehouse.ID, tanggal, tanggal.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, err
+		}
+
+		slots := []dto.PickupSlotResponse{}
+		for _, slot := range hari.Slots {
+			terisi := countOverlappingAppointments(slot, booked)
+			sisa := max(slot.Kapasitas-terisi, 0)
+			slots = append(slots, dto.PickupSlotResponse{
+				Mulai:     slot.Mulai,
+				Selesai:   slot.Selesai,
+				Kapasitas: slot.Kapasitas,
+				Terisi:    terisi,
+				Sisa:      sisa,
+				Tersedia:  sisa > 0 && slot.Mulai.After(now),
+			})
+		}
+		result = append(result, dto.PickupSlotHariResponse{
+			WarehouseID:   warehouse.ID.String(),
+			NamaWarehouse: warehouse.Nama,
+			Tanggal:       tanggal.Format("2006-01-02"),
+			IsTutup:       hari.IsTutup,
+			Keterangan:    hari.Keterangan,
+			Slot:          slots,
+		})
+	}
+	return result, nil
+}
+
+func (s *pickupAppointmentService) GetAppointments(ctx context.Context, buyerID, pesananID string) ([]dto.PickupAppointmentResponse, error) {
+	pesanan, err := s.buyerPesanan(ctx, buyerID, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	appointments, err := s.appointmentRepo.FindActiveByPesananID(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []dto.PickupAppointmentResponse{}
+	for i := range appointments {
+		result = append(result, toPickupAppointmentResponse(&appointments[i]))
+	}
+	return result, nil
+}
+
+func (s *pickupAppointmentService) Book(ctx context.Context, buyerID, pesananID string, req *dto.BookPickupAppointmentRequest) (*dto.PickupAppointmentResponse, error) {
+	pesanan, err := s.readyPickupPesanan(ctx, buyerID, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	warehouses, err := s.pesananWarehouses(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	warehouse, err := pickWarehouse(warehouses, req.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := s.appointmentRepo.FindActiveByPesananID(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, appointment := range active {
+		if appointment.WarehouseID == warehouse.ID {
+			return nil, ErrPickupAppointmentExists
+		}
+	}
+
+	slot, err := s.resolveSlot(ctx, warehouse.ID, req.SlotMulai)
+	if err != nil {
+		return nil, err
+	}
+	appointment := &models.PickupAppointment{
+		PesananID:   pesanan.ID,
+		WarehouseID: warehouse.ID,
+		BuyerID:     pesanan.BuyerID,
+		SlotMulai:   slot.Mulai,
+		SlotSelesai: slot.Selesai,
+		Status:      models.PickupAppointmentBooked,
+		Catatan:     req.Catatan,
+	}
+	if err := s.book(ctx, appointment, slot.Kapasitas, nil); err != nil {
+		return nil, err
+	}
+
+	appointment.Warehouse = warehouse
+	resp := toPickupAppointmentResponse(appointment)
+	return &resp, nil
+}
+
+func (s *pickupAppointmentService) Reschedule(ctx context.Context, buyerID, pesananID, appointmentID string, req *dto.ReschedulePickupAppointmentRequest) (*dto.PickupAppointmentResponse, error) {
+	pesanan, err := s.readyPickupPesanan(ctx, buyerID, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.pesananAppointment(ctx, pesanan.ID, appointmentID)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status != models.PickupAppointmentBooked {
+		return nil, ErrPickupAppointmentNotActive
+	}
+
+	slot, err := s.resolveSlot(ctx, current.WarehouseID, req.SlotMulai)
+	if err != nil {
+		return nil, err
+	}
+	if slot.Mulai.Equal(current.SlotMulai) {
+		return nil, errors.New("slot baru sama dengan janji pickup saat ini")
+	}
+
+	catatan := current.Catatan
+	if req.Catatan != nil {
+		catatan = req.Catatan
+	}
+	appointment := &models.PickupAppointment{
+		PesananID:   pesanan.ID,
+		WarehouseID: current.WarehouseID,
+		BuyerID:     pesanan.BuyerID,
+		SlotMulai:   slot.Mulai,
+		SlotSelesai: slot.Selesai,
+		Status:      models.PickupAppointmentBooked,
+		Catatan:     catatan,
+	}
+	if err := s.book(ctx, appointment, slot.Kapasitas, &current.ID); err != nil {
+		return nil, err
+	}
+
+	appointment.Warehouse = current.Warehouse
+	resp := toPickupAppointmentResponse(appointment)
+	return &resp, nil
+}
+
+func (s *pickupAppointmentService) Cancel(ctx context.Context, buyerID, pesananID, appointmentID string, req *dto.CancelPickupAppointmentRequest) error {
+	pesanan, err := s.buyerPesanan(ctx, buyerID, pesananID)
+	if err != nil {
+		return err
+	}
+	appointment, err := s.pesananAppointment(ctx, pesanan.ID, appointmentID)
+	if err != nil {
+		return err
+	}
+
+	cancelled, err := s.appointmentRepo.Cancel(ctx, appointment.ID, req.Alasan)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return ErrPickupAppointmentNotActive
+	}
+	return nil
+}
+
+// book menyimpan janji lewat repository dan memetakan hasilnya ke error layanan
+func (s *pickupAppointmentService) book(ctx context.Context, appointment *models.PickupAppointment, kapasitas int, replaceID *uuid.UUID) error {
+	booked, err := s.appointmentRepo.Book(ctx, appointment, kapasitas, replaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPickupAppointmentNotActive
+		}
+		if strings.Contains(err.Error(), "duplicate key value") {
+			return ErrPickupAppointmentExists
+		}
+		return err
+	}
+	if !booked {
+		return ErrPickupSlotFull
+	}
+	return nil
+}
+
+// resolveSlot mencocokkan slot_mulai dari buyer dengan slot yang dibentuk
+// jadwal gudang pada tanggal tersebut
+func (s *pickupAppointmentService) resolveSlot(ctx context.Context, warehouseID uuid.UUID, value string) (*pickupSlot, error) {
+	mulai, err := parseSlotMulai(value)
+	if err != nil {
+		return nil, err
+	}
+	if !mulai.After(time.Now()) {
+		return nil, errors.New("slot pickup sudah lewat")
+	}
+
+	mulaiWIB := mulai.In(jakartaLocation)
+	tanggal := time.Date(mulaiWIB.Year(), mulaiWIB.Month(), mulaiWIB.Day(), 0, 0, 0, 0, jakartaLocation)
+	if tanggal.After(pickupToday().AddDate(0, 0, pickupHorizonHari)) {
+		return nil, fmt.Errorf("janji pickup hanya bisa dibuat maksimal %d hari ke depan", pickupHorizonHari)
+	}
+
+	hari, err := s.pickupHari(ctx, warehouseID, tanggal)
+	if err != nil {
+		return nil, err
+	}
+	if hari.IsTutup {
+		return nil, errors.New("gudang tutup pada tanggal tersebut")
+	}
+	for _, slot := range hari.Slots {
+		if slot.Mulai.Equal(mulai) {
+			if slot.Kapasitas <= 0 {
+				return nil, ErrPickupSlotFull
+			}
+			return &slot, nil
+		}
+	}
+	return nil, errors.New("slot pickup tidak tersedia di jadwal gudang")
+}
+
+// closeAppointments dipanggil di transaksi perubahan status pesanan: janji
+// BOOKED selesai saat pesanan COMPLETED dan batal saat pesanan CANCELLED
+func (s *pickupAppointmentService) closeAppointments(tx *gorm.DB, pesanan *models.Pesanan, from models.OrderStatus, trigger orderstate.Trigger) error {
+	if pesanan.DeliveryType != models.DeliveryTypePickup {
+		return nil
+	}
+
+	updates := map[string]interface{}{"status": models.PickupAppointmentCompleted}
+	if pesanan.OrderStatus == models.OrderStatusCancelled {
+		updates = map[string]interface{}{
+			"status":       models.PickupAppointmentCancelled,
+			"alasan_batal": "Pesanan dibatalkan",
+			"cancelled_at": time.Now(),
+		}
+	}
+	return tx.Model(&models.PickupAppointment{}).
+		Where("pesanan_id = ? AND status = ?", pesanan.ID, models.PickupAppointmentBooked).
+		Updates(updates).Error
+}
+
+func (s *pickupAppointmentService) buyerPesanan(ctx context.Context, buyerID, pesananID string) (*models.Pesanan, error) {
+	id, err := uuid.Parse(pesananID)
+	if err != nil {
+		return nil, ErrPickupPesananNotFound
+	}
+	var pesanan models.Pesanan
+	if err := s.db.WithContext(ctx).First(&pesanan, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPickupPesananNotFound
+		}
+		return nil, err
+	}
+	// Pesanan buyer lain diperlakukan tidak ada agar ID pesanan tidak bisa ditebak
+	if pesanan.BuyerID.String() != buyerID {
+		return nil, ErrPickupPesananNotFound
+	}
+	return &pesanan, nil
+}
+
+func (s *pickupAppointmentService) readyPickupPesanan(ctx context.Context, buyerID, pesananID string) (*models.Pesanan, error) {
+	pesanan, err := s.buyerPesanan(ctx, buyerID, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	if pesanan.DeliveryType != models.DeliveryTypePickup || pesanan.OrderStatus != models.OrderStatusReady {
+		return nil, ErrPickupNotAllowed
+	}
+	return pesanan, nil
+}
+
+func (s *pickupAppointmentService) pesananAppointment(ctx context.Context, pesananID uuid.UUID, appointmentID string) (*models.PickupAppointment, error) {
+	id, err := uuid.Parse(appointmentID)
+	if err != nil {
+		return nil, ErrPickupAppointmentNotFound
+	}
+	appointment, err := s.appointmentRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPickupAppointmentNotFound
+		}
+		return nil, err
+	}
+	if appointment.PesananID != pesananID {
+		return nil, ErrPickupAppointmentNotFound
+	}
+	return appointment, nil
+}
+
+// pesananWarehouses mengembalikan gudang asal item pesanan; buyer mengambil
+// barang di setiap gudang tersebut
+func (s *pickupAppointmentService) pesananWarehouses(ctx context.Context, pesananID uuid.UUID) ([]models.Warehouse, error) {
+	var warehouses []models.Warehouse
+	err := s.db.WithContext(ctx).
+		Where("id IN (SELECT p.warehouse_id FROM pesanan_item pi JOIN produk p ON p.id = pi.produk_id WHERE pi.pesanan_id = ?)", pesananID).
+		Order("nama ASC").
+		Find(&warehouses).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(warehouses) == 0 {
+		return nil, errors.New("gudang pickup pesanan tidak ditemukan")
+	}
+	return warehouses, nil
+}
+
+// pickWarehouse memilih gudang pickup; warehouse_id boleh kosong jika pesanan hanya dari satu gudang
+func pickWarehouse(warehouses []models.Warehouse, warehouseID *string) (*models.Warehouse, error) {
+	if warehouseID == nil || *warehouseID == "" {
+		if len(warehouses) > 1 {
+			return nil, errors.New("warehouse_id wajib diisi karena pesanan diambil dari lebih dari satu gudang")
+		}
+		return &warehouses[0], nil
+	}
+	for i := range warehouses {
+		if warehouses[i].ID.String() == *warehouseID {
+			return &warehouses[i], nil
+		}
+	}
+	return nil, errors.New("gudang bukan lokasi pickup pesanan ini")
+}
+
+// pickupHari membentuk slot satu gudang pada satu tanggal dari jadwal & pengecualiannya
+func (s *pickupAppointmentService) pickupHari(ctx context.Context, warehouseID uuid.UUID, tanggal time.Time) (pickupHari, error) {
+	jadwalList, err := s.jadwalRepo.FindByWarehouseID(ctx, warehouseID)
+	if err != nil {
+		return pickupHari{}, err
+	}
+	pengecualianList, err := s.pengecualianRepo.FindByRange(ctx, &warehouseID, tanggal, tanggal)
+	if err != nil {
+		return pickupHari{}, err
+	}
+
+	var pengecualian *models.JadwalGudangPengecualian
+	if len(pengecualianList) > 0 {
+		pengecualian = &pengecualianList[0]
+	}
+	return generatePickupSlots(tanggal, findJadwalHari(jadwalList, tanggal), pengecualian), nil
+}
+
+// ========================================
+// Admin
+// ========================================
+
+// Calendar menampilkan janji pickup per tanggal per gudang beserta jam buka
+// hari itu (setelah pengecualian), untuk rentang maksimal maxKalenderHari
+func (s *pickupAppointmentService) Calendar(ctx context.Context, params *dto.PickupKalenderQueryParams) ([]dto.PickupKalenderHariResponse, error) {
+	dari, sampai, err := parseRentangTanggal(params.TanggalDari, params.TanggalSampai, pickupToday())
+	if err != nil {
+		return nil, err
+	}
+	if sampai.Sub(dari) >= maxKalenderHari*24*time.Hour {
+		return nil, fmt.Errorf("rentang kalender maksimal %d hari", maxKalenderHari)
+	}
+	if params.Status != "" {
+		switch models.PickupAppointmentStatus(params.Status) {
+		case models.PickupAppointmentBooked, models.PickupAppointmentCancelled, models.PickupAppointmentCompleted:
+		default:
+			return nil, errors.New("status harus BOOKED, CANCELLED, atau COMPLETED")
+		}
+	}
+
+	var warehouses []models.Warehouse
+	filter := repositories.PickupAppointmentFilter{Status: params.Status}
+	if params.WarehouseID != "" {
+		warehouse, err := s.warehouseRepo.FindByID(ctx, params.WarehouseID)
+		if err != nil {
+			return nil, errors.New("warehouse tidak ditemukan")
+		}
+		warehouses = []models.Warehouse{*warehouse}
+		filter.WarehouseID = &warehouse.ID
+	} else {
+		isActive := true
+		list, _, err := s.warehouseRepo.FindAll(ctx, &models.PaginationRequest{Page: 1, PerPage: 100, SortBy: "nama", Order: "asc", IsActive: &isActive}, "")
+		if err != nil {
+			return nil, err
+		}
+		warehouses = list
+	}
+
+	appointments, err := s.appointmentRepo.FindByRange(ctx, filter, dari, sampai.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	pengecualianList, err := s.pengecualianRepo.FindByRange(ctx, filter.WarehouseID, dari, sampai)
+	if err != nil {
+		return nil, err
+	}
+	pengecualianByKey := map[string]*models.JadwalGudangPengecualian{}
+	for i := range pengecualianList {
+		p := &pengecualianList[i]
+		pengecualianByKey[p.WarehouseID.String()+"|"+p.Tanggal.Format("2006-01-02")] = p
+	}
+	appointmentsByKey := map[string][]models.PickupAppointment{}
+	for _, appointment := range appointments {
+		key := appointment.WarehouseID.String() + "|" + appointment.SlotMulai.In(jakartaLocation).Format("2006-01-02")
+		appointmentsByKey[key] = append(appointmentsByKey[key], appointment)
+	}
+
+	jadwalByWarehouse := map[uuid.UUID][]models.JadwalGudang{}
+	for _, warehouse := range warehouses {
+		if jadwalByWarehouse[warehouse.ID], err = s.jadwalRepo.FindByWarehouseID(ctx, warehouse.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	result := []dto.PickupKalenderHariResponse{}
+	for tanggal := dari; !tanggal.After(sampai); tanggal = tanggal.AddDate(0, 0, 1) {
+		for _, warehouse := range warehouses {
+			key := warehouse.ID.String() + "|" + tanggal.Format("2006-01-02")
+			hari := generatePickupSlots(tanggal, findJadwalHari(jadwalByWarehouse[warehouse.ID], tanggal), pengecualianByKey[key])
+
+			janji := []dto.PickupKalenderItemResponse{}
+			for _, appointment := range appointmentsByKey[key] {
+				janji = append(janji, toPickupKalenderItemResponse(&appointment))
+			}
+			result = append(result, dto.PickupKalenderHariResponse{
+				Tanggal:       tanggal.Format("2006-01-02"),
+				WarehouseID:   warehouse.ID.String(),
+				NamaWarehouse: warehouse.Nama,
+				IsTutup:       hari.IsTutup,
+				Keterangan:    hari.Keterangan,
+				JamBuka:       hari.JamBuka,
+				JamTutup:      hari.JamTutup,
+				TotalJanji:    len(janji),
+				Janji:         janji,
+			})
+		}
+	}
+	return result, nil
+}
+
+func (s *pickupAppointmentService) GetPengecualian(ctx context.Context, params *dto.JadwalGudangPengecualianQueryParams) ([]dto.JadwalGudangPengecualianResponse, error) {
+	today := pickupToday()
+	dari, sampai, err := parseRentangTanggal(params.TanggalDari, params.TanggalSampai, today)
+	if err != nil {
+		return nil, err
+	}
+	// Tanpa tanggal_sampai, tampilkan pengecualian setahun ke depan
+	if params.TanggalSampai == "" {
+		sampai = dari.AddDate(1, 0, 0)
+	}
+
+	var warehouseID *uuid.UUID
+	if params.WarehouseID != "" {
+		id, err := uuid.Parse(params.WarehouseID)
+		if err != nil {
+			return nil, errors.New("warehouse_id tidak valid")
+		}
+		warehouseID = &id
+	}
+
+	list, err := s.pengecualianRepo.FindByRange(ctx, warehouseID, dari, sampai)
+	if err != nil {
+		return nil, err
+	}
+	result := []dto.JadwalGudangPengecualianResponse{}
+	for i := range list {
+		result = append(result, toJadwalGudangPengecualianResponse(&list[i]))
+	}
+	return result, nil
+}
+
+func (s *pickupAppointmentService) CreatePengecualian(ctx context.Context, req *dto.JadwalGudangPengecualianRequest) (*dto.JadwalGudangPengecualianResponse, error) {
+	pengecualian := &models.JadwalGudangPengecualian{}
+	if err := s.applyPengecualianRequest(ctx, pengecualian, req); err != nil {
+		return nil, err
+	}
+	if err := s.pengecualianRepo.Create(ctx, pengecualian); err != nil {
+		if strings.Contains(err.Error(), "duplicate key value") {
+			return nil, ErrJadwalPengecualianDuplicate
+		}
+		return nil, err
+	}
+
+	resp := toJadwalGudangPengecualianResponse(pengecualian)
+	return &resp, nil
+}
+
+func (s *pickupAppointmentService) UpdatePengecualian(ctx context.Context, id string, req *dto.JadwalGudangPengecualianRequest) (*dto.JadwalGudangPengecualianResponse, error) {
+	pengecualian, err := s.findPengecualian(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyPengecualianRequest(ctx, pengecualian, req); err != nil {
+		return nil, err
+	}
+	if err := s.pengecualianRepo.Update(ctx, pengecualian); err != nil {
+		if strings.Contains(err.Error(), "duplicate key value") {
+			return nil, ErrJadwalPengecualianDuplicate
+		}
+		return nil, err
+	}
+
+	resp := toJadwalGudangPengecualianResponse(pengecualian)
+	return &resp, nil
+}
+
+func (s *pickupAppointmentService) DeletePengecualian(ctx context.Context, id string) error {
+	pengecualian, err := s.findPengecualian(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.pengecualianRepo.Delete(ctx, pengecualian)
+}
+
+func (s *pickupAppointmentService) findPengecualian(ctx context.Context, id string) (*models.JadwalGudangPengecualian, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrJadwalPengecualianNotFound
+	}
+	pengecualian, err := s.pengecualianRepo.FindByID(ctx, parsed)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJadwalPengecualianNotFound
+		}
+		return nil, err
+	}
+	return pengecualian, nil
+}
+
+// applyPengecualianRequest memvalidasi request lalu menyalinnya ke model.
+// Janji yang sudah ada di tanggal tersebut tidak dibatalkan otomatis; admin
+// melihatnya di kalender dan menghubungi buyer.
+func (s *pickupAppointmentService) applyPengecualianRequest(ctx context.Context, pengecualian *models.JadwalGudangPengecualian, req *dto.JadwalGudangPengecualianRequest) error {
+	warehouse, err := s.warehouseRepo.FindByID(ctx, req.WarehouseID)
+	if err != nil {
+		return errors.New("warehouse tidak ditemukan")
+	}
+	tanggal, err := parseTanggalWIB(req.Tanggal)
+	if err != nil {
+		return err
+	}
+
+	pengecualian.WarehouseID = warehouse.ID
+	pengecualian.Tanggal = time.Date(tanggal.Year(), tanggal.Month(), tanggal.Day(), 0, 0, 0, 0, time.UTC)
+	pengecualian.IsTutup = req.IsTutup
+	pengecualian.Keterangan = req.Keterangan
+	if req.IsTutup {
+		pengecualian.JamBuka = nil
+		pengecualian.JamTutup = nil
+		pengecualian.KapasitasSlot = nil
+		return nil
+	}
+
+	if req.JamBuka == nil || req.JamTutup == nil {
+		return errors.New("jam buka dan jam tutup wajib diisi jika gudang tidak tutup")
+	}
+	buka, err := parseJamMenit(*req.JamBuka)
+	if err != nil {
+		return err
+	}
+	tutup, err := parseJamMenit(*req.JamTutup)
+	if err != nil {
+		return err
+	}
+	if buka >= tutup {
+		return errors.New("jam tutup harus lebih besar dari jam buka")
+	}
+	pengecualian.JamBuka = req.JamBuka
+	pengecualian.JamTutup = req.JamTutup
+	pengecualian.KapasitasSlot = req.KapasitasSlot
+	return nil
+}
+
+// ========================================
+// Pembentukan slot
+// ========================================
+
+type pickupSlot struct {
+	Mulai     time.Time
+	Selesai   time.Time
+	Kapasitas int
+}
+
+// pickupHari jam operasional satu gudang pada satu tanggal setelah pengecualian
+type pickupHari struct {
+	IsTutup    bool
+	Keterangan *string
+	JamBuka    *string
+	JamTutup   *string
+	Slots      []pickupSlot
+}
+
+// generatePickupSlots membagi jam buka-tutup tanggal (00:00 WIB) menjadi slot
+// sepanjang durasi_slot_menit. Pengecualian menimpa jadwal mingguan: tutup
+// seharian, atau jam buka-tutup/kapasitas khusus. Sisa waktu yang tidak cukup
+// untuk satu slot penuh sebelum jam tutup tidak dijadikan slot.
+func generatePickupSlots(tanggal time.Time, jadwal *models.JadwalGudang, pengecualian *models.JadwalGudangPengecualian) pickupHari {
+	durasi, kapasitas := defaultDurasiSlotMenit, defaultKapasitasSlot
+	var jamBuka, jamTutup *string
+	if jadwal != nil {
+		if jadwal.DurasiSlotMenit > 0 {
+			durasi = jadwal.DurasiSlotMenit
+		}
+		if jadwal.KapasitasSlot > 0 {
+			kapasitas = jadwal.KapasitasSlot
+		}
+		if jadwal.IsBuka {
+			jamBuka, jamTutup = jadwal.JamBuka, jadwal.JamTutup
+		}
+	}
+
+	hari := pickupHari{}
+	if pengecualian != nil {
+		hari.Keterangan = pengecualian.Keterangan
+		if pengecualian.IsTutup {
+			hari.IsTutup = true
+			return hari
+		}
+		jamBuka, jamTutup = pengecualian.JamBuka, pengecualian.JamTutup
+		if pengecualian.KapasitasSlot != nil {
+			kapasitas = *pengecualian.KapasitasSlot
+		}
+	}
+
+	if jamBuka == nil || jamTutup == nil {
+		hari.IsTutup = true
+		return hari
+	}
+	buka, errBuka := parseJamMenit(*jamBuka)
+	tutup, errTutup := parseJamMenit(*jamTutup)
+	if errBuka != nil || errTutup != nil || buka >= tutup {
+		hari.IsTutup = true
+		return hari
+	}
+
+	hari.JamBuka, hari.JamTutup = jamBuka, jamTutup
+	for menit := buka; menit+durasi <= tutup; menit += durasi {
+		mulai := tanggal.Add(time.Duration(menit) * time.Minute)
+		hari.Slots = append(hari.Slots, pickupSlot{
+			Mulai:     mulai,
+			Selesai:   mulai.Add(time.Duration(durasi) * time.Minute),
+			Kapasitas: kapasitas,
+		})
+	}
+	return hari
+}
+
+// countOverlappingAppointments menghitung janji yang bertumpuk dengan slot,
+// sama seperti pengecekan kapasitas saat booking
+func countOverlappingAppointments(slot pickupSlot, appointments []models.PickupAppointment) int {
+	total := 0
+	for _, appointment := range appointments {
+		if appointment.SlotMulai.Before(slot.Selesai) && appointment.SlotSelesai.After(slot.Mulai) {
+			total++
+		}
+	}
+	return total
+}
+
+func findJadwalHari(jadwalList []models.JadwalGudang, tanggal time.Time) *models.JadwalGudang {
+	for i := range jadwalList {
+		if jadwalList[i].Hari == int(tanggal.Weekday()) {
+			return &jadwalList[i]
+		}
+	}
+	return nil
+}
+
+// parseJamMenit membaca jam "15:04" atau "15:04:05" (kolom TIME) menjadi menit sejak 00:00
+func parseJamMenit(value string) (int, error) {
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Hour()*60 + t.Minute(), nil
+		}
+	}
+	return 0, fmt.Errorf("format jam %q tidak valid, gunakan HH:MM", value)
+}
+
+// parseSlotMulai membaca slot_mulai RFC3339, atau tanggal-jam tanpa zona sebagai WIB
+func parseSlotMulai(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, jakartaLocation); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("format slot_mulai tidak valid")
+}
+
+func parseTanggalWIB(value string) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02", value, jakartaLocation)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("format tanggal %q tidak valid, gunakan YYYY-MM-DD", value)
+	}
+	return t, nil
+}
+
+// parseRentangTanggal membaca tanggal_dari/tanggal_sampai; yang kosong diisi fallback
+func parseRentangTanggal(dariValue, sampaiValue string, fallback time.Time) (time.Time, time.Time, error) {
+	dari, sampai := fallback, fallback
+	var err error
+	if dariValue != "" {
+		if dari, err = parseTanggalWIB(dariValue); err != nil {
+			return dari, sampai, err
+		}
+		sampai = dari
+	}
+	if sampaiValue != "" {
+		if sampai, err = parseTanggalWIB(sampaiValue); err != nil {
+			return dari, sampai, err
+		}
+	}
+	if sampai.Before(dari) {
+		return dari, sampai, errors.New("tanggal_sampai harus setelah tanggal_dari")
+	}
+	return dari, sampai, nil
+}
+
+// pickupToday tanggal hari ini pukul 00:00 WIB
+func pickupToday() time.Time {
+	now := time.Now().In(jakartaLocation)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, jakartaLocation)
+}
+
+func toPickupAppointmentResponse(appointment *models.PickupAppointment) dto.PickupAppointmentResponse {
+	resp := dto.PickupAppointmentResponse{
+		ID:          appointment.ID.String(),
+		PesananID:   appointment.PesananID.String(),
+		WarehouseID: appointment.WarehouseID.String(),
+		SlotMulai:   appointment.SlotMulai,
+		SlotSelesai: appointment.SlotSelesai,
+		Status:      string(appointment.Status),
+		Catatan:     appointment.Catatan,
+		AlasanBatal: appointment.AlasanBatal,
+		CancelledAt: appointment.CancelledAt,
+		CreatedAt:   appointment.CreatedAt,
+	}
+	if appointment.Warehouse != nil {
+		resp.NamaWarehouse = appointment.Warehouse.Nama
+	}
+	return resp
+}
+
+func toPickupKalenderItemResponse(appointment *models.PickupAppointment) dto.PickupKalenderItemResponse {
+	item := dto.PickupKalenderItemResponse{
+		ID:          appointment.ID.String(),
+		PesananID:   appointment.PesananID.String(),
+		SlotMulai:   appointment.SlotMulai,
+		SlotSelesai: appointment.SlotSelesai,
+		Status:      string(appointment.Status),
+		Catatan:     appointment.Catatan,
+	}
+	if appointment.Pesanan != nil {
+		item.KodePesanan = appointment.Pesanan.Kode
+	}
+	if appointment.Buyer != nil {
+		item.NamaBuyer = appointment.Buyer.Nama
+		item.TeleponBuyer = appointment.Buyer.Telepon
+	}
+	return item
+}
+
+func toJadwalGudangPengecualianResponse(pengecualian *models.JadwalGudangPengecualian) dto.JadwalGudangPengecualianResponse {
+	return dto.JadwalGudangPengecualianResponse{
+		ID:            pengecualian.ID.String(),
+		WarehouseID:   pengecualian.WarehouseID.String(),
+		Tanggal:       pengecualian.Tanggal.Format("2006-01-02"),
+		IsTutup:       pengecualian.IsTutup,
+		JamBuka:       pengecualian.JamBuka,
+		JamTutup:      pengecualian.JamTutup,
+		KapasitasSlot: pengecualian.KapasitasSlot,
+		Keterangan:    pengecualian.Keterangan,
+		CreatedAt:     pengecualian.CreatedAt,
+		UpdatedAt:     pengecualian.UpdatedAt,
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/orderstate"
+	"project-bulky-be/internal/repositories"
+	"project-bulky-be/internal/testutil/fakedb"
+
+	"github.com/google/uuid"
+)
+
+func TestGeneratePickupSlots(t *testing.T) {
+	// Senin, 5 Oktober 2026 WIB
+	tanggal := time.Date(2026, 10, 5, 0, 0, 0, 0, jakartaLocation)
+	buka, tutup := "09:00:00", "11:30:00"
+	jadwal := &models.JadwalGudang{Hari: 1, JamBuka: &buka, JamTutup: &tutup, IsBuka: true, DurasiSlotMenit: 60, KapasitasSlot: 3}
+
+	hari := generatePickupSlots(tanggal, jadwal, nil)
+	if hari.IsTutup || len(hari.Slots) != 2 {
+		t.Fatalf("09:00-11:30 per 60 menit harus 2 slot penuh, got tutup=%v slot=%d", hari.IsTutup, len(hari.Slots))
+	}
+	if !hari.Slots[1].Mulai.Equal(time.Date(2026, 10, 5, 10, 0, 0, 0, jakartaLocation)) || hari.Slots[1].Kapasitas != 3 {
+		t.Fatalf("slot kedua harus 10:00 WIB kapasitas 3, got %v / %d", hari.Slots[1].Mulai, hari.Slots[1].Kapasitas)
+	}
+
+	// Pengecualian tutup menimpa jadwal mingguan
+	libur := "Libur nasional"
+	hari = generatePickupSlots(tanggal, jadwal, &models.JadwalGudangPengecualian{IsTutup: true, Keterangan: &libur})
+	if !hari.IsTutup || len(hari.Slots) != 0 || derefString(hari.Keterangan) != libur {
+		t.Fatalf("pengecualian tutup harus tanpa slot, got tutup=%v slot=%d", hari.IsTutup, len(hari.Slots))
+	}
+
+	// Jam & kapasitas khusus, durasi tetap dari jadwal
+	khususBuka, khususTutup, kapasitas := "13:00", "15:00", 1
+	hari = generatePickupSlots(tanggal, jadwal, &models.JadwalGudangPengecualian{JamBuka: &khususBuka, JamTutup: &khususTutup, KapasitasSlot: &kapasitas})
+	if len(hari.Slots) != 2 || hari.Slots[0].Mulai.Hour() != 13 || hari.Slots[0].Kapasitas != 1 {
+		t.Fatalf("jam khusus 13:00-15:00 kapasitas 1, got %+v", hari.Slots)
+	}
+
+	// Hari tanpa jadwal atau jadwal tutup dianggap tutup
+	if hari = generatePickupSlots(tanggal, nil, nil); !hari.IsTutup {
+		t.Fatal("tanpa jadwal harus tutup")
+	}
+}
+
+func TestCountOverlappingAppointments(t *testing.T) {
+	mulai := time.Date(2026, 10, 5, 10, 0, 0, 0, jakartaLocation)
+	slot := pickupSlot{Mulai: mulai, Selesai: mulai.Add(time.Hour)}
+	appointments := []models.PickupAppointment{
+		{SlotMulai: mulai, SlotSelesai: mulai.Add(time.Hour)},
+		// Slot lama 30 menit yang bertumpuk tetap dihitung
+		{SlotMulai: mulai.Add(30 * time.Minute), SlotSelesai: mulai.Add(time.Hour)},
+		// Berbatasan, tidak bertumpuk
+		{SlotMulai: mulai.Add(-time.Hour), SlotSelesai: mulai},
+	}
+	if got := countOverlappingAppointments(slot, appointments); got != 2 {
+		t.Fatalf("harus 2 janji bertumpuk, got %d", got)
+	}
+}
+
+func TestCancelledPesananFreesPickupSlot(t *testing.T) {
+	mulai := time.Date(2026, 10, 19, 10, 0, 0, 0, jakartaLocation)
+	slot := pickupSlot{Mulai: mulai, Selesai: mulai.Add(time.Hour), Kapasitas: 2}
+	warehouseID := uuid.New()
+	pesanan := models.Pesanan{
+		ID: uuid.New(), Kode: "ORD-20261017-0001", BuyerID: uuid.New(),
+		OrderStatus: models.OrderStatusReady, PaymentStatus: models.PaymentStatusPaid, DeliveryType: models.DeliveryTypePickup,
+	}
+	appointments := []models.PickupAppointment{
+		{PesananID: pesanan.ID, SlotMulai: mulai, SlotSelesai: slot.Selesai, Status: models.PickupAppointmentBooked},
+		{PesananID: uuid.New(), SlotMulai: mulai, SlotSelesai: slot.Selesai, Status: models.PickupAppointmentBooked},
+	}
+
+	db, _ := fakedb.OpenPesanan(t, pesanan, func(q fakedb.Query) (*fakedb.Result, error) {
+		switch {
+		// closeAppointments: UPDATE pickup_appointment ... WHERE pesanan_id = ? AND status = 'BOOKED'
+		case q.Is("UPDATE", "pickup_appointment"):
+			status, _ := q.Set("status")
+			for i := range appointments {
+				if appointments[i].PesananID.String() == q.Args[len(q.Args)-2] && appointments[i].Status == models.PickupAppointmentBooked {
+					appointments[i].Status = models.PickupAppointmentStatus(fmt.Sprint(status))
+				}
+			}
+		// Book: hitung janji BOOKED yang bertumpuk dengan slot
+		case q.Is("SELECT", "pickup_appointment"):
+			var booked []models.PickupAppointment
+			for _, appointment := range appointments {
+				if appointment.Status == models.PickupAppointmentBooked {
+					booked = append(booked, appointment)
+				}
+			}
+			return &fakedb.Result{Columns: []string{"count"}, Rows: [][]driver.Value{{int64(countOverlappingAppointments(slot, booked))}}}, nil
+		case q.Is("INSERT", "pickup_appointment"):
+			appointments = append(appointments, models.PickupAppointment{PesananID: uuid.New(), SlotMulai: mulai, SlotSelesai: slot.Selesai, Status: models.PickupAppointmentBooked})
+		}
+		return nil, nil
+	})
+	machine := orderstate.NewMachine(db)
+	service := NewPickupAppointmentService(db, repositories.NewPickupAppointmentRepository(db), nil, nil, nil, machine).(*pickupAppointmentService)
+	booking := func() error {
+		return service.book(context.Background(), &models.PickupAppointment{
+			PesananID: uuid.New(), WarehouseID: warehouseID, SlotMulai: mulai, SlotSelesai: slot.Selesai, Status: models.PickupAppointmentBooked,
+		}, slot.Kapasitas, nil)
+	}
+
+	// Dua janji sudah mengisi kapasitas slot
+	if err := booking(); !errors.Is(err, ErrPickupSlotFull) {
+		t.Fatalf("slot berkapasitas %d yang sudah terisi harus ditolak, got %v", slot.Kapasitas, err)
+	}
+	if _, err := machine.Transition(context.Background(), orderstate.Request{PesananID: pesanan.ID, To: models.OrderStatusCancelled, Trigger: orderstate.TriggerAdmin}); err != nil {
+		t.Fatalf("pembatalan pesanan harus berhasil: %v", err)
+	}
+	if appointments[0].Status != models.PickupAppointmentCancelled || appointments[1].Status != models.PickupAppointmentBooked {
+		t.Fatalf("hanya janji pesanan yang dibatalkan yang ikut batal, got %s & %s", appointments[0].Status, appointments[1].Status)
+	}
+
+	// Kapasitas yang dilepas pesanan batal bisa dipakai buyer lain, tapi hanya satu
+	if err := booking(); err != nil {
+		t.Fatalf("slot yang dilepas pesanan batal harus bisa dipesan, got %v", err)
+	}
+	if err := booking(); !errors.Is(err, ErrPickupSlotFull) {
+		t.Fatalf("slot harus penuh lagi setelah satu booking baru, got %v", err)
+	}
+}
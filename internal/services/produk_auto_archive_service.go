@@ -7,17 +7,16 @@ import (
 	"time"
 
 	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/orderstate"
 	"project-bulky-be/internal/repositories"
 )
 
-// ProdukAutoArchiveService menjalankan job berkala untuk mengarsipkan (is_active=false)
-// produk yang sudah terjual (is_sold=true) lebih dari 1 hari, dihitung dari order-nya
-// mencapai status SHIPPED atau COMPLETED.
+// ProdukAutoArchiveService mengarsipkan (is_active=false) produk yang sudah terjual
+// (is_sold=true) lebih dari 1 hari, dihitung dari order-nya mencapai status SHIPPED
+// atau COMPLETED. Dijalankan berkala sebagai job cron models.JobTypeProdukAutoArchive.
 type ProdukAutoArchiveService interface {
 	// Run mengeksekusi satu kali proses pengecekan & pengarsipan produk.
-	Run(ctx context.Context)
-	// StartScheduler menjalankan Run secara berkala setiap `interval` sampai ctx dibatalkan.
-	StartScheduler(ctx context.Context, interval time.Duration)
+	Run(ctx context.Context) error
 }
 
 type produkAutoArchiveService struct {
@@ -39,17 +38,23 @@ func NewProdukAutoArchiveService(
 	}
 }
 
-func (s *produkAutoArchiveService) Run(ctx context.Context) {
+// ProdukAutoArchiveJobHandler menjalankan satu putaran auto-archive (job cron)
+func ProdukAutoArchiveJobHandler(service ProdukAutoArchiveService) JobHandler {
+	return func(ctx context.Context, job *models.BackgroundJob) error {
+		return service.Run(ctx)
+	}
+}
+
+func (s *produkAutoArchiveService) Run(ctx context.Context) error {
 	threshold := time.Now().Add(-s.delay)
 
-	produkList, err := s.produkRepo.FindSoldProdukToArchive(ctx, threshold)
+	produkList, err := s.produkRepo.FindSoldProdukToArchive(ctx, orderstate.HandedOverStatuses(), threshold)
 	if err != nil {
-		log.Printf("[produk-auto-archive] gagal mengambil daftar produk: %v", err)
-		return
+		return err
 	}
 
 	if len(produkList) == 0 {
-		return
+		return nil
 	}
 
 	archived := 0
@@ -63,6 +68,7 @@ func (s *produkAutoArchiveService) Run(ctx context.Context) {
 	}
 
 	log.Printf("[produk-auto-archive] %d/%d produk berhasil diarsipkan otomatis", archived, len(produkList))
+	return nil
 }
 
 func (s *produkAutoArchiveService) logArchive(produk models.Produk) {
@@ -88,18 +94,3 @@ func (s *produkAutoArchiveService) logArchive(produk models.Produk) {
 		log.Printf("[produk-auto-archive] gagal mencatat activity log untuk produk %s: %v", produk.ID, err)
 	}
 }
-
-func (s *produkAutoArchiveService) StartScheduler(ctx context.Context, interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			log.Println("[produk-auto-archive] scheduler dihentikan")
-			return
-		case <-ticker.C:
-			s.Run(ctx)
-		}
-	}
-}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
 	"mime/multipart"
 	"strings"
 
@@ -737,7 +736,7 @@ func (s *produkService) toDetailResponse(p *models.Produk) *models.ProdukDetailR
 		Lebar:              p.Lebar,
 		Tinggi:             p.Tinggi,
 		Berat:              p.Berat,
-		BeratVolumetrik:    s.calculateBeratVolumetrik(p.Panjang, p.Lebar, p.Tinggi),
+		BeratVolumetrik:    utils.CalculateBeratVolumetrik(p.Panjang, p.Lebar, p.Tinggi),
 		IsActive:           p.IsActive,
 		IsSale:             p.IsSale,
 		IsQcPass:           p.IsQcPass,
@@ -787,19 +786,6 @@ func (s *produkService) toDetailResponse(p *models.Produk) *models.ProdukDetailR
 	return resp
 }
 
-// calculateBeratVolumetrik menghitung berat volumetrik berdasarkan dimensi produk
-// Formula: (Panjang × Lebar × Tinggi) / 6000
-// Hasil dalam kg, dibulatkan 2 desimal
-func (s *produkService) calculateBeratVolumetrik(panjang, lebar, tinggi float64) float64 {
-	if panjang == 0 || lebar == 0 || tinggi == 0 {
-		return 0
-	}
-	// Formula standar: (P × L × T) / 6000
-	// Divisor 6000 untuk konversi cm³ ke kg
-	volumetrik := (panjang * lebar * tinggi) / 6000
-	return math.Round(volumetrik*100) / 100
-}
-
 // Helper function
 func intPtr(i int) *int {
 	return &i
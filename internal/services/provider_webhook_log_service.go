@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/config"
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// Header webhook bertanda tangan
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	// WebhookEventIDHeader opsional; jika dikirim dipakai sebagai kunci replay
+	WebhookEventIDHeader = "X-Webhook-Event-Id"
+)
+
+var (
+	ErrWebhookNotConfigured    = errors.New("webhook provider belum dikonfigurasi")
+	ErrWebhookUnauthorized     = errors.New("authorization webhook tidak valid")
+	ErrWebhookInvalidSignature = errors.New("signature webhook tidak valid")
+	ErrWebhookStaleTimestamp   = errors.New("timestamp webhook tidak valid atau di luar toleransi")
+	ErrWebhookReplay           = errors.New("event webhook sudah diterima sebelumnya")
+	ErrWebhookNotReprocessable = errors.New("hanya webhook yang lolos verifikasi yang dapat diproses ulang")
+)
+
+// WebhookPayloadError payload webhook lolos verifikasi tapi tidak valid
+type WebhookPayloadError struct {
+	Detail string
+}
+
+func (e *WebhookPayloadError) Error() string {
+	return "payload webhook tidak valid: " + e.Detail
+}
+
+// webhookReplayWindow jendela deteksi replay webhook tanpa event ID maupun
+// timestamp bertanda tangan
+const webhookReplayWindow = 10 * time.Minute
+
+// webhookRedactedHeaders tidak disimpan apa adanya di log webhook
+var webhookRedactedHeaders = []string{"Authorization", WebhookSignatureHeader, "Cookie"}
+
+// WebhookRequest adalah request webhook mentah dari provider
+type WebhookRequest struct {
+	Provider string
+	Headers  map[string][]string
+	Body     []byte
+	RemoteIP string
+}
+
+// webhookVerifier kredensial verifikasi webhook satu provider
+type webhookVerifier struct {
+	authorization string
+	secret        string
+	tolerance     time.Duration
+}
+
+// ProviderWebhookLogService memverifikasi, mencatat dan memproses webhook
+// provider pengiriman, serta menyediakan log-nya untuk admin
+type ProviderWebhookLogService interface {
+	// Receive memverifikasi & mencatat webhook lalu memprosesnya. Request yang
+	// ditolak tetap dicatat; error menjelaskan alasan penolakan.
+	Receive(ctx context.Context, req *WebhookRequest) (*models.ProviderWebhookLog, error)
+	GetAll(ctx context.Context, params *dto.WebhookLogQueryParams) ([]models.ProviderWebhookLog, *models.PaginationMeta, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.ProviderWebhookLog, error)
+	// Reprocess memproses ulang body webhook tersimpan yang lolos verifikasi
+	Reprocess(ctx context.Context, id uuid.UUID) (*models.ProviderWebhookLog, error)
+}
+
+// webhookDeliveryTypes delivery type yang provider-nya mem-parse body webhook
+// setiap sumber webhook (LTL & LCL berbagi webhook Forwarder)
+var webhookDeliveryTypes = map[string]models.DeliveryType{
+	models.WebhookProviderDeliveree: models.DeliveryTypeDeliveree,
+	models.WebhookProviderForwarder: models.DeliveryTypeForwarder,
+}
+
+type providerWebhookLogService struct {
+	repo      repositories.ProviderWebhookLogRepository
+	shipping  ShippingService
+	deliveree DelivereeWebhookService
+	forwarder ForwarderWebhookService
+	verifiers map[string]webhookVerifier
+}
+
+func NewProviderWebhookLogService(repo repositories.ProviderWebhookLogRepository, shipping ShippingService, deliveree DelivereeWebhookService, forwarder ForwarderWebhookService, cfg *config.Config) ProviderWebhookLogService {
+	return &providerWebhookLogService{
+		repo:      repo,
+		shipping:  shipping,
+		deliveree: deliveree,
+		forwarder: forwarder,
+		verifiers: map[string]webhookVerifier{
+			models.WebhookProviderDeliveree: {
+				authorization: cfg.DelivereeWebhookAuthorization,
+				secret:        cfg.DelivereeWebhookSecret,
+				tolerance:     cfg.WebhookTimestampTolerance,
+			},
+			models.WebhookProviderForwarder: {
+				authorization: cfg.ForwarderWebhookAuthorization,
+				secret:        cfg.ForwarderWebhookSecret,
+				tolerance:     cfg.WebhookTimestampTolerance,
+			},
+		},
+	}
+}
+
+func (s *providerWebhookLogService) Receive(ctx context.Context, req *WebhookRequest) (*models.ProviderWebhookLog, error) {
+	now := time.Now()
+	entry := &models.ProviderWebhookLog{
+		Provider:      req.Provider,
+		Headers:       redactWebhookHeaders(req.Headers),
+		Body:          webhookLogBody(req.Body),
+		ProcessStatus: models.WebhookProcessSkipped,
+		ReceivedAt:    now,
+	}
+	if req.RemoteIP != "" {
+		entry.RemoteIP = &req.RemoteIP
+	}
+
+	verifier := s.verifiers[req.Provider]
+	if verifier.authorization == "" && verifier.secret == "" {
+		entry.Verification = models.WebhookVerificationUnauthorized
+		return s.reject(ctx, entry, ErrWebhookNotConfigured)
+	}
+
+	verification, verifyErr := verifier.verify(req.Headers, req.Body, now)
+	entry.Verification = verification
+	if verifyErr != nil {
+		return s.reject(ctx, entry, verifyErr)
+	}
+
+	event, err := s.parseWebhook(req.Provider, req.Body)
+	if err != nil {
+		entry.Verification = models.WebhookVerificationInvalidPayload
+		return s.reject(ctx, entry, err)
+	}
+	entry.BookingRef = &event.BookingRef
+	entry.Status = &event.Status
+
+	// Timestamp hanya dipakai jika ikut ditandatangani (secret dikonfigurasi)
+	timestamp := ""
+	if verifier.secret != "" {
+		timestamp = webhookHeader(req.Headers, WebhookTimestampHeader)
+	}
+	eventKey := webhookEventKey(webhookHeader(req.Headers, WebhookEventIDHeader), timestamp, event, now)
+	entry.EventKey = &eventKey
+	entry.ProcessStatus = models.WebhookProcessPending
+
+	created, err := s.repo.Create(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		// Event yang sama sudah diterima dan belum gagal: catat sebagai replay
+		entry.ID = uuid.Nil
+		entry.Verification = models.WebhookVerificationReplay
+		entry.ProcessStatus = models.WebhookProcessSkipped
+		return s.reject(ctx, entry, ErrWebhookReplay)
+	}
+
+	return entry, s.process(ctx, entry, false)
+}
+
+func (s *providerWebhookLogService) GetAll(ctx context.Context, params *dto.WebhookLogQueryParams) ([]models.ProviderWebhookLog, *models.PaginationMeta, error) {
+	filter := repositories.ProviderWebhookLogFilter{
+		Provider:      strings.ToUpper(params.Provider),
+		Verification:  strings.ToUpper(params.Verification),
+		ProcessStatus: strings.ToUpper(params.ProcessStatus),
+		BookingRef:    params.BookingRef,
+	}
+	logs, total, err := s.repo.FindAll(ctx, filter, params.Page, params.PerPage)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta := models.NewPaginationMeta(params.Page, params.PerPage, total)
+	return logs, &meta, nil
+}
+
+func (s *providerWebhookLogService) GetByID(ctx context.Context, id uuid.UUID) (*models.ProviderWebhookLog, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+func (s *providerWebhookLogService) Reprocess(ctx context.Context, id uuid.UUID) (*models.ProviderWebhookLog, error) {
+	entry, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Verification != models.WebhookVerificationAccepted {
+		return nil, ErrWebhookNotReprocessable
+	}
+	// Log FAILED tidak lagi memegang kunci event; jika provider sudah mengirim
+	// ulang dan diterima lewat log lain, proses ulang log ini akan dobel
+	if entry.ProcessStatus == models.WebhookProcessFailed && entry.EventKey != nil {
+		exists, err := s.repo.ExistsAccepted(ctx, entry.Provider, *entry.EventKey, entry.ID)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrWebhookReplay
+		}
+	}
+
+	processErr := s.process(ctx, entry, true)
+	if errors.Is(processErr, ErrWebhookReplay) {
+		return nil, processErr
+	}
+	updated, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return updated, processErr
+}
+
+// reject mencatat webhook yang tidak diproses lalu mengembalikan alasannya
+func (s *providerWebhookLogService) reject(ctx context.Context, entry *models.ProviderWebhookLog, reason error) (*models.ProviderWebhookLog, error) {
+	msg := reason.Error()
+	entry.ProcessError = &msg
+	if _, err := s.repo.Create(ctx, entry); err != nil {
+		// Gagal mencatat tidak mengubah hasil verifikasi
+		log.Printf("[webhook] gagal mencatat webhook %s ditolak (%s): %v", entry.Provider, entry.Verification, err)
+	}
+	return entry, reason
+}
+
+// process meneruskan body webhook ke handler provider dan menyimpan hasilnya
+func (s *providerWebhookLogService) process(ctx context.Context, entry *models.ProviderWebhookLog, reprocess bool) error {
+	event, err := s.parseWebhook(entry.Provider, []byte(entry.Body))
+	var matched bool
+	if err == nil {
+		switch entry.Provider {
+		case models.WebhookProviderDeliveree:
+			matched, err = s.deliveree.Handle(ctx, event)
+		case models.WebhookProviderForwarder:
+			matched, err = s.forwarder.Handle(ctx, event)
+		}
+	}
+
+	status := models.WebhookProcessIgnored
+	var processError *string
+	if err != nil {
+		status = models.WebhookProcessFailed
+		msg := err.Error()
+		processError = &msg
+	} else if matched {
+		status = models.WebhookProcessProcessed
+	}
+
+	if markErr := s.repo.MarkResult(ctx, entry.ID, status, processError, reprocess); markErr != nil {
+		if strings.Contains(markErr.Error(), "duplicate key value") {
+			// Event diterima lewat log lain selagi log FAILED ini diproses ulang
+			return ErrWebhookReplay
+		}
+		log.Printf("[webhook] gagal menyimpan hasil webhook %s: %v", entry.ID, markErr)
+	}
+	now := time.Now()
+	entry.ProcessStatus = status
+	entry.ProcessError = processError
+	entry.ProcessedAt = &now
+	return err
+}
+
+// verify memeriksa Authorization statis (jika dikonfigurasi) lalu signature
+// HMAC + timestamp (jika secret dikonfigurasi)
+func (v webhookVerifier) verify(headers map[string][]string, body []byte, now time.Time) (models.WebhookVerification, error) {
+	if v.authorization != "" {
+		got := webhookHeader(headers, "Authorization")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(v.authorization)) != 1 {
+			return models.WebhookVerificationUnauthorized, ErrWebhookUnauthorized
+		}
+	}
+	if v.secret == "" {
+		return models.WebhookVerificationAccepted, nil
+	}
+
+	timestamp := webhookHeader(headers, WebhookTimestampHeader)
+	signature := strings.TrimPrefix(webhookHeader(headers, WebhookSignatureHeader), "sha256=")
+	given, err := hex.DecodeString(signature)
+	if signature == "" || err != nil || !hmac.Equal(given, webhookSignature(v.secret, timestamp, body)) {
+		return models.WebhookVerificationInvalidSignature, ErrWebhookInvalidSignature
+	}
+
+	// Timestamp ikut ditandatangani, sehingga request lama tidak bisa dikirim
+	// ulang dengan timestamp baru
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return models.WebhookVerificationStaleTimestamp, ErrWebhookStaleTimestamp
+	}
+	if diff := now.Sub(time.Unix(unix, 0)); diff > v.tolerance || diff < -v.tolerance {
+		return models.WebhookVerificationStaleTimestamp, ErrWebhookStaleTimestamp
+	}
+	return models.WebhookVerificationAccepted, nil
+}
+
+// webhookSignature adalah HMAC-SHA256 dari "<timestamp>.<body>"
+func webhookSignature(secret, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// parseWebhook membaca body webhook lewat ParseWebhook provider pengiriman
+// sumbernya
+func (s *providerWebhookLogService) parseWebhook(source string, body []byte) (*ShippingWebhookEvent, error) {
+	deliveryType, ok := webhookDeliveryTypes[source]
+	if !ok {
+		return nil, fmt.Errorf("provider webhook %s tidak dikenal", source)
+	}
+	provider, err := s.shipping.Provider(deliveryType)
+	if err != nil {
+		return nil, err
+	}
+	return provider.ParseWebhook(body)
+}
+
+// webhookEventKey adalah kunci replay event. Event ID dari provider dipakai
+// jika ada; selain itu satu event dikenali dari booking + status + tracking
+// URL + waktu kirim: timestamp bertanda tangan, atau jendela
+// webhookReplayWindow saat webhook tanpa signature. Request yang sama persis
+// ditolak, sedangkan status yang sah dikirim ulang kemudian (mis. kembali ke
+// delivery_in_progress) tetap diproses.
+func webhookEventKey(eventID, timestamp string, event *ShippingWebhookEvent, now time.Time) string {
+	key := "event|" + eventID
+	if eventID == "" {
+		sent := "ts|" + timestamp
+		if timestamp == "" {
+			sent = "window|" + strconv.FormatInt(now.Truncate(webhookReplayWindow).Unix(), 10)
+		}
+		key = strings.Join([]string{event.BookingRef, event.Status, event.TrackingURL, sent}, "|")
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// webhookHeader mengambil nilai header tanpa membedakan huruf besar/kecil
+func webhookHeader(headers map[string][]string, name string) string {
+	for key, values := range headers {
+		if strings.EqualFold(key, name) && len(values) > 0 {
+			return strings.TrimSpace(values[0])
+		}
+	}
+	return ""
+}
+
+// webhookLogBody menyiapkan body mentah untuk kolom text (tanpa NUL & UTF-8 rusak)
+func webhookLogBody(body []byte) string {
+	return strings.ToValidUTF8(strings.ReplaceAll(string(body), "\x00", ""), "\uFFFD")
+}
+
+// redactWebhookHeaders menyalin header request untuk log dengan kredensial disamarkan
+func redactWebhookHeaders(headers map[string][]string) json.RawMessage {
+	copied := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		redacted := false
+		for _, name := range webhookRedactedHeaders {
+			if strings.EqualFold(key, name) {
+				redacted = true
+				break
+			}
+		}
+		if redacted {
+			copied[key] = []string{"[redacted]"}
+			continue
+		}
+		copied[key] = values
+	}
+	raw, err := json.Marshal(copied)
+	if err != nil {
+		return json.RawMessage("{}")
+	}
+	return raw
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/models"
+)
+
+func TestWebhookVerifierVerify(t *testing.T) {
+	now := time.Unix(1_760_000_000, 0)
+	body := []byte(`{"status":"delivery_completed","id":123456}`)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	sig := hex.EncodeToString(webhookSignature("rahasia", ts, body))
+	v := webhookVerifier{authorization: "token", secret: "rahasia", tolerance: 5 * time.Minute}
+
+	cases := []struct {
+		name    string
+		headers map[string][]string
+		now     time.Time
+		want    models.WebhookVerification
+	}{
+		{"valid", map[string][]string{"Authorization": {"token"}, "X-Webhook-Timestamp": {ts}, "X-Webhook-Signature": {sig}}, now, models.WebhookVerificationAccepted},
+		{"prefix sha256 & header lowercase", map[string][]string{"authorization": {"token"}, "x-webhook-timestamp": {ts}, "x-webhook-signature": {"sha256=" + sig}}, now, models.WebhookVerificationAccepted},
+		{"authorization salah", map[string][]string{"Authorization": {"bocor"}, "X-Webhook-Timestamp": {ts}, "X-Webhook-Signature": {sig}}, now, models.WebhookVerificationUnauthorized},
+		{"tanpa signature", map[string][]string{"Authorization": {"token"}, "X-Webhook-Timestamp": {ts}}, now, models.WebhookVerificationInvalidSignature},
+		// Timestamp diganti tanpa menandatangani ulang
+		{"timestamp diubah", map[string][]string{"Authorization": {"token"}, "X-Webhook-Timestamp": {strconv.FormatInt(now.Unix()+1, 10)}, "X-Webhook-Signature": {sig}}, now, models.WebhookVerificationInvalidSignature},
+		{"di luar toleransi", map[string][]string{"Authorization": {"token"}, "X-Webhook-Timestamp": {ts}, "X-Webhook-Signature": {sig}}, now.Add(6 * time.Minute), models.WebhookVerificationStaleTimestamp},
+	}
+	for _, tc := range cases {
+		got, _ := v.verify(tc.headers, body, tc.now)
+		if got != tc.want {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.want, got)
+		}
+	}
+
+	// Tanpa secret hanya Authorization statis yang dicek
+	legacy := webhookVerifier{authorization: "token"}
+	if got, err := legacy.verify(map[string][]string{"Authorization": {"token"}}, body, now); got != models.WebhookVerificationAccepted || err != nil {
+		t.Fatalf("authorization statis: expected ACCEPTED, got %s (%v)", got, err)
+	}
+}
+
+func TestWebhookEventKey(t *testing.T) {
+	payload, err := parseOnDemandWebhook([]byte(`{"status":"delivery_in_progress","id":"B-1"}`))
+	if err != nil {
+		t.Fatalf("payload valid ditolak: %v", err)
+	}
+	completed, _ := parseOnDemandWebhook([]byte(`{"status":"delivery_completed","id":"B-1"}`))
+
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	if webhookEventKey("", "1792227600", payload, now) != webhookEventKey("", "1792227600", payload, now.Add(time.Minute)) {
+		t.Fatal("event yang sama harus menghasilkan key yang sama")
+	}
+	if webhookEventKey("", "1792227600", payload, now) == webhookEventKey("", "1792227600", completed, now) {
+		t.Fatal("perubahan status harus menghasilkan key berbeda")
+	}
+	if webhookEventKey("evt-1", "1792227600", payload, now) != webhookEventKey("evt-1", "1792227900", completed, now) {
+		t.Fatal("event ID provider harus menjadi kunci jika dikirim")
+	}
+
+	// Status yang sama dikirim ulang kemudian bukan replay
+	if webhookEventKey("", "1792227600", payload, now) == webhookEventKey("", "1792231200", payload, now) {
+		t.Fatal("timestamp bertanda tangan berbeda harus menghasilkan key berbeda")
+	}
+	if webhookEventKey("", "", payload, now) != webhookEventKey("", "", payload, now.Add(time.Minute)) {
+		t.Fatal("webhook tanpa timestamp dalam jendela replay harus menghasilkan key yang sama")
+	}
+	if webhookEventKey("", "", payload, now) == webhookEventKey("", "", payload, now.Add(webhookReplayWindow)) {
+		t.Fatal("webhook tanpa timestamp di luar jendela replay bukan replay")
+	}
+
+	var payloadErr *WebhookPayloadError
+	if _, err := parseOnDemandWebhook([]byte(`{"status":"delivery_completed"}`)); !errors.As(err, &payloadErr) {
+		t.Fatal("payload tanpa id/no_booking harus ditolak")
+	}
+}
+
+func TestRedactWebhookHeaders(t *testing.T) {
+	raw := redactWebhookHeaders(map[string][]string{
+		"Authorization":       {"token"},
+		"X-Webhook-Signature": {"abc"},
+		"Content-Type":        {"application/json"},
+	})
+	var headers map[string][]string
+	if err := json.Unmarshal(raw, &headers); err != nil {
+		t.Fatalf("header log bukan JSON: %v", err)
+	}
+	if headers["Authorization"][0] != "[redacted]" || headers["X-Webhook-Signature"][0] != "[redacted]" {
+		t.Fatalf("kredensial harus disamarkan, got %v", headers)
+	}
+	if headers["Content-Type"][0] != "application/json" {
+		t.Fatalf("header lain harus tetap, got %v", headers)
+	}
+}
+
+// fakeWebhookShipping mengembalikan provider yang mencatat body webhook
+type fakeWebhookShipping struct {
+	ShippingService
+	providers map[models.DeliveryType]ShippingProvider
+}
+
+func (s *fakeWebhookShipping) Provider(deliveryType models.DeliveryType) (ShippingProvider, error) {
+	if provider, ok := s.providers[deliveryType]; ok {
+		return provider, nil
+	}
+	return nil, errors.New("provider tidak terdaftar")
+}
+
+type fakeWebhookParser struct {
+	ShippingProvider
+	parsed int
+}
+
+func (p *fakeWebhookParser) ParseWebhook(body []byte) (*ShippingWebhookEvent, error) {
+	p.parsed++
+	return parseOnDemandWebhook(body)
+}
+
+func TestParseWebhookUsesShippingProvider(t *testing.T) {
+	forwarder := &fakeWebhookParser{}
+	s := &providerWebhookLogService{shipping: &fakeWebhookShipping{
+		providers: map[models.DeliveryType]ShippingProvider{models.DeliveryTypeForwarder: forwarder},
+	}}
+
+	event, err := s.parseWebhook(models.WebhookProviderForwarder, []byte(`{"status":"canceled","no_booking":"FWD-1"}`))
+	if err != nil || forwarder.parsed != 1 || event.BookingRef != "FWD-1" {
+		t.Fatalf("webhook Forwarder harus dibaca provider Forwarder, got %+v err=%v parsed=%d", event, err, forwarder.parsed)
+	}
+	if _, err := s.parseWebhook("LALAMOVE", []byte(`{}`)); err == nil {
+		t.Fatal("sumber webhook tanpa provider harus ditolak")
+	}
+}
@@ -0,0 +1,637 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/orderstate"
+	"project-bulky-be/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	// refundSyncBatch jumlah refund PROCESSING yang dicek per jalan job cron
+	refundSyncBatch = 50
+	// alasanRefundPembatalan alasan refund otomatis saat pesanan dibatalkan
+	alasanRefundPembatalan = "Pesanan dibatalkan"
+)
+
+var (
+	ErrRefundPesananNotFound  = errors.New("pesanan tidak ditemukan")
+	ErrRefundNotFound         = errors.New("refund tidak ditemukan")
+	ErrRefundNothingToRefund  = errors.New("tidak ada pembayaran lunas yang bisa di-refund")
+	ErrRefundInvalid          = errors.New("pengajuan refund tidak valid")
+	ErrRefundStatusNotAllowed = errors.New("status refund tidak mengizinkan aksi ini")
+)
+
+// RefundService mengelola refund pesanan: pengajuan (admin atau otomatis saat
+// pesanan dibatalkan), persetujuan finance, pengiriman ke payment gateway dan
+// pembaruan payment_status pembayaran/pesanan setelah dana kembali.
+type RefundService interface {
+	GetByPesanan(ctx context.Context, pesananID string) (*dto.PesananRefundSummaryResponse, error)
+	GetAll(ctx context.Context, params *dto.RefundQueryParams) ([]dto.RefundResponse, *models.PaginationMeta, error)
+	GetByID(ctx context.Context, id string) (*dto.RefundResponse, error)
+	Create(ctx context.Context, pesananID string, req *dto.CreateRefundRequest, adminID uuid.UUID) ([]dto.RefundResponse, error)
+	// Approve menyetujui refund REQUESTED/FAILED lalu meneruskannya ke payment
+	// gateway, atau langsung COMPLETED jika refund manual
+	Approve(ctx context.Context, id string, req *dto.ApproveRefundRequest, adminID uuid.UUID) (*dto.RefundResponse, error)
+	Reject(ctx context.Context, id string, req *dto.RejectRefundRequest, adminID uuid.UUID) (*dto.RefundResponse, error)
+	// SyncProcessing mengecek refund PROCESSING ke payment gateway (job cron)
+	SyncProcessing(ctx context.Context) (int, error)
+}
+
+type refundService struct {
+	db         *gorm.DB
+	refundRepo repositories.PesananRefundRepository
+	gateway    PaymentRefundGateway
+}
+
+// NewRefundService juga mendaftarkan hook orderMachine: pembayaran lunas
+// pesanan yang dibatalkan otomatis diajukan refund penuh untuk disetujui finance.
+func NewRefundService(db *gorm.DB, refundRepo repositories.PesananRefundRepository, gateway PaymentRefundGateway, orderMachine *orderstate.Machine) RefundService {
+	s := &refundService{
+		db:         db,
+		refundRepo: refundRepo,
+		gateway:    gateway,
+	}
+	orderMachine.OnEnterTx(models.OrderStatusCancelled, s.requestCancellationRefunds)
+	return s
+}
+
+// RefundSyncJobHandler adalah handler job cron models.JobTypePaymentRefundSync
+func RefundSyncJobHandler(refund RefundService) JobHandler {
+	return func(ctx context.Context, job *models.BackgroundJob) error {
+		_, err := refund.SyncProcessing(ctx)
+		return err
+	}
+}
+
+func (s *refundService) GetByPesanan(ctx context.Context, pesananID string) (*dto.PesananRefundSummaryResponse, error) {
+	pesanan, err := s.findPesanan(ctx, pesananID)
+	if err != nil {
+		return nil, err
+	}
+
+	var pembayaran []models.PesananPembayaran
+	if err := s.db.WithContext(ctx).Preload("Buyer").
+		Where("pesanan_id = ?", pesanan.ID).Order("created_at ASC").
+		Find(&pembayaran).Error; err != nil {
+		return nil, err
+	}
+	refunds, err := s.refundRepo.FindByPesananID(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	completed := completedRefundTotals(refunds)
+	reserved := reservedRefundTotals(refunds)
+	resp := &dto.PesananRefundSummaryResponse{
+		PesananID:     pesanan.ID,
+		PaymentStatus: string(pesanan.PaymentStatus),
+		TotalRefund:   decimal.Zero,
+		Pembayaran:    make([]dto.RefundPembayaranResponse, 0, len(pembayaran)),
+		Refund:        make([]dto.RefundResponse, 0, len(refunds)),
+	}
+	for _, p := range pembayaran {
+		sisa := decimal.Zero
+		if p.Status == models.PaymentStatusPaid {
+			sisa = decimal.Max(p.Jumlah.Sub(reserved[p.ID]), decimal.Zero)
+		}
+		resp.Pembayaran = append(resp.Pembayaran, dto.RefundPembayaranResponse{
+			ID:           p.ID,
+			BuyerID:      p.BuyerID,
+			NamaPembayar: p.Buyer.Nama,
+			Status:       string(p.Status),
+			Jumlah:       p.Jumlah,
+			Direfund:     completed[p.ID],
+			SisaRefund:   sisa,
+		})
+		resp.TotalRefund = resp.TotalRefund.Add(completed[p.ID])
+	}
+	for i := range refunds {
+		resp.Refund = append(resp.Refund, mapRefundResponse(&refunds[i]))
+	}
+	return resp, nil
+}
+
+func (s *refundService) GetAll(ctx context.Context, params *dto.RefundQueryParams) ([]dto.RefundResponse, *models.PaginationMeta, error) {
+	params.SetDefaults()
+
+	filter := repositories.PesananRefundFilter{Status: strings.ToUpper(params.Status)}
+	if params.PesananID != "" {
+		id, err := uuid.Parse(params.PesananID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: pesanan_id tidak valid", ErrRefundInvalid)
+		}
+		filter.PesananID = &id
+	}
+	if params.BuyerID != "" {
+		id, err := uuid.Parse(params.BuyerID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: buyer_id tidak valid", ErrRefundInvalid)
+		}
+		filter.BuyerID = &id
+	}
+
+	refunds, total, err := s.refundRepo.FindAll(ctx, filter, params.Page, params.PerPage)
+	if err != nil {
+		return nil, nil, err
+	}
+	items := make([]dto.RefundResponse, 0, len(refunds))
+	for i := range refunds {
+		items = append(items, mapRefundResponse(&refunds[i]))
+	}
+	meta := models.NewPaginationMeta(params.Page, params.PerPage, total)
+	return items, &meta, nil
+}
+
+func (s *refundService) GetByID(ctx context.Context, id string) (*dto.RefundResponse, error) {
+	refund, err := s.findRefund(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := mapRefundResponse(refund)
+	return &resp, nil
+}
+
+func (s *refundService) Create(ctx context.Context, pesananID string, req *dto.CreateRefundRequest, adminID uuid.UUID) ([]dto.RefundResponse, error) {
+	pesanan, err := s.findPesanan(ctx, pesananID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]refundPlanItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		id, err := uuid.Parse(item.PesananPembayaranID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: pesanan_pembayaran_id tidak valid", ErrRefundInvalid)
+		}
+		items = append(items, refundPlanItem{PembayaranID: id, Jumlah: item.Jumlah})
+	}
+
+	createdIDs := make(map[uuid.UUID]bool)
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		refunds, err := s.planPesananRefunds(ctx, tx, pesanan.ID, items, req.Alasan)
+		if err != nil {
+			return err
+		}
+		for i := range refunds {
+			refunds[i].RequestedBy = &adminID
+		}
+		if err := s.refundRepo.WithTx(tx).Create(ctx, refunds); err != nil {
+			return err
+		}
+		for _, r := range refunds {
+			createdIDs[r.ID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Muat ulang agar nama buyer ikut di response
+	refunds, err := s.refundRepo.FindByPesananID(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]dto.RefundResponse, 0, len(createdIDs))
+	for i := range refunds {
+		if createdIDs[refunds[i].ID] {
+			refunds[i].Pesanan = pesanan
+			resp = append(resp, mapRefundResponse(&refunds[i]))
+		}
+	}
+	return resp, nil
+}
+
+func (s *refundService) Approve(ctx context.Context, id string, req *dto.ApproveRefundRequest, adminID uuid.UUID) (*dto.RefundResponse, error) {
+	refund, err := s.findRefund(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	fields := map[string]interface{}{
+		"status":            models.RefundStatusProcessing,
+		"is_manual":         req.Manual,
+		"approved_by":       adminID,
+		"approved_at":       now,
+		"percobaan":         refund.Percobaan + 1,
+		"gateway_refund_id": nil,
+		"gateway_status":    nil,
+		"gateway_error":     nil,
+		"catatan_finance":   req.Catatan,
+	}
+	if req.Manual {
+		fields["gateway"] = nil
+	} else {
+		fields["gateway"] = s.gateway.Code()
+	}
+	refund.ApprovedBy = &adminID
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ok, err := s.refundRepo.WithTx(tx).UpdateStatus(ctx, refund.ID,
+			[]models.RefundStatus{models.RefundStatusRequested, models.RefundStatusFailed}, fields)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrRefundStatusNotAllowed
+		}
+		if req.Manual {
+			// Dana sudah dikembalikan finance di luar payment gateway
+			return s.completeRefund(ctx, tx, refund, nil)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.Manual {
+		refund.Percobaan++
+		refund.GatewayRefundID = nil
+		s.submitToGateway(ctx, refund)
+	}
+	return s.GetByID(ctx, id)
+}
+
+func (s *refundService) Reject(ctx context.Context, id string, req *dto.RejectRefundRequest, adminID uuid.UUID) (*dto.RefundResponse, error) {
+	refund, err := s.findRefund(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.refundRepo.UpdateStatus(ctx, refund.ID,
+		[]models.RefundStatus{models.RefundStatusRequested, models.RefundStatusFailed},
+		map[string]interface{}{
+			"status":          models.RefundStatusRejected,
+			"rejected_by":     adminID,
+			"rejected_at":     time.Now(),
+			"catatan_finance": req.Catatan,
+		})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrRefundStatusNotAllowed
+	}
+	return s.GetByID(ctx, id)
+}
+
+// SyncProcessing mengecek refund yang masih PROCESSING: refund yang belum
+// tercatat di gateway (mis. timeout saat approve) dikirim ulang dengan
+// idempotency key yang sama, sisanya ditanyakan statusnya.
+func (s *refundService) SyncProcessing(ctx context.Context) (int, error) {
+	refunds, err := s.refundRepo.FindProcessing(ctx, refundSyncBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	for i := range refunds {
+		refund := &refunds[i]
+		if refund.IsManual {
+			continue
+		}
+		var result *GatewayRefundResult
+		if refund.GatewayRefundID == nil {
+			result, err = s.gateway.Refund(ctx, s.gatewayRequest(refund))
+		} else {
+			result, err = s.gateway.GetRefund(ctx, *refund.GatewayRefundID)
+		}
+		if err == nil {
+			err = s.applyGatewayResult(ctx, refund, result)
+		}
+		if err != nil {
+			log.Printf("[refund] sinkronisasi gagal: refund=%s error=%v", refund.ID, err)
+			errs = append(errs, err)
+		}
+	}
+	log.Printf("[refund] sinkronisasi: %d refund diproses, %d gagal", len(refunds), len(errs))
+
+	// Job hanya gagal jika semua refund gagal (mis. payment gateway down)
+	if len(refunds) > 0 && len(errs) == len(refunds) {
+		return len(refunds), errors.Join(errs...)
+	}
+	return len(refunds), nil
+}
+
+// requestCancellationRefunds mengajukan refund penuh atas sisa setiap
+// pembayaran lunas saat pesanan dibatalkan, dalam transaksi pembatalan.
+func (s *refundService) requestCancellationRefunds(tx *gorm.DB, pesanan *models.Pesanan, from models.OrderStatus, trigger orderstate.Trigger) error {
+	ctx := tx.Statement.Context
+	refunds, err := s.planPesananRefunds(ctx, tx, pesanan.ID, nil, alasanRefundPembatalan)
+	if errors.Is(err, ErrRefundNothingToRefund) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.refundRepo.WithTx(tx).Create(ctx, refunds)
+}
+
+// planPesananRefunds mengunci pembayaran pesanan lalu menyusun refund baru
+func (s *refundService) planPesananRefunds(ctx context.Context, tx *gorm.DB, pesananID uuid.UUID, items []refundPlanItem, alasan string) ([]models.PesananRefund, error) {
+	pembayaran, err := s.refundRepo.WithTx(tx).LockPembayaran(ctx, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.refundRepo.WithTx(tx).FindByPesananID(ctx, pesananID)
+	if err != nil {
+		return nil, err
+	}
+	return planRefunds(pembayaran, existing, items, alasan)
+}
+
+// submitToGateway meneruskan refund yang baru disetujui ke payment gateway.
+// Kegagalan sementara dibiarkan PROCESSING untuk diulang job sinkronisasi.
+func (s *refundService) submitToGateway(ctx context.Context, refund *models.PesananRefund) {
+	result, err := s.gateway.Refund(ctx, s.gatewayRequest(refund))
+	if err == nil {
+		err = s.applyGatewayResult(ctx, refund, result)
+	}
+	if err != nil {
+		log.Printf("[refund] kirim ke gateway gagal, diulang job sinkronisasi: refund=%s error=%v", refund.ID, err)
+	}
+}
+
+func (s *refundService) gatewayRequest(refund *models.PesananRefund) *GatewayRefundRequest {
+	req := &GatewayRefundRequest{
+		IdempotencyKey: fmt.Sprintf("%s-%d", refund.ID, refund.Percobaan),
+		ReferenceID:    refund.ID.String(),
+		Amount:         refund.Jumlah,
+		Reason:         "REQUESTED_BY_CUSTOMER",
+	}
+	if refund.RequestedBy == nil {
+		req.Reason = "CANCELLATION"
+	}
+	if refund.Pembayaran != nil && refund.Pembayaran.XenditInvoiceID != nil {
+		req.InvoiceID = *refund.Pembayaran.XenditInvoiceID
+	}
+	return req
+}
+
+// applyGatewayResult menyimpan hasil payment gateway ke refund PROCESSING
+func (s *refundService) applyGatewayResult(ctx context.Context, refund *models.PesananRefund, result *GatewayRefundResult) error {
+	fields := map[string]interface{}{"gateway_status": result.Status}
+	if result.RefundID != "" {
+		fields["gateway_refund_id"] = result.RefundID
+	}
+	processing := []models.RefundStatus{models.RefundStatusProcessing}
+
+	switch result.Status {
+	case GatewayRefundSucceeded:
+		return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return s.completeRefund(ctx, tx, refund, fields)
+		})
+	case GatewayRefundFailed:
+		fields["status"] = models.RefundStatusFailed
+		fields["gateway_error"] = result.FailureReason
+		_, err := s.refundRepo.UpdateStatus(ctx, refund.ID, processing, fields)
+		return err
+	default:
+		_, err := s.refundRepo.UpdateStatus(ctx, refund.ID, processing, fields)
+		return err
+	}
+}
+
+// completeRefund menandai refund COMPLETED lalu memperbarui status pembayaran
+// (REFUNDED jika sudah di-refund penuh) dan payment_status pesanan.
+func (s *refundService) completeRefund(ctx context.Context, tx *gorm.DB, refund *models.PesananRefund, fields map[string]interface{}) error {
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	fields["status"] = models.RefundStatusCompleted
+	fields["refunded_at"] = time.Now()
+	ok, err := s.refundRepo.WithTx(tx).UpdateStatus(ctx, refund.ID, []models.RefundStatus{models.RefundStatusProcessing}, fields)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrRefundStatusNotAllowed
+	}
+
+	pembayaran, err := s.refundRepo.WithTx(tx).LockPembayaran(ctx, refund.PesananID)
+	if err != nil {
+		return err
+	}
+	refunds, err := s.refundRepo.WithTx(tx).FindByPesananID(ctx, refund.PesananID)
+	if err != nil {
+		return err
+	}
+	completed := completedRefundTotals(refunds)
+	for i := range pembayaran {
+		p := &pembayaran[i]
+		if p.Status == models.PaymentStatusPaid && completed[p.ID].GreaterThanOrEqual(p.Jumlah) {
+			if err := tx.Model(&models.PesananPembayaran{}).Where("id = ?", p.ID).
+				Update("status", models.PaymentStatusRefunded).Error; err != nil {
+				return err
+			}
+			p.Status = models.PaymentStatusRefunded
+		}
+	}
+
+	status, changed := resolveRefundedPaymentStatus(pembayaran)
+	if !changed {
+		return nil
+	}
+	var pesanan models.Pesanan
+	if err := tx.Select("id", "payment_status").First(&pesanan, "id = ?", refund.PesananID).Error; err != nil {
+		return err
+	}
+	if pesanan.PaymentStatus == status {
+		return nil
+	}
+	if err := tx.Model(&models.Pesanan{}).Where("id = ?", pesanan.ID).Update("payment_status", status).Error; err != nil {
+		return err
+	}
+	statusFrom := string(pesanan.PaymentStatus)
+	note := "Seluruh pembayaran sudah di-refund"
+	return tx.Create(&models.PesananStatusHistory{
+		PesananID:  pesanan.ID,
+		StatusFrom: &statusFrom,
+		StatusTo:   string(status),
+		StatusType: models.StatusHistoryTypePayment,
+		ChangedBy:  refund.ApprovedBy,
+		Note:       &note,
+	}).Error
+}
+
+func (s *refundService) findPesanan(ctx context.Context, pesananID string) (*models.Pesanan, error) {
+	id, err := uuid.Parse(pesananID)
+	if err != nil {
+		return nil, ErrRefundPesananNotFound
+	}
+	var pesanan models.Pesanan
+	if err := s.db.WithContext(ctx).First(&pesanan, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRefundPesananNotFound
+		}
+		return nil, err
+	}
+	return &pesanan, nil
+}
+
+func (s *refundService) findRefund(ctx context.Context, id string) (*models.PesananRefund, error) {
+	refundID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrRefundNotFound
+	}
+	refund, err := s.refundRepo.FindByID(ctx, refundID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRefundNotFound
+		}
+		return nil, err
+	}
+	return refund, nil
+}
+
+// refundPlanItem satu pembayaran yang diajukan refund; Jumlah nil berarti
+// seluruh sisa pembayaran
+type refundPlanItem struct {
+	PembayaranID uuid.UUID
+	Jumlah       *decimal.Decimal
+}
+
+// planRefunds menyusun refund REQUESTED dari pembayaran pesanan. Items kosong
+// berarti refund penuh atas sisa semua pembayaran lunas. Sisa pembayaran
+// dikurangi refund yang masih berjalan/selesai agar total tidak melebihi yang dibayar.
+func planRefunds(pembayaran []models.PesananPembayaran, existing []models.PesananRefund, items []refundPlanItem, alasan string) ([]models.PesananRefund, error) {
+	reserved := reservedRefundTotals(existing)
+	byID := make(map[uuid.UUID]*models.PesananPembayaran, len(pembayaran))
+	for i := range pembayaran {
+		byID[pembayaran[i].ID] = &pembayaran[i]
+	}
+
+	if len(items) == 0 {
+		for _, p := range pembayaran {
+			if p.Status == models.PaymentStatusPaid && p.Jumlah.Sub(reserved[p.ID]).IsPositive() {
+				items = append(items, refundPlanItem{PembayaranID: p.ID})
+			}
+		}
+		if len(items) == 0 {
+			return nil, ErrRefundNothingToRefund
+		}
+	}
+
+	refunds := make([]models.PesananRefund, 0, len(items))
+	for _, item := range items {
+		p, ok := byID[item.PembayaranID]
+		if !ok {
+			return nil, fmt.Errorf("%w: pembayaran %s bukan milik pesanan ini", ErrRefundInvalid, item.PembayaranID)
+		}
+		if p.Status != models.PaymentStatusPaid {
+			return nil, fmt.Errorf("%w: pembayaran %s berstatus %s, hanya pembayaran PAID yang bisa di-refund", ErrRefundInvalid, p.ID, p.Status)
+		}
+		sisa := p.Jumlah.Sub(reserved[p.ID])
+		jumlah := sisa
+		if item.Jumlah != nil {
+			jumlah = item.Jumlah.Round(2)
+		}
+		if !jumlah.IsPositive() {
+			return nil, fmt.Errorf("%w: jumlah refund pembayaran %s harus lebih dari 0", ErrRefundInvalid, p.ID)
+		}
+		if jumlah.GreaterThan(sisa) {
+			return nil, fmt.Errorf("%w: jumlah refund pembayaran %s melebihi sisa %s", ErrRefundInvalid, p.ID, sisa.StringFixed(2))
+		}
+
+		tipe := models.RefundTipePartial
+		if jumlah.Equal(p.Jumlah) {
+			tipe = models.RefundTipeFull
+		}
+		refunds = append(refunds, models.PesananRefund{
+			PesananID:           p.PesananID,
+			PesananPembayaranID: p.ID,
+			BuyerID:             p.BuyerID,
+			Tipe:                tipe,
+			Jumlah:              jumlah,
+			Alasan:              alasan,
+			Status:              models.RefundStatusRequested,
+		})
+		// Item berulang untuk pembayaran yang sama ikut memotong sisa
+		reserved[p.ID] = reserved[p.ID].Add(jumlah)
+	}
+	return refunds, nil
+}
+
+// reservedRefundTotals total refund per pembayaran yang memotong sisa dana
+func reservedRefundTotals(refunds []models.PesananRefund) map[uuid.UUID]decimal.Decimal {
+	totals := make(map[uuid.UUID]decimal.Decimal)
+	for _, r := range refunds {
+		if r.IsReserved() {
+			totals[r.PesananPembayaranID] = totals[r.PesananPembayaranID].Add(r.Jumlah)
+		}
+	}
+	return totals
+}
+
+// completedRefundTotals total refund COMPLETED per pembayaran
+func completedRefundTotals(refunds []models.PesananRefund) map[uuid.UUID]decimal.Decimal {
+	totals := make(map[uuid.UUID]decimal.Decimal)
+	for _, r := range refunds {
+		if r.Status == models.RefundStatusCompleted {
+			totals[r.PesananPembayaranID] = totals[r.PesananPembayaranID].Add(r.Jumlah)
+		}
+	}
+	return totals
+}
+
+// resolveRefundedPaymentStatus mengembalikan REFUNDED jika tidak ada lagi
+// pembayaran lunas dan minimal satu sudah di-refund penuh. Refund sebagian
+// tidak mengubah payment_status pesanan.
+func resolveRefundedPaymentStatus(pembayaran []models.PesananPembayaran) (models.PaymentStatus, bool) {
+	refunded := false
+	for _, p := range pembayaran {
+		switch p.Status {
+		case models.PaymentStatusPaid:
+			return "", false
+		case models.PaymentStatusRefunded:
+			refunded = true
+		}
+	}
+	return models.PaymentStatusRefunded, refunded
+}
+
+func mapRefundResponse(r *models.PesananRefund) dto.RefundResponse {
+	resp := dto.RefundResponse{
+		ID:                  r.ID,
+		PesananID:           r.PesananID,
+		PesananPembayaranID: r.PesananPembayaranID,
+		BuyerID:             r.BuyerID,
+		Tipe:                string(r.Tipe),
+		Jumlah:              r.Jumlah,
+		Alasan:              r.Alasan,
+		Status:              string(r.Status),
+		IsManual:            r.IsManual,
+		Gateway:             r.Gateway,
+		GatewayRefundID:     r.GatewayRefundID,
+		GatewayStatus:       r.GatewayStatus,
+		GatewayError:        r.GatewayError,
+		CatatanFinance:      r.CatatanFinance,
+		RequestedBy:         r.RequestedBy,
+		ApprovedBy:          r.ApprovedBy,
+		ApprovedAt:          r.ApprovedAt,
+		RejectedBy:          r.RejectedBy,
+		RejectedAt:          r.RejectedAt,
+		RefundedAt:          r.RefundedAt,
+		CreatedAt:           r.CreatedAt,
+	}
+	if r.Pesanan != nil {
+		resp.KodePesanan = r.Pesanan.Kode
+	}
+	if r.Buyer != nil {
+		resp.NamaBuyer = r.Buyer.Nama
+	}
+	return resp
+}
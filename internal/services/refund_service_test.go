@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/orderstate"
+	"project-bulky-be/internal/repositories"
+	"project-bulky-be/internal/testutil/fakedb"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func testPembayaran(jumlah int64, status models.PaymentStatus) models.PesananPembayaran {
+	return models.PesananPembayaran{ID: uuid.New(), BuyerID: uuid.New(), Jumlah: decimal.NewFromInt(jumlah), Status: status}
+}
+
+// fakeRefundRepository menyimpan refund di memori; hanya method yang dipakai
+// hook pembatalan yang diimplementasikan. Create hanya diterima lewat WithTx.
+type fakeRefundRepository struct {
+	repositories.PesananRefundRepository
+	pembayaran []models.PesananPembayaran
+	refunds    []models.PesananRefund
+	createErr  error
+}
+
+func (r *fakeRefundRepository) WithTx(tx *gorm.DB) repositories.PesananRefundRepository {
+	return &fakeRefundTx{fakeRefundRepository: r}
+}
+
+func (r *fakeRefundRepository) LockPembayaran(context.Context, uuid.UUID) ([]models.PesananPembayaran, error) {
+	return r.pembayaran, nil
+}
+
+func (r *fakeRefundRepository) FindByPesananID(context.Context, uuid.UUID) ([]models.PesananRefund, error) {
+	return r.refunds, nil
+}
+
+func (r *fakeRefundRepository) Create(context.Context, []models.PesananRefund) error {
+	return errors.New("refund harus disimpan di transaksi pemanggil")
+}
+
+type fakeRefundTx struct {
+	*fakeRefundRepository
+}
+
+func (r *fakeRefundTx) Create(_ context.Context, refunds []models.PesananRefund) error {
+	if r.createErr != nil {
+		return r.createErr
+	}
+	r.refunds = append(r.refunds, refunds...)
+	return nil
+}
+
+func TestPlanRefunds(t *testing.T) {
+	// Pesanan SPLIT: dua pembayar lunas, satu masih pending
+	a := testPembayaran(600000, models.PaymentStatusPaid)
+	b := testPembayaran(400000, models.PaymentStatusPaid)
+	c := testPembayaran(250000, models.PaymentStatusPending)
+	pembayaran := []models.PesananPembayaran{a, b, c}
+
+	// Tanpa items: refund penuh semua pembayaran lunas, per pembayar
+	refunds, err := planRefunds(pembayaran, nil, nil, "batal")
+	if err != nil || len(refunds) != 2 {
+		t.Fatalf("harus 2 refund penuh, got %+v err=%v", refunds, err)
+	}
+	if refunds[0].Tipe != models.RefundTipeFull || refunds[1].BuyerID != b.BuyerID {
+		t.Fatalf("refund penuh per pembayar salah: %+v", refunds)
+	}
+
+	// Refund sebagian yang sudah berjalan memotong sisa; yang ditolak tidak
+	partial := decimal.NewFromInt(100000)
+	existing := []models.PesananRefund{
+		{PesananPembayaranID: a.ID, Jumlah: decimal.NewFromInt(200000), Status: models.RefundStatusCompleted},
+		{PesananPembayaranID: a.ID, Jumlah: decimal.NewFromInt(300000), Status: models.RefundStatusRejected},
+	}
+	refunds, err = planRefunds(pembayaran, existing, []refundPlanItem{{PembayaranID: a.ID, Jumlah: &partial}}, "rusak")
+	if err != nil || refunds[0].Tipe != models.RefundTipePartial || !refunds[0].Jumlah.Equal(partial) {
+		t.Fatalf("refund sebagian salah: %+v err=%v", refunds, err)
+	}
+
+	// Jumlah kosong berarti sisa pembayaran (600rb - 200rb)
+	refunds, err = planRefunds(pembayaran, existing, []refundPlanItem{{PembayaranID: a.ID}}, "rusak")
+	if err != nil || !refunds[0].Jumlah.Equal(decimal.NewFromInt(400000)) {
+		t.Fatalf("sisa refund harus 400000, got %+v err=%v", refunds, err)
+	}
+
+	// Melebihi sisa, pembayaran belum lunas & pembayaran pesanan lain ditolak
+	lebih := decimal.NewFromInt(400001)
+	for _, items := range [][]refundPlanItem{
+		{{PembayaranID: a.ID, Jumlah: &lebih}},
+		{{PembayaranID: a.ID}, {PembayaranID: a.ID}},
+		{{PembayaranID: c.ID}},
+		{{PembayaranID: uuid.New()}},
+	} {
+		if _, err := planRefunds(pembayaran, existing, items, "x"); !errors.Is(err, ErrRefundInvalid) {
+			t.Fatalf("items %+v harus ditolak, got %v", items, err)
+		}
+	}
+
+	// Tidak ada pembayaran lunas
+	if _, err := planRefunds([]models.PesananPembayaran{c}, nil, nil, "x"); !errors.Is(err, ErrRefundNothingToRefund) {
+		t.Fatalf("pesanan tanpa pembayaran lunas harus ErrRefundNothingToRefund, got %v", err)
+	}
+}
+
+func TestResolveRefundedPaymentStatus(t *testing.T) {
+	paid := testPembayaran(1, models.PaymentStatusPaid)
+	refunded := testPembayaran(1, models.PaymentStatusRefunded)
+	pending := testPembayaran(1, models.PaymentStatusPending)
+
+	// Masih ada pembayar SPLIT yang belum di-refund penuh
+	if _, changed := resolveRefundedPaymentStatus([]models.PesananPembayaran{paid, refunded}); changed {
+		t.Fatal("pesanan dengan pembayaran PAID tidak boleh REFUNDED")
+	}
+	status, changed := resolveRefundedPaymentStatus([]models.PesananPembayaran{refunded, pending})
+	if !changed || status != models.PaymentStatusRefunded {
+		t.Fatalf("semua pembayaran lunas sudah di-refund harus REFUNDED, got %s %v", status, changed)
+	}
+	if _, changed := resolveRefundedPaymentStatus([]models.PesananPembayaran{pending}); changed {
+		t.Fatal("pesanan tanpa refund tidak boleh berubah")
+	}
+}
+
+func TestCancelPaidPesananRequestsRefund(t *testing.T) {
+	pesanan := models.Pesanan{
+		ID: uuid.New(), Kode: "ORD-20261017-0002", BuyerID: uuid.New(),
+		OrderStatus: models.OrderStatusProcessing, PaymentStatus: models.PaymentStatusPaid, DeliveryType: models.DeliveryTypeForwarder,
+		Total: decimal.NewFromInt(1000000),
+	}
+	// Pesanan SPLIT: pembayar A sudah menerima refund sebagian, pembayar C belum bayar
+	a := testPembayaran(600000, models.PaymentStatusPaid)
+	b := testPembayaran(400000, models.PaymentStatusPaid)
+	c := testPembayaran(200000, models.PaymentStatusPending)
+	repo := &fakeRefundRepository{
+		pembayaran: []models.PesananPembayaran{a, b, c},
+		refunds: []models.PesananRefund{{
+			PesananPembayaranID: a.ID, BuyerID: a.BuyerID, Tipe: models.RefundTipePartial,
+			Jumlah: decimal.NewFromInt(100000), Status: models.RefundStatusCompleted,
+		}},
+	}
+	db, fake := fakedb.OpenPesanan(t, pesanan, nil)
+	machine := orderstate.NewMachine(db)
+	NewRefundService(db, repo, nil, machine)
+
+	if _, err := machine.Transition(context.Background(), orderstate.Request{PesananID: pesanan.ID, To: models.OrderStatusCancelled, Trigger: orderstate.TriggerAdmin}); err != nil {
+		t.Fatalf("pembatalan pesanan harus berhasil: %v", err)
+	}
+	if len(repo.refunds) != 3 || fake.Commits() != 1 {
+		t.Fatalf("pembatalan harus mengajukan refund untuk dua pembayar lunas lalu commit, got %d refund (commit=%d)", len(repo.refunds)-1, fake.Commits())
+	}
+	expected := []struct {
+		pembayaran models.PesananPembayaran
+		tipe       models.RefundTipe
+		jumlah     int64
+	}{
+		// Sisa setelah refund sebelumnya
+		{a, models.RefundTipePartial, 500000},
+		{b, models.RefundTipeFull, 400000},
+	}
+	for i, want := range expected {
+		refund := repo.refunds[i+1]
+		if refund.PesananPembayaranID != want.pembayaran.ID || refund.BuyerID != want.pembayaran.BuyerID ||
+			refund.Tipe != want.tipe || !refund.Jumlah.Equal(decimal.NewFromInt(want.jumlah)) ||
+			refund.Status != models.RefundStatusRequested || refund.Alasan != alasanRefundPembatalan {
+			t.Fatalf("refund pembayar %d harus %s %d ke pembayarnya sendiri, got %+v", i+1, want.tipe, want.jumlah, refund)
+		}
+	}
+
+	// Gagal menyimpan refund membatalkan pembatalan pesanan
+	pesanan.ID = uuid.New()
+	db, fake = fakedb.OpenPesanan(t, pesanan, nil)
+	machine = orderstate.NewMachine(db)
+	storeErr := errors.New("koneksi terputus")
+	NewRefundService(db, &fakeRefundRepository{pembayaran: []models.PesananPembayaran{a, b}, createErr: storeErr}, nil, machine)
+
+	_, err := machine.Transition(context.Background(), orderstate.Request{PesananID: pesanan.ID, To: models.OrderStatusCancelled, Trigger: orderstate.TriggerAdmin})
+	if !errors.Is(err, storeErr) || fake.Commits() != 0 || fake.Rollbacks() != 1 {
+		t.Fatalf("gagal mengajukan refund harus me-rollback pembatalan, got err=%v commit=%d rollback=%d", err, fake.Commits(), fake.Rollbacks())
+	}
+}
@@ -19,11 +19,12 @@ type RoleService interface {
 }
 
 type roleService struct {
-	repo repositories.RoleRepository
+	repo            repositories.RoleRepository
+	permissionCache PermissionCache
 }
 
-func NewRoleService(repo repositories.RoleRepository) RoleService {
-	return &roleService{repo: repo}
+func NewRoleService(repo repositories.RoleRepository, permissionCache PermissionCache) RoleService {
+	return &roleService{repo: repo, permissionCache: permissionCache}
 }
 
 func (s *roleService) GetAll() ([]models.RoleResponseFormat, error) {
@@ -59,11 +60,12 @@ func (s *roleService) GetByIDWithPermissions(id uuid.UUID) (*models.RoleDetailRe
 	}
 
 	return &models.RoleDetailResponse{
-		ID:          role.ID.String(),
-		Nama:        role.Nama,
-		Kode:        role.Kode,
-		Deskripsi:   role.Deskripsi,
-		Permissions: permissions,
+		ID:               role.ID.String(),
+		Nama:             role.Nama,
+		Kode:             role.Kode,
+		Deskripsi:        role.Deskripsi,
+		RequireTwoFactor: role.RequireTwoFactor,
+		Permissions:      permissions,
 	}, nil
 }
 
@@ -104,7 +106,11 @@ func (s *roleService) Update(role *models.Role, permissionIDs []uuid.UUID) error
 	}
 
 	// Assign permissions (replace all, bisa kosong)
-	return s.repo.AssignPermissions(role.ID, permissionIDs)
+	err = s.repo.AssignPermissions(role.ID, permissionIDs)
+
+	// Admin dengan role ini langsung memakai permission baru di request berikutnya
+	s.permissionCache.InvalidateRole(role.ID)
+	return err
 }
 
 func (s *roleService) Delete(id uuid.UUID) error {
@@ -129,5 +135,9 @@ func (s *roleService) Delete(id uuid.UUID) error {
 		return errors.New("role masih digunakan oleh admin, tidak dapat dihapus")
 	}
 
-	return s.repo.Delete(id)
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.permissionCache.InvalidateRole(id)
+	return nil
 }
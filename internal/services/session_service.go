@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSessionRevoked  = errors.New("sesi sudah berakhir, silakan login ulang")
+	ErrSessionRequired = errors.New("token tidak terikat sesi, silakan login ulang")
+)
+
+// sessionCacheTTL membatasi berapa lama hasil pengecekan session disimpan di
+// memori. Revoke dari instance yang sama langsung menghapus cache; revoke dari
+// instance lain (multi-replica) paling lambat terlihat setelah TTL ini.
+const sessionCacheTTL = 30 * time.Second
+
+// SessionService mengelola session server-side yang mengikat setiap access
+// token (claim sid), sehingga token bisa dicabut sebelum expired.
+type SessionService interface {
+	Create(ctx context.Context, userType models.UserType, userID uuid.UUID, ipAddress, userAgent string, expiresAt time.Time) (*models.AuthSession, error)
+	// ValidateSession dipanggil AuthMiddleware untuk setiap request.
+	ValidateSession(ctx context.Context, claims *utils.JWTClaims) error
+	// Extend memperpanjang masa berlaku session saat refresh token ditukar.
+	// Mengembalikan ErrSessionRevoked jika session sudah tidak aktif.
+	Extend(ctx context.Context, sessionID uuid.UUID, expiresAt time.Time) (*models.AuthSession, error)
+	Revoke(ctx context.Context, sessionID uuid.UUID, reason string) error
+	// RevokeAllForUser me-revoke semua session user. exceptID (opsional) dipakai
+	// saat ganti password agar session yang sedang dipakai tetap aktif.
+	RevokeAllForUser(ctx context.Context, userType models.UserType, userID uuid.UUID, reason string, exceptID *uuid.UUID) error
+	// StartCleanupScheduler menghapus session expired secara berkala sampai ctx dibatalkan.
+	StartCleanupScheduler(ctx context.Context, interval time.Duration)
+}
+
+// sessionCacheEntry menyimpan data session (bukan hasil validasi per token),
+// sehingga token dengan claim yang tidak cocok tidak "meracuni" cache milik
+// pemilik session yang sah.
+type sessionCacheEntry struct {
+	session  *models.AuthSession // untuk key sid:<id>, nil = tidak ditemukan
+	active   bool                // untuk key buyer:<id> (token tanpa sid)
+	cachedAt time.Time
+}
+
+type sessionService struct {
+	repo     repositories.AuthSessionRepository
+	authRepo repositories.AuthRepository
+
+	mu    sync.RWMutex
+	cache map[string]sessionCacheEntry
+}
+
+func NewSessionService(repo repositories.AuthSessionRepository, authRepo repositories.AuthRepository) SessionService {
+	return &sessionService{
+		repo:     repo,
+		authRepo: authRepo,
+		cache:    make(map[string]sessionCacheEntry),
+	}
+}
+
+func (s *sessionService) Create(ctx context.Context, userType models.UserType, userID uuid.UUID, ipAddress, userAgent string, expiresAt time.Time) (*models.AuthSession, error) {
+	session := &models.AuthSession{
+		ID:        uuid.New(),
+		UserType:  userType,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}
+	if ipAddress != "" {
+		session.IPAddress = &ipAddress
+	}
+	if userAgent != "" {
+		if len(userAgent) > 500 {
+			userAgent = userAgent[:500]
+		}
+		session.UserAgent = &userAgent
+	}
+
+	if err := s.repo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *sessionService) ValidateSession(ctx context.Context, claims *utils.JWTClaims) error {
+	if claims.SessionID == "" {
+		// Token buyer diterbitkan storefront BE (DB & secret yang sama) tanpa
+		// session; minimal pastikan akun buyer masih aktif & belum dihapus.
+		if claims.UserType == string(models.UserTypeBuyer) {
+			return s.validateBuyerAccount(ctx, claims.UserID)
+		}
+		// Token admin tanpa sid berasal dari versi sebelum session server-side
+		// → wajib login ulang.
+		return ErrSessionRequired
+	}
+
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return ErrSessionRevoked
+	}
+
+	key := "sid:" + sessionID.String()
+	entry, ok := s.cached(key)
+	if !ok {
+		session, err := s.repo.FindByID(ctx, sessionID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		// Session yang tidak ditemukan ikut di-cache (session nil) agar token
+		// palsu/terhapus tidak memicu query berulang.
+		entry = sessionCacheEntry{session: session}
+		s.store(key, entry)
+	}
+
+	session := entry.session
+	if session == nil ||
+		!session.IsActive(time.Now()) ||
+		session.UserID.String() != claims.UserID ||
+		string(session.UserType) != claims.UserType {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+func (s *sessionService) validateBuyerAccount(ctx context.Context, userID string) error {
+	buyerID, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrSessionRevoked
+	}
+
+	key := "buyer:" + buyerID.String()
+	entry, ok := s.cached(key)
+	if !ok {
+		buyer, err := s.authRepo.FindBuyerByID(buyerID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		entry = sessionCacheEntry{active: err == nil && buyer.IsActive}
+		s.store(key, entry)
+	}
+
+	if !entry.active {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+func (s *sessionService) Extend(ctx context.Context, sessionID uuid.UUID, expiresAt time.Time) (*models.AuthSession, error) {
+	// Selalu baca dari DB (bukan cache) agar revoke dari instance lain langsung terlihat
+	session, err := s.repo.FindByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionRevoked
+		}
+		return nil, err
+	}
+	if !session.IsActive(time.Now()) {
+		return nil, ErrSessionRevoked
+	}
+
+	if err := s.repo.UpdateExpiresAt(ctx, sessionID, expiresAt); err != nil {
+		return nil, err
+	}
+	session.ExpiresAt = expiresAt
+	s.invalidate("sid:" + sessionID.String())
+	return session, nil
+}
+
+func (s *sessionService) Revoke(ctx context.Context, sessionID uuid.UUID, reason string) error {
+	if err := s.repo.Revoke(ctx, sessionID, reason); err != nil {
+		return err
+	}
+	s.invalidate("sid:" + sessionID.String())
+	return nil
+}
+
+func (s *sessionService) RevokeAllForUser(ctx context.Context, userType models.UserType, userID uuid.UUID, reason string, exceptID *uuid.UUID) error {
+	ids, err := s.repo.RevokeByUser(ctx, userType, userID, reason, exceptID)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, "sid:"+id.String())
+	}
+	if userType == models.UserTypeBuyer {
+		keys = append(keys, "buyer:"+userID.String())
+	}
+	s.invalidate(keys...)
+	return nil
+}
+
+func (s *sessionService) StartCleanupScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[auth-session] cleanup scheduler dihentikan")
+			return
+		case <-ticker.C:
+			// Session expired disimpan 7 hari untuk kebutuhan audit sebelum dihapus
+			deleted, err := s.repo.DeleteExpired(ctx, time.Now().Add(-7*24*time.Hour))
+			if err != nil {
+				log.Printf("[auth-session] gagal menghapus session expired: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("[auth-session] %d session expired dihapus", deleted)
+			}
+			s.pruneCache()
+		}
+	}
+}
+
+func (s *sessionService) cached(key string) (sessionCacheEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.cache[key]
+	if !ok || time.Since(entry.cachedAt) > sessionCacheTTL {
+		return sessionCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (s *sessionService) store(key string, entry sessionCacheEntry) {
+	entry.cachedAt = time.Now()
+	s.mu.Lock()
+	s.cache[key] = entry
+	s.mu.Unlock()
+}
+
+func (s *sessionService) invalidate(keys ...string) {
+	s.mu.Lock()
+	for _, key := range keys {
+		delete(s.cache, key)
+	}
+	s.mu.Unlock()
+}
+
+func (s *sessionService) pruneCache() {
+	s.mu.Lock()
+	for key, entry := range s.cache {
+		if time.Since(entry.cachedAt) > sessionCacheTTL {
+			delete(s.cache, key)
+		}
+	}
+	s.mu.Unlock()
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeAuthSessionRepository menyimpan session di memori dan menghitung
+// berapa kali FindByID dipanggil (untuk memverifikasi cache).
+type fakeAuthSessionRepository struct {
+	sessions map[uuid.UUID]*models.AuthSession
+	finds    int
+}
+
+func (r *fakeAuthSessionRepository) Create(ctx context.Context, session *models.AuthSession) error {
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *fakeAuthSessionRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.AuthSession, error) {
+	r.finds++
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (r *fakeAuthSessionRepository) FindActiveByUser(ctx context.Context, userType models.UserType, userID uuid.UUID) ([]models.AuthSession, error) {
+	return nil, nil
+}
+
+func (r *fakeAuthSessionRepository) UpdateExpiresAt(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	if session, ok := r.sessions[id]; ok && session.RevokedAt == nil {
+		session.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+func (r *fakeAuthSessionRepository) Revoke(ctx context.Context, id uuid.UUID, reason string) error {
+	if session, ok := r.sessions[id]; ok {
+		now := time.Now()
+		session.RevokedAt = &now
+		session.RevokedReason = &reason
+	}
+	return nil
+}
+
+func (r *fakeAuthSessionRepository) RevokeByUser(ctx context.Context, userType models.UserType, userID uuid.UUID, reason string, exceptID *uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for id, session := range r.sessions {
+		if session.UserType != userType || session.UserID != userID || session.RevokedAt != nil {
+			continue
+		}
+		if exceptID != nil && id == *exceptID {
+			continue
+		}
+		r.Revoke(ctx, id, reason)
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (r *fakeAuthSessionRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestSessionServiceRevocation(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeAuthSessionRepository{sessions: map[uuid.UUID]*models.AuthSession{}}
+	svc := NewSessionService(repo, nil)
+
+	adminID := uuid.New()
+	current, _ := svc.Create(ctx, models.UserTypeAdmin, adminID, "127.0.0.1", "test", time.Now().Add(time.Hour))
+	other, _ := svc.Create(ctx, models.UserTypeAdmin, adminID, "127.0.0.1", "test", time.Now().Add(time.Hour))
+
+	claimsFor := func(s *models.AuthSession) *utils.JWTClaims {
+		return &utils.JWTClaims{UserID: adminID.String(), UserType: "ADMIN", SessionID: s.ID.String()}
+	}
+
+	if err := svc.ValidateSession(ctx, claimsFor(current)); err != nil {
+		t.Fatalf("session aktif ditolak: %v", err)
+	}
+	// Request kedua harus dilayani dari cache
+	if err := svc.ValidateSession(ctx, claimsFor(current)); err != nil {
+		t.Fatalf("session aktif ditolak (cache): %v", err)
+	}
+	if repo.finds != 1 {
+		t.Fatalf("expected 1 lookup DB (sisanya dari cache), got %d", repo.finds)
+	}
+
+	// Ganti password: session lain dicabut, session saat ini tetap aktif
+	if err := svc.ValidateSession(ctx, claimsFor(other)); err != nil {
+		t.Fatalf("session lain ditolak sebelum revoke: %v", err)
+	}
+	if err := svc.RevokeAllForUser(ctx, models.UserTypeAdmin, adminID, models.SessionRevokePasswordChanged, &current.ID); err != nil {
+		t.Fatalf("RevokeAllForUser: %v", err)
+	}
+	if err := svc.ValidateSession(ctx, claimsFor(other)); err != ErrSessionRevoked {
+		t.Fatalf("session yang sudah di-revoke harus ditolak walau ada di cache, got %v", err)
+	}
+	if err := svc.ValidateSession(ctx, claimsFor(current)); err != nil {
+		t.Fatalf("session saat ini tidak boleh ikut di-revoke: %v", err)
+	}
+
+	// Logout
+	if err := svc.Revoke(ctx, current.ID, models.SessionRevokeLogout); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if err := svc.ValidateSession(ctx, claimsFor(current)); err != ErrSessionRevoked {
+		t.Fatalf("session setelah logout harus ditolak, got %v", err)
+	}
+
+	// Token admin tanpa sid (versi lama) wajib login ulang
+	legacy := &utils.JWTClaims{UserID: adminID.String(), UserType: "ADMIN"}
+	if err := svc.ValidateSession(ctx, legacy); err != ErrSessionRequired {
+		t.Fatalf("token admin tanpa sid harus ditolak, got %v", err)
+	}
+
+	// sid milik user lain tidak boleh dipakai
+	third, _ := svc.Create(ctx, models.UserTypeAdmin, adminID, "127.0.0.1", "test", time.Now().Add(time.Hour))
+	stolen := &utils.JWTClaims{UserID: uuid.New().String(), UserType: "ADMIN", SessionID: third.ID.String()}
+	if err := svc.ValidateSession(ctx, stolen); err != ErrSessionRevoked {
+		t.Fatalf("sid dengan user berbeda harus ditolak, got %v", err)
+	}
+	if err := svc.ValidateSession(ctx, claimsFor(third)); err != nil {
+		t.Fatalf("pemilik sah session tidak boleh ikut ditolak setelah percobaan sid curian: %v", err)
+	}
+}
@@ -0,0 +1,423 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Status pesanan_status_history untuk aksi pengiriman (status_type SHIPMENT)
+const (
+	ShipmentHistoryCancelled = "CANCELLED"
+	ShipmentHistoryRebooked  = "REBOOKED"
+)
+
+// ErrShipmentBusy dikembalikan jika booking pesanan sedang dipegang proses lain
+// (job booking, retry, atau aksi admin lain)
+var ErrShipmentBusy = errors.New("booking pesanan sedang diproses proses lain, coba lagi beberapa saat lagi")
+
+// CancelShipmentOptions parameter pembatalan booking pengiriman
+type CancelShipmentOptions struct {
+	// PengirimanID nil berarti semua pengiriman yang masih bisa dibatalkan
+	PengirimanID *uuid.UUID
+	Reason       *string
+	ChangedBy    *uuid.UUID
+}
+
+// RebookShipmentOptions parameter booking ulang satu pengiriman
+type RebookShipmentOptions struct {
+	PengirimanID uuid.UUID
+	// VehicleTypeID kendaraan Deliveree pengganti (opsional)
+	VehicleTypeID *int
+	// DeliveryType jalur Forwarder pengganti: FORWARDER (LTL) / FORWARDER_LCL (opsional)
+	DeliveryType *models.DeliveryType
+	Reason       *string
+	ChangedBy    *uuid.UUID
+}
+
+// CancelShipments membatalkan booking pengiriman di provider lalu mengosongkan
+// booking ref-nya agar bisa di-booking ulang. Pengiriman yang sudah dibawa
+// driver/selesai dilewati. Webhook yang datang belakangan untuk booking ref
+// lama tidak lagi cocok dengan pengiriman mana pun sehingga diabaikan.
+func (s *shippingService) CancelShipments(ctx context.Context, pesanan *models.Pesanan, opts CancelShipmentOptions) ([]models.PesananPengiriman, error) {
+	locked, err := s.lockBooking(pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, ErrShipmentBusy
+	}
+	defer s.releaseBooking(pesanan.ID)
+
+	shipments, err := s.pengirimanRepo.FindByPesananID(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Booking lama (sebelum ada pesanan_pengiriman) hanya tercatat di kolom pesanan
+	if len(shipments) == 0 && opts.PengirimanID == nil {
+		return nil, s.cancelLegacyBooking(ctx, pesanan, opts)
+	}
+
+	var targets []*models.PesananPengiriman
+	for i := range shipments {
+		shipment := &shipments[i]
+		if opts.PengirimanID != nil {
+			if shipment.ID != *opts.PengirimanID {
+				continue
+			}
+			if !shipment.IsBooked() {
+				return nil, errors.New("pengiriman belum memiliki booking")
+			}
+			if !shipmentCancellable(shipment) {
+				return nil, errors.New("pengiriman sudah dibawa driver/selesai, booking tidak bisa dibatalkan")
+			}
+		} else if !shipment.IsBooked() || !shipmentCancellable(shipment) {
+			continue
+		}
+		targets = append(targets, shipment)
+	}
+	if opts.PengirimanID != nil && len(targets) == 0 {
+		return nil, errors.New("pengiriman tidak ditemukan")
+	}
+
+	// Satu provider gagal tidak menghentikan pembatalan pengiriman lain
+	var errs []error
+	for _, shipment := range targets {
+		if err := s.cancelShipment(ctx, pesanan, shipment, opts.Reason, opts.ChangedBy); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	refreshed, err := s.pengirimanRepo.FindByPesananID(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	return refreshed, errors.Join(errs...)
+}
+
+// RebookShipment membatalkan booking satu pengiriman (jika ada) lalu membooking
+// ulang dengan kendaraan atau jalur pengganti. Verifikasi quote ongkir tidak
+// dijalankan: penggantian kendaraan/jalur adalah keputusan admin.
+func (s *shippingService) RebookShipment(ctx context.Context, pesanan *models.Pesanan, opts RebookShipmentOptions) (*models.PesananPengiriman, error) {
+	if pesanan.AlamatBuyer == nil {
+		return nil, errors.New("pesanan tidak memiliki alamat pengiriman")
+	}
+
+	locked, err := s.lockBooking(pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, ErrShipmentBusy
+	}
+	defer s.releaseBooking(pesanan.ID)
+
+	shipments, err := s.pengirimanRepo.FindByPesananID(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+	var shipment *models.PesananPengiriman
+	for i := range shipments {
+		if shipments[i].ID == opts.PengirimanID {
+			shipment = &shipments[i]
+			break
+		}
+	}
+	if shipment == nil {
+		return nil, errors.New("pengiriman tidak ditemukan")
+	}
+	if !shipmentCancellable(shipment) {
+		return nil, errors.New("pengiriman sudah dibawa driver/selesai, tidak bisa di-booking ulang")
+	}
+
+	deliveryType, err := rebookDeliveryType(shipment.DeliveryType, opts)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := s.Provider(deliveryType)
+	if err != nil {
+		return nil, err
+	}
+
+	previousRef := ""
+	if shipment.IsBooked() {
+		previousRef = *shipment.BookingRef
+		reason := "Dibatalkan untuk booking ulang"
+		if opts.Reason != nil && *opts.Reason != "" {
+			reason += ": " + *opts.Reason
+		}
+		if err := s.cancelShipment(ctx, pesanan, shipment, &reason, opts.ChangedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	req := &ShipmentRequest{
+		Pesanan:       pesanan,
+		Reference:     shipmentReference(pesanan, shipment, len(shipments)),
+		Origin:        &shipment.Warehouse,
+		Destination:   pesanan.AlamatBuyer,
+		Items:         shipment.Items,
+		VehicleTypeID: opts.VehicleTypeID,
+	}
+	ref, bookErr := s.bookShipment(ctx, provider, shipment, req)
+	if bookErr != nil {
+		log.Printf("[shipping] booking ulang gagal: pesanan=%s pengiriman=%d delivery_type=%s error=%v", pesanan.Kode, shipment.Urutan, deliveryType, bookErr)
+		msg := bookErr.Error()
+		if err := s.pengirimanRepo.UpdateFields(ctx, shipment.ID, map[string]interface{}{
+			"delivery_type": deliveryType,
+			"booking_error": msg,
+		}); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("booking ulang gagal: %w", bookErr)
+	}
+	log.Printf("[shipping] booking ulang sukses: pesanan=%s pengiriman=%d delivery_type=%s ref=%s sebelumnya=%s", pesanan.Kode, shipment.Urutan, deliveryType, ref, previousRef)
+
+	note := fmt.Sprintf("Pengiriman #%d (%s) di-booking ulang via %s, booking %s", shipment.Urutan, shipment.Warehouse.Nama, deliveryType, ref)
+	if previousRef != "" {
+		note += " menggantikan " + previousRef
+	}
+	if opts.VehicleTypeID != nil {
+		note += fmt.Sprintf(", kendaraan %d", *opts.VehicleTypeID)
+	}
+	note = appendReason(note, opts.Reason)
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.pengirimanRepo.WithTx(tx).UpdateFields(ctx, shipment.ID, map[string]interface{}{
+			"delivery_type":  deliveryType,
+			"booking_ref":    ref,
+			"booking_status": nil,
+			"booking_error":  nil,
+			"tracking_url":   nil,
+			"booked_at":      time.Now(),
+		}); err != nil {
+			return err
+		}
+		// Jalur Forwarder pesanan mengikuti pilihan admin agar UI konsisten
+		if deliveryType != pesanan.DeliveryType {
+			if err := tx.Model(&models.Pesanan{}).Where("id = ?", pesanan.ID).Update("delivery_type", deliveryType).Error; err != nil {
+				return err
+			}
+		}
+		statusFrom := string(shipment.DeliveryType)
+		return tx.Create(&models.PesananStatusHistory{
+			PesananID:  pesanan.ID,
+			StatusFrom: &statusFrom,
+			StatusTo:   ShipmentHistoryRebooked,
+			StatusType: models.StatusHistoryTypeShipment,
+			ChangedBy:  opts.ChangedBy,
+			Note:       &note,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.pengirimanRepo.FindByID(ctx, shipment.ID)
+}
+
+// cancelShipment membatalkan booking satu pengiriman di provider, lalu dalam
+// satu transaksi mengosongkan booking-nya dan mencatat riwayat SHIPMENT.
+func (s *shippingService) cancelShipment(ctx context.Context, pesanan *models.Pesanan, shipment *models.PesananPengiriman, reason *string, changedBy *uuid.UUID) error {
+	ref := *shipment.BookingRef
+	provider, err := s.Provider(shipment.DeliveryType)
+	if err != nil {
+		return err
+	}
+	note := fmt.Sprintf("Pengiriman #%d (%s) booking %s %s dibatalkan", shipment.Urutan, shipment.Warehouse.Nama, shipment.DeliveryType, ref)
+	// Booking yang sudah dibatalkan provider (webhook canceled) cukup dilepas
+	if shipment.BookingStatus != nil && *shipment.BookingStatus == string(ProviderWebhookStatusCanceled) {
+		note += " (sudah dibatalkan provider)"
+	} else {
+		// Niat batal dicatat dulu: jika proses terhenti setelah provider
+		// membatalkan, booking_ref yang masih terisi tetap punya jejak
+		if err := s.pengirimanRepo.UpdateFields(ctx, shipment.ID, map[string]interface{}{
+			"cancel_requested_at": time.Now(),
+		}); err != nil {
+			return err
+		}
+		if err := provider.Cancel(ctx, ref); err != nil {
+			msg := fmt.Sprintf("gagal membatalkan booking %s di %s: %v", ref, provider.Code(), err)
+			if err := s.pengirimanRepo.UpdateFields(ctx, shipment.ID, map[string]interface{}{"booking_error": msg}); err != nil {
+				log.Printf("[shipping] gagal mencatat error pembatalan: pesanan=%s pengiriman=%d error=%v", pesanan.Kode, shipment.Urutan, err)
+			}
+			return fmt.Errorf("gagal membatalkan booking %s di %s: %w", ref, provider.Code(), err)
+		}
+	}
+	log.Printf("[shipping] booking dibatalkan: pesanan=%s pengiriman=%d delivery_type=%s ref=%s", pesanan.Kode, shipment.Urutan, shipment.DeliveryType, ref)
+	note = appendReason(note, reason)
+	statusFrom := "BOOKED"
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.pengirimanRepo.WithTx(tx).UpdateFields(ctx, shipment.ID, map[string]interface{}{
+			"booking_ref":         nil,
+			"booking_status":      nil,
+			"booking_error":       nil,
+			"tracking_url":        nil,
+			"booked_at":           nil,
+			"cancel_requested_at": nil,
+		}); err != nil {
+			return err
+		}
+		return tx.Create(&models.PesananStatusHistory{
+			PesananID:  pesanan.ID,
+			StatusFrom: &statusFrom,
+			StatusTo:   ShipmentHistoryCancelled,
+			StatusType: models.StatusHistoryTypeShipment,
+			ChangedBy:  changedBy,
+			Note:       &note,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	shipment.BookingRef = nil
+	shipment.BookingStatus = nil
+	shipment.BookedAt = nil
+	shipment.CancelRequestedAt = nil
+	return nil
+}
+
+// cancelLegacyBooking membatalkan booking pesanan lama yang belum punya data
+// pesanan_pengiriman (booking ref hanya ada di kolom pesanan)
+func (s *shippingService) cancelLegacyBooking(ctx context.Context, pesanan *models.Pesanan, opts CancelShipmentOptions) error {
+	ref := pesanan.DelivereeBookingID
+	if ref == nil {
+		ref = pesanan.ForwarderTrackingNo
+	}
+	if ref == nil || *ref == "" {
+		return nil
+	}
+	if pesanan.BookingStatus != nil && !bookingStatusCancellable(*pesanan.BookingStatus) {
+		return nil
+	}
+	provider, err := s.Provider(pesanan.DeliveryType)
+	if err != nil {
+		return err
+	}
+	canceled := pesanan.BookingStatus != nil && *pesanan.BookingStatus == string(ProviderWebhookStatusCanceled)
+	if !canceled {
+		// Pesanan lama tidak punya cancel_requested_at; niat batal dicatat di
+		// booking_error yang baru dikosongkan setelah pembatalan tercatat
+		intent := fmt.Sprintf("pembatalan booking %s di %s belum selesai", *ref, provider.Code())
+		if err := s.db.WithContext(ctx).Model(&models.Pesanan{}).Where("id = ?", pesanan.ID).
+			UpdateColumn("booking_error", intent).Error; err != nil {
+			return err
+		}
+		if err := provider.Cancel(ctx, *ref); err != nil {
+			msg := fmt.Sprintf("gagal membatalkan booking %s di %s: %v", *ref, provider.Code(), err)
+			if err := s.db.WithContext(ctx).Model(&models.Pesanan{}).Where("id = ?", pesanan.ID).
+				UpdateColumn("booking_error", msg).Error; err != nil {
+				log.Printf("[shipping] gagal mencatat error pembatalan: pesanan=%s error=%v", pesanan.Kode, err)
+			}
+			return fmt.Errorf("gagal membatalkan booking %s di %s: %w", *ref, provider.Code(), err)
+		}
+	}
+
+	note := appendReason(fmt.Sprintf("Booking %s %s dibatalkan", pesanan.DeliveryType, *ref), opts.Reason)
+	statusFrom := "BOOKED"
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Pesanan{}).Where("id = ?", pesanan.ID).UpdateColumns(map[string]interface{}{
+			"deliveree_booking_id":  nil,
+			"forwarder_tracking_no": nil,
+			"booking_status":        nil,
+			"booking_error":         nil,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PesananStatusHistory{
+			PesananID:  pesanan.ID,
+			StatusFrom: &statusFrom,
+			StatusTo:   ShipmentHistoryCancelled,
+			StatusType: models.StatusHistoryTypeShipment,
+			ChangedBy:  opts.ChangedBy,
+			Note:       &note,
+		}).Error
+	})
+}
+
+// lockBooking memegang booking_lock_at pesanan selama aksi admin berjalan agar
+// tidak bertabrakan dengan job booking atau retry (lihat ClaimBooking). Lock
+// yang lebih tua dari bookingLockTimeout dianggap milik proses yang mati.
+func (s *shippingService) lockBooking(pesananID uuid.UUID) (bool, error) {
+	now := time.Now().UTC()
+	res := s.db.Model(&models.Pesanan{}).
+		Where("id = ?", pesananID).
+		Where("booking_lock_at IS NULL OR booking_lock_at < ?", now.Add(-bookingLockTimeout)).
+		Update("booking_lock_at", now)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// releaseBooking melepas lock dan menyelaraskan kolom booking pesanan dengan
+// pengiriman utama, sama seperti BookShipments
+func (s *shippingService) releaseBooking(pesananID uuid.UUID) {
+	updates := map[string]interface{}{"booking_lock_at": nil}
+	var primary models.PesananPengiriman
+	err := s.db.Where("pesanan_id = ?", pesananID).Order("urutan ASC").First(&primary).Error
+	if err == nil {
+		updates["deliveree_booking_id"] = nil
+		updates["forwarder_tracking_no"] = nil
+		if primary.IsBooked() {
+			column := "forwarder_tracking_no"
+			if primary.DeliveryType == models.DeliveryTypeDeliveree {
+				column = "deliveree_booking_id"
+			}
+			updates[column] = *primary.BookingRef
+		}
+	}
+	if err := s.db.Model(&models.Pesanan{}).Where("id = ?", pesananID).UpdateColumns(updates).Error; err != nil {
+		log.Printf("[shipping] gagal melepas lock booking pesanan=%s: %v", pesananID, err)
+	}
+}
+
+// shipmentCancellable false jika barang sudah dibawa driver atau sampai
+func shipmentCancellable(shipment *models.PesananPengiriman) bool {
+	if shipment.ShippedAt != nil || shipment.CompletedAt != nil {
+		return false
+	}
+	return shipment.BookingStatus == nil || bookingStatusCancellable(*shipment.BookingStatus)
+}
+
+func bookingStatusCancellable(status string) bool {
+	return status != string(ProviderWebhookStatusDeliveryInProgress) &&
+		status != string(ProviderWebhookStatusDeliveryCompleted)
+}
+
+// rebookDeliveryType memvalidasi pengganti kendaraan/jalur. Deliveree dan
+// Forwarder ditagih berbeda, jadi booking ulang tidak bisa pindah antar
+// keduanya; jalur hanya bisa diganti antar LTL dan LCL.
+func rebookDeliveryType(current models.DeliveryType, opts RebookShipmentOptions) (models.DeliveryType, error) {
+	target := current
+	if opts.DeliveryType != nil {
+		target = *opts.DeliveryType
+		if !isForwarder(current) || !isForwarder(target) {
+			return "", errors.New("jalur hanya bisa diganti antar Forwarder LTL (FORWARDER) dan LCL (FORWARDER_LCL)")
+		}
+	}
+	if opts.VehicleTypeID != nil && target != models.DeliveryTypeDeliveree {
+		return "", errors.New("kendaraan hanya bisa dipilih untuk pengiriman Deliveree")
+	}
+	return target, nil
+}
+
+func isForwarder(deliveryType models.DeliveryType) bool {
+	return deliveryType == models.DeliveryTypeForwarder || deliveryType == models.DeliveryTypeForwarderLCL
+}
+
+func appendReason(note string, reason *string) string {
+	if reason != nil && strings.TrimSpace(*reason) != "" {
+		return note + ". Alasan: " + strings.TrimSpace(*reason)
+	}
+	return note
+}
@@ -0,0 +1,409 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	coverageMatchedOnKota     = "kota"
+	coverageMatchedOnProvinsi = "provinsi"
+
+	forwarderRouteLTL = "LTL"
+	forwarderRouteLCL = "LCL"
+)
+
+// ShippingCoverageService mengelola zona coverage pengiriman (tabel
+// shipping_coverage_zone) dan menentukan provider & jalur untuk satu alamat.
+// Menggantikan daftar provinsi Deliveree/Forwarder LTL yang dulu hard-coded.
+type ShippingCoverageService interface {
+	GetAll(ctx context.Context, params *models.ShippingCoverageZoneFilterRequest) ([]models.ShippingCoverageZoneResponse, *models.PaginationMeta, error)
+	GetByID(ctx context.Context, id string) (*models.ShippingCoverageZoneResponse, error)
+	Create(ctx context.Context, req *models.CreateShippingCoverageZoneRequest) (*models.ShippingCoverageZoneResponse, error)
+	Update(ctx context.Context, id string, req *models.UpdateShippingCoverageZoneRequest) (*models.ShippingCoverageZoneResponse, error)
+	Delete(ctx context.Context, id string) error
+	// Resolve mencocokkan alamat ke zona aktif. Nama kota dinormalisasi lewat
+	// mapping kota/kecamatan Forwarder sehingga ejaan lain tetap dikenali.
+	Resolve(ctx context.Context, alamat *models.AlamatBuyer) (*models.ShippingCoverageResult, error)
+	// TestAddress menjalankan Resolve untuk alamat buyer tersimpan atau wilayah
+	// yang diisi manual, untuk dicek admin sebelum/ sesudah mengubah zona.
+	TestAddress(ctx context.Context, req *models.TestShippingCoverageRequest) (*models.ShippingCoverageResult, error)
+}
+
+type shippingCoverageService struct {
+	repo                 repositories.ShippingCoverageZoneRepository
+	forwarderMappingRepo repositories.ForwarderMappingRepository
+	alamatBuyerRepo      repositories.AlamatBuyerRepository
+}
+
+func NewShippingCoverageService(repo repositories.ShippingCoverageZoneRepository, forwarderMappingRepo repositories.ForwarderMappingRepository, alamatBuyerRepo repositories.AlamatBuyerRepository) ShippingCoverageService {
+	return &shippingCoverageService{
+		repo:                 repo,
+		forwarderMappingRepo: forwarderMappingRepo,
+		alamatBuyerRepo:      alamatBuyerRepo,
+	}
+}
+
+func (s *shippingCoverageService) GetAll(ctx context.Context, params *models.ShippingCoverageZoneFilterRequest) ([]models.ShippingCoverageZoneResponse, *models.PaginationMeta, error) {
+	zones, total, err := s.repo.FindAll(ctx, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	responses := make([]models.ShippingCoverageZoneResponse, 0, len(zones))
+	for i := range zones {
+		responses = append(responses, *toShippingCoverageZoneResponse(&zones[i]))
+	}
+
+	meta := models.NewPaginationMeta(params.Page, params.PerPage, total)
+	return responses, &meta, nil
+}
+
+func (s *shippingCoverageService) GetByID(ctx context.Context, id string) (*models.ShippingCoverageZoneResponse, error) {
+	zone, err := s.findZone(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toShippingCoverageZoneResponse(zone), nil
+}
+
+func (s *shippingCoverageService) Create(ctx context.Context, req *models.CreateShippingCoverageZoneRequest) (*models.ShippingCoverageZoneResponse, error) {
+	provinsi := strings.TrimSpace(req.Provinsi)
+	if provinsi == "" {
+		return nil, errors.New("Provinsi wajib diisi")
+	}
+
+	zone := &models.ShippingCoverageZone{
+		Provider: models.DeliveryType(req.Provider),
+		Provinsi: provinsi,
+		Kota:     trimOptional(req.Kota),
+		Aliases:  cleanAliases(req.Aliases),
+		IsActive: true,
+		Catatan:  req.Catatan,
+	}
+	if req.IsActive != nil {
+		zone.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.Create(ctx, zone); err != nil {
+		if strings.Contains(err.Error(), "duplicate key value") {
+			return nil, errors.New("Zona coverage untuk provider & wilayah ini sudah ada")
+		}
+		return nil, err
+	}
+
+	return toShippingCoverageZoneResponse(zone), nil
+}
+
+func (s *shippingCoverageService) Update(ctx context.Context, id string, req *models.UpdateShippingCoverageZoneRequest) (*models.ShippingCoverageZoneResponse, error) {
+	zone, err := s.findZone(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Provider != nil {
+		zone.Provider = models.DeliveryType(*req.Provider)
+	}
+	if req.Provinsi != nil {
+		provinsi := strings.TrimSpace(*req.Provinsi)
+		if provinsi == "" {
+			return nil, errors.New("Provinsi wajib diisi")
+		}
+		zone.Provinsi = provinsi
+	}
+	// Kota string kosong mengubah zona menjadi level provinsi
+	if req.Kota != nil {
+		zone.Kota = trimOptional(req.Kota)
+	}
+	if req.Aliases != nil {
+		zone.Aliases = cleanAliases(req.Aliases)
+	}
+	if req.IsActive != nil {
+		zone.IsActive = *req.IsActive
+	}
+	if req.Catatan != nil {
+		zone.Catatan = req.Catatan
+	}
+
+	if err := s.repo.Update(ctx, zone); err != nil {
+		if strings.Contains(err.Error(), "duplicate key value") {
+			return nil, errors.New("Zona coverage untuk provider & wilayah ini sudah ada")
+		}
+		return nil, err
+	}
+
+	return toShippingCoverageZoneResponse(zone), nil
+}
+
+func (s *shippingCoverageService) Delete(ctx context.Context, id string) error {
+	zone, err := s.findZone(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, zone.ID)
+}
+
+func (s *shippingCoverageService) findZone(ctx context.Context, id string) (*models.ShippingCoverageZone, error) {
+	zoneID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("ID zona coverage tidak valid")
+	}
+
+	zone, err := s.repo.FindByID(ctx, zoneID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("Zona coverage tidak ditemukan")
+		}
+		return nil, err
+	}
+	return zone, nil
+}
+
+// ─── Resolver ─────────────────────────────────────────────────────────────────
+
+func (s *shippingCoverageService) Resolve(ctx context.Context, alamat *models.AlamatBuyer) (*models.ShippingCoverageResult, error) {
+	zones, err := s.repo.FindActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	kotaCandidates, forwarderCity, err := s.resolveKota(ctx, alamat.Kota, alamat.Kecamatan)
+	if err != nil {
+		return nil, err
+	}
+	provinsi := normalizeProvinsi(alamat.Provinsi)
+
+	result := &models.ShippingCoverageResult{
+		Provinsi:  alamat.Provinsi,
+		Kota:      alamat.Kota,
+		Kecamatan: alamat.Kecamatan,
+		Deliveree: buildCoverageMatch(matchCoverageZone(zones, models.DeliveryTypeDeliveree, provinsi, kotaCandidates)),
+		Forwarder: buildCoverageMatch(matchCoverageZone(zones, models.DeliveryTypeForwarder, provinsi, kotaCandidates)),
+	}
+	if alamat.ID != uuid.Nil {
+		alamatID := alamat.ID.String()
+		result.AlamatBuyerID = &alamatID
+	}
+	if forwarderCity != nil {
+		result.ForwarderCity = &models.ForwarderCityMappingResponse{
+			ID:                forwarderCity.ID,
+			KotaPattern:       forwarderCity.KotaPattern,
+			ForwarderCityID:   forwarderCity.ForwarderCityID,
+			ForwarderCityName: forwarderCity.ForwarderCityName,
+			CreatedAt:         forwarderCity.CreatedAt,
+			UpdatedAt:         forwarderCity.UpdatedAt,
+		}
+	}
+
+	result.ForwarderDeliveryType = string(models.DeliveryTypeForwarderLCL)
+	result.ForwarderRoute = forwarderRouteLCL
+	if result.Forwarder.Covered {
+		result.ForwarderDeliveryType = string(models.DeliveryTypeForwarder)
+		result.ForwarderRoute = forwarderRouteLTL
+	}
+
+	return result, nil
+}
+
+func (s *shippingCoverageService) TestAddress(ctx context.Context, req *models.TestShippingCoverageRequest) (*models.ShippingCoverageResult, error) {
+	if req.AlamatBuyerID != nil && *req.AlamatBuyerID != "" {
+		alamat, err := s.alamatBuyerRepo.FindByID(ctx, *req.AlamatBuyerID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("Alamat buyer tidak ditemukan")
+			}
+			return nil, err
+		}
+		return s.Resolve(ctx, alamat)
+	}
+
+	if strings.TrimSpace(req.Provinsi) == "" && strings.TrimSpace(req.Kota) == "" {
+		return nil, errors.New("Isi alamat_buyer_id atau provinsi/kota yang akan dites")
+	}
+	return s.Resolve(ctx, &models.AlamatBuyer{
+		Provinsi:  req.Provinsi,
+		Kota:      req.Kota,
+		Kecamatan: req.Kecamatan,
+	})
+}
+
+// resolveKota mengumpulkan semua ejaan kota alamat yang dikenal: nama asli
+// yang dinormalisasi, nama kota Forwarder hasil mapping, serta pattern lain
+// yang menunjuk city_id Forwarder yang sama. Jika kota tidak ter-mapping,
+// kecamatan dipakai untuk menebak kota (hanya jika hasilnya tunggal, sama
+// seperti lookup subdistrict saat booking).
+func (s *shippingCoverageService) resolveKota(ctx context.Context, kota string, kecamatan *string) ([]string, *models.ForwarderCityMapping, error) {
+	candidates := []string{}
+	seen := map[string]bool{}
+	add := func(name string) {
+		name = utils.NormalizeKota(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			candidates = append(candidates, name)
+		}
+	}
+	add(kota)
+
+	forwarderCityID := 0
+	var forwarderCity *models.ForwarderCityMapping
+	if pattern := utils.NormalizeKota(kota); pattern != "" {
+		mapping, err := s.forwarderMappingRepo.FindCityByPattern(ctx, pattern)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+		if mapping != nil {
+			forwarderCity = mapping
+			forwarderCityID = mapping.ForwarderCityID
+		}
+	}
+
+	if forwarderCityID == 0 && kecamatan != nil {
+		if pattern := utils.NormalizeKecamatan(*kecamatan); pattern != "" {
+			subdistricts, err := s.forwarderMappingRepo.FindSubdistrictsByPattern(ctx, pattern)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(subdistricts) == 1 {
+				forwarderCityID = subdistricts[0].ForwarderCityID
+			}
+		}
+	}
+
+	if forwarderCityID != 0 {
+		cities, err := s.forwarderMappingRepo.FindCitiesByForwarderCityID(ctx, forwarderCityID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range cities {
+			if forwarderCity == nil {
+				forwarderCity = &cities[i]
+			}
+			add(cities[i].KotaPattern)
+			add(cities[i].ForwarderCityName)
+		}
+	}
+
+	return candidates, forwarderCity, nil
+}
+
+// matchCoverageZone mencari zona aktif provider yang melayani alamat. Zona
+// level kota diutamakan dan cukup cocok di nama kota (provinsi dari Google
+// Maps sering beda ejaan); zona level provinsi dicocokkan lewat nama
+// provinsi atau aliasnya.
+func matchCoverageZone(zones []models.ShippingCoverageZone, provider models.DeliveryType, provinsi string, kotaCandidates []string) (*models.ShippingCoverageZone, string) {
+	kotaSet := make(map[string]bool, len(kotaCandidates))
+	for _, k := range kotaCandidates {
+		kotaSet[k] = true
+	}
+
+	for i := range zones {
+		zone := &zones[i]
+		if zone.Provider != provider || !zone.IsActive || zone.Kota == nil {
+			continue
+		}
+		if kotaSet[utils.NormalizeKota(*zone.Kota)] {
+			return zone, coverageMatchedOnKota
+		}
+		for _, alias := range zone.Aliases {
+			if kotaSet[utils.NormalizeKota(alias)] {
+				return zone, coverageMatchedOnKota
+			}
+		}
+	}
+
+	if provinsi == "" {
+		return nil, ""
+	}
+	for i := range zones {
+		zone := &zones[i]
+		if zone.Provider != provider || !zone.IsActive || zone.Kota != nil {
+			continue
+		}
+		if normalizeProvinsi(zone.Provinsi) == provinsi {
+			return zone, coverageMatchedOnProvinsi
+		}
+		for _, alias := range zone.Aliases {
+			if normalizeProvinsi(alias) == provinsi {
+				return zone, coverageMatchedOnProvinsi
+			}
+		}
+	}
+
+	return nil, ""
+}
+
+// normalizeProvinsi menyamakan penulisan provinsi: lowercase, spasi ganda
+// dirapikan dan prefix "provinsi" dibuang.
+// Contoh: "Provinsi  Jawa Barat" -> "jawa barat"
+func normalizeProvinsi(provinsi string) string {
+	provinsi = strings.Join(strings.Fields(strings.ToLower(provinsi)), " ")
+	prefixes := []string{"provinsi ", "prov. ", "prov "}
+	for _, p := range prefixes {
+		provinsi = strings.TrimPrefix(provinsi, p)
+	}
+	return strings.TrimSpace(provinsi)
+}
+
+func buildCoverageMatch(zone *models.ShippingCoverageZone, matchedOn string) models.ShippingCoverageMatch {
+	if zone == nil {
+		return models.ShippingCoverageMatch{}
+	}
+	return models.ShippingCoverageMatch{
+		Covered:   true,
+		MatchedOn: &matchedOn,
+		Zone:      toShippingCoverageZoneResponse(zone),
+	}
+}
+
+func toShippingCoverageZoneResponse(zone *models.ShippingCoverageZone) *models.ShippingCoverageZoneResponse {
+	aliases := []string(zone.Aliases)
+	if aliases == nil {
+		aliases = []string{}
+	}
+	return &models.ShippingCoverageZoneResponse{
+		ID:        zone.ID.String(),
+		Provider:  string(zone.Provider),
+		Provinsi:  zone.Provinsi,
+		Kota:      zone.Kota,
+		Aliases:   aliases,
+		IsActive:  zone.IsActive,
+		Catatan:   zone.Catatan,
+		CreatedAt: zone.CreatedAt,
+		UpdatedAt: zone.UpdatedAt,
+	}
+}
+
+// cleanAliases membuang alias kosong & duplikat (tidak peka huruf besar/kecil).
+func cleanAliases(aliases []string) models.StringList {
+	result := models.StringList{}
+	seen := map[string]bool{}
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		key := strings.ToLower(alias)
+		if alias == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, alias)
+	}
+	return result
+}
+
+func trimOptional(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+
+	"project-bulky-be/internal/models"
+)
+
+func TestMatchCoverageZone(t *testing.T) {
+	makassar := "Makassar"
+	zones := []models.ShippingCoverageZone{
+		{Provider: models.DeliveryTypeForwarder, Provinsi: "Jawa Barat", Aliases: models.StringList{"West Java"}, IsActive: true},
+		{Provider: models.DeliveryTypeDeliveree, Provinsi: "Sulawesi Selatan", Kota: &makassar, Aliases: models.StringList{"Kota Ujung Pandang"}, IsActive: true},
+		{Provider: models.DeliveryTypeDeliveree, Provinsi: "Bali", IsActive: false},
+	}
+
+	// Ejaan Google Maps (bahasa Inggris + prefix provinsi) tetap cocok lewat alias
+	zone, matchedOn := matchCoverageZone(zones, models.DeliveryTypeForwarder, normalizeProvinsi("Provinsi  west java"), nil)
+	if zone == nil || matchedOn != coverageMatchedOnProvinsi {
+		t.Fatalf("alias provinsi harus cocok, got %v %q", zone, matchedOn)
+	}
+
+	// Zona level kota cocok lewat alias kota meski provinsi alamat beda ejaan
+	zone, matchedOn = matchCoverageZone(zones, models.DeliveryTypeDeliveree, "south sulawesi", []string{"ujung pandang"})
+	if zone == nil || matchedOn != coverageMatchedOnKota {
+		t.Fatalf("alias kota harus cocok, got %v %q", zone, matchedOn)
+	}
+
+	// Kota lain di provinsi yang sama tidak ikut tercakup zona level kota
+	if zone, _ := matchCoverageZone(zones, models.DeliveryTypeDeliveree, "sulawesi selatan", []string{"gowa"}); zone != nil {
+		t.Fatalf("kota di luar zona tidak boleh cocok")
+	}
+
+	// Zona nonaktif & provider lain diabaikan
+	if zone, _ := matchCoverageZone(zones, models.DeliveryTypeDeliveree, "bali", nil); zone != nil {
+		t.Fatalf("zona nonaktif tidak boleh cocok")
+	}
+	if zone, _ := matchCoverageZone(zones, models.DeliveryTypeDeliveree, "jawa barat", nil); zone != nil {
+		t.Fatalf("zona provider lain tidak boleh cocok")
+	}
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// buktiPengirimanDir direktori salinan tanda tangan penerima di UPLOAD_PATH
+const buktiPengirimanDir = "bukti-pengiriman"
+
+// signatureExtensions tipe gambar tanda tangan yang diterima dari provider
+var signatureExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/jpg":  ".jpg",
+	"image/webp": ".webp",
+}
+
+// CaptureProofOfDelivery menyimpan bukti pengiriman setiap pengiriman Deliveree
+// yang sudah ter-booking. Bukti yang sudah ada ditimpa dengan detail terbaru;
+// tanda tangan yang sudah disalin tidak diunduh ulang.
+func (s *shippingService) CaptureProofOfDelivery(ctx context.Context, pesanan *models.Pesanan) ([]models.PesananBuktiPengiriman, error) {
+	shipments, err := s.pengirimanRepo.FindByPesananID(ctx, pesanan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var detailProvider delivereeDetailProvider
+	result := []models.PesananBuktiPengiriman{}
+	var errs []error
+	for i := range shipments {
+		shipment := &shipments[i]
+		if shipment.DeliveryType != models.DeliveryTypeDeliveree || !shipment.IsBooked() {
+			continue
+		}
+		if detailProvider == nil {
+			provider, err := s.Provider(models.DeliveryTypeDeliveree)
+			if err != nil {
+				return nil, err
+			}
+			var ok bool
+			if detailProvider, ok = provider.(delivereeDetailProvider); !ok {
+				return nil, fmt.Errorf("provider %s tidak menyediakan detail Deliveree", provider.Code())
+			}
+		}
+
+		bukti, err := s.captureShipmentProof(ctx, detailProvider, shipment)
+		if bukti != nil {
+			result = append(result, *bukti)
+		}
+		if err != nil {
+			log.Printf("[shipping] gagal menyimpan bukti pengiriman pesanan=%s ref=%s: %v", pesanan.Kode, *shipment.BookingRef, err)
+			errs = append(errs, err)
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+func (s *shippingService) ProofOfDelivery(ctx context.Context, pesananID uuid.UUID) ([]models.PesananBuktiPengiriman, error) {
+	return s.buktiRepo.FindByPesananID(ctx, pesananID)
+}
+
+// captureShipmentProof menyimpan bukti satu pengiriman. Jika tanda tangan gagal
+// disalin, bukti tetap disimpan tanpa signature_path dan error dikembalikan
+// agar job mencoba lagi.
+func (s *shippingService) captureShipmentProof(ctx context.Context, detailProvider delivereeDetailProvider, shipment *models.PesananPengiriman) (*models.PesananBuktiPengiriman, error) {
+	detail, err := detailProvider.Detail(ctx, *shipment.BookingRef)
+	if err != nil {
+		return nil, err
+	}
+	bukti := proofOfDeliveryRecord(shipment, detail, time.Now())
+
+	var copyErr error
+	if bukti.SignatureSourceURL != nil {
+		existing, err := s.buktiRepo.FindByBooking(ctx, shipment.ID, *shipment.BookingRef)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil && existing.SignaturePath != nil && derefString(existing.SignatureSourceURL) == *bukti.SignatureSourceURL {
+			bukti.SignaturePath = existing.SignaturePath
+		} else {
+			signaturePath, err := s.copySignature(ctx, *bukti.SignatureSourceURL, path.Join(buktiPengirimanDir, shipment.PesananID.String()))
+			if err != nil {
+				copyErr = fmt.Errorf("gagal menyalin tanda tangan: %w", err)
+			} else {
+				bukti.SignaturePath = &signaturePath
+			}
+		}
+	}
+
+	if err := s.buktiRepo.Upsert(ctx, bukti); err != nil {
+		return nil, err
+	}
+	return bukti, copyErr
+}
+
+// copySignature mengunduh gambar tanda tangan dari provider ke storage upload
+func (s *shippingService) copySignature(ctx context.Context, url, directory string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	ext, ok := signatureExtensions[mediaType]
+	if !ok {
+		return "", fmt.Errorf("tipe file %q bukan gambar", mediaType)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, utils.MaxImageSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > utils.MaxImageSize {
+		return "", errors.New("ukuran tanda tangan melebihi 5MB")
+	}
+	return utils.SaveFileBytes(data, directory, ext, s.cfg)
+}
+
+// proofOfDeliveryRecord memetakan detail Deliveree ke baris bukti pengiriman.
+// Lokasi pertama adalah titik pickup; bukti penerima diambil dari lokasi tujuan
+// terakhir, sedangkan biaya tambahan dijumlahkan dari semua lokasi.
+func proofOfDeliveryRecord(shipment *models.PesananPengiriman, detail *DelivereeDeliveryDetail, capturedAt time.Time) *models.PesananBuktiPengiriman {
+	raw, err := json.Marshal(detail)
+	if err != nil {
+		raw = json.RawMessage("{}")
+	}
+	bukti := &models.PesananBuktiPengiriman{
+		PesananPengirimanID: shipment.ID,
+		PesananID:           shipment.PesananID,
+		BookingRef:          *shipment.BookingRef,
+		TotalFees:           decimal.NewFromFloat(detail.TotalFees).Round(2),
+		Currency:            optionalString(detail.Currency),
+		CompletedAt:         parseProviderTime(detail.CompletedAt),
+		RawDetail:           raw,
+		CapturedAt:          capturedAt,
+	}
+	if detail.Driver != nil {
+		bukti.DriverName = optionalString(detail.Driver.Name)
+		bukti.DriverPhone = optionalString(detail.Driver.Phone)
+	}
+
+	parking, tolls, waiting := decimal.Zero, decimal.Zero, decimal.Zero
+	for _, loc := range detail.Locations {
+		parking = parking.Add(decimal.NewFromFloat(loc.ParkingFees))
+		tolls = tolls.Add(decimal.NewFromFloat(loc.TollsFees))
+		waiting = waiting.Add(decimal.NewFromFloat(loc.WaitingTimeFees))
+	}
+	bukti.ParkingFees = parking.Round(2)
+	bukti.TollsFees = tolls.Round(2)
+	bukti.WaitingTimeFees = waiting.Round(2)
+
+	if len(detail.Locations) > 1 {
+		dest := detail.Locations[len(detail.Locations)-1]
+		bukti.DeliveryStatus = optionalString(dest.DeliveryStatus)
+		bukti.RecipientName = optionalString(dest.RecipientName)
+		bukti.RecipientPhone = optionalString(dest.RecipientPhone)
+		bukti.FailedDeliveryReason = optionalString(dest.FailedDeliveryReason)
+		bukti.SignatureSourceURL = optionalString(dest.SignatureURL)
+		bukti.ArrivedAt = parseProviderTime(dest.ArrivedAt)
+		bukti.LeftAt = parseProviderTime(dest.LeavedAt)
+	}
+	return bukti
+}
+
+// parseProviderTime membaca waktu dari provider: RFC3339, unix detik, atau
+// format tanggal/jam WIB. Nil jika kosong atau formatnya tidak dikenal.
+func parseProviderTime(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		t := time.Unix(unix, 0)
+		return &t
+	}
+	for _, layout := range trackingTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, jakartaLocation); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+func optionalString(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
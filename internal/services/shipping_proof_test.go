@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestProofOfDeliveryRecord(t *testing.T) {
+	ref := "D-123"
+	shipment := &models.PesananPengiriman{ID: uuid.New(), PesananID: uuid.New(), BookingRef: &ref}
+	detail := &DelivereeDeliveryDetail{
+		TotalFees:   350000,
+		Currency:    "IDR",
+		CompletedAt: "2026-10-01T15:04:05+07:00",
+		Driver:      &DelivereeDriver{Name: "Budi", Phone: "0812"},
+		Locations: []DelivereeDeliveryLocation{
+			// Lokasi pickup: biaya ikut dijumlah, data penerima diabaikan
+			{Name: "Gudang", RecipientName: "Staf Gudang", ParkingFees: 5000},
+			{
+				Name:            "Tujuan",
+				RecipientName:   "Andi",
+				DeliveryStatus:  "delivered",
+				SignatureURL:    "https://cdn.deliveree.com/sig.png",
+				ArrivedAt:       "2026-10-01 14:30:00",
+				ParkingFees:     10000,
+				TollsFees:       25000.5,
+				WaitingTimeFees: 15000,
+			},
+		},
+	}
+
+	bukti := proofOfDeliveryRecord(shipment, detail, time.Now())
+	if derefString(bukti.RecipientName) != "Andi" || derefString(bukti.SignatureSourceURL) != "https://cdn.deliveree.com/sig.png" {
+		t.Fatalf("penerima harus dari lokasi tujuan, got %v / %v", derefString(bukti.RecipientName), derefString(bukti.SignatureSourceURL))
+	}
+	if bukti.ParkingFees.String() != "15000" || bukti.TollsFees.String() != "25000.5" || bukti.WaitingTimeFees.String() != "15000" {
+		t.Fatalf("biaya tambahan dijumlah dari semua lokasi, got parkir=%s tol=%s tunggu=%s", bukti.ParkingFees, bukti.TollsFees, bukti.WaitingTimeFees)
+	}
+	if bukti.ArrivedAt == nil || !bukti.ArrivedAt.Equal(time.Date(2026, 10, 1, 14, 30, 0, 0, jakartaLocation)) {
+		t.Fatalf("arrived_at harus dibaca sebagai WIB, got %v", bukti.ArrivedAt)
+	}
+	if bukti.CompletedAt == nil || bukti.LeftAt != nil {
+		t.Fatalf("completed_at RFC3339 harus terbaca dan left_at kosong tetap nil, got %v / %v", bukti.CompletedAt, bukti.LeftAt)
+	}
+	if derefString(bukti.DriverName) != "Budi" || bukti.BookingRef != ref {
+		t.Fatalf("driver & booking ref harus tersimpan, got %v / %s", derefString(bukti.DriverName), bukti.BookingRef)
+	}
+}
+
+func TestParseProviderTime(t *testing.T) {
+	if got := parseProviderTime("1760000000"); got == nil || got.Unix() != 1760000000 {
+		t.Fatalf("unix detik harus terbaca, got %v", got)
+	}
+	if got := parseProviderTime("bukan waktu"); got != nil {
+		t.Fatalf("format tidak dikenal harus nil, got %v", got)
+	}
+}
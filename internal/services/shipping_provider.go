@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+	"project-bulky-be/pkg/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+// Mode provider pengiriman (SHIPPING_PROVIDER_MODE)
+const (
+	// ShippingModeLive memakai API Deliveree & Forwarder sungguhan
+	ShippingModeLive = "live"
+	// ShippingModeSandbox memakai provider tiruan untuk local/staging: booking,
+	// progres status dan webhook disimulasikan tanpa memanggil API luar
+	ShippingModeSandbox = "sandbox"
+)
+
+// Kode provider, dipakai di log dan hasil tracking/quote
+const (
+	ShippingProviderDeliveree    = "DELIVEREE"
+	ShippingProviderForwarderLTL = "FORWARDER_LTL"
+	ShippingProviderForwarderLCL = "FORWARDER_LCL"
+	ShippingProviderSandbox      = "SANDBOX"
+)
+
+var (
+	// ErrShippingQuoteUnsupported dikembalikan provider yang belum punya API tarif
+	ErrShippingQuoteUnsupported = errors.New("provider tidak mendukung quote ongkir")
+	// ErrShippingCancelUnsupported dikembalikan provider yang tidak bisa membatalkan booking via API
+	ErrShippingCancelUnsupported = errors.New("provider tidak mendukung pembatalan booking")
+)
+
+// ShipmentRequest adalah satu pengiriman dari gudang asal ke alamat buyer
+type ShipmentRequest struct {
+	// Pesanan dipakai untuk kendaraan hasil checkout dan nilai barang (asuransi)
+	Pesanan *models.Pesanan
+	// Reference dikirim ke provider sebagai nomor referensi booking: kode
+	// pesanan, ditambah urutan pengiriman jika pesanan dikirim dari beberapa gudang
+	Reference   string
+	Origin      *models.Warehouse
+	Destination *models.AlamatBuyer
+	Items       []models.PesananItem
+	// QuotedVehicleTypeID adalah kendaraan Deliveree hasil quote untuk gudang
+	// asal ini; diutamakan saat booking agar sama dengan ongkir yang ditagih
+	QuotedVehicleTypeID *int
+	// VehicleTypeID adalah kendaraan Deliveree pilihan admin saat booking ulang;
+	// diutamakan di atas kendaraan quote maupun checkout
+	VehicleTypeID *int
+	// RencanaMuat adalah rencana muat Deliveree yang sudah disusun & disimpan
+	// sebelum booking; nil berarti provider menyusunnya sendiri (mis. saat quote)
+	RencanaMuat *models.RencanaMuat
+}
+
+// isWholeOrder mengembalikan true jika pengiriman membawa semua item pesanan
+func (r *ShipmentRequest) isWholeOrder() bool {
+	return len(r.Items) == len(r.Pesanan.Items)
+}
+
+// NilaiBarang adalah nilai barang yang diasuransikan untuk pengiriman ini
+func (r *ShipmentRequest) NilaiBarang() decimal.Decimal {
+	if r.isWholeOrder() {
+		return r.Pesanan.BiayaProduk
+	}
+	total := decimal.Zero
+	for _, item := range r.Items {
+		total = total.Add(item.Subtotal)
+	}
+	return total
+}
+
+// PremiAsuransi membagi biaya asuransi pesanan (BiayaLainnya) secara
+// proporsional terhadap nilai barang pengiriman
+func (r *ShipmentRequest) PremiAsuransi() decimal.Decimal {
+	if r.isWholeOrder() || r.Pesanan.BiayaProduk.IsZero() {
+		return r.Pesanan.BiayaLainnya
+	}
+	return r.Pesanan.BiayaLainnya.Mul(r.NilaiBarang()).Div(r.Pesanan.BiayaProduk).Round(0)
+}
+
+// TotalKubikasi menghitung total volume barang dalam m3 (dimensi produk dalam cm)
+func (r *ShipmentRequest) TotalKubikasi() float64 {
+	total := 0.0
+	for _, item := range r.Items {
+		p := item.Produk
+		total += (p.Panjang * p.Lebar * p.Tinggi / 1_000_000) * float64(item.Qty)
+	}
+	return total
+}
+
+// TotalBerat menghitung total berat barang dalam kg
+func (r *ShipmentRequest) TotalBerat() float64 {
+	total := 0.0
+	for _, item := range r.Items {
+		total += item.Produk.Berat * float64(item.Qty)
+	}
+	return total
+}
+
+// TotalBeratVolumetrik menghitung total berat volumetrik barang dalam kg
+func (r *ShipmentRequest) TotalBeratVolumetrik() float64 {
+	total := 0.0
+	for _, item := range r.Items {
+		p := item.Produk
+		total += utils.CalculateBeratVolumetrik(p.Panjang, p.Lebar, p.Tinggi) * float64(item.Qty)
+	}
+	return total
+}
+
+// BeratTertagih adalah berat yang dipakai tarif: yang lebih besar antara
+// berat aktual dan berat volumetrik
+func (r *ShipmentRequest) BeratTertagih() float64 {
+	return math.Max(r.TotalBerat(), r.TotalBeratVolumetrik())
+}
+
+// ShippingQuote adalah estimasi ongkir dari provider
+type ShippingQuote struct {
+	Provider      string          `json:"provider"`
+	VehicleTypeID *int            `json:"vehicle_type_id,omitempty"`
+	VehicleName   string          `json:"vehicle_name,omitempty"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+}
+
+// ShippingWebhookEvent adalah event status dari webhook provider yang sudah
+// dinormalisasi
+type ShippingWebhookEvent struct {
+	BookingRef  string `json:"booking_ref"`
+	Status      string `json:"status"`
+	TrackingURL string `json:"tracking_url,omitempty"`
+}
+
+// ShippingProvider adalah satu carrier pengiriman. Carrier baru cukup
+// mengimplementasikan interface ini lalu didaftarkan di NewShippingService.
+type ShippingProvider interface {
+	Code() string
+	// Quote menghitung estimasi ongkir untuk pengiriman
+	Quote(ctx context.Context, req *ShipmentRequest) (*ShippingQuote, error)
+	// Book membuat booking di provider dan mengembalikan booking ref
+	// (booking ID Deliveree / booking number Forwarder)
+	Book(ctx context.Context, req *ShipmentRequest) (string, error)
+	Track(ctx context.Context, bookingRef string) (*TrackingResult, error)
+	Cancel(ctx context.Context, bookingRef string) error
+	// ParseWebhook membaca body webhook mentah dari provider
+	ParseWebhook(body []byte) (*ShippingWebhookEvent, error)
+}
+
+// delivereeDetailProvider diimplementasikan provider yang bisa mengembalikan
+// detail lengkap booking format Deliveree (driver, lokasi, biaya)
+type delivereeDetailProvider interface {
+	Detail(ctx context.Context, bookingRef string) (*DelivereeDeliveryDetail, error)
+}
+
+// loadPlanProvider diimplementasikan provider yang memilih kendaraan dari
+// rencana muat (Deliveree); rencana disimpan di pengiriman sebelum booking
+type loadPlanProvider interface {
+	PlanLoad(ctx context.Context, req *ShipmentRequest) (*models.RencanaMuat, error)
+}
+
+// forwarderInvoiceProvider diimplementasikan provider yang menyediakan invoice Forwarder
+type forwarderInvoiceProvider interface {
+	Invoices(ctx context.Context, bookingNo string) ([]ForwarderInvoice, error)
+}
+
+// parseOnDemandWebhook membaca format webhook on-demand yang dipakai bersama
+// oleh Deliveree dan Forwarder (status + id/no_booking + tracking_url)
+func parseOnDemandWebhook(body []byte) (*ShippingWebhookEvent, error) {
+	var req dto.DelivereeWebhookRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, &WebhookPayloadError{Detail: err.Error()}
+	}
+	if req.Status == "" {
+		return nil, &WebhookPayloadError{Detail: "field status wajib diisi"}
+	}
+	ref := string(req.ID)
+	if ref == "" {
+		ref = string(req.NoBooking)
+	}
+	if ref == "" {
+		return nil, &WebhookPayloadError{Detail: "field id atau no_booking wajib diisi"}
+	}
+	return &ShippingWebhookEvent{BookingRef: ref, Status: req.Status, TrackingURL: req.TrackingURL}, nil
+}
+
+// buildFullAddress menggabungkan alamat lengkap dengan data wilayah agar
+// address yang dikirim ke provider tidak hanya berisi nama jalan saja.
+func buildFullAddress(alamat *models.AlamatBuyer) string {
+	parts := []string{alamat.AlamatLengkap}
+	if alamat.Kelurahan != nil && *alamat.Kelurahan != "" {
+		parts = append(parts, *alamat.Kelurahan)
+	}
+	if alamat.Kecamatan != nil && *alamat.Kecamatan != "" {
+		parts = append(parts, *alamat.Kecamatan)
+	}
+	if alamat.Kota != "" {
+		parts = append(parts, alamat.Kota)
+	}
+	if alamat.Provinsi != "" {
+		parts = append(parts, alamat.Provinsi)
+	}
+	if alamat.KodePos != nil && *alamat.KodePos != "" {
+		parts = append(parts, *alamat.KodePos)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func derefFloat(f *float64) float64 {
+	if f == nil {
+		return 0
+	}
+	return *f
+}
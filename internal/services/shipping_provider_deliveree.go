@@ -0,0 +1,488 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// delivereeProvider adalah ShippingProvider untuk Deliveree (on-demand,
+// hanya Jawa, Sumatra dan Bali)
+type delivereeProvider struct {
+	baseURL     string
+	apiKey      string
+	vehicleType DelivereeVehicleTypeService
+}
+
+func newDelivereeProvider(baseURL, apiKey string, vehicleType DelivereeVehicleTypeService) *delivereeProvider {
+	return &delivereeProvider{baseURL: baseURL, apiKey: apiKey, vehicleType: vehicleType}
+}
+
+func (p *delivereeProvider) Code() string {
+	return ShippingProviderDeliveree
+}
+
+func (p *delivereeProvider) configured() error {
+	if p.baseURL == "" || p.apiKey == "" {
+		return fmt.Errorf("konfigurasi Deliveree tidak lengkap")
+	}
+	return nil
+}
+
+// environment menentukan master kendaraan yang dipakai (sandbox/production)
+func (p *delivereeProvider) environment() string {
+	if strings.Contains(p.baseURL, "sandbox") {
+		return string(models.DelivereeEnvSandbox)
+	}
+	return string(models.DelivereeEnvProduction)
+}
+
+type delivereeLocation struct {
+	Address        string  `json:"address"`
+	Latitude       float64 `json:"latitude"`
+	Longitude      float64 `json:"longitude"`
+	RecipientName  string  `json:"recipient_name"`
+	RecipientPhone string  `json:"recipient_phone"`
+	Note           string  `json:"note,omitempty"`
+	IsPayer        bool    `json:"is_payer"`
+	NeedCOD        bool    `json:"need_cod,omitempty"`
+	CODNote        string  `json:"cod_note,omitempty"`
+	CODInvoiceFees float64 `json:"cod_invoice_fees,omitempty"`
+	NeedPOD        bool    `json:"need_pod,omitempty"`
+	PODNote        string  `json:"pod_note,omitempty"`
+}
+
+type delivereeCreateRequest struct {
+	VehicleTypeID        int                 `json:"vehicle_type_id"`
+	BookingPaymentType   string              `json:"booking_payment_type"`
+	Note                 string              `json:"note,omitempty"`
+	TimeType             string              `json:"time_type"`
+	PickupTime           string              `json:"pickup_time"`
+	JobOrderNumber       string              `json:"job_order_number,omitempty"`
+	AllowParkingFees     bool                `json:"allow_parking_fees"`
+	AllowTollsFees       bool                `json:"allow_tolls_fees"`
+	AllowWaitingTimeFees bool                `json:"allow_waiting_time_fees"`
+	SendFirstToDriver    bool                `json:"send_first_to_driver"`
+	MarkedAsFavorite     bool                `json:"marked_as_favorite"`
+	Locations            []delivereeLocation `json:"locations"`
+	RequireSignatures    bool                `json:"require_signatures"`
+	ExtraServices        []interface{}       `json:"extra_services"`
+	EstimateTransitTimes []interface{}       `json:"estimate_transit_times_attributes"`
+}
+
+type delivereeCreateResponse struct {
+	BookingID int `json:"booking_id"`
+}
+
+type delivereeQuoteResponse struct {
+	Data []struct {
+		VehicleTypeID   int     `json:"vehicle_type_id"`
+		VehicleTypeName string  `json:"vehicle_type_name"`
+		TotalFees       float64 `json:"total_fees"`
+		Currency        string  `json:"currency"`
+	} `json:"data"`
+}
+
+// PlanLoad menyusun rencana muat pengiriman dari master kendaraan environment aktif
+func (p *delivereeProvider) PlanLoad(ctx context.Context, req *ShipmentRequest) (*models.RencanaMuat, error) {
+	return p.vehicleType.PlanLoad(ctx, p.environment(), req.Items)
+}
+
+// selectVehicle memilih kendaraan untuk pengiriman. vehicle nil berarti
+// vehicleTypeID berasal dari fallback berbasis qty (master data belum ada).
+// Error dikembalikan jika barang tidak muat di ruang kargo kendaraan atau
+// muatan butuh lebih dari satu kendaraan.
+func (p *delivereeProvider) selectVehicle(ctx context.Context, req *ShipmentRequest) (vehicleTypeID int, vehicle *models.DelivereeVehicleType, err error) {
+	pesanan := req.Pesanan
+	environment := p.environment()
+
+	// Rencana muat mengecek dimensi setiap unit terhadap ruang kargo. Master
+	// data yang belum ada tidak menghentikan booking (fallback logic qty di bawah).
+	plan := req.RencanaMuat
+	if plan == nil {
+		plan, err = p.PlanLoad(ctx, req)
+		if errors.Is(err, ErrMuatanMelebihiKendaraan) {
+			return 0, nil, err
+		}
+		if err != nil {
+			log.Printf("[deliveree] rencana muat gagal pesanan=%s environment=%s err=%v — fallback ke logic qty", pesanan.Kode, environment, err)
+			plan = nil
+		}
+	}
+	if plan != nil && plan.IsSplit() {
+		return 0, nil, fmt.Errorf("%w: butuh %s, pecah pengiriman atau pakai Forwarder", ErrMuatanPerluBeberapaKendaraan, describeRencanaMuat(plan))
+	}
+
+	// 1. Prioritas: kendaraan pilihan admin saat booking ulang, jika masih
+	// aktif di environment ini dan ruang kargonya memuat semua barang.
+	if req.VehicleTypeID != nil {
+		if v, vErr := p.vehicleType.FindActiveByIDDeliveree(ctx, *req.VehicleTypeID, environment); vErr == nil && v != nil {
+			if item := unfitItem(v, req.Items); item != nil {
+				return 0, nil, fmt.Errorf("%w: %s tidak muat di kendaraan pilihan %s", ErrMuatanMelebihiKendaraan, item.NamaProduk, v.Nama)
+			}
+			log.Printf("[deliveree] pakai vehicle_type_id pilihan admin pesanan=%s id=%d", pesanan.Kode, v.IDDeliveree)
+			return v.IDDeliveree, v, nil
+		}
+		log.Printf("[deliveree] vehicle_type_id pilihan admin pesanan=%s id=%d tidak aktif/tidak ditemukan", pesanan.Kode, *req.VehicleTypeID)
+	}
+
+	// 2. Kendaraan dari quote ongkir (shipping_quote) untuk gudang asal ini,
+	// jika masih aktif di environment ini dan memuat semua barang.
+	if req.QuotedVehicleTypeID != nil && *req.QuotedVehicleTypeID > 0 {
+		if v := p.fittingVehicle(ctx, req, *req.QuotedVehicleTypeID, "quote"); v != nil {
+			return v.IDDeliveree, v, nil
+		}
+	}
+
+	// 3. Kendaraan yang disimpan storefront saat checkout
+	// (deliveree_vehicle_type_id), jika masih aktif di environment ini dan
+	// memuat semua barang. Ini menjamin ongkir yang ditagih ke buyer saat
+	// checkout konsisten dengan kendaraan yang benar-benar dipakai saat create booking.
+	if pesanan.DelivereeVehicleTypeID != nil && *pesanan.DelivereeVehicleTypeID > 0 {
+		if v := p.fittingVehicle(ctx, req, *pesanan.DelivereeVehicleTypeID, "checkout"); v != nil {
+			return v.IDDeliveree, v, nil
+		}
+	}
+
+	// 4. Jika tidak ada nilai dari checkout (order lama) atau nilainya invalid,
+	// pakai kendaraan rencana muat (kubikasi, berat & dimensi barang).
+	if plan != nil {
+		vehicleTypeID = plan.Kendaraan[0].VehicleTypeID
+		if v, vErr := p.vehicleType.FindActiveByIDDeliveree(ctx, vehicleTypeID, environment); vErr == nil && v != nil {
+			return v.IDDeliveree, v, nil
+		}
+		return vehicleTypeID, nil, nil
+	}
+
+	// Fallback ke logic lama berbasis qty jika master data belum di-sync, agar
+	// proses booking tidak buntu total.
+	totalQty := 0
+	for _, item := range req.Items {
+		totalQty += item.Qty
+	}
+	return delivereeVehicleTypeID(totalQty, p.baseURL), nil, nil
+}
+
+// fittingVehicle mengembalikan kendaraan aktif idDeliveree jika ruang kargonya
+// memuat semua barang pengiriman; source hanya untuk log
+func (p *delivereeProvider) fittingVehicle(ctx context.Context, req *ShipmentRequest, idDeliveree int, source string) *models.DelivereeVehicleType {
+	v, err := p.vehicleType.FindActiveByIDDeliveree(ctx, idDeliveree, p.environment())
+	if err != nil || v == nil {
+		log.Printf("[deliveree] vehicle_type_id %s pesanan=%s id=%d tidak aktif/tidak ditemukan", source, req.Pesanan.Kode, idDeliveree)
+		return nil
+	}
+	if item := unfitItem(v, req.Items); item != nil {
+		log.Printf("[deliveree] vehicle_type_id %s pesanan=%s id=%d tidak memuat %s — pakai rencana muat", source, req.Pesanan.Kode, idDeliveree, item.NamaProduk)
+		return nil
+	}
+	log.Printf("[deliveree] pakai vehicle_type_id dari %s pesanan=%s id=%d", source, req.Pesanan.Kode, v.IDDeliveree)
+	return v
+}
+
+func (p *delivereeProvider) Quote(ctx context.Context, req *ShipmentRequest) (*ShippingQuote, error) {
+	if err := p.configured(); err != nil {
+		return nil, err
+	}
+	vehicleTypeID, _, err := p.selectVehicle(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(p.buildCreateRequest(req, vehicleTypeID))
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat request body: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/deliveries/get_quote", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", p.apiKey)
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghubungi Deliveree: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	log.Printf("[deliveree] <-- POST /deliveries/get_quote pesanan=%s status=%d body=%s", req.Pesanan.Kode, resp.StatusCode, string(respBody))
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Deliveree API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var result delivereeQuoteResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("gagal parse response Deliveree: %w", err)
+	}
+	for _, q := range result.Data {
+		if q.VehicleTypeID != vehicleTypeID {
+			continue
+		}
+		id := q.VehicleTypeID
+		currency := q.Currency
+		if currency == "" {
+			currency = "IDR"
+		}
+		return &ShippingQuote{
+			Provider:      p.Code(),
+			VehicleTypeID: &id,
+			VehicleName:   q.VehicleTypeName,
+			Amount:        decimal.NewFromFloat(q.TotalFees),
+			Currency:      currency,
+		}, nil
+	}
+	return nil, fmt.Errorf("Deliveree tidak mengembalikan tarif untuk vehicle_type_id %d", vehicleTypeID)
+}
+
+func (p *delivereeProvider) Book(ctx context.Context, req *ShipmentRequest) (string, error) {
+	if err := p.configured(); err != nil {
+		return "", err
+	}
+	if req.Destination == nil {
+		return "", fmt.Errorf("pesanan tidak memiliki alamat pengiriman")
+	}
+
+	// Vehicle type dipilih berdasarkan total kubikasi & berat barang (master data
+	// deliveree_vehicle_type), menggantikan logic lama yang berbasis jumlah qty/palet.
+	vehicleTypeID, vehicle, err := p.selectVehicle(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	bookingID, bookErr := p.bookWithVehicle(ctx, req, vehicleTypeID)
+	if bookErr != nil && vehicle != nil {
+		// Retry sekali dengan kendaraan satu tingkat lebih besar jika booking gagal
+		// (mis. tidak ada driver tersedia untuk kendaraan yang dipilih).
+		nextVehicle, nextErr := p.vehicleType.NextLargerVehicle(ctx, p.environment(), vehicle.IDDeliveree)
+		if nextErr == nil && nextVehicle != nil && unfitItem(nextVehicle, req.Items) == nil {
+			log.Printf("[deliveree] retry pesanan=%s vehicle_type_id=%d gagal (%v), coba kendaraan lebih besar id=%d", req.Pesanan.Kode, vehicleTypeID, bookErr, nextVehicle.IDDeliveree)
+			return p.bookWithVehicle(ctx, req, nextVehicle.IDDeliveree)
+		}
+	}
+	return bookingID, bookErr
+}
+
+func (p *delivereeProvider) buildCreateRequest(req *ShipmentRequest, vehicleTypeID int) delivereeCreateRequest {
+	warehouse := req.Origin
+	alamat := req.Destination
+
+	return delivereeCreateRequest{
+		VehicleTypeID:        vehicleTypeID,
+		BookingPaymentType:   "credit",
+		TimeType:             "now",
+		PickupTime:           "",
+		JobOrderNumber:       req.Reference,
+		AllowParkingFees:     true,
+		AllowTollsFees:       true,
+		AllowWaitingTimeFees: true,
+		SendFirstToDriver:    false,
+		MarkedAsFavorite:     true,
+		RequireSignatures:    true,
+		ExtraServices:        []interface{}{},
+		EstimateTransitTimes: []interface{}{},
+		Locations: []delivereeLocation{
+			{
+				Address:        derefString(warehouse.Alamat),
+				Latitude:       derefFloat(warehouse.Latitude),
+				Longitude:      derefFloat(warehouse.Longitude),
+				RecipientName:  "Bulky.id",
+				RecipientPhone: derefString(warehouse.Telepon),
+				Note:           "Pickup at warehouse",
+				IsPayer:        false,
+			},
+			{
+				Address:        buildFullAddress(alamat),
+				Latitude:       derefFloat(alamat.Latitude),
+				Longitude:      derefFloat(alamat.Longitude),
+				RecipientName:  alamat.NamaPenerima,
+				RecipientPhone: alamat.TeleponPenerima,
+				Note:           "Drop at buyer location",
+				IsPayer:        true,
+				NeedCOD:        false,
+				NeedPOD:        false,
+			},
+		},
+	}
+}
+
+func (p *delivereeProvider) bookWithVehicle(ctx context.Context, req *ShipmentRequest, vehicleTypeID int) (string, error) {
+	kode := req.Reference
+	createReq := p.buildCreateRequest(req, vehicleTypeID)
+
+	body, err := json.Marshal(createReq)
+	if err != nil {
+		return "", fmt.Errorf("gagal membuat request body: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/deliveries", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("gagal membuat HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", p.apiKey)
+
+	log.Printf("[deliveree] --> POST /deliveries pesanan=%s vehicle_type_id=%d body=%s", kode, createReq.VehicleTypeID, string(body))
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		log.Printf("[deliveree] <-- POST /deliveries pesanan=%s error=%v", kode, err)
+		return "", fmt.Errorf("connection timeout atau gagal menghubungi Deliveree: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	log.Printf("[deliveree] <-- POST /deliveries pesanan=%s status=%d body=%s", kode, resp.StatusCode, string(respBody))
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Deliveree API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var result delivereeCreateResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("gagal parse response Deliveree: %w", err)
+	}
+	if result.BookingID == 0 {
+		return "", fmt.Errorf("Deliveree tidak mengembalikan booking ID")
+	}
+
+	return strconv.Itoa(result.BookingID), nil
+}
+
+// getDelivery mengambil data booking mentah dari GET /deliveries/:id
+func (p *delivereeProvider) getDelivery(ctx context.Context, bookingRef string) ([]byte, error) {
+	if err := p.configured(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[deliveree] --> GET /deliveries/%s", bookingRef)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/deliveries/"+bookingRef, nil)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", p.apiKey)
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		log.Printf("[deliveree] <-- GET /deliveries/%s error=%v", bookingRef, err)
+		return nil, fmt.Errorf("gagal menghubungi Deliveree: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	log.Printf("[deliveree] <-- GET /deliveries/%s status=%d", bookingRef, resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Deliveree API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+	return respBody, nil
+}
+
+func (p *delivereeProvider) Track(ctx context.Context, bookingRef string) (*TrackingResult, error) {
+	respBody, err := p.getDelivery(ctx, bookingRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Status      string `json:"status"`
+		TrackingURL string `json:"tracking_url"`
+		Locations   []struct {
+			Name           string `json:"name"`
+			DeliveryStatus string `json:"delivery_status"`
+		} `json:"locations"`
+	}
+	if err := json.Unmarshal(respBody, &data); err != nil {
+		return nil, fmt.Errorf("gagal parse response Deliveree: %w", err)
+	}
+
+	history := make([]TrackingEvent, 0, len(data.Locations))
+	for _, loc := range data.Locations {
+		history = append(history, TrackingEvent{
+			Status: loc.DeliveryStatus + " - " + loc.Name,
+		})
+	}
+
+	return &TrackingResult{
+		Provider:    "DELIVEREE",
+		BookingRef:  bookingRef,
+		Status:      data.Status,
+		TrackingURL: data.TrackingURL,
+		History:     history,
+	}, nil
+}
+
+func (p *delivereeProvider) Detail(ctx context.Context, bookingRef string) (*DelivereeDeliveryDetail, error) {
+	respBody, err := p.getDelivery(ctx, bookingRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var detail DelivereeDeliveryDetail
+	if err := json.Unmarshal(respBody, &detail); err != nil {
+		return nil, fmt.Errorf("gagal parse response Deliveree: %w", err)
+	}
+	return &detail, nil
+}
+
+func (p *delivereeProvider) Cancel(ctx context.Context, bookingRef string) error {
+	if err := p.configured(); err != nil {
+		return err
+	}
+
+	log.Printf("[deliveree] --> POST /deliveries/%s/cancel", bookingRef)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/deliveries/"+bookingRef+"/cancel", nil)
+	if err != nil {
+		return fmt.Errorf("gagal membuat HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", p.apiKey)
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("gagal menghubungi Deliveree: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	log.Printf("[deliveree] <-- POST /deliveries/%s/cancel status=%d body=%s", bookingRef, resp.StatusCode, string(respBody))
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("Deliveree API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+func (p *delivereeProvider) ParseWebhook(body []byte) (*ShippingWebhookEvent, error) {
+	return parseOnDemandWebhook(body)
+}
+
+func delivereeVehicleTypeID(totalQty int, baseURL string) int {
+	isSandbox := strings.Contains(baseURL, "sandbox")
+	switch {
+	case totalQty <= 4:
+		if isSandbox {
+			return 14
+		}
+		return 2701
+	case totalQty <= 8:
+		if isSandbox {
+			return 24
+		}
+		return 2703
+	default:
+		if isSandbox {
+			return 36
+		}
+		return 2723
+	}
+}
@@ -0,0 +1,920 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// forwarderClient berisi credential dan helper bersama Forwarder (token,
+// mapping kecamatan, tracking, invoice). Dipakai provider LTL dan LCL.
+type forwarderClient struct {
+	apiURL     string
+	clientName string
+	username   string
+	password   string
+	db         *gorm.DB
+}
+
+func (c *forwarderClient) configured() error {
+	if c.apiURL == "" || c.clientName == "" || c.username == "" || c.password == "" {
+		return fmt.Errorf("konfigurasi Forwarder tidak lengkap")
+	}
+	return nil
+}
+
+// Quote belum didukung: integrasi Forwarder belum memakai API tarif, ongkir
+// dihitung storefront saat checkout
+func (c *forwarderClient) Quote(ctx context.Context, req *ShipmentRequest) (*ShippingQuote, error) {
+	return nil, ErrShippingQuoteUnsupported
+}
+
+// Cancel membatalkan booking lewat endpoint /cancelbooking (scope CANCELBOOKING)
+func (c *forwarderClient) Cancel(ctx context.Context, bookingRef string) error {
+	if err := c.configured(); err != nil {
+		return err
+	}
+	token, err := c.token(ctx, "CANCELBOOKING")
+	if err != nil {
+		return fmt.Errorf("gagal mendapatkan token Forwarder: %w", err)
+	}
+
+	reqBody, _ := json.Marshal(map[string]string{"booking_no": bookingRef})
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+"/cancelbooking", bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("gagal membuat HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("Client_name", c.clientName)
+
+	log.Printf("[forwarder] --> POST /cancelbooking booking_no=%s", bookingRef)
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("gagal menghubungi Forwarder: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	log.Printf("[forwarder] <-- POST /cancelbooking booking_no=%s status=%d body=%s", bookingRef, resp.StatusCode, string(respBody))
+
+	var result struct {
+		Msg       string `json:"msg"`
+		IsSuccess string `json:"isSuccess"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("gagal parse response Forwarder: %w", err)
+	}
+	if result.IsSuccess != "ok" {
+		return fmt.Errorf("Forwarder API error: %s", result.Msg)
+	}
+	return nil
+}
+
+func (c *forwarderClient) ParseWebhook(body []byte) (*ShippingWebhookEvent, error) {
+	return parseOnDemandWebhook(body)
+}
+
+// forwarderLTLProvider adalah ShippingProvider Forwarder jalur darat (LTL)
+type forwarderLTLProvider struct {
+	*forwarderClient
+}
+
+func (p *forwarderLTLProvider) Code() string {
+	return ShippingProviderForwarderLTL
+}
+
+// forwarderLCLProvider adalah ShippingProvider Forwarder jalur laut (LCL)
+// untuk provinsi di luar coverage LTL
+type forwarderLCLProvider struct {
+	*forwarderClient
+}
+
+func (p *forwarderLCLProvider) Code() string {
+	return ShippingProviderForwarderLCL
+}
+
+// ─── Forwarder LTL (Darat) ────────────────────────────────────────────────────
+
+type forwarderTokenRequest struct {
+	Scope string `json:"scope"`
+}
+
+type forwarderTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	Status      string `json:"status"`
+	Message     string `json:"message"`
+}
+
+type forwarderLocation struct {
+	Address   string `json:"address"`
+	Latitude  string `json:"latitude"`
+	Longitude string `json:"longitude"`
+	Type      string `json:"type"`
+	Order     int    `json:"order"`
+	PicName   string `json:"picname"`
+	PicPhone  string `json:"picphone"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+type forwarderDataDetail struct {
+	Packaging   string  `json:"packaging"`
+	Commodity   string  `json:"commodity"`
+	CargoDesc   string  `json:"cargodesc"`
+	Qty         int     `json:"qty"`
+	Length      float64 `json:"length"`
+	Width       float64 `json:"width"`
+	Height      float64 `json:"height"`
+	Volume      float64 `json:"volume"`
+	TotalVolume float64 `json:"totalvolume"`
+	Weight      float64 `json:"weight"`
+	TotalWeight float64 `json:"totalweight"`
+}
+
+type forwarderCreateBookingRequest struct {
+	TransportID              string                `json:"transportid"`
+	LoadID                   string                `json:"loadid"`
+	ServiceID                string                `json:"serviceid"`
+	OriginCityID             string                `json:"origincityid"`
+	DestinationCityID        string                `json:"destinationcityid"`
+	DestinationSubdistrictID string                `json:"destinationsubdistrictid"`
+	PriceID                  int                   `json:"priceid"`
+	PickupTimeType           string                `json:"pickuptimetype"`
+	PickupTimeOn             string                `json:"pickuptimeon"`
+	VehicleID                string                `json:"vehicleid"`
+	VehicleQty               int                   `json:"vehicleqty"`
+	ShipperName              string                `json:"shippername"`
+	ShipperPhone             string                `json:"shipperphone"`
+	ShipperAddress           string                `json:"shipperaddress"`
+	ConsigneeName            string                `json:"consigneename"`
+	ConsigneePhone           string                `json:"consigneephone"`
+	ConsigneeAddress         string                `json:"consigneeaddress"`
+	EstDistance              string                `json:"estdistance"`
+	EstPrice                 string                `json:"estprice"`
+	BasisPrice               string                `json:"basisprice"`
+	Remark                   string                `json:"remark,omitempty"`
+	WithInsurance            string                `json:"withinsurance"`
+	CommodityAmount          string                `json:"commodityamount,omitempty"`
+	InsuranceID              string                `json:"insuranceid,omitempty"`
+	PremiAmount              string                `json:"premiamount,omitempty"`
+	Locations                []forwarderLocation   `json:"locations"`
+	DataDetail               []forwarderDataDetail `json:"datadetail"`
+}
+
+type forwarderBookingResponse struct {
+	Msg  string `json:"msg"`
+	Data struct {
+		BookingNo string `json:"booking_no"`
+	} `json:"data"`
+	IsSuccess string `json:"isSuccess"`
+}
+
+func (p *forwarderLTLProvider) Book(ctx context.Context, req *ShipmentRequest) (string, error) {
+	if err := p.configured(); err != nil {
+		return "", err
+	}
+	pesanan := req.Pesanan
+
+	// Get access token
+	token, err := p.token(ctx, "CREATEBOOKINGLAND")
+	if err != nil {
+		return "", fmt.Errorf("gagal mendapatkan token Forwarder: %w", err)
+	}
+
+	warehouse := req.Origin
+
+	if req.Destination == nil {
+		return "", fmt.Errorf("pesanan tidak memiliki alamat pengiriman")
+	}
+	alamat := req.Destination
+
+	// Lookup origin city (warehouse)
+	if warehouse.Kota == nil || *warehouse.Kota == "" {
+		return "", fmt.Errorf("warehouse tidak memiliki data kota")
+	}
+	var originMapping models.ForwarderCityMapping
+	if err := p.db.Where("kota_pattern = ?", utils.NormalizeKota(*warehouse.Kota)).First(&originMapping).Error; err != nil {
+		return "", fmt.Errorf("kota asal warehouse (%s) tidak ditemukan di Forwarder mapping", *warehouse.Kota)
+	}
+
+	// Lookup destination city (buyer)
+	var destMapping models.ForwarderCityMapping
+	if err := p.db.Where("kota_pattern = ?", utils.NormalizeKota(alamat.Kota)).First(&destMapping).Error; err != nil {
+		return "", fmt.Errorf("kota tujuan tidak ditemukan di Forwarder mapping. Silakan tambahkan mapping untuk kota: %s", alamat.Kota)
+	}
+
+	// Lookup destination subdistrict (optional for LTL)
+	subdistrictID := "0"
+	if alamat.Kecamatan != nil && *alamat.Kecamatan != "" {
+		subdistrictID = p.resolveSubdistrictID(ctx, *alamat.Kecamatan, destMapping.ForwarderCityID)
+	}
+
+	// Build datadetail from items
+	dataDetail := make([]forwarderDataDetail, 0, len(req.Items))
+	for _, item := range req.Items {
+		p := item.Produk
+		vol := (p.Panjang * p.Lebar * p.Tinggi) / 1_000_000 // cm³ → m³
+		dataDetail = append(dataDetail, forwarderDataDetail{
+			Packaging:   "5",
+			Commodity:   "222",
+			CargoDesc:   item.NamaProduk,
+			Qty:         item.Qty,
+			Length:      p.Panjang,
+			Width:       p.Lebar,
+			Height:      p.Tinggi,
+			Volume:      vol,
+			TotalVolume: vol * float64(item.Qty),
+			Weight:      p.Berat,
+			TotalWeight: p.Berat * float64(item.Qty),
+		})
+	}
+
+	warehouseAlamat := ""
+	if warehouse.Alamat != nil {
+		warehouseAlamat = *warehouse.Alamat
+	}
+	warehouseTelepon := ""
+	if warehouse.Telepon != nil {
+		warehouseTelepon = *warehouse.Telepon
+	}
+	warehouseLat := "0"
+	if warehouse.Latitude != nil {
+		warehouseLat = strconv.FormatFloat(*warehouse.Latitude, 'f', 8, 64)
+	}
+	warehouseLng := "0"
+	if warehouse.Longitude != nil {
+		warehouseLng = strconv.FormatFloat(*warehouse.Longitude, 'f', 8, 64)
+	}
+	buyerLat := "0"
+	if alamat.Latitude != nil {
+		buyerLat = strconv.FormatFloat(*alamat.Latitude, 'f', 8, 64)
+	}
+	buyerLng := "0"
+	if alamat.Longitude != nil {
+		buyerLng = strconv.FormatFloat(*alamat.Longitude, 'f', 8, 64)
+	}
+
+	withInsurance := "0"
+	commodityAmount := ""
+	insuranceID := ""
+	premiAmount := ""
+	if pesanan.BiayaLainnya.IsPositive() {
+		withInsurance = "1"
+		commodityAmount = req.NilaiBarang().StringFixed(0)
+		insuranceID = "1"
+		premiAmount = req.PremiAsuransi().StringFixed(0)
+	}
+
+	bookingReq := forwarderCreateBookingRequest{
+		TransportID:              "3",
+		LoadID:                   "5",
+		ServiceID:                "1",
+		OriginCityID:             strconv.Itoa(originMapping.ForwarderCityID),
+		DestinationCityID:        strconv.Itoa(destMapping.ForwarderCityID),
+		DestinationSubdistrictID: subdistrictID,
+		PriceID:                  1,
+		PickupTimeType:           "SCHEDULE",
+		PickupTimeOn:             "",
+		VehicleID:                "0",
+		VehicleQty:               1,
+		ShipperName:              "Liquid8 | Bulky",
+		ShipperPhone:             warehouseTelepon,
+		ShipperAddress:           warehouseAlamat,
+		ConsigneeName:            alamat.NamaPenerima,
+		ConsigneePhone:           alamat.TeleponPenerima,
+		ConsigneeAddress:         buildFullAddress(alamat),
+		EstDistance:              "0",
+		EstPrice:                 "",
+		BasisPrice:               "ECONOMY",
+		Remark:                   req.Reference,
+		WithInsurance:            withInsurance,
+		CommodityAmount:          commodityAmount,
+		InsuranceID:              insuranceID,
+		PremiAmount:              premiAmount,
+		Locations: []forwarderLocation{
+			{
+				Address:   warehouseAlamat,
+				Latitude:  warehouseLat,
+				Longitude: warehouseLng,
+				Type:      "PICKUP",
+				Order:     1,
+				PicName:   "Liquid8 | Bulky",
+				PicPhone:  warehouseTelepon,
+				Detail:    warehouseAlamat,
+			},
+			{
+				Address:   buildFullAddress(alamat),
+				Latitude:  buyerLat,
+				Longitude: buyerLng,
+				Type:      "DELIVERY",
+				Order:     2,
+				PicName:   alamat.NamaPenerima,
+				PicPhone:  alamat.TeleponPenerima,
+				Detail:    buildFullAddress(alamat),
+			},
+		},
+		DataDetail: dataDetail,
+	}
+
+	body, err := json.Marshal(bookingReq)
+	if err != nil {
+		return "", fmt.Errorf("gagal membuat request body: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL+"/createbookingland", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("gagal membuat HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("Client_name", p.clientName)
+
+	log.Printf("[forwarder] --> POST /createbookingland pesanan=%s transport=%s load=%s origin_city=%s dest_city=%s consignee=%s body=%s",
+		pesanan.Kode, bookingReq.TransportID, bookingReq.LoadID,
+		bookingReq.OriginCityID, bookingReq.DestinationCityID, bookingReq.ConsigneeName, string(body))
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		log.Printf("[forwarder] <-- POST /createbookingland pesanan=%s error=%v", pesanan.Kode, err)
+		return "", fmt.Errorf("connection timeout atau gagal menghubungi Forwarder: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	log.Printf("[forwarder] <-- POST /createbookingland pesanan=%s status=%d body=%s", pesanan.Kode, resp.StatusCode, string(respBody))
+
+	var result forwarderBookingResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("gagal parse response Forwarder: %w", err)
+	}
+
+	if result.IsSuccess != "ok" {
+		return "", fmt.Errorf("Forwarder API error: %s", result.Msg)
+	}
+	if result.Data.BookingNo == "" {
+		return "", fmt.Errorf("Forwarder tidak mengembalikan booking number")
+	}
+
+	return result.Data.BookingNo, nil
+}
+
+// ─── Forwarder LCL (Sea Freight / Luar Jawa) ──────────────────────────────────
+
+type forwarderBookingDetail struct {
+	Qty             string  `json:"qty"`
+	ContainerTypeID string  `json:"containertypeid"`
+	PackageID       string  `json:"packageid"`
+	Length          float64 `json:"length"`
+	Width           float64 `json:"width"`
+	Height          float64 `json:"height"`
+	Volume          float64 `json:"volume"`
+	Weight          string  `json:"weight"`
+	CargoID         string  `json:"cargoid"`
+	CargoDesc       string  `json:"cargodesc"`
+}
+
+type forwarderCreateBookingLCLRequest struct {
+	TransportID              string                   `json:"transportid"`
+	MoveTypeID               string                   `json:"movetypeid"`
+	LoadTypeID               string                   `json:"loadtypeid"`
+	ServiceTypeID            string                   `json:"servicetypeid"`
+	OriginCityID             string                   `json:"origincityid"`
+	DestinationCityID        string                   `json:"destinationcityid"`
+	DestinationSubdistrictID string                   `json:"destinationsubdistrictid"`
+	LCLBasisID               string                   `json:"lclbasisid"`
+	CargoReadyDate           string                   `json:"cargoreadydate"`
+	Shipper                  string                   `json:"shipper"`
+	ShipperAddress           string                   `json:"shipperaddress"`
+	ShipperLat               string                   `json:"shipperlat"`
+	ShipperLng               string                   `json:"shipperlng"`
+	ShipperCountry           string                   `json:"shippercountry"`
+	ShipperProvince          string                   `json:"shipperprovince"`
+	ShipperCity              string                   `json:"shippercity"`
+	ShipperPostalCode        string                   `json:"shipperpostalcode"`
+	ShipperRemark            string                   `json:"shipperremark"`
+	Consignee                string                   `json:"consignee"`
+	ConsigneeAddress         string                   `json:"consigneeaddress"`
+	ConsigneeLat             string                   `json:"consigneelat"`
+	ConsigneeLng             string                   `json:"consigneelng"`
+	ConsigneeCountry         string                   `json:"consigneecountry"`
+	ConsigneeProvince        string                   `json:"consigneeprovince"`
+	ConsigneeCity            string                   `json:"consigneecity"`
+	ConsigneePostalCode      string                   `json:"consigneepostalcode"`
+	ConsigneeRemark          string                   `json:"consigneeremark"`
+	Pickup                   string                   `json:"pickup"`
+	PickupAddress            string                   `json:"pickupaddress"`
+	PickupLat                string                   `json:"pickuplat"`
+	PickupLng                string                   `json:"pickuplng"`
+	PickupCountry            string                   `json:"pickupcountry"`
+	PickupProvince           string                   `json:"pickupprovince"`
+	PickupCity               string                   `json:"pickupcity"`
+	PickupPostalCode         string                   `json:"pickuppostalcode"`
+	PickupPhone              string                   `json:"pickupphone"`
+	PickupRemark             string                   `json:"pickupremark"`
+	Delivery                 string                   `json:"delivery"`
+	DeliveryAddress          string                   `json:"deliveryaddress"`
+	DeliveryLat              string                   `json:"deliverylat"`
+	DeliveryLng              string                   `json:"deliverylng"`
+	DeliveryCountry          string                   `json:"deliverycountry"`
+	DeliveryProvince         string                   `json:"deliveryprovince"`
+	DeliveryCity             string                   `json:"deliverycity"`
+	DeliveryPostalCode       string                   `json:"deliverypostalcode"`
+	DeliveryPhone            string                   `json:"deliveryphone"`
+	DeliveryRemark           string                   `json:"deliveryremark"`
+	VoucherCode              string                   `json:"vouchercode"`
+	CurrencyID               string                   `json:"currencyid"`
+	Incoterm                 string                   `json:"incoterm"`
+	WithInsurance            string                   `json:"withinsurance"`
+	CommodityAmount          string                   `json:"commodityamount,omitempty"`
+	InsuranceID              string                   `json:"insuranceid,omitempty"`
+	PremiAmount              string                   `json:"premiamount,omitempty"`
+	BookingDetail            []forwarderBookingDetail `json:"bookingdetail"`
+}
+
+func (p *forwarderLCLProvider) Book(ctx context.Context, req *ShipmentRequest) (string, error) {
+	if err := p.configured(); err != nil {
+		return "", err
+	}
+	pesanan := req.Pesanan
+
+	token, err := p.token(ctx, "CREATEBOOKING")
+	if err != nil {
+		return "", fmt.Errorf("gagal mendapatkan token Forwarder: %w", err)
+	}
+
+	warehouse := req.Origin
+
+	if req.Destination == nil {
+		return "", fmt.Errorf("pesanan tidak memiliki alamat pengiriman")
+	}
+	alamat := req.Destination
+
+	if warehouse.Kota == nil || *warehouse.Kota == "" {
+		return "", fmt.Errorf("warehouse tidak memiliki data kota")
+	}
+	var originMapping models.ForwarderCityMapping
+	if err := p.db.Where("kota_pattern = ?", utils.NormalizeKota(*warehouse.Kota)).First(&originMapping).Error; err != nil {
+		return "", fmt.Errorf("kota asal warehouse (%s) tidak ditemukan di Forwarder mapping", *warehouse.Kota)
+	}
+
+	var destMapping models.ForwarderCityMapping
+	if err := p.db.Where("kota_pattern = ?", utils.NormalizeKota(alamat.Kota)).First(&destMapping).Error; err != nil {
+		return "", fmt.Errorf("kota tujuan tidak ditemukan di Forwarder mapping. Silakan tambahkan mapping untuk kota: %s", alamat.Kota)
+	}
+
+	subdistrictID := "0"
+	if alamat.Kecamatan != nil && *alamat.Kecamatan != "" {
+		subdistrictID = p.resolveSubdistrictID(ctx, *alamat.Kecamatan, destMapping.ForwarderCityID)
+	}
+
+	bookingDetail := make([]forwarderBookingDetail, 0, len(req.Items))
+	for _, item := range req.Items {
+		p := item.Produk
+		vol := (p.Panjang * p.Lebar * p.Tinggi) / 1_000_000
+		bookingDetail = append(bookingDetail, forwarderBookingDetail{
+			Qty:             strconv.Itoa(item.Qty),
+			ContainerTypeID: "63", // LCL tidak pakai container type spesifik (Less than Container Load)
+			PackageID:       "7",
+			Length:          p.Panjang,
+			Width:           p.Lebar,
+			Height:          p.Tinggi,
+			Volume:          vol * float64(item.Qty),
+			Weight:          strconv.FormatFloat(p.Berat*float64(item.Qty), 'f', 0, 64),
+			CargoID:         "78",
+			CargoDesc:       item.NamaProduk,
+		})
+	}
+
+	warehouseAlamat := ""
+	if warehouse.Alamat != nil {
+		warehouseAlamat = *warehouse.Alamat
+	}
+	warehouseTelepon := ""
+	if warehouse.Telepon != nil {
+		warehouseTelepon = *warehouse.Telepon
+	}
+	warehouseKodePos := ""
+	if warehouse.KodePos != nil {
+		warehouseKodePos = *warehouse.KodePos
+	}
+	warehouseLat := "0"
+	if warehouse.Latitude != nil {
+		warehouseLat = strconv.FormatFloat(*warehouse.Latitude, 'f', 8, 64)
+	}
+	warehouseLng := "0"
+	if warehouse.Longitude != nil {
+		warehouseLng = strconv.FormatFloat(*warehouse.Longitude, 'f', 8, 64)
+	}
+	buyerLat := "0"
+	if alamat.Latitude != nil {
+		buyerLat = strconv.FormatFloat(*alamat.Latitude, 'f', 8, 64)
+	}
+	buyerLng := "0"
+	if alamat.Longitude != nil {
+		buyerLng = strconv.FormatFloat(*alamat.Longitude, 'f', 8, 64)
+	}
+	buyerKodePos := ""
+	if alamat.KodePos != nil {
+		buyerKodePos = *alamat.KodePos
+	}
+
+	withInsurance := "0"
+	commodityAmount := ""
+	insuranceID := ""
+	premiAmount := ""
+	if pesanan.BiayaLainnya.IsPositive() {
+		withInsurance = "1"
+		commodityAmount = req.NilaiBarang().StringFixed(0)
+		insuranceID = "1"
+		premiAmount = req.PremiAsuransi().StringFixed(0)
+	}
+
+	catatanAlamat := "-"
+	if alamat.Catatan != nil && *alamat.Catatan != "" {
+		catatanAlamat = *alamat.Catatan
+	}
+
+	bookingReq := forwarderCreateBookingLCLRequest{
+		TransportID:              "1",
+		MoveTypeID:               "1",
+		LoadTypeID:               "2",
+		ServiceTypeID:            "1",
+		OriginCityID:             "13", // Jakarta — pelabuhan terdekat dari gudang Bogor untuk LCL
+		DestinationCityID:        strconv.Itoa(destMapping.ForwarderCityID),
+		DestinationSubdistrictID: subdistrictID,
+		LCLBasisID:               "1",
+		CargoReadyDate:           "",
+		Shipper:                  "Liquid8 | Bulky.id",
+		ShipperAddress:           warehouseAlamat,
+		ShipperLat:               warehouseLat,
+		ShipperLng:               warehouseLng,
+		ShipperCountry:           "Indonesia",
+		ShipperProvince:          "Jawa Barat",
+		ShipperCity:              *warehouse.Kota,
+		ShipperPostalCode:        warehouseKodePos,
+		ShipperRemark:            req.Reference,
+		Consignee:                alamat.NamaPenerima,
+		ConsigneeAddress:         buildFullAddress(alamat),
+		ConsigneeLat:             buyerLat,
+		ConsigneeLng:             buyerLng,
+		ConsigneeCountry:         "Indonesia",
+		ConsigneeProvince:        alamat.Provinsi,
+		ConsigneeCity:            alamat.Kota,
+		ConsigneePostalCode:      buyerKodePos,
+		ConsigneeRemark:          catatanAlamat,
+		Pickup:                   "Liquid8 | Bulky.id",
+		PickupAddress:            warehouseAlamat,
+		PickupLat:                warehouseLat,
+		PickupLng:                warehouseLng,
+		PickupCountry:            "Indonesia",
+		PickupProvince:           "Jawa Barat",
+		PickupCity:               *warehouse.Kota,
+		PickupPostalCode:         warehouseKodePos,
+		PickupPhone:              warehouseTelepon,
+		PickupRemark:             req.Reference,
+		Delivery:                 alamat.NamaPenerima,
+		DeliveryAddress:          buildFullAddress(alamat),
+		DeliveryLat:              buyerLat,
+		DeliveryLng:              buyerLng,
+		DeliveryCountry:          "Indonesia",
+		DeliveryProvince:         alamat.Provinsi,
+		DeliveryCity:             alamat.Kota,
+		DeliveryPostalCode:       buyerKodePos,
+		DeliveryPhone:            alamat.TeleponPenerima,
+		DeliveryRemark:           catatanAlamat,
+		VoucherCode:              "",
+		CurrencyID:               "1",
+		Incoterm:                 "",
+		WithInsurance:            withInsurance,
+		CommodityAmount:          commodityAmount,
+		InsuranceID:              insuranceID,
+		PremiAmount:              premiAmount,
+		BookingDetail:            bookingDetail,
+	}
+
+	body, err := json.Marshal(bookingReq)
+	if err != nil {
+		return "", fmt.Errorf("gagal membuat request body: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL+"/createbooking", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("gagal membuat HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("Client_name", p.clientName)
+
+	log.Printf("[forwarder] --> POST /createbooking pesanan=%s transport=%s load=%s origin_city=%s dest_city=%s consignee=%s body=%s",
+		pesanan.Kode, bookingReq.TransportID, bookingReq.LoadTypeID,
+		bookingReq.OriginCityID, bookingReq.DestinationCityID, bookingReq.Consignee, string(body))
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		log.Printf("[forwarder] <-- POST /createbooking pesanan=%s error=%v", pesanan.Kode, err)
+		return "", fmt.Errorf("connection timeout atau gagal menghubungi Forwarder: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	log.Printf("[forwarder] <-- POST /createbooking pesanan=%s status=%d body=%s", pesanan.Kode, resp.StatusCode, string(respBody))
+
+	var result forwarderBookingResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("gagal parse response Forwarder: %w", err)
+	}
+
+	if result.IsSuccess != "ok" {
+		return "", fmt.Errorf("Forwarder API error: %s", result.Msg)
+	}
+	if result.Data.BookingNo == "" {
+		return "", fmt.Errorf("Forwarder tidak mengembalikan booking number")
+	}
+
+	return result.Data.BookingNo, nil
+}
+
+// resolveSubdistrictID mencari subdistrict ID dengan 3 langkah fallback:
+// 1. DB lookup by kecamatan_pattern + forwarder_city_id
+// 2. DB lookup by kecamatan_pattern saja (any city)
+// 3. API call ke /subdistrictlist
+func (c *forwarderClient) resolveSubdistrictID(ctx context.Context, kecamatan string, forwarderCityID int) string {
+	normalized := utils.NormalizeKecamatan(kecamatan)
+	if normalized == "" {
+		return "0"
+	}
+
+	// Step 1: match by kecamatan + city_id
+	var m models.ForwarderSubdistrictMapping
+	if err := c.db.Where("kecamatan_pattern = ? AND forwarder_city_id = ?", normalized, forwarderCityID).First(&m).Error; err == nil {
+		return strconv.Itoa(m.ForwarderSubdistrictID)
+	}
+
+	// Step 2: match by kecamatan saja — hanya pakai kalau hasilnya tunggal
+	var all []models.ForwarderSubdistrictMapping
+	if err := c.db.Where("kecamatan_pattern = ?", normalized).Find(&all).Error; err == nil && len(all) == 1 {
+		return strconv.Itoa(all[0].ForwarderSubdistrictID)
+	}
+
+	// Step 3: API call ke /subdistrictlist
+	token, err := c.token(ctx, "SUBDISTRICTLIST")
+	if err != nil {
+		log.Printf("[forwarder] resolveSubdistrict: gagal get token: %v", err)
+		return "0"
+	}
+
+	reqBody, _ := json.Marshal(map[string]string{
+		"subdistrict_name": strings.ToUpper(normalized),
+		"city_id":          strconv.Itoa(forwarderCityID),
+	})
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+"/subdistrictlist", bytes.NewReader(reqBody))
+	if err != nil {
+		return "0"
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("Client_name", c.clientName)
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		log.Printf("[forwarder] resolveSubdistrict: API error: %v", err)
+		return "0"
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+
+	var result struct {
+		IsSuccess string `json:"isSuccess"`
+		Data      []struct {
+			ItemID   int    `json:"item_id"`
+			ItemName string `json:"item_name"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil || result.IsSuccess != "ok" || len(result.Data) == 0 {
+		log.Printf("[forwarder] resolveSubdistrict: tidak ditemukan via API untuk kecamatan=%s city_id=%d body=%s", normalized, forwarderCityID, string(respBody))
+		return "0"
+	}
+
+	// Simpan ke DB agar lookup berikutnya tidak perlu API call
+	c.db.Create(&models.ForwarderSubdistrictMapping{
+		KecamatanPattern:         normalized,
+		ForwarderCityID:          forwarderCityID,
+		ForwarderSubdistrictID:   result.Data[0].ItemID,
+		ForwarderSubdistrictName: result.Data[0].ItemName,
+	})
+
+	return strconv.Itoa(result.Data[0].ItemID)
+}
+
+func (c *forwarderClient) token(ctx context.Context, scope string) (string, error) {
+	tokenReq := forwarderTokenRequest{Scope: scope}
+	body, _ := json.Marshal(tokenReq)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+"/accesstoken", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("client_name", c.clientName)
+	httpReq.Header.Set("username", c.username)
+	httpReq.Header.Set("password", c.password)
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("connection timeout: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	log.Printf("[forwarder] <-- POST /accesstoken scope=%s status=%d body=%s", scope, resp.StatusCode, string(respBody))
+	var result forwarderTokenResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("gagal parse token response: %w", err)
+	}
+	if result.Status != "ok" || result.AccessToken == "" {
+		return "", fmt.Errorf("gagal mendapatkan token: %s", result.Message)
+	}
+	return result.AccessToken, nil
+}
+
+// ─── Forwarder Invoice ────────────────────────────────────────────────────────
+
+type ForwarderInvoiceDetail struct {
+	FreightElementName string `json:"freight_element_name"`
+	BasisName          string `json:"basis_name"`
+	TotalIDR           string `json:"total_idr"`
+	Amount             string `json:"amount"`
+	Total              string `json:"total"`
+	Subtotal           string `json:"subtotal"`
+	InvoiceNo          string `json:"invoice_no"`
+	Qty                string `json:"qty"`
+	ContainerType      string `json:"container_type"`
+	Currency           string `json:"currency"`
+	Tax                string `json:"tax"`
+	Remark             string `json:"remark"`
+}
+
+type ForwarderInvoice struct {
+	BookingNo          string                   `json:"booking_no"`
+	InvoiceNo          string                   `json:"invoice_no"`
+	DueDate            string                   `json:"due_date"`
+	InvoiceID          string                   `json:"invoice_id"`
+	Currency           string                   `json:"currency"`
+	Remark             string                   `json:"remark"`
+	CreateDate         string                   `json:"create_date"`
+	DownloadInvoiceURL string                   `json:"download_invoice_url"`
+	DataDetail         []ForwarderInvoiceDetail `json:"data_detail"`
+	InvoiceDate        string                   `json:"invoice_date"`
+	QuotationNo        string                   `json:"quotation_no"`
+	Status             string                   `json:"status"`
+}
+
+type forwarderInvoiceListRequest struct {
+	UserName  string `json:"user_name"`
+	BookingNo string `json:"booking_no"`
+	InvoiceNo string `json:"invoice_no"`
+}
+
+type forwarderInvoiceListResponse struct {
+	Msg       string             `json:"msg"`
+	Data      []ForwarderInvoice `json:"data"`
+	IsSuccess string             `json:"isSuccess"`
+}
+
+func (c *forwarderClient) Invoices(ctx context.Context, bookingNo string) ([]ForwarderInvoice, error) {
+	if err := c.configured(); err != nil {
+		return nil, err
+	}
+
+	token, err := c.token(ctx, "INVOICELIST")
+	if err != nil {
+		return nil, fmt.Errorf("gagal mendapatkan token Forwarder: %w", err)
+	}
+
+	reqBody := forwarderInvoiceListRequest{
+		// UserName:  clientName,
+		BookingNo: bookingNo,
+		InvoiceNo: "",
+	}
+	body, _ := json.Marshal(reqBody)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+"/invoicelist", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("Client_name", c.clientName)
+
+	log.Printf("[forwarder] --> POST /invoicelist booking_no=%s", bookingNo)
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		log.Printf("[forwarder] <-- POST /invoicelist booking_no=%s error=%v", bookingNo, err)
+		return nil, fmt.Errorf("connection timeout atau gagal menghubungi Forwarder: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	log.Printf("[forwarder] <-- POST /invoicelist booking_no=%s status=%d body=%s", bookingNo, resp.StatusCode, string(respBody))
+
+	var result forwarderInvoiceListResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("gagal parse response Forwarder: %w", err)
+	}
+	if result.IsSuccess != "ok" {
+		return nil, fmt.Errorf("Forwarder API error: %s", result.Msg)
+	}
+
+	return result.Data, nil
+}
+
+// ─── Tracking ─────────────────────────────────────────────────────────────────
+
+func (c *forwarderClient) Track(ctx context.Context, bookingRef string) (*TrackingResult, error) {
+	if err := c.configured(); err != nil {
+		return nil, err
+	}
+
+	token, err := c.token(ctx, "TRACKANDTRACE")
+	if err != nil {
+		return nil, fmt.Errorf("gagal mendapatkan token Forwarder: %w", err)
+	}
+
+	reqBody, _ := json.Marshal(map[string]string{
+		"ref_cust_id": "",
+		"booking_no":  bookingRef,
+	})
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+"/trackandtrace", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("Client_name", c.clientName)
+
+	log.Printf("[forwarder] --> POST /trackandtrace booking_no=%s", bookingRef)
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		log.Printf("[forwarder] <-- POST /trackandtrace booking_no=%s error=%v", bookingRef, err)
+		return nil, fmt.Errorf("gagal menghubungi Forwarder: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	log.Printf("[forwarder] <-- POST /trackandtrace booking_no=%s status=%d body=%s", bookingRef, resp.StatusCode, string(respBody))
+
+	var result struct {
+		Msg       string `json:"msg"`
+		IsSuccess string `json:"isSuccess"`
+		Data      []struct {
+			BookingNumber string `json:"booking_number"`
+			Status        []struct {
+				StatusDate string `json:"status_date"`
+				StatusName string `json:"status_name"`
+				StatusTime string `json:"status_time"`
+			} `json:"status"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("gagal parse response Forwarder: %w", err)
+	}
+	if result.IsSuccess != "ok" {
+		return nil, fmt.Errorf("Forwarder tracking error: %s", result.Msg)
+	}
+
+	history := []TrackingEvent{}
+	currentStatus := ""
+	if len(result.Data) > 0 {
+		for _, s := range result.Data[0].Status {
+			history = append(history, TrackingEvent{
+				Date:   s.StatusDate,
+				Time:   s.StatusTime,
+				Status: s.StatusName,
+			})
+		}
+		if len(history) > 0 {
+			currentStatus = history[len(history)-1].Status
+		}
+	}
+
+	return &TrackingResult{
+		Provider:   "FORWARDER",
+		BookingRef: bookingRef,
+		Status:     currentStatus,
+		History:    history,
+	}, nil
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/dto"
+	"project-bulky-be/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Target webhook simulasi sandbox: event dikirim ke handler webhook provider
+// yang sama dengan provider aslinya
+const (
+	sandboxWebhookDeliveree = "deliveree"
+	sandboxWebhookForwarder = "forwarder"
+)
+
+// sandboxProgression adalah urutan status yang disimulasikan setelah booking,
+// satu status per langkah (SHIPPING_SANDBOX_STEP)
+var sandboxProgression = []ProviderWebhookStatus{
+	ProviderWebhookStatusLocatingDriver,
+	ProviderWebhookStatusDriverAcceptBooking,
+	ProviderWebhookStatusDeliveryInProgress,
+	ProviderWebhookStatusDeliveryCompleted,
+}
+
+// sandboxShippingProvider adalah provider tiruan untuk local/staging. Booking
+// langsung berhasil, status maju satu langkah setiap step, dan webhook
+// dikirim lewat job queue ke handler webhook provider yang asli sehingga
+// seluruh alur (status pesanan, outbox, riwayat) teruji tanpa sandbox provider.
+type sandboxShippingProvider struct {
+	webhookTarget string
+	jobQueue      JobQueueService
+	step          time.Duration
+}
+
+func newSandboxShippingProvider(webhookTarget string, jobQueue JobQueueService, step time.Duration) *sandboxShippingProvider {
+	return &sandboxShippingProvider{webhookTarget: webhookTarget, jobQueue: jobQueue, step: step}
+}
+
+func (p *sandboxShippingProvider) Code() string {
+	return ShippingProviderSandbox
+}
+
+// Quote menghitung tarif tiruan yang deterministik dari volume & berat
+func (p *sandboxShippingProvider) Quote(ctx context.Context, req *ShipmentRequest) (*ShippingQuote, error) {
+	amount := decimal.NewFromInt(150000).
+		Add(decimal.NewFromFloat(req.TotalKubikasi() * 250000)).
+		Add(decimal.NewFromFloat(req.TotalBerat() * 2000)).
+		Round(-3)
+	return &ShippingQuote{
+		Provider:    p.Code(),
+		VehicleName: "Sandbox Truck",
+		Amount:      amount,
+		Currency:    "IDR",
+	}, nil
+}
+
+// Book membuat booking ref SBX-<unix>-<acak> lalu menjadwalkan webhook
+// progres status. Waktu booking disimpan di ref agar Track tidak butuh state.
+func (p *sandboxShippingProvider) Book(ctx context.Context, req *ShipmentRequest) (string, error) {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	bookedAt := time.Now()
+	ref := fmt.Sprintf("SBX-%d-%s", bookedAt.Unix(), hex.EncodeToString(suffix))
+
+	for i, status := range sandboxProgression {
+		body, _ := json.Marshal(dto.DelivereeWebhookRequest{
+			Status:      string(status),
+			ID:          dto.FlexString(ref),
+			TrackingURL: "https://sandbox.bulky.local/tracking/" + ref,
+		})
+		payload := sandboxWebhookPayload{Target: p.webhookTarget, Body: body}
+		err := p.jobQueue.Enqueue(ctx, nil, models.JobTypeShippingSandboxWebhook, payload, EnqueueOptions{
+			RunAt: bookedAt.Add(time.Duration(i+1) * p.step),
+		})
+		if err != nil {
+			return "", fmt.Errorf("gagal menjadwalkan webhook sandbox: %w", err)
+		}
+	}
+
+	log.Printf("[shipping-sandbox] booking pesanan=%s ref=%s target=%s", req.Reference, ref, p.webhookTarget)
+	return ref, nil
+}
+
+func (p *sandboxShippingProvider) Track(ctx context.Context, bookingRef string) (*TrackingResult, error) {
+	bookedAt, err := sandboxBookedAt(bookingRef)
+	if err != nil {
+		return nil, err
+	}
+
+	history := []TrackingEvent{}
+	for i, status := range sandboxProgression {
+		at := bookedAt.Add(time.Duration(i+1) * p.step)
+		if at.After(time.Now()) {
+			break
+		}
+		history = append(history, TrackingEvent{
+			Date:   at.Format("2006-01-02"),
+			Time:   at.Format("15:04:05"),
+			Status: string(status),
+		})
+	}
+	status := "booked"
+	if len(history) > 0 {
+		status = history[len(history)-1].Status
+	}
+
+	return &TrackingResult{
+		Provider:    p.Code(),
+		BookingRef:  bookingRef,
+		Status:      status,
+		TrackingURL: "https://sandbox.bulky.local/tracking/" + bookingRef,
+		History:     history,
+	}, nil
+}
+
+// Detail mengembalikan detail format Deliveree agar halaman detail di panel
+// bisa diuji di mode sandbox
+func (p *sandboxShippingProvider) Detail(ctx context.Context, bookingRef string) (*DelivereeDeliveryDetail, error) {
+	tracking, err := p.Track(ctx, bookingRef)
+	if err != nil {
+		return nil, err
+	}
+	bookedAt, _ := sandboxBookedAt(bookingRef)
+	detail := &DelivereeDeliveryDetail{
+		Status:      tracking.Status,
+		Currency:    "IDR",
+		TrackingURL: tracking.TrackingURL,
+		CreatedAt:   bookedAt.Format(time.RFC3339),
+		VehicleTypeInfo: DelivereeVehicleTypeInfo{
+			Name: "Sandbox Truck",
+		},
+		Driver: &DelivereeDriver{
+			Name:  "Driver Sandbox",
+			Phone: "080000000000",
+		},
+		Locations: []DelivereeDeliveryLocation{
+			{Name: "Gudang Sandbox"},
+			{Name: "Tujuan Sandbox"},
+		},
+	}
+	// Bukti pengiriman tiruan (tanpa tanda tangan) setelah status selesai
+	if tracking.Status == string(ProviderWebhookStatusDeliveryCompleted) {
+		completedAt := bookedAt.Add(time.Duration(len(sandboxProgression)) * p.step).Format(time.RFC3339)
+		detail.CompletedAt = completedAt
+		detail.Locations[1].RecipientName = "Penerima Sandbox"
+		detail.Locations[1].DeliveryStatus = "delivered"
+		detail.Locations[1].ArrivedAt = completedAt
+	}
+	return detail, nil
+}
+
+func (p *sandboxShippingProvider) Invoices(ctx context.Context, bookingNo string) ([]ForwarderInvoice, error) {
+	return []ForwarderInvoice{}, nil
+}
+
+// Cancel selalu berhasil. Webhook progres yang sudah dijadwalkan tetap
+// terkirim dan diabaikan handler webhook jika booking ref sudah dilepas.
+func (p *sandboxShippingProvider) Cancel(ctx context.Context, bookingRef string) error {
+	log.Printf("[shipping-sandbox] cancel ref=%s", bookingRef)
+	return nil
+}
+
+func (p *sandboxShippingProvider) ParseWebhook(body []byte) (*ShippingWebhookEvent, error) {
+	return parseOnDemandWebhook(body)
+}
+
+func sandboxBookedAt(bookingRef string) (time.Time, error) {
+	parts := strings.Split(bookingRef, "-")
+	if len(parts) != 3 || parts[0] != "SBX" {
+		return time.Time{}, fmt.Errorf("booking ref sandbox tidak valid: %s", bookingRef)
+	}
+	unix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("booking ref sandbox tidak valid: %s", bookingRef)
+	}
+	return time.Unix(unix, 0), nil
+}
+
+// sandboxWebhookPayload adalah payload job models.JobTypeShippingSandboxWebhook
+type sandboxWebhookPayload struct {
+	Target string          `json:"target"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// SandboxWebhookJobHandler mengirim webhook simulasi sandbox ke handler
+// webhook Deliveree/Forwarder. Body dibaca lewat ParseWebhook provider seperti
+// webhook asli. Didaftarkan di main saat mode sandbox aktif.
+func SandboxWebhookJobHandler(shipping ShippingService, deliveree DelivereeWebhookService, forwarder ForwarderWebhookService) JobHandler {
+	return func(ctx context.Context, job *models.BackgroundJob) error {
+		var payload sandboxWebhookPayload
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return err
+		}
+
+		deliveryType := models.DeliveryTypeDeliveree
+		handle := deliveree.Handle
+		switch payload.Target {
+		case sandboxWebhookDeliveree:
+		case sandboxWebhookForwarder:
+			deliveryType = models.DeliveryTypeForwarder
+			handle = forwarder.Handle
+		default:
+			return fmt.Errorf("target webhook sandbox tidak dikenal: %s", payload.Target)
+		}
+		provider, err := shipping.Provider(deliveryType)
+		if err != nil {
+			return err
+		}
+		event, err := provider.ParseWebhook(payload.Body)
+		if err != nil {
+			return err
+		}
+		_, err = handle(ctx, event)
+		return err
+	}
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSandboxProviderTrackProgressesByStep(t *testing.T) {
+	p := newSandboxShippingProvider(sandboxWebhookDeliveree, nil, time.Minute)
+
+	// Booking 2,5 langkah yang lalu → dua status sudah terlewati
+	ref := fmt.Sprintf("SBX-%d-abc123", time.Now().Add(-150*time.Second).Unix())
+	result, err := p.Track(context.Background(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.History) != 2 || result.Status != string(ProviderWebhookStatusDriverAcceptBooking) {
+		t.Fatalf("harus di status ke-2, got %q dengan %d riwayat", result.Status, len(result.History))
+	}
+
+	if _, err := p.Track(context.Background(), "12345"); err == nil {
+		t.Fatal("booking ref non-sandbox harus ditolak")
+	}
+}
+
+func TestParseOnDemandWebhook(t *testing.T) {
+	// Deliveree mengirim booking ID sebagai number
+	event, err := parseOnDemandWebhook([]byte(`{"id": 123456, "status": "delivery_completed"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.BookingRef != "123456" || event.Status != "delivery_completed" {
+		t.Fatalf("event tidak sesuai: %+v", event)
+	}
+
+	if _, err := parseOnDemandWebhook([]byte(`{"status": "canceled"}`)); err == nil {
+		t.Fatal("webhook tanpa id/no_booking harus ditolak")
+	}
+}
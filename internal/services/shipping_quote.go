@@ -0,0 +1,446 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ErrShippingQuoteInvalid dikembalikan jika quote ongkir pesanan tidak ada,
+// kedaluwarsa saat pesanan dibuat, atau dihitung untuk item/alamat lain
+var ErrShippingQuoteInvalid = errors.New("quote ongkir tidak valid untuk pesanan")
+
+// shippingQuoteRetention adalah lama quote kedaluwarsa disimpan sebelum
+// dihapus job cron. Quote yang dipakai pesanan tidak pernah dihapus.
+const shippingQuoteRetention = 7 * 24 * time.Hour
+
+// shippingEstimasiHari adalah estimasi lama kirim (hari) per delivery type.
+// API tarif provider belum mengembalikan ETA, jadi dipakai angka operasional.
+var shippingEstimasiHari = map[models.DeliveryType][2]int{
+	models.DeliveryTypeDeliveree:    {0, 1},
+	models.DeliveryTypeForwarder:    {3, 7},
+	models.DeliveryTypeForwarderLCL: {7, 21},
+}
+
+// quoteGroup adalah item quote dari satu gudang asal
+type quoteGroup struct {
+	warehouse models.Warehouse
+	items     []models.PesananItem
+}
+
+// Quote menghitung opsi ongkir per delivery type untuk item ke satu alamat.
+// Request yang sama (item & alamat) dalam masa TTL dijawab dari cache.
+func (s *shippingService) Quote(ctx context.Context, req *models.ShippingQuoteRequest, source string) (*models.ShippingQuoteResponse, error) {
+	alamat, err := s.quoteDestination(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	hash := shippingQuoteHash(alamat, req.Items)
+
+	now := time.Now()
+	cached, err := s.quoteRepo.FindValidByHash(ctx, hash, now)
+	if err == nil {
+		return toShippingQuoteResponse(cached, true)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	items, err := s.quoteItems(ctx, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	coverage, err := s.coverage.Resolve(ctx, alamat)
+	if err != nil {
+		return nil, fmt.Errorf("gagal cek coverage pengiriman: %w", err)
+	}
+
+	pesanan := &models.Pesanan{Kode: "QUOTE", Items: items}
+	for _, item := range items {
+		pesanan.BiayaProduk = pesanan.BiayaProduk.Add(item.Subtotal)
+	}
+	groups := groupQuoteItems(items)
+
+	delivereeReason := ""
+	if !coverage.Deliveree.Covered {
+		delivereeReason = fmt.Sprintf("Alamat di luar zona coverage Deliveree (provinsi %q)", alamat.Provinsi)
+	}
+	options := []models.ShippingQuoteOption{
+		s.quoteOption(ctx, models.DeliveryTypeDeliveree, delivereeReason, pesanan, alamat, groups),
+		s.quoteOption(ctx, models.DeliveryType(coverage.ForwarderDeliveryType), "", pesanan, alamat, groups),
+	}
+
+	itemsJSON, err := json.Marshal(req.Items)
+	if err != nil {
+		return nil, err
+	}
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	quote := &models.ShippingQuote{
+		RequestHash: hash,
+		Source:      source,
+		Provinsi:    alamat.Provinsi,
+		Kota:        alamat.Kota,
+		Kecamatan:   alamat.Kecamatan,
+		Items:       itemsJSON,
+		Options:     optionsJSON,
+		ExpiresAt:   now.Add(s.quoteTTL),
+	}
+	if alamat.ID != uuid.Nil {
+		quote.AlamatBuyerID = &alamat.ID
+	}
+	if err := s.quoteRepo.Create(ctx, quote); err != nil {
+		return nil, err
+	}
+
+	return toShippingQuoteResponse(quote, false)
+}
+
+func (s *shippingService) PurgeExpiredQuotes(ctx context.Context) (int64, error) {
+	deleted, err := s.quoteRepo.DeleteExpired(ctx, time.Now().Add(-shippingQuoteRetention))
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		log.Printf("[shipping] %d quote ongkir kedaluwarsa dihapus", deleted)
+	}
+	return deleted, nil
+}
+
+// ShippingQuotePurgeJobHandler menghapus quote kedaluwarsa (job cron)
+func ShippingQuotePurgeJobHandler(shipping ShippingService) JobHandler {
+	return func(ctx context.Context, job *models.BackgroundJob) error {
+		_, err := shipping.PurgeExpiredQuotes(ctx)
+		return err
+	}
+}
+
+// quoteOption menghitung satu opsi pengiriman: satu quote provider per gudang
+// asal, dijumlahkan. unavailableReason terisi berarti opsi tidak tersedia.
+func (s *shippingService) quoteOption(ctx context.Context, deliveryType models.DeliveryType, unavailableReason string, pesanan *models.Pesanan, alamat *models.AlamatBuyer, groups []quoteGroup) models.ShippingQuoteOption {
+	estimasi := shippingEstimasiHari[deliveryType]
+	option := models.ShippingQuoteOption{
+		DeliveryType:    string(deliveryType),
+		Currency:        "IDR",
+		EstimasiHariMin: estimasi[0],
+		EstimasiHariMax: estimasi[1],
+		Shipments:       []models.ShippingQuoteShipment{},
+	}
+	switch deliveryType {
+	case models.DeliveryTypeForwarder:
+		route := forwarderRouteLTL
+		option.Route = &route
+	case models.DeliveryTypeForwarderLCL:
+		route := forwarderRouteLCL
+		option.Route = &route
+	}
+	if unavailableReason != "" {
+		option.Reason = &unavailableReason
+		return option
+	}
+
+	provider, err := s.Provider(deliveryType)
+	if err != nil {
+		reason := err.Error()
+		option.Reason = &reason
+		return option
+	}
+	option.Provider = provider.Code()
+	option.Available = true
+
+	total := decimal.Zero
+	priced := true
+	for _, group := range groups {
+		req := &ShipmentRequest{
+			Pesanan:     pesanan,
+			Reference:   pesanan.Kode,
+			Origin:      &group.warehouse,
+			Destination: alamat,
+			Items:       group.items,
+		}
+		shipment := models.ShippingQuoteShipment{
+			WarehouseID:     group.warehouse.ID,
+			WarehouseNama:   group.warehouse.Nama,
+			TotalKubikasi:   math.Round(req.TotalKubikasi()*1000) / 1000,
+			TotalBerat:      math.Round(req.TotalBerat()*100) / 100,
+			BeratVolumetrik: math.Round(req.TotalBeratVolumetrik()*100) / 100,
+			BeratTertagih:   math.Round(req.BeratTertagih()*100) / 100,
+		}
+
+		quote, err := provider.Quote(ctx, req)
+		switch {
+		case errors.Is(err, ErrShippingQuoteUnsupported):
+			priced = false
+		case err != nil:
+			log.Printf("[shipping] quote gagal delivery_type=%s gudang=%s: %v", deliveryType, group.warehouse.Nama, err)
+			msg := err.Error()
+			shipment.Error = &msg
+			priced = false
+			option.Available = false
+			reason := fmt.Sprintf("Gagal mendapatkan tarif dari gudang %s", group.warehouse.Nama)
+			option.Reason = &reason
+		default:
+			amount := quote.Amount
+			shipment.VehicleTypeID = quote.VehicleTypeID
+			shipment.VehicleName = quote.VehicleName
+			shipment.Harga = &amount
+			total = total.Add(amount)
+			if quote.Currency != "" {
+				option.Currency = quote.Currency
+			}
+		}
+		option.Shipments = append(option.Shipments, shipment)
+	}
+
+	if priced {
+		option.Harga = &total
+	} else if option.Available {
+		reason := "Tarif provider belum tersedia via API, ongkir dihitung manual"
+		option.Reason = &reason
+	}
+	return option
+}
+
+// quoteDestination mengambil alamat buyer tersimpan atau membangun alamat dari
+// wilayah yang diisi manual
+func (s *shippingService) quoteDestination(ctx context.Context, req *models.ShippingQuoteRequest) (*models.AlamatBuyer, error) {
+	if req.AlamatBuyerID != nil && *req.AlamatBuyerID != "" {
+		var alamat models.AlamatBuyer
+		if err := s.db.WithContext(ctx).Where("id = ?", *req.AlamatBuyerID).First(&alamat).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("Alamat buyer tidak ditemukan")
+			}
+			return nil, err
+		}
+		return &alamat, nil
+	}
+
+	if strings.TrimSpace(req.Provinsi) == "" || strings.TrimSpace(req.Kota) == "" {
+		return nil, errors.New("Isi alamat_buyer_id atau provinsi & kota tujuan")
+	}
+	return &models.AlamatBuyer{
+		Provinsi:      req.Provinsi,
+		Kota:          req.Kota,
+		Kecamatan:     req.Kecamatan,
+		AlamatLengkap: req.AlamatLengkap,
+		Latitude:      req.Latitude,
+		Longitude:     req.Longitude,
+	}, nil
+}
+
+// quoteItems memuat produk (beserta gudangnya) untuk item quote
+func (s *shippingService) quoteItems(ctx context.Context, reqItems []models.ShippingQuoteItemRequest) ([]models.PesananItem, error) {
+	ids := make([]string, 0, len(reqItems))
+	for _, item := range reqItems {
+		ids = append(ids, item.ProdukID)
+	}
+
+	var produks []models.Produk
+	if err := s.db.WithContext(ctx).Preload("Warehouse").Where("id IN ?", ids).Find(&produks).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]models.Produk, len(produks))
+	for _, p := range produks {
+		byID[p.ID.String()] = p
+	}
+
+	items := make([]models.PesananItem, 0, len(reqItems))
+	for _, reqItem := range reqItems {
+		produk, ok := byID[strings.ToLower(reqItem.ProdukID)]
+		if !ok {
+			return nil, fmt.Errorf("Produk %s tidak ditemukan", reqItem.ProdukID)
+		}
+		harga := decimal.NewFromFloat(produk.HargaSesudahDiskon)
+		items = append(items, models.PesananItem{
+			ProdukID:    produk.ID,
+			NamaProduk:  produk.NamaID,
+			Qty:         reqItem.Qty,
+			HargaSatuan: harga,
+			Subtotal:    harga.Mul(decimal.NewFromInt(int64(reqItem.Qty))),
+			Produk:      produk,
+		})
+	}
+	return items, nil
+}
+
+// groupQuoteItems mengelompokkan item per gudang produk, urut sesuai item
+// (sama seperti pembagian pengiriman saat booking)
+func groupQuoteItems(items []models.PesananItem) []quoteGroup {
+	var groups []quoteGroup
+	index := map[uuid.UUID]int{}
+	for _, item := range items {
+		i, ok := index[item.Produk.WarehouseID]
+		if !ok {
+			i = len(groups)
+			index[item.Produk.WarehouseID] = i
+			groups = append(groups, quoteGroup{warehouse: item.Produk.Warehouse})
+		}
+		groups[i].items = append(groups[i].items, item)
+	}
+	return groups
+}
+
+// shippingQuoteHash adalah kunci cache quote: alamat tujuan + item (urutan
+// item tidak berpengaruh)
+func shippingQuoteHash(alamat *models.AlamatBuyer, items []models.ShippingQuoteItemRequest) string {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		parts = append(parts, fmt.Sprintf("%s:%d", strings.ToLower(item.ProdukID), item.Qty))
+	}
+	sort.Strings(parts)
+
+	destination := alamat.ID.String()
+	if alamat.ID == uuid.Nil {
+		kecamatan := ""
+		if alamat.Kecamatan != nil {
+			kecamatan = *alamat.Kecamatan
+		}
+		destination = strings.ToLower(strings.Join([]string{
+			strings.TrimSpace(alamat.Provinsi),
+			strings.TrimSpace(alamat.Kota),
+			strings.TrimSpace(kecamatan),
+			strings.TrimSpace(alamat.AlamatLengkap),
+			fmt.Sprintf("%.6f,%.6f", derefFloat(alamat.Latitude), derefFloat(alamat.Longitude)),
+		}, "|"))
+	}
+
+	sum := sha256.Sum256([]byte(destination + "#" + strings.Join(parts, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+func toShippingQuoteResponse(quote *models.ShippingQuote, cached bool) (*models.ShippingQuoteResponse, error) {
+	var options []models.ShippingQuoteOption
+	if err := json.Unmarshal(quote.Options, &options); err != nil {
+		return nil, fmt.Errorf("gagal membaca opsi quote: %w", err)
+	}
+	return &models.ShippingQuoteResponse{
+		ID:        quote.ID.String(),
+		ExpiresAt: quote.ExpiresAt,
+		Cached:    cached,
+		Options:   options,
+	}, nil
+}
+
+// ─── Verifikasi saat booking ─────────────────────────────────────────────────
+
+// quotedShipping membaca quote pesanan untuk delivery type hasil routing dan
+// memastikan quote memang untuk pesanan ini serta ongkir yang ditagih tidak
+// lebih kecil dari harga quote. Hasilnya adalah kendaraan quote per gudang
+// asal. Pesanan tanpa shipping_quote_id (pesanan lama atau tarif manual dari
+// panel) tidak punya quote untuk diverifikasi.
+func (s *shippingService) quotedShipping(ctx context.Context, pesanan *models.Pesanan, deliveryType models.DeliveryType) (map[uuid.UUID]int, error) {
+	if pesanan.ShippingQuoteID == nil {
+		return nil, nil
+	}
+	quote, err := s.quoteRepo.FindByID(ctx, *pesanan.ShippingQuoteID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: quote %s tidak ditemukan", ErrShippingQuoteInvalid, *pesanan.ShippingQuoteID)
+		}
+		return nil, err
+	}
+	// Quote harus berlaku saat checkout, bukan saat booking (setelah dibayar)
+	if err := validateShippingQuote(quote, pesanan, pesanan.CreatedAt); err != nil {
+		return nil, err
+	}
+	var options []models.ShippingQuoteOption
+	if err := json.Unmarshal(quote.Options, &options); err != nil {
+		return nil, fmt.Errorf("gagal membaca opsi quote: %w", err)
+	}
+
+	option := findQuoteOption(options, deliveryType)
+	if err := verifyQuotedShipping(option, pesanan.BiayaPengiriman); err != nil {
+		return nil, err
+	}
+	if option == nil {
+		return nil, nil
+	}
+
+	vehicles := map[uuid.UUID]int{}
+	for _, shipment := range option.Shipments {
+		if shipment.VehicleTypeID != nil {
+			vehicles[shipment.WarehouseID] = *shipment.VehicleTypeID
+		}
+	}
+	return vehicles, nil
+}
+
+// validateShippingQuote memastikan quote belum kedaluwarsa pada waktu at dan
+// dihitung untuk item (produk & qty) serta alamat tujuan pesanan.
+// pesanan.Items dan pesanan.AlamatBuyer harus sudah dimuat.
+func validateShippingQuote(quote *models.ShippingQuote, pesanan *models.Pesanan, at time.Time) error {
+	if at.After(quote.ExpiresAt) {
+		return fmt.Errorf("%w: quote %s kedaluwarsa %s", ErrShippingQuoteInvalid, quote.ID, quote.ExpiresAt.Format(time.RFC3339))
+	}
+
+	var quoted []models.ShippingQuoteItemRequest
+	if err := json.Unmarshal(quote.Items, &quoted); err != nil {
+		return fmt.Errorf("gagal membaca item quote: %w", err)
+	}
+	qty := map[string]int{}
+	for _, item := range quoted {
+		qty[strings.ToLower(item.ProdukID)] += item.Qty
+	}
+	for _, item := range pesanan.Items {
+		qty[item.ProdukID.String()] -= item.Qty
+	}
+	for produkID, selisih := range qty {
+		if selisih != 0 {
+			return fmt.Errorf("%w: item pesanan berbeda dengan item quote (produk %s)", ErrShippingQuoteInvalid, produkID)
+		}
+	}
+
+	alamat := pesanan.AlamatBuyer
+	if alamat == nil {
+		return fmt.Errorf("%w: pesanan tidak memiliki alamat pengiriman", ErrShippingQuoteInvalid)
+	}
+	if quote.AlamatBuyerID != nil {
+		if *quote.AlamatBuyerID != alamat.ID {
+			return fmt.Errorf("%w: quote dihitung untuk alamat buyer lain", ErrShippingQuoteInvalid)
+		}
+		return nil
+	}
+	// Quote dari wilayah yang diisi manual: provinsi & kota harus sama
+	if !strings.EqualFold(strings.TrimSpace(quote.Provinsi), strings.TrimSpace(alamat.Provinsi)) ||
+		!strings.EqualFold(strings.TrimSpace(quote.Kota), strings.TrimSpace(alamat.Kota)) {
+		return fmt.Errorf("%w: quote dihitung untuk %s, %s", ErrShippingQuoteInvalid, quote.Kota, quote.Provinsi)
+	}
+	return nil
+}
+
+func findQuoteOption(options []models.ShippingQuoteOption, deliveryType models.DeliveryType) *models.ShippingQuoteOption {
+	for i := range options {
+		if options[i].DeliveryType == string(deliveryType) {
+			return &options[i]
+		}
+	}
+	return nil
+}
+
+// verifyQuotedShipping menolak booking jika ongkir yang ditagih ke buyer lebih
+// kecil dari harga quote. Opsi tanpa harga (tarif manual) tidak diverifikasi.
+func verifyQuotedShipping(option *models.ShippingQuoteOption, billed decimal.Decimal) error {
+	if option == nil || option.Harga == nil {
+		return nil
+	}
+	if billed.LessThan(*option.Harga) {
+		return fmt.Errorf("ongkir ditagih Rp %s lebih kecil dari quote %s Rp %s", billed.StringFixed(0), option.DeliveryType, option.Harga.StringFixed(0))
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func TestShippingQuoteHash(t *testing.T) {
+	alamat := &models.AlamatBuyer{Provinsi: "Jawa Barat", Kota: "Bandung"}
+	a := []models.ShippingQuoteItemRequest{{ProdukID: "A", Qty: 1}, {ProdukID: "b", Qty: 2}}
+	b := []models.ShippingQuoteItemRequest{{ProdukID: "b", Qty: 2}, {ProdukID: "a", Qty: 1}}
+
+	// Urutan item tidak mengubah kunci cache
+	if shippingQuoteHash(alamat, a) != shippingQuoteHash(alamat, b) {
+		t.Fatalf("hash harus sama untuk item yang sama dengan urutan berbeda")
+	}
+
+	// Qty atau alamat berbeda menghasilkan kunci berbeda
+	c := []models.ShippingQuoteItemRequest{{ProdukID: "a", Qty: 3}, {ProdukID: "b", Qty: 2}}
+	if shippingQuoteHash(alamat, a) == shippingQuoteHash(alamat, c) {
+		t.Fatalf("hash harus berbeda untuk qty berbeda")
+	}
+	other := &models.AlamatBuyer{Provinsi: "Jawa Barat", Kota: "Bekasi"}
+	if shippingQuoteHash(alamat, a) == shippingQuoteHash(other, a) {
+		t.Fatalf("hash harus berbeda untuk alamat berbeda")
+	}
+}
+
+func TestGroupQuoteItems(t *testing.T) {
+	w1, w2 := uuid.New(), uuid.New()
+	items := []models.PesananItem{
+		{Produk: models.Produk{WarehouseID: w1}},
+		{Produk: models.Produk{WarehouseID: w2}},
+		{Produk: models.Produk{WarehouseID: w1}},
+	}
+
+	groups := groupQuoteItems(items)
+	if len(groups) != 2 || len(groups[0].items) != 2 || len(groups[1].items) != 1 {
+		t.Fatalf("item harus dikelompokkan per gudang asal, got %d grup", len(groups))
+	}
+}
+
+func TestVerifyQuotedShipping(t *testing.T) {
+	harga := decimal.NewFromInt(250000)
+	option := &models.ShippingQuoteOption{DeliveryType: string(models.DeliveryTypeDeliveree), Harga: &harga}
+
+	if err := verifyQuotedShipping(option, decimal.NewFromInt(250000)); err != nil {
+		t.Fatalf("ongkir sama dengan quote harus lolos: %v", err)
+	}
+	if err := verifyQuotedShipping(option, decimal.NewFromInt(200000)); err == nil {
+		t.Fatalf("ongkir di bawah quote harus ditolak")
+	}
+
+	// Opsi tanpa harga (tarif manual) & pesanan tanpa opsi quote tidak diverifikasi
+	if err := verifyQuotedShipping(&models.ShippingQuoteOption{}, decimal.Zero); err != nil {
+		t.Fatalf("opsi tanpa harga tidak boleh diverifikasi: %v", err)
+	}
+	if err := verifyQuotedShipping(nil, decimal.Zero); err != nil {
+		t.Fatalf("tanpa opsi tidak boleh diverifikasi: %v", err)
+	}
+}
+
+func TestValidateShippingQuote(t *testing.T) {
+	produkID, alamatID := uuid.New(), uuid.New()
+	checkout := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	quote := &models.ShippingQuote{
+		ID:            uuid.New(),
+		AlamatBuyerID: &alamatID,
+		Items:         json.RawMessage(`[{"produk_id":"` + strings.ToUpper(produkID.String()) + `","qty":2}]`),
+		ExpiresAt:     checkout.Add(10 * time.Minute),
+	}
+	pesanan := &models.Pesanan{
+		Items:       []models.PesananItem{{ProdukID: produkID, Qty: 2}},
+		AlamatBuyer: &models.AlamatBuyer{ID: alamatID, Provinsi: "Jawa Barat", Kota: "Bandung"},
+	}
+	if err := validateShippingQuote(quote, pesanan, checkout); err != nil {
+		t.Fatalf("quote untuk item & alamat yang sama harus valid: %v", err)
+	}
+
+	// Quote kedaluwarsa saat pesanan dibuat
+	if err := validateShippingQuote(quote, pesanan, checkout.Add(time.Hour)); !errors.Is(err, ErrShippingQuoteInvalid) {
+		t.Fatalf("quote kedaluwarsa harus ditolak, got %v", err)
+	}
+
+	// Qty berbeda
+	pesanan.Items[0].Qty = 1
+	if err := validateShippingQuote(quote, pesanan, checkout); !errors.Is(err, ErrShippingQuoteInvalid) {
+		t.Fatalf("item berbeda harus ditolak, got %v", err)
+	}
+	pesanan.Items[0].Qty = 2
+
+	// Alamat buyer lain
+	pesanan.AlamatBuyer = &models.AlamatBuyer{ID: uuid.New(), Provinsi: "Jawa Barat", Kota: "Bandung"}
+	if err := validateShippingQuote(quote, pesanan, checkout); !errors.Is(err, ErrShippingQuoteInvalid) {
+		t.Fatalf("alamat berbeda harus ditolak, got %v", err)
+	}
+
+	// Quote wilayah manual dicocokkan lewat provinsi & kota
+	quote.AlamatBuyerID, quote.Provinsi, quote.Kota = nil, "JAWA BARAT", "bandung"
+	if err := validateShippingQuote(quote, pesanan, checkout); err != nil {
+		t.Fatalf("quote wilayah manual dengan kota sama harus valid: %v", err)
+	}
+}
+
+// fakeShippingQuoteRepository tidak menyimpan quote apa pun
+type fakeShippingQuoteRepository struct {
+	repositories.ShippingQuoteRepository
+}
+
+func (fakeShippingQuoteRepository) FindByID(context.Context, uuid.UUID) (*models.ShippingQuote, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func TestQuotedShippingMissingQuote(t *testing.T) {
+	quoteID := uuid.New()
+	s := &shippingService{quoteRepo: &fakeShippingQuoteRepository{}}
+	pesanan := &models.Pesanan{Kode: "ORD-20261017-0009", ShippingQuoteID: &quoteID}
+
+	// Quote yang dicatat pesanan tidak ada lagi: booking tidak boleh lolos tanpa verifikasi
+	if _, err := s.quotedShipping(context.Background(), pesanan, models.DeliveryTypeDeliveree); !errors.Is(err, ErrShippingQuoteInvalid) {
+		t.Fatalf("quote yang hilang harus menggagalkan verifikasi, got %v", err)
+	}
+}
@@ -1,21 +1,16 @@
 package services
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
-	"os"
-	"strconv"
-	"strings"
 	"time"
 
-	"log"
-
+	"project-bulky-be/internal/config"
 	"project-bulky-be/internal/models"
-	"project-bulky-be/pkg/utils"
+	"project-bulky-be/internal/repositories"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -23,6 +18,10 @@ import (
 
 // TrackingEvent satu entri riwayat status pengiriman
 type TrackingEvent struct {
+	// EventID kunci dedupe event (lihat trackingEventID)
+	EventID string `json:"event_id,omitempty"`
+	// Source WEBHOOK atau POLLING untuk event tersimpan
+	Source string `json:"source,omitempty"`
 	Date   string `json:"date"`
 	Time   string `json:"time"`
 	Status string `json:"status"`
@@ -37,6 +36,30 @@ type TrackingResult struct {
 	History     []TrackingEvent `json:"history"`
 }
 
+// ShipmentTracking adalah tracking satu pengiriman (satu gudang asal)
+type ShipmentTracking struct {
+	PengirimanID  uuid.UUID       `json:"pengiriman_id"`
+	Urutan        int             `json:"urutan"`
+	WarehouseID   uuid.UUID       `json:"warehouse_id"`
+	WarehouseNama string          `json:"warehouse_nama"`
+	DeliveryType  string          `json:"delivery_type"`
+	BookingRef    string          `json:"booking_ref"`
+	BookingStatus *string         `json:"booking_status"`
+	Tracking      *TrackingResult `json:"tracking"`
+	// Live bernilai true jika tracking berhasil diambil langsung dari provider;
+	// false berarti riwayat hanya dari event tersimpan
+	Live bool `json:"live"`
+	// Error terisi jika tracking pengiriman ini gagal diambil dari provider
+	Error string `json:"error,omitempty"`
+}
+
+// PesananTracking adalah tracking semua pengiriman satu pesanan
+type PesananTracking struct {
+	PesananID uuid.UUID          `json:"pesanan_id"`
+	Kode      string             `json:"kode"`
+	Shipments []ShipmentTracking `json:"shipments"`
+}
+
 // DelivereeVehicleTypeInfo is the vehicle info returned by Deliveree.
 type DelivereeVehicleTypeInfo struct {
 	ID              int     `json:"id"`
@@ -106,203 +129,140 @@ type DelivereeDeliveryDetail struct {
 }
 
 type ShippingService interface {
-	// TriggerBookingAsync launches booking in a goroutine (non-blocking).
-	TriggerBookingAsync(pesanan *models.Pesanan)
-	// BookDelivery runs the booking synchronously and returns the result.
-	BookDelivery(ctx context.Context, pesanan *models.Pesanan) (delivereeBookingID *string, forwarderTrackingNo *string, err error)
+	// BookAndRecord claims the pesanan, runs the booking and stores the result
+	// (booking id / tracking no / booking_error). Returns nil when another
+	// process already holds the claim. Dipanggil worker job queue.
+	BookAndRecord(ctx context.Context, pesanan *models.Pesanan) error
+	// BookShipments membooking setiap pengiriman (satu per gudang asal) yang
+	// belum ter-booking lalu menyimpan hasilnya dan melepas claim. Pesanan harus
+	// sudah di-claim lewat ClaimBooking.
+	BookShipments(ctx context.Context, pesanan *models.Pesanan) ([]models.PesananPengiriman, error)
 	// ClaimBooking atomically reserves the pesanan for booking.
 	// Returns true only if the caller won the right to book (no existing booking
 	// and no other process currently claiming). Prevents double booking when
-	// TriggerBookingAsync and RetryBooking run concurrently.
+	// BookAndRecord and RetryBooking run concurrently.
 	ClaimBooking(pesananID uuid.UUID) (bool, error)
-	// TrackDelivery retrieves live tracking info from the shipping provider.
-	TrackDelivery(ctx context.Context, pesanan *models.Pesanan) (*TrackingResult, error)
+	// ResetBookings mengosongkan booking pengiriman yang belum berjalan (mis.
+	// dibatalkan provider) agar bisa di-booking ulang.
+	ResetBookings(ctx context.Context, pesananID uuid.UUID) (int64, error)
+	// CancelShipments membatalkan booking pengiriman di provider (satu atau
+	// semua yang belum berjalan) dan mencatat riwayat SHIPMENT.
+	CancelShipments(ctx context.Context, pesanan *models.Pesanan, opts CancelShipmentOptions) ([]models.PesananPengiriman, error)
+	// RebookShipment membooking ulang satu pengiriman dengan kendaraan/jalur pengganti.
+	RebookShipment(ctx context.Context, pesanan *models.Pesanan, opts RebookShipmentOptions) (*models.PesananPengiriman, error)
+	// Shipments mengembalikan pengiriman pesanan per gudang asal.
+	Shipments(ctx context.Context, pesananID uuid.UUID) ([]models.PesananPengiriman, error)
+	// TrackDelivery membangun tracking setiap pengiriman dari event tersimpan
+	// digabung dengan fetch live ke provider.
+	TrackDelivery(ctx context.Context, pesanan *models.Pesanan) (*PesananTracking, error)
 	// GetDelivereeDetail retrieves full delivery detail from Deliveree API.
-	GetDelivereeDetail(ctx context.Context, pesanan *models.Pesanan) (*DelivereeDeliveryDetail, error)
+	GetDelivereeDetail(ctx context.Context, pesanan *models.Pesanan, pengirimanID *uuid.UUID) (*DelivereeDeliveryDetail, error)
 	// GetForwarderInvoice retrieves invoice list from Forwarder by booking number.
 	GetForwarderInvoice(ctx context.Context, bookingNo string) ([]ForwarderInvoice, error)
+	// Provider mengembalikan ShippingProvider terdaftar untuk delivery type.
+	Provider(deliveryType models.DeliveryType) (ShippingProvider, error)
+	// Quote menghitung opsi ongkir per delivery type (kendaraan, jalur LTL/LCL,
+	// harga, estimasi hari) dan menyimpannya sebagai quote ber-TTL.
+	Quote(ctx context.Context, req *models.ShippingQuoteRequest, source string) (*models.ShippingQuoteResponse, error)
+	// PurgeExpiredQuotes menghapus quote kedaluwarsa yang tidak dipakai pesanan.
+	PurgeExpiredQuotes(ctx context.Context) (int64, error)
+	// PollTracking menyimpan event tracking terbaru pengiriman Forwarder yang
+	// masih berjalan. Mengembalikan jumlah event baru.
+	PollTracking(ctx context.Context) (int64, error)
+	// CaptureProofOfDelivery mengambil detail Deliveree setiap pengiriman yang
+	// sudah ter-booking lalu menyimpan bukti pengiriman beserta salinan tanda tangan.
+	CaptureProofOfDelivery(ctx context.Context, pesanan *models.Pesanan) ([]models.PesananBuktiPengiriman, error)
+	// ProofOfDelivery mengembalikan bukti pengiriman tersimpan satu pesanan.
+	ProofOfDelivery(ctx context.Context, pesananID uuid.UUID) ([]models.PesananBuktiPengiriman, error)
 }
 
 type shippingService struct {
-	db                   *gorm.DB
-	delivereeVehicleType DelivereeVehicleTypeService
-}
-
-func NewShippingService(db *gorm.DB, delivereeVehicleType DelivereeVehicleTypeService) ShippingService {
-	return &shippingService{db: db, delivereeVehicleType: delivereeVehicleType}
-}
-
-// forwarderLTLProvinsi adalah daftar provinsi (lowercase) yang dilayani forwarder
-// via jalur darat (LTL). Provinsi di luar list ini dikirim via laut (LCL).
-// Nama provinsi bisa dalam bahasa Indonesia maupun Inggris (Google Maps).
-var forwarderLTLProvinsi = map[string]bool{
-	// Bali
-	"bali": true,
-
-	// Bangka Belitung
-	"kepulauan bangka belitung": true,
-	"bangkabelitung islands":    true,
-
-	// Bengkulu
-	"bengkulu": true,
-
-	// DI Yogyakarta
-	"daerah istimewa yogyakarta":   true,
-	"special region of yogyakarta": true,
-	"di yogyakarta":                true,
-	"yogyakarta":                   true,
-
-	// Gorontalo
-	"gorontalo": true,
-
-	// Jambi
-	"jambi": true,
-
-	// DKI Jakarta
-	"dki jakarta": true,
-	"jakarta":     true,
-
-	// Jawa Barat
-	"jawa barat": true,
-	"west java":  true,
-
-	// Jawa Tengah
-	"jawa tengah":  true,
-	"central java": true,
-
-	// Jawa Timur
-	"jawa timur": true,
-	"east java":  true,
-
-	// Banten
-	"banten": true,
-
-	// Kepulauan Riau
-	"kepulauan riau": true,
-	"riau islands":   true,
-
-	// Lampung
-	"lampung": true,
-
-	// Nanggroe Aceh Darussalam
-	"aceh": true,
-
-	// Nusa Tenggara Barat
-	"nusa tenggara barat": true,
-	"west nusa tenggara":  true,
-
-	// Riau
-	"riau": true,
-
-	// Sumatera Barat
-	"sumatera barat": true,
-	"west sumatra":   true,
-
-	// Sumatera Selatan
-	"sumatera selatan": true,
-	"south sumatra":    true,
-
-	// Sumatera Utara
-	"sumatera utara": true,
-	"north sumatra":  true,
-
-	// Sulawesi Utara
-	"sulawesi utara": true,
-	"north sulawesi": true,
-
-	// Sulawesi Tengah
-	"sulawesi tengah":  true,
-	"central sulawesi": true,
-
-	// Sulawesi Selatan
-	"sulawesi selatan": true,
-	"south sulawesi":   true,
-
-	// Sulawesi Tenggara
-	"sulawesi tenggara":  true,
-	"southeast sulawesi": true,
-
-	// Sulawesi Barat
-	"sulawesi barat": true,
-	"west sulawesi":  true,
-
-	// Gorontalo sudah ada di atas
-}
-
-// isInLTLProvince mengembalikan true jika provinsi dilayani forwarder via darat (LTL).
-// Provinsi yang tidak ada di list dikirim via laut (LCL).
-func isInLTLProvince(provinsi string) bool {
-	return forwarderLTLProvinsi[strings.ToLower(strings.TrimSpace(provinsi))]
-}
-
-// delivereeProvinsi adalah daftar provinsi (lowercase) yang dilayani Deliveree.
-// Deliveree hanya beroperasi di Jawa, Sumatra, dan Bali.
-var delivereeProvinsi = map[string]bool{
-	// Bali
-	"bali": true,
-	// Banten
-	"banten": true,
-	// Bengkulu
-	"bengkulu": true,
-	// DI Yogyakarta
-	"daerah istimewa yogyakarta":   true,
-	"special region of yogyakarta": true,
-	"di yogyakarta":                true,
-	"yogyakarta":                   true,
-	// DKI Jakarta
-	"dki jakarta": true,
-	"jakarta":     true,
-	// Jambi
-	"jambi": true,
-	// Jawa Barat
-	"jawa barat": true,
-	"west java":  true,
-	// Jawa Tengah
-	"jawa tengah":  true,
-	"central java": true,
-	// Jawa Timur
-	"jawa timur": true,
-	"east java":  true,
-	// Lampung
-	"lampung": true,
-	// Nanggroe Aceh Darussalam
-	"aceh": true,
-	// Riau
-	"riau": true,
-	// Kepulauan Riau
-	"kepulauan riau": true,
-	"riau islands":   true,
-	// Bangka Belitung
-	"kepulauan bangka belitung": true,
-	"bangkabelitung islands":    true,
-	// Sumatera Barat
-	"sumatera barat": true,
-	"west sumatra":   true,
-	// Sumatera Selatan
-	"sumatera selatan": true,
-	"south sumatra":    true,
-	// Sumatera Utara
-	"sumatera utara": true,
-	"north sumatra":  true,
-}
-
-// isInDelivereeProvince mengembalikan true jika provinsi dilayani Deliveree (Jawa, Sumatra, Bali).
-func isInDelivereeProvince(provinsi string) bool {
-	return delivereeProvinsi[strings.ToLower(strings.TrimSpace(provinsi))]
-}
+	db             *gorm.DB
+	pengirimanRepo repositories.PesananPengirimanRepository
+	eventRepo      repositories.PesananPengirimanEventRepository
+	buktiRepo      repositories.PesananBuktiPengirimanRepository
+	coverage       ShippingCoverageService
+	quoteRepo      repositories.ShippingQuoteRepository
+	quoteTTL       time.Duration
+	providers      map[models.DeliveryType]ShippingProvider
+	cfg            *config.Config
+	httpClient     *http.Client
+}
+
+// NewShippingService mendaftarkan provider per delivery type sesuai
+// SHIPPING_PROVIDER_MODE. Mode sandbox memakai provider tiruan untuk semua
+// delivery type; routing LTL/LCL dan cek coverage tetap berjalan seperti live.
+func NewShippingService(db *gorm.DB, pengirimanRepo repositories.PesananPengirimanRepository, eventRepo repositories.PesananPengirimanEventRepository, buktiRepo repositories.PesananBuktiPengirimanRepository, coverage ShippingCoverageService, quoteRepo repositories.ShippingQuoteRepository, delivereeVehicleType DelivereeVehicleTypeService, jobQueue JobQueueService, cfg *config.Config) ShippingService {
+	s := &shippingService{
+		db:             db,
+		pengirimanRepo: pengirimanRepo,
+		eventRepo:      eventRepo,
+		buktiRepo:      buktiRepo,
+		coverage:       coverage,
+		quoteRepo:      quoteRepo,
+		quoteTTL:       cfg.ShippingQuoteTTL,
+		providers:      map[models.DeliveryType]ShippingProvider{},
+		cfg:            cfg,
+		httpClient:     &http.Client{Timeout: 30 * time.Second},
+	}
+
+	if cfg.ShippingProviderMode == ShippingModeSandbox {
+		log.Printf("[shipping] mode sandbox aktif: booking & webhook disimulasikan setiap %s", cfg.ShippingSandboxStep)
+		s.register(models.DeliveryTypeDeliveree, newSandboxShippingProvider(sandboxWebhookDeliveree, jobQueue, cfg.ShippingSandboxStep))
+		forwarderSandbox := newSandboxShippingProvider(sandboxWebhookForwarder, jobQueue, cfg.ShippingSandboxStep)
+		s.register(models.DeliveryTypeForwarder, forwarderSandbox)
+		s.register(models.DeliveryTypeForwarderLCL, forwarderSandbox)
+		return s
+	}
+
+	forwarder := &forwarderClient{
+		apiURL:     cfg.ForwarderAPIURL,
+		clientName: cfg.ForwarderClientName,
+		username:   cfg.ForwarderUsername,
+		password:   cfg.ForwarderPassword,
+		db:         db,
+	}
+	s.register(models.DeliveryTypeDeliveree, newDelivereeProvider(cfg.DelivereeBaseURL, cfg.DelivereeAPIKey, delivereeVehicleType))
+	s.register(models.DeliveryTypeForwarder, &forwarderLTLProvider{forwarder})
+	s.register(models.DeliveryTypeForwarderLCL, &forwarderLCLProvider{forwarder})
+	return s
+}
+
+func (s *shippingService) register(deliveryType models.DeliveryType, provider ShippingProvider) {
+	s.providers[deliveryType] = provider
+}
+
+func (s *shippingService) Provider(deliveryType models.DeliveryType) (ShippingProvider, error) {
+	provider, ok := s.providers[deliveryType]
+	if !ok {
+		return nil, fmt.Errorf("delivery type %s tidak memiliki provider pengiriman", deliveryType)
+	}
+	return provider, nil
+}
+
+// bookingLockTimeout batas umur booking_lock_at. Booking dan aksi admin selesai
+// jauh di bawah ini (job booking dibatasi 2 menit); lock yang lebih tua berarti
+// pemegangnya mati sebelum melepas lock dan boleh diambil alih.
+const bookingLockTimeout = 10 * time.Minute
 
 // ClaimBooking secara atomik mengunci pesanan untuk proses booking.
 // Lock memakai kolom khusus booking_lock_at (migration 000170) yang TIDAK pernah
 // disentuh webhook provider — kolom booking_status murni milik webhook Deliveree
 // (locating_driver, delivery_in_progress, dsb.) sehingga tidak akan tertimpa
 // maupun salah dibaca sebagai lock.
+// Claim hanya berhasil jika masih ada pengiriman yang belum ter-booking (atau
+// pesanan belum punya pengiriman sama sekali).
 // Hanya satu pemanggil (goroutine trigger atau request retry) yang berhasil;
 // pemanggil lain mendapat false dan harus berhenti tanpa memanggil API provider.
+// Lock yang melewati bookingLockTimeout diambil alih.
 func (s *shippingService) ClaimBooking(pesananID uuid.UUID) (bool, error) {
 	now := time.Now().UTC()
 	res := s.db.Model(&models.Pesanan{}).
-		Where("id = ? AND deliveree_booking_id IS NULL AND forwarder_tracking_no IS NULL AND booking_lock_at IS NULL",
-			pesananID).
+		Where("id = ?", pesananID).
+		Where("booking_lock_at IS NULL OR booking_lock_at < ?", now.Add(-bookingLockTimeout)).
+		Where(`(EXISTS (SELECT 1 FROM pesanan_pengiriman pp WHERE pp.pesanan_id = pesanan.id AND pp.booking_ref IS NULL))
+			OR (NOT EXISTS (SELECT 1 FROM pesanan_pengiriman pp WHERE pp.pesanan_id = pesanan.id)
+				AND deliveree_booking_id IS NULL AND forwarder_tracking_no IS NULL)`).
 		Update("booking_lock_at", now)
 	if res.Error != nil {
 		return false, res.Error
@@ -310,1326 +270,378 @@ func (s *shippingService) ClaimBooking(pesananID uuid.UUID) (bool, error) {
 	return res.RowsAffected > 0, nil
 }
 
-func (s *shippingService) TriggerBookingAsync(pesanan *models.Pesanan) {
-	go func(p *models.Pesanan) {
-		// Claim atomik: cegah double-booking jika trigger dipanggil ulang atau
-		// bertabrakan dengan RetryBooking. Hanya satu goroutine yang menang.
-		claimed, err := s.ClaimBooking(p.ID)
-		if err != nil {
-			log.Printf("[shipping] booking claim error: pesanan=%s err=%v", p.Kode, err)
-			return
-		}
-		if !claimed {
-			log.Printf("[shipping] skip booking async: pesanan=%s sudah di-booking/sedang diproses proses lain", p.Kode)
-			return
-		}
-
-		log.Printf("[shipping] trigger booking async: pesanan=%s delivery_type=%s", p.Kode, p.DeliveryType)
-		ctx := context.Background()
-		delivereeID, trackingNo, err := s.BookDelivery(ctx, p)
-		updates := map[string]interface{}{}
-		if err != nil {
-			log.Printf("[shipping] booking gagal: pesanan=%s delivery_type=%s error=%v", p.Kode, p.DeliveryType, err)
-			errMsg := err.Error()
-			updates["booking_error"] = errMsg
-		} else {
-			// delivery_type mungkin sudah diubah oleh BookDelivery (FORWARDER → FORWARDER_LCL),
-			// log pakai nilai dari p.DeliveryType hanya sebagai referensi awal.
-			log.Printf("[shipping] booking sukses: pesanan=%s initial_delivery_type=%s deliveree_id=%v tracking_no=%v", p.Kode, p.DeliveryType, delivereeID, trackingNo)
-			updates["booking_error"] = nil
-			if delivereeID != nil {
-				updates["deliveree_booking_id"] = *delivereeID
-			}
-			if trackingNo != nil {
-				updates["forwarder_tracking_no"] = *trackingNo
-			}
-		}
-		// Release claim — reset booking_lock_at agar retry/trigger ulang bisa jalan.
-		// booking_status TIDAK disentuh di sini: kolom itu milik webhook provider.
-		updates["booking_lock_at"] = nil
-		s.db.Model(&models.Pesanan{}).Where("id = ?", p.ID).UpdateColumns(updates)
-	}(pesanan)
-}
-
-func (s *shippingService) BookDelivery(ctx context.Context, pesanan *models.Pesanan) (*string, *string, error) {
-	switch pesanan.DeliveryType {
-	case models.DeliveryTypeDeliveree:
-		if pesanan.AlamatBuyer == nil || !isInDelivereeProvince(pesanan.AlamatBuyer.Provinsi) {
-			provinsi := ""
-			if pesanan.AlamatBuyer != nil {
-				provinsi = pesanan.AlamatBuyer.Provinsi
-			}
-			return nil, nil, fmt.Errorf("Deliveree tidak tersedia untuk provinsi %q (hanya Jawa, Sumatra, dan Bali)", provinsi)
-		}
-		bookingID, err := s.bookDeliveree(ctx, pesanan)
-		if err != nil {
-			return nil, nil, err
-		}
-		return bookingID, nil, nil
-	case models.DeliveryTypeForwarder, models.DeliveryTypeForwarderLCL:
-		var trackingNo *string
-		var err error
-		if pesanan.AlamatBuyer != nil && isInLTLProvince(pesanan.AlamatBuyer.Provinsi) {
-			// Provinsi dilayani LTL (darat).
-			// Update delivery_type ke FORWARDER di awal agar UI langsung reflect routing yang benar,
-			// termasuk saat booking gagal dan admin melihat status di panel.
-			if pesanan.DeliveryType != models.DeliveryTypeForwarder {
-				s.db.Model(&models.Pesanan{}).Where("id = ?", pesanan.ID).Update("delivery_type", models.DeliveryTypeForwarder)
-			}
-			log.Printf("[shipping] routing LTL pesanan=%s provinsi=%s", pesanan.Kode, pesanan.AlamatBuyer.Provinsi)
-			trackingNo, err = s.bookForwarder(ctx, pesanan)
-		} else {
-			// Provinsi di luar coverage LTL → LCL (laut).
-			// Update delivery_type ke FORWARDER_LCL di awal agar UI langsung reflect routing yang benar,
-			// termasuk saat booking gagal dan admin melihat status di panel.
-			provinsi := ""
-			if pesanan.AlamatBuyer != nil {
-				provinsi = pesanan.AlamatBuyer.Provinsi
-			}
-			if pesanan.DeliveryType != models.DeliveryTypeForwarderLCL {
-				s.db.Model(&models.Pesanan{}).Where("id = ?", pesanan.ID).Update("delivery_type", models.DeliveryTypeForwarderLCL)
-			}
-			log.Printf("[shipping] routing LCL pesanan=%s provinsi=%s (luar LTL coverage)", pesanan.Kode, provinsi)
-			trackingNo, err = s.bookForwarderLCL(ctx, pesanan)
-		}
-		if err != nil {
-			return nil, nil, err
-		}
-		return nil, trackingNo, nil
-	default:
-		return nil, nil, fmt.Errorf("delivery type %s tidak memerlukan booking", pesanan.DeliveryType)
-	}
-}
-
-// buildFullAddress menggabungkan alamat lengkap dengan data wilayah agar
-// address yang dikirim ke provider tidak hanya berisi nama jalan saja.
-func buildFullAddress(alamat *models.AlamatBuyer) string {
-	parts := []string{alamat.AlamatLengkap}
-	if alamat.Kelurahan != nil && *alamat.Kelurahan != "" {
-		parts = append(parts, *alamat.Kelurahan)
-	}
-	if alamat.Kecamatan != nil && *alamat.Kecamatan != "" {
-		parts = append(parts, *alamat.Kecamatan)
-	}
-	if alamat.Kota != "" {
-		parts = append(parts, alamat.Kota)
-	}
-	if alamat.Provinsi != "" {
-		parts = append(parts, alamat.Provinsi)
+func (s *shippingService) BookAndRecord(ctx context.Context, p *models.Pesanan) error {
+	// Claim atomik: cegah double-booking jika trigger dipanggil ulang atau
+	// bertabrakan dengan RetryBooking. Hanya satu pemanggil yang menang.
+	claimed, err := s.ClaimBooking(p.ID)
+	if err != nil {
+		return err
 	}
-	if alamat.KodePos != nil && *alamat.KodePos != "" {
-		parts = append(parts, *alamat.KodePos)
+	if !claimed {
+		log.Printf("[shipping] skip booking: pesanan=%s sudah di-booking/sedang diproses proses lain", p.Kode)
+		return nil
 	}
-	return strings.Join(parts, ", ")
-}
-
-// ─── Deliveree ────────────────────────────────────────────────────────────────
 
-type delivereeLocation struct {
-	Address        string  `json:"address"`
-	Latitude       float64 `json:"latitude"`
-	Longitude      float64 `json:"longitude"`
-	RecipientName  string  `json:"recipient_name"`
-	RecipientPhone string  `json:"recipient_phone"`
-	Note           string  `json:"note,omitempty"`
-	IsPayer        bool    `json:"is_payer"`
-	NeedCOD        bool    `json:"need_cod,omitempty"`
-	CODNote        string  `json:"cod_note,omitempty"`
-	CODInvoiceFees float64 `json:"cod_invoice_fees,omitempty"`
-	NeedPOD        bool    `json:"need_pod,omitempty"`
-	PODNote        string  `json:"pod_note,omitempty"`
+	log.Printf("[shipping] trigger booking: pesanan=%s delivery_type=%s", p.Kode, p.DeliveryType)
+	// Error booking dikembalikan agar job queue menjadwalkan percobaan ulang
+	_, err = s.BookShipments(ctx, p)
+	return err
 }
 
-type delivereeCreateRequest struct {
-	VehicleTypeID        int                 `json:"vehicle_type_id"`
-	BookingPaymentType   string              `json:"booking_payment_type"`
-	Note                 string              `json:"note,omitempty"`
-	TimeType             string              `json:"time_type"`
-	PickupTime           string              `json:"pickup_time"`
-	JobOrderNumber       string              `json:"job_order_number,omitempty"`
-	AllowParkingFees     bool                `json:"allow_parking_fees"`
-	AllowTollsFees       bool                `json:"allow_tolls_fees"`
-	AllowWaitingTimeFees bool                `json:"allow_waiting_time_fees"`
-	SendFirstToDriver    bool                `json:"send_first_to_driver"`
-	MarkedAsFavorite     bool                `json:"marked_as_favorite"`
-	Locations            []delivereeLocation `json:"locations"`
-	RequireSignatures    bool                `json:"require_signatures"`
-	ExtraServices        []interface{}       `json:"extra_services"`
-	EstimateTransitTimes []interface{}       `json:"estimate_transit_times_attributes"`
-}
-
-type delivereeCreateResponse struct {
-	BookingID int `json:"booking_id"`
-}
-
-func (s *shippingService) bookDeliveree(ctx context.Context, pesanan *models.Pesanan) (*string, error) {
-	baseURL := os.Getenv("DELIVEREE_BASE_URL")
-	apiKey := os.Getenv("DELIVEREE_API_KEY")
-	if baseURL == "" || apiKey == "" {
-		return nil, fmt.Errorf("konfigurasi Deliveree tidak lengkap")
-	}
-
-	// AlamatBuyer is required
-	if pesanan.AlamatBuyer == nil {
-		return nil, fmt.Errorf("pesanan tidak memiliki alamat pengiriman")
-	}
-
-	// Vehicle type dipilih berdasarkan total kubikasi & berat barang (master data
-	// deliveree_vehicle_type), menggantikan logic lama yang berbasis jumlah qty/palet.
-	environment := "production"
-	if strings.Contains(baseURL, "sandbox") {
-		environment = "sandbox"
-	}
-	totalKubikasi := 0.0
-	totalBerat := 0.0
-	for _, item := range pesanan.Items {
-		p := item.Produk
-		// Dimensi produk dalam cm → konversi ke m3 (panjang x lebar x tinggi / 1_000_000).
-		totalKubikasi += (p.Panjang * p.Lebar * p.Tinggi / 1_000_000) * float64(item.Qty)
-		totalBerat += p.Berat * float64(item.Qty)
-	}
-
-	var vehicleTypeID int
-	var vehicle *models.DelivereeVehicleType
+func (s *shippingService) BookShipments(ctx context.Context, pesanan *models.Pesanan) ([]models.PesananPengiriman, error) {
+	shipments, bookErr := s.bookShipments(ctx, pesanan)
 
-	// 1. Prioritas: pakai kendaraan hasil quote yang disimpan storefront saat
-	// checkout (deliveree_vehicle_type_id), jika masih aktif di environment ini.
-	// Ini menjamin ongkir yang ditagih ke buyer saat checkout konsisten dengan
-	// kendaraan yang benar-benar dipakai saat create booking.
-	if pesanan.DelivereeVehicleTypeID != nil && *pesanan.DelivereeVehicleTypeID > 0 {
-		if v, vErr := s.delivereeVehicleType.FindActiveByIDDeliveree(ctx, *pesanan.DelivereeVehicleTypeID, environment); vErr == nil && v != nil {
-			vehicle = v
-			vehicleTypeID = v.IDDeliveree
-			log.Printf("[deliveree] pakai vehicle_type_id dari checkout pesanan=%s id=%d", pesanan.Kode, vehicleTypeID)
-		} else {
-			log.Printf("[deliveree] vehicle_type_id checkout pesanan=%s id=%d tidak aktif/tidak ditemukan — fallback ke SelectVehicle", pesanan.Kode, *pesanan.DelivereeVehicleTypeID)
-		}
+	updates := map[string]interface{}{"booking_error": nil}
+	if bookErr != nil {
+		log.Printf("[shipping] booking gagal: pesanan=%s delivery_type=%s error=%v", pesanan.Kode, pesanan.DeliveryType, bookErr)
+		updates["booking_error"] = bookErr.Error()
 	}
-
-	// 2. Jika tidak ada nilai dari checkout (order lama) atau nilainya invalid,
-	// seleksi normal berbasis total kubikasi & berat (master data).
-	if vehicleTypeID == 0 {
-		var err error
-		vehicle, err = s.delivereeVehicleType.SelectVehicle(ctx, environment, totalKubikasi, totalBerat)
-		if err != nil {
-			// Fallback ke logic lama berbasis qty jika master data belum di-sync/tidak
-			// ditemukan kendaraan yang cukup, agar proses booking tidak buntu total.
-			log.Printf("[deliveree] SelectVehicle gagal pesanan=%s environment=%s totalKubikasi=%.3f totalBerat=%.2f err=%v — fallback ke logic qty", pesanan.Kode, environment, totalKubikasi, totalBerat, err)
-			totalQty := 0
-			for _, item := range pesanan.Items {
-				totalQty += item.Qty
-			}
-			vehicleTypeID = delivereeVehicleTypeID(totalQty, baseURL)
-		} else {
-			vehicleTypeID = vehicle.IDDeliveree
+	// Kolom booking di pesanan mengikuti pengiriman utama agar klien lama tetap jalan
+	if len(shipments) > 0 && shipments[0].IsBooked() {
+		column := "forwarder_tracking_no"
+		if shipments[0].DeliveryType == models.DeliveryTypeDeliveree {
+			column = "deliveree_booking_id"
 		}
+		updates[column] = *shipments[0].BookingRef
 	}
-
-	bookingID, bookErr := s.bookDelivereeWithVehicle(ctx, pesanan, baseURL, apiKey, vehicleTypeID)
-	if bookErr != nil && vehicle != nil {
-		// Retry sekali dengan kendaraan satu tingkat lebih besar jika booking gagal
-		// (mis. tidak ada driver tersedia untuk kendaraan yang dipilih).
-		nextVehicle, nextErr := s.delivereeVehicleType.NextLargerVehicle(ctx, environment, vehicle.IDDeliveree)
-		if nextErr == nil && nextVehicle != nil {
-			log.Printf("[deliveree] retry pesanan=%s vehicle_type_id=%d gagal (%v), coba kendaraan lebih besar id=%d", pesanan.Kode, vehicleTypeID, bookErr, nextVehicle.IDDeliveree)
-			return s.bookDelivereeWithVehicle(ctx, pesanan, baseURL, apiKey, nextVehicle.IDDeliveree)
-		}
+	// Release claim — reset booking_lock_at agar retry/trigger ulang bisa jalan.
+	// booking_status TIDAK disentuh di sini: kolom itu milik webhook provider.
+	updates["booking_lock_at"] = nil
+	if err := s.db.Model(&models.Pesanan{}).Where("id = ?", pesanan.ID).UpdateColumns(updates).Error; err != nil {
+		return shipments, err
 	}
-	return bookingID, bookErr
+	return shipments, bookErr
 }
 
-func (s *shippingService) bookDelivereeWithVehicle(ctx context.Context, pesanan *models.Pesanan, baseURL, apiKey string, vehicleTypeID int) (*string, error) {
-	// Get first active warehouse
-	warehouse, err := s.getActiveWarehouse()
+// bookShipments membooking setiap pengiriman yang belum punya booking ref.
+// Kegagalan satu gudang tidak menghentikan booking gudang lain; error
+// digabung dan dikembalikan bersama daftar pengiriman terbaru.
+func (s *shippingService) bookShipments(ctx context.Context, pesanan *models.Pesanan) ([]models.PesananPengiriman, error) {
+	deliveryType, err := s.routeDelivery(ctx, pesanan)
 	if err != nil {
-		return nil, fmt.Errorf("gagal mendapatkan data warehouse: %w", err)
-	}
-
-	alamat := pesanan.AlamatBuyer
-	if alamat == nil {
-		return nil, fmt.Errorf("pesanan tidak memiliki alamat pengiriman")
-	}
-
-	warehouseAlamat := ""
-	if warehouse.Alamat != nil {
-		warehouseAlamat = *warehouse.Alamat
-	}
-	warehouseTelepon := ""
-	if warehouse.Telepon != nil {
-		warehouseTelepon = *warehouse.Telepon
-	}
-	warehouseLat := 0.0
-	if warehouse.Latitude != nil {
-		warehouseLat = *warehouse.Latitude
-	}
-	warehouseLng := 0.0
-	if warehouse.Longitude != nil {
-		warehouseLng = *warehouse.Longitude
-	}
-
-	buyerLat := 0.0
-	if alamat.Latitude != nil {
-		buyerLat = *alamat.Latitude
-	}
-	buyerLng := 0.0
-	if alamat.Longitude != nil {
-		buyerLng = *alamat.Longitude
-	}
-
-	req := delivereeCreateRequest{
-		VehicleTypeID:        vehicleTypeID,
-		BookingPaymentType:   "credit",
-		TimeType:             "now",
-		PickupTime:           "",
-		JobOrderNumber:       pesanan.Kode,
-		AllowParkingFees:     true,
-		AllowTollsFees:       true,
-		AllowWaitingTimeFees: true,
-		SendFirstToDriver:    false,
-		MarkedAsFavorite:     true,
-		RequireSignatures:    true,
-		ExtraServices:        []interface{}{},
-		EstimateTransitTimes: []interface{}{},
-		Locations: []delivereeLocation{
-			{
-				Address:        warehouseAlamat,
-				Latitude:       warehouseLat,
-				Longitude:      warehouseLng,
-				RecipientName:  "Bulky.id",
-				RecipientPhone: warehouseTelepon,
-				Note:           "Pickup at warehouse",
-				IsPayer:        false,
-			},
-			{
-				Address:        buildFullAddress(alamat),
-				Latitude:       buyerLat,
-				Longitude:      buyerLng,
-				RecipientName:  alamat.NamaPenerima,
-				RecipientPhone: alamat.TeleponPenerima,
-				Note:           "Drop at buyer location",
-				IsPayer:        true,
-				NeedCOD:        false,
-				NeedPOD:        false,
-			},
-		},
+		return nil, err
 	}
-
-	body, err := json.Marshal(req)
+	provider, err := s.Provider(deliveryType)
 	if err != nil {
-		return nil, fmt.Errorf("gagal membuat request body: %w", err)
+		return nil, err
 	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/deliveries", bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("gagal membuat HTTP request: %w", err)
+	if pesanan.AlamatBuyer == nil {
+		return nil, fmt.Errorf("pesanan tidak memiliki alamat pengiriman")
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", apiKey)
-
-	log.Printf("[deliveree] --> POST /deliveries pesanan=%s vehicle_type_id=%d body=%s", pesanan.Kode, req.VehicleTypeID, string(body))
-
-	resp, err := http.DefaultClient.Do(httpReq)
+	quotedVehicles, err := s.quotedShipping(ctx, pesanan, deliveryType)
 	if err != nil {
-		log.Printf("[deliveree] <-- POST /deliveries pesanan=%s error=%v", pesanan.Kode, err)
-		return nil, fmt.Errorf("connection timeout atau gagal menghubungi Deliveree: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-	log.Printf("[deliveree] <-- POST /deliveries pesanan=%s status=%d body=%s", pesanan.Kode, resp.StatusCode, string(respBody))
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Deliveree API error (status %d): %s", resp.StatusCode, string(respBody))
+		return nil, err
 	}
 
-	var result delivereeCreateResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("gagal parse response Deliveree: %w", err)
-	}
-	if result.BookingID == 0 {
-		return nil, fmt.Errorf("Deliveree tidak mengembalikan booking ID")
+	shipments, err := s.planShipments(ctx, pesanan, deliveryType)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menyiapkan pengiriman per gudang: %w", err)
 	}
 
-	bookingID := strconv.Itoa(result.BookingID)
-	return &bookingID, nil
-}
+	var errs []error
+	for i := range shipments {
+		shipment := &shipments[i]
+		if shipment.IsBooked() {
+			continue
+		}
 
-func delivereeVehicleTypeID(totalQty int, baseURL string) int {
-	isSandbox := strings.Contains(baseURL, "sandbox")
-	switch {
-	case totalQty <= 4:
-		if isSandbox {
-			return 14
+		req := &ShipmentRequest{
+			Pesanan:     pesanan,
+			Reference:   shipmentReference(pesanan, shipment, len(shipments)),
+			Origin:      &shipment.Warehouse,
+			Destination: pesanan.AlamatBuyer,
+			Items:       shipment.Items,
 		}
-		return 2701
-	case totalQty <= 8:
-		if isSandbox {
-			return 24
+		if vehicleTypeID, ok := quotedVehicles[shipment.WarehouseID]; ok {
+			req.QuotedVehicleTypeID = &vehicleTypeID
 		}
-		return 2703
-	default:
-		if isSandbox {
-			return 36
+		ref, err := s.bookShipment(ctx, provider, shipment, req)
+		fields := map[string]interface{}{}
+		if err != nil {
+			if len(shipments) > 1 {
+				err = fmt.Errorf("gudang %s: %w", shipment.Warehouse.Nama, err)
+			}
+			errs = append(errs, err)
+			msg := err.Error()
+			shipment.BookingError = &msg
+			fields["booking_error"] = msg
+		} else {
+			log.Printf("[shipping] booking sukses: pesanan=%s pengiriman=%d gudang=%s delivery_type=%s ref=%s", pesanan.Kode, shipment.Urutan, shipment.Warehouse.Nama, deliveryType, ref)
+			now := time.Now()
+			shipment.BookingRef = &ref
+			shipment.BookingError = nil
+			shipment.BookedAt = &now
+			fields["booking_ref"] = ref
+			fields["booking_error"] = nil
+			fields["booked_at"] = now
+		}
+		if err := s.pengirimanRepo.UpdateFields(ctx, shipment.ID, fields); err != nil {
+			return shipments, err
 		}
-		return 2723
 	}
+	return shipments, errors.Join(errs...)
 }
 
-// ─── Forwarder ────────────────────────────────────────────────────────────────
-
-type forwarderTokenRequest struct {
-	Scope string `json:"scope"`
-}
-
-type forwarderTokenResponse struct {
-	AccessToken string `json:"access_token"`
-	Status      string `json:"status"`
-	Message     string `json:"message"`
-}
-
-type forwarderLocation struct {
-	Address   string `json:"address"`
-	Latitude  string `json:"latitude"`
-	Longitude string `json:"longitude"`
-	Type      string `json:"type"`
-	Order     int    `json:"order"`
-	PicName   string `json:"picname"`
-	PicPhone  string `json:"picphone"`
-	Detail    string `json:"detail,omitempty"`
-}
-
-type forwarderDataDetail struct {
-	Packaging   string  `json:"packaging"`
-	Commodity   string  `json:"commodity"`
-	CargoDesc   string  `json:"cargodesc"`
-	Qty         int     `json:"qty"`
-	Length      float64 `json:"length"`
-	Width       float64 `json:"width"`
-	Height      float64 `json:"height"`
-	Volume      float64 `json:"volume"`
-	TotalVolume float64 `json:"totalvolume"`
-	Weight      float64 `json:"weight"`
-	TotalWeight float64 `json:"totalweight"`
-}
-
-type forwarderCreateBookingRequest struct {
-	TransportID              string                `json:"transportid"`
-	LoadID                   string                `json:"loadid"`
-	ServiceID                string                `json:"serviceid"`
-	OriginCityID             string                `json:"origincityid"`
-	DestinationCityID        string                `json:"destinationcityid"`
-	DestinationSubdistrictID string                `json:"destinationsubdistrictid"`
-	PriceID                  int                   `json:"priceid"`
-	PickupTimeType           string                `json:"pickuptimetype"`
-	PickupTimeOn             string                `json:"pickuptimeon"`
-	VehicleID                string                `json:"vehicleid"`
-	VehicleQty               int                   `json:"vehicleqty"`
-	ShipperName              string                `json:"shippername"`
-	ShipperPhone             string                `json:"shipperphone"`
-	ShipperAddress           string                `json:"shipperaddress"`
-	ConsigneeName            string                `json:"consigneename"`
-	ConsigneePhone           string                `json:"consigneephone"`
-	ConsigneeAddress         string                `json:"consigneeaddress"`
-	EstDistance              string                `json:"estdistance"`
-	EstPrice                 string                `json:"estprice"`
-	BasisPrice               string                `json:"basisprice"`
-	Remark                   string                `json:"remark,omitempty"`
-	WithInsurance            string                `json:"withinsurance"`
-	CommodityAmount          string                `json:"commodityamount,omitempty"`
-	InsuranceID              string                `json:"insuranceid,omitempty"`
-	PremiAmount              string                `json:"premiamount,omitempty"`
-	Locations                []forwarderLocation   `json:"locations"`
-	DataDetail               []forwarderDataDetail `json:"datadetail"`
-}
-
-type forwarderBookingResponse struct {
-	Msg  string `json:"msg"`
-	Data struct {
-		BookingNo string `json:"booking_no"`
-	} `json:"data"`
-	IsSuccess string `json:"isSuccess"`
-}
-
-func (s *shippingService) bookForwarder(ctx context.Context, pesanan *models.Pesanan) (*string, error) {
-	apiURL := os.Getenv("FORWARDER_API_URL")
-	clientName := os.Getenv("FORWARDER_CLIENT_NAME")
-	username := os.Getenv("FORWARDER_USERNAME")
-	password := os.Getenv("FORWARDER_PASSWORD")
-	if apiURL == "" || clientName == "" || username == "" || password == "" {
-		return nil, fmt.Errorf("konfigurasi Forwarder tidak lengkap")
-	}
-
-	// Get access token
-	token, err := s.getForwarderToken(ctx, apiURL, clientName, username, password, "CREATEBOOKINGLAND")
-	if err != nil {
-		return nil, fmt.Errorf("gagal mendapatkan token Forwarder: %w", err)
-	}
-
-	// Get first active warehouse
-	warehouse, err := s.getActiveWarehouse()
-	if err != nil {
-		return nil, fmt.Errorf("gagal mendapatkan data warehouse: %w", err)
-	}
-
-	if pesanan.AlamatBuyer == nil {
-		return nil, fmt.Errorf("pesanan tidak memiliki alamat pengiriman")
-	}
-	alamat := pesanan.AlamatBuyer
-
-	// Lookup origin city (warehouse)
-	if warehouse.Kota == nil || *warehouse.Kota == "" {
-		return nil, fmt.Errorf("warehouse tidak memiliki data kota")
-	}
-	var originMapping models.ForwarderCityMapping
-	if err := s.db.Where("kota_pattern = ?", utils.NormalizeKota(*warehouse.Kota)).First(&originMapping).Error; err != nil {
-		return nil, fmt.Errorf("kota asal warehouse (%s) tidak ditemukan di Forwarder mapping", *warehouse.Kota)
-	}
-
-	// Lookup destination city (buyer)
-	var destMapping models.ForwarderCityMapping
-	if err := s.db.Where("kota_pattern = ?", utils.NormalizeKota(alamat.Kota)).First(&destMapping).Error; err != nil {
-		return nil, fmt.Errorf("kota tujuan tidak ditemukan di Forwarder mapping. Silakan tambahkan mapping untuk kota: %s", alamat.Kota)
-	}
-
-	// Lookup destination subdistrict (optional for LTL)
-	subdistrictID := "0"
-	if alamat.Kecamatan != nil && *alamat.Kecamatan != "" {
-		subdistrictID = s.resolveForwarderSubdistrictID(ctx, *alamat.Kecamatan, destMapping.ForwarderCityID, apiURL, clientName, username, password)
-	}
-
-	// Build datadetail from items
-	dataDetail := make([]forwarderDataDetail, 0, len(pesanan.Items))
-	for _, item := range pesanan.Items {
-		p := item.Produk
-		vol := (p.Panjang * p.Lebar * p.Tinggi) / 1_000_000 // cm³ → m³
-		dataDetail = append(dataDetail, forwarderDataDetail{
-			Packaging:   "5",
-			Commodity:   "222",
-			CargoDesc:   item.NamaProduk,
-			Qty:         item.Qty,
-			Length:      p.Panjang,
-			Width:       p.Lebar,
-			Height:      p.Tinggi,
-			Volume:      vol,
-			TotalVolume: vol * float64(item.Qty),
-			Weight:      p.Berat,
-			TotalWeight: p.Berat * float64(item.Qty),
-		})
+// bookShipment membooking satu pengiriman. Provider yang memilih kendaraan dari
+// rencana muat (Deliveree) menyusun rencananya dulu dan disimpan di pengiriman
+// agar kendaraan terpilih terlihat di detail pesanan admin, termasuk saat
+// muatan ditolak karena butuh beberapa kendaraan.
+func (s *shippingService) bookShipment(ctx context.Context, provider ShippingProvider, shipment *models.PesananPengiriman, req *ShipmentRequest) (string, error) {
+	planner, ok := provider.(loadPlanProvider)
+	if !ok {
+		return provider.Book(ctx, req)
 	}
 
-	warehouseAlamat := ""
-	if warehouse.Alamat != nil {
-		warehouseAlamat = *warehouse.Alamat
+	plan, planErr := planner.PlanLoad(ctx, req)
+	if planErr != nil && !errors.Is(planErr, ErrMuatanMelebihiKendaraan) {
+		log.Printf("[shipping] rencana muat gagal: pengiriman=%s error=%v", shipment.ID, planErr)
 	}
-	warehouseTelepon := ""
-	if warehouse.Telepon != nil {
-		warehouseTelepon = *warehouse.Telepon
-	}
-	warehouseLat := "0"
-	if warehouse.Latitude != nil {
-		warehouseLat = strconv.FormatFloat(*warehouse.Latitude, 'f', 8, 64)
-	}
-	warehouseLng := "0"
-	if warehouse.Longitude != nil {
-		warehouseLng = strconv.FormatFloat(*warehouse.Longitude, 'f', 8, 64)
-	}
-	buyerLat := "0"
-	if alamat.Latitude != nil {
-		buyerLat = strconv.FormatFloat(*alamat.Latitude, 'f', 8, 64)
-	}
-	buyerLng := "0"
-	if alamat.Longitude != nil {
-		buyerLng = strconv.FormatFloat(*alamat.Longitude, 'f', 8, 64)
-	}
-
-	withInsurance := "0"
-	commodityAmount := ""
-	insuranceID := ""
-	premiAmount := ""
-	if pesanan.BiayaLainnya.IsPositive() {
-		withInsurance = "1"
-		commodityAmount = pesanan.BiayaProduk.StringFixed(0)
-		insuranceID = "1"
-		premiAmount = pesanan.BiayaLainnya.StringFixed(0)
-	}
-
-	bookingReq := forwarderCreateBookingRequest{
-		TransportID:              "3",
-		LoadID:                   "5",
-		ServiceID:                "1",
-		OriginCityID:             strconv.Itoa(originMapping.ForwarderCityID),
-		DestinationCityID:        strconv.Itoa(destMapping.ForwarderCityID),
-		DestinationSubdistrictID: subdistrictID,
-		PriceID:                  1,
-		PickupTimeType:           "SCHEDULE",
-		PickupTimeOn:             "",
-		VehicleID:                "0",
-		VehicleQty:               1,
-		ShipperName:              "Liquid8 | Bulky",
-		ShipperPhone:             warehouseTelepon,
-		ShipperAddress:           warehouseAlamat,
-		ConsigneeName:            alamat.NamaPenerima,
-		ConsigneePhone:           alamat.TeleponPenerima,
-		ConsigneeAddress:         buildFullAddress(alamat),
-		EstDistance:              "0",
-		EstPrice:                 "",
-		BasisPrice:               "ECONOMY",
-		Remark:                   pesanan.Kode,
-		WithInsurance:            withInsurance,
-		CommodityAmount:          commodityAmount,
-		InsuranceID:              insuranceID,
-		PremiAmount:              premiAmount,
-		Locations: []forwarderLocation{
-			{
-				Address:   warehouseAlamat,
-				Latitude:  warehouseLat,
-				Longitude: warehouseLng,
-				Type:      "PICKUP",
-				Order:     1,
-				PicName:   "Liquid8 | Bulky",
-				PicPhone:  warehouseTelepon,
-				Detail:    warehouseAlamat,
-			},
-			{
-				Address:   buildFullAddress(alamat),
-				Latitude:  buyerLat,
-				Longitude: buyerLng,
-				Type:      "DELIVERY",
-				Order:     2,
-				PicName:   alamat.NamaPenerima,
-				PicPhone:  alamat.TeleponPenerima,
-				Detail:    buildFullAddress(alamat),
-			},
-		},
-		DataDetail: dataDetail,
-	}
-
-	body, err := json.Marshal(bookingReq)
-	if err != nil {
-		return nil, fmt.Errorf("gagal membuat request body: %w", err)
-	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+"/createbookingland", bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("gagal membuat HTTP request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+token)
-	httpReq.Header.Set("Client_name", clientName)
-
-	log.Printf("[forwarder] --> POST /createbookingland pesanan=%s transport=%s load=%s origin_city=%s dest_city=%s consignee=%s body=%s",
-		pesanan.Kode, bookingReq.TransportID, bookingReq.LoadID,
-		bookingReq.OriginCityID, bookingReq.DestinationCityID, bookingReq.ConsigneeName, string(body))
-
-	resp, err := http.DefaultClient.Do(httpReq)
-	if err != nil {
-		log.Printf("[forwarder] <-- POST /createbookingland pesanan=%s error=%v", pesanan.Kode, err)
-		return nil, fmt.Errorf("connection timeout atau gagal menghubungi Forwarder: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-	log.Printf("[forwarder] <-- POST /createbookingland pesanan=%s status=%d body=%s", pesanan.Kode, resp.StatusCode, string(respBody))
-
-	var result forwarderBookingResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("gagal parse response Forwarder: %w", err)
-	}
-
-	if result.IsSuccess != "ok" {
-		return nil, fmt.Errorf("Forwarder API error: %s", result.Msg)
+	// Rencana lama dikosongkan jika gagal disusun agar tidak menyesatkan
+	if err := s.pengirimanRepo.UpdateFields(ctx, shipment.ID, map[string]interface{}{"rencana_muat": plan}); err != nil {
+		return "", err
 	}
-	if result.Data.BookingNo == "" {
-		return nil, fmt.Errorf("Forwarder tidak mengembalikan booking number")
+	shipment.RencanaMuat = plan
+	if errors.Is(planErr, ErrMuatanMelebihiKendaraan) {
+		return "", planErr
 	}
-
-	return &result.Data.BookingNo, nil
-}
-
-// ─── Forwarder LCL (Sea Freight / Luar Jawa) ──────────────────────────────────
-
-type forwarderBookingDetail struct {
-	Qty             string  `json:"qty"`
-	ContainerTypeID string  `json:"containertypeid"`
-	PackageID       string  `json:"packageid"`
-	Length          float64 `json:"length"`
-	Width           float64 `json:"width"`
-	Height          float64 `json:"height"`
-	Volume          float64 `json:"volume"`
-	Weight          string  `json:"weight"`
-	CargoID         string  `json:"cargoid"`
-	CargoDesc       string  `json:"cargodesc"`
-}
-
-type forwarderCreateBookingLCLRequest struct {
-	TransportID              string                   `json:"transportid"`
-	MoveTypeID               string                   `json:"movetypeid"`
-	LoadTypeID               string                   `json:"loadtypeid"`
-	ServiceTypeID            string                   `json:"servicetypeid"`
-	OriginCityID             string                   `json:"origincityid"`
-	DestinationCityID        string                   `json:"destinationcityid"`
-	DestinationSubdistrictID string                   `json:"destinationsubdistrictid"`
-	LCLBasisID               string                   `json:"lclbasisid"`
-	CargoReadyDate           string                   `json:"cargoreadydate"`
-	Shipper                  string                   `json:"shipper"`
-	ShipperAddress           string                   `json:"shipperaddress"`
-	ShipperLat               string                   `json:"shipperlat"`
-	ShipperLng               string                   `json:"shipperlng"`
-	ShipperCountry           string                   `json:"shippercountry"`
-	ShipperProvince          string                   `json:"shipperprovince"`
-	ShipperCity              string                   `json:"shippercity"`
-	ShipperPostalCode        string                   `json:"shipperpostalcode"`
-	ShipperRemark            string                   `json:"shipperremark"`
-	Consignee                string                   `json:"consignee"`
-	ConsigneeAddress         string                   `json:"consigneeaddress"`
-	ConsigneeLat             string                   `json:"consigneelat"`
-	ConsigneeLng             string                   `json:"consigneelng"`
-	ConsigneeCountry         string                   `json:"consigneecountry"`
-	ConsigneeProvince        string                   `json:"consigneeprovince"`
-	ConsigneeCity            string                   `json:"consigneecity"`
-	ConsigneePostalCode      string                   `json:"consigneepostalcode"`
-	ConsigneeRemark          string                   `json:"consigneeremark"`
-	Pickup                   string                   `json:"pickup"`
-	PickupAddress            string                   `json:"pickupaddress"`
-	PickupLat                string                   `json:"pickuplat"`
-	PickupLng                string                   `json:"pickuplng"`
-	PickupCountry            string                   `json:"pickupcountry"`
-	PickupProvince           string                   `json:"pickupprovince"`
-	PickupCity               string                   `json:"pickupcity"`
-	PickupPostalCode         string                   `json:"pickuppostalcode"`
-	PickupPhone              string                   `json:"pickupphone"`
-	PickupRemark             string                   `json:"pickupremark"`
-	Delivery                 string                   `json:"delivery"`
-	DeliveryAddress          string                   `json:"deliveryaddress"`
-	DeliveryLat              string                   `json:"deliverylat"`
-	DeliveryLng              string                   `json:"deliverylng"`
-	DeliveryCountry          string                   `json:"deliverycountry"`
-	DeliveryProvince         string                   `json:"deliveryprovince"`
-	DeliveryCity             string                   `json:"deliverycity"`
-	DeliveryPostalCode       string                   `json:"deliverypostalcode"`
-	DeliveryPhone            string                   `json:"deliveryphone"`
-	DeliveryRemark           string                   `json:"deliveryremark"`
-	VoucherCode              string                   `json:"vouchercode"`
-	CurrencyID               string                   `json:"currencyid"`
-	Incoterm                 string                   `json:"incoterm"`
-	WithInsurance            string                   `json:"withinsurance"`
-	CommodityAmount          string                   `json:"commodityamount,omitempty"`
-	InsuranceID              string                   `json:"insuranceid,omitempty"`
-	PremiAmount              string                   `json:"premiamount,omitempty"`
-	BookingDetail            []forwarderBookingDetail `json:"bookingdetail"`
+	req.RencanaMuat = plan
+	return provider.Book(ctx, req)
 }
 
-func (s *shippingService) bookForwarderLCL(ctx context.Context, pesanan *models.Pesanan) (*string, error) {
-	apiURL := os.Getenv("FORWARDER_API_URL")
-	clientName := os.Getenv("FORWARDER_CLIENT_NAME")
-	username := os.Getenv("FORWARDER_USERNAME")
-	password := os.Getenv("FORWARDER_PASSWORD")
-	if apiURL == "" || clientName == "" || username == "" || password == "" {
-		return nil, fmt.Errorf("konfigurasi Forwarder tidak lengkap")
-	}
-
-	token, err := s.getForwarderToken(ctx, apiURL, clientName, username, password, "CREATEBOOKING")
-	if err != nil {
-		return nil, fmt.Errorf("gagal mendapatkan token Forwarder: %w", err)
-	}
-
-	warehouse, err := s.getActiveWarehouse()
+// planShipments mengelompokkan item pesanan per gudang produk dan memastikan
+// setiap gudang punya satu pengiriman. Pengiriman yang sudah ada (mis. dari
+// percobaan booking sebelumnya) dipakai ulang.
+func (s *shippingService) planShipments(ctx context.Context, pesanan *models.Pesanan, deliveryType models.DeliveryType) ([]models.PesananPengiriman, error) {
+	existing, err := s.pengirimanRepo.FindByPesananID(ctx, pesanan.ID)
 	if err != nil {
-		return nil, fmt.Errorf("gagal mendapatkan data warehouse: %w", err)
-	}
-
-	if pesanan.AlamatBuyer == nil {
-		return nil, fmt.Errorf("pesanan tidak memiliki alamat pengiriman")
-	}
-	alamat := pesanan.AlamatBuyer
-
-	if warehouse.Kota == nil || *warehouse.Kota == "" {
-		return nil, fmt.Errorf("warehouse tidak memiliki data kota")
-	}
-	var originMapping models.ForwarderCityMapping
-	if err := s.db.Where("kota_pattern = ?", utils.NormalizeKota(*warehouse.Kota)).First(&originMapping).Error; err != nil {
-		return nil, fmt.Errorf("kota asal warehouse (%s) tidak ditemukan di Forwarder mapping", *warehouse.Kota)
-	}
-
-	var destMapping models.ForwarderCityMapping
-	if err := s.db.Where("kota_pattern = ?", utils.NormalizeKota(alamat.Kota)).First(&destMapping).Error; err != nil {
-		return nil, fmt.Errorf("kota tujuan tidak ditemukan di Forwarder mapping. Silakan tambahkan mapping untuk kota: %s", alamat.Kota)
+		return nil, err
 	}
-
-	subdistrictID := "0"
-	if alamat.Kecamatan != nil && *alamat.Kecamatan != "" {
-		subdistrictID = s.resolveForwarderSubdistrictID(ctx, *alamat.Kecamatan, destMapping.ForwarderCityID, apiURL, clientName, username, password)
+	byWarehouse := make(map[uuid.UUID]uuid.UUID, len(existing))
+	for _, shipment := range existing {
+		byWarehouse[shipment.WarehouseID] = shipment.ID
 	}
 
-	bookingDetail := make([]forwarderBookingDetail, 0, len(pesanan.Items))
+	// Item yang belum masuk pengiriman dikelompokkan per gudang, urut sesuai item
+	var order []uuid.UUID
+	groups := map[uuid.UUID][]uuid.UUID{}
+	var fallback *models.Warehouse
 	for _, item := range pesanan.Items {
-		p := item.Produk
-		vol := (p.Panjang * p.Lebar * p.Tinggi) / 1_000_000
-		bookingDetail = append(bookingDetail, forwarderBookingDetail{
-			Qty:             strconv.Itoa(item.Qty),
-			ContainerTypeID: "63", // LCL tidak pakai container type spesifik (Less than Container Load)
-			PackageID:       "7",
-			Length:          p.Panjang,
-			Width:           p.Lebar,
-			Height:          p.Tinggi,
-			Volume:          vol * float64(item.Qty),
-			Weight:          strconv.FormatFloat(p.Berat*float64(item.Qty), 'f', 0, 64),
-			CargoID:         "78",
-			CargoDesc:       item.NamaProduk,
-		})
-	}
-
-	warehouseAlamat := ""
-	if warehouse.Alamat != nil {
-		warehouseAlamat = *warehouse.Alamat
-	}
-	warehouseTelepon := ""
-	if warehouse.Telepon != nil {
-		warehouseTelepon = *warehouse.Telepon
-	}
-	warehouseKodePos := ""
-	if warehouse.KodePos != nil {
-		warehouseKodePos = *warehouse.KodePos
-	}
-	warehouseLat := "0"
-	if warehouse.Latitude != nil {
-		warehouseLat = strconv.FormatFloat(*warehouse.Latitude, 'f', 8, 64)
-	}
-	warehouseLng := "0"
-	if warehouse.Longitude != nil {
-		warehouseLng = strconv.FormatFloat(*warehouse.Longitude, 'f', 8, 64)
-	}
-	buyerLat := "0"
-	if alamat.Latitude != nil {
-		buyerLat = strconv.FormatFloat(*alamat.Latitude, 'f', 8, 64)
-	}
-	buyerLng := "0"
-	if alamat.Longitude != nil {
-		buyerLng = strconv.FormatFloat(*alamat.Longitude, 'f', 8, 64)
-	}
-	buyerKodePos := ""
-	if alamat.KodePos != nil {
-		buyerKodePos = *alamat.KodePos
-	}
-
-	withInsurance := "0"
-	commodityAmount := ""
-	insuranceID := ""
-	premiAmount := ""
-	if pesanan.BiayaLainnya.IsPositive() {
-		withInsurance = "1"
-		commodityAmount = pesanan.BiayaProduk.StringFixed(0)
-		insuranceID = "1"
-		premiAmount = pesanan.BiayaLainnya.StringFixed(0)
-	}
-
-	catatanAlamat := "-"
-	if alamat.Catatan != nil && *alamat.Catatan != "" {
-		catatanAlamat = *alamat.Catatan
-	}
-
-	bookingReq := forwarderCreateBookingLCLRequest{
-		TransportID:              "1",
-		MoveTypeID:               "1",
-		LoadTypeID:               "2",
-		ServiceTypeID:            "1",
-		OriginCityID:             "13", // Jakarta — pelabuhan terdekat dari gudang Bogor untuk LCL
-		DestinationCityID:        strconv.Itoa(destMapping.ForwarderCityID),
-		DestinationSubdistrictID: subdistrictID,
-		LCLBasisID:               "1",
-		CargoReadyDate:           "",
-		Shipper:                  "Liquid8 | Bulky.id",
-		ShipperAddress:           warehouseAlamat,
-		ShipperLat:               warehouseLat,
-		ShipperLng:               warehouseLng,
-		ShipperCountry:           "Indonesia",
-		ShipperProvince:          "Jawa Barat",
-		ShipperCity:              *warehouse.Kota,
-		ShipperPostalCode:        warehouseKodePos,
-		ShipperRemark:            pesanan.Kode,
-		Consignee:                alamat.NamaPenerima,
-		ConsigneeAddress:         buildFullAddress(alamat),
-		ConsigneeLat:             buyerLat,
-		ConsigneeLng:             buyerLng,
-		ConsigneeCountry:         "Indonesia",
-		ConsigneeProvince:        alamat.Provinsi,
-		ConsigneeCity:            alamat.Kota,
-		ConsigneePostalCode:      buyerKodePos,
-		ConsigneeRemark:          catatanAlamat,
-		Pickup:                   "Liquid8 | Bulky.id",
-		PickupAddress:            warehouseAlamat,
-		PickupLat:                warehouseLat,
-		PickupLng:                warehouseLng,
-		PickupCountry:            "Indonesia",
-		PickupProvince:           "Jawa Barat",
-		PickupCity:               *warehouse.Kota,
-		PickupPostalCode:         warehouseKodePos,
-		PickupPhone:              warehouseTelepon,
-		PickupRemark:             pesanan.Kode,
-		Delivery:                 alamat.NamaPenerima,
-		DeliveryAddress:          buildFullAddress(alamat),
-		DeliveryLat:              buyerLat,
-		DeliveryLng:              buyerLng,
-		DeliveryCountry:          "Indonesia",
-		DeliveryProvince:         alamat.Provinsi,
-		DeliveryCity:             alamat.Kota,
-		DeliveryPostalCode:       buyerKodePos,
-		DeliveryPhone:            alamat.TeleponPenerima,
-		DeliveryRemark:           catatanAlamat,
-		VoucherCode:              "",
-		CurrencyID:               "1",
-		Incoterm:                 "",
-		WithInsurance:            withInsurance,
-		CommodityAmount:          commodityAmount,
-		InsuranceID:              insuranceID,
-		PremiAmount:              premiAmount,
-		BookingDetail:            bookingDetail,
-	}
-
-	body, err := json.Marshal(bookingReq)
-	if err != nil {
-		return nil, fmt.Errorf("gagal membuat request body: %w", err)
-	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+"/createbooking", bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("gagal membuat HTTP request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+token)
-	httpReq.Header.Set("Client_name", clientName)
-
-	log.Printf("[forwarder] --> POST /createbooking pesanan=%s transport=%s load=%s origin_city=%s dest_city=%s consignee=%s body=%s",
-		pesanan.Kode, bookingReq.TransportID, bookingReq.LoadTypeID,
-		bookingReq.OriginCityID, bookingReq.DestinationCityID, bookingReq.Consignee, string(body))
-
-	resp, err := http.DefaultClient.Do(httpReq)
-	if err != nil {
-		log.Printf("[forwarder] <-- POST /createbooking pesanan=%s error=%v", pesanan.Kode, err)
-		return nil, fmt.Errorf("connection timeout atau gagal menghubungi Forwarder: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-	log.Printf("[forwarder] <-- POST /createbooking pesanan=%s status=%d body=%s", pesanan.Kode, resp.StatusCode, string(respBody))
-
-	var result forwarderBookingResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("gagal parse response Forwarder: %w", err)
-	}
-
-	if result.IsSuccess != "ok" {
-		return nil, fmt.Errorf("Forwarder API error: %s", result.Msg)
-	}
-	if result.Data.BookingNo == "" {
-		return nil, fmt.Errorf("Forwarder tidak mengembalikan booking number")
-	}
-
-	return &result.Data.BookingNo, nil
-}
-
-// resolveForwarderSubdistrictID mencari subdistrict ID dengan 3 langkah fallback:
-// 1. DB lookup by kecamatan_pattern + forwarder_city_id
-// 2. DB lookup by kecamatan_pattern saja (any city)
-// 3. API call ke /subdistrictlist
-func (s *shippingService) resolveForwarderSubdistrictID(ctx context.Context, kecamatan string, forwarderCityID int, apiURL, clientName, username, password string) string {
-	normalized := utils.NormalizeKecamatan(kecamatan)
-	if normalized == "" {
-		return "0"
-	}
-
-	// Step 1: match by kecamatan + city_id
-	var m models.ForwarderSubdistrictMapping
-	if err := s.db.Where("kecamatan_pattern = ? AND forwarder_city_id = ?", normalized, forwarderCityID).First(&m).Error; err == nil {
-		return strconv.Itoa(m.ForwarderSubdistrictID)
-	}
-
-	// Step 2: match by kecamatan saja — hanya pakai kalau hasilnya tunggal
-	var all []models.ForwarderSubdistrictMapping
-	if err := s.db.Where("kecamatan_pattern = ?", normalized).Find(&all).Error; err == nil && len(all) == 1 {
-		return strconv.Itoa(all[0].ForwarderSubdistrictID)
-	}
-
-	// Step 3: API call ke /subdistrictlist
-	token, err := s.getForwarderToken(ctx, apiURL, clientName, username, password, "SUBDISTRICTLIST")
-	if err != nil {
-		log.Printf("[forwarder] resolveSubdistrict: gagal get token: %v", err)
-		return "0"
-	}
-
-	reqBody, _ := json.Marshal(map[string]string{
-		"subdistrict_name": strings.ToUpper(normalized),
-		"city_id":          strconv.Itoa(forwarderCityID),
+		if item.PesananPengirimanID != nil {
+			continue
+		}
+		warehouseID := item.Produk.WarehouseID
+		if warehouseID == uuid.Nil {
+			// Produk sudah dihapus: pakai gudang aktif tertua seperti perilaku lama
+			if fallback == nil {
+				if fallback, err = findActiveWarehouse(s.db); err != nil {
+					return nil, fmt.Errorf("gagal mendapatkan data warehouse: %w", err)
+				}
+			}
+			warehouseID = fallback.ID
+		}
+		if _, ok := groups[warehouseID]; !ok {
+			order = append(order, warehouseID)
+		}
+		groups[warehouseID] = append(groups[warehouseID], item.ID)
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := s.pengirimanRepo.WithTx(tx)
+		urutan := len(existing)
+		for _, warehouseID := range order {
+			shipmentID, ok := byWarehouse[warehouseID]
+			if !ok {
+				urutan++
+				shipment := &models.PesananPengiriman{
+					PesananID:    pesanan.ID,
+					WarehouseID:  warehouseID,
+					Urutan:       urutan,
+					DeliveryType: deliveryType,
+				}
+				if err := repo.Create(ctx, shipment); err != nil {
+					return err
+				}
+				shipmentID = shipment.ID
+			}
+			if err := repo.AssignItems(ctx, shipmentID, groups[warehouseID]); err != nil {
+				return err
+			}
+		}
+		// Pengiriman yang belum ter-booking mengikuti hasil routing terbaru (LTL/LCL)
+		return tx.Model(&models.PesananPengiriman{}).
+			Where("pesanan_id = ? AND booking_ref IS NULL", pesanan.ID).
+			Update("delivery_type", deliveryType).Error
 	})
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+"/subdistrictlist", bytes.NewReader(reqBody))
-	if err != nil {
-		return "0"
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+token)
-	httpReq.Header.Set("Client_name", clientName)
-
-	resp, err := http.DefaultClient.Do(httpReq)
 	if err != nil {
-		log.Printf("[forwarder] resolveSubdistrict: API error: %v", err)
-		return "0"
-	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-
-	var result struct {
-		IsSuccess string `json:"isSuccess"`
-		Data      []struct {
-			ItemID   int    `json:"item_id"`
-			ItemName string `json:"item_name"`
-		} `json:"data"`
-	}
-	if err := json.Unmarshal(respBody, &result); err != nil || result.IsSuccess != "ok" || len(result.Data) == 0 {
-		log.Printf("[forwarder] resolveSubdistrict: tidak ditemukan via API untuk kecamatan=%s city_id=%d body=%s", normalized, forwarderCityID, string(respBody))
-		return "0"
+		return nil, err
 	}
+	return s.pengirimanRepo.FindByPesananID(ctx, pesanan.ID)
+}
 
-	// Simpan ke DB agar lookup berikutnya tidak perlu API call
-	s.db.Create(&models.ForwarderSubdistrictMapping{
-		KecamatanPattern:         normalized,
-		ForwarderCityID:          forwarderCityID,
-		ForwarderSubdistrictID:   result.Data[0].ItemID,
-		ForwarderSubdistrictName: result.Data[0].ItemName,
+func (s *shippingService) ResetBookings(ctx context.Context, pesananID uuid.UUID) (int64, error) {
+	// Pengiriman yang sudah dibawa driver/selesai tidak boleh di-booking ulang
+	return s.pengirimanRepo.ClearBookings(ctx, pesananID, []string{
+		string(ProviderWebhookStatusDeliveryInProgress),
+		string(ProviderWebhookStatusDeliveryCompleted),
 	})
-
-	return strconv.Itoa(result.Data[0].ItemID)
 }
 
-func (s *shippingService) getForwarderToken(ctx context.Context, apiURL, clientName, username, password, scope string) (string, error) {
-	tokenReq := forwarderTokenRequest{Scope: scope}
-	body, _ := json.Marshal(tokenReq)
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+"/accesstoken", bytes.NewReader(body))
-	if err != nil {
-		return "", err
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("client_name", clientName)
-	httpReq.Header.Set("username", username)
-	httpReq.Header.Set("password", password)
-
-	resp, err := http.DefaultClient.Do(httpReq)
-	if err != nil {
-		return "", fmt.Errorf("connection timeout: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-	log.Printf("[forwarder] <-- POST /accesstoken scope=%s status=%d body=%s", scope, resp.StatusCode, string(respBody))
-	var result forwarderTokenResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return "", fmt.Errorf("gagal parse token response: %w", err)
+// shipmentReference adalah nomor referensi booking di provider. Pesanan satu
+// gudang memakai kode pesanan apa adanya seperti sebelumnya.
+func shipmentReference(pesanan *models.Pesanan, shipment *models.PesananPengiriman, total int) string {
+	if total <= 1 {
+		return pesanan.Kode
 	}
-	if result.Status != "ok" || result.AccessToken == "" {
-		return "", fmt.Errorf("gagal mendapatkan token: %s", result.Message)
-	}
-	return result.AccessToken, nil
-}
-
-// ─── Forwarder Invoice ────────────────────────────────────────────────────────
-
-type ForwarderInvoiceDetail struct {
-	FreightElementName string `json:"freight_element_name"`
-	BasisName          string `json:"basis_name"`
-	TotalIDR           string `json:"total_idr"`
-	Amount             string `json:"amount"`
-	Total              string `json:"total"`
-	Subtotal           string `json:"subtotal"`
-	InvoiceNo          string `json:"invoice_no"`
-	Qty                string `json:"qty"`
-	ContainerType      string `json:"container_type"`
-	Currency           string `json:"currency"`
-	Tax                string `json:"tax"`
-	Remark             string `json:"remark"`
-}
-
-type ForwarderInvoice struct {
-	BookingNo          string                   `json:"booking_no"`
-	InvoiceNo          string                   `json:"invoice_no"`
-	DueDate            string                   `json:"due_date"`
-	InvoiceID          string                   `json:"invoice_id"`
-	Currency           string                   `json:"currency"`
-	Remark             string                   `json:"remark"`
-	CreateDate         string                   `json:"create_date"`
-	DownloadInvoiceURL string                   `json:"download_invoice_url"`
-	DataDetail         []ForwarderInvoiceDetail `json:"data_detail"`
-	InvoiceDate        string                   `json:"invoice_date"`
-	QuotationNo        string                   `json:"quotation_no"`
-	Status             string                   `json:"status"`
+	return fmt.Sprintf("%s-%d", pesanan.Kode, shipment.Urutan)
 }
 
-type forwarderInvoiceListRequest struct {
-	UserName  string `json:"user_name"`
-	BookingNo string `json:"booking_no"`
-	InvoiceNo string `json:"invoice_no"`
-}
-
-type forwarderInvoiceListResponse struct {
-	Msg       string             `json:"msg"`
-	Data      []ForwarderInvoice `json:"data"`
-	IsSuccess string             `json:"isSuccess"`
-}
-
-func (s *shippingService) GetForwarderInvoice(ctx context.Context, bookingNo string) ([]ForwarderInvoice, error) {
-	apiURL := os.Getenv("FORWARDER_API_URL")
-	clientName := os.Getenv("FORWARDER_CLIENT_NAME")
-	username := os.Getenv("FORWARDER_USERNAME")
-	password := os.Getenv("FORWARDER_PASSWORD")
-	if apiURL == "" || clientName == "" || username == "" || password == "" {
-		return nil, fmt.Errorf("konfigurasi Forwarder tidak lengkap")
-	}
-
-	token, err := s.getForwarderToken(ctx, apiURL, clientName, username, password, "INVOICELIST")
-	if err != nil {
-		return nil, fmt.Errorf("gagal mendapatkan token Forwarder: %w", err)
-	}
-
-	reqBody := forwarderInvoiceListRequest{
-		// UserName:  clientName,
-		BookingNo: bookingNo,
-		InvoiceNo: "",
-	}
-	body, _ := json.Marshal(reqBody)
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+"/invoicelist", bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("gagal membuat HTTP request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+token)
-	httpReq.Header.Set("Client_name", clientName)
-
-	log.Printf("[forwarder] --> POST /invoicelist booking_no=%s", bookingNo)
-
-	resp, err := http.DefaultClient.Do(httpReq)
-	if err != nil {
-		log.Printf("[forwarder] <-- POST /invoicelist booking_no=%s error=%v", bookingNo, err)
-		return nil, fmt.Errorf("connection timeout atau gagal menghubungi Forwarder: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-	log.Printf("[forwarder] <-- POST /invoicelist booking_no=%s status=%d body=%s", bookingNo, resp.StatusCode, string(respBody))
-
-	var result forwarderInvoiceListResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("gagal parse response Forwarder: %w", err)
-	}
-	if result.IsSuccess != "ok" {
-		return nil, fmt.Errorf("Forwarder API error: %s", result.Msg)
+// findActiveWarehouse mengembalikan gudang aktif tertua, dipakai sebagai gudang
+// asal item yang produknya sudah tidak ada
+func findActiveWarehouse(db *gorm.DB) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	if err := db.Where("is_active = true").Order("created_at ASC").First(&warehouse).Error; err != nil {
+		return nil, err
 	}
-
-	return result.Data, nil
+	return &warehouse, nil
 }
 
-// ─── Tracking ─────────────────────────────────────────────────────────────────
+// routeDelivery menentukan delivery type efektif berdasarkan zona coverage
+// (shipping_coverage_zone).
+// Pesanan Forwarder dialihkan ke LTL (darat) atau LCL (laut) dan delivery_type
+// langsung diperbarui agar UI reflect routing yang benar, termasuk saat booking
+// gagal dan admin melihat status di panel.
+func (s *shippingService) routeDelivery(ctx context.Context, pesanan *models.Pesanan) (models.DeliveryType, error) {
+	provinsi := ""
+	var coverage *models.ShippingCoverageResult
+	if pesanan.AlamatBuyer != nil {
+		provinsi = pesanan.AlamatBuyer.Provinsi
+		result, err := s.coverage.Resolve(ctx, pesanan.AlamatBuyer)
+		if err != nil {
+			return "", fmt.Errorf("gagal cek coverage pengiriman: %w", err)
+		}
+		coverage = result
+	}
 
-func (s *shippingService) TrackDelivery(ctx context.Context, pesanan *models.Pesanan) (*TrackingResult, error) {
 	switch pesanan.DeliveryType {
 	case models.DeliveryTypeDeliveree:
-		return s.trackDeliveree(ctx, pesanan)
+		if coverage == nil || !coverage.Deliveree.Covered {
+			return "", fmt.Errorf("Deliveree tidak tersedia untuk provinsi %q (di luar zona coverage Deliveree)", provinsi)
+		}
+		return models.DeliveryTypeDeliveree, nil
 	case models.DeliveryTypeForwarder, models.DeliveryTypeForwarderLCL:
-		return s.trackForwarder(ctx, pesanan)
+		target := models.DeliveryTypeForwarderLCL
+		if coverage != nil && coverage.Forwarder.Covered {
+			target = models.DeliveryTypeForwarder
+			log.Printf("[shipping] routing LTL pesanan=%s provinsi=%s kota=%s (zona %s)", pesanan.Kode, provinsi, pesanan.AlamatBuyer.Kota, coverage.Forwarder.Zone.ID)
+		} else {
+			log.Printf("[shipping] routing LCL pesanan=%s provinsi=%s (luar LTL coverage)", pesanan.Kode, provinsi)
+		}
+		if pesanan.DeliveryType != target {
+			s.db.Model(&models.Pesanan{}).Where("id = ?", pesanan.ID).Update("delivery_type", target)
+		}
+		return target, nil
 	default:
-		return nil, fmt.Errorf("delivery type %s tidak mendukung tracking", pesanan.DeliveryType)
+		return "", fmt.Errorf("delivery type %s tidak memerlukan booking", pesanan.DeliveryType)
 	}
 }
 
-func (s *shippingService) trackDeliveree(ctx context.Context, pesanan *models.Pesanan) (*TrackingResult, error) {
-	baseURL := os.Getenv("DELIVEREE_BASE_URL")
-	apiKey := os.Getenv("DELIVEREE_API_KEY")
-	if baseURL == "" || apiKey == "" {
-		return nil, fmt.Errorf("konfigurasi Deliveree tidak lengkap")
-	}
-	if pesanan.DelivereeBookingID == nil {
-		return nil, fmt.Errorf("pesanan belum memiliki Deliveree booking ID")
-	}
-
-	url := baseURL + "/deliveries/" + *pesanan.DelivereeBookingID
-	log.Printf("[deliveree] --> GET /deliveries/%s pesanan=%s", *pesanan.DelivereeBookingID, pesanan.Kode)
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("gagal membuat HTTP request: %w", err)
-	}
-	httpReq.Header.Set("Authorization", apiKey)
-
-	resp, err := http.DefaultClient.Do(httpReq)
-	if err != nil {
-		log.Printf("[deliveree] <-- GET /deliveries/%s pesanan=%s error=%v", *pesanan.DelivereeBookingID, pesanan.Kode, err)
-		return nil, fmt.Errorf("gagal menghubungi Deliveree: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-	log.Printf("[deliveree] <-- GET /deliveries/%s pesanan=%s status=%d body=%s", *pesanan.DelivereeBookingID, pesanan.Kode, resp.StatusCode, string(respBody))
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Deliveree tracking error (status %d): %s", resp.StatusCode, string(respBody))
-	}
-
-	var data struct {
-		Status      string `json:"status"`
-		TrackingURL string `json:"tracking_url"`
-		Locations   []struct {
-			Name           string `json:"name"`
-			DeliveryStatus string `json:"delivery_status"`
-		} `json:"locations"`
-	}
-	if err := json.Unmarshal(respBody, &data); err != nil {
-		return nil, fmt.Errorf("gagal parse response Deliveree: %w", err)
-	}
-
-	history := make([]TrackingEvent, 0, len(data.Locations))
-	for _, loc := range data.Locations {
-		history = append(history, TrackingEvent{
-			Status: loc.DeliveryStatus + " - " + loc.Name,
-		})
-	}
+// ─── Tracking ─────────────────────────────────────────────────────────────────
 
-	return &TrackingResult{
-		Provider:    "DELIVEREE",
-		BookingRef:  *pesanan.DelivereeBookingID,
-		Status:      data.Status,
-		TrackingURL: data.TrackingURL,
-		History:     history,
-	}, nil
+func (s *shippingService) Shipments(ctx context.Context, pesananID uuid.UUID) ([]models.PesananPengiriman, error) {
+	return s.pengirimanRepo.FindByPesananID(ctx, pesananID)
 }
 
-func (s *shippingService) GetDelivereeDetail(ctx context.Context, pesanan *models.Pesanan) (*DelivereeDeliveryDetail, error) {
-	baseURL := os.Getenv("DELIVEREE_BASE_URL")
-	apiKey := os.Getenv("DELIVEREE_API_KEY")
-	if baseURL == "" || apiKey == "" {
-		return nil, fmt.Errorf("konfigurasi Deliveree tidak lengkap")
-	}
-	if pesanan.DelivereeBookingID == nil {
-		return nil, fmt.Errorf("pesanan belum memiliki Deliveree booking ID")
-	}
-
-	url := baseURL + "/deliveries/" + *pesanan.DelivereeBookingID
-	log.Printf("[deliveree] --> GET /deliveries/%s (detail) pesanan=%s", *pesanan.DelivereeBookingID, pesanan.Kode)
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+func (s *shippingService) TrackDelivery(ctx context.Context, pesanan *models.Pesanan) (*PesananTracking, error) {
+	shipments, err := s.pengirimanRepo.FindByPesananID(ctx, pesanan.ID)
 	if err != nil {
-		return nil, fmt.Errorf("gagal membuat HTTP request: %w", err)
+		return nil, err
 	}
-	httpReq.Header.Set("Authorization", apiKey)
 
-	resp, err := http.DefaultClient.Do(httpReq)
-	if err != nil {
-		log.Printf("[deliveree] <-- GET /deliveries/%s (detail) pesanan=%s error=%v", *pesanan.DelivereeBookingID, pesanan.Kode, err)
-		return nil, fmt.Errorf("gagal menghubungi Deliveree: %w", err)
+	result := &PesananTracking{PesananID: pesanan.ID, Kode: pesanan.Kode, Shipments: []ShipmentTracking{}}
+	var lastErr error
+	available := 0
+	for i := range shipments {
+		shipment := &shipments[i]
+		if !shipment.IsBooked() {
+			continue
+		}
+		tracking := ShipmentTracking{
+			PengirimanID:  shipment.ID,
+			Urutan:        shipment.Urutan,
+			WarehouseID:   shipment.WarehouseID,
+			WarehouseNama: shipment.Warehouse.Nama,
+			DeliveryType:  string(shipment.DeliveryType),
+			BookingRef:    *shipment.BookingRef,
+			BookingStatus: shipment.BookingStatus,
+		}
+		live, _, err := s.fetchTracking(ctx, shipment)
+		if err != nil {
+			// Provider gagal tidak menyembunyikan timeline tersimpan maupun
+			// tracking pengiriman lain
+			lastErr = err
+			tracking.Error = err.Error()
+		} else {
+			tracking.Live = true
+		}
+		tracking.Tracking, err = s.storedTracking(ctx, shipment, live)
+		if err != nil {
+			return nil, err
+		}
+		if tracking.Tracking != nil {
+			available++
+		}
+		result.Shipments = append(result.Shipments, tracking)
 	}
-	defer resp.Body.Close()
 
-	respBody, _ := io.ReadAll(resp.Body)
-	log.Printf("[deliveree] <-- GET /deliveries/%s (detail) pesanan=%s status=%d", *pesanan.DelivereeBookingID, pesanan.Kode, resp.StatusCode)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Deliveree API error (status %d): %s", resp.StatusCode, string(respBody))
+	if len(result.Shipments) == 0 {
+		return nil, fmt.Errorf("pesanan belum memiliki booking pengiriman")
 	}
-
-	var detail DelivereeDeliveryDetail
-	if err := json.Unmarshal(respBody, &detail); err != nil {
-		return nil, fmt.Errorf("gagal parse response Deliveree: %w", err)
+	if available == 0 {
+		return nil, lastErr
 	}
-
-	return &detail, nil
+	return result, nil
 }
 
-func (s *shippingService) trackForwarder(ctx context.Context, pesanan *models.Pesanan) (*TrackingResult, error) {
-	apiURL := os.Getenv("FORWARDER_API_URL")
-	clientName := os.Getenv("FORWARDER_CLIENT_NAME")
-	username := os.Getenv("FORWARDER_USERNAME")
-	password := os.Getenv("FORWARDER_PASSWORD")
-	if apiURL == "" || clientName == "" || username == "" || password == "" {
-		return nil, fmt.Errorf("konfigurasi Forwarder tidak lengkap")
-	}
-	if pesanan.ForwarderTrackingNo == nil {
-		return nil, fmt.Errorf("pesanan belum memiliki Forwarder tracking number")
-	}
-
-	token, err := s.getForwarderToken(ctx, apiURL, clientName, username, password, "TRACKANDTRACE")
+// GetDelivereeDetail mengambil detail booking Deliveree untuk satu pengiriman.
+// pengirimanID nil berarti pengiriman Deliveree pertama yang sudah ter-booking.
+func (s *shippingService) GetDelivereeDetail(ctx context.Context, pesanan *models.Pesanan, pengirimanID *uuid.UUID) (*DelivereeDeliveryDetail, error) {
+	shipments, err := s.pengirimanRepo.FindByPesananID(ctx, pesanan.ID)
 	if err != nil {
-		return nil, fmt.Errorf("gagal mendapatkan token Forwarder: %w", err)
+		return nil, err
 	}
-
-	reqBody, _ := json.Marshal(map[string]string{
-		"ref_cust_id": "",
-		"booking_no":  *pesanan.ForwarderTrackingNo,
-	})
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+"/trackandtrace", bytes.NewReader(reqBody))
-	if err != nil {
-		return nil, fmt.Errorf("gagal membuat HTTP request: %w", err)
+	var ref string
+	for _, shipment := range shipments {
+		if pengirimanID != nil && shipment.ID != *pengirimanID {
+			continue
+		}
+		if shipment.DeliveryType == models.DeliveryTypeDeliveree && shipment.IsBooked() {
+			ref = *shipment.BookingRef
+			break
+		}
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+token)
-	httpReq.Header.Set("Client_name", clientName)
-
-	log.Printf("[forwarder] --> POST /trackandtrace pesanan=%s booking_no=%s", pesanan.Kode, *pesanan.ForwarderTrackingNo)
-
-	resp, err := http.DefaultClient.Do(httpReq)
-	if err != nil {
-		log.Printf("[forwarder] <-- POST /trackandtrace pesanan=%s error=%v", pesanan.Kode, err)
-		return nil, fmt.Errorf("gagal menghubungi Forwarder: %w", err)
+	if ref == "" {
+		return nil, fmt.Errorf("pengiriman belum memiliki Deliveree booking ID")
 	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-	log.Printf("[forwarder] <-- POST /trackandtrace pesanan=%s status=%d body=%s", pesanan.Kode, resp.StatusCode, string(respBody))
 
-	var result struct {
-		Msg       string `json:"msg"`
-		IsSuccess string `json:"isSuccess"`
-		Data      []struct {
-			BookingNumber string `json:"booking_number"`
-			Status        []struct {
-				StatusDate string `json:"status_date"`
-				StatusName string `json:"status_name"`
-				StatusTime string `json:"status_time"`
-			} `json:"status"`
-		} `json:"data"`
-	}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("gagal parse response Forwarder: %w", err)
-	}
-	if result.IsSuccess != "ok" {
-		return nil, fmt.Errorf("Forwarder tracking error: %s", result.Msg)
+	provider, err := s.Provider(models.DeliveryTypeDeliveree)
+	if err != nil {
+		return nil, err
 	}
-
-	history := []TrackingEvent{}
-	currentStatus := ""
-	if len(result.Data) > 0 {
-		for _, s := range result.Data[0].Status {
-			history = append(history, TrackingEvent{
-				Date:   s.StatusDate,
-				Time:   s.StatusTime,
-				Status: s.StatusName,
-			})
-		}
-		if len(history) > 0 {
-			currentStatus = history[len(history)-1].Status
-		}
+	detailProvider, ok := provider.(delivereeDetailProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s tidak menyediakan detail Deliveree", provider.Code())
 	}
-
-	return &TrackingResult{
-		Provider:   "FORWARDER",
-		BookingRef: *pesanan.ForwarderTrackingNo,
-		Status:     currentStatus,
-		History:    history,
-	}, nil
+	return detailProvider.Detail(ctx, ref)
 }
 
-func (s *shippingService) getActiveWarehouse() (*models.Warehouse, error) {
-	var warehouse models.Warehouse
-	if err := s.db.Where("is_active = true").Order("created_at ASC").First(&warehouse).Error; err != nil {
+func (s *shippingService) GetForwarderInvoice(ctx context.Context, bookingNo string) ([]ForwarderInvoice, error) {
+	provider, err := s.Provider(models.DeliveryTypeForwarder)
+	if err != nil {
 		return nil, err
 	}
-	return &warehouse, nil
+	invoiceProvider, ok := provider.(forwarderInvoiceProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s tidak menyediakan invoice Forwarder", provider.Code())
+	}
+	return invoiceProvider.Invoices(ctx, bookingNo)
 }
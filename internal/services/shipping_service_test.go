@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/repositories"
+	"project-bulky-be/internal/testutil/fakedb"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func TestShipmentRequestSplitsInsuranceByWarehouse(t *testing.T) {
+	items := []models.PesananItem{
+		{Subtotal: decimal.NewFromInt(3_000_000)},
+		{Subtotal: decimal.NewFromInt(1_000_000)},
+	}
+	pesanan := &models.Pesanan{
+		Kode:         "ORD-001",
+		BiayaProduk:  decimal.NewFromInt(4_000_000),
+		BiayaLainnya: decimal.NewFromInt(40_000),
+		Items:        items,
+	}
+
+	// Pesanan satu gudang memakai nilai pesanan apa adanya
+	whole := &ShipmentRequest{Pesanan: pesanan, Items: items}
+	if !whole.NilaiBarang().Equal(pesanan.BiayaProduk) || !whole.PremiAsuransi().Equal(pesanan.BiayaLainnya) {
+		t.Fatalf("pengiriman penuh harus memakai nilai pesanan, got %s / %s", whole.NilaiBarang(), whole.PremiAsuransi())
+	}
+
+	// Pengiriman gudang kedua hanya membawa 1/4 nilai barang
+	partial := &ShipmentRequest{Pesanan: pesanan, Items: items[1:]}
+	if !partial.NilaiBarang().Equal(decimal.NewFromInt(1_000_000)) {
+		t.Fatalf("nilai barang salah: %s", partial.NilaiBarang())
+	}
+	if !partial.PremiAsuransi().Equal(decimal.NewFromInt(10_000)) {
+		t.Fatalf("premi harus proporsional, got %s", partial.PremiAsuransi())
+	}
+}
+
+func TestShipmentReference(t *testing.T) {
+	pesanan := &models.Pesanan{Kode: "ORD-001"}
+	if ref := shipmentReference(pesanan, &models.PesananPengiriman{Urutan: 1}, 1); ref != "ORD-001" {
+		t.Fatalf("pesanan satu gudang harus memakai kode pesanan, got %s", ref)
+	}
+	if ref := shipmentReference(pesanan, &models.PesananPengiriman{Urutan: 2}, 2); ref != "ORD-001-2" {
+		t.Fatalf("pengiriman kedua harus diberi akhiran urutan, got %s", ref)
+	}
+}
+
+func TestRebookDeliveryType(t *testing.T) {
+	lcl := models.DeliveryTypeForwarderLCL
+	vehicle := 3
+
+	// Jalur Forwarder bisa diganti antar LTL dan LCL
+	if got, err := rebookDeliveryType(models.DeliveryTypeForwarder, RebookShipmentOptions{DeliveryType: &lcl}); err != nil || got != lcl {
+		t.Fatalf("LTL → LCL harus boleh, got %s %v", got, err)
+	}
+
+	// Deliveree tidak bisa dipindah ke Forwarder (tarif berbeda)
+	if _, err := rebookDeliveryType(models.DeliveryTypeDeliveree, RebookShipmentOptions{DeliveryType: &lcl}); err == nil {
+		t.Fatalf("Deliveree → Forwarder harus ditolak")
+	}
+
+	// Kendaraan hanya berlaku untuk Deliveree
+	if _, err := rebookDeliveryType(models.DeliveryTypeForwarder, RebookShipmentOptions{VehicleTypeID: &vehicle}); err == nil {
+		t.Fatalf("kendaraan untuk Forwarder harus ditolak")
+	}
+	if got, err := rebookDeliveryType(models.DeliveryTypeDeliveree, RebookShipmentOptions{VehicleTypeID: &vehicle}); err != nil || got != models.DeliveryTypeDeliveree {
+		t.Fatalf("ganti kendaraan Deliveree harus boleh, got %s %v", got, err)
+	}
+}
+
+func TestShipmentCancellable(t *testing.T) {
+	inProgress := string(ProviderWebhookStatusDeliveryInProgress)
+	locating := string(ProviderWebhookStatusLocatingDriver)
+
+	if !shipmentCancellable(&models.PesananPengiriman{BookingStatus: &locating}) {
+		t.Fatalf("pengiriman yang masih mencari driver harus bisa dibatalkan")
+	}
+	if shipmentCancellable(&models.PesananPengiriman{BookingStatus: &inProgress}) {
+		t.Fatalf("pengiriman yang sudah dibawa driver tidak boleh dibatalkan")
+	}
+}
+
+// fakeCancelProvider mencatat apakah niat batal sudah tersimpan saat Cancel dipanggil
+type fakeCancelProvider struct {
+	ShippingProvider
+	err          error
+	intentBefore bool
+	fake         *fakedb.DB
+}
+
+func (p *fakeCancelProvider) Code() string { return "deliveree" }
+
+func (p *fakeCancelProvider) Cancel(context.Context, string) error {
+	for _, q := range p.fake.Queries() {
+		if _, ok := q.Set("cancel_requested_at"); ok && q.Is("UPDATE", "pesanan_pengiriman") {
+			p.intentBefore = true
+		}
+	}
+	return p.err
+}
+
+func TestCancelShipmentRecordsIntentBeforeProvider(t *testing.T) {
+	for _, providerErr := range []error{nil, errors.New("timeout")} {
+		db, fake := fakedb.Open(t, nil)
+		provider := &fakeCancelProvider{err: providerErr, fake: fake}
+		s := &shippingService{
+			db:             db,
+			pengirimanRepo: repositories.NewPesananPengirimanRepository(db),
+			providers:      map[models.DeliveryType]ShippingProvider{models.DeliveryTypeDeliveree: provider},
+		}
+		ref := "DLV-1"
+		shipment := &models.PesananPengiriman{ID: uuid.New(), Urutan: 1, DeliveryType: models.DeliveryTypeDeliveree, BookingRef: &ref}
+
+		err := s.cancelShipment(context.Background(), &models.Pesanan{ID: uuid.New(), Kode: "ORD-001"}, shipment, nil, nil)
+		if !provider.intentBefore {
+			t.Fatalf("cancel_requested_at harus disimpan sebelum provider dipanggil")
+		}
+		var released bool
+		for _, q := range fake.Queries() {
+			if v, ok := q.Set("booking_ref"); ok && v == nil {
+				released = true
+			}
+		}
+		if providerErr != nil {
+			// Provider gagal: booking tetap terpasang dengan jejak niat batal
+			if err == nil || released || shipment.BookingRef == nil {
+				t.Fatalf("pembatalan gagal tidak boleh melepas booking, got err=%v released=%v", err, released)
+			}
+			continue
+		}
+		if err != nil || !released || shipment.BookingRef != nil || fake.Commits() != 1 {
+			t.Fatalf("pembatalan sukses harus melepas booking dalam transaksi, got err=%v released=%v commit=%d", err, released, fake.Commits())
+		}
+	}
+}
+
+func TestLockBookingTakesOverStaleLock(t *testing.T) {
+	var cutoff time.Time
+	db, _ := fakedb.Open(t, func(q fakedb.Query) (*fakedb.Result, error) {
+		if q.Is("UPDATE", "pesanan") && strings.Contains(q.SQL, "booking_lock_at <") {
+			for _, arg := range q.Args {
+				// Argumen waktu terlama adalah batas lock basi (yang lain nilai SET)
+				if at, ok := arg.(time.Time); ok && (cutoff.IsZero() || at.Before(cutoff)) {
+					cutoff = at
+				}
+			}
+		}
+		return nil, nil
+	})
+	s := &shippingService{db: db}
+
+	locked, err := s.lockBooking(uuid.New())
+	if err != nil || !locked {
+		t.Fatalf("lock harus berhasil, got %v %v", locked, err)
+	}
+	// Lock lebih tua dari bookingLockTimeout boleh diambil alih
+	if age := time.Since(cutoff); age < bookingLockTimeout-time.Minute || age > bookingLockTimeout+time.Minute {
+		t.Fatalf("batas lock basi harus sekitar %s, got %s", bookingLockTimeout, age)
+	}
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"project-bulky-be/internal/models"
+)
+
+const (
+	// trackingPollWindow membatasi poller ke pengiriman yang di-booking dalam
+	// 30 hari terakhir; pengiriman lebih lama dianggap macet dan dicek manual
+	trackingPollWindow = 30 * 24 * time.Hour
+	// trackingPollBatch jumlah maksimal pengiriman per satu kali poll
+	trackingPollBatch = 200
+	// maxProviderEventIDLength mengikuti panjang kolom provider_event_id
+	maxProviderEventIDLength = 150
+	// trackingEventTimeLayout format waktu event di kunci dedupe
+	trackingEventTimeLayout = "2006-01-02 15:04:05"
+)
+
+// trackingTimeLayouts format tanggal/jam yang dikirim provider di riwayat tracking
+var trackingTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"02-01-2006 15:04:05",
+	"02-01-2006 15:04",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"2006-01-02",
+	"02-01-2006",
+	"02/01/2006",
+}
+
+// PollTracking mengambil tracking pengiriman Forwarder yang masih berjalan dan
+// menyimpan event barunya. Forwarder tidak selalu mengirim webhook, jadi
+// timeline-nya dilengkapi dari polling terjadwal.
+func (s *shippingService) PollTracking(ctx context.Context) (int64, error) {
+	shipments, err := s.pengirimanRepo.FindPollable(ctx,
+		[]models.DeliveryType{models.DeliveryTypeForwarder, models.DeliveryTypeForwarderLCL},
+		time.Now().Add(-trackingPollWindow), trackingPollBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	var recorded int64
+	var errs []error
+	for i := range shipments {
+		_, n, err := s.fetchTracking(ctx, &shipments[i])
+		if err != nil {
+			// Satu booking gagal tidak menghentikan poll pengiriman lain
+			log.Printf("[shipping] poll tracking gagal: pengiriman=%s ref=%s error=%v", shipments[i].ID, *shipments[i].BookingRef, err)
+			errs = append(errs, err)
+			continue
+		}
+		recorded += n
+	}
+	log.Printf("[shipping] poll tracking: %d pengiriman, %d event baru, %d gagal", len(shipments), recorded, len(errs))
+
+	// Job hanya gagal jika semua pengiriman gagal (mis. API Forwarder down)
+	if len(shipments) > 0 && len(errs) == len(shipments) {
+		return recorded, errors.Join(errs...)
+	}
+	return recorded, nil
+}
+
+// ShippingTrackingPollJobHandler adalah handler job cron models.JobTypeShippingTrackingPoll
+func ShippingTrackingPollJobHandler(shipping ShippingService) JobHandler {
+	return func(ctx context.Context, job *models.BackgroundJob) error {
+		_, err := shipping.PollTracking(ctx)
+		return err
+	}
+}
+
+// fetchTracking mengambil tracking live satu pengiriman dari provider lalu
+// menyimpan riwayatnya sebagai event POLLING. Riwayat di hasil tracking diberi
+// event ID dan sumber agar bisa digabung dengan event tersimpan.
+func (s *shippingService) fetchTracking(ctx context.Context, shipment *models.PesananPengiriman) (*TrackingResult, int64, error) {
+	provider, err := s.Provider(shipment.DeliveryType)
+	if err != nil {
+		return nil, 0, err
+	}
+	tracking, err := provider.Track(ctx, *shipment.BookingRef)
+	if err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+	for i := range tracking.History {
+		tracking.History[i].EventID = trackingEventID(tracking.History[i], now)
+		tracking.History[i].Source = models.ShipmentEventSourcePolling
+	}
+
+	events := shipmentEventRecords(shipment, tracking.History, tracking.TrackingURL, now)
+	recorded, err := s.eventRepo.Record(ctx, events)
+	if err != nil {
+		// Tracking live tetap dikembalikan walau gagal disimpan
+		log.Printf("[shipping] gagal menyimpan event tracking pengiriman=%s: %v", shipment.ID, err)
+		return tracking, 0, nil
+	}
+	return tracking, recorded, nil
+}
+
+// storedTracking membangun hasil tracking dari event tersimpan digabung dengan
+// hasil fetch live (boleh nil jika provider gagal), dideduplikasi lewat event ID
+func (s *shippingService) storedTracking(ctx context.Context, shipment *models.PesananPengiriman, live *TrackingResult) (*TrackingResult, error) {
+	stored, err := s.eventRepo.FindByPengirimanID(ctx, shipment.ID, *shipment.BookingRef)
+	if err != nil {
+		return nil, err
+	}
+
+	result := live
+	if result == nil {
+		if len(stored) == 0 {
+			return nil, nil
+		}
+		result = &TrackingResult{
+			Provider:    string(shipment.DeliveryType),
+			BookingRef:  *shipment.BookingRef,
+			Status:      derefString(shipment.BookingStatus),
+			TrackingURL: derefString(shipment.TrackingURL),
+		}
+		if provider, err := s.Provider(shipment.DeliveryType); err == nil {
+			result.Provider = provider.Code()
+		}
+	}
+
+	var liveHistory []TrackingEvent
+	if live != nil {
+		liveHistory = live.History
+	}
+	result.History = mergeTrackingEvents(toTrackingEvents(stored), liveHistory)
+	return result, nil
+}
+
+// shipmentEventRecords mengubah riwayat tracking menjadi baris event.
+// Event dengan ID sama dalam satu batch hanya disimpan sekali.
+func shipmentEventRecords(shipment *models.PesananPengiriman, history []TrackingEvent, trackingURL string, receivedAt time.Time) []models.PesananPengirimanEvent {
+	var url *string
+	if trackingURL != "" {
+		url = &trackingURL
+	}
+	seen := map[string]bool{}
+	events := make([]models.PesananPengirimanEvent, 0, len(history))
+	for _, ev := range history {
+		id := trackingEventID(ev, receivedAt)
+		if seen[id] || strings.TrimSpace(ev.Status) == "" {
+			continue
+		}
+		seen[id] = true
+		source := ev.Source
+		if source == "" {
+			source = models.ShipmentEventSourcePolling
+		}
+		events = append(events, models.PesananPengirimanEvent{
+			PesananPengirimanID: shipment.ID,
+			PesananID:           shipment.PesananID,
+			BookingRef:          *shipment.BookingRef,
+			ProviderEventID:     id,
+			Source:              source,
+			Status:              ev.Status,
+			TrackingURL:         url,
+			OccurredAt:          parseTrackingTime(ev.Date, ev.Time, receivedAt),
+		})
+	}
+	return events
+}
+
+// trackingEventID adalah kunci dedupe event, sama untuk webhook maupun
+// polling: waktu event (WIB, sampai detik) + status. Waktu yang formatnya
+// tidak dikenal dipakai apa adanya; event tanpa waktu memakai receivedAt.
+// Kunci yang terlalu panjang diganti hash-nya.
+func trackingEventID(ev TrackingEvent, receivedAt time.Time) string {
+	if ev.EventID != "" {
+		return ev.EventID
+	}
+	at := strings.TrimSpace(ev.Date + " " + ev.Time)
+	if t, ok := lookupTrackingTime(ev.Date, ev.Time); ok {
+		at = t.Format(trackingEventTimeLayout)
+	} else if at == "" {
+		at = receivedAt.In(jakartaLocation).Format(trackingEventTimeLayout)
+	}
+	key := at + "|" + strings.ToLower(strings.TrimSpace(ev.Status))
+	if len(key) > maxProviderEventIDLength {
+		sum := sha1.Sum([]byte(key))
+		key = "sha1:" + hex.EncodeToString(sum[:])
+	}
+	return key
+}
+
+// parseTrackingTime membaca tanggal/jam riwayat provider (WIB). Fallback ke
+// waktu event diterima jika kosong atau formatnya tidak dikenal.
+func parseTrackingTime(date, clock string, fallback time.Time) time.Time {
+	if t, ok := lookupTrackingTime(date, clock); ok {
+		return t
+	}
+	return fallback
+}
+
+func lookupTrackingTime(date, clock string) (time.Time, bool) {
+	value := strings.TrimSpace(date + " " + clock)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range trackingTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, jakartaLocation); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func toTrackingEvents(events []models.PesananPengirimanEvent) []TrackingEvent {
+	result := make([]TrackingEvent, len(events))
+	for i, ev := range events {
+		at := ev.OccurredAt.In(jakartaLocation)
+		result[i] = TrackingEvent{
+			EventID: ev.ProviderEventID,
+			Source:  ev.Source,
+			Date:    at.Format("2006-01-02"),
+			Time:    at.Format("15:04:05"),
+			Status:  ev.Status,
+		}
+	}
+	return result
+}
+
+// mergeTrackingEvents menggabungkan event tersimpan dengan event live yang
+// belum tersimpan (mis. penyimpanan gagal), tanpa duplikasi event ID, urut
+// waktu event. Event yang waktunya tidak terbaca diletakkan di akhir.
+func mergeTrackingEvents(stored, live []TrackingEvent) []TrackingEvent {
+	seen := make(map[string]bool, len(stored))
+	merged := make([]TrackingEvent, 0, len(stored)+len(live))
+	for _, ev := range stored {
+		seen[ev.EventID] = true
+		merged = append(merged, ev)
+	}
+	for _, ev := range live {
+		if seen[ev.EventID] {
+			continue
+		}
+		seen[ev.EventID] = true
+		merged = append(merged, ev)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		a, okA := lookupTrackingTime(merged[i].Date, merged[i].Time)
+		b, okB := lookupTrackingTime(merged[j].Date, merged[j].Time)
+		if okA != okB {
+			return okA
+		}
+		return a.Before(b)
+	})
+	return merged
+}
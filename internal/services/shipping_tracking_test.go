@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"project-bulky-be/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestShipmentEventRecordsDedupe(t *testing.T) {
+	ref := "FWD-001"
+	shipment := &models.PesananPengiriman{ID: uuid.New(), PesananID: uuid.New(), BookingRef: &ref}
+	receivedAt := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	history := []TrackingEvent{
+		{Date: "2026-01-01", Time: "08:00", Status: "Pickup"},
+		{Date: "2026-01-01", Time: "08:00", Status: "pickup "},
+		{Date: "2026-01-01", Time: "17:30", Status: "Transit Jakarta"},
+		{Status: ""},
+	}
+
+	events := shipmentEventRecords(shipment, history, "", receivedAt)
+	if len(events) != 2 {
+		t.Fatalf("event duplikat & kosong harus dilewati, got %d event", len(events))
+	}
+
+	// Waktu riwayat provider dibaca sebagai WIB
+	want := time.Date(2026, 1, 1, 8, 0, 0, 0, jakartaLocation)
+	if !events[0].OccurredAt.Equal(want) {
+		t.Fatalf("occurred_at salah: %s", events[0].OccurredAt)
+	}
+	if events[0].Source != models.ShipmentEventSourcePolling {
+		t.Fatalf("sumber default harus POLLING, got %s", events[0].Source)
+	}
+
+	// Event tanpa waktu memakai waktu diterima sebagai waktu event & kunci
+	webhook := shipmentEventRecords(shipment, []TrackingEvent{{Source: models.ShipmentEventSourceWebhook, Status: "delivery_in_progress"}}, "", receivedAt)
+	if webhook[0].ProviderEventID != "2026-01-02 10:00:00|delivery_in_progress" || !webhook[0].OccurredAt.Equal(receivedAt) {
+		t.Fatalf("event webhook salah: %+v", webhook[0])
+	}
+}
+
+func TestTrackingEventIDSameSchemeForWebhookAndPolling(t *testing.T) {
+	receivedAt := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	polling := TrackingEvent{Source: models.ShipmentEventSourcePolling, Date: "02/01/2026", Time: "10:00", Status: "Delivered"}
+	webhook := TrackingEvent{Source: models.ShipmentEventSourceWebhook, Date: "2026-01-02", Time: "10:00:00", Status: "delivered"}
+	if trackingEventID(polling, receivedAt) != trackingEventID(webhook, receivedAt) {
+		t.Fatalf("status & waktu sama harus menghasilkan ID sama: %s vs %s", trackingEventID(polling, receivedAt), trackingEventID(webhook, receivedAt))
+	}
+
+	// Status sama di waktu berbeda adalah event berbeda
+	webhook.Time = "15:00:00"
+	if trackingEventID(polling, receivedAt) == trackingEventID(webhook, receivedAt) {
+		t.Fatal("status sama di waktu berbeda harus menghasilkan ID berbeda")
+	}
+}
+
+func TestMergeTrackingEvents(t *testing.T) {
+	stored := []TrackingEvent{
+		{EventID: "a", Date: "2026-01-01", Time: "08:00:00", Status: "Pickup"},
+		{EventID: "b", Date: "2026-01-01", Time: "17:30:00", Status: "Transit"},
+	}
+	live := []TrackingEvent{
+		{EventID: "b", Date: "2026-01-01", Time: "17:30:00", Status: "Transit"},
+		{EventID: "c", Date: "2026-01-02", Time: "09:00:00", Status: "Delivered"},
+		{EventID: "d", Date: "2026-01-01", Time: "12:00:00", Status: "Sortir"},
+	}
+
+	merged := mergeTrackingEvents(stored, live)
+	if len(merged) != 4 {
+		t.Fatalf("event live yang belum tersimpan harus ditambahkan tanpa duplikat, got %+v", merged)
+	}
+	for i, want := range []string{"a", "d", "b", "c"} {
+		if merged[i].EventID != want {
+			t.Fatalf("event harus urut waktu, got %+v", merged)
+		}
+	}
+}
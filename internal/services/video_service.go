@@ -2,14 +2,18 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"log"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"project-bulky-be/internal/config"
 	"project-bulky-be/internal/dto"
 	"project-bulky-be/internal/models"
 	"project-bulky-be/internal/repositories"
+	"project-bulky-be/pkg/transcoder"
 	"project-bulky-be/pkg/utils"
 
 	"github.com/google/uuid"
@@ -23,7 +27,9 @@ type VideoService interface {
 	MarkFailed(ctx context.Context, id uuid.UUID, errMsg string) error
 	MarkProcessing(ctx context.Context, id uuid.UUID, rawVideoURL string) error
 	GetVideoFilePath(ctx context.Context, id uuid.UUID) (string, error)
-	RecoverStuckJobs(ctx context.Context)
+	// EnqueueTranscode menjadwalkan job transcode untuk file mentah yang sudah
+	// diupload. oldVideoURL (opsional) dihapus setelah transcode berhasil.
+	EnqueueTranscode(ctx context.Context, id uuid.UUID, rawRelativePath, oldVideoURL string) error
 	GetTranscodeStatus(ctx context.Context, id uuid.UUID) (*dto.VideoTranscodeStatusResponse, error)
 	Update(ctx context.Context, id uuid.UUID, req *dto.UpdateVideoRequest) (*dto.VideoResponse, error)
 	Delete(ctx context.Context, id uuid.UUID) error
@@ -41,19 +47,30 @@ type VideoService interface {
 type videoService struct {
 	videoRepo    repositories.VideoRepository
 	kategoriRepo repositories.KategoriVideoRepository
+	jobQueue     JobQueueService
 	cfg          *config.Config
 }
 
 func NewVideoService(
 	videoRepo repositories.VideoRepository,
 	kategoriRepo repositories.KategoriVideoRepository,
+	jobQueue JobQueueService,
 	cfg *config.Config,
 ) VideoService {
-	return &videoService{
+	s := &videoService{
 		videoRepo:    videoRepo,
 		kategoriRepo: kategoriRepo,
+		jobQueue:     jobQueue,
 		cfg:          cfg,
 	}
+	// Maksimal 2 transcode berjalan bersamaan per instance (ffmpeg berat di CPU)
+	jobQueue.RegisterHandler(models.JobTypeVideoTranscode, s.handleTranscode, JobHandlerOptions{
+		Concurrency: 2,
+		Timeout:     30 * time.Minute,
+		MaxAttempts: 3,
+		OnDead:      s.transcodeDead,
+	})
+	return s
 }
 
 func (s *videoService) Create(ctx context.Context, req *dto.CreateVideoRequest) (*dto.VideoResponse, error) {
@@ -459,14 +476,69 @@ func (s *videoService) MarkProcessing(ctx context.Context, id uuid.UUID, rawVide
 	})
 }
 
-func (s *videoService) RecoverStuckJobs(ctx context.Context) {
-	affected, err := s.videoRepo.MarkStuckAsFailed(ctx, 15*time.Minute)
+// videoTranscodePayload adalah payload job models.JobTypeVideoTranscode
+type videoTranscodePayload struct {
+	VideoID         uuid.UUID `json:"video_id"`
+	RawRelativePath string    `json:"raw_relative_path"`
+	OldVideoURL     string    `json:"old_video_url,omitempty"`
+}
+
+func (s *videoService) EnqueueTranscode(ctx context.Context, id uuid.UUID, rawRelativePath, oldVideoURL string) error {
+	err := s.jobQueue.Enqueue(ctx, nil, models.JobTypeVideoTranscode, videoTranscodePayload{
+		VideoID:         id,
+		RawRelativePath: rawRelativePath,
+		OldVideoURL:     oldVideoURL,
+	}, EnqueueOptions{})
+	if err != nil {
+		_ = s.MarkFailed(ctx, id, err.Error())
+	}
+	return err
+}
+
+// handleTranscode mengkonversi file mentah ke MP4 streamable lalu mengupdate
+// record video. File mentah baru dihapus setelah sukses atau job DEAD (lihat
+// transcodeDead), agar percobaan ulang masih punya input. ffmpeg dihentikan
+// saat batas waktu job habis sehingga tidak berjalan dobel dengan worker lain.
+func (s *videoService) handleTranscode(ctx context.Context, job *models.BackgroundJob) error {
+	var payload videoTranscodePayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return err
+	}
+
+	rawAbsPath := filepath.Join(s.cfg.UploadPath, filepath.FromSlash(payload.RawRelativePath))
+	result, err := transcoder.Transcode(ctx, rawAbsPath)
 	if err != nil {
-		log.Printf("[video] RecoverStuckJobs error: %v", err)
+		return err
+	}
+	transcoder.Cleanup(rawAbsPath)
+
+	// Derive relative URL dari rawRelativePath (konsisten dengan SaveUploadedFile yang menyimpan tanpa prefix "uploads/")
+	dir := filepath.Dir(payload.RawRelativePath)
+	base := strings.TrimSuffix(filepath.Base(payload.RawRelativePath), filepath.Ext(payload.RawRelativePath))
+	relativeStreamURL := filepath.ToSlash(filepath.Join(dir, "stream_"+base+".mp4"))
+
+	if err := s.MarkReady(ctx, payload.VideoID, relativeStreamURL, result.DurasiDetik); err != nil {
+		return err
+	}
+
+	// Hapus file video lama setelah transcode berhasil (raw relative path, langsung ke DeleteFile)
+	if payload.OldVideoURL != "" {
+		utils.DeleteFile(payload.OldVideoURL, s.cfg)
+	}
+	return nil
+}
+
+// transcodeDead menandai video gagal saat job transcode DEAD, termasuk jika
+// worker mati di percobaan terakhir, lalu menghapus file mentahnya
+func (s *videoService) transcodeDead(ctx context.Context, job *models.BackgroundJob, err error) {
+	var payload videoTranscodePayload
+	if jsonErr := json.Unmarshal(job.Payload, &payload); jsonErr != nil {
+		log.Printf("[video] payload job transcode %s tidak valid: %v", job.ID, jsonErr)
 		return
 	}
-	if affected > 0 {
-		log.Printf("[video] RecoverStuckJobs: %d video dikembalikan ke status failed", affected)
+	transcoder.Cleanup(filepath.Join(s.cfg.UploadPath, filepath.FromSlash(payload.RawRelativePath)))
+	if markErr := s.MarkFailed(ctx, payload.VideoID, err.Error()); markErr != nil {
+		log.Printf("[video] gagal menandai transcode video %s gagal: %v", payload.VideoID, markErr)
 	}
 }
 
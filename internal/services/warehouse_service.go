@@ -335,6 +335,9 @@ func (s *warehouseService) GetJadwal(ctx context.Context) ([]dto.JadwalGudangRes
 			JamBuka:  j.JamBuka,
 			JamTutup: j.JamTutup,
 			IsBuka:   j.IsBuka,
+
+			DurasiSlotMenit: j.DurasiSlotMenit,
+			KapasitasSlot:   j.KapasitasSlot,
 		})
 	}
 
@@ -361,18 +364,33 @@ func (s *warehouseService) UpdateJadwal(ctx context.Context, req *dto.UpdateJadw
 				return nil, fmt.Errorf("jam tutup harus lebih besar dari jam buka untuk hari %d", j.Hari)
 			}
 		}
+		if j.DurasiSlotMenit != nil && (*j.DurasiSlotMenit < minDurasiSlotMenit || *j.DurasiSlotMenit > maxDurasiSlotMenit) {
+			return nil, fmt.Errorf("durasi slot harus antara %d dan %d menit untuk hari %d", minDurasiSlotMenit, maxDurasiSlotMenit, j.Hari)
+		}
+		if j.KapasitasSlot != nil && *j.KapasitasSlot < 1 {
+			return nil, fmt.Errorf("kapasitas slot minimal 1 untuk hari %d", j.Hari)
+		}
 	}
 
 	// Convert to models
 	jadwalModels := []models.JadwalGudang{}
 	for _, j := range req.Jadwal {
-		jadwalModels = append(jadwalModels, models.JadwalGudang{
-			WarehouseID: warehouse.ID,
-			Hari:        j.Hari,
-			JamBuka:     j.JamBuka,
-			JamTutup:    j.JamTutup,
-			IsBuka:      j.IsBuka,
-		})
+		jadwal := models.JadwalGudang{
+			WarehouseID:     warehouse.ID,
+			Hari:            j.Hari,
+			JamBuka:         j.JamBuka,
+			JamTutup:        j.JamTutup,
+			IsBuka:          j.IsBuka,
+			DurasiSlotMenit: defaultDurasiSlotMenit,
+			KapasitasSlot:   defaultKapasitasSlot,
+		}
+		if j.DurasiSlotMenit != nil {
+			jadwal.DurasiSlotMenit = *j.DurasiSlotMenit
+		}
+		if j.KapasitasSlot != nil {
+			jadwal.KapasitasSlot = *j.KapasitasSlot
+		}
+		jadwalModels = append(jadwalModels, jadwal)
 	}
 
 	if err := s.jadwalRepo.UpdateBatch(ctx, warehouse.ID, jadwalModels); err != nil {
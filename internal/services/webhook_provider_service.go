@@ -3,8 +3,11 @@ package services
 import (
 	"context"
 	"errors"
+	"log"
+	"time"
 
 	"project-bulky-be/internal/models"
+	"project-bulky-be/internal/orderstate"
 	"project-bulky-be/internal/repositories"
 
 	"gorm.io/gorm"
@@ -49,6 +52,8 @@ var providerWebhookStatuses = map[ProviderWebhookStatus]bool{
 //     paket siap dikirim tapi booking gagal/tidak ada driver, bukan kembali ke
 //     PROCESSING (yang berarti sedang diproses di gudang). Admin bisa retry
 //     booking dari status READY.
+//   - Hasil pemetaan hanya status tujuan; sah/tidaknya ditentukan tabel
+//     transisi orderstate (mis. SHIPPED → READY ditolak).
 func mapProviderWebhookToOrderStatus(status ProviderWebhookStatus) (models.OrderStatus, bool) {
 	switch status {
 	case ProviderWebhookStatusDeliveryCompleted:
@@ -63,8 +68,9 @@ func mapProviderWebhookToOrderStatus(status ProviderWebhookStatus) (models.Order
 }
 
 // providerWebhookConfig membedakan perilaku webhook per provider:
-// kolom mana yang dipakai untuk mencari pesanan dan delivery type mana yang
-// berhak menerima event dari provider ini.
+// kolom mana yang dipakai untuk mencari pesanan lama (sebelum ada
+// pesanan_pengiriman) dan delivery type mana yang berhak menerima event dari
+// provider ini.
 type providerWebhookConfig struct {
 	// lookupColumn adalah kolom di tabel pesanan yang diisi dengan identifier
 	// booking/tracking provider (deliveree_booking_id / forwarder_tracking_no).
@@ -77,17 +83,23 @@ type providerWebhookConfig struct {
 // provider pengiriman on-demand (Deliveree/Forwarder). Kedua provider memakai
 // format status yang sama, hanya endpoint dan kolom identifikasi yang beda.
 type ProviderWebhookHandler struct {
-	pesananRepo repositories.PesananRepository
-	db          *gorm.DB
-	cfg         providerWebhookConfig
+	pesananRepo    repositories.PesananRepository
+	pengirimanRepo repositories.PesananPengirimanRepository
+	eventRepo      repositories.PesananPengirimanEventRepository
+	orderMachine   *orderstate.Machine
+	db             *gorm.DB
+	cfg            providerWebhookConfig
 }
 
 // NewProviderWebhookHandler membuat handler generik untuk satu provider.
-func NewProviderWebhookHandler(pesananRepo repositories.PesananRepository, db *gorm.DB, cfg providerWebhookConfig) *ProviderWebhookHandler {
+func NewProviderWebhookHandler(pesananRepo repositories.PesananRepository, pengirimanRepo repositories.PesananPengirimanRepository, eventRepo repositories.PesananPengirimanEventRepository, orderMachine *orderstate.Machine, db *gorm.DB, cfg providerWebhookConfig) *ProviderWebhookHandler {
 	return &ProviderWebhookHandler{
-		pesananRepo: pesananRepo,
-		db:          db,
-		cfg:         cfg,
+		pesananRepo:    pesananRepo,
+		pengirimanRepo: pengirimanRepo,
+		eventRepo:      eventRepo,
+		orderMachine:   orderMachine,
+		db:             db,
+		cfg:            cfg,
 	}
 }
 
@@ -106,8 +118,8 @@ func (h *ProviderWebhookHandler) Handle(ctx context.Context, identifier string,
 		return false, errors.New("identifier booking wajib diisi")
 	}
 
-	var pesanan models.Pesanan
-	if err := h.db.Where(h.cfg.lookupColumn+" = ?", identifier).First(&pesanan).Error; err != nil {
+	pesanan, shipment, err := h.findTarget(ctx, identifier)
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			// Pesanan belum ditemukan — kemungkinan booking belum tercatat atau
 			// webhook datang lebih awal. Biarkan provider mengirim ulang.
@@ -121,36 +133,133 @@ func (h *ProviderWebhookHandler) Handle(ctx context.Context, identifier string,
 		return false, nil
 	}
 
+	extra := h.buildExtraUpdates(status, trackingURL)
+
+	if shipment != nil {
+		if err := h.recordShipmentStatus(ctx, shipment, ws, trackingURL); err != nil {
+			return false, err
+		}
+		if err := h.recordShipmentEvent(ctx, shipment, status, trackingURL); err != nil {
+			return false, err
+		}
+		// Kolom booking di pesanan hanya mengikuti pengiriman utama
+		if shipment.Urutan != 1 {
+			extra = map[string]interface{}{}
+		}
+		// Pesanan multi-gudang baru COMPLETED setelah semua pengiriman diterima
+		if ws == ProviderWebhookStatusDeliveryCompleted {
+			remaining, err := h.pengirimanRepo.CountNotInStatus(ctx, pesanan.ID, string(ProviderWebhookStatusDeliveryCompleted))
+			if err != nil {
+				return false, err
+			}
+			if remaining > 0 {
+				if err := h.pesananRepo.UpdateBookingInfo(pesanan.ID, extra); err != nil {
+					return false, err
+				}
+				return true, nil
+			}
+		}
+	}
+
 	orderStatus, ok := mapProviderWebhookToOrderStatus(ws)
 	if !ok {
 		// Status diketahui tapi tidak mengubah order status (locating_driver,
 		// driver_accept_booking) — hanya update info booking.
-		extra := h.buildExtraUpdates(status, trackingURL)
-		if len(extra) > 0 {
-			if err := h.pesananRepo.UpdateFromWebhook(pesanan.ID, pesanan.OrderStatus, extra); err != nil {
-				return false, err
-			}
+		if err := h.pesananRepo.UpdateBookingInfo(pesanan.ID, extra); err != nil {
+			return false, err
 		}
 		return true, nil
 	}
 
-	// Anti-lompat-status: event timeout/canceled (→ READY) hanya boleh mengubah
-	// order_status jika pesanan memang sudah READY. Jika masih PROCESSING/PENDING
-	// (mis. booking via retry terjadi lebih awal dari admin set READY), webhook
-	// cukup meng-update kolom pendukung — status gudang tetap kendali admin.
-	// Perlindungan SHIPPED/COMPLETED ditangani di UpdateFromWebhook.
-	if orderStatus == models.OrderStatusReady && pesanan.OrderStatus != models.OrderStatusReady {
-		orderStatus = pesanan.OrderStatus
+	_, err = h.orderMachine.Transition(ctx, orderstate.Request{
+		PesananID: pesanan.ID,
+		To:        orderStatus,
+		Trigger:   orderstate.TriggerWebhook,
+		Extra:     extra,
+	})
+	var transitionErr *orderstate.TransitionError
+	if errors.As(err, &transitionErr) {
+		// Event terlambat/tidak urut (mis. timeout setelah SHIPPED, atau
+		// canceled saat pesanan masih PROCESSING): status gudang tetap, info
+		// booking tetap dicatat. Bukan error agar provider tidak retry terus.
+		log.Printf("[webhook] %s %s diabaikan untuk pesanan %s: %v", h.cfg.lookupColumn, identifier, pesanan.Kode, transitionErr)
+		if err := h.pesananRepo.UpdateBookingInfo(pesanan.ID, extra); err != nil {
+			return false, err
+		}
+		return true, nil
 	}
-
-	extra := h.buildExtraUpdates(status, trackingURL)
-	if err := h.pesananRepo.UpdateFromWebhook(pesanan.ID, orderStatus, extra); err != nil {
+	if err != nil {
 		return false, err
 	}
 
 	return true, nil
 }
 
+// findTarget mencari pengiriman dari booking ref lalu pesanannya. Booking
+// lama yang belum tercatat sebagai pengiriman dicari lewat kolom pesanan.
+func (h *ProviderWebhookHandler) findTarget(ctx context.Context, identifier string) (*models.Pesanan, *models.PesananPengiriman, error) {
+	deliveryTypes := make([]models.DeliveryType, 0, len(h.cfg.deliveryTypes))
+	for deliveryType := range h.cfg.deliveryTypes {
+		deliveryTypes = append(deliveryTypes, deliveryType)
+	}
+
+	var pesanan models.Pesanan
+	shipment, err := h.pengirimanRepo.FindByBookingRef(ctx, identifier, deliveryTypes)
+	if err == nil {
+		if err := h.db.First(&pesanan, "id = ?", shipment.PesananID).Error; err != nil {
+			return nil, nil, err
+		}
+		return &pesanan, shipment, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+
+	if err := h.db.Where(h.cfg.lookupColumn+" = ?", identifier).First(&pesanan).Error; err != nil {
+		return nil, nil, err
+	}
+	return &pesanan, nil, nil
+}
+
+// recordShipmentStatus menyimpan status webhook ke pengiriman yang bersangkutan.
+func (h *ProviderWebhookHandler) recordShipmentStatus(ctx context.Context, shipment *models.PesananPengiriman, status ProviderWebhookStatus, trackingURL string) error {
+	fields := map[string]interface{}{
+		"booking_status": string(status),
+	}
+	if trackingURL != "" {
+		fields["tracking_url"] = trackingURL
+	}
+	now := time.Now()
+	switch status {
+	case ProviderWebhookStatusDeliveryInProgress:
+		if shipment.ShippedAt == nil {
+			fields["shipped_at"] = now
+		}
+	case ProviderWebhookStatusDeliveryCompleted:
+		if shipment.CompletedAt == nil {
+			fields["completed_at"] = now
+		}
+	}
+	return h.pengirimanRepo.UpdateFields(ctx, shipment.ID, fields)
+}
+
+// recordShipmentEvent menyimpan webhook ke timeline pengiriman. Webhook tidak
+// membawa waktu event, jadi waktu diterima dipakai sebagai waktu event dengan
+// kunci dedupe yang sama seperti polling (lihat trackingEventID). Webhook yang
+// sama persis sudah ditolak sebagai replay sebelum sampai di sini.
+func (h *ProviderWebhookHandler) recordShipmentEvent(ctx context.Context, shipment *models.PesananPengiriman, status, trackingURL string) error {
+	now := time.Now()
+	at := now.In(jakartaLocation)
+	events := shipmentEventRecords(shipment, []TrackingEvent{{
+		Source: models.ShipmentEventSourceWebhook,
+		Date:   at.Format("2006-01-02"),
+		Time:   at.Format("15:04:05"),
+		Status: status,
+	}}, trackingURL, now)
+	_, err := h.eventRepo.Record(ctx, events)
+	return err
+}
+
 // buildExtraUpdates menyiapkan kolom pendukung dari payload webhook.
 func (h *ProviderWebhookHandler) buildExtraUpdates(status, trackingURL string) map[string]interface{} {
 	extra := map[string]interface{}{
@@ -0,0 +1,246 @@
+// Package fakedb adalah driver database/sql palsu untuk unit test yang butuh
+// *gorm.DB tanpa PostgreSQL. Setiap statement dicatat lalu dijawab oleh
+// Handler milik test, sehingga test bisa mensimulasikan baris tabel yang
+// relevan dan memeriksa SQL yang dijalankan (termasuk commit/rollback).
+package fakedb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Query adalah satu statement yang diterima driver. Args sudah melalui
+// driver.Valuer (uuid, decimal, dsb. berbentuk nilai dasarnya).
+type Query struct {
+	SQL  string
+	Args []driver.Value
+	InTx bool
+}
+
+var setColumnRegex = regexp.MustCompile(`"(\w+)"=\$(\d+)`)
+
+// Set mengembalikan nilai kolom di klausa SET UPDATE (`"kolom"=$n`)
+func (q Query) Set(column string) (driver.Value, bool) {
+	for _, m := range setColumnRegex.FindAllStringSubmatch(q.SQL, -1) {
+		if m[1] != column {
+			continue
+		}
+		n, _ := strconv.Atoi(m[2])
+		if n < 1 || n > len(q.Args) {
+			return nil, false
+		}
+		return q.Args[n-1], true
+	}
+	return nil, false
+}
+
+// Is true jika statement diawali verb (SELECT/INSERT/UPDATE/DELETE) untuk tabel
+func (q Query) Is(verb, table string) bool {
+	sql := strings.ToUpper(strings.TrimSpace(q.SQL))
+	if !strings.HasPrefix(sql, verb) {
+		return false
+	}
+	return strings.Contains(q.SQL, `"`+table+`"`) || strings.Contains(q.SQL, " "+table+" ")
+}
+
+// Result jawaban Handler. Nil berarti tanpa baris dan satu baris terpengaruh.
+type Result struct {
+	Columns      []string
+	Rows         [][]driver.Value
+	RowsAffected int64
+}
+
+type Handler func(q Query) (*Result, error)
+
+// DB mencatat statement yang dijalankan beserta jumlah commit/rollback
+type DB struct {
+	mu        sync.Mutex
+	handler   Handler
+	queries   []Query
+	commits   int
+	rollbacks int
+	commitErr error
+}
+
+// Open membuat *gorm.DB (dialek PostgreSQL) yang dijawab handler
+func Open(t testing.TB, handler Handler) (*gorm.DB, *DB) {
+	t.Helper()
+	fake := &DB{handler: handler}
+	sqlDB := sql.OpenDB(connector{db: fake})
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:                 logger.Discard,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatalf("fakedb: %v", err)
+	}
+	return db, fake
+}
+
+// Queries salinan semua statement yang sudah dijalankan
+func (d *DB) Queries() []Query {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Query(nil), d.queries...)
+}
+
+func (d *DB) Commits() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.commits
+}
+
+func (d *DB) Rollbacks() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.rollbacks
+}
+
+// FailCommit membuat commit berikutnya gagal dengan err (nil memulihkan)
+func (d *DB) FailCommit(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.commitErr = err
+}
+
+func (d *DB) run(sql string, args []driver.NamedValue, inTx bool) (*Result, error) {
+	q := Query{SQL: sql, InTx: inTx, Args: make([]driver.Value, len(args))}
+	for i, arg := range args {
+		q.Args[i] = arg.Value
+	}
+	d.mu.Lock()
+	d.queries = append(d.queries, q)
+	handler := d.handler
+	d.mu.Unlock()
+
+	if handler == nil {
+		return nil, nil
+	}
+	return handler(q)
+}
+
+type connector struct {
+	db *DB
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{db: c.db}, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, fmt.Errorf("fakedb: gunakan Open")
+}
+
+type conn struct {
+	db   *DB
+	inTx bool
+}
+
+func (c *conn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fakedb: prepared statement tidak didukung")
+}
+
+func (c *conn) Close() error { return nil }
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.inTx = true
+	return &tx{conn: c}, nil
+}
+
+// CheckNamedValue menerima semua tipe: yang dikenal database/sql diubah ke
+// nilai dasarnya, sisanya diteruskan apa adanya ke Handler
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if v, err := driver.DefaultParameterConverter.ConvertValue(nv.Value); err == nil {
+		nv.Value = v
+	}
+	return nil
+}
+
+func (c *conn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result, err := c.db.run(query, args, c.inTx)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		result = &Result{}
+	}
+	return &rows{result: result}, nil
+}
+
+func (c *conn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result, err := c.db.run(query, args, c.inTx)
+	if err != nil {
+		return nil, err
+	}
+	affected := int64(1)
+	if result != nil {
+		affected = result.RowsAffected
+	}
+	return driver.RowsAffected(affected), nil
+}
+
+type tx struct {
+	conn *conn
+}
+
+func (t *tx) Commit() error {
+	t.conn.inTx = false
+	t.conn.db.mu.Lock()
+	defer t.conn.db.mu.Unlock()
+	if err := t.conn.db.commitErr; err != nil {
+		t.conn.db.commitErr = nil
+		return err
+	}
+	t.conn.db.commits++
+	return nil
+}
+
+func (t *tx) Rollback() error {
+	t.conn.inTx = false
+	t.conn.db.mu.Lock()
+	t.conn.db.rollbacks++
+	t.conn.db.mu.Unlock()
+	return nil
+}
+
+type rows struct {
+	result *Result
+	next   int
+}
+
+func (r *rows) Columns() []string { return r.result.Columns }
+
+func (r *rows) Close() error { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.Rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.Rows[r.next])
+	r.next++
+	return nil
+}
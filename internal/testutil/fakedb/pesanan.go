@@ -0,0 +1,38 @@
+package fakedb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"testing"
+
+	"project-bulky-be/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// OpenPesanan membuat *gorm.DB yang menjawab SELECT pesanan dengan satu baris
+// pesanan; order_status-nya mengikuti UPDATE terakhir sehingga transisi
+// orderstate.Machine bisa dijalankan. Statement lain diteruskan ke handler
+// (nil berarti tanpa baris).
+func OpenPesanan(t testing.TB, pesanan models.Pesanan, handler Handler) (*gorm.DB, *DB) {
+	t.Helper()
+	return Open(t, func(q Query) (*Result, error) {
+		switch {
+		case q.Is("UPDATE", "pesanan"):
+			if v, ok := q.Set("order_status"); ok {
+				pesanan.OrderStatus = models.OrderStatus(fmt.Sprint(v))
+			}
+			return nil, nil
+		case q.Is("SELECT", "pesanan"):
+			return &Result{
+				Columns: []string{"id", "kode", "buyer_id", "order_status", "payment_status", "delivery_type", "total"},
+				Rows: [][]driver.Value{{pesanan.ID.String(), pesanan.Kode, pesanan.BuyerID.String(), string(pesanan.OrderStatus),
+					string(pesanan.PaymentStatus), string(pesanan.DeliveryType), pesanan.Total.String()}},
+			}, nil
+		}
+		if handler == nil {
+			return nil, nil
+		}
+		return handler(q)
+	})
+}
@@ -0,0 +1,31 @@
+-- migrations/000181_generalize_admin_session_to_auth_session.down.sql
+
+DROP INDEX IF EXISTS idx_auth_session_user;
+DROP INDEX IF EXISTS idx_auth_session_expires_at;
+DROP INDEX IF EXISTS idx_auth_session_active;
+
+DELETE FROM auth_session;
+
+ALTER TABLE auth_session
+    DROP COLUMN IF EXISTS user_type,
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS revoked_reason,
+    ADD COLUMN token VARCHAR(500) NOT NULL UNIQUE,
+    ALTER COLUMN expires_at TYPE TIMESTAMP USING expires_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE auth_session RENAME COLUMN user_id TO admin_id;
+ALTER TABLE auth_session RENAME CONSTRAINT auth_session_pkey TO admin_session_pkey;
+ALTER TABLE auth_session RENAME TO admin_session;
+ALTER TABLE admin_session
+    ADD CONSTRAINT admin_session_admin_id_fkey FOREIGN KEY (admin_id) REFERENCES admin(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_admin_session_admin_id ON admin_session(admin_id);
+CREATE INDEX idx_admin_session_expires_at ON admin_session(expires_at);
+
+CREATE OR REPLACE FUNCTION clean_expired_sessions()
+RETURNS void AS $$
+BEGIN
+    DELETE FROM admin_session WHERE expires_at < NOW();
+END;
+$$ LANGUAGE plpgsql;
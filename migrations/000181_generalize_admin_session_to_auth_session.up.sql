@@ -0,0 +1,51 @@
+-- migrations/000181_generalize_admin_session_to_auth_session.up.sql
+-- Session server-side untuk JWT (admin & buyer).
+--
+-- Latar belakang: AuthMiddleware hanya memvalidasi signature + expiry JWT,
+-- sehingga token admin yang sudah logout, dinonaktifkan, atau di-reset
+-- password-nya tetap bisa dipakai sampai expired (default 24 jam).
+--
+-- Tabel admin_session (000015) tidak pernah diisi sejak refresh token dihapus
+-- (000049). Tabel ini digeneralisasi menjadi auth_session: setiap token yang
+-- diterbitkan membawa claim "sid" = auth_session.id, dan middleware menolak
+-- token yang session-nya sudah di-revoke.
+
+DROP FUNCTION IF EXISTS clean_expired_sessions();
+
+ALTER TABLE admin_session RENAME TO auth_session;
+
+-- Sisa data lama (jika ada) tidak relevan: kolom token berisi refresh token
+-- yang sudah tidak dipakai sejak 000049.
+DELETE FROM auth_session;
+
+ALTER TABLE auth_session DROP CONSTRAINT IF EXISTS admin_session_admin_id_fkey;
+ALTER TABLE auth_session DROP CONSTRAINT IF EXISTS admin_session_token_key;
+ALTER TABLE auth_session RENAME CONSTRAINT admin_session_pkey TO auth_session_pkey;
+ALTER TABLE auth_session RENAME COLUMN admin_id TO user_id;
+ALTER TABLE auth_session DROP COLUMN token;
+
+-- user_id bisa admin.id atau buyer.id (sama seperti activity_log), jadi
+-- tidak ada FK — pembersihan dilakukan lewat revoke saat akun dihapus.
+ALTER TABLE auth_session
+    ADD COLUMN user_type VARCHAR(20) NOT NULL CHECK (user_type IN ('ADMIN', 'BUYER')),
+    ADD COLUMN revoked_at TIMESTAMPTZ,
+    ADD COLUMN revoked_reason VARCHAR(50),
+    ALTER COLUMN expires_at TYPE TIMESTAMPTZ USING expires_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+
+DROP INDEX IF EXISTS idx_admin_session_admin_id;
+DROP INDEX IF EXISTS idx_admin_session_token;
+DROP INDEX IF EXISTS idx_admin_session_expires_at;
+DROP INDEX IF EXISTS idx_admin_session_expires;
+
+CREATE INDEX idx_auth_session_user ON auth_session(user_type, user_id);
+CREATE INDEX idx_auth_session_expires_at ON auth_session(expires_at);
+CREATE INDEX idx_auth_session_active ON auth_session(user_type, user_id)
+    WHERE revoked_at IS NULL;
+
+COMMENT ON TABLE auth_session IS 'Session login admin & buyer. JWT membawa claim sid = id session ini; session yang di-revoke langsung menolak token-nya.';
+COMMENT ON COLUMN auth_session.user_type IS 'ADMIN atau BUYER';
+COMMENT ON COLUMN auth_session.user_id IS 'admin.id atau buyer.id sesuai user_type';
+COMMENT ON COLUMN auth_session.expires_at IS 'Waktu session berakhir (sama dengan expiry token yang diterbitkan)';
+COMMENT ON COLUMN auth_session.revoked_at IS 'Waktu session di-revoke (logout, ganti password, akun dinonaktifkan/dihapus). NULL = aktif';
+COMMENT ON COLUMN auth_session.revoked_reason IS 'Alasan revoke: LOGOUT, PASSWORD_CHANGED, PASSWORD_RESET, ACCOUNT_DEACTIVATED, ACCOUNT_DELETED';
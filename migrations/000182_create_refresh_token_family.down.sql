@@ -0,0 +1,2 @@
+-- migrations/000182_create_refresh_token_family.down.sql
+DROP TABLE IF EXISTS refresh_token;
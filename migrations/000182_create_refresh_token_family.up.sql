@@ -0,0 +1,34 @@
+-- migrations/000182_create_refresh_token_family.up.sql
+-- Refresh token rotation dengan reuse detection.
+--
+-- Latar belakang: sejak 000049 sistem memakai satu access token 24 jam,
+-- sehingga admin & buyer ter-logout di tengah shift. Sekarang access token
+-- dibuat pendek (JWT_ACCESS_EXPIRY) dan diperpanjang lewat refresh token.
+--
+-- Setiap refresh token hanya bisa dipakai sekali. Token family = auth_session:
+-- semua refresh token hasil rotasi dari satu login berbagi session_id yang
+-- sama. Jika token yang sudah di-rotate dipakai lagi (indikasi token dicuri),
+-- seluruh family di-revoke dengan me-revoke auth_session-nya.
+
+CREATE TABLE IF NOT EXISTS refresh_token (
+    id          UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    session_id  UUID NOT NULL REFERENCES auth_session(id) ON DELETE CASCADE,
+    user_type   VARCHAR(20) NOT NULL CHECK (user_type IN ('ADMIN', 'BUYER')),
+    user_id     UUID NOT NULL,
+    token_hash  VARCHAR(64) NOT NULL,
+    parent_id   UUID REFERENCES refresh_token(id) ON DELETE SET NULL,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    rotated_at  TIMESTAMPTZ,
+    revoked_at  TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT refresh_token_token_hash_key UNIQUE (token_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_token_session ON refresh_token(session_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_token_user ON refresh_token(user_type, user_id);
+
+COMMENT ON TABLE refresh_token IS 'Refresh token sekali pakai; family = auth_session (session_id)';
+COMMENT ON COLUMN refresh_token.token_hash IS 'SHA-256 hex dari refresh token, token mentah tidak pernah disimpan';
+COMMENT ON COLUMN refresh_token.parent_id IS 'Refresh token sebelumnya yang di-rotate menjadi token ini';
+COMMENT ON COLUMN refresh_token.rotated_at IS 'Diisi saat token ditukar; pemakaian ulang setelahnya = reuse';
+COMMENT ON COLUMN refresh_token.revoked_at IS 'Diisi saat family di-revoke (reuse, logout, dsb.)';
@@ -0,0 +1,2 @@
+-- migrations/000183_create_login_throttle.down.sql
+DROP TABLE IF EXISTS login_throttle;
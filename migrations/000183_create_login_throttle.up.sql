@@ -0,0 +1,32 @@
+-- migrations/000183_create_login_throttle.up.sql
+-- Proteksi brute-force login.
+--
+-- Latar belakang: login gagal hanya dicatat sebagai LOGIN_FAILED di
+-- activity_log tanpa tindakan apa pun, sehingga password bisa ditebak tanpa
+-- batas lewat /api/panel/auth/login.
+--
+-- Counter disimpan per akun (scope ACCOUNT, identifier = email lowercase) dan
+-- per IP (scope IP, identifier = alamat IP). Setelah beberapa kegagalan, login
+-- berikutnya harus menunggu (exponential backoff); setelah N kegagalan akun
+-- dikunci sementara (locked_at diisi) dan bisa dibuka admin lebih awal.
+
+CREATE TABLE IF NOT EXISTS login_throttle (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    scope           VARCHAR(10) NOT NULL CHECK (scope IN ('ACCOUNT', 'IP')),
+    user_type       VARCHAR(20) NOT NULL CHECK (user_type IN ('ADMIN', 'BUYER')),
+    identifier      VARCHAR(255) NOT NULL,
+    failed_count    INT NOT NULL DEFAULT 0,
+    last_failed_at  TIMESTAMPTZ,
+    blocked_until   TIMESTAMPTZ,
+    locked_at       TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT login_throttle_scope_identifier_key UNIQUE (scope, user_type, identifier)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_throttle_last_failed_at ON login_throttle(last_failed_at);
+
+COMMENT ON TABLE login_throttle IS 'Counter login gagal per akun & per IP untuk backoff dan lockout sementara';
+COMMENT ON COLUMN login_throttle.identifier IS 'Email (lowercase) untuk scope ACCOUNT, alamat IP untuk scope IP';
+COMMENT ON COLUMN login_throttle.blocked_until IS 'Login ditolak (423) sampai waktu ini, baik karena backoff maupun lockout';
+COMMENT ON COLUMN login_throttle.locked_at IS 'Diisi saat lockout penuh tercapai, NULL jika hanya backoff';
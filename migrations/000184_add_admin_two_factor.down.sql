@@ -0,0 +1,9 @@
+-- migrations/000184_add_admin_two_factor.down.sql
+ALTER TABLE role DROP COLUMN IF EXISTS require_two_factor;
+
+ALTER TABLE admin
+    DROP COLUMN IF EXISTS two_factor_last_step,
+    DROP COLUMN IF EXISTS two_factor_recovery_codes,
+    DROP COLUMN IF EXISTS two_factor_confirmed_at,
+    DROP COLUMN IF EXISTS two_factor_secret,
+    DROP COLUMN IF EXISTS two_factor_enabled;
@@ -0,0 +1,27 @@
+-- migrations/000184_add_admin_two_factor.up.sql
+-- Two-factor authentication (TOTP) untuk admin panel.
+--
+-- Latar belakang: admin bisa mengubah status pesanan, membatalkan booking,
+-- mengatur harga cargo WMS, dan menghapus aset hanya dengan email + password.
+-- Admin kini bisa mendaftarkan aplikasi authenticator (TOTP, RFC 6238); jika
+-- aktif, login menjadi dua langkah. Role dengan require_two_factor = true
+-- wajib mendaftar sebelum bisa mengakses endpoint ber-permission.
+
+ALTER TABLE admin
+    ADD COLUMN IF NOT EXISTS two_factor_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS two_factor_secret VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS two_factor_confirmed_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS two_factor_recovery_codes JSONB,
+    ADD COLUMN IF NOT EXISTS two_factor_last_step BIGINT;
+
+COMMENT ON COLUMN admin.two_factor_secret IS 'Secret TOTP (base32) terenkripsi AES-GCM; terisi saat setup walau belum dikonfirmasi';
+COMMENT ON COLUMN admin.two_factor_recovery_codes IS 'Array SHA-256 hex dari recovery code yang belum dipakai';
+COMMENT ON COLUMN admin.two_factor_last_step IS 'Time-step TOTP terakhir yang diterima, mencegah kode yang sama dipakai ulang';
+
+ALTER TABLE role
+    ADD COLUMN IF NOT EXISTS require_two_factor BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN role.require_two_factor IS 'Admin dengan role ini wajib mengaktifkan 2FA';
+
+-- Role dengan akses paling sensitif (lihat 000167)
+UPDATE role SET require_two_factor = TRUE WHERE kode IN ('SUPER_ADMIN', 'FINANCE');
@@ -0,0 +1,2 @@
+-- migrations/000185_create_password_reset_token.down.sql
+DROP TABLE IF EXISTS password_reset_token;
@@ -0,0 +1,24 @@
+-- migrations/000185_create_password_reset_token.up.sql
+-- Reset password mandiri lewat token sekali pakai yang dikirim via email.
+--
+-- Latar belakang: selama ini password hanya bisa di-reset oleh admin lain
+-- (PUT /panel/admin/:id/reset-password), sehingga setiap lupa password menjadi
+-- tiket support. Token mentah hanya dikirim ke email; database menyimpan hash-nya.
+
+CREATE TABLE IF NOT EXISTS password_reset_token (
+    id            UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_type     VARCHAR(20) NOT NULL CHECK (user_type IN ('ADMIN', 'BUYER')),
+    user_id       UUID NOT NULL,
+    token_hash    VARCHAR(64) NOT NULL,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    used_at       TIMESTAMPTZ,
+    requested_ip  VARCHAR(45),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT password_reset_token_token_hash_key UNIQUE (token_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_token_user ON password_reset_token(user_type, user_id);
+
+COMMENT ON TABLE password_reset_token IS 'Token reset password sekali pakai (forgot password)';
+COMMENT ON COLUMN password_reset_token.token_hash IS 'SHA-256 hex dari token, token mentah tidak pernah disimpan';
+COMMENT ON COLUMN password_reset_token.used_at IS 'Diisi saat token dipakai atau digantikan token yang lebih baru';
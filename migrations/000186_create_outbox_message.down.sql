@@ -0,0 +1,2 @@
+-- migrations/000186_create_outbox_message.down.sql
+DROP TABLE IF EXISTS outbox_message;
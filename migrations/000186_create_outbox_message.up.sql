@@ -0,0 +1,38 @@
+-- migrations/000186_create_outbox_message.up.sql
+-- Transactional outbox untuk efek samping eksternal (notifikasi storefront,
+-- trigger booking provider, email).
+--
+-- Latar belakang: notifikasi set-ready storefront dipanggil langsung via HTTP
+-- setelah status berubah dan hanya di-log jika gagal, sehingga buyer PICKUP bisa
+-- tidak pernah menerima WA "pesanan siap diambil". Pesan outbox ditulis dalam
+-- transaksi yang sama dengan perubahan status, lalu dikirim dispatcher dengan
+-- retry + exponential backoff. Pesan yang melewati batas percobaan menjadi DEAD
+-- dan bisa di-replay admin.
+
+CREATE TABLE IF NOT EXISTS outbox_message (
+    id               UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_type       VARCHAR(100) NOT NULL,
+    aggregate_type   VARCHAR(50) NOT NULL,
+    aggregate_id     UUID,
+    payload          JSONB NOT NULL DEFAULT '{}'::jsonb,
+    status           VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'SENT', 'DEAD')),
+    attempts         INT NOT NULL DEFAULT 0,
+    max_attempts     INT NOT NULL DEFAULT 8,
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until     TIMESTAMPTZ,
+    last_error       TEXT,
+    sent_at          TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Dipakai dispatcher untuk mengambil pesan yang jatuh tempo
+CREATE INDEX IF NOT EXISTS idx_outbox_message_due ON outbox_message(next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_outbox_message_status ON outbox_message(status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_outbox_message_aggregate ON outbox_message(aggregate_type, aggregate_id);
+
+COMMENT ON TABLE outbox_message IS 'Pesan efek samping eksternal yang dikirim dispatcher (transactional outbox)';
+COMMENT ON COLUMN outbox_message.event_type IS 'Jenis event, menentukan handler dispatcher (mis. storefront.pesanan_set_ready)';
+COMMENT ON COLUMN outbox_message.status IS 'PENDING (menunggu/retry), SENT (berhasil), DEAD (melewati max_attempts, perlu replay)';
+COMMENT ON COLUMN outbox_message.locked_until IS 'Lease dispatcher; pesan tidak diambil instance lain sebelum lewat';
+COMMENT ON COLUMN outbox_message.last_error IS 'Error percobaan terakhir';
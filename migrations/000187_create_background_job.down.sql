@@ -0,0 +1,2 @@
+-- migrations/000187_create_background_job.down.sql
+DROP TABLE IF EXISTS background_job;
@@ -0,0 +1,37 @@
+-- migrations/000187_create_background_job.up.sql
+-- Antrean job background berbasis Postgres (FOR UPDATE SKIP LOCKED).
+--
+-- Latar belakang: booking pengiriman, transcode video dan auto-archive produk
+-- sebelumnya berjalan di goroutine/ticker dalam proses API, sehingga deploy di
+-- tengah proses menghilangkan pekerjaan (video tertahan di status processing,
+-- booking tidak pernah terkirim). Job disimpan di tabel ini dan diambil worker
+-- dengan visibility timeout: job yang worker-nya mati diambil ulang setelah
+-- locked_until lewat.
+
+CREATE TABLE IF NOT EXISTS background_job (
+    id            UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    job_type      VARCHAR(100) NOT NULL,
+    payload       JSONB NOT NULL DEFAULT '{}'::jsonb,
+    status        VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'RUNNING', 'DONE', 'DEAD')),
+    attempts      INT NOT NULL DEFAULT 0,
+    max_attempts  INT NOT NULL DEFAULT 5,
+    run_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until  TIMESTAMPTZ,
+    locked_by     VARCHAR(100),
+    unique_key    VARCHAR(200),
+    last_error    TEXT,
+    started_at    TIMESTAMPTZ,
+    finished_at   TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT background_job_unique_key_key UNIQUE (unique_key)
+);
+
+-- Dipakai worker untuk mengambil job siap jalan per job_type
+CREATE INDEX IF NOT EXISTS idx_background_job_ready ON background_job(job_type, run_at) WHERE status IN ('PENDING', 'RUNNING');
+CREATE INDEX IF NOT EXISTS idx_background_job_status ON background_job(status, created_at DESC);
+
+COMMENT ON TABLE background_job IS 'Antrean job background (booking pengiriman, transcode video, job terjadwal)';
+COMMENT ON COLUMN background_job.status IS 'PENDING (menunggu/retry), RUNNING (sedang dikerjakan), DONE, DEAD (melewati max_attempts)';
+COMMENT ON COLUMN background_job.locked_until IS 'Visibility timeout: job RUNNING yang lewat batas ini dianggap worker-nya mati dan diambil ulang';
+COMMENT ON COLUMN background_job.unique_key IS 'Deduplikasi, mis. slot job cron agar tidak dobel di banyak instance';
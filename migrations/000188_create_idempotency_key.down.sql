@@ -0,0 +1,2 @@
+-- migrations/000188_create_idempotency_key.down.sql
+DROP TABLE IF EXISTS idempotency_key;
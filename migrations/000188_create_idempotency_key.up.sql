@@ -0,0 +1,35 @@
+-- migrations/000188_create_idempotency_key.up.sql
+-- Penyimpanan Idempotency-Key untuk endpoint panel yang mengubah data dan
+-- webhook provider.
+--
+-- Latar belakang: request yang diulang (double-click retry booking, webhook
+-- Deliveree yang dikirim ulang, set harga cargo WMS) bisa diproses dua kali.
+-- Respons pertama disimpan per (scope, method, route, key) selama TTL dan
+-- dikirim ulang apa adanya untuk request berikutnya dengan key yang sama.
+-- Key yang sama dengan body berbeda ditolak.
+
+CREATE TABLE IF NOT EXISTS idempotency_key (
+    id                     UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    scope                  VARCHAR(100) NOT NULL,
+    method                 VARCHAR(10) NOT NULL,
+    route                  VARCHAR(500) NOT NULL,
+    request_key            VARCHAR(255) NOT NULL,
+    request_hash           VARCHAR(64) NOT NULL,
+    status                 VARCHAR(20) NOT NULL DEFAULT 'IN_PROGRESS' CHECK (status IN ('IN_PROGRESS', 'COMPLETED')),
+    response_status        INT,
+    response_content_type  VARCHAR(255),
+    response_body          BYTEA,
+    expires_at             TIMESTAMPTZ NOT NULL,
+    created_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_idempotency_key UNIQUE (scope, method, route, request_key)
+);
+
+-- Dipakai cleanup scheduler untuk menghapus key yang sudah kedaluwarsa
+CREATE INDEX IF NOT EXISTS idx_idempotency_key_expires_at ON idempotency_key(expires_at);
+
+COMMENT ON TABLE idempotency_key IS 'Respons pertama per Idempotency-Key, dikirim ulang untuk request yang diulang';
+COMMENT ON COLUMN idempotency_key.scope IS 'Pemilik key: <user_type>:<user_id> untuk panel, webhook:<provider> untuk webhook';
+COMMENT ON COLUMN idempotency_key.route IS 'Path request (termasuk parameter), key yang sama di route lain dianggap berbeda';
+COMMENT ON COLUMN idempotency_key.request_hash IS 'SHA-256 body request; key yang sama dengan hash berbeda ditolak';
+COMMENT ON COLUMN idempotency_key.status IS 'IN_PROGRESS (request pertama masih diproses), COMPLETED (respons tersimpan)';
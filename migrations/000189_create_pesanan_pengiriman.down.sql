@@ -0,0 +1,4 @@
+-- migrations/000189_create_pesanan_pengiriman.down.sql
+DROP INDEX IF EXISTS idx_pesanan_item_pengiriman_id;
+ALTER TABLE pesanan_item DROP COLUMN IF EXISTS pesanan_pengiriman_id;
+DROP TABLE IF EXISTS pesanan_pengiriman;
@@ -0,0 +1,76 @@
+-- migrations/000189_create_pesanan_pengiriman.up.sql
+-- Pengiriman per gudang asal untuk pesanan delivery.
+--
+-- Latar belakang: booking sebelumnya selalu memakai gudang aktif tertua sebagai
+-- titik pickup, padahal setiap produk punya warehouse_id sendiri. Pesanan yang
+-- barangnya tersimpan di gudang lain ter-booking dari lokasi yang salah.
+-- Item pesanan kini dikelompokkan per gudang produk dan setiap kelompok
+-- di-booking sebagai satu pengiriman dengan booking ID, tracking dan status
+-- masing-masing.
+--
+-- Kolom deliveree_booking_id / forwarder_tracking_no / booking_status /
+-- tracking_url di tabel pesanan tetap diisi dari pengiriman utama (urutan 1)
+-- agar klien lama tetap berjalan.
+
+CREATE TABLE IF NOT EXISTS pesanan_pengiriman (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    pesanan_id      UUID NOT NULL REFERENCES pesanan(id) ON DELETE CASCADE,
+    warehouse_id    UUID NOT NULL REFERENCES warehouse(id),
+    urutan          INT NOT NULL DEFAULT 1,
+    delivery_type   delivery_type NOT NULL,
+    booking_ref     VARCHAR(100),
+    booking_status  VARCHAR(50),
+    booking_error   TEXT,
+    tracking_url    TEXT,
+    booked_at       TIMESTAMPTZ,
+    shipped_at      TIMESTAMPTZ,
+    completed_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT pesanan_pengiriman_pesanan_warehouse_key UNIQUE (pesanan_id, warehouse_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pesanan_pengiriman_pesanan_id ON pesanan_pengiriman(pesanan_id, urutan);
+-- Dipakai webhook provider untuk mencari pengiriman dari booking ref
+CREATE INDEX IF NOT EXISTS idx_pesanan_pengiriman_booking_ref ON pesanan_pengiriman(booking_ref) WHERE booking_ref IS NOT NULL;
+
+ALTER TABLE pesanan_item
+    ADD COLUMN IF NOT EXISTS pesanan_pengiriman_id UUID REFERENCES pesanan_pengiriman(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_pesanan_item_pengiriman_id ON pesanan_item(pesanan_pengiriman_id);
+
+-- Backfill: pesanan yang sudah ter-booking dicatat sebagai satu pengiriman dari
+-- gudang produk pertamanya (atau gudang aktif tertua bila produk tidak ada)
+INSERT INTO pesanan_pengiriman (pesanan_id, warehouse_id, urutan, delivery_type, booking_ref, booking_status, booking_error, tracking_url, booked_at, shipped_at, completed_at)
+SELECT p.id,
+       COALESCE(
+           (SELECT pr.warehouse_id FROM pesanan_item pi JOIN produk pr ON pr.id = pi.produk_id
+            WHERE pi.pesanan_id = p.id ORDER BY pi.created_at ASC LIMIT 1),
+           (SELECT w.id FROM warehouse w WHERE w.is_active = true ORDER BY w.created_at ASC LIMIT 1)
+       ),
+       1,
+       p.delivery_type,
+       COALESCE(p.deliveree_booking_id, p.forwarder_tracking_no),
+       p.booking_status,
+       p.booking_error,
+       p.tracking_url,
+       p.updated_at,
+       p.shipped_at,
+       p.completed_at
+FROM pesanan p
+WHERE p.deleted_at IS NULL
+  AND (p.deliveree_booking_id IS NOT NULL OR p.forwarder_tracking_no IS NOT NULL)
+  AND EXISTS (SELECT 1 FROM warehouse w WHERE w.is_active = true)
+ON CONFLICT (pesanan_id, warehouse_id) DO NOTHING;
+
+UPDATE pesanan_item pi
+SET pesanan_pengiriman_id = pp.id
+FROM pesanan_pengiriman pp
+WHERE pp.pesanan_id = pi.pesanan_id
+  AND pi.pesanan_pengiriman_id IS NULL;
+
+COMMENT ON TABLE pesanan_pengiriman IS 'Pengiriman pesanan per gudang asal; satu pesanan delivery bisa punya beberapa pengiriman';
+COMMENT ON COLUMN pesanan_pengiriman.urutan IS 'Urutan pengiriman dalam pesanan; urutan 1 adalah pengiriman utama yang di-mirror ke tabel pesanan';
+COMMENT ON COLUMN pesanan_pengiriman.booking_ref IS 'Booking ID Deliveree atau booking number Forwarder';
+COMMENT ON COLUMN pesanan_pengiriman.booking_status IS 'Status terakhir dari webhook provider (locating_driver, delivery_in_progress, dsb.)';
+COMMENT ON COLUMN pesanan_item.pesanan_pengiriman_id IS 'Pengiriman yang membawa item ini (diisi saat booking)';
@@ -0,0 +1,6 @@
+-- migrations/000190_create_shipping_coverage_zone.down.sql
+DELETE FROM role_permission
+WHERE permission_id IN (SELECT id FROM permission WHERE kode IN ('shipping_coverage:read', 'shipping_coverage:manage'));
+DELETE FROM permission WHERE kode IN ('shipping_coverage:read', 'shipping_coverage:manage');
+
+DROP TABLE IF EXISTS shipping_coverage_zone;
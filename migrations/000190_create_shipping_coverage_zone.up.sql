@@ -0,0 +1,86 @@
+-- migrations/000190_create_shipping_coverage_zone.up.sql
+-- Zona coverage pengiriman per provider, dikelola dari admin panel.
+--
+-- Latar belakang: daftar provinsi yang dilayani Deliveree dan Forwarder darat
+-- (LTL) sebelumnya berupa map di kode, sehingga perubahan coverage butuh
+-- deploy ulang. Ejaan provinsi dari Google Maps yang tidak ada di map membuat
+-- pesanan Forwarder diam-diam dialihkan ke laut (LCL).
+--
+-- Satu baris = satu provinsi (kota NULL) atau satu kota tertentu yang dilayani
+-- provider. Ejaan lain (bahasa Inggris, singkatan) dicatat di kolom aliases.
+-- Provider FORWARDER berarti jalur darat (LTL); alamat di luar zona FORWARDER
+-- dikirim via laut (FORWARDER_LCL).
+
+CREATE TABLE IF NOT EXISTS shipping_coverage_zone (
+    id          UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    provider    delivery_type NOT NULL,
+    provinsi    VARCHAR(100) NOT NULL,
+    kota        VARCHAR(100),
+    aliases     JSONB NOT NULL DEFAULT '[]'::jsonb,
+    is_active   BOOLEAN NOT NULL DEFAULT TRUE,
+    catatan     TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at  TIMESTAMPTZ,
+    CONSTRAINT shipping_coverage_zone_provider_check CHECK (provider IN ('DELIVEREE', 'FORWARDER'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_shipping_coverage_zone_wilayah
+    ON shipping_coverage_zone(provider, LOWER(provinsi), COALESCE(LOWER(kota), ''))
+    WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_shipping_coverage_zone_deleted_at ON shipping_coverage_zone(deleted_at);
+
+COMMENT ON TABLE shipping_coverage_zone IS 'Zona coverage pengiriman per provider (provinsi atau kota), pengganti daftar provinsi hard-coded';
+COMMENT ON COLUMN shipping_coverage_zone.provider IS 'DELIVEREE atau FORWARDER (jalur darat/LTL). Di luar zona FORWARDER dikirim via LCL';
+COMMENT ON COLUMN shipping_coverage_zone.kota IS 'NULL = seluruh provinsi; terisi = hanya kota tersebut';
+COMMENT ON COLUMN shipping_coverage_zone.aliases IS 'Ejaan lain provinsi/kota (mis. nama bahasa Inggris dari Google Maps)';
+
+-- Seed dari daftar provinsi yang sebelumnya hard-coded
+INSERT INTO shipping_coverage_zone (provider, provinsi, aliases)
+SELECT p.provider::delivery_type, z.provinsi, z.aliases::jsonb
+FROM (VALUES
+    ('Bali', '[]'),
+    ('Banten', '[]'),
+    ('Bengkulu', '[]'),
+    ('Daerah Istimewa Yogyakarta', '["special region of yogyakarta", "di yogyakarta", "yogyakarta"]'),
+    ('DKI Jakarta', '["jakarta"]'),
+    ('Jambi', '[]'),
+    ('Jawa Barat', '["west java"]'),
+    ('Jawa Tengah', '["central java"]'),
+    ('Jawa Timur', '["east java"]'),
+    ('Lampung', '[]'),
+    ('Aceh', '[]'),
+    ('Riau', '[]'),
+    ('Kepulauan Riau', '["riau islands"]'),
+    ('Kepulauan Bangka Belitung', '["bangkabelitung islands"]'),
+    ('Sumatera Barat', '["west sumatra"]'),
+    ('Sumatera Selatan', '["south sumatra"]'),
+    ('Sumatera Utara', '["north sumatra"]')
+) AS z(provinsi, aliases)
+CROSS JOIN (VALUES ('DELIVEREE'), ('FORWARDER')) AS p(provider)
+ON CONFLICT DO NOTHING;
+
+-- Forwarder darat juga melayani Nusa Tenggara Barat, Gorontalo & Sulawesi
+INSERT INTO shipping_coverage_zone (provider, provinsi, aliases) VALUES
+    ('FORWARDER', 'Gorontalo', '[]'),
+    ('FORWARDER', 'Nusa Tenggara Barat', '["west nusa tenggara"]'),
+    ('FORWARDER', 'Sulawesi Utara', '["north sulawesi"]'),
+    ('FORWARDER', 'Sulawesi Tengah', '["central sulawesi"]'),
+    ('FORWARDER', 'Sulawesi Selatan', '["south sulawesi"]'),
+    ('FORWARDER', 'Sulawesi Tenggara', '["southeast sulawesi"]'),
+    ('FORWARDER', 'Sulawesi Barat', '["west sulawesi"]')
+ON CONFLICT DO NOTHING;
+
+-- Permission kelola zona coverage (Super Admin)
+INSERT INTO permission (nama, kode, modul, deskripsi) VALUES
+    ('View Shipping Coverage', 'shipping_coverage:read', 'shipping', 'Melihat zona coverage pengiriman & tes alamat'),
+    ('Manage Shipping Coverage', 'shipping_coverage:manage', 'shipping', 'Menambah, mengubah & menghapus zona coverage pengiriman')
+ON CONFLICT (kode) DO NOTHING;
+
+INSERT INTO role_permission (role_id, permission_id)
+SELECT r.id, p.id
+FROM role r
+CROSS JOIN permission p
+WHERE r.nama = 'Super Admin'
+AND p.kode IN ('shipping_coverage:read', 'shipping_coverage:manage')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
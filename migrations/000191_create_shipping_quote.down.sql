@@ -0,0 +1,3 @@
+-- migrations/000191_create_shipping_quote.down.sql
+ALTER TABLE pesanan DROP COLUMN IF EXISTS shipping_quote_id;
+DROP TABLE IF EXISTS shipping_quote;
@@ -0,0 +1,39 @@
+-- migrations/000191_create_shipping_quote.up.sql
+-- Quote ongkir per provider untuk item + alamat tujuan.
+--
+-- Latar belakang: pemilihan kendaraan Deliveree (kubikasi & berat) hanya
+-- berjalan saat booking, sedangkan pesanan.biaya_pengiriman berisi angka apa
+-- pun yang dikirim storefront. Quote menghitung opsi per provider (kendaraan,
+-- jalur LTL/LCL, harga, estimasi hari) di depan dan disimpan di sini.
+--
+-- Quote dengan request_hash yang sama dipakai ulang sampai expires_at tanpa
+-- memanggil API provider lagi. pesanan.shipping_quote_id mencatat quote yang
+-- dipakai saat checkout agar booking bisa mencocokkan ongkir yang ditagih.
+
+CREATE TABLE IF NOT EXISTS shipping_quote (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    request_hash    VARCHAR(64) NOT NULL,
+    source          VARCHAR(20) NOT NULL,
+    alamat_buyer_id UUID REFERENCES alamat_buyer(id) ON DELETE SET NULL,
+    provinsi        VARCHAR(100) NOT NULL DEFAULT '',
+    kota            VARCHAR(100) NOT NULL DEFAULT '',
+    kecamatan       VARCHAR(100),
+    items           JSONB NOT NULL DEFAULT '[]'::jsonb,
+    options         JSONB NOT NULL DEFAULT '[]'::jsonb,
+    expires_at      TIMESTAMPTZ NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT shipping_quote_source_check CHECK (source IN ('ADMIN', 'STOREFRONT'))
+);
+
+-- Lookup cache: quote terbaru yang belum kedaluwarsa untuk request yang sama
+CREATE INDEX IF NOT EXISTS idx_shipping_quote_request_hash ON shipping_quote(request_hash, expires_at DESC);
+CREATE INDEX IF NOT EXISTS idx_shipping_quote_expires_at ON shipping_quote(expires_at);
+
+COMMENT ON TABLE shipping_quote IS 'Quote ongkir per provider (kendaraan, jalur, harga, estimasi) untuk item + alamat';
+COMMENT ON COLUMN shipping_quote.request_hash IS 'SHA-256 dari item & alamat yang dinormalisasi, kunci cache quote';
+COMMENT ON COLUMN shipping_quote.options IS 'Opsi per delivery type beserta rincian per gudang asal';
+
+ALTER TABLE pesanan
+    ADD COLUMN IF NOT EXISTS shipping_quote_id UUID REFERENCES shipping_quote(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN pesanan.shipping_quote_id IS 'Quote ongkir yang dipakai saat checkout; dicocokkan dengan biaya_pengiriman saat booking';
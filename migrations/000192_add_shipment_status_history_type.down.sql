@@ -0,0 +1,4 @@
+-- migrations/000192_add_shipment_status_history_type.down.sql
+-- PostgreSQL tidak support DROP VALUE dari enum.
+-- Rollback manual: hapus riwayat status_type SHIPMENT lalu buat ulang enum
+-- tanpa SHIPMENT jika diperlukan.
@@ -0,0 +1,5 @@
+-- migrations/000192_add_shipment_status_history_type.up.sql
+-- Riwayat aksi pengiriman (batal booking / booking ulang) dicatat di
+-- pesanan_status_history dengan status_type SHIPMENT agar tampil di timeline
+-- pesanan yang sama dengan perubahan status order & pembayaran.
+ALTER TYPE status_history_type ADD VALUE IF NOT EXISTS 'SHIPMENT';
@@ -0,0 +1,2 @@
+-- migrations/000193_create_pesanan_pengiriman_event.down.sql
+DROP TABLE IF EXISTS pesanan_pengiriman_event;
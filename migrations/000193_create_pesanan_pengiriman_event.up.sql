@@ -0,0 +1,32 @@
+-- migrations/000193_create_pesanan_pengiriman_event.up.sql
+-- Riwayat event tracking per pengiriman.
+--
+-- Latar belakang: tracking di panel selalu diambil langsung dari provider dan
+-- webhook hanya memperbarui booking_status/tracking_url, sehingga tidak ada
+-- timeline tersimpan — saat API provider down admin tidak melihat apa pun.
+-- Event kini disimpan dari webhook, dari poller terjadwal (Forwarder tidak
+-- selalu mengirim webhook) dan dari fetch live di panel. Event yang sama dari
+-- sumber berbeda dideduplikasi lewat provider_event_id per booking.
+
+CREATE TABLE IF NOT EXISTS pesanan_pengiriman_event (
+    id                     UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    pesanan_pengiriman_id  UUID NOT NULL REFERENCES pesanan_pengiriman(id) ON DELETE CASCADE,
+    pesanan_id             UUID NOT NULL REFERENCES pesanan(id) ON DELETE CASCADE,
+    booking_ref            VARCHAR(100) NOT NULL,
+    provider_event_id      VARCHAR(150) NOT NULL,
+    source                 VARCHAR(20) NOT NULL CHECK (source IN ('WEBHOOK', 'POLLING')),
+    status                 VARCHAR(255) NOT NULL,
+    tracking_url           TEXT,
+    occurred_at            TIMESTAMPTZ NOT NULL,
+    created_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT pesanan_pengiriman_event_dedupe_key UNIQUE (pesanan_pengiriman_id, booking_ref, provider_event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pesanan_pengiriman_event_pengiriman ON pesanan_pengiriman_event(pesanan_pengiriman_id, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_pesanan_pengiriman_event_pesanan ON pesanan_pengiriman_event(pesanan_id);
+
+COMMENT ON TABLE pesanan_pengiriman_event IS 'Timeline tracking pengiriman dari webhook, poller dan fetch live provider';
+COMMENT ON COLUMN pesanan_pengiriman_event.booking_ref IS 'Booking ref saat event diterima; event booking lama tetap tersimpan setelah booking ulang';
+COMMENT ON COLUMN pesanan_pengiriman_event.provider_event_id IS 'Kunci dedupe event dari provider (status webhook atau tanggal+jam+status tracking)';
+COMMENT ON COLUMN pesanan_pengiriman_event.source IS 'WEBHOOK atau POLLING (poller terjadwal / fetch live di panel)';
+COMMENT ON COLUMN pesanan_pengiriman_event.occurred_at IS 'Waktu event menurut provider; waktu diterima jika provider tidak mengirim waktu';
@@ -0,0 +1,6 @@
+-- migrations/000194_create_provider_webhook_log.down.sql
+DELETE FROM role_permission
+WHERE permission_id IN (SELECT id FROM permission WHERE kode IN ('webhook_log:read', 'webhook_log:reprocess'));
+DELETE FROM permission WHERE kode IN ('webhook_log:read', 'webhook_log:reprocess');
+
+DROP TABLE IF EXISTS provider_webhook_log;
@@ -0,0 +1,54 @@
+-- migrations/000194_create_provider_webhook_log.up.sql
+-- Log mentah webhook provider pengiriman (Deliveree/Forwarder).
+--
+-- Latar belakang: webhook hanya diverifikasi dengan header Authorization
+-- statis, sehingga siapa pun yang pernah melihat header tersebut bisa
+-- mengirim ulang (replay) atau memalsukan status yang menyelesaikan pesanan.
+-- Webhook kini bisa diverifikasi dengan signature HMAC + toleransi timestamp,
+-- dan setiap request yang masuk disimpan mentah untuk audit — termasuk yang
+-- ditolak. Baris ACCEPTED yang belum FAILED sekaligus menjadi penyimpanan
+-- event yang sudah dilihat: event yang sama ditolak sebagai REPLAY.
+
+CREATE TABLE IF NOT EXISTS provider_webhook_log (
+    id               UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    provider         VARCHAR(20) NOT NULL CHECK (provider IN ('DELIVEREE', 'FORWARDER')),
+    event_key        VARCHAR(128),
+    booking_ref      VARCHAR(100),
+    status           VARCHAR(100),
+    headers          JSONB NOT NULL DEFAULT '{}',
+    body             TEXT NOT NULL DEFAULT '',
+    remote_ip        VARCHAR(64),
+    verification     VARCHAR(30) NOT NULL CHECK (verification IN ('ACCEPTED', 'REPLAY', 'UNAUTHORIZED', 'INVALID_SIGNATURE', 'STALE_TIMESTAMP', 'INVALID_PAYLOAD')),
+    process_status   VARCHAR(20) NOT NULL DEFAULT 'SKIPPED' CHECK (process_status IN ('PENDING', 'PROCESSED', 'IGNORED', 'FAILED', 'SKIPPED')),
+    process_error    TEXT,
+    reprocess_count  INT NOT NULL DEFAULT 0,
+    received_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at     TIMESTAMPTZ
+);
+
+-- Satu event hanya boleh diterima sekali selama belum gagal diproses;
+-- event yang gagal boleh dikirim ulang provider
+CREATE UNIQUE INDEX IF NOT EXISTS uq_provider_webhook_log_event ON provider_webhook_log(provider, event_key)
+    WHERE verification = 'ACCEPTED' AND process_status <> 'FAILED';
+CREATE INDEX IF NOT EXISTS idx_provider_webhook_log_received ON provider_webhook_log(received_at DESC);
+CREATE INDEX IF NOT EXISTS idx_provider_webhook_log_booking ON provider_webhook_log(provider, booking_ref);
+
+COMMENT ON TABLE provider_webhook_log IS 'Log mentah webhook provider pengiriman untuk audit, deteksi replay & proses ulang';
+COMMENT ON COLUMN provider_webhook_log.event_key IS 'Kunci event: header X-Webhook-Event-Id, atau hash booking+status+tracking URL+timestamp bertanda tangan (tanpa signature: jendela 10 menit)';
+COMMENT ON COLUMN provider_webhook_log.headers IS 'Header request; Authorization & signature disamarkan';
+COMMENT ON COLUMN provider_webhook_log.verification IS 'Hasil verifikasi: ACCEPTED, REPLAY, UNAUTHORIZED, INVALID_SIGNATURE, STALE_TIMESTAMP, INVALID_PAYLOAD';
+COMMENT ON COLUMN provider_webhook_log.process_status IS 'PENDING, PROCESSED (pesanan diperbarui), IGNORED (tidak ada yang cocok), FAILED, SKIPPED (tidak lolos verifikasi)';
+COMMENT ON COLUMN provider_webhook_log.reprocess_count IS 'Jumlah proses ulang manual oleh admin';
+
+INSERT INTO permission (nama, kode, modul, deskripsi) VALUES
+    ('View Webhook Log', 'webhook_log:read', 'shipping', 'Melihat log webhook provider pengiriman'),
+    ('Reprocess Webhook', 'webhook_log:reprocess', 'shipping', 'Memproses ulang webhook provider pengiriman yang tersimpan')
+ON CONFLICT (kode) DO NOTHING;
+
+INSERT INTO role_permission (role_id, permission_id)
+SELECT r.id, p.id
+FROM role r
+CROSS JOIN permission p
+WHERE r.nama = 'Super Admin'
+AND p.kode IN ('webhook_log:read', 'webhook_log:reprocess')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
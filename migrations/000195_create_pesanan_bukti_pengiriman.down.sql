@@ -0,0 +1,2 @@
+-- migrations/000195_create_pesanan_bukti_pengiriman.down.sql
+DROP TABLE IF EXISTS pesanan_bukti_pengiriman;
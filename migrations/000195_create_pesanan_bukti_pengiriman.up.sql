@@ -0,0 +1,47 @@
+-- migrations/000195_create_pesanan_bukti_pengiriman.up.sql
+-- Bukti pengiriman (proof of delivery) per pengiriman Deliveree.
+--
+-- Latar belakang: tanda tangan penerima, nama penerima, waktu tiba dan biaya
+-- tambahan (parkir, tol, waktu tunggu) hanya tersedia lewat detail Deliveree
+-- yang diambil langsung saat admin membuka panel, dan tidak pernah disimpan.
+-- Untuk sengketa dengan buyer dibutuhkan catatan permanen: saat pesanan
+-- COMPLETED detail diambil sekali, gambar tanda tangan disalin ke storage
+-- upload kita dan datanya disimpan di tabel ini.
+
+CREATE TABLE IF NOT EXISTS pesanan_bukti_pengiriman (
+    id                      UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    pesanan_pengiriman_id   UUID NOT NULL REFERENCES pesanan_pengiriman(id) ON DELETE CASCADE,
+    pesanan_id              UUID NOT NULL REFERENCES pesanan(id) ON DELETE CASCADE,
+    booking_ref             VARCHAR(100) NOT NULL,
+    delivery_status         VARCHAR(50),
+    recipient_name          VARCHAR(255),
+    recipient_phone         VARCHAR(50),
+    failed_delivery_reason  TEXT,
+    signature_source_url    TEXT,
+    signature_path          VARCHAR(500),
+    driver_name             VARCHAR(255),
+    driver_phone            VARCHAR(50),
+    arrived_at              TIMESTAMPTZ,
+    left_at                 TIMESTAMPTZ,
+    completed_at            TIMESTAMPTZ,
+    parking_fees            DECIMAL(15,2) NOT NULL DEFAULT 0,
+    tolls_fees              DECIMAL(15,2) NOT NULL DEFAULT 0,
+    waiting_time_fees       DECIMAL(15,2) NOT NULL DEFAULT 0,
+    total_fees              DECIMAL(15,2) NOT NULL DEFAULT 0,
+    currency                VARCHAR(10),
+    raw_detail              JSONB NOT NULL DEFAULT '{}',
+    captured_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT pesanan_bukti_pengiriman_booking_key UNIQUE (pesanan_pengiriman_id, booking_ref)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pesanan_bukti_pengiriman_pesanan ON pesanan_bukti_pengiriman(pesanan_id);
+
+COMMENT ON TABLE pesanan_bukti_pengiriman IS 'Bukti pengiriman Deliveree yang disimpan permanen saat pesanan COMPLETED';
+COMMENT ON COLUMN pesanan_bukti_pengiriman.signature_source_url IS 'URL tanda tangan asli dari Deliveree (bisa kedaluwarsa)';
+COMMENT ON COLUMN pesanan_bukti_pengiriman.signature_path IS 'Salinan tanda tangan di storage upload (relatif terhadap UPLOAD_PATH)';
+COMMENT ON COLUMN pesanan_bukti_pengiriman.arrived_at IS 'Waktu driver tiba di lokasi tujuan';
+COMMENT ON COLUMN pesanan_bukti_pengiriman.parking_fees IS 'Total biaya parkir semua lokasi booking';
+COMMENT ON COLUMN pesanan_bukti_pengiriman.total_fees IS 'Total biaya booking menurut Deliveree';
+COMMENT ON COLUMN pesanan_bukti_pengiriman.raw_detail IS 'Response detail Deliveree lengkap saat bukti diambil';
@@ -0,0 +1,7 @@
+-- migrations/000196_create_pickup_appointment.down.sql
+DROP TABLE IF EXISTS pickup_appointment;
+DROP TABLE IF EXISTS jadwal_gudang_pengecualian;
+
+ALTER TABLE jadwal_gudang
+    DROP COLUMN IF EXISTS durasi_slot_menit,
+    DROP COLUMN IF EXISTS kapasitas_slot;
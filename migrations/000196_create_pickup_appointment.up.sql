@@ -0,0 +1,57 @@
+-- migrations/000196_create_pickup_appointment.up.sql
+-- Janji pickup (appointment) pesanan PICKUP di gudang.
+--
+-- Latar belakang: buyer pesanan PICKUP datang tanpa jadwal sehingga gudang
+-- sering kedatangan banyak buyer sekaligus. Slot pickup kini dibentuk dari
+-- jadwal_gudang (durasi & kapasitas per hari), dengan pengecualian per tanggal
+-- untuk libur/penutupan atau jam khusus. Buyer memesan satu slot per gudang
+-- asal barang untuk pesanan yang sudah READY.
+
+ALTER TABLE jadwal_gudang
+    ADD COLUMN IF NOT EXISTS durasi_slot_menit INT NOT NULL DEFAULT 60 CHECK (durasi_slot_menit BETWEEN 15 AND 480),
+    ADD COLUMN IF NOT EXISTS kapasitas_slot INT NOT NULL DEFAULT 5 CHECK (kapasitas_slot >= 1);
+
+COMMENT ON COLUMN jadwal_gudang.durasi_slot_menit IS 'Durasi satu slot janji pickup (menit)';
+COMMENT ON COLUMN jadwal_gudang.kapasitas_slot IS 'Jumlah janji pickup maksimal per slot';
+
+CREATE TABLE IF NOT EXISTS jadwal_gudang_pengecualian (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    warehouse_id    UUID NOT NULL REFERENCES warehouse(id) ON DELETE CASCADE,
+    tanggal         DATE NOT NULL,
+    is_tutup        BOOLEAN NOT NULL DEFAULT true,
+    jam_buka        TIME,
+    jam_tutup       TIME,
+    kapasitas_slot  INT CHECK (kapasitas_slot >= 0),
+    keterangan      VARCHAR(255),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT jadwal_gudang_pengecualian_tanggal_key UNIQUE (warehouse_id, tanggal),
+    CONSTRAINT jadwal_gudang_pengecualian_jam_check CHECK (is_tutup OR (jam_buka IS NOT NULL AND jam_tutup IS NOT NULL AND jam_buka < jam_tutup))
+);
+
+COMMENT ON TABLE jadwal_gudang_pengecualian IS 'Libur/penutupan atau jam khusus gudang per tanggal, menimpa jadwal_gudang';
+COMMENT ON COLUMN jadwal_gudang_pengecualian.is_tutup IS 'true = gudang tutup seharian; false = buka dengan jam_buka/jam_tutup khusus';
+COMMENT ON COLUMN jadwal_gudang_pengecualian.kapasitas_slot IS 'Kapasitas per slot khusus tanggal ini; NULL = ikut jadwal_gudang';
+
+CREATE TABLE IF NOT EXISTS pickup_appointment (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    pesanan_id      UUID NOT NULL REFERENCES pesanan(id) ON DELETE CASCADE,
+    warehouse_id    UUID NOT NULL REFERENCES warehouse(id),
+    buyer_id        UUID NOT NULL REFERENCES buyer(id),
+    slot_mulai      TIMESTAMPTZ NOT NULL,
+    slot_selesai    TIMESTAMPTZ NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'BOOKED' CHECK (status IN ('BOOKED', 'CANCELLED', 'COMPLETED')),
+    catatan         VARCHAR(500),
+    alasan_batal    VARCHAR(255),
+    cancelled_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Satu janji aktif per pesanan per gudang asal
+CREATE UNIQUE INDEX IF NOT EXISTS uq_pickup_appointment_active ON pickup_appointment(pesanan_id, warehouse_id)
+    WHERE status = 'BOOKED';
+CREATE INDEX IF NOT EXISTS idx_pickup_appointment_slot ON pickup_appointment(warehouse_id, slot_mulai) WHERE status <> 'CANCELLED';
+
+COMMENT ON TABLE pickup_appointment IS 'Janji pickup buyer untuk pesanan PICKUP yang READY';
+COMMENT ON COLUMN pickup_appointment.status IS 'BOOKED, CANCELLED (buyer/pesanan batal atau dijadwal ulang), COMPLETED (pesanan selesai)';
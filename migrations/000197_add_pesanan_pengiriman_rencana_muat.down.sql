@@ -0,0 +1,3 @@
+-- migrations/000197_add_pesanan_pengiriman_rencana_muat.down.sql
+ALTER TABLE pesanan_pengiriman
+    DROP COLUMN IF EXISTS rencana_muat;
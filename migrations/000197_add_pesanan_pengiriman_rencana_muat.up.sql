@@ -0,0 +1,14 @@
+-- migrations/000197_add_pesanan_pengiriman_rencana_muat.up.sql
+-- Rencana muat Deliveree per pengiriman.
+--
+-- Latar belakang: pemilihan kendaraan hanya membandingkan total kubikasi &
+-- berat dengan kapasitas kendaraan, tanpa melihat apakah palet yang panjang
+-- atau tinggi muat di ruang kargo (cargo_length/width/height). Booking untuk
+-- barang oversize ditolak driver. Sebelum booking, unit barang kini dicek per
+-- dimensi terhadap ruang kargo setiap kendaraan dan dibagi ke satu atau lebih
+-- kendaraan; hasilnya disimpan di sini agar terlihat di detail pesanan admin.
+
+ALTER TABLE pesanan_pengiriman
+    ADD COLUMN IF NOT EXISTS rencana_muat JSONB;
+
+COMMENT ON COLUMN pesanan_pengiriman.rencana_muat IS 'Rencana muat Deliveree terakhir: kendaraan terpilih beserta item yang dibawa tiap kendaraan';
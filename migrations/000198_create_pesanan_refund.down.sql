@@ -0,0 +1,6 @@
+-- migrations/000198_create_pesanan_refund.down.sql
+DELETE FROM role_permission
+WHERE permission_id IN (SELECT id FROM permission WHERE kode IN ('refund:read', 'refund:approve'));
+DELETE FROM permission WHERE kode IN ('refund:read', 'refund:approve');
+
+DROP TABLE IF EXISTS pesanan_refund;
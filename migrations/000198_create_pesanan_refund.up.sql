@@ -0,0 +1,64 @@
+-- migrations/000198_create_pesanan_refund.up.sql
+-- Refund pembayaran pesanan.
+--
+-- Latar belakang: pembatalan pesanan hanya mengubah status ke CANCELLED dan
+-- mengembalikan produk; status pembayaran REFUNDED tidak pernah diisi sehingga
+-- refund dicatat finance di spreadsheet. Refund kini tercatat per pembayaran
+-- (per pembayar untuk pesanan SPLIT), bisa penuh atau sebagian, dan harus
+-- disetujui pemegang permission refund:approve sebelum diteruskan ke payment
+-- gateway. Pembatalan pesanan yang sudah dibayar otomatis mengajukan refund penuh.
+
+CREATE TABLE IF NOT EXISTS pesanan_refund (
+    id                    UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    pesanan_id            UUID NOT NULL REFERENCES pesanan(id) ON DELETE CASCADE,
+    pesanan_pembayaran_id UUID NOT NULL REFERENCES pesanan_pembayaran(id) ON DELETE CASCADE,
+    buyer_id              UUID NOT NULL REFERENCES buyer(id),
+    tipe                  VARCHAR(20) NOT NULL,
+    jumlah                DECIMAL(15,2) NOT NULL,
+    alasan                TEXT NOT NULL,
+    status                VARCHAR(20) NOT NULL DEFAULT 'REQUESTED',
+    is_manual             BOOLEAN NOT NULL DEFAULT false,
+    gateway               VARCHAR(30),
+    gateway_refund_id     VARCHAR(100),
+    gateway_status        VARCHAR(30),
+    gateway_error         TEXT,
+    percobaan             INT NOT NULL DEFAULT 0,
+    catatan_finance       TEXT,
+    requested_by          UUID REFERENCES admin(id) ON DELETE SET NULL,
+    approved_by           UUID REFERENCES admin(id) ON DELETE SET NULL,
+    approved_at           TIMESTAMPTZ,
+    rejected_by           UUID REFERENCES admin(id) ON DELETE SET NULL,
+    rejected_at           TIMESTAMPTZ,
+    refunded_at           TIMESTAMPTZ,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT pesanan_refund_tipe_check CHECK (tipe IN ('FULL', 'PARTIAL')),
+    CONSTRAINT pesanan_refund_status_check CHECK (status IN ('REQUESTED', 'REJECTED', 'PROCESSING', 'COMPLETED', 'FAILED')),
+    CONSTRAINT pesanan_refund_jumlah_check CHECK (jumlah > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pesanan_refund_pesanan_id ON pesanan_refund(pesanan_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_pesanan_refund_pembayaran_id ON pesanan_refund(pesanan_pembayaran_id);
+CREATE INDEX IF NOT EXISTS idx_pesanan_refund_status ON pesanan_refund(status, created_at);
+-- Dipakai dasbor revenue (refund selesai per tanggal)
+CREATE INDEX IF NOT EXISTS idx_pesanan_refund_refunded_at ON pesanan_refund(refunded_at) WHERE status = 'COMPLETED';
+
+COMMENT ON TABLE pesanan_refund IS 'Refund per pembayaran pesanan; diajukan admin/otomatis saat pembatalan, disetujui finance';
+COMMENT ON COLUMN pesanan_refund.tipe IS 'FULL jika mengembalikan seluruh sisa pembayaran, PARTIAL jika sebagian';
+COMMENT ON COLUMN pesanan_refund.status IS 'REQUESTED -> PROCESSING (menunggu gateway) -> COMPLETED/FAILED; REJECTED jika ditolak finance';
+COMMENT ON COLUMN pesanan_refund.is_manual IS 'Dana dikembalikan finance di luar payment gateway (mis. transfer bank)';
+COMMENT ON COLUMN pesanan_refund.requested_by IS 'Admin pengaju; NULL jika diajukan otomatis saat pesanan dibatalkan';
+COMMENT ON COLUMN pesanan_refund.percobaan IS 'Jumlah pengiriman ke payment gateway; dipakai sebagai bagian idempotency key';
+
+INSERT INTO permission (nama, kode, modul, deskripsi) VALUES
+    ('View Refund', 'refund:read', 'finance', 'Melihat daftar refund pembayaran pesanan'),
+    ('Approve Refund', 'refund:approve', 'finance', 'Menyetujui atau menolak refund pembayaran pesanan')
+ON CONFLICT (kode) DO NOTHING;
+
+INSERT INTO role_permission (role_id, permission_id)
+SELECT r.id, p.id
+FROM role r
+CROSS JOIN permission p
+WHERE r.nama = 'Super Admin'
+AND p.kode IN ('refund:read', 'refund:approve')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
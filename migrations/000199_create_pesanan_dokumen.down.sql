@@ -0,0 +1,3 @@
+-- migrations/000199_create_pesanan_dokumen.down.sql
+DROP TABLE IF EXISTS pesanan_dokumen;
+DROP TABLE IF EXISTS dokumen_nomor_urut;
@@ -0,0 +1,51 @@
+-- migrations/000199_create_pesanan_dokumen.up.sql
+-- Dokumen invoice & nota kredit pesanan (PDF).
+--
+-- Latar belakang: satu-satunya "invoice" di panel adalah invoice ongkir dari
+-- Forwarder. Buyer B2B butuh invoice penjualan sendiri (biaya produk, ongkir,
+-- PPN, potongan kupon, pembayaran split) untuk pembukuan. Nomor dokumen
+-- berurutan tanpa celah per tahun fiskal: nomor diambil dari
+-- dokumen_nomor_urut di transaksi yang sama dengan penyimpanan dokumen,
+-- sehingga transaksi yang gagal tidak menghabiskan nomor. PDF yang sudah
+-- diterbitkan disimpan apa adanya (checksum) dan tidak pernah dibuat ulang.
+
+CREATE TABLE IF NOT EXISTS dokumen_nomor_urut (
+    tipe           VARCHAR(20) NOT NULL,
+    tahun          INT NOT NULL,
+    nomor_terakhir INT NOT NULL DEFAULT 0,
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tipe, tahun)
+);
+
+COMMENT ON TABLE dokumen_nomor_urut IS 'Counter nomor dokumen per tipe & tahun fiskal; baris dikunci selama transaksi penerbitan';
+
+CREATE TABLE IF NOT EXISTS pesanan_dokumen (
+    id             UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    pesanan_id     UUID NOT NULL REFERENCES pesanan(id) ON DELETE CASCADE,
+    tipe           VARCHAR(20) NOT NULL,
+    nomor          VARCHAR(30) NOT NULL,
+    tahun_fiskal   INT NOT NULL,
+    urutan         INT NOT NULL,
+    referensi_id   UUID REFERENCES pesanan_dokumen(id),
+    total          DECIMAL(15,2) NOT NULL,
+    file_path      TEXT NOT NULL,
+    checksum       VARCHAR(64) NOT NULL,
+    ukuran         BIGINT NOT NULL,
+    snapshot       JSONB NOT NULL,
+    diterbitkan_by UUID REFERENCES admin(id) ON DELETE SET NULL,
+    diterbitkan_at TIMESTAMPTZ NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT pesanan_dokumen_tipe_check CHECK (tipe IN ('INVOICE', 'CREDIT_NOTE')),
+    CONSTRAINT pesanan_dokumen_nomor_unique UNIQUE (nomor),
+    CONSTRAINT pesanan_dokumen_urutan_unique UNIQUE (tipe, tahun_fiskal, urutan),
+    -- Satu invoice dan paling banyak satu nota kredit per pesanan
+    CONSTRAINT pesanan_dokumen_pesanan_tipe_unique UNIQUE (pesanan_id, tipe)
+);
+
+COMMENT ON TABLE pesanan_dokumen IS 'Invoice & nota kredit PDF yang sudah diterbitkan; tidak boleh diubah setelah terbit';
+COMMENT ON COLUMN pesanan_dokumen.nomor IS 'INV/<tahun>/<urutan 6 digit> atau CN/<tahun>/<urutan 6 digit>';
+COMMENT ON COLUMN pesanan_dokumen.referensi_id IS 'Invoice yang dikoreksi oleh nota kredit';
+COMMENT ON COLUMN pesanan_dokumen.file_path IS 'Path relatif di DOKUMEN_STORAGE_PATH (tidak di-serve publik)';
+COMMENT ON COLUMN pesanan_dokumen.checksum IS 'SHA-256 file PDF; diverifikasi setiap kali diunduh';
+COMMENT ON COLUMN pesanan_dokumen.snapshot IS 'Data yang dicetak di PDF saat terbit';
+COMMENT ON COLUMN pesanan_dokumen.diterbitkan_by IS 'Admin penerbit; NULL jika diterbitkan buyer/sistem';
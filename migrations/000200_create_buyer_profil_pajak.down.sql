@@ -0,0 +1,22 @@
+-- migrations/000200_create_buyer_profil_pajak.down.sql
+DELETE FROM role_permission
+WHERE permission_id IN (SELECT id FROM permission WHERE kode IN ('efaktur:read', 'efaktur:manage'));
+DELETE FROM permission WHERE kode IN ('efaktur:read', 'efaktur:manage');
+
+DROP TRIGGER IF EXISTS trg_pesanan_snapshot_profil_pajak ON pesanan;
+DROP FUNCTION IF EXISTS snapshot_pesanan_profil_pajak();
+
+DROP INDEX IF EXISTS idx_pesanan_efaktur;
+ALTER TABLE pesanan
+    DROP CONSTRAINT IF EXISTS pesanan_efaktur_nomor_check,
+    DROP CONSTRAINT IF EXISTS pesanan_efaktur_status_check,
+    DROP COLUMN IF EXISTS efaktur_updated_by,
+    DROP COLUMN IF EXISTS efaktur_uploaded_at,
+    DROP COLUMN IF EXISTS efaktur_nomor_at,
+    DROP COLUMN IF EXISTS efaktur_nomor,
+    DROP COLUMN IF EXISTS efaktur_status,
+    DROP COLUMN IF EXISTS alamat_pajak_snapshot,
+    DROP COLUMN IF EXISTS nama_perusahaan_snapshot,
+    DROP COLUMN IF EXISTS npwp_snapshot;
+
+DROP TABLE IF EXISTS buyer_profil_pajak;
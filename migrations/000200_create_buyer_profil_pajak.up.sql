@@ -0,0 +1,85 @@
+-- migrations/000200_create_buyer_profil_pajak.up.sql
+-- Profil pajak buyer & data e-Faktur pesanan.
+--
+-- Latar belakang: buyer B2B (PKP) membutuhkan faktur pajak atas pesanan yang
+-- dikenai PPN, tetapi buyer belum punya NPWP/identitas pajak dan finance
+-- menyusun file impor e-Faktur secara manual. Buyer kini bisa menyimpan satu
+-- profil pajak (NPWP, nama perusahaan, alamat pajak) yang disalin ke pesanan
+-- saat pesanan dibuat, seperti alamat_snapshot, sehingga perubahan profil
+-- tidak mengubah faktur pesanan lama. Penyalinan dilakukan trigger karena
+-- pesanan dibuat oleh storefront BE.
+--
+-- Status e-Faktur per pesanan: BELUM -> NOMOR_TERBIT (nomor faktur dari DJP
+-- sudah dicatat) -> DIUNGGAH (faktur sudah diunggah/approved di e-Faktur).
+
+CREATE TABLE IF NOT EXISTS buyer_profil_pajak (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    buyer_id        UUID NOT NULL UNIQUE REFERENCES buyer(id) ON DELETE CASCADE,
+    npwp            VARCHAR(16) NOT NULL,
+    nama_perusahaan VARCHAR(255) NOT NULL,
+    alamat_pajak    TEXT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT buyer_profil_pajak_npwp_check CHECK (npwp ~ '^([0-9]{15}|[0-9]{16})$')
+);
+
+CREATE TRIGGER trg_buyer_profil_pajak_updated_at
+    BEFORE UPDATE ON buyer_profil_pajak
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE buyer_profil_pajak IS 'Identitas pajak buyer (PKP) untuk faktur pajak; satu profil per buyer';
+COMMENT ON COLUMN buyer_profil_pajak.npwp IS 'NPWP tanpa tanda baca: 15 digit (format lama) atau 16 digit';
+
+ALTER TABLE pesanan
+    ADD COLUMN IF NOT EXISTS npwp_snapshot VARCHAR(16),
+    ADD COLUMN IF NOT EXISTS nama_perusahaan_snapshot VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS alamat_pajak_snapshot TEXT,
+    ADD COLUMN IF NOT EXISTS efaktur_status VARCHAR(20) NOT NULL DEFAULT 'BELUM',
+    ADD COLUMN IF NOT EXISTS efaktur_nomor VARCHAR(30),
+    ADD COLUMN IF NOT EXISTS efaktur_nomor_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS efaktur_uploaded_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS efaktur_updated_by UUID REFERENCES admin(id) ON DELETE SET NULL;
+
+ALTER TABLE pesanan
+    ADD CONSTRAINT pesanan_efaktur_status_check CHECK (efaktur_status IN ('BELUM', 'NOMOR_TERBIT', 'DIUNGGAH')),
+    ADD CONSTRAINT pesanan_efaktur_nomor_check CHECK (efaktur_status = 'BELUM' OR efaktur_nomor IS NOT NULL);
+
+-- Dipakai export e-Faktur (pesanan ber-PPN yang lunas per tanggal bayar)
+CREATE INDEX IF NOT EXISTS idx_pesanan_efaktur ON pesanan(paid_at, efaktur_status)
+    WHERE biaya_ppn > 0 AND deleted_at IS NULL;
+
+COMMENT ON COLUMN pesanan.npwp_snapshot IS 'Salinan buyer_profil_pajak.npwp saat pesanan dibuat; NULL jika buyer belum punya profil pajak';
+COMMENT ON COLUMN pesanan.efaktur_status IS 'BELUM -> NOMOR_TERBIT -> DIUNGGAH';
+COMMENT ON COLUMN pesanan.efaktur_nomor IS 'Nomor seri faktur pajak dari DJP';
+
+CREATE OR REPLACE FUNCTION snapshot_pesanan_profil_pajak()
+RETURNS TRIGGER AS $$
+BEGIN
+    SELECT npwp, nama_perusahaan, alamat_pajak
+    INTO NEW.npwp_snapshot, NEW.nama_perusahaan_snapshot, NEW.alamat_pajak_snapshot
+    FROM buyer_profil_pajak
+    WHERE buyer_id = NEW.buyer_id;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_pesanan_snapshot_profil_pajak
+    BEFORE INSERT ON pesanan
+    FOR EACH ROW
+    WHEN (NEW.npwp_snapshot IS NULL)
+    EXECUTE FUNCTION snapshot_pesanan_profil_pajak();
+
+INSERT INTO permission (nama, kode, modul, deskripsi) VALUES
+    ('View e-Faktur', 'efaktur:read', 'finance', 'Melihat dan mengekspor data e-Faktur pesanan ber-PPN'),
+    ('Manage e-Faktur', 'efaktur:manage', 'finance', 'Mencatat nomor faktur pajak dan status unggah e-Faktur')
+ON CONFLICT (kode) DO NOTHING;
+
+INSERT INTO role_permission (role_id, permission_id)
+SELECT r.id, p.id
+FROM role r
+CROSS JOIN permission p
+WHERE r.nama = 'Super Admin'
+AND p.kode IN ('efaktur:read', 'efaktur:manage')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
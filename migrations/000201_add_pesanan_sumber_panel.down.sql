@@ -0,0 +1,17 @@
+-- migrations/000201_add_pesanan_sumber_panel.down.sql
+DELETE FROM role_permission
+WHERE permission_id IN (SELECT id FROM permission WHERE kode = 'pesanan:create');
+DELETE FROM permission WHERE kode = 'pesanan:create';
+
+ALTER TABLE pesanan_pembayaran
+    DROP COLUMN IF EXISTS dicatat_oleh,
+    DROP COLUMN IF EXISTS referensi_transfer,
+    DROP COLUMN IF EXISTS is_manual;
+
+DROP INDEX IF EXISTS idx_pesanan_formulir_submission_id;
+ALTER TABLE pesanan
+    DROP CONSTRAINT IF EXISTS pesanan_dibuat_oleh_check,
+    DROP CONSTRAINT IF EXISTS pesanan_sumber_check,
+    DROP COLUMN IF EXISTS formulir_submission_id,
+    DROP COLUMN IF EXISTS dibuat_oleh,
+    DROP COLUMN IF EXISTS sumber;
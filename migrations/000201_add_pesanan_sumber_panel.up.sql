@@ -0,0 +1,47 @@
+-- migrations/000201_add_pesanan_sumber_panel.up.sql
+-- Pesanan yang dibuat admin dari panel (penjualan offline / partai besar).
+--
+-- Latar belakang: deal partai besar masuk lewat formulir_partai_besar_submission
+-- dan WhatsApp, harga dinegosiasikan per item, lalu tim sales harus meminta
+-- buyer checkout sendiri di storefront. Admin kini bisa membuat pesanan untuk
+-- buyer yang sudah terdaftar langsung dari panel. Pesanan menyimpan sumbernya,
+-- admin pembuat dan submission formulir asal; pembayaran transfer bank yang
+-- dicatat manual ditandai is_manual beserta referensi transfer dan admin
+-- pencatatnya.
+
+ALTER TABLE pesanan
+    ADD COLUMN IF NOT EXISTS sumber VARCHAR(20) NOT NULL DEFAULT 'STOREFRONT',
+    ADD COLUMN IF NOT EXISTS dibuat_oleh UUID REFERENCES admin(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS formulir_submission_id UUID REFERENCES formulir_partai_besar_submission(id) ON DELETE SET NULL;
+
+ALTER TABLE pesanan
+    ADD CONSTRAINT pesanan_sumber_check CHECK (sumber IN ('STOREFRONT', 'PANEL')),
+    ADD CONSTRAINT pesanan_dibuat_oleh_check CHECK (sumber = 'STOREFRONT' OR dibuat_oleh IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS idx_pesanan_formulir_submission_id ON pesanan(formulir_submission_id)
+    WHERE formulir_submission_id IS NOT NULL;
+
+COMMENT ON COLUMN pesanan.sumber IS 'STOREFRONT (checkout buyer) atau PANEL (dibuat admin)';
+COMMENT ON COLUMN pesanan.dibuat_oleh IS 'Admin pembuat pesanan PANEL';
+COMMENT ON COLUMN pesanan.formulir_submission_id IS 'Submission formulir partai besar asal pesanan PANEL';
+
+ALTER TABLE pesanan_pembayaran
+    ADD COLUMN IF NOT EXISTS is_manual BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS referensi_transfer VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS dicatat_oleh UUID REFERENCES admin(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN pesanan_pembayaran.is_manual IS 'Pembayaran transfer bank yang dicatat admin, bukan lewat Xendit';
+COMMENT ON COLUMN pesanan_pembayaran.referensi_transfer IS 'Nomor referensi / berita transfer bank';
+COMMENT ON COLUMN pesanan_pembayaran.dicatat_oleh IS 'Admin yang mencatat pembayaran manual';
+
+INSERT INTO permission (nama, kode, modul, deskripsi) VALUES
+    ('Create Pesanan', 'pesanan:create', 'pesanan', 'Membuat pesanan untuk buyer dari panel (penjualan offline / partai besar)')
+ON CONFLICT (kode) DO NOTHING;
+
+INSERT INTO role_permission (role_id, permission_id)
+SELECT r.id, p.id
+FROM role r
+CROSS JOIN permission p
+WHERE r.nama = 'Super Admin'
+AND p.kode = 'pesanan:create'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
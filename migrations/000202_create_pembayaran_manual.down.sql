@@ -0,0 +1,11 @@
+-- migrations/000202_create_pembayaran_manual.down.sql
+DROP TABLE IF EXISTS mutasi_bank;
+DROP TABLE IF EXISTS pesanan_bukti_transfer;
+
+UPDATE pesanan_pembayaran
+SET metode_pembayaran_id = NULL
+WHERE metode_pembayaran_id IN (SELECT id FROM metode_pembayaran WHERE kode = 'TRANSFER_MANUAL');
+DELETE FROM metode_pembayaran WHERE kode = 'TRANSFER_MANUAL';
+DELETE FROM metode_pembayaran_group WHERE nama = 'Transfer Manual';
+
+ALTER TABLE metode_pembayaran DROP COLUMN IF EXISTS is_manual;
@@ -0,0 +1,87 @@
+-- migrations/000202_create_pembayaran_manual.up.sql
+-- Pembayaran transfer bank manual: bukti transfer buyer, antrean review
+-- finance dan impor mutasi rekening.
+--
+-- Latar belakang: pesanan_pembayaran hanya memodelkan invoice Xendit, padahal
+-- banyak buyer B2B membayar lewat transfer langsung ke rekening perusahaan dan
+-- finance mengonfirmasinya di luar sistem. Kini buyer memilih metode
+-- TRANSFER_MANUAL, mengunggah bukti transfer, lalu finance menyetujui (dicatat
+-- sebagai pesanan_pembayaran is_manual) atau menolak dengan alasan. Mutasi
+-- rekening (CSV dari internet banking) bisa diimpor; baris yang nominal dan
+-- kode pesanannya cocok disetujui otomatis.
+
+ALTER TABLE metode_pembayaran
+    ADD COLUMN IF NOT EXISTS is_manual BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN metode_pembayaran.is_manual IS 'Metode di luar Xendit; pembayaran dikonfirmasi finance dari bukti transfer / mutasi rekening';
+
+INSERT INTO metode_pembayaran_group (id, nama, urutan, is_active, created_at, updated_at)
+VALUES (uuid_generate_v4(), 'Transfer Manual', 5, true, NOW(), NOW())
+ON CONFLICT (nama) DO NOTHING;
+
+INSERT INTO metode_pembayaran (id, group_id, nama, kode, logo_value, urutan, is_active, is_manual, created_at, updated_at)
+VALUES (
+    uuid_generate_v4(),
+    (SELECT id FROM metode_pembayaran_group WHERE nama = 'Transfer Manual'),
+    'Transfer Bank (Konfirmasi Manual)', 'TRANSFER_MANUAL', 'bank', 1, true, true, NOW(), NOW()
+)
+ON CONFLICT (kode) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS pesanan_bukti_transfer (
+    id               UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    pesanan_id       UUID NOT NULL REFERENCES pesanan(id) ON DELETE CASCADE,
+    buyer_id         UUID NOT NULL REFERENCES buyer(id),
+    jumlah           DECIMAL(15,2) NOT NULL,
+    bank_pengirim    VARCHAR(100) NOT NULL,
+    nama_pengirim    VARCHAR(100) NOT NULL,
+    tanggal_transfer DATE NOT NULL,
+    catatan          TEXT,
+    file_path        VARCHAR(500) NOT NULL,
+    content_type     VARCHAR(50) NOT NULL,
+    status           VARCHAR(20) NOT NULL DEFAULT 'MENUNGGU',
+    alasan_penolakan TEXT,
+    reviewed_by      UUID REFERENCES admin(id) ON DELETE SET NULL,
+    reviewed_at      TIMESTAMPTZ,
+    pembayaran_id    UUID REFERENCES pesanan_pembayaran(id) ON DELETE SET NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT pesanan_bukti_transfer_jumlah_check CHECK (jumlah > 0),
+    CONSTRAINT pesanan_bukti_transfer_status_check CHECK (status IN ('MENUNGGU', 'DISETUJUI', 'DITOLAK')),
+    CONSTRAINT pesanan_bukti_transfer_alasan_check CHECK (status <> 'DITOLAK' OR alasan_penolakan IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pesanan_bukti_transfer_pesanan ON pesanan_bukti_transfer(pesanan_id);
+-- Antrean review finance
+CREATE INDEX IF NOT EXISTS idx_pesanan_bukti_transfer_status ON pesanan_bukti_transfer(status, created_at);
+
+CREATE TRIGGER trg_pesanan_bukti_transfer_updated_at
+    BEFORE UPDATE ON pesanan_bukti_transfer
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE pesanan_bukti_transfer IS 'Bukti transfer bank yang diunggah buyer untuk dikonfirmasi finance';
+COMMENT ON COLUMN pesanan_bukti_transfer.status IS 'MENUNGGU -> DISETUJUI (pembayaran_id terisi) / DITOLAK (alasan_penolakan wajib)';
+COMMENT ON COLUMN pesanan_bukti_transfer.file_path IS 'File bukti relatif terhadap DOKUMEN_STORAGE_PATH (tidak di-serve publik)';
+
+CREATE TABLE IF NOT EXISTS mutasi_bank (
+    id                 UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tanggal            DATE NOT NULL,
+    keterangan         TEXT NOT NULL,
+    jumlah             DECIMAL(15,2) NOT NULL,
+    sidik              VARCHAR(64) NOT NULL UNIQUE,
+    status             VARCHAR(20) NOT NULL,
+    pesanan_id         UUID REFERENCES pesanan(id) ON DELETE SET NULL,
+    bukti_transfer_id  UUID REFERENCES pesanan_bukti_transfer(id) ON DELETE SET NULL,
+    pembayaran_id      UUID REFERENCES pesanan_pembayaran(id) ON DELETE SET NULL,
+    catatan_pencocokan TEXT,
+    nama_file          VARCHAR(255),
+    diimpor_oleh       UUID REFERENCES admin(id) ON DELETE SET NULL,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT mutasi_bank_jumlah_check CHECK (jumlah > 0),
+    CONSTRAINT mutasi_bank_status_check CHECK (status IN ('COCOK', 'BELUM_COCOK'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_mutasi_bank_status ON mutasi_bank(status, tanggal DESC);
+
+COMMENT ON TABLE mutasi_bank IS 'Baris kredit mutasi rekening hasil impor CSV beserta hasil pencocokan ke pesanan';
+COMMENT ON COLUMN mutasi_bank.sidik IS 'SHA-256 tanggal, keterangan, jumlah & urutan kemunculan; impor ulang file yang sama dilewati';
@@ -0,0 +1,2 @@
+ALTER TABLE pesanan_pengiriman
+    DROP COLUMN IF EXISTS cancel_requested_at;
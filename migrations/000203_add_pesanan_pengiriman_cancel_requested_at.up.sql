@@ -0,0 +1,12 @@
+-- Jejak pembatalan booking pengiriman.
+--
+-- Latar belakang: pembatalan memanggil API provider lebih dulu, baru kemudian
+-- mengosongkan booking_ref di database. Jika proses terhenti di antaranya,
+-- booking sudah batal di provider tetapi masih terlihat aktif di sini tanpa
+-- jejak. cancel_requested_at kini diisi sebelum provider dipanggil dan
+-- dikosongkan bersama booking_ref setelah pembatalan tercatat.
+
+ALTER TABLE pesanan_pengiriman
+    ADD COLUMN IF NOT EXISTS cancel_requested_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN pesanan_pengiriman.cancel_requested_at IS 'Waktu pembatalan booking dikirim ke provider; terisi bersama booking_ref berarti pembatalan belum selesai tercatat';
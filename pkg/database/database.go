@@ -106,7 +106,10 @@ func AutoMigrate(env string) {
 		&models.ProdukGambar{},
 		&models.ProdukDokumen{},
 		&models.Admin{},
-		&models.AdminSession{},
+		&models.AuthSession{},
+		&models.RefreshToken{},
+		&models.PasswordResetToken{},
+		&models.OutboxMessage{}, &models.BackgroundJob{}, &models.IdempotencyKey{},
 	)
 	if err != nil {
 		log.Fatal("Failed to auto migrate:", err)
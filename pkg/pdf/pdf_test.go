@@ -0,0 +1,65 @@
+package pdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDocumentBytes(t *testing.T) {
+	doc := New("INV/2026/000001", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	doc.AddPage()
+	doc.SetFont(HelveticaBold, 14)
+	doc.Text(40, 60, "INVOICE (Asli)")
+	doc.AddPage()
+	doc.Line(40, 80, 555, 80, 0.5)
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatal("header/trailer PDF tidak valid")
+	}
+	if !bytes.Contains(out, []byte("/Count 2")) {
+		t.Fatal("dokumen harus 2 halaman")
+	}
+
+	// startxref harus menunjuk tabel xref dan setiap offset ke objeknya
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(out[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d tidak menunjuk xref", xref)
+	}
+	for i, off := range regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(out, -1) {
+		n, _ := strconv.Atoi(string(off[1]))
+		if !bytes.HasPrefix(out[n:], []byte(strconv.Itoa(i+1)+" 0 obj")) {
+			t.Fatalf("offset objek %d salah", i+1)
+		}
+	}
+
+	// Dokumen yang sama menghasilkan byte yang sama
+	again, _ := doc.Bytes()
+	if !bytes.Equal(out, again) {
+		t.Fatal("output PDF harus deterministik")
+	}
+}
+
+func TestStringWidthAndWrap(t *testing.T) {
+	doc := New("", time.Time{})
+	doc.SetFont(Helvetica, 10)
+	if w := doc.StringWidth("100"); w != 16.68 {
+		t.Fatalf("lebar '100' harus 16.68, got %v", w)
+	}
+	lines := doc.WrapText("Palet campuran elektronik rumah tangga grade B", 100)
+	if len(lines) < 2 {
+		t.Fatalf("teks panjang harus dipecah, got %v", lines)
+	}
+	for _, l := range lines {
+		if doc.StringWidth(l) > 100 {
+			t.Fatalf("baris %q melebihi lebar", l)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package transcoder
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -19,12 +20,13 @@ type TranscodeResult struct {
 // Cocok untuk konten vertikal 9:16 Bulky TV.
 // inputPath harus berupa absolute path.
 // Output disimpan di direktori yang sama dengan prefix "stream_".
-func Transcode(inputPath string) (*TranscodeResult, error) {
+// ffmpeg dihentikan jika ctx dibatalkan (mis. batas waktu job habis).
+func Transcode(ctx context.Context, inputPath string) (*TranscodeResult, error) {
 	dir := filepath.Dir(inputPath)
 	base := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
 	output := filepath.Join(dir, "stream_"+base+".mp4")
 
-	cmd := exec.Command("ffmpeg",
+	cmd := exec.CommandContext(ctx, "ffmpeg",
 		"-i", inputPath,
 		"-threads", "2",
 		"-c:v", "libx264",
@@ -43,7 +45,7 @@ func Transcode(inputPath string) (*TranscodeResult, error) {
 		return nil, fmt.Errorf("ffmpeg gagal: %w\noutput: %s", err, string(out))
 	}
 
-	durasi, err := extractDuration(output)
+	durasi, err := extractDuration(ctx, output)
 	if err != nil {
 		durasi = 0 // fallback, tidak fatal
 	}
@@ -52,8 +54,8 @@ func Transcode(inputPath string) (*TranscodeResult, error) {
 }
 
 // extractDuration mengambil durasi video dalam detik via ffprobe.
-func extractDuration(path string) (int, error) {
-	cmd := exec.Command("ffprobe",
+func extractDuration(ctx context.Context, path string) (int, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe",
 		"-v", "error",
 		"-show_entries", "format=duration",
 		"-of", "default=noprint_wrappers=1:nokey=1",
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	base := time.Date(2026, 1, 31, 10, 17, 42, 0, time.UTC) // Sabtu
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 * * * *", time.Date(2026, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 1, 31, 10, 30, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2026, 2, 1, 2, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, 2, 2, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		// Step dari satu angka: 5, 15, 25, ...
+		{"5/10 * * * *", time.Date(2026, 1, 31, 10, 25, 0, 0, time.UTC)},
+		{"0-30/10 * * * *", time.Date(2026, 1, 31, 10, 20, 0, 0, time.UTC)},
+		// */2 pada tanggal tidak membatasi: tanggal ganjil DAN hari Senin
+		{"0 0 */2 * 1", time.Date(2026, 2, 9, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		schedule, err := ParseCron(tc.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tc.expr, err)
+		}
+		if got := schedule.Next(base); !got.Equal(tc.want) {
+			t.Errorf("Next(%q) = %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+
+	for _, invalid := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "a * * * *", "60/5 * * * *"} {
+		if _, err := ParseCron(invalid); err == nil {
+			t.Errorf("ParseCron(%q) harus error", invalid)
+		}
+	}
+}
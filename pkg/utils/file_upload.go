@@ -171,6 +171,25 @@ func SaveUploadedFile(file *multipart.FileHeader, directory string, cfg *config.
 	return relativePath, nil
 }
 
+// SaveFileBytes saves raw file content (e.g. downloaded from a provider) to the
+// specified directory with a generated filename and the given extension.
+// Returns the relative path for URL generation (e.g., "bukti-pengiriman/uuid.png")
+func SaveFileBytes(data []byte, directory, ext string, cfg *config.Config) (string, error) {
+	uploadPath := filepath.Join(cfg.UploadPath, directory)
+	if err := os.MkdirAll(uploadPath, 0755); err != nil {
+		return "", fmt.Errorf("gagal membuat direktori upload: %w", err)
+	}
+
+	filename := fmt.Sprintf("%s%s", uuid.New().String(), ext)
+	if err := os.WriteFile(filepath.Join(uploadPath, filename), data, 0644); err != nil {
+		return "", fmt.Errorf("gagal menyimpan file: %w", err)
+	}
+
+	relativePath := filepath.Join(directory, filename)
+	relativePath = strings.ReplaceAll(relativePath, "\\", "/")
+	return relativePath, nil
+}
+
 // DeleteFile deletes a file from the filesystem
 func DeleteFile(filePath string, cfg *config.Config) error {
 	if filePath == "" {
@@ -18,22 +18,20 @@ var (
 var jwtSecret []byte
 var accessTokenDuration time.Duration
 
-// JWTClaims adalah custom claims untuk JWT
+// JWTClaims adalah custom claims untuk JWT. Token hanya membawa identitas;
+// role & permission admin di-resolve live dari database oleh middleware.
 type JWTClaims struct {
-	UserID      string   `json:"sub"`
-	UserType    string   `json:"type"`
-	Email       string   `json:"email"`
-	RoleID      string   `json:"role_id,omitempty"`     // Hanya untuk Admin
-	RoleKode    string   `json:"role_kode,omitempty"`   // Hanya untuk Admin
-	Permissions []string `json:"permissions,omitempty"` // Hanya untuk Admin
+	UserID    string `json:"sub"`
+	UserType  string `json:"type"`
+	Email     string `json:"email"`
+	SessionID string `json:"sid,omitempty"` // auth_session.id, kosong untuk token lama / dari storefront
 
 	// Legacy support (akan dihapus)
 	AdminID string `json:"admin_id,omitempty"`
-	Role    string `json:"role,omitempty"` // Deprecated, use RoleKode
 	jwt.RegisteredClaims
 }
 
-// SetJWTConfig sets JWT configuration (24 hours for single token)
+// SetJWTConfig sets JWT configuration (access token duration)
 func SetJWTConfig(secret string, accessDuration time.Duration) {
 	jwtSecret = []byte(secret)
 	accessTokenDuration = accessDuration
@@ -47,18 +45,17 @@ func SetJWTSecret(secret string) {
 	}
 }
 
-// GenerateAccessToken generates a new access token with user type and permissions
-func GenerateAccessToken(userID uuid.UUID, userType, email, roleID, roleKode string, permissions []string) (string, error) {
+// GenerateAccessToken generates a new access token with user identity.
+// sessionID mengikat token ke auth_session agar bisa di-revoke server-side.
+func GenerateAccessToken(userID, sessionID uuid.UUID, userType, email string) (string, error) {
 	now := time.Now()
 	claims := JWTClaims{
-		UserID:      userID.String(),
-		UserType:    userType,
-		Email:       email,
-		RoleID:      roleID,
-		RoleKode:    roleKode,
-		Permissions: permissions,
-		Role:        roleKode, // Legacy
+		UserID:    userID.String(),
+		UserType:  userType,
+		Email:     email,
+		SessionID: sessionID.String(),
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenDuration)),
 			Subject:   userID.String(),
@@ -98,3 +95,47 @@ func ValidateJWT(tokenString string) (*JWTClaims, error) {
 func GetAccessTokenDuration() int {
 	return int(accessTokenDuration.Seconds())
 }
+
+// GetAccessTokenTTL returns the access token duration
+func GetAccessTokenTTL() time.Duration {
+	return accessTokenDuration
+}
+
+// twoFactorChallengeType dipakai sebagai claim type token challenge 2FA, sehingga
+// token ini tidak pernah lolos AdminOnly / validasi session sebagai access token.
+const twoFactorChallengeType = "ADMIN_2FA_CHALLENGE"
+
+// GenerateTwoFactorChallengeToken membuat token sementara setelah password admin
+// benar, untuk ditukar bersama kode TOTP di langkah kedua login.
+func GenerateTwoFactorChallengeToken(adminID uuid.UUID, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := JWTClaims{
+		UserID:   adminID.String(),
+		UserType: twoFactorChallengeType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			Subject:   adminID.String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// ValidateTwoFactorChallengeToken memvalidasi token challenge 2FA dan mengembalikan ID admin
+func ValidateTwoFactorChallengeToken(tokenString string) (uuid.UUID, error) {
+	claims, err := ValidateJWT(tokenString)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if claims.UserType != twoFactorChallengeType {
+		return uuid.Nil, ErrInvalidToken
+	}
+	adminID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return uuid.Nil, ErrInvalidToken
+	}
+	return adminID, nil
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// Test vector RFC 6238 (SHA-1, secret "12345678901234567890"), 6 digit terakhir
+func TestTOTPCodeRFC6238(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		got, err := TOTPCode(secret, TOTPStep(time.Unix(unix, 0)))
+		if err != nil || got != want {
+			t.Errorf("T=%d: expected %s, got %s (%v)", unix, want, got, err)
+		}
+	}
+
+	// Kode step sebelumnya masih diterima dengan skew 1, step yang cocok dikembalikan
+	now := time.Unix(1111111109, 0)
+	prev, _ := TOTPCode(secret, TOTPStep(now)-1)
+	step, ok := ValidateTOTP(secret, prev, now, 1)
+	if !ok || step != TOTPStep(now)-1 {
+		t.Fatalf("kode step sebelumnya harus valid dengan skew 1, got step=%d ok=%v", step, ok)
+	}
+	if _, ok := ValidateTOTP(secret, prev, now, 0); ok {
+		t.Fatal("kode step sebelumnya harus ditolak tanpa skew")
+	}
+}
+
+func TestEncryptStringRoundTrip(t *testing.T) {
+	encrypted, err := EncryptString("kunci-rahasia", "JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("EncryptString: %v", err)
+	}
+	plain, err := DecryptString("kunci-rahasia", encrypted)
+	if err != nil || plain != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("round trip gagal: %q (%v)", plain, err)
+	}
+	if _, err := DecryptString("kunci-lain", encrypted); err == nil {
+		t.Fatal("dekripsi dengan key berbeda harus gagal")
+	}
+}
@@ -0,0 +1,16 @@
+package utils
+
+import "math"
+
+// CalculateBeratVolumetrik menghitung berat volumetrik berdasarkan dimensi produk
+// Formula: (Panjang × Lebar × Tinggi) / 6000
+// Hasil dalam kg, dibulatkan 2 desimal
+func CalculateBeratVolumetrik(panjang, lebar, tinggi float64) float64 {
+	if panjang == 0 || lebar == 0 || tinggi == 0 {
+		return 0
+	}
+	// Formula standar: (P × L × T) / 6000
+	// Divisor 6000 untuk konversi cm³ ke kg
+	volumetrik := (panjang * lebar * tinggi) / 6000
+	return math.Round(volumetrik*100) / 100
+}